@@ -10,9 +10,11 @@ import (
 	"time"
 
 	"github.com/lucas-moura1/mercadobitcoin-challenge/config"
+	"github.com/lucas-moura1/mercadobitcoin-challenge/entity"
 	"github.com/lucas-moura1/mercadobitcoin-challenge/handler"
 	"github.com/lucas-moura1/mercadobitcoin-challenge/repository"
 	"github.com/lucas-moura1/mercadobitcoin-challenge/usecase"
+	"go.uber.org/zap"
 )
 
 func main() {
@@ -26,23 +28,146 @@ func main() {
 		panic(err)
 	}
 
+	// "seed" loads the demo data and exits instead of serving, so CI can reset
+	// a database to a known state with the same binary.
+	if len(os.Args) > 1 && os.Args[1] == "seed" {
+		if err := config.Seed(db); err != nil {
+			log.Fatalw("failed to seed database", "error", err)
+		}
+		log.Info("Seed completed successfully!")
+		return
+	}
+
+	instruments, err := config.SetupInstruments()
+	if err != nil {
+		panic(err)
+	}
+
+	divisionPrecision, err := config.SetupDivisionPrecision()
+	if err != nil {
+		panic(err)
+	}
+	entity.SetDivisionPrecision(divisionPrecision)
+
+	maxFills, isolation, err := config.SetupMatching()
+	if err != nil {
+		panic(err)
+	}
+
+	maxBookLevels, err := config.SetupOrderBook()
+	if err != nil {
+		panic(err)
+	}
+
+	rejectSelfCross, err := config.SetupSelfCross()
+	if err != nil {
+		panic(err)
+	}
+
+	notionalLimits, err := config.SetupNotionalLimits()
+	if err != nil {
+		panic(err)
+	}
+
+	readTimeout, writeTimeout, err := config.SetupRequestTimeouts()
+	if err != nil {
+		panic(err)
+	}
+
+	apiPrefix, err := config.SetupAPIPrefix()
+	if err != nil {
+		panic(err)
+	}
+
+	maintenanceMode, err := config.SetupMaintenance()
+	if err != nil {
+		panic(err)
+	}
+
+	snapshotInterval, err := config.SetupSnapshots()
+	if err != nil {
+		panic(err)
+	}
+
+	spreadSnapshotInterval, err := config.SetupSpreadSnapshots()
+	if err != nil {
+		panic(err)
+	}
+
+	expirySweepInterval, expiryGrace, err := config.SetupExpiry()
+	if err != nil {
+		panic(err)
+	}
+	maxOrderAge, err := config.SetupMaxOrderAge()
+	if err != nil {
+		panic(err)
+	}
+	expiryPolicy := usecase.ExpiryPolicy{Grace: expiryGrace, MaxAge: maxOrderAge}
+
+	strictJSON, err := config.SetupStrictJSON()
+	if err != nil {
+		panic(err)
+	}
+	handler.SetStrictJSON(strictJSON)
+
+	importBatchSize, maxImportOrders, err := config.SetupImport()
+	if err != nil {
+		panic(err)
+	}
+	importLimits := handler.ImportLimits{MaxOrders: maxImportOrders, BatchSize: importBatchSize}
+
+	accountRepository := repository.NewAccountRepository(log, db)
 	orderRepository := repository.NewOrderRepository(log, db)
-	walletRepository := repository.NewWalletRepository(log, db)
-	tradeRepository := repository.NewTradeRepository(log)
+	walletRepository := repository.NewWalletRepository(log, db, instruments)
+	tradeRepository := repository.NewTradeRepository(log, db)
+	eventRepository := repository.NewEventRepository(log, db)
+	orderRejectionRepository := repository.NewOrderRejectionRepository(log, db)
+	apiKeyRepository := repository.NewApiKeyRepository(log, db)
 
-	orderUsecase := usecase.NewOrderUseCase(log, orderRepository, walletRepository, tradeRepository, db)
-	accountUsecase := usecase.NewAccountUseCase(log, walletRepository)
+	marketHaltUsecase := usecase.NewMarketHaltUseCase(log)
+	orderUsecase := usecase.NewOrderUseCase(log, orderRepository, walletRepository, tradeRepository, eventRepository, orderRejectionRepository, db, usecase.OrderUseCaseConfig{
+		MaxFills:        maxFills,
+		Instruments:     instruments,
+		Isolation:       isolation,
+		MaxBookLevels:   maxBookLevels,
+		RejectSelfCross: rejectSelfCross,
+		Halts:           marketHaltUsecase,
+		NotionalLimits:  notionalLimits,
+		Clock:           usecase.SystemClock,
+		Expiry:          expiryPolicy,
+	})
+	accountUsecase := usecase.NewAccountUseCase(log, accountRepository, walletRepository, orderRepository, tradeRepository, eventRepository, db)
+	tradeUsecase := usecase.NewTradeUseCase(log, tradeRepository, usecase.SystemClock)
+	eventUsecase := usecase.NewEventUseCase(log, eventRepository)
+	apiKeyUsecase := usecase.NewApiKeyUseCase(log, apiKeyRepository, usecase.SystemClock)
 
-	orderHandler := handler.NewOrderHandler(log, orderUsecase)
-	accountHandler := handler.NewAccountHandler(log, accountUsecase)
+	orderHandler := handler.NewOrderHandler(log, orderUsecase, instruments, importLimits)
+	accountHandler := handler.NewAccountHandler(log, accountUsecase, instruments)
+	tradeHandler := handler.NewTradeHandler(log, tradeUsecase, instruments)
+	eventHandler := handler.NewEventHandler(log, eventUsecase)
+	marketHandler := handler.NewMarketHandler(log, marketHaltUsecase)
 
-	http.HandleFunc("POST /orders", orderHandler.CreateOrder)
-	http.HandleFunc("POST /orders/{id}/cancel", orderHandler.CancelOrder)
-	http.HandleFunc("GET /orders/{instrument_pair}", orderHandler.GetOrderBook)
+	router := handler.NewRouter(handler.RouterConfig{
+		Prefix:        apiPrefix,
+		ReadTimeout:   readTimeout,
+		WriteTimeout:  writeTimeout,
+		AdminToken:    os.Getenv("ADMIN_TOKEN"),
+		ApiKeyUseCase: apiKeyUsecase,
+		Maintenance:   handler.NewMaintenance(maintenanceMode),
+		Orders:        orderHandler,
+		Accounts:      accountHandler,
+		Trades:        tradeHandler,
+		Events:        eventHandler,
+		Markets:       marketHandler,
+	})
 
-	http.HandleFunc("GET /accounts/{id}/balance", accountHandler.GetAccountBalance)
+	jobsCtx, stopJobs := context.WithCancel(context.Background())
+	defer stopJobs()
+	go runBalanceSnapshots(jobsCtx, accountUsecase, snapshotInterval)
+	go runSpreadSnapshots(jobsCtx, orderUsecase, spreadSnapshotInterval)
+	go runOrderExpiry(jobsCtx, log, orderUsecase, expirySweepInterval)
 
-	server := &http.Server{Addr: fmt.Sprintf(":%s", os.Getenv("PORT"))}
+	server := &http.Server{Addr: fmt.Sprintf(":%s", os.Getenv("PORT")), Handler: router}
 
 	go func() {
 		log.Info("Server started at :8080")
@@ -55,6 +180,8 @@ func main() {
 	signal.Notify(stop, syscall.SIGTERM, syscall.SIGINT, os.Interrupt)
 	<-stop
 
+	stopJobs()
+
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
@@ -63,3 +190,56 @@ func main() {
 	}
 	log.Info("Server gracefully stopped!")
 }
+
+// runBalanceSnapshots snapshots every wallet balance once per interval until
+// ctx is cancelled. Failures are logged by the use case and retried on the
+// next tick.
+func runBalanceSnapshots(ctx context.Context, accountUsecase usecase.AccountUseCase, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case t := <-ticker.C:
+			accountUsecase.SnapshotBalances(t)
+		}
+	}
+}
+
+// runSpreadSnapshots records every pair's best bid and ask once per interval
+// until ctx is cancelled. Failures are logged by the use case and retried on
+// the next tick.
+func runSpreadSnapshots(ctx context.Context, orderUsecase usecase.OrderUseCase, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case t := <-ticker.C:
+			orderUsecase.SnapshotSpreads(t)
+		}
+	}
+}
+
+// runOrderExpiry cancels good-till-date orders past their expiry once per
+// interval until ctx is cancelled. A failed sweep is retried on the next
+// tick.
+func runOrderExpiry(ctx context.Context, log *zap.SugaredLogger, orderUsecase usecase.OrderUseCase, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if _, err := orderUsecase.ExpireOrders(); err != nil {
+				log.Errorw("failed to expire orders", "error", err)
+			}
+		}
+	}
+}
@@ -0,0 +1,317 @@
+// Command simulate replays a scenario of orders through the matching engine
+// against an in-memory SQLite database and prints the resulting trades, order
+// book and balances. It uses the same repositories, use cases and trade
+// executor as the server, so a scenario reproduces matching behaviour
+// deterministically.
+//
+//	go run ./cmd/simulate -scenario cmd/simulate/testdata/crossing.json
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"math/rand"
+	"os"
+	"sort"
+
+	"github.com/google/uuid"
+	"github.com/lucas-moura1/mercadobitcoin-challenge/config"
+	"github.com/lucas-moura1/mercadobitcoin-challenge/entity"
+	"github.com/lucas-moura1/mercadobitcoin-challenge/repository"
+	"github.com/lucas-moura1/mercadobitcoin-challenge/usecase"
+	"github.com/shopspring/decimal"
+	"go.uber.org/zap"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// Scenario is the JSON input of a simulation. Accounts are referenced by name
+// from orders; orders are submitted in file order unless Shuffle is set.
+type Scenario struct {
+	Accounts []ScenarioAccount `json:"accounts"`
+	Orders   []ScenarioOrder   `json:"orders"`
+	// Shuffle submits the orders in a random order drawn from the seed.
+	Shuffle bool `json:"shuffle"`
+}
+
+type ScenarioAccount struct {
+	Name    string            `json:"name"`
+	Wallets map[string]string `json:"wallets"`
+}
+
+type ScenarioOrder struct {
+	Account         string `json:"account"`
+	InstrumentPair  string `json:"instrument_pair"`
+	OrderType       string `json:"order_type"`
+	Price           string `json:"price"`
+	Quantity        string `json:"quantity"`
+	MinFillQuantity string `json:"min_fill_quantity,omitempty"`
+}
+
+func main() {
+	scenarioPath := flag.String("scenario", "", "path to the scenario JSON file")
+	seed := flag.Int64("seed", 1, "seed for randomized aspects of the scenario")
+	flag.Parse()
+
+	if *scenarioPath == "" {
+		fmt.Fprintln(os.Stderr, "usage: simulate -scenario <file> [-seed <n>]")
+		os.Exit(2)
+	}
+
+	file, err := os.Open(*scenarioPath)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	defer file.Close()
+
+	if err := run(file, os.Stdout, *seed); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+type simulation struct {
+	out          io.Writer
+	instruments  *entity.InstrumentConfig
+	accountRepo  repository.AccountRepository
+	walletRepo   repository.WalletRepository
+	tradeRepo    repository.TradeRepository
+	orderUseCase usecase.OrderUseCase
+	accountUC    usecase.AccountUseCase
+	accountIDs   map[string]uuid.UUID
+	orderLabels  map[uuid.UUID]string
+}
+
+func run(input io.Reader, out io.Writer, seed int64) error {
+	var scenario Scenario
+	if err := json.NewDecoder(input).Decode(&scenario); err != nil {
+		return fmt.Errorf("invalid scenario: %w", err)
+	}
+
+	instruments, err := config.SetupInstruments()
+	if err != nil {
+		return err
+	}
+
+	db, err := openDatabase()
+	if err != nil {
+		return err
+	}
+
+	log := zap.NewNop().Sugar()
+	orderRepo := repository.NewOrderRepository(log, db)
+	walletRepo := repository.NewWalletRepository(log, db, instruments)
+	tradeRepo := repository.NewTradeRepository(log, db)
+	eventRepo := repository.NewEventRepository(log, db)
+	accountRepo := repository.NewAccountRepository(log, db)
+
+	sim := &simulation{
+		out:          out,
+		instruments:  instruments,
+		accountRepo:  accountRepo,
+		walletRepo:   walletRepo,
+		tradeRepo:    tradeRepo,
+		orderUseCase: usecase.NewOrderUseCase(log, orderRepo, walletRepo, tradeRepo, eventRepo, nil, db, usecase.OrderUseCaseConfig{Instruments: instruments, Clock: usecase.SystemClock}),
+		accountUC:    usecase.NewAccountUseCase(log, accountRepo, walletRepo, orderRepo, tradeRepo, eventRepo, db),
+		accountIDs:   make(map[string]uuid.UUID),
+		orderLabels:  make(map[uuid.UUID]string),
+	}
+
+	if err := sim.createAccounts(scenario.Accounts); err != nil {
+		return err
+	}
+
+	orders := scenario.Orders
+	labels := make([]string, len(orders))
+	for i := range orders {
+		labels[i] = fmt.Sprintf("#%d", i+1)
+	}
+	if scenario.Shuffle {
+		rnd := rand.New(rand.NewSource(seed))
+		rnd.Shuffle(len(orders), func(i, j int) {
+			orders[i], orders[j] = orders[j], orders[i]
+			labels[i], labels[j] = labels[j], labels[i]
+		})
+	}
+
+	fmt.Fprintln(out, "orders:")
+	pairs := make(map[string]bool)
+	for i, o := range orders {
+		if err := sim.submit(labels[i], o); err != nil {
+			return err
+		}
+		pairs[o.InstrumentPair] = true
+	}
+
+	sortedPairs := make([]string, 0, len(pairs))
+	for pair := range pairs {
+		sortedPairs = append(sortedPairs, pair)
+	}
+	sort.Strings(sortedPairs)
+
+	for _, pair := range sortedPairs {
+		if err := sim.printTrades(pair); err != nil {
+			return err
+		}
+		if err := sim.printOrderBook(pair); err != nil {
+			return err
+		}
+	}
+
+	return sim.printBalances(scenario.Accounts)
+}
+
+func openDatabase() (*gorm.DB, error) {
+	db, err := gorm.Open(sqlite.Open(fmt.Sprintf("file:%s?mode=memory&cache=shared", uuid.NewString())), &gorm.Config{
+		Logger:  logger.Discard,
+		NowFunc: entity.NowUTC,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := db.AutoMigrate(&entity.Account{}, &entity.Wallet{}, &entity.Order{}, &entity.Trade{}, &entity.Event{}); err != nil {
+		return nil, err
+	}
+	if err := db.Exec("CREATE UNIQUE INDEX idx_wallet_account_asset ON wallet(account_id, asset_symbol)").Error; err != nil {
+		return nil, err
+	}
+	return db, nil
+}
+
+func (s *simulation) createAccounts(accounts []ScenarioAccount) error {
+	for _, a := range accounts {
+		if _, ok := s.accountIDs[a.Name]; ok {
+			return fmt.Errorf("duplicate account %q", a.Name)
+		}
+
+		account := &entity.Account{Name: a.Name}
+		if err := s.accountRepo.Create(account); err != nil {
+			return err
+		}
+		s.accountIDs[a.Name] = account.ID
+
+		for symbol, raw := range a.Wallets {
+			balance, err := decimal.NewFromString(raw)
+			if err != nil {
+				return fmt.Errorf("invalid %s balance for account %q: %w", symbol, a.Name, err)
+			}
+			wallet := &entity.Wallet{AccountID: account.ID, AssetSymbol: symbol, Balance: balance}
+			if err := s.walletRepo.Create(nil, wallet); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (s *simulation) submit(label string, o ScenarioOrder) error {
+	accountID, ok := s.accountIDs[o.Account]
+	if !ok {
+		return fmt.Errorf("order %s: unknown account %q", label, o.Account)
+	}
+
+	price, err := decimal.NewFromString(o.Price)
+	if err != nil {
+		return fmt.Errorf("order %s: invalid price: %w", label, err)
+	}
+	quantity, err := decimal.NewFromString(o.Quantity)
+	if err != nil {
+		return fmt.Errorf("order %s: invalid quantity: %w", label, err)
+	}
+	minFill := decimal.Zero
+	if o.MinFillQuantity != "" {
+		if minFill, err = decimal.NewFromString(o.MinFillQuantity); err != nil {
+			return fmt.Errorf("order %s: invalid min fill quantity: %w", label, err)
+		}
+	}
+
+	order := &entity.Order{
+		AccountID:       accountID,
+		InstrumentPair:  o.InstrumentPair,
+		OrderType:       o.OrderType,
+		Price:           price,
+		Quantity:        quantity,
+		MinFillQuantity: minFill,
+	}
+
+	summary := fmt.Sprintf("  %s %s %s %s %s @ %s", label, o.Account, order.OrderType, o.InstrumentPair,
+		s.instruments.FormatQuantity(o.InstrumentPair, quantity), s.instruments.FormatPrice(o.InstrumentPair, price))
+
+	if err := s.orderUseCase.CreateOrder(context.Background(), order); err != nil {
+		fmt.Fprintf(s.out, "%s -> rejected: %v\n", summary, err)
+		return nil
+	}
+
+	s.orderLabels[order.ID] = label
+	fmt.Fprintf(s.out, "%s -> %s (remaining %s)\n", summary, order.Status,
+		s.instruments.FormatQuantity(o.InstrumentPair, order.RemainingQuantity))
+	return nil
+}
+
+func (s *simulation) printTrades(pair string) error {
+	trades, err := s.tradeRepo.GetByInstrumentPair(pair, usecase.MaxTradesLimit)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(s.out, "\ntrades %s:\n", pair)
+	if len(trades) == 0 {
+		fmt.Fprintln(s.out, "  (none)")
+	}
+	// The repository returns newest first; print in execution order.
+	for i := len(trades) - 1; i >= 0; i-- {
+		trade := trades[i]
+		fmt.Fprintf(s.out, "  buyer %s seller %s: %s @ %s\n",
+			s.orderLabels[trade.BuyerOrderID], s.orderLabels[trade.SellerOrderID],
+			s.instruments.FormatQuantity(pair, trade.Quantity), s.instruments.FormatPrice(pair, trade.Price))
+	}
+	return nil
+}
+
+func (s *simulation) printOrderBook(pair string) error {
+	fmt.Fprintf(s.out, "\norder book %s:\n", pair)
+
+	book, err := s.orderUseCase.GetOrderBook(pair, decimal.Zero)
+	if err != nil {
+		return err
+	}
+	if len(book.Asks) == 0 && len(book.Bids) == 0 {
+		fmt.Fprintln(s.out, "  (empty)")
+		return nil
+	}
+
+	for _, ask := range book.Asks {
+		fmt.Fprintf(s.out, "  ask %s @ %s\n",
+			s.instruments.FormatQuantity(pair, ask.Quantity), s.instruments.FormatPrice(pair, ask.Price))
+	}
+	for _, bid := range book.Bids {
+		fmt.Fprintf(s.out, "  bid %s @ %s\n",
+			s.instruments.FormatQuantity(pair, bid.Quantity), s.instruments.FormatPrice(pair, bid.Price))
+	}
+	return nil
+}
+
+func (s *simulation) printBalances(accounts []ScenarioAccount) error {
+	fmt.Fprintln(s.out, "\nbalances:")
+	for _, a := range accounts {
+		wallets, err := s.accountUC.GetAccountBalance(s.accountIDs[a.Name])
+		if errors.Is(err, repository.ErrNotFound) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+
+		sort.Slice(wallets, func(i, j int) bool { return wallets[i].AssetSymbol < wallets[j].AssetSymbol })
+		for _, w := range wallets {
+			fmt.Fprintf(s.out, "  %s %s %s\n", a.Name, w.AssetSymbol, s.instruments.FormatAmount(w.AssetSymbol, w.Balance))
+		}
+	}
+	return nil
+}
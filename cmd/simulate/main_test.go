@@ -0,0 +1,64 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRun_Crossing(t *testing.T) {
+	t.Setenv("ASSET_SCALES", "")
+	t.Setenv("BALANCE_EPSILONS", "")
+
+	scenario, err := os.Open("testdata/crossing.json")
+	if err != nil {
+		t.Fatalf("failed to open scenario: %v", err)
+	}
+	defer scenario.Close()
+
+	want, err := os.ReadFile("testdata/crossing.out")
+	if err != nil {
+		t.Fatalf("failed to read expected output: %v", err)
+	}
+
+	var out bytes.Buffer
+	assert.NoError(t, run(scenario, &out, 1))
+	assert.Equal(t, string(want), out.String())
+}
+
+func TestRun_ShuffleIsSeeded(t *testing.T) {
+	t.Setenv("ASSET_SCALES", "")
+	t.Setenv("BALANCE_EPSILONS", "")
+
+	scenario := `{
+		"accounts": [
+			{ "name": "alice", "wallets": { "BTC": "1", "BRL": "0" } },
+			{ "name": "bob", "wallets": { "BTC": "0", "BRL": "1000000" } }
+		],
+		"orders": [
+			{ "account": "alice", "instrument_pair": "BTC_BRL", "order_type": "SELL", "price": "100", "quantity": "0.1" },
+			{ "account": "alice", "instrument_pair": "BTC_BRL", "order_type": "SELL", "price": "101", "quantity": "0.1" },
+			{ "account": "bob", "instrument_pair": "BTC_BRL", "order_type": "BUY", "price": "101", "quantity": "0.1" },
+			{ "account": "bob", "instrument_pair": "BTC_BRL", "order_type": "BUY", "price": "102", "quantity": "0.1" }
+		],
+		"shuffle": true
+	}`
+
+	simulate := func(seed int64) string {
+		var out bytes.Buffer
+		assert.NoError(t, run(strings.NewReader(scenario), &out, seed))
+		return out.String()
+	}
+
+	assert.Equal(t, simulate(7), simulate(7))
+}
+
+func TestRun_UnknownAccount(t *testing.T) {
+	scenario := `{"orders": [{ "account": "ghost", "instrument_pair": "BTC_BRL", "order_type": "BUY", "price": "1", "quantity": "1" }]}`
+
+	var out bytes.Buffer
+	assert.Error(t, run(strings.NewReader(scenario), &out, 1))
+}
@@ -4,12 +4,13 @@ import (
     "fmt"
     "os"
 
+    "github.com/lucas-moura1/mercadobitcoin-challenge/entity"
     "gorm.io/driver/postgres"
     "gorm.io/gorm"
 )
 
 func SetupDatabase() (*gorm.DB, error) {
-    dsn := fmt.Sprintf("host=%s user=%s password=%s dbname=%s port=%s sslmode=disable",
+    dsn := fmt.Sprintf("host=%s user=%s password=%s dbname=%s port=%s sslmode=disable TimeZone=UTC",
         os.Getenv("DB_HOST"),
         os.Getenv("DB_USER"),
         os.Getenv("DB_PASSWORD"),
@@ -17,7 +18,7 @@ func SetupDatabase() (*gorm.DB, error) {
         os.Getenv("DB_PORT"),
     )
 
-    db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{})
+    db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{NowFunc: entity.NowUTC})
     if err != nil {
         return nil, fmt.Errorf("failed to connect to database: %v", err)
     }
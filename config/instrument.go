@@ -0,0 +1,99 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/lucas-moura1/mercadobitcoin-challenge/entity"
+	"github.com/shopspring/decimal"
+)
+
+const defaultAssetScales = "BTC:8,ETH:4,BRL:2"
+
+// SetupInstruments reads ASSET_SCALES as a comma-separated list of
+// SYMBOL:SCALE entries, e.g. "BTC:8,ETH:4,BRL:2", and the optional
+// BALANCE_EPSILONS as SYMBOL:AMOUNT entries, e.g. "BRL:0.05", overriding the
+// default epsilon of one unit at the asset's scale. MAX_DECIMAL_PLACES caps
+// the decimal places accepted in request prices and quantities; unset uses
+// the largest asset scale. Symbols are normalized like those in requests, so
+// "btc:8" configures BTC; one that is not a valid symbol is an error.
+func SetupInstruments() (*entity.InstrumentConfig, error) {
+	raw := os.Getenv("ASSET_SCALES")
+	if raw == "" {
+		raw = defaultAssetScales
+	}
+
+	var assets []entity.Asset
+	for _, entry := range strings.Split(raw, ",") {
+		parts := strings.Split(strings.TrimSpace(entry), ":")
+		if len(parts) != 2 || parts[0] == "" {
+			return nil, fmt.Errorf("invalid asset scale entry %q", entry)
+		}
+
+		symbol, err := entity.NormalizeAssetSymbol(parts[0])
+		if err != nil {
+			return nil, fmt.Errorf("invalid asset symbol %q in ASSET_SCALES", parts[0])
+		}
+
+		scale, err := strconv.ParseInt(parts[1], 10, 32)
+		if err != nil || scale < 0 {
+			return nil, fmt.Errorf("invalid scale for asset %q: %s", parts[0], parts[1])
+		}
+
+		assets = append(assets, entity.Asset{Symbol: symbol, Scale: int32(scale)})
+	}
+
+	if err := applyBalanceEpsilons(assets, os.Getenv("BALANCE_EPSILONS")); err != nil {
+		return nil, err
+	}
+
+	instruments := entity.NewInstrumentConfig(assets...)
+
+	if raw := os.Getenv("MAX_DECIMAL_PLACES"); raw != "" {
+		places, err := strconv.ParseInt(raw, 10, 32)
+		if err != nil || places < 0 {
+			return nil, fmt.Errorf("invalid MAX_DECIMAL_PLACES %q", raw)
+		}
+		instruments.SetMaxDecimalPlaces(int32(places))
+	}
+
+	return instruments, nil
+}
+
+func applyBalanceEpsilons(assets []entity.Asset, raw string) error {
+	if raw == "" {
+		return nil
+	}
+
+	for _, entry := range strings.Split(raw, ",") {
+		parts := strings.Split(strings.TrimSpace(entry), ":")
+		if len(parts) != 2 || parts[0] == "" {
+			return fmt.Errorf("invalid balance epsilon entry %q", entry)
+		}
+
+		symbol, err := entity.NormalizeAssetSymbol(parts[0])
+		if err != nil {
+			return fmt.Errorf("invalid asset symbol %q in BALANCE_EPSILONS", parts[0])
+		}
+
+		epsilon, err := decimal.NewFromString(parts[1])
+		if err != nil || !epsilon.IsPositive() {
+			return fmt.Errorf("invalid balance epsilon for asset %q: %s", parts[0], parts[1])
+		}
+
+		found := false
+		for i := range assets {
+			if assets[i].Symbol == symbol {
+				assets[i].Epsilon = epsilon
+				found = true
+			}
+		}
+		if !found {
+			return fmt.Errorf("balance epsilon for unknown asset %q", parts[0])
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,34 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSetupInstruments_NormalizesSymbols(t *testing.T) {
+	t.Setenv("ASSET_SCALES", " btc:6, Brl:2")
+	t.Setenv("BALANCE_EPSILONS", "brl:0.05")
+
+	instruments, err := SetupInstruments()
+	require.NoError(t, err)
+
+	btc, ok := instruments.Asset("BTC")
+	if assert.True(t, ok) {
+		assert.Equal(t, int32(6), btc.Scale)
+	}
+	brl, ok := instruments.Asset("BRL")
+	if assert.True(t, ok) {
+		assert.Equal(t, "0.05", brl.Epsilon.String())
+	}
+	_, ok = instruments.Asset("btc")
+	assert.False(t, ok)
+}
+
+func TestSetupInstruments_InvalidSymbol(t *testing.T) {
+	t.Setenv("ASSET_SCALES", "BTC:8,B-RL:2")
+
+	_, err := SetupInstruments()
+	assert.Error(t, err)
+}
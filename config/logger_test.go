@@ -0,0 +1,88 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestRedactingCore(t *testing.T) {
+	accountID := uuid.New()
+
+	tests := []struct {
+		name   string
+		keys   string
+		redact bool
+	}{
+		{name: "raw when disabled", keys: "", redact: false},
+		{name: "hashed when enabled", keys: "account_id", redact: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			core, logs := observer.New(zapcore.DebugLevel)
+			logger := zap.New(core)
+			if keys := redactedKeys(tt.keys); len(keys) > 0 {
+				logger = logger.WithOptions(zap.WrapCore(func(core zapcore.Core) zapcore.Core {
+					return newRedactingCore(core, keys)
+				}))
+			}
+			log := logger.Sugar()
+
+			log.Debugw("updating wallet balance", "account_id", accountID, "asset", "BRL")
+			log.With("account_id", accountID).Infow("fetching account balance")
+			log.Infow("fetching account balance", "account_id", accountID)
+
+			entries := logs.All()
+			if !assert.Len(t, entries, 3) {
+				return
+			}
+			values := make([]string, len(entries))
+			for i, entry := range entries {
+				values[i] = fieldString(entry.ContextMap()["account_id"])
+			}
+			assert.Equal(t, "BRL", entries[0].ContextMap()["asset"], "other fields are left alone")
+
+			if !tt.redact {
+				for _, v := range values {
+					assert.Equal(t, accountID.String(), v)
+				}
+				return
+			}
+
+			for _, v := range values {
+				assert.NotContains(t, v, accountID.String())
+				assert.Len(t, v, 16)
+			}
+			// The hash is stable, so entries about one account still correlate.
+			assert.Equal(t, values[0], values[1])
+			assert.Equal(t, values[0], values[2])
+		})
+	}
+}
+
+func fieldString(v any) string {
+	if s, ok := v.(string); ok {
+		return s
+	}
+	if s, ok := v.(interface{ String() string }); ok {
+		return s.String()
+	}
+	return ""
+}
+
+func TestRedactingCore_RespectsLevel(t *testing.T) {
+	core, logs := observer.New(zapcore.InfoLevel)
+	log := zap.New(newRedactingCore(core, redactedKeys("account_id"))).Sugar()
+
+	log.Debugw("updating wallet balance", "account_id", uuid.New())
+	log.Infow("fetching account balance", "account_id", uuid.New())
+
+	if assert.Len(t, logs.All(), 1) {
+		assert.Equal(t, zapcore.InfoLevel, logs.All()[0].Level)
+	}
+}
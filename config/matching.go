@@ -0,0 +1,134 @@
+package config
+
+import (
+	"database/sql"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/lucas-moura1/mercadobitcoin-challenge/entity"
+	"github.com/shopspring/decimal"
+)
+
+var isolationLevels = map[string]sql.IsolationLevel{
+	"":                sql.LevelDefault,
+	"read_committed":  sql.LevelReadCommitted,
+	"repeatable_read": sql.LevelRepeatableRead,
+	"serializable":    sql.LevelSerializable,
+}
+
+// SetupMatching reads MAX_FILLS_PER_ORDER, the maximum number of resting
+// orders a single incoming order may fill in one transaction (zero means the
+// use case default applies), and ORDER_TX_ISOLATION, the isolation level of
+// the create-and-match transaction: read_committed, repeatable_read or
+// serializable. Unset keeps the database default.
+func SetupMatching() (int, sql.IsolationLevel, error) {
+	isolation, ok := isolationLevels[strings.ToLower(os.Getenv("ORDER_TX_ISOLATION"))]
+	if !ok {
+		return 0, 0, fmt.Errorf("invalid ORDER_TX_ISOLATION %q", os.Getenv("ORDER_TX_ISOLATION"))
+	}
+
+	raw := os.Getenv("MAX_FILLS_PER_ORDER")
+	if raw == "" {
+		return 0, isolation, nil
+	}
+
+	maxFills, err := strconv.Atoi(raw)
+	if err != nil || maxFills <= 0 {
+		return 0, 0, fmt.Errorf("invalid MAX_FILLS_PER_ORDER %q", raw)
+	}
+
+	return maxFills, isolation, nil
+}
+
+// SetupSelfCross reads REJECT_SELF_CROSS, which makes order creation fail
+// when the new order would cross a resting order of the same account.
+// Unset means disabled.
+func SetupSelfCross() (bool, error) {
+	raw := os.Getenv("REJECT_SELF_CROSS")
+	if raw == "" {
+		return false, nil
+	}
+
+	reject, err := strconv.ParseBool(raw)
+	if err != nil {
+		return false, fmt.Errorf("invalid REJECT_SELF_CROSS %q", raw)
+	}
+
+	return reject, nil
+}
+
+// SetupDivisionPrecision reads DIVISION_PRECISION, the number of decimal
+// places kept when dividing amounts. Unset means
+// entity.DefaultDivisionPrecision.
+func SetupDivisionPrecision() (int32, error) {
+	raw := os.Getenv("DIVISION_PRECISION")
+	if raw == "" {
+		return entity.DefaultDivisionPrecision, nil
+	}
+
+	places, err := strconv.ParseInt(raw, 10, 32)
+	if err != nil || places <= 0 {
+		return 0, fmt.Errorf("invalid DIVISION_PRECISION %q", raw)
+	}
+
+	return int32(places), nil
+}
+
+// SetupOrderBook reads MAX_BOOK_LEVELS, the number of price levels per side
+// kept in the aggregated order book. Zero or unset means no cap.
+func SetupOrderBook() (int, error) {
+	raw := os.Getenv("MAX_BOOK_LEVELS")
+	if raw == "" {
+		return 0, nil
+	}
+
+	maxLevels, err := strconv.Atoi(raw)
+	if err != nil || maxLevels < 0 {
+		return 0, fmt.Errorf("invalid MAX_BOOK_LEVELS %q", raw)
+	}
+
+	return maxLevels, nil
+}
+
+// SetupNotionalLimits reads MAX_OPEN_NOTIONAL, the default cap on the notional
+// an account may commit to open buy orders in one quote asset, and
+// MAX_OPEN_NOTIONAL_OVERRIDES as ACCOUNT_ID:AMOUNT entries, e.g.
+// "0190...:50000", replacing the default for those accounts. A zero amount
+// means no cap; unset MAX_OPEN_NOTIONAL means none by default.
+func SetupNotionalLimits() (*entity.NotionalLimits, error) {
+	defaultLimit := decimal.Zero
+	if raw := os.Getenv("MAX_OPEN_NOTIONAL"); raw != "" {
+		limit, err := decimal.NewFromString(raw)
+		if err != nil || limit.IsNegative() {
+			return nil, fmt.Errorf("invalid MAX_OPEN_NOTIONAL %q", raw)
+		}
+		defaultLimit = limit
+	}
+
+	accounts := make(map[uuid.UUID]decimal.Decimal)
+	if raw := os.Getenv("MAX_OPEN_NOTIONAL_OVERRIDES"); raw != "" {
+		for _, entry := range strings.Split(raw, ",") {
+			parts := strings.Split(strings.TrimSpace(entry), ":")
+			if len(parts) != 2 {
+				return nil, fmt.Errorf("invalid max open notional override %q", entry)
+			}
+
+			accountID, err := uuid.Parse(parts[0])
+			if err != nil {
+				return nil, fmt.Errorf("invalid account id in max open notional override %q", entry)
+			}
+
+			limit, err := decimal.NewFromString(parts[1])
+			if err != nil || limit.IsNegative() {
+				return nil, fmt.Errorf("invalid amount in max open notional override %q", entry)
+			}
+
+			accounts[accountID] = limit
+		}
+	}
+
+	return entity.NewNotionalLimits(defaultLimit, accounts), nil
+}
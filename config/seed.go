@@ -0,0 +1,73 @@
+package config
+
+import (
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/lucas-moura1/mercadobitcoin-challenge/entity"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	seedJohnID = uuid.MustParse("11111111-1111-1111-1111-111111111111")
+	seedJaneID = uuid.MustParse("22222222-2222-2222-2222-222222222222")
+)
+
+// Seed creates the demo accounts, their wallets and API keys. It is safe to
+// run again: accounts are upserted by ID and API keys by key, so their names
+// and secrets are reset, while wallets that already exist are left alone,
+// balance included. Everything is written in one transaction.
+func Seed(db *gorm.DB) error {
+	accounts := []*entity.Account{
+		{Base: entity.Base{ID: seedJohnID}, Name: "John Doe"},
+		{Base: entity.Base{ID: seedJaneID}, Name: "Jane Doe"},
+	}
+
+	wallets := []*entity.Wallet{
+		{AccountID: seedJohnID, AssetSymbol: "BTC", Balance: decimal.RequireFromString("1.5")},
+		{AccountID: seedJohnID, AssetSymbol: "BRL", Balance: decimal.RequireFromString("200000")},
+		{AccountID: seedJaneID, AssetSymbol: "BTC", Balance: decimal.RequireFromString("0.5")},
+		{AccountID: seedJaneID, AssetSymbol: "BRL", Balance: decimal.RequireFromString("305000")},
+	}
+
+	apiKeys := []*entity.ApiKey{
+		{AccountID: seedJohnID, Key: "john-doe-key", Secret: "john-doe-secret"},
+		{AccountID: seedJaneID, Key: "jane-doe-key", Secret: "jane-doe-secret"},
+	}
+
+	return db.Transaction(func(tx *gorm.DB) error {
+		for _, account := range accounts {
+			err := tx.Clauses(clause.OnConflict{
+				Columns:   []clause.Column{{Name: "id"}},
+				DoUpdates: clause.AssignmentColumns([]string{"name", "updated_at"}),
+			}).Create(account).Error
+			if err != nil {
+				return fmt.Errorf("failed to seed account %s: %w", account.Name, err)
+			}
+		}
+
+		for _, wallet := range wallets {
+			err := tx.Clauses(clause.OnConflict{
+				Columns:   []clause.Column{{Name: "account_id"}, {Name: "asset_symbol"}},
+				DoNothing: true,
+			}).Create(wallet).Error
+			if err != nil {
+				return fmt.Errorf("failed to seed %s wallet for account %s: %w", wallet.AssetSymbol, wallet.AccountID, err)
+			}
+		}
+
+		for _, apiKey := range apiKeys {
+			err := tx.Clauses(clause.OnConflict{
+				Columns:   []clause.Column{{Name: "key"}},
+				DoUpdates: clause.AssignmentColumns([]string{"account_id", "secret", "updated_at"}),
+			}).Create(apiKey).Error
+			if err != nil {
+				return fmt.Errorf("failed to seed api key %s: %w", apiKey.Key, err)
+			}
+		}
+
+		return nil
+	})
+}
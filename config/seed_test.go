@@ -0,0 +1,81 @@
+package config
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/lucas-moura1/mercadobitcoin-challenge/entity"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+// newSeedDB returns a SQLite database with the tables Seed writes and the
+// unique indexes its upserts rely on.
+func newSeedDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "seed.db")), &gorm.Config{NowFunc: entity.NowUTC})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&entity.Account{}, &entity.Wallet{}, &entity.ApiKey{}))
+	require.NoError(t, db.Exec("CREATE UNIQUE INDEX idx_wallet_account_asset ON wallet(account_id, asset_symbol)").Error)
+	require.NoError(t, db.Exec("CREATE UNIQUE INDEX idx_api_key_key ON api_key(key)").Error)
+	return db
+}
+
+func TestSeed_Idempotent(t *testing.T) {
+	db := newSeedDB(t)
+
+	type state struct {
+		Accounts []entity.Account
+		Wallets  []entity.Wallet
+		ApiKeys  []entity.ApiKey
+	}
+	snapshot := func() state {
+		var s state
+		require.NoError(t, db.Order("id").Find(&s.Accounts).Error)
+		require.NoError(t, db.Order("account_id, asset_symbol").Find(&s.Wallets).Error)
+		require.NoError(t, db.Order("key").Find(&s.ApiKeys).Error)
+		return s
+	}
+
+	require.NoError(t, Seed(db))
+	first := snapshot()
+
+	require.NoError(t, Seed(db))
+	second := snapshot()
+
+	assert.Len(t, second.Accounts, 2)
+	assert.Len(t, second.Wallets, 4)
+	assert.Len(t, second.ApiKeys, 2)
+
+	if assert.Len(t, second.Wallets, len(first.Wallets)) {
+		for i, wallet := range second.Wallets {
+			assert.Equal(t, first.Wallets[i].ID, wallet.ID)
+			assert.True(t, entity.DecimalEqual(first.Wallets[i].Balance, wallet.Balance), wallet.Balance.String())
+		}
+	}
+	for i, account := range second.Accounts {
+		assert.Equal(t, first.Accounts[i].Name, account.Name)
+	}
+	for i, apiKey := range second.ApiKeys {
+		assert.Equal(t, first.ApiKeys[i].AccountID, apiKey.AccountID)
+		assert.Equal(t, first.ApiKeys[i].Secret, apiKey.Secret)
+	}
+}
+
+func TestSeed_KeepsExistingWalletBalance(t *testing.T) {
+	db := newSeedDB(t)
+
+	require.NoError(t, Seed(db))
+	require.NoError(t, db.Model(&entity.Wallet{}).
+		Where("account_id = ? AND asset_symbol = ?", seedJohnID, "BTC").
+		Update("balance", decimal.RequireFromString("0.25")).Error)
+
+	require.NoError(t, Seed(db))
+
+	var wallet entity.Wallet
+	require.NoError(t, db.Where("account_id = ? AND asset_symbol = ?", seedJohnID, "BTC").First(&wallet).Error)
+	assert.True(t, entity.DecimalEqual(decimal.RequireFromString("0.25"), wallet.Balance), wallet.Balance.String())
+}
@@ -0,0 +1,171 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const defaultAPIPrefix = "/v1"
+
+// SetupAPIPrefix reads API_PREFIX, the path every route is served under
+// (default "/v1"). Set it to "/" to serve the routes at the root.
+func SetupAPIPrefix() (string, error) {
+	raw := os.Getenv("API_PREFIX")
+	if raw == "" {
+		return defaultAPIPrefix, nil
+	}
+
+	if !strings.HasPrefix(raw, "/") || strings.ContainsAny(raw, " {}") {
+		return "", fmt.Errorf("invalid API_PREFIX %q", raw)
+	}
+
+	return strings.TrimRight(raw, "/"), nil
+}
+
+const (
+	defaultReadRequestTimeout  = 5 * time.Second
+	defaultWriteRequestTimeout = 10 * time.Second
+)
+
+// SetupRequestTimeouts reads READ_REQUEST_TIMEOUT and WRITE_REQUEST_TIMEOUT as
+// Go durations (e.g. "5s"). Reads default to 5s; writes, which run the
+// matching engine, default to 10s.
+func SetupRequestTimeouts() (time.Duration, time.Duration, error) {
+	read, err := durationFromEnv("READ_REQUEST_TIMEOUT", defaultReadRequestTimeout)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	write, err := durationFromEnv("WRITE_REQUEST_TIMEOUT", defaultWriteRequestTimeout)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	return read, write, nil
+}
+
+// SetupMaintenance reads MAINTENANCE_MODE, whether the server starts with
+// order placement paused. Unset means disabled.
+func SetupMaintenance() (bool, error) {
+	raw := os.Getenv("MAINTENANCE_MODE")
+	if raw == "" {
+		return false, nil
+	}
+
+	enabled, err := strconv.ParseBool(raw)
+	if err != nil {
+		return false, fmt.Errorf("invalid MAINTENANCE_MODE %q", raw)
+	}
+
+	return enabled, nil
+}
+
+// SetupStrictJSON reads STRICT_JSON, whether request bodies with fields the
+// API does not accept are rejected instead of ignored. Unset means disabled.
+func SetupStrictJSON() (bool, error) {
+	raw := os.Getenv("STRICT_JSON")
+	if raw == "" {
+		return false, nil
+	}
+
+	strict, err := strconv.ParseBool(raw)
+	if err != nil {
+		return false, fmt.Errorf("invalid STRICT_JSON %q", raw)
+	}
+
+	return strict, nil
+}
+
+// SetupImport reads IMPORT_BATCH_SIZE, how many rows an order import inserts
+// per statement, and IMPORT_MAX_ORDERS, the most rows one import request may
+// carry. Zero or unset leaves each to its default.
+func SetupImport() (batchSize, maxOrders int, err error) {
+	batchSize, err = nonNegativeIntFromEnv("IMPORT_BATCH_SIZE")
+	if err != nil {
+		return 0, 0, err
+	}
+
+	maxOrders, err = nonNegativeIntFromEnv("IMPORT_MAX_ORDERS")
+	if err != nil {
+		return 0, 0, err
+	}
+
+	return batchSize, maxOrders, nil
+}
+
+const defaultSnapshotInterval = 24 * time.Hour
+
+// SetupSnapshots reads SNAPSHOT_INTERVAL, how often wallet balances are
+// snapshotted for point-in-time queries (default 24h).
+func SetupSnapshots() (time.Duration, error) {
+	return durationFromEnv("SNAPSHOT_INTERVAL", defaultSnapshotInterval)
+}
+
+const defaultSpreadSnapshotInterval = time.Minute
+
+// SetupSpreadSnapshots reads SPREAD_SNAPSHOT_INTERVAL, how often each pair's
+// best bid and ask are recorded for spread history (default 1m).
+func SetupSpreadSnapshots() (time.Duration, error) {
+	return durationFromEnv("SPREAD_SNAPSHOT_INTERVAL", defaultSpreadSnapshotInterval)
+}
+
+const (
+	defaultExpirySweepInterval = time.Second
+	defaultExpiryGrace         = 2 * time.Second
+)
+
+// SetupExpiry reads EXPIRY_SWEEP_INTERVAL, how often good-till-date orders
+// past their expiry are cancelled (default 1s), and EXPIRY_GRACE, how long an
+// expired order may wait on a match in flight on its pair before the sweeper
+// cancels it anyway (default 2s).
+func SetupExpiry() (time.Duration, time.Duration, error) {
+	interval, err := durationFromEnv("EXPIRY_SWEEP_INTERVAL", defaultExpirySweepInterval)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	grace, err := durationFromEnv("EXPIRY_GRACE", defaultExpiryGrace)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	return interval, grace, nil
+}
+
+// SetupMaxOrderAge reads MAX_ORDER_AGE, how long any order may rest on the
+// book before the expiry sweeper cancels it, whatever its own expiry. Unset
+// means orders rest until filled, cancelled or their own expiry.
+func SetupMaxOrderAge() (time.Duration, error) {
+	return durationFromEnv("MAX_ORDER_AGE", 0)
+}
+
+func durationFromEnv(name string, fallback time.Duration) (time.Duration, error) {
+	raw := os.Getenv(name)
+	if raw == "" {
+		return fallback, nil
+	}
+
+	d, err := time.ParseDuration(raw)
+	if err != nil || d <= 0 {
+		return 0, fmt.Errorf("invalid %s %q", name, raw)
+	}
+
+	return d, nil
+}
+
+func nonNegativeIntFromEnv(name string) (int, error) {
+	raw := os.Getenv(name)
+	if raw == "" {
+		return 0, nil
+	}
+
+	n, err := strconv.Atoi(raw)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("invalid %s %q", name, raw)
+	}
+
+	return n, nil
+}
@@ -0,0 +1,29 @@
+package entity
+
+import (
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+)
+
+var (
+	ErrAccountHasBalance    = NewError("ACCOUNT_HAS_BALANCE", http.StatusConflict, "account has nonzero balances")
+	ErrAccountHasOpenOrders = NewError("ACCOUNT_HAS_OPEN_ORDERS", http.StatusConflict, "account has open orders")
+	ErrInvalidAdjustment    = NewError("INVALID_ADJUSTMENT", http.StatusBadRequest, "adjustment amount must be nonzero")
+)
+
+// AdjustmentReason is the reason recorded for an operator's manual change
+// to a wallet balance.
+const AdjustmentReason = "adjustment"
+
+// WalletAdjustment is the event payload of a manual balance change. Amount
+// is signed: positive credits the wallet, negative debits it. Balance is the
+// balance after the change.
+type WalletAdjustment struct {
+	AccountID   uuid.UUID       `json:"account_id"`
+	AssetSymbol string          `json:"asset_symbol"`
+	Amount      decimal.Decimal `json:"amount"`
+	Balance     decimal.Decimal `json:"balance"`
+	Reason      string          `json:"reason"`
+}
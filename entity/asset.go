@@ -0,0 +1,59 @@
+package entity
+
+import (
+	"net/http"
+	"strings"
+)
+
+var ErrInvalidAssetSymbol = NewError("INVALID_ASSET_SYMBOL", http.StatusBadRequest, "asset symbol must be 2 to 10 letters or digits")
+
+const (
+	MinAssetSymbolLength = 2
+	MaxAssetSymbolLength = 10
+)
+
+// NormalizeAssetSymbol trims and uppercases s and checks the result is a
+// valid asset symbol, so "btc" and " BTC " both become "BTC". Symbols must
+// go through it before they are stored or matched against a pair.
+func NormalizeAssetSymbol(s string) (string, error) {
+	symbol := strings.ToUpper(strings.TrimSpace(s))
+	if !IsValidAssetSymbol(symbol) {
+		return "", ErrInvalidAssetSymbol
+	}
+	return symbol, nil
+}
+
+// IsValidAssetSymbol reports whether s is already a normalized asset symbol:
+// uppercase ASCII letters and digits, between MinAssetSymbolLength and
+// MaxAssetSymbolLength characters long.
+func IsValidAssetSymbol(s string) bool {
+	if len(s) < MinAssetSymbolLength || len(s) > MaxAssetSymbolLength {
+		return false
+	}
+	for _, c := range s {
+		if (c < 'A' || c > 'Z') && (c < '0' || c > '9') {
+			return false
+		}
+	}
+	return true
+}
+
+// NormalizeInstrumentPair normalizes both assets of a BASE_QUOTE pair, so
+// "btc_brl" becomes "BTC_BRL". Any malformed pair or invalid asset is
+// reported as ErrInvalidPairFormat.
+func NormalizeInstrumentPair(pair string) (string, error) {
+	assets := strings.Split(strings.TrimSpace(pair), "_")
+	if len(assets) != 2 {
+		return "", ErrInvalidPairFormat
+	}
+
+	base, err := NormalizeAssetSymbol(assets[0])
+	if err != nil {
+		return "", ErrInvalidPairFormat
+	}
+	quote, err := NormalizeAssetSymbol(assets[1])
+	if err != nil {
+		return "", ErrInvalidPairFormat
+	}
+	return base + "_" + quote, nil
+}
@@ -0,0 +1,81 @@
+package entity
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNormalizeAssetSymbol(t *testing.T) {
+	tests := []struct {
+		name    string
+		symbol  string
+		want    string
+		wantErr bool
+	}{
+		{name: "canonical", symbol: "BTC", want: "BTC"},
+		{name: "lowercase", symbol: "btc", want: "BTC"},
+		{name: "padded", symbol: " BTC ", want: "BTC"},
+		{name: "digits", symbol: "1INCH", want: "1INCH"},
+		{name: "max length", symbol: "ABCDEFGHIJ", want: "ABCDEFGHIJ"},
+		{name: "empty", symbol: "", wantErr: true},
+		{name: "blank", symbol: "   ", wantErr: true},
+		{name: "too short", symbol: "B", wantErr: true},
+		{name: "too long", symbol: "BITCOINCASH", wantErr: true},
+		{name: "inner space", symbol: "B TC", wantErr: true},
+		{name: "separator", symbol: "BTC_BRL", wantErr: true},
+		{name: "punctuation", symbol: "BTC-", wantErr: true},
+		{name: "non ascii", symbol: "ÉTH", wantErr: true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := NormalizeAssetSymbol(tc.symbol)
+			if tc.wantErr {
+				assert.ErrorIs(t, err, ErrInvalidAssetSymbol)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.want, got)
+		})
+	}
+}
+
+func TestNormalizeInstrumentPair(t *testing.T) {
+	tests := []struct {
+		name    string
+		pair    string
+		want    string
+		wantErr bool
+	}{
+		{name: "canonical", pair: "BTC_BRL", want: "BTC_BRL"},
+		{name: "lowercase", pair: "btc_brl", want: "BTC_BRL"},
+		{name: "padded", pair: " eth_btc ", want: "ETH_BTC"},
+		{name: "padded assets", pair: "btc _ brl", want: "BTC_BRL"},
+		{name: "no separator", pair: "BTCBRL", wantErr: true},
+		{name: "missing quote", pair: "BTC_", wantErr: true},
+		{name: "three assets", pair: "ONE_TWO_THREE", wantErr: true},
+		{name: "invalid asset", pair: "Bitcoin Cash_BRL", wantErr: true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := NormalizeInstrumentPair(tc.pair)
+			if tc.wantErr {
+				assert.ErrorIs(t, err, ErrInvalidPairFormat)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.want, got)
+		})
+	}
+}
+
+func TestWalletBeforeCreate_NormalizesSymbol(t *testing.T) {
+	wallet := &Wallet{AssetSymbol: " btc "}
+	assert.NoError(t, wallet.BeforeCreate(nil))
+	assert.Equal(t, "BTC", wallet.AssetSymbol)
+	assert.NotEqual(t, "00000000-0000-0000-0000-000000000000", wallet.ID.String())
+
+	assert.ErrorIs(t, (&Wallet{AssetSymbol: "Bitcoin Cash"}).BeforeCreate(nil), ErrInvalidAssetSymbol)
+}
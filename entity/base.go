@@ -13,9 +13,29 @@ type Base struct {
 	UpdatedAt time.Time `json:"updated_at" gorm:"autoUpdateTime"`
 }
 
+// NowUTC is the clock GORM stamps autoCreateTime and autoUpdateTime columns
+// with, so stored timestamps do not depend on the server's local zone.
+func NowUTC() time.Time {
+	return time.Now().UTC()
+}
+
+// BeforeCreate assigns a time-ordered UUIDv7 so rows are inserted roughly in
+// creation order, keeping primary key indexes compact for time-range scans.
 func (b *Base) BeforeCreate(tx *gorm.DB) error {
 	if b.ID == uuid.Nil {
-		b.ID = uuid.New()
+		id, err := uuid.NewV7()
+		if err != nil {
+			return err
+		}
+		b.ID = id
 	}
 	return nil
 }
+
+// AfterFind normalizes timestamps read back from the database to UTC, since
+// drivers return them in the connection's or the server's zone.
+func (b *Base) AfterFind(tx *gorm.DB) error {
+	b.CreatedAt = b.CreatedAt.UTC()
+	b.UpdatedAt = b.UpdatedAt.UTC()
+	return nil
+}
@@ -0,0 +1,46 @@
+package entity
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBeforeCreate_TimeOrderedIDs(t *testing.T) {
+	const n = 1000
+
+	orderIDs := make([]uuid.UUID, n)
+	tradeIDs := make([]uuid.UUID, n)
+	for i := 0; i < n; i++ {
+		o := &Order{}
+		assert.NoError(t, o.BeforeCreate(nil))
+		orderIDs[i] = o.ID
+
+		tr := &Trade{}
+		assert.NoError(t, tr.BeforeCreate(nil))
+		tradeIDs[i] = tr.ID
+	}
+
+	for _, ids := range [][]uuid.UUID{orderIDs, tradeIDs} {
+		for i, id := range ids {
+			assert.Equal(t, uuid.Version(7), id.Version())
+			if i > 0 {
+				assert.Equal(t, 1, bytes.Compare(id[:], ids[i-1][:]), "id %d is not greater than its predecessor", i)
+			}
+		}
+	}
+}
+
+func TestBeforeCreate_KeepsExistingID(t *testing.T) {
+	id := uuid.New()
+
+	o := &Order{Base: Base{ID: id}}
+	assert.NoError(t, o.BeforeCreate(nil))
+	assert.Equal(t, id, o.ID)
+
+	tr := &Trade{ID: id}
+	assert.NoError(t, tr.BeforeCreate(nil))
+	assert.Equal(t, id, tr.ID)
+}
@@ -0,0 +1,48 @@
+package entity
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+var (
+	ErrInvalidInterval  = NewError("INVALID_INTERVAL", http.StatusBadRequest, "invalid candle interval")
+	ErrInvalidTimeRange = NewError("INVALID_TIME_RANGE", http.StatusBadRequest, "invalid time range")
+	ErrInvalidWindow    = NewError("INVALID_WINDOW", http.StatusBadRequest, "invalid window")
+	ErrInvalidCursor    = NewError("INVALID_CURSOR", http.StatusBadRequest, "invalid cursor")
+
+	ErrInvalidSampleInterval = NewError("INVALID_SAMPLE_INTERVAL", http.StatusBadRequest, "sample interval must be between zero and the window")
+	ErrInvalidCostBasis      = NewError("INVALID_COST_BASIS", http.StatusBadRequest, "cost basis method must be average or fifo")
+)
+
+var candleIntervals = map[string]time.Duration{
+	"1m":  time.Minute,
+	"5m":  5 * time.Minute,
+	"15m": 15 * time.Minute,
+	"1h":  time.Hour,
+	"4h":  4 * time.Hour,
+	"1d":  24 * time.Hour,
+}
+
+// ParseCandleInterval returns the bucket size for one of the supported
+// candle intervals (1m, 5m, 15m, 1h, 4h, 1d).
+func ParseCandleInterval(interval string) (time.Duration, error) {
+	d, ok := candleIntervals[interval]
+	if !ok {
+		return 0, ErrInvalidInterval
+	}
+	return d, nil
+}
+
+// Candle is the OHLCV summary of the trades executed in
+// [OpenTime, OpenTime+interval).
+type Candle struct {
+	OpenTime time.Time
+	Open     decimal.Decimal
+	High     decimal.Decimal
+	Low      decimal.Decimal
+	Close    decimal.Decimal
+	Volume   decimal.Decimal
+}
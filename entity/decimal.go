@@ -0,0 +1,41 @@
+package entity
+
+import "github.com/shopspring/decimal"
+
+// DefaultDivisionPrecision is the number of decimal places kept by
+// DecimalDiv unless SetDivisionPrecision overrides it.
+const DefaultDivisionPrecision int32 = 16
+
+var divisionPrecision = DefaultDivisionPrecision
+
+// SetDivisionPrecision sets the places kept by DecimalDiv, and by any plain
+// decimal.Div through decimal.DivisionPrecision. It must be called at startup,
+// before any division runs.
+func SetDivisionPrecision(places int32) {
+	divisionPrecision = places
+	decimal.DivisionPrecision = int(places)
+}
+
+// DivisionPrecision returns the places kept by DecimalDiv.
+func DivisionPrecision() int32 {
+	return divisionPrecision
+}
+
+// DecimalDiv divides a by b, rounding half away from zero to the configured
+// division precision. Divisions must go through it so results do not depend
+// on the library default.
+func DecimalDiv(a, b decimal.Decimal) decimal.Decimal {
+	return a.DivRound(b, divisionPrecision)
+}
+
+// DecimalEqual reports whether a and b are the same number regardless of
+// scale, so 1.0 and 1.00 are equal. Quantities and balances must be compared
+// through these helpers rather than by struct equality.
+func DecimalEqual(a, b decimal.Decimal) bool {
+	return a.Cmp(b) == 0
+}
+
+// DecimalLess reports whether a is strictly less than b, regardless of scale.
+func DecimalLess(a, b decimal.Decimal) bool {
+	return a.Cmp(b) < 0
+}
@@ -0,0 +1,65 @@
+package entity
+
+import (
+	"testing"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDecimalEqual(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want bool
+	}{
+		{"1.0", "1.00", true},
+		{"1", "1.00000000", true},
+		{"0", "0.000", true},
+		{"-0.0", "0", true},
+		{"1.0", "1.01", false},
+		{"0.00000001", "0", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.a+"=="+tt.b, func(t *testing.T) {
+			a, b := decimal.RequireFromString(tt.a), decimal.RequireFromString(tt.b)
+			assert.Equal(t, tt.want, DecimalEqual(a, b))
+			assert.Equal(t, tt.want, DecimalEqual(b, a))
+		})
+	}
+}
+
+func TestDecimalDiv(t *testing.T) {
+	defer SetDivisionPrecision(DefaultDivisionPrecision)
+
+	tests := []struct {
+		places int32
+		a, b   string
+		want   string
+	}{
+		{16, "1", "3", "0.3333333333333333"},
+		{8, "1", "3", "0.33333333"},
+		{8, "2", "3", "0.66666667"},
+		{2, "-2", "3", "-0.67"},
+		{4, "10", "4", "2.5"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.a+"/"+tt.b, func(t *testing.T) {
+			SetDivisionPrecision(tt.places)
+			a, b := decimal.RequireFromString(tt.a), decimal.RequireFromString(tt.b)
+
+			got := DecimalDiv(a, b)
+			assert.Equal(t, tt.want, got.String())
+			assert.Equal(t, tt.places, DivisionPrecision())
+			// Plain Div follows the same setting.
+			assert.True(t, DecimalEqual(got, a.Div(b)))
+		})
+	}
+}
+
+func TestDecimalLess(t *testing.T) {
+	assert.True(t, DecimalLess(decimal.RequireFromString("0.99"), decimal.RequireFromString("1.00")))
+	assert.False(t, DecimalLess(decimal.RequireFromString("1.0"), decimal.RequireFromString("1.00")))
+	assert.False(t, DecimalLess(decimal.RequireFromString("1.01"), decimal.RequireFromString("1")))
+}
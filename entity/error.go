@@ -0,0 +1,31 @@
+package entity
+
+import "errors"
+
+// Error is a domain error. Code is a stable, machine-readable identifier for
+// clients, and Status is the HTTP status a handler should answer with. The
+// package's sentinels are *Error values, so errors.Is still matches them by
+// identity, wrapped or not.
+type Error struct {
+	Code    string
+	Message string
+	Status  int
+}
+
+// NewError returns a domain error. It is meant for package-level sentinels.
+func NewError(code string, status int, message string) *Error {
+	return &Error{Code: code, Message: message, Status: status}
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+// AsError returns the first *Error in err's chain, if any.
+func AsError(err error) (*Error, bool) {
+	var domainErr *Error
+	if errors.As(err, &domainErr) {
+		return domainErr, true
+	}
+	return nil, false
+}
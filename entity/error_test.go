@@ -0,0 +1,83 @@
+package entity
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestErrorSentinels(t *testing.T) {
+	tests := []struct {
+		err    error
+		code   string
+		status int
+	}{
+		{err: ErrAccountHasBalance, code: "ACCOUNT_HAS_BALANCE", status: http.StatusConflict},
+		{err: ErrAccountHasOpenOrders, code: "ACCOUNT_HAS_OPEN_ORDERS", status: http.StatusConflict},
+		{err: ErrInvalidAdjustment, code: "INVALID_ADJUSTMENT", status: http.StatusBadRequest},
+		{err: ErrInvalidSignature, code: "INVALID_SIGNATURE", status: http.StatusUnauthorized},
+		{err: ErrStaleTimestamp, code: "STALE_TIMESTAMP", status: http.StatusUnauthorized},
+		{err: ErrInvalidAssetSymbol, code: "INVALID_ASSET_SYMBOL", status: http.StatusBadRequest},
+		{err: ErrInvalidInterval, code: "INVALID_INTERVAL", status: http.StatusBadRequest},
+		{err: ErrInvalidTimeRange, code: "INVALID_TIME_RANGE", status: http.StatusBadRequest},
+		{err: ErrInvalidWindow, code: "INVALID_WINDOW", status: http.StatusBadRequest},
+		{err: ErrInvalidCursor, code: "INVALID_CURSOR", status: http.StatusBadRequest},
+		{err: ErrInvalidSampleInterval, code: "INVALID_SAMPLE_INTERVAL", status: http.StatusBadRequest},
+		{err: ErrInvalidCostBasis, code: "INVALID_COST_BASIS", status: http.StatusBadRequest},
+		{err: ErrUnsupportedAsset, code: "UNSUPPORTED_ASSET", status: http.StatusBadRequest},
+		{err: ErrInvalidPrice, code: "INVALID_PRICE", status: http.StatusBadRequest},
+		{err: ErrInvalidQuantity, code: "INVALID_QUANTITY", status: http.StatusBadRequest},
+		{err: ErrInvalidOrderType, code: "INVALID_ORDER_TYPE", status: http.StatusBadRequest},
+		{err: ErrInvalidPairFormat, code: "INVALID_PAIR_FORMAT", status: http.StatusBadRequest},
+		{err: ErrMaxQuantity, code: "MAX_QUANTITY_EXCEEDED", status: http.StatusBadRequest},
+		{err: ErrMaxPrice, code: "MAX_PRICE_EXCEEDED", status: http.StatusBadRequest},
+		{err: ErrInvalidSide, code: "INVALID_SIDE", status: http.StatusBadRequest},
+		{err: ErrInvalidBookView, code: "INVALID_BOOK_VIEW", status: http.StatusBadRequest},
+		{err: ErrInvalidMinQuantity, code: "INVALID_MIN_QUANTITY", status: http.StatusBadRequest},
+		{err: ErrInvalidMinFill, code: "INVALID_MIN_FILL", status: http.StatusBadRequest},
+		{err: ErrOrderNotOpen, code: "ORDER_NOT_OPEN", status: http.StatusConflict},
+		{err: ErrOrderFilled, code: "ORDER_FILLED", status: http.StatusConflict},
+		{err: ErrOrderNotOwned, code: "ORDER_NOT_OWNED", status: http.StatusForbidden},
+		{err: ErrSelfCross, code: "SELF_CROSS", status: http.StatusBadRequest},
+		{err: ErrImportCrossesBook, code: "IMPORT_CROSSES_BOOK", status: http.StatusBadRequest},
+		{err: ErrMarketHalted, code: "MARKET_HALTED", status: http.StatusServiceUnavailable},
+		{err: ErrInvalidExpiry, code: "INVALID_EXPIRY", status: http.StatusBadRequest},
+		{err: ErrInvalidQuoteQuantity, code: "INVALID_QUOTE_QUANTITY", status: http.StatusBadRequest},
+		{err: ErrQuoteQuantityNotBuy, code: "QUOTE_QUANTITY_NOT_BUY", status: http.StatusBadRequest},
+		{err: ErrQuoteQuantityWithQuantity, code: "QUOTE_QUANTITY_WITH_QUANTITY", status: http.StatusBadRequest},
+		{err: ErrQuoteQuantityWithPrice, code: "QUOTE_QUANTITY_WITH_PRICE", status: http.StatusBadRequest},
+		{err: ErrWalletNotFound, code: "WALLET_NOT_FOUND", status: http.StatusBadRequest},
+		{err: ErrInsufficientBalance, code: "INSUFFICIENT_BALANCE", status: http.StatusBadRequest},
+		{err: ErrMaxNotionalExceeded, code: "MAX_NOTIONAL_EXCEEDED", status: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.code, func(t *testing.T) {
+			wrapped := fmt.Errorf("failed to do something: %w", tt.err)
+
+			domainErr, ok := AsError(wrapped)
+			if assert.True(t, ok) {
+				assert.Equal(t, tt.code, domainErr.Code)
+				assert.Equal(t, tt.status, domainErr.Status)
+			}
+			assert.ErrorIs(t, wrapped, tt.err)
+		})
+	}
+}
+
+func TestError_IsMatchesByIdentity(t *testing.T) {
+	other := NewError(ErrOrderNotOpen.Code, ErrOrderNotOpen.Status, ErrOrderNotOpen.Message)
+
+	assert.False(t, errors.Is(other, ErrOrderNotOpen))
+	assert.True(t, errors.Is(errors.Join(errors.New("first"), ErrOrderNotOpen), ErrOrderNotOpen))
+}
+
+func TestAsError_NotADomainError(t *testing.T) {
+	domainErr, ok := AsError(errors.New("connection refused"))
+
+	assert.False(t, ok)
+	assert.Nil(t, domainErr)
+}
@@ -0,0 +1,46 @@
+package entity
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type EventType string
+
+const (
+	EventTypeOrderCreated   EventType = "ORDER_CREATED"
+	EventTypeOrderCancelled EventType = "ORDER_CANCELLED"
+	EventTypeOrderExpired   EventType = "ORDER_EXPIRED"
+	EventTypeTradeExecuted  EventType = "TRADE_EXECUTED"
+	EventTypeWalletAdjusted EventType = "WALLET_ADJUSTED"
+	EventTypeAccountDeleted EventType = "ACCOUNT_DELETED"
+)
+
+// Event is an append-only record of a state change. Sequence is assigned by
+// the database and gives the total order in which events must be replayed.
+type Event struct {
+	Sequence    int64           `json:"sequence" gorm:"primaryKey;autoIncrement"`
+	EventType   string          `json:"event_type"`
+	AggregateID uuid.UUID       `json:"aggregate_id" gorm:"type:uuid"`
+	Payload     json.RawMessage `json:"payload" gorm:"type:jsonb"`
+	CreatedAt   time.Time       `json:"created_at" gorm:"autoCreateTime"`
+}
+
+func (Event) TableName() string {
+	return "event"
+}
+
+func NewEvent(eventType EventType, aggregateID uuid.UUID, payload any) (*Event, error) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Event{
+		EventType:   string(eventType),
+		AggregateID: aggregateID,
+		Payload:     data,
+	}, nil
+}
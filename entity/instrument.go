@@ -0,0 +1,211 @@
+package entity
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/shopspring/decimal"
+)
+
+var ErrUnsupportedAsset = NewError("UNSUPPORTED_ASSET", http.StatusBadRequest, "unsupported asset")
+
+type Asset struct {
+	Symbol string
+	Scale  int32
+	// Epsilon is how far below zero a settlement may take the balance and
+	// still be treated as rounding residue. Zero means one unit at Scale.
+	Epsilon decimal.Decimal
+}
+
+// InstrumentConfig holds the assets known to the exchange and derives the
+// display scale of each pair from them: prices use the quote asset scale and
+// quantities use the base asset scale. A nil config formats values as-is.
+type InstrumentConfig struct {
+	assets map[string]Asset
+	// maxDecimalPlaces overrides the largest asset scale as the precision
+	// limit for request values; negative means no override.
+	maxDecimalPlaces int32
+}
+
+func NewInstrumentConfig(assets ...Asset) *InstrumentConfig {
+	c := &InstrumentConfig{assets: make(map[string]Asset, len(assets)), maxDecimalPlaces: -1}
+	for _, asset := range assets {
+		c.assets[asset.Symbol] = asset
+	}
+	return c
+}
+
+func (c *InstrumentConfig) Asset(symbol string) (Asset, bool) {
+	if c == nil {
+		return Asset{}, false
+	}
+	asset, ok := c.assets[symbol]
+	return asset, ok
+}
+
+// ValidatePair returns ErrUnsupportedAsset unless both assets of pair are
+// registered. A nil config has no registry and accepts every pair.
+func (c *InstrumentConfig) ValidatePair(pair string) error {
+	if c == nil {
+		return nil
+	}
+	for _, symbol := range strings.Split(pair, "_") {
+		if _, ok := c.Asset(symbol); !ok {
+			return fmt.Errorf("%w: %s", ErrUnsupportedAsset, symbol)
+		}
+	}
+	return nil
+}
+
+// SetMaxDecimalPlaces overrides the precision limit returned by
+// MaxDecimalPlaces.
+func (c *InstrumentConfig) SetMaxDecimalPlaces(places int32) {
+	c.maxDecimalPlaces = places
+}
+
+// MaxDecimalPlaces is the most decimal places a price or quantity may be sent
+// with: the configured override, or else the largest scale of any asset. A
+// nil or empty config has no limit.
+func (c *InstrumentConfig) MaxDecimalPlaces() (int32, bool) {
+	if c == nil {
+		return 0, false
+	}
+	if c.maxDecimalPlaces >= 0 {
+		return c.maxDecimalPlaces, true
+	}
+	if len(c.assets) == 0 {
+		return 0, false
+	}
+
+	var places int32
+	for _, asset := range c.assets {
+		if asset.Scale > places {
+			places = asset.Scale
+		}
+	}
+	return places, true
+}
+
+// ExceedsDecimalPlaces reports whether value carries more significant decimal
+// places than MaxDecimalPlaces allows. Trailing zeros are not significant.
+func (c *InstrumentConfig) ExceedsDecimalPlaces(value decimal.Decimal) bool {
+	places, ok := c.MaxDecimalPlaces()
+	return ok && ExceedsPlaces(value, places)
+}
+
+// PriceDecimalPlaces is the most decimal places a price or quote amount on
+// pair may be sent with: the quote asset's scale, lowered to
+// MaxDecimalPlaces when that is set below it. A pair with an unknown quote
+// asset falls back to MaxDecimalPlaces.
+func (c *InstrumentConfig) PriceDecimalPlaces(pair string) (int32, bool) {
+	scale, ok := c.PriceScale(normalizedPair(pair))
+	return c.pairDecimalPlaces(scale, ok)
+}
+
+// QuantityDecimalPlaces is the most decimal places a base quantity on pair
+// may be sent with: the base asset's scale, so every accepted quantity can
+// be settled exactly. It is capped and falls back like PriceDecimalPlaces.
+func (c *InstrumentConfig) QuantityDecimalPlaces(pair string) (int32, bool) {
+	scale, ok := c.QuantityScale(normalizedPair(pair))
+	return c.pairDecimalPlaces(scale, ok)
+}
+
+func (c *InstrumentConfig) pairDecimalPlaces(scale int32, ok bool) (int32, bool) {
+	places, limited := c.MaxDecimalPlaces()
+	if !ok {
+		return places, limited
+	}
+	if limited && places < scale {
+		return places, true
+	}
+	return scale, true
+}
+
+// ExceedsPlaces reports whether value carries more than places significant
+// decimal places. Trailing zeros are not significant.
+func ExceedsPlaces(value decimal.Decimal, places int32) bool {
+	return !value.Equal(value.Truncate(places))
+}
+
+// normalizedPair is pair in canonical form, or pair itself when it cannot be
+// normalized.
+func normalizedPair(pair string) string {
+	if normalized, err := NormalizeInstrumentPair(pair); err == nil {
+		return normalized
+	}
+	return pair
+}
+
+// BalanceEpsilon returns the tolerated balance deficit for symbol. Unknown
+// assets, and every asset of a nil config, get no tolerance.
+func (c *InstrumentConfig) BalanceEpsilon(symbol string) decimal.Decimal {
+	asset, ok := c.Asset(symbol)
+	if !ok {
+		return decimal.Zero
+	}
+	if asset.Epsilon.IsPositive() {
+		return asset.Epsilon
+	}
+	return decimal.New(1, -asset.Scale)
+}
+
+func (c *InstrumentConfig) PriceScale(pair string) (int32, bool) {
+	_, quoteSymbol, err := SplitInstrumentPair(pair)
+	if err != nil {
+		return 0, false
+	}
+	quote, ok := c.Asset(quoteSymbol)
+	return quote.Scale, ok
+}
+
+func (c *InstrumentConfig) QuantityScale(pair string) (int32, bool) {
+	baseSymbol, _, err := SplitInstrumentPair(pair)
+	if err != nil {
+		return 0, false
+	}
+	base, ok := c.Asset(baseSymbol)
+	return base.Scale, ok
+}
+
+func (c *InstrumentConfig) FormatPrice(pair string, value decimal.Decimal) string {
+	scale, ok := c.PriceScale(pair)
+	if !ok {
+		return value.String()
+	}
+	return value.StringFixed(scale)
+}
+
+func (c *InstrumentConfig) FormatQuantity(pair string, value decimal.Decimal) string {
+	scale, ok := c.QuantityScale(pair)
+	if !ok {
+		return value.String()
+	}
+	return value.StringFixed(scale)
+}
+
+func (c *InstrumentConfig) FormatAmount(symbol string, value decimal.Decimal) string {
+	asset, ok := c.Asset(symbol)
+	if !ok {
+		return value.String()
+	}
+	return value.StringFixed(asset.Scale)
+}
+
+// FormatInversePrice formats 1/value as a price of the reciprocal market of
+// pair, at that market's price scale (the base asset scale of pair). The
+// reciprocal is rounded once, straight to the display scale, rather than
+// through DecimalDiv and then again by StringFixed. Zero formats as zero.
+func (c *InstrumentConfig) FormatInversePrice(pair string, value decimal.Decimal) string {
+	scale, ok := c.QuantityScale(pair)
+	if value.IsZero() {
+		if !ok {
+			return value.String()
+		}
+		return value.StringFixed(scale)
+	}
+	if !ok {
+		return DecimalDiv(decimal.NewFromInt(1), value).String()
+	}
+	return decimal.NewFromInt(1).DivRound(value, scale).StringFixed(scale)
+}
@@ -0,0 +1,176 @@
+package entity
+
+import (
+	"testing"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInstrumentConfig_Format(t *testing.T) {
+	cfg := NewInstrumentConfig(
+		Asset{Symbol: "BTC", Scale: 8},
+		Asset{Symbol: "ETH", Scale: 4},
+		Asset{Symbol: "BRL", Scale: 2},
+	)
+
+	tests := []struct {
+		name         string
+		cfg          *InstrumentConfig
+		pair         string
+		price        string
+		quantity     string
+		wantPrice    string
+		wantQuantity string
+	}{
+		{
+			name:         "BTC_BRL uses 2/8",
+			cfg:          cfg,
+			pair:         "BTC_BRL",
+			price:        "200000",
+			quantity:     "0.5",
+			wantPrice:    "200000.00",
+			wantQuantity: "0.50000000",
+		},
+		{
+			name:         "ETH_BTC uses 8/4",
+			cfg:          cfg,
+			pair:         "ETH_BTC",
+			price:        "0.06",
+			quantity:     "2.5",
+			wantPrice:    "0.06000000",
+			wantQuantity: "2.5000",
+		},
+		{
+			name:         "unknown asset falls back to raw string",
+			cfg:          cfg,
+			pair:         "DOGE_USD",
+			price:        "0.1",
+			quantity:     "10",
+			wantPrice:    "0.1",
+			wantQuantity: "10",
+		},
+		{
+			name:         "nil config falls back to raw string",
+			cfg:          nil,
+			pair:         "BTC_BRL",
+			price:        "100",
+			quantity:     "1.4",
+			wantPrice:    "100",
+			wantQuantity: "1.4",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			price := decimal.RequireFromString(tt.price)
+			quantity := decimal.RequireFromString(tt.quantity)
+
+			assert.Equal(t, tt.wantPrice, tt.cfg.FormatPrice(tt.pair, price))
+			assert.Equal(t, tt.wantQuantity, tt.cfg.FormatQuantity(tt.pair, quantity))
+		})
+	}
+}
+
+func TestInstrumentConfig_FormatAmount(t *testing.T) {
+	cfg := NewInstrumentConfig(Asset{Symbol: "BRL", Scale: 2})
+
+	assert.Equal(t, "1000.50", cfg.FormatAmount("BRL", decimal.RequireFromString("1000.5")))
+	assert.Equal(t, "0.5", cfg.FormatAmount("BTC", decimal.RequireFromString("0.5")))
+}
+
+func TestInstrumentConfig_FormatInversePrice(t *testing.T) {
+	cfg := NewInstrumentConfig(Asset{Symbol: "BTC", Scale: 8}, Asset{Symbol: "BRL", Scale: 2})
+
+	// 1/3 rounds once at the BTC scale of the BRL_BTC market.
+	assert.Equal(t, "0.33333333", cfg.FormatInversePrice("BTC_BRL", decimal.RequireFromString("3")))
+	assert.Equal(t, "0.00000990", cfg.FormatInversePrice("BTC_BRL", decimal.RequireFromString("101000")))
+	assert.Equal(t, "0.00000000", cfg.FormatInversePrice("BTC_BRL", decimal.Zero))
+	assert.Equal(t, "0.25", cfg.FormatInversePrice("DOGE_BRL", decimal.RequireFromString("4")))
+
+	inverted, err := InvertInstrumentPair("BTC_BRL")
+	assert.NoError(t, err)
+	assert.Equal(t, "BRL_BTC", inverted)
+	_, err = InvertInstrumentPair("BTCBRL")
+	assert.ErrorIs(t, err, ErrInvalidPairFormat)
+}
+
+func TestInstrumentConfig_ValidatePair(t *testing.T) {
+	cfg := NewInstrumentConfig(Asset{Symbol: "BTC", Scale: 8}, Asset{Symbol: "BRL", Scale: 2})
+
+	assert.NoError(t, cfg.ValidatePair("BTC_BRL"))
+	assert.ErrorIs(t, cfg.ValidatePair("DOGE_BRL"), ErrUnsupportedAsset)
+	assert.ErrorIs(t, cfg.ValidatePair("BTC_USD"), ErrUnsupportedAsset)
+
+	var none *InstrumentConfig
+	assert.NoError(t, none.ValidatePair("DOGE_BRL"))
+}
+
+func TestInstrumentConfig_BalanceEpsilon(t *testing.T) {
+	cfg := NewInstrumentConfig(
+		Asset{Symbol: "BTC", Scale: 8},
+		Asset{Symbol: "BRL", Scale: 2, Epsilon: decimal.RequireFromString("0.05")},
+	)
+
+	assert.Equal(t, "0.00000001", cfg.BalanceEpsilon("BTC").String())
+	assert.Equal(t, "0.05", cfg.BalanceEpsilon("BRL").String())
+	assert.True(t, cfg.BalanceEpsilon("ETH").IsZero())
+
+	var none *InstrumentConfig
+	assert.True(t, none.BalanceEpsilon("BTC").IsZero())
+}
+
+func TestInstrumentConfig_ExceedsDecimalPlaces(t *testing.T) {
+	cfg := NewInstrumentConfig(Asset{Symbol: "BTC", Scale: 8}, Asset{Symbol: "BRL", Scale: 2})
+
+	places, ok := cfg.MaxDecimalPlaces()
+	assert.True(t, ok)
+	assert.Equal(t, int32(8), places)
+	assert.False(t, cfg.ExceedsDecimalPlaces(decimal.RequireFromString("0.00000001")))
+	assert.False(t, cfg.ExceedsDecimalPlaces(decimal.RequireFromString("1.1000000000")), "trailing zeros are not significant")
+	assert.True(t, cfg.ExceedsDecimalPlaces(decimal.RequireFromString("0.000000001")))
+
+	cfg.SetMaxDecimalPlaces(2)
+	assert.False(t, cfg.ExceedsDecimalPlaces(decimal.RequireFromString("100.25")))
+	assert.True(t, cfg.ExceedsDecimalPlaces(decimal.RequireFromString("100.251")))
+
+	var none *InstrumentConfig
+	assert.False(t, none.ExceedsDecimalPlaces(decimal.RequireFromString("0.0000000000001")))
+}
+
+func TestInstrumentConfig_PairDecimalPlaces(t *testing.T) {
+	cfg := NewInstrumentConfig(Asset{Symbol: "BTC", Scale: 8}, Asset{Symbol: "ETH", Scale: 4}, Asset{Symbol: "BRL", Scale: 2})
+
+	tests := []struct {
+		name      string
+		pair      string
+		override  int32
+		wantPrice int32
+		wantQty   int32
+	}{
+		{name: "scales of the pair's assets", pair: "ETH_BRL", override: -1, wantPrice: 2, wantQty: 4},
+		{name: "quote scale of a crypto pair", pair: "ETH_BTC", override: -1, wantPrice: 8, wantQty: 4},
+		{name: "pair is normalized first", pair: "eth_brl", override: -1, wantPrice: 2, wantQty: 4},
+		{name: "unknown asset falls back to the largest scale", pair: "DOGE_BRL", override: -1, wantPrice: 2, wantQty: 8},
+		{name: "override lowers a larger scale", pair: "ETH_BTC", override: 3, wantPrice: 3, wantQty: 3},
+		{name: "override does not raise a smaller scale", pair: "ETH_BRL", override: 6, wantPrice: 2, wantQty: 4},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg.SetMaxDecimalPlaces(tt.override)
+
+			price, ok := cfg.PriceDecimalPlaces(tt.pair)
+			assert.True(t, ok)
+			assert.Equal(t, tt.wantPrice, price)
+
+			qty, ok := cfg.QuantityDecimalPlaces(tt.pair)
+			assert.True(t, ok)
+			assert.Equal(t, tt.wantQty, qty)
+		})
+	}
+
+	var none *InstrumentConfig
+	_, ok := none.QuantityDecimalPlaces("ETH_BRL")
+	assert.False(t, ok)
+}
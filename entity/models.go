@@ -32,23 +32,106 @@ func (Wallet) TableName() string {
 	return "wallet"
 }
 
+// BeforeCreate normalizes the asset symbol, so no wallet is stored under a
+// symbol that would not match the assets of a pair.
+func (w *Wallet) BeforeCreate(tx *gorm.DB) error {
+	symbol, err := NormalizeAssetSymbol(w.AssetSymbol)
+	if err != nil {
+		return err
+	}
+	w.AssetSymbol = symbol
+	return w.Base.BeforeCreate(tx)
+}
+
+// WalletSnapshot is a wallet's balance as of TakenAt. Snapshots are only
+// ever inserted and let past balances be read without replaying trades.
+type WalletSnapshot struct {
+	ID          uuid.UUID       `json:"id" gorm:"type:uuid;primary_key"`
+	WalletID    uuid.UUID       `json:"wallet_id" gorm:"type:uuid"`
+	AccountID   uuid.UUID       `json:"account_id" gorm:"type:uuid"`
+	AssetSymbol string          `json:"asset_symbol"`
+	Balance     decimal.Decimal `json:"balance" gorm:"type:decimal(20,8)"`
+	TakenAt     time.Time       `json:"taken_at"`
+}
+
+func (WalletSnapshot) TableName() string {
+	return "wallet_snapshot"
+}
+
+func (s *WalletSnapshot) BeforeCreate(tx *gorm.DB) error {
+	if s.ID == uuid.Nil {
+		id, err := uuid.NewV7()
+		if err != nil {
+			return err
+		}
+		s.ID = id
+	}
+	return nil
+}
+
+// SpreadSnapshot is a pair's best bid and ask as of TakenAt. Like wallet
+// snapshots they are only ever inserted; a side with no resting orders is
+// stored as NULL.
+type SpreadSnapshot struct {
+	ID             uuid.UUID           `json:"id" gorm:"type:uuid;primary_key"`
+	InstrumentPair string              `json:"instrument_pair"`
+	BestBid        decimal.NullDecimal `json:"best_bid" gorm:"type:decimal(20,8)"`
+	BestAsk        decimal.NullDecimal `json:"best_ask" gorm:"type:decimal(20,8)"`
+	TakenAt        time.Time           `json:"taken_at"`
+}
+
+func (SpreadSnapshot) TableName() string {
+	return "spread_snapshot"
+}
+
+func (s *SpreadSnapshot) BeforeCreate(tx *gorm.DB) error {
+	if s.ID == uuid.Nil {
+		id, err := uuid.NewV7()
+		if err != nil {
+			return err
+		}
+		s.ID = id
+	}
+	return nil
+}
+
+// Spread returns the best ask minus the best bid, and false when either side
+// was empty.
+func (s *SpreadSnapshot) Spread() (decimal.Decimal, bool) {
+	if !s.BestBid.Valid || !s.BestAsk.Valid {
+		return decimal.Zero, false
+	}
+	return s.BestAsk.Decimal.Sub(s.BestBid.Decimal), true
+}
+
 type Trade struct {
-	ID            uuid.UUID       `json:"id" gorm:"type:uuid;primary_key"`
-	BuyerOrderID  uuid.UUID       `json:"buyer_order_id" gorm:"type:uuid"`
-	SellerOrderID uuid.UUID       `json:"seller_order_id" gorm:"type:uuid"`
-	Price         decimal.Decimal `json:"price" gorm:"type:decimal(20,8)"`
-	Quantity      decimal.Decimal `json:"quantity" gorm:"type:decimal(20,8)"`
-	ExecutedAt    time.Time       `json:"executed_at"`
-	DeletedAt     *time.Time      `json:"deleted_at,omitempty"`
+	ID             uuid.UUID       `json:"id" gorm:"type:uuid;primary_key"`
+	BuyerOrderID   uuid.UUID       `json:"buyer_order_id" gorm:"type:uuid"`
+	SellerOrderID  uuid.UUID       `json:"seller_order_id" gorm:"type:uuid"`
+	InstrumentPair string          `json:"instrument_pair"`
+	Price          decimal.Decimal `json:"price" gorm:"type:decimal(20,8)"`
+	Quantity       decimal.Decimal `json:"quantity" gorm:"type:decimal(20,8)"`
+	ExecutedAt     time.Time       `json:"executed_at" gorm:"autoCreateTime"`
+	DeletedAt      *time.Time      `json:"deleted_at,omitempty"`
 }
 
 func (Trade) TableName() string {
 	return "trade"
 }
 
+// AfterFind normalizes ExecutedAt to UTC, like Base.AfterFind.
+func (t *Trade) AfterFind(tx *gorm.DB) error {
+	t.ExecutedAt = t.ExecutedAt.UTC()
+	return nil
+}
+
 func (t *Trade) BeforeCreate(tx *gorm.DB) error {
 	if t.ID == uuid.Nil {
-		t.ID = uuid.New()
+		id, err := uuid.NewV7()
+		if err != nil {
+			return err
+		}
+		t.ID = id
 	}
 	return nil
 }
@@ -2,19 +2,46 @@ package entity
 
 import (
 	"errors"
+	"net/http"
 	"strings"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
 )
 
 var (
-	ErrInvalidPrice      = errors.New("price must be greater than zero")
-	ErrInvalidQuantity   = errors.New("quantity must be greater than zero")
-	ErrInvalidOrderType  = errors.New("invalid order type")
-	ErrInvalidPairFormat = errors.New("invalid instrument pair format")
-	ErrMaxQuantity       = errors.New("quantity exceeds maximum limit")
-	ErrMaxPrice          = errors.New("price exceeds maximum limit")
+	ErrInvalidPrice       = NewError("INVALID_PRICE", http.StatusBadRequest, "price must be greater than zero")
+	ErrInvalidQuantity    = NewError("INVALID_QUANTITY", http.StatusBadRequest, "quantity must be greater than zero")
+	ErrInvalidOrderType   = NewError("INVALID_ORDER_TYPE", http.StatusBadRequest, "invalid order type")
+	ErrInvalidPairFormat  = NewError("INVALID_PAIR_FORMAT", http.StatusBadRequest, "invalid instrument pair format")
+	ErrMaxQuantity        = NewError("MAX_QUANTITY_EXCEEDED", http.StatusBadRequest, "quantity exceeds maximum limit")
+	ErrMaxPrice           = NewError("MAX_PRICE_EXCEEDED", http.StatusBadRequest, "price exceeds maximum limit")
+	ErrInvalidSide        = NewError("INVALID_SIDE", http.StatusBadRequest, "invalid book side")
+	ErrInvalidBookView    = NewError("INVALID_BOOK_VIEW", http.StatusBadRequest, "invalid book view")
+	ErrInvalidMinQuantity = NewError("INVALID_MIN_QUANTITY", http.StatusBadRequest, "min quantity must not be negative")
+	ErrInvalidMinFill     = NewError("INVALID_MIN_FILL", http.StatusBadRequest, "min fill quantity must be between zero and quantity")
+	ErrOrderNotOpen       = NewError("ORDER_NOT_OPEN", http.StatusConflict, "order is not open")
+	ErrOrderFilled        = NewError("ORDER_FILLED", http.StatusConflict, "order is already filled")
+	ErrOrderNotOwned      = NewError("ORDER_NOT_OWNED", http.StatusForbidden, "order belongs to another account")
+	ErrSelfCross          = NewError("SELF_CROSS", http.StatusBadRequest, "order crosses a resting order of the same account")
+	ErrImportCrossesBook  = NewError("IMPORT_CROSSES_BOOK", http.StatusBadRequest, "imported order crosses the opposite side of the book")
+	ErrMarketHalted       = NewError("MARKET_HALTED", http.StatusServiceUnavailable, "market is halted")
+	ErrInvalidExpiry      = NewError("INVALID_EXPIRY", http.StatusBadRequest, "expiry must be in the future")
+
+	ErrInvalidQuoteQuantity      = NewError("INVALID_QUOTE_QUANTITY", http.StatusBadRequest, "quote quantity must be greater than zero")
+	ErrQuoteQuantityNotBuy       = NewError("QUOTE_QUANTITY_NOT_BUY", http.StatusBadRequest, "quote quantity is only supported on buy orders")
+	ErrQuoteQuantityWithQuantity = NewError("QUOTE_QUANTITY_WITH_QUANTITY", http.StatusBadRequest, "quote quantity cannot be combined with quantity")
+	ErrQuoteQuantityWithPrice    = NewError("QUOTE_QUANTITY_WITH_PRICE", http.StatusBadRequest, "quote quantity orders buy at market and take no price")
+)
+
+// BookSide identifies one side of the aggregated order book.
+type BookSide string
+
+const (
+	BookSideBid BookSide = "bid"
+	BookSideAsk BookSide = "ask"
 )
 
 type OrderType string
@@ -38,6 +65,10 @@ const (
 	MaxPrice    = 100000000
 )
 
+// ReservationScale is the scale of the reserved amount column. Reservations
+// are rounded up to it, so an order never holds less than it can spend.
+const ReservationScale = 8
+
 type Order struct {
 	Base
 	AccountID         uuid.UUID       `json:"account_id" gorm:"type:uuid"`
@@ -46,52 +77,214 @@ type Order struct {
 	Price             decimal.Decimal `json:"price" gorm:"type:decimal(20,8)"`
 	Quantity          decimal.Decimal `json:"quantity" gorm:"type:decimal(20,8)"`
 	RemainingQuantity decimal.Decimal `json:"remaining_quantity" gorm:"type:decimal(20,8)"`
-	Status            string          `json:"status"`
+	// MinFillQuantity is how much must match immediately for the order to
+	// execute at all; zero means no minimum.
+	MinFillQuantity decimal.Decimal `json:"min_fill_quantity" gorm:"type:decimal(20,8)"`
+	// QuoteQuantity is the quote budget of a market buy, which sweeps the
+	// asks until the budget is spent instead of buying a base quantity at a
+	// limit price; zero for limit orders. Once matched, Quantity is the base
+	// bought.
+	QuoteQuantity decimal.Decimal `json:"quote_quantity" gorm:"type:decimal(20,8)"`
+	Status        string          `json:"status"`
+	// ReservedAsset and ReservedAmount are the part of the account's wallet
+	// the order still holds: set at placement, reduced by each fill and
+	// released in full once the order is filled or cancelled. The amount is
+	// tracked here rather than recomputed from price and quantity, so what
+	// is released is exactly what was reserved.
+	ReservedAsset  string          `json:"reserved_asset,omitempty"`
+	ReservedAmount decimal.Decimal `json:"reserved_amount" gorm:"type:decimal(20,8)"`
+	// ExpiresAt makes the order good-till-date: once it passes, the expiry
+	// sweeper cancels whatever remains. Nil means good-till-cancelled.
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
 }
 
 func (Order) TableName() string {
 	return "order"
 }
 
+func (o *Order) AfterFind(tx *gorm.DB) error {
+	if err := o.Base.AfterFind(tx); err != nil {
+		return err
+	}
+	if o.ExpiresAt != nil {
+		expiresAt := o.ExpiresAt.UTC()
+		o.ExpiresAt = &expiresAt
+	}
+	return nil
+}
+
+// orderRule is one check of order validation: err is reported when
+// violated returns true.
+type orderRule struct {
+	violated func(o *Order) bool
+	err      error
+}
+
+// limitOrderRules are the checks on a limit order, in the order Validate
+// reports them.
+var limitOrderRules = []orderRule{
+	{func(o *Order) bool { return o.Price.LessThanOrEqual(decimal.Zero) }, ErrInvalidPrice},
+	{func(o *Order) bool { return o.Quantity.LessThanOrEqual(decimal.Zero) }, ErrInvalidQuantity},
+	{func(o *Order) bool { return o.Quantity.GreaterThan(decimal.NewFromInt(MaxQuantity)) }, ErrMaxQuantity},
+	{func(o *Order) bool { return o.Price.GreaterThan(decimal.NewFromInt(MaxPrice)) }, ErrMaxPrice},
+	{func(o *Order) bool {
+		return o.MinFillQuantity.IsNegative() || o.MinFillQuantity.GreaterThan(o.Quantity)
+	}, ErrInvalidMinFill},
+	{func(o *Order) bool { return !isValidOrderType(o.OrderType) }, ErrInvalidOrderType},
+	{func(o *Order) bool { return !IsValidInstrumentPair(o.InstrumentPair) }, ErrInvalidPairFormat},
+}
+
+// marketBuyRules are the checks on an order spending a quote budget at
+// market. The budget replaces both the price and the base quantity.
+var marketBuyRules = []orderRule{
+	{func(o *Order) bool { return o.OrderType != string(OrderTypeBuy) }, ErrQuoteQuantityNotBuy},
+	{func(o *Order) bool { return !o.Quantity.IsZero() }, ErrQuoteQuantityWithQuantity},
+	{func(o *Order) bool { return !o.Price.IsZero() }, ErrQuoteQuantityWithPrice},
+	{func(o *Order) bool { return !o.QuoteQuantity.IsPositive() }, ErrInvalidQuoteQuantity},
+	{func(o *Order) bool { return o.QuoteQuantity.GreaterThan(decimal.NewFromInt(MaxPrice * MaxQuantity)) }, ErrMaxQuantity},
+	{func(o *Order) bool { return !o.MinFillQuantity.IsZero() }, ErrInvalidMinFill},
+	{func(o *Order) bool { return !IsValidInstrumentPair(o.InstrumentPair) }, ErrInvalidPairFormat},
+}
+
+func (o *Order) rules() []orderRule {
+	if !o.QuoteQuantity.IsZero() {
+		return marketBuyRules
+	}
+	return limitOrderRules
+}
+
+func isValidOrderType(orderType string) bool {
+	return orderType == string(OrderTypeBuy) || orderType == string(OrderTypeSell)
+}
+
+// Validate returns the first rule the order violates, or nil. It is the fast
+// path the engine uses; see ValidateAll to report every violation.
 func (o *Order) Validate() error {
-	if o.Price.LessThanOrEqual(decimal.Zero) {
-		return ErrInvalidPrice
+	for _, rule := range o.rules() {
+		if rule.violated(o) {
+			return rule.err
+		}
+	}
+	return nil
+}
+
+// ValidateAll checks the same rules as Validate but reports every violation
+// instead of stopping at the first, joined with errors.Join so each can be
+// matched with errors.Is. It returns nil for a valid order.
+func (o *Order) ValidateAll() error {
+	var violations []error
+	for _, rule := range o.rules() {
+		if rule.violated(o) {
+			violations = append(violations, rule.err)
+		}
 	}
+	return errors.Join(violations...)
+}
+
+// IsMarketBuy reports whether o spends a quote budget at market rather than
+// trading a base quantity at a limit price.
+func (o *Order) IsMarketBuy() bool {
+	return o.QuoteQuantity.IsPositive()
+}
 
-	if o.Quantity.LessThanOrEqual(decimal.Zero) {
-		return ErrInvalidQuantity
+// OppositeType returns the order type on the other side of the book.
+func (o *Order) OppositeType() string {
+	if o.OrderType == string(OrderTypeBuy) {
+		return string(OrderTypeSell)
 	}
+	return string(OrderTypeBuy)
+}
 
-	if o.Quantity.GreaterThan(decimal.NewFromInt(MaxQuantity)) {
-		return ErrMaxQuantity
+// Crosses reports whether o and resting, an order on the opposite side, are
+// priced so that they would trade with each other. A market buy crosses
+// every ask.
+func (o *Order) Crosses(resting *Order) bool {
+	if o.IsMarketBuy() {
+		return resting.OrderType == string(OrderTypeSell)
 	}
+	if o.OrderType == string(OrderTypeBuy) {
+		return o.Price.GreaterThanOrEqual(resting.Price)
+	}
+	return o.Price.LessThanOrEqual(resting.Price)
+}
+
+func IsValidInstrumentPair(pair string) bool {
+	_, _, err := SplitInstrumentPair(pair)
+	return err == nil
+}
 
-	if o.Price.GreaterThan(decimal.NewFromInt(MaxPrice)) {
-		return ErrMaxPrice
+// SplitInstrumentPair returns the base and quote assets of a BASE_QUOTE pair.
+// Code that needs the assets must go through it rather than indexing the
+// split itself, so a malformed pair is an error and never a panic. Both
+// assets must already be normalized; see NormalizeInstrumentPair.
+func SplitInstrumentPair(pair string) (string, string, error) {
+	assets := strings.Split(pair, "_")
+	if len(assets) != 2 || !IsValidAssetSymbol(assets[0]) || !IsValidAssetSymbol(assets[1]) {
+		return "", "", ErrInvalidPairFormat
 	}
+	return assets[0], assets[1], nil
+}
 
-	if o.OrderType != string(OrderTypeBuy) && o.OrderType != string(OrderTypeSell) {
-		return ErrInvalidOrderType
+// InvertInstrumentPair returns the reciprocal market of pair, QUOTE_BASE for
+// BASE_QUOTE.
+func InvertInstrumentPair(pair string) (string, error) {
+	base, quote, err := SplitInstrumentPair(pair)
+	if err != nil {
+		return "", err
 	}
+	return quote + "_" + base, nil
+}
 
-	if !IsValidInstrumentPair(o.InstrumentPair) {
-		return ErrInvalidPairFormat
+// Reserve sets aside what the order can spend: the quote amount at its limit
+// price for a buy, the base quantity for a sell. A market buy never rests on
+// the book and reserves nothing.
+func (o *Order) Reserve() error {
+	if o.IsMarketBuy() {
+		o.ReservedAsset, o.ReservedAmount = "", decimal.Zero
+		return nil
 	}
 
+	asset, amount, err := o.GetRequiredAssetAndAmount()
+	if err != nil {
+		return err
+	}
+	o.ReservedAsset = asset
+	o.ReservedAmount = amount.RoundCeil(ReservationScale)
 	return nil
 }
 
-func IsValidInstrumentPair(pair string) bool {
-	assets := strings.Split(pair, "_")
-	return len(assets) == 2 && assets[0] != "" && assets[1] != ""
+// ConsumeReservation takes a fill of qty out of the reservation, at the
+// order's own price rather than the trade's, since that is the rate it was
+// reserved at. It must be called after RemainingQuantity is reduced: once
+// nothing remains to fill, whatever is left over, from price improvement or
+// rounding, is released as well, so the reservation never goes negative and
+// never outlives the order.
+func (o *Order) ConsumeReservation(qty decimal.Decimal) {
+	used := qty
+	if o.OrderType == string(OrderTypeBuy) {
+		used = o.Price.Mul(qty)
+	}
+
+	reserved := o.ReservedAmount.Sub(used).RoundCeil(ReservationScale)
+	if !o.RemainingQuantity.IsPositive() || reserved.IsNegative() {
+		reserved = decimal.Zero
+	}
+	o.ReservedAmount = reserved
 }
 
-func (o *Order) GetRequiredAssetAndAmount() (string, decimal.Decimal) {
-	assets := strings.Split(o.InstrumentPair, "_")
+func (o *Order) GetRequiredAssetAndAmount() (string, decimal.Decimal, error) {
+	base, quote, err := SplitInstrumentPair(o.InstrumentPair)
+	if err != nil {
+		return "", decimal.Zero, err
+	}
+
+	if o.IsMarketBuy() {
+		return quote, o.QuoteQuantity, nil
+	}
 
 	if o.OrderType == string(OrderTypeBuy) {
-		return assets[1], o.Price.Mul(o.Quantity)
+		return quote, o.Price.Mul(o.Quantity), nil
 	}
 
-	return assets[0], o.Quantity
+	return base, o.Quantity, nil
 }
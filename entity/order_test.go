@@ -32,6 +32,40 @@ func TestOrderValidate(t *testing.T) {
 				Quantity:       decimal.RequireFromString("2.5"),
 			},
 		},
+		{
+			name: "valid min fill equal to quantity",
+			order: Order{
+				InstrumentPair:  "BTC_BRL",
+				OrderType:       string(OrderTypeBuy),
+				Price:           decimal.RequireFromString("100"),
+				Quantity:        decimal.RequireFromString("1"),
+				MinFillQuantity: decimal.RequireFromString("1"),
+			},
+		},
+		{
+			name: "invalid min fill above quantity",
+			order: Order{
+				InstrumentPair:  "BTC_BRL",
+				OrderType:       string(OrderTypeBuy),
+				Price:           decimal.RequireFromString("100"),
+				Quantity:        decimal.RequireFromString("1"),
+				MinFillQuantity: decimal.RequireFromString("1.1"),
+			},
+			wantErr: true,
+			errIs:   ErrInvalidMinFill,
+		},
+		{
+			name: "invalid min fill negative",
+			order: Order{
+				InstrumentPair:  "BTC_BRL",
+				OrderType:       string(OrderTypeSell),
+				Price:           decimal.RequireFromString("100"),
+				Quantity:        decimal.RequireFromString("1"),
+				MinFillQuantity: decimal.RequireFromString("-0.1"),
+			},
+			wantErr: true,
+			errIs:   ErrInvalidMinFill,
+		},
 		{
 			name: "invalid price zero",
 			order: Order{
@@ -142,6 +176,56 @@ func TestOrderValidate(t *testing.T) {
 			wantErr: true,
 			errIs:   ErrInvalidPairFormat,
 		},
+		{
+			name: "valid market buy with quote quantity",
+			order: Order{
+				InstrumentPair: "BTC_BRL",
+				OrderType:      string(OrderTypeBuy),
+				QuoteQuantity:  decimal.RequireFromString("10000"),
+			},
+		},
+		{
+			name: "quote quantity combined with quantity",
+			order: Order{
+				InstrumentPair: "BTC_BRL",
+				OrderType:      string(OrderTypeBuy),
+				Quantity:       decimal.RequireFromString("1"),
+				QuoteQuantity:  decimal.RequireFromString("10000"),
+			},
+			wantErr: true,
+			errIs:   ErrQuoteQuantityWithQuantity,
+		},
+		{
+			name: "quote quantity combined with price",
+			order: Order{
+				InstrumentPair: "BTC_BRL",
+				OrderType:      string(OrderTypeBuy),
+				Price:          decimal.RequireFromString("100"),
+				QuoteQuantity:  decimal.RequireFromString("10000"),
+			},
+			wantErr: true,
+			errIs:   ErrQuoteQuantityWithPrice,
+		},
+		{
+			name: "quote quantity on a sell",
+			order: Order{
+				InstrumentPair: "BTC_BRL",
+				OrderType:      string(OrderTypeSell),
+				QuoteQuantity:  decimal.RequireFromString("10000"),
+			},
+			wantErr: true,
+			errIs:   ErrQuoteQuantityNotBuy,
+		},
+		{
+			name: "negative quote quantity",
+			order: Order{
+				InstrumentPair: "BTC_BRL",
+				OrderType:      string(OrderTypeBuy),
+				QuoteQuantity:  decimal.RequireFromString("-1"),
+			},
+			wantErr: true,
+			errIs:   ErrInvalidQuoteQuantity,
+		},
 	}
 
 	for _, tt := range tests {
@@ -159,6 +243,55 @@ func TestOrderValidate(t *testing.T) {
 	}
 }
 
+func TestOrderValidateAll(t *testing.T) {
+	tests := []struct {
+		name  string
+		order Order
+		want  []error
+	}{
+		{
+			name:  "valid order",
+			order: Order{InstrumentPair: "BTC_BRL", OrderType: string(OrderTypeBuy), Price: decimal.RequireFromString("100"), Quantity: decimal.RequireFromString("1")},
+		},
+		{
+			name:  "bad price and bad pair",
+			order: Order{InstrumentPair: "BTCBRL", OrderType: string(OrderTypeBuy), Price: decimal.Zero, Quantity: decimal.RequireFromString("1")},
+			want:  []error{ErrInvalidPrice, ErrInvalidPairFormat},
+		},
+		{
+			name:  "every limit order field",
+			order: Order{InstrumentPair: "BTC_", OrderType: "HOLD", Price: decimal.NewFromInt(MaxPrice + 1), Quantity: decimal.NewFromInt(MaxQuantity + 1), MinFillQuantity: decimal.NewFromInt(-1)},
+			want:  []error{ErrMaxQuantity, ErrMaxPrice, ErrInvalidMinFill, ErrInvalidOrderType, ErrInvalidPairFormat},
+		},
+		{
+			name:  "market buy",
+			order: Order{InstrumentPair: "BTC_BRL", OrderType: string(OrderTypeSell), QuoteQuantity: decimal.RequireFromString("100"), Price: decimal.RequireFromString("1")},
+			want:  []error{ErrQuoteQuantityNotBuy, ErrQuoteQuantityWithPrice},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			err := tc.order.ValidateAll()
+			if tc.want == nil {
+				assert.NoError(t, err)
+				assert.NoError(t, tc.order.Validate())
+				return
+			}
+
+			joined, ok := err.(interface{ Unwrap() []error })
+			if assert.True(t, ok, "ValidateAll must join its violations") {
+				assert.Equal(t, tc.want, joined.Unwrap())
+			}
+			for _, want := range tc.want {
+				assert.ErrorIs(t, err, want)
+			}
+			// Validate still stops at the first violation.
+			assert.Equal(t, tc.want[0], tc.order.Validate())
+		})
+	}
+}
+
 func TestIsValidInstrumentPair(t *testing.T) {
 	tests := []struct {
 		pair string
@@ -171,6 +304,8 @@ func TestIsValidInstrumentPair(t *testing.T) {
 		{"_BRL", false},
 		{"", false},
 		{"ONE_TWO_THREE", false},
+		{"btc_brl", false},
+		{"BTC_BR L", false},
 	}
 
 	for _, tc := range tests {
@@ -219,8 +354,9 @@ func TestGetRequiredAssetAndAmount(t *testing.T) {
 				Price:          decimal.RequireFromString(tt.price),
 				Quantity:       decimal.RequireFromString(tt.qty),
 			}
-			asset, amount := o.GetRequiredAssetAndAmount()
+			asset, amount, err := o.GetRequiredAssetAndAmount()
 
+			assert.NoError(t, err)
 			assert.Equal(t, tt.wantAsset, asset)
 
 			wantAmount := decimal.RequireFromString(tt.wantAmountStr)
@@ -228,3 +364,104 @@ func TestGetRequiredAssetAndAmount(t *testing.T) {
 		})
 	}
 }
+
+func TestGetRequiredAssetAndAmount_MalformedPair(t *testing.T) {
+	for _, pair := range []string{"BTC", "", "BTC_", "BTC_BRL_USD"} {
+		t.Run(pair, func(t *testing.T) {
+			o := Order{
+				InstrumentPair: pair,
+				OrderType:      string(OrderTypeBuy),
+				Price:          decimal.RequireFromString("1"),
+				Quantity:       decimal.RequireFromString("1"),
+			}
+
+			_, _, err := o.GetRequiredAssetAndAmount()
+			assert.ErrorIs(t, err, ErrInvalidPairFormat)
+		})
+	}
+}
+
+func TestOrderCrosses(t *testing.T) {
+	tests := []struct {
+		name         string
+		orderType    string
+		price        string
+		restingPrice string
+		want         bool
+	}{
+		{name: "buy above resting sell", orderType: string(OrderTypeBuy), price: "101", restingPrice: "100", want: true},
+		{name: "buy at resting sell", orderType: string(OrderTypeBuy), price: "100.00", restingPrice: "100", want: true},
+		{name: "buy below resting sell", orderType: string(OrderTypeBuy), price: "99", restingPrice: "100", want: false},
+		{name: "sell below resting buy", orderType: string(OrderTypeSell), price: "99", restingPrice: "100", want: true},
+		{name: "sell above resting buy", orderType: string(OrderTypeSell), price: "101", restingPrice: "100", want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			o := Order{OrderType: tt.orderType, Price: decimal.RequireFromString(tt.price)}
+			resting := &Order{OrderType: o.OppositeType(), Price: decimal.RequireFromString(tt.restingPrice)}
+
+			assert.Equal(t, tt.want, o.Crosses(resting))
+		})
+	}
+}
+
+func TestOrderReservation(t *testing.T) {
+	tests := []struct {
+		name         string
+		order        Order
+		fills        []string
+		wantAsset    string
+		wantReserved string
+		wantAfter    string
+	}{
+		{
+			name:         "sell reserves base",
+			order:        Order{InstrumentPair: "BTC_BRL", OrderType: string(OrderTypeSell), Price: decimal.RequireFromString("100"), Quantity: decimal.RequireFromString("1")},
+			fills:        []string{"0.4"},
+			wantAsset:    "BTC",
+			wantReserved: "1",
+			wantAfter:    "0.6",
+		},
+		{
+			name:         "buy rounds reservation up",
+			order:        Order{InstrumentPair: "BTC_BRL", OrderType: string(OrderTypeBuy), Price: decimal.RequireFromString("33.33333333"), Quantity: decimal.RequireFromString("0.3")},
+			fills:        []string{"0.1"},
+			wantAsset:    "BRL",
+			wantReserved: "10",
+			wantAfter:    "6.66666667",
+		},
+		{
+			name:         "filled order releases the leftover",
+			order:        Order{InstrumentPair: "BTC_BRL", OrderType: string(OrderTypeBuy), Price: decimal.RequireFromString("33.33333333"), Quantity: decimal.RequireFromString("0.3")},
+			fills:        []string{"0.1", "0.1", "0.1"},
+			wantAsset:    "BRL",
+			wantReserved: "10",
+			wantAfter:    "0",
+		},
+		{
+			name:         "market buy reserves nothing",
+			order:        Order{InstrumentPair: "BTC_BRL", OrderType: string(OrderTypeBuy), QuoteQuantity: decimal.RequireFromString("100"), Quantity: decimal.RequireFromString("1")},
+			fills:        []string{"0.5"},
+			wantReserved: "0",
+			wantAfter:    "0",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			order := tc.order
+			order.RemainingQuantity = order.Quantity
+			assert.NoError(t, order.Reserve())
+			assert.Equal(t, tc.wantAsset, order.ReservedAsset)
+			assert.Equal(t, tc.wantReserved, order.ReservedAmount.String())
+
+			for _, fill := range tc.fills {
+				qty := decimal.RequireFromString(fill)
+				order.RemainingQuantity = order.RemainingQuantity.Sub(qty)
+				order.ConsumeReservation(qty)
+			}
+			assert.Equal(t, tc.wantAfter, order.ReservedAmount.String())
+		})
+	}
+}
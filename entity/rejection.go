@@ -0,0 +1,93 @@
+package entity
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+)
+
+var (
+	ErrWalletNotFound      = NewError("WALLET_NOT_FOUND", http.StatusBadRequest, "wallet not found for required asset")
+	ErrInsufficientBalance = NewError("INSUFFICIENT_BALANCE", http.StatusBadRequest, "insufficient balance")
+)
+
+type RejectionReason string
+
+const (
+	RejectionInvalidOrder        RejectionReason = "INVALID_ORDER"
+	RejectionUnsupportedAsset    RejectionReason = "UNSUPPORTED_ASSET"
+	RejectionSelfCross           RejectionReason = "SELF_CROSS"
+	RejectionWalletNotFound      RejectionReason = "WALLET_NOT_FOUND"
+	RejectionInsufficientBalance RejectionReason = "INSUFFICIENT_BALANCE"
+	RejectionMarketHalted        RejectionReason = "MARKET_HALTED"
+	RejectionMaxNotional         RejectionReason = "MAX_NOTIONAL_EXCEEDED"
+)
+
+var rejectionReasons = []struct {
+	err    error
+	reason RejectionReason
+}{
+	{ErrInvalidPrice, RejectionInvalidOrder},
+	{ErrInvalidQuantity, RejectionInvalidOrder},
+	{ErrInvalidOrderType, RejectionInvalidOrder},
+	{ErrInvalidPairFormat, RejectionInvalidOrder},
+	{ErrMaxQuantity, RejectionInvalidOrder},
+	{ErrMaxPrice, RejectionInvalidOrder},
+	{ErrInvalidMinFill, RejectionInvalidOrder},
+	{ErrInvalidExpiry, RejectionInvalidOrder},
+	{ErrInvalidQuoteQuantity, RejectionInvalidOrder},
+	{ErrQuoteQuantityNotBuy, RejectionInvalidOrder},
+	{ErrQuoteQuantityWithQuantity, RejectionInvalidOrder},
+	{ErrQuoteQuantityWithPrice, RejectionInvalidOrder},
+	{ErrUnsupportedAsset, RejectionUnsupportedAsset},
+	{ErrSelfCross, RejectionSelfCross},
+	{ErrWalletNotFound, RejectionWalletNotFound},
+	{ErrInsufficientBalance, RejectionInsufficientBalance},
+	{ErrMarketHalted, RejectionMarketHalted},
+	{ErrMaxNotionalExceeded, RejectionMaxNotional},
+}
+
+// RejectionReasonFor maps an order creation error to its rejection reason.
+// It returns false for errors that are not a rejection of the order itself,
+// such as database failures.
+func RejectionReasonFor(err error) (RejectionReason, bool) {
+	for _, r := range rejectionReasons {
+		if errors.Is(err, r.err) {
+			return r.reason, true
+		}
+	}
+	return "", false
+}
+
+// OrderRejection records an order that was refused at creation, with the
+// attempted order as submitted and why it was refused.
+type OrderRejection struct {
+	Base
+	AccountID       uuid.UUID       `json:"account_id" gorm:"type:uuid"`
+	InstrumentPair  string          `json:"instrument_pair"`
+	OrderType       string          `json:"order_type"`
+	Price           decimal.Decimal `json:"price" gorm:"type:decimal(20,8)"`
+	Quantity        decimal.Decimal `json:"quantity" gorm:"type:decimal(20,8)"`
+	MinFillQuantity decimal.Decimal `json:"min_fill_quantity" gorm:"type:decimal(20,8)"`
+	Reason          string          `json:"reason"`
+	Message         string          `json:"message"`
+}
+
+func (OrderRejection) TableName() string {
+	return "order_rejection"
+}
+
+func NewOrderRejection(order *Order, reason RejectionReason, err error) *OrderRejection {
+	return &OrderRejection{
+		AccountID:       order.AccountID,
+		InstrumentPair:  order.InstrumentPair,
+		OrderType:       order.OrderType,
+		Price:           order.Price,
+		Quantity:        order.Quantity,
+		MinFillQuantity: order.MinFillQuantity,
+		Reason:          string(reason),
+		Message:         err.Error(),
+	}
+}
@@ -0,0 +1,33 @@
+package entity
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRejectionReasonFor(t *testing.T) {
+	tests := []struct {
+		name       string
+		err        error
+		wantReason RejectionReason
+		wantOK     bool
+	}{
+		{name: "validation error", err: ErrInvalidPrice, wantReason: RejectionInvalidOrder, wantOK: true},
+		{name: "wrapped unsupported asset", err: fmt.Errorf("%w: DOGE", ErrUnsupportedAsset), wantReason: RejectionUnsupportedAsset, wantOK: true},
+		{name: "insufficient balance", err: ErrInsufficientBalance, wantReason: RejectionInsufficientBalance, wantOK: true},
+		{name: "market halted", err: ErrMarketHalted, wantReason: RejectionMarketHalted, wantOK: true},
+		{name: "self cross", err: ErrSelfCross, wantReason: RejectionSelfCross, wantOK: true},
+		{name: "infrastructure error is not a rejection", err: errors.New("connection reset"), wantOK: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reason, ok := RejectionReasonFor(tt.err)
+			assert.Equal(t, tt.wantOK, ok)
+			assert.Equal(t, tt.wantReason, reason)
+		})
+	}
+}
@@ -0,0 +1,37 @@
+package entity
+
+import (
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+)
+
+var ErrMaxNotionalExceeded = NewError("MAX_NOTIONAL_EXCEEDED", http.StatusBadRequest, "order exceeds the account's maximum open notional")
+
+// NotionalLimits caps the notional, price times remaining quantity, an
+// account may have committed to open buy orders in one quote asset. The
+// default applies to every account without an override; a zero limit means
+// no cap.
+type NotionalLimits struct {
+	defaultLimit decimal.Decimal
+	accounts     map[uuid.UUID]decimal.Decimal
+}
+
+func NewNotionalLimits(defaultLimit decimal.Decimal, accounts map[uuid.UUID]decimal.Decimal) *NotionalLimits {
+	return &NotionalLimits{defaultLimit: defaultLimit, accounts: accounts}
+}
+
+// For returns the cap that applies to accountID and whether there is one.
+// A nil NotionalLimits caps nothing.
+func (l *NotionalLimits) For(accountID uuid.UUID) (decimal.Decimal, bool) {
+	if l == nil {
+		return decimal.Zero, false
+	}
+
+	limit, ok := l.accounts[accountID]
+	if !ok {
+		limit = l.defaultLimit
+	}
+	return limit, limit.IsPositive()
+}
@@ -2,30 +2,76 @@ package handler
 
 import (
 	"encoding/json"
+	"errors"
 	"net/http"
+	"time"
 
 	"github.com/google/uuid"
+	"github.com/lucas-moura1/mercadobitcoin-challenge/entity"
+	"github.com/lucas-moura1/mercadobitcoin-challenge/repository"
 	"github.com/lucas-moura1/mercadobitcoin-challenge/usecase"
+	"github.com/shopspring/decimal"
 	"go.uber.org/zap"
 )
 
 type accountHandler struct {
 	log            *zap.SugaredLogger
 	accountUseCase usecase.AccountUseCase
+	instruments    *entity.InstrumentConfig
 }
 
-func NewAccountHandler(log *zap.SugaredLogger, accountUseCase usecase.AccountUseCase) *accountHandler {
-	return &accountHandler{log: log, accountUseCase: accountUseCase}
+func NewAccountHandler(
+	log *zap.SugaredLogger,
+	accountUseCase usecase.AccountUseCase,
+	instruments *entity.InstrumentConfig,
+) *accountHandler {
+	return &accountHandler{log: log, accountUseCase: accountUseCase, instruments: instruments}
 }
 
 type GetAccountBalanceResponse struct {
 	AccountID uuid.UUID       `json:"account_id"`
 	Balances  []*AssetBalance `json:"balances"`
+	// NextCursor is only set on a paged request that has more wallets.
+	NextCursor *string `json:"next_cursor,omitempty"`
 }
 
 type AssetBalance struct {
-	Asset   string `json:"asset"`
+	Asset string `json:"asset"`
+	// Balance is the wallet total, Available plus Reserved.
 	Balance string `json:"balance"`
+	// Available and Reserved split Balance into what new orders can use
+	// and what resting orders hold. Snapshots leave both out.
+	Available *string `json:"available,omitempty"`
+	Reserved  *string `json:"reserved,omitempty"`
+	// TakenAt is set when the balance comes from a snapshot.
+	TakenAt *time.Time `json:"taken_at,omitempty"`
+}
+
+// assetBalance formats wallet as an AssetBalance split by what its account's
+// orders reserve of the asset.
+func (h *accountHandler) assetBalance(wallet *entity.Wallet, reserved map[string]decimal.Decimal) *AssetBalance {
+	held := reserved[wallet.AssetSymbol]
+	available := h.instruments.FormatAmount(wallet.AssetSymbol, wallet.Balance.Sub(held))
+	reservedAmount := h.instruments.FormatAmount(wallet.AssetSymbol, held)
+
+	return &AssetBalance{
+		Asset:     wallet.AssetSymbol,
+		Balance:   h.instruments.FormatAmount(wallet.AssetSymbol, wallet.Balance),
+		Available: &available,
+		Reserved:  &reservedAmount,
+	}
+}
+
+// reservedBalances loads what the account's orders reserve, writing a 500
+// and returning false when it cannot.
+func (h *accountHandler) reservedBalances(w http.ResponseWriter, accountID uuid.UUID) (map[string]decimal.Decimal, bool) {
+	reserved, err := h.accountUseCase.GetReservedBalances(accountID)
+	if err != nil {
+		h.log.Errorw("failed to get reserved balances", "account_id", accountID, "error", err)
+		errorHandler(w, http.StatusInternalServerError, err.Error())
+		return nil, false
+	}
+	return reserved, true
 }
 
 func (h *accountHandler) GetAccountBalance(w http.ResponseWriter, r *http.Request) {
@@ -36,25 +82,43 @@ func (h *accountHandler) GetAccountBalance(w http.ResponseWriter, r *http.Reques
 		return
 	}
 
+	if v := r.URL.Query().Get("at"); v != "" {
+		at, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			h.log.Errorw("invalid at parameter", "at", v, "error", err)
+			errorHandler(w, http.StatusBadRequest, "Invalid at parameter")
+			return
+		}
+		h.getAccountBalanceAt(w, accountID, at)
+		return
+	}
+
+	query := r.URL.Query()
+	if query.Has("limit") || query.Has("cursor") {
+		h.getAccountBalancePage(w, r, accountID)
+		return
+	}
+
 	h.log.Infow("getting account balance", "account_id", accountID)
 
 	wallets, err := h.accountUseCase.GetAccountBalance(accountID)
 	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			errorHandler(w, http.StatusNotFound, "No wallets found")
+			return
+		}
 		errorHandler(w, http.StatusInternalServerError, err.Error())
 		return
 	}
 
-	if wallets == nil {
-		errorHandler(w, http.StatusNotFound, "No wallets found")
+	reserved, ok := h.reservedBalances(w, accountID)
+	if !ok {
 		return
 	}
 
 	balances := make([]*AssetBalance, len(wallets))
 	for i, wallet := range wallets {
-		balances[i] = &AssetBalance{
-			Asset:   wallet.AssetSymbol,
-			Balance: wallet.Balance.String(),
-		}
+		balances[i] = h.assetBalance(wallet, reserved)
 	}
 
 	response := GetAccountBalanceResponse{
@@ -65,3 +129,278 @@ func (h *accountHandler) GetAccountBalance(w http.ResponseWriter, r *http.Reques
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(response)
 }
+
+type GetAccountEquityResponse struct {
+	AccountID uuid.UUID         `json:"account_id"`
+	Quote     string            `json:"quote"`
+	Assets    []*AssetValuation `json:"assets"`
+	Total     string            `json:"total"`
+}
+
+// AssetValuation is one wallet valued in the quote. Price and Value are
+// omitted, and Valued is false, when the asset never traded against it.
+type AssetValuation struct {
+	Asset   string  `json:"asset"`
+	Balance string  `json:"balance"`
+	Valued  bool    `json:"valued"`
+	Price   *string `json:"price,omitempty"`
+	Value   *string `json:"value,omitempty"`
+}
+
+func (h *accountHandler) GetAccountEquity(w http.ResponseWriter, r *http.Request) {
+	accountID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		h.log.Errorw("invalid account id", "error", err)
+		errorHandler(w, http.StatusBadRequest, "Invalid account ID")
+		return
+	}
+	quote := r.URL.Query().Get("quote")
+	if quote == "" {
+		errorHandler(w, http.StatusBadRequest, "quote parameter is required")
+		return
+	}
+
+	h.log.Infow("getting account equity", "account_id", accountID, "quote", quote)
+
+	equity, err := h.accountUseCase.GetAccountEquity(accountID, quote)
+	if err != nil {
+		h.log.Errorw("failed to value account equity", "account_id", accountID, "quote", quote, "error", err)
+		switch {
+		case errors.Is(err, repository.ErrNotFound):
+			errorHandler(w, http.StatusNotFound, "No wallets found")
+		default:
+			domainErrorHandler(w, err, http.StatusInternalServerError)
+		}
+		return
+	}
+
+	assets := make([]*AssetValuation, len(equity.Assets))
+	for i, a := range equity.Assets {
+		assets[i] = &AssetValuation{
+			Asset:   a.AssetSymbol,
+			Balance: h.instruments.FormatAmount(a.AssetSymbol, a.Balance),
+			Valued:  a.Value != nil,
+		}
+		if a.Value != nil {
+			price := h.instruments.FormatPrice(a.AssetSymbol+"_"+equity.Quote, *a.Price)
+			value := h.instruments.FormatAmount(equity.Quote, *a.Value)
+			assets[i].Price = &price
+			assets[i].Value = &value
+		}
+	}
+
+	response := GetAccountEquityResponse{
+		AccountID: accountID,
+		Quote:     equity.Quote,
+		Assets:    assets,
+		Total:     h.instruments.FormatAmount(equity.Quote, equity.Total),
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+type GetAssetBalanceResponse struct {
+	AccountID uuid.UUID `json:"account_id"`
+	*AssetBalance
+}
+
+func (h *accountHandler) GetAssetBalance(w http.ResponseWriter, r *http.Request) {
+	accountID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		h.log.Errorw("invalid account id", "error", err)
+		errorHandler(w, http.StatusBadRequest, "Invalid account ID")
+		return
+	}
+	asset, ok := assetPathValue(w, r)
+	if !ok {
+		return
+	}
+
+	h.log.Infow("getting asset balance", "account_id", accountID, "asset", asset)
+
+	wallet, err := h.accountUseCase.GetAssetBalance(accountID, asset)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			errorHandler(w, http.StatusNotFound, "Wallet not found")
+			return
+		}
+		errorHandler(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	reserved, ok := h.reservedBalances(w, accountID)
+	if !ok {
+		return
+	}
+
+	response := GetAssetBalanceResponse{
+		AccountID:    accountID,
+		AssetBalance: h.assetBalance(wallet, reserved),
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// assetPathValue returns the {asset} path value normalized, since wallets
+// store their symbols uppercase. An invalid symbol is answered with 400.
+func assetPathValue(w http.ResponseWriter, r *http.Request) (string, bool) {
+	asset, err := entity.NormalizeAssetSymbol(r.PathValue("asset"))
+	if err != nil {
+		domainErrorHandler(w, err, http.StatusBadRequest)
+		return "", false
+	}
+	return asset, true
+}
+
+type AdjustBalanceRequest struct {
+	// Amount is signed: positive credits the wallet, negative debits it.
+	Amount string `json:"amount"`
+}
+
+func (h *accountHandler) AdjustBalance(w http.ResponseWriter, r *http.Request) {
+	accountID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		h.log.Errorw("invalid account id", "error", err)
+		errorHandler(w, http.StatusBadRequest, "Invalid account ID")
+		return
+	}
+	asset, ok := assetPathValue(w, r)
+	if !ok {
+		return
+	}
+
+	req := new(AdjustBalanceRequest)
+	if err := decodeJSON(r, req); err != nil {
+		h.log.Errorw("failed to decode request body", "error", err)
+		errorHandler(w, http.StatusBadRequest, invalidBodyMessage(err))
+		return
+	}
+
+	amount, err := parseDecimal(req.Amount)
+	if err != nil {
+		h.log.Errorw("invalid amount format", "error", err)
+		errorHandler(w, http.StatusBadRequest, invalidDecimalMessage(err, "amount", "Invalid amount format"))
+		return
+	}
+
+	wallet, err := h.accountUseCase.AdjustBalance(r.Context(), accountID, asset, amount)
+	if err != nil {
+		h.log.Errorw("failed to adjust wallet balance", "account_id", accountID, "asset", asset, "error", err)
+		switch {
+		case errors.Is(err, repository.ErrNotFound):
+			errorHandler(w, http.StatusNotFound, "Wallet not found")
+		default:
+			domainErrorHandler(w, err, http.StatusInternalServerError)
+		}
+		return
+	}
+
+	reserved, ok := h.reservedBalances(w, accountID)
+	if !ok {
+		return
+	}
+
+	response := GetAssetBalanceResponse{
+		AccountID:    accountID,
+		AssetBalance: h.assetBalance(wallet, reserved),
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+func (h *accountHandler) getAccountBalancePage(w http.ResponseWriter, r *http.Request, accountID uuid.UUID) {
+	limit, err := queryLimit(r)
+	if err != nil {
+		h.log.Errorw("invalid limit parameter", "limit", r.URL.Query().Get("limit"))
+		errorHandler(w, http.StatusBadRequest, "Invalid limit parameter")
+		return
+	}
+	cursor := r.URL.Query().Get("cursor")
+
+	h.log.Infow("getting account balance page", "account_id", accountID, "cursor", cursor, "limit", limit)
+
+	page, err := h.accountUseCase.GetAccountBalancePage(accountID, cursor, limit)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			errorHandler(w, http.StatusNotFound, "No wallets found")
+			return
+		}
+		errorHandler(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	reserved, ok := h.reservedBalances(w, accountID)
+	if !ok {
+		return
+	}
+
+	balances := make([]*AssetBalance, len(page.Wallets))
+	for i, wallet := range page.Wallets {
+		balances[i] = h.assetBalance(wallet, reserved)
+	}
+
+	response := GetAccountBalanceResponse{AccountID: accountID, Balances: balances}
+	if page.NextCursor != "" {
+		response.NextCursor = &page.NextCursor
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+func (h *accountHandler) getAccountBalanceAt(w http.ResponseWriter, accountID uuid.UUID, at time.Time) {
+	h.log.Infow("getting account balance snapshot", "account_id", accountID, "at", at)
+
+	snapshots, err := h.accountUseCase.GetAccountBalanceAt(accountID, at)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			errorHandler(w, http.StatusNotFound, "No balance snapshot found")
+			return
+		}
+		errorHandler(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	balances := make([]*AssetBalance, len(snapshots))
+	for i, snapshot := range snapshots {
+		takenAt := snapshot.TakenAt
+		balances[i] = &AssetBalance{
+			Asset:   snapshot.AssetSymbol,
+			Balance: h.instruments.FormatAmount(snapshot.AssetSymbol, snapshot.Balance),
+			TakenAt: &takenAt,
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(GetAccountBalanceResponse{AccountID: accountID, Balances: balances})
+}
+
+func (h *accountHandler) DeleteAccount(w http.ResponseWriter, r *http.Request) {
+	accountID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		h.log.Errorw("invalid account id", "error", err)
+		errorHandler(w, http.StatusBadRequest, "Invalid account ID")
+		return
+	}
+
+	if signedAccountMismatch(r, accountID) {
+		errorHandler(w, http.StatusForbidden, "API key does not belong to account")
+		return
+	}
+
+	if err := h.accountUseCase.DeleteAccount(r.Context(), accountID); err != nil {
+		h.log.Errorw("failed to delete account", "account_id", accountID, "error", err)
+		switch {
+		case errors.Is(err, repository.ErrNotFound):
+			errorHandler(w, http.StatusNotFound, "Account not found")
+		default:
+			domainErrorHandler(w, err, http.StatusInternalServerError)
+		}
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
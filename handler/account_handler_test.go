@@ -1,12 +1,16 @@
 package handler
 
 import (
+	"encoding/json"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/lucas-moura1/mercadobitcoin-challenge/entity"
+	"github.com/lucas-moura1/mercadobitcoin-challenge/repository"
 	"github.com/lucas-moura1/mercadobitcoin-challenge/usecase"
 	"github.com/shopspring/decimal"
 	"github.com/stretchr/testify/assert"
@@ -56,7 +60,7 @@ func TestAccountHandler_GetAccountBalance(t *testing.T) {
 			pathValue: uuid.New().String(),
 			setupMock: func(m *usecase.MockAccountUseCase, id string) {
 				uid, _ := uuid.Parse(id)
-				m.EXPECT().GetAccountBalance(uid).Return(nil, nil).Times(1)
+				m.EXPECT().GetAccountBalance(uid).Return(nil, repository.ErrNotFound).Times(1)
 			},
 			wantStatus: http.StatusNotFound,
 		},
@@ -69,8 +73,9 @@ func TestAccountHandler_GetAccountBalance(t *testing.T) {
 			defer ctrl.Finish()
 
 			mockUC := usecase.NewMockAccountUseCase(ctrl)
+			mockUC.EXPECT().GetReservedBalances(gomock.Any()).Return(map[string]decimal.Decimal{}, nil).AnyTimes()
 
-			h := NewAccountHandler(zap.NewNop().Sugar(), mockUC)
+			h := NewAccountHandler(zap.NewNop().Sugar(), mockUC, nil)
 
 			req := httptest.NewRequest(http.MethodGet, "/accounts/{id}/balance", nil)
 			req.SetPathValue("id", tt.pathValue)
@@ -83,3 +88,628 @@ func TestAccountHandler_GetAccountBalance(t *testing.T) {
 		})
 	}
 }
+
+func TestAccountHandler_GetAccountBalance_Paged(t *testing.T) {
+	accountID := uuid.New()
+	wallets := []*entity.Wallet{
+		{AccountID: accountID, AssetSymbol: "ADA", Balance: decimal.RequireFromString("1")},
+		{AccountID: accountID, AssetSymbol: "BRL", Balance: decimal.RequireFromString("2")},
+	}
+
+	tests := []struct {
+		name       string
+		query      string
+		setupMock  func(m *usecase.MockAccountUseCase)
+		wantStatus int
+		wantCursor *string
+	}{
+		{
+			name:  "first page returns the next cursor",
+			query: "?limit=2",
+			setupMock: func(m *usecase.MockAccountUseCase) {
+				m.EXPECT().GetAccountBalancePage(accountID, "", 2).
+					Return(&usecase.BalancePage{Wallets: wallets, NextCursor: "BRL"}, nil).Times(1)
+			},
+			wantStatus: http.StatusOK,
+			wantCursor: func() *string { s := "BRL"; return &s }(),
+		},
+		{
+			name:  "last page omits the cursor",
+			query: "?cursor=BRL",
+			setupMock: func(m *usecase.MockAccountUseCase) {
+				m.EXPECT().GetAccountBalancePage(accountID, "BRL", 0).
+					Return(&usecase.BalancePage{Wallets: wallets[1:]}, nil).Times(1)
+			},
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:  "no params returns every wallet unpaged",
+			query: "",
+			setupMock: func(m *usecase.MockAccountUseCase) {
+				m.EXPECT().GetAccountBalance(accountID).Return(wallets, nil).Times(1)
+			},
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "invalid limit returns 400",
+			query:      "?limit=0",
+			setupMock:  func(m *usecase.MockAccountUseCase) {},
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:  "account without wallets returns 404",
+			query: "?limit=2",
+			setupMock: func(m *usecase.MockAccountUseCase) {
+				m.EXPECT().GetAccountBalancePage(accountID, "", 2).Return(nil, repository.ErrNotFound).Times(1)
+			},
+			wantStatus: http.StatusNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockUC := usecase.NewMockAccountUseCase(ctrl)
+			mockUC.EXPECT().GetReservedBalances(gomock.Any()).Return(map[string]decimal.Decimal{}, nil).AnyTimes()
+			tt.setupMock(mockUC)
+			h := NewAccountHandler(zap.NewNop().Sugar(), mockUC, nil)
+
+			req := httptest.NewRequest(http.MethodGet, "/accounts/{id}/balance"+tt.query, nil)
+			req.SetPathValue("id", accountID.String())
+			respWriter := httptest.NewRecorder()
+
+			h.GetAccountBalance(respWriter, req)
+
+			assert.Equal(t, tt.wantStatus, respWriter.Code)
+			if respWriter.Code == http.StatusOK {
+				var resp GetAccountBalanceResponse
+				assert.NoError(t, json.Unmarshal(respWriter.Body.Bytes(), &resp))
+				assert.Equal(t, tt.wantCursor, resp.NextCursor)
+				assert.NotEmpty(t, resp.Balances)
+			}
+		})
+	}
+}
+
+func TestAccountHandler_GetAssetBalance(t *testing.T) {
+	accountID := uuid.New()
+
+	tests := []struct {
+		name        string
+		pathValue   string
+		asset       string
+		setupMock   func(m *usecase.MockAccountUseCase)
+		wantStatus  int
+		wantBalance string
+	}{
+		{
+			name:      "found returns the asset balance",
+			pathValue: accountID.String(),
+			setupMock: func(m *usecase.MockAccountUseCase) {
+				m.EXPECT().GetAssetBalance(accountID, "BTC").Return(&entity.Wallet{
+					AccountID:   accountID,
+					AssetSymbol: "BTC",
+					Balance:     decimal.RequireFromString("0.5"),
+				}, nil).Times(1)
+			},
+			wantStatus:  http.StatusOK,
+			wantBalance: "0.5",
+		},
+		{
+			name:      "lowercase asset is normalized",
+			pathValue: accountID.String(),
+			asset:     "btc",
+			setupMock: func(m *usecase.MockAccountUseCase) {
+				m.EXPECT().GetAssetBalance(accountID, "BTC").Return(&entity.Wallet{
+					AccountID:   accountID,
+					AssetSymbol: "BTC",
+					Balance:     decimal.RequireFromString("0.5"),
+				}, nil).Times(1)
+			},
+			wantStatus:  http.StatusOK,
+			wantBalance: "0.5",
+		},
+		{
+			name:       "invalid asset returns 400",
+			pathValue:  accountID.String(),
+			asset:      "B!",
+			setupMock:  func(m *usecase.MockAccountUseCase) {},
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:      "missing wallet returns 404",
+			pathValue: accountID.String(),
+			setupMock: func(m *usecase.MockAccountUseCase) {
+				m.EXPECT().GetAssetBalance(accountID, "BTC").Return(nil, repository.ErrNotFound).Times(1)
+			},
+			wantStatus: http.StatusNotFound,
+		},
+		{
+			name:       "invalid UUID returns 400",
+			pathValue:  "test",
+			setupMock:  func(m *usecase.MockAccountUseCase) {},
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:      "usecase error returns 500",
+			pathValue: accountID.String(),
+			setupMock: func(m *usecase.MockAccountUseCase) {
+				m.EXPECT().GetAssetBalance(accountID, "BTC").Return(nil, assert.AnError).Times(1)
+			},
+			wantStatus: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockUC := usecase.NewMockAccountUseCase(ctrl)
+			mockUC.EXPECT().GetReservedBalances(gomock.Any()).Return(map[string]decimal.Decimal{}, nil).AnyTimes()
+			tt.setupMock(mockUC)
+
+			h := NewAccountHandler(zap.NewNop().Sugar(), mockUC, nil)
+
+			asset := tt.asset
+			if asset == "" {
+				asset = "BTC"
+			}
+			req := httptest.NewRequest(http.MethodGet, "/accounts/{id}/balance/{asset}", nil)
+			req.SetPathValue("id", tt.pathValue)
+			req.SetPathValue("asset", asset)
+			respWriter := httptest.NewRecorder()
+
+			h.GetAssetBalance(respWriter, req)
+			assert.Equal(t, tt.wantStatus, respWriter.Code)
+
+			if tt.wantStatus == http.StatusOK {
+				var resp GetAssetBalanceResponse
+				assert.NoError(t, json.NewDecoder(respWriter.Body).Decode(&resp))
+				assert.Equal(t, accountID, resp.AccountID)
+				assert.Equal(t, "BTC", resp.Asset)
+				assert.Equal(t, tt.wantBalance, resp.Balance)
+			}
+		})
+	}
+}
+
+func TestAccountHandler_GetAccountEquity(t *testing.T) {
+	accountID := uuid.New()
+	price := decimal.RequireFromString("200000")
+	value := decimal.RequireFromString("100000")
+
+	tests := []struct {
+		name       string
+		query      string
+		setupMock  func(m *usecase.MockAccountUseCase)
+		wantStatus int
+	}{
+		{
+			name:  "values assets with a market and flags the rest",
+			query: "?quote=BRL",
+			setupMock: func(m *usecase.MockAccountUseCase) {
+				m.EXPECT().GetAccountEquity(accountID, "BRL").Return(&usecase.Equity{
+					AccountID: accountID,
+					Quote:     "BRL",
+					Assets: []*usecase.AssetValuation{
+						{AssetSymbol: "BTC", Balance: decimal.RequireFromString("0.5"), Price: &price, Value: &value},
+						{AssetSymbol: "DOGE", Balance: decimal.RequireFromString("300")},
+					},
+					Total: value,
+				}, nil).Times(1)
+			},
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "missing quote returns 400",
+			setupMock:  func(m *usecase.MockAccountUseCase) {},
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:  "invalid quote returns 400",
+			query: "?quote=B",
+			setupMock: func(m *usecase.MockAccountUseCase) {
+				m.EXPECT().GetAccountEquity(accountID, "B").Return(nil, entity.ErrInvalidAssetSymbol).Times(1)
+			},
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:  "no wallets returns 404",
+			query: "?quote=BRL",
+			setupMock: func(m *usecase.MockAccountUseCase) {
+				m.EXPECT().GetAccountEquity(accountID, "BRL").Return(nil, repository.ErrNotFound).Times(1)
+			},
+			wantStatus: http.StatusNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockUC := usecase.NewMockAccountUseCase(ctrl)
+			tt.setupMock(mockUC)
+
+			h := NewAccountHandler(zap.NewNop().Sugar(), mockUC, nil)
+
+			req := httptest.NewRequest(http.MethodGet, "/accounts/{id}/equity"+tt.query, nil)
+			req.SetPathValue("id", accountID.String())
+			respWriter := httptest.NewRecorder()
+
+			h.GetAccountEquity(respWriter, req)
+			assert.Equal(t, tt.wantStatus, respWriter.Code)
+
+			if tt.wantStatus == http.StatusOK {
+				var resp GetAccountEquityResponse
+				assert.NoError(t, json.NewDecoder(respWriter.Body).Decode(&resp))
+				assert.Equal(t, "BRL", resp.Quote)
+				assert.Equal(t, "100000", resp.Total)
+				assert.Len(t, resp.Assets, 2)
+				assert.True(t, resp.Assets[0].Valued)
+				assert.Equal(t, "200000", *resp.Assets[0].Price)
+				assert.Equal(t, "100000", *resp.Assets[0].Value)
+				assert.False(t, resp.Assets[1].Valued)
+				assert.Nil(t, resp.Assets[1].Price)
+				assert.Nil(t, resp.Assets[1].Value)
+			}
+		})
+	}
+}
+
+func TestAccountHandler_GetAccountBalance_InstrumentScale(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	instruments := entity.NewInstrumentConfig(
+		entity.Asset{Symbol: "BTC", Scale: 8},
+		entity.Asset{Symbol: "BRL", Scale: 2},
+	)
+	mockUC := usecase.NewMockAccountUseCase(ctrl)
+	mockUC.EXPECT().GetReservedBalances(gomock.Any()).Return(map[string]decimal.Decimal{}, nil).AnyTimes()
+	h := NewAccountHandler(zap.NewNop().Sugar(), mockUC, instruments)
+
+	accountID := uuid.New()
+	mockUC.EXPECT().GetAccountBalance(accountID).Return([]*entity.Wallet{
+		{AccountID: accountID, AssetSymbol: "BTC", Balance: decimal.RequireFromString("0.5")},
+		{AccountID: accountID, AssetSymbol: "BRL", Balance: decimal.RequireFromString("1000")},
+	}, nil).Times(1)
+
+	req := httptest.NewRequest(http.MethodGet, "/accounts/{id}/balance", nil)
+	req.SetPathValue("id", accountID.String())
+	respWriter := httptest.NewRecorder()
+
+	h.GetAccountBalance(respWriter, req)
+
+	assert.Equal(t, http.StatusOK, respWriter.Code)
+	var resp GetAccountBalanceResponse
+	assert.NoError(t, json.Unmarshal(respWriter.Body.Bytes(), &resp))
+	if assert.Len(t, resp.Balances, 2) {
+		assert.Equal(t, "0.50000000", resp.Balances[0].Balance)
+		assert.Equal(t, "1000.00", resp.Balances[1].Balance)
+	}
+}
+
+func TestAccountHandler_GetAccountBalance_Reserved(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	instruments := entity.NewInstrumentConfig(
+		entity.Asset{Symbol: "BTC", Scale: 8},
+		entity.Asset{Symbol: "BRL", Scale: 2},
+	)
+	mockUC := usecase.NewMockAccountUseCase(ctrl)
+	h := NewAccountHandler(zap.NewNop().Sugar(), mockUC, instruments)
+
+	accountID := uuid.New()
+	mockUC.EXPECT().GetAccountBalance(accountID).Return([]*entity.Wallet{
+		{AccountID: accountID, AssetSymbol: "BRL", Balance: decimal.RequireFromString("1000")},
+		{AccountID: accountID, AssetSymbol: "BTC", Balance: decimal.RequireFromString("0.5")},
+	}, nil).Times(1)
+	mockUC.EXPECT().GetReservedBalances(accountID).Return(map[string]decimal.Decimal{
+		"BRL": decimal.RequireFromString("140"),
+	}, nil).Times(1)
+
+	req := httptest.NewRequest(http.MethodGet, "/accounts/{id}/balance", nil)
+	req.SetPathValue("id", accountID.String())
+	respWriter := httptest.NewRecorder()
+
+	h.GetAccountBalance(respWriter, req)
+
+	assert.Equal(t, http.StatusOK, respWriter.Code)
+	var resp GetAccountBalanceResponse
+	assert.NoError(t, json.Unmarshal(respWriter.Body.Bytes(), &resp))
+	if assert.Len(t, resp.Balances, 2) {
+		brl, btc := resp.Balances[0], resp.Balances[1]
+		assert.Equal(t, "1000.00", brl.Balance)
+		assert.Equal(t, "860.00", *brl.Available)
+		assert.Equal(t, "140.00", *brl.Reserved)
+		assert.Equal(t, "0.50000000", btc.Balance)
+		assert.Equal(t, "0.50000000", *btc.Available)
+		assert.Equal(t, "0.00000000", *btc.Reserved)
+	}
+}
+
+func TestAccountHandler_GetAccountBalance_ReservedError(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockUC := usecase.NewMockAccountUseCase(ctrl)
+	h := NewAccountHandler(zap.NewNop().Sugar(), mockUC, nil)
+
+	accountID := uuid.New()
+	mockUC.EXPECT().GetAccountBalance(accountID).Return([]*entity.Wallet{
+		{AccountID: accountID, AssetSymbol: "BRL", Balance: decimal.RequireFromString("1000")},
+	}, nil).Times(1)
+	mockUC.EXPECT().GetReservedBalances(accountID).Return(nil, assert.AnError).Times(1)
+
+	req := httptest.NewRequest(http.MethodGet, "/accounts/{id}/balance", nil)
+	req.SetPathValue("id", accountID.String())
+	respWriter := httptest.NewRecorder()
+
+	h.GetAccountBalance(respWriter, req)
+
+	assert.Equal(t, http.StatusInternalServerError, respWriter.Code)
+}
+
+func TestAccountHandler_GetAccountBalance_At(t *testing.T) {
+	accountID := uuid.New()
+	takenAt := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	at := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name       string
+		query      string
+		setupMock  func(m *usecase.MockAccountUseCase)
+		wantStatus int
+	}{
+		{
+			name:  "returns snapshot balances",
+			query: "?at=2024-01-01T12:00:00Z",
+			setupMock: func(m *usecase.MockAccountUseCase) {
+				m.EXPECT().GetAccountBalanceAt(accountID, at).Return([]*entity.WalletSnapshot{
+					{AccountID: accountID, AssetSymbol: "BRL", Balance: decimal.RequireFromString("100"), TakenAt: takenAt},
+				}, nil).Times(1)
+			},
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "invalid at returns 400",
+			query:      "?at=yesterday",
+			setupMock:  func(m *usecase.MockAccountUseCase) {},
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:  "no snapshot returns 404",
+			query: "?at=2024-01-01T12:00:00Z",
+			setupMock: func(m *usecase.MockAccountUseCase) {
+				m.EXPECT().GetAccountBalanceAt(accountID, at).Return(nil, repository.ErrNotFound).Times(1)
+			},
+			wantStatus: http.StatusNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockUC := usecase.NewMockAccountUseCase(ctrl)
+			h := NewAccountHandler(zap.NewNop().Sugar(), mockUC, entity.NewInstrumentConfig(entity.Asset{Symbol: "BRL", Scale: 2}))
+
+			tt.setupMock(mockUC)
+
+			req := httptest.NewRequest(http.MethodGet, "/accounts/{id}/balance"+tt.query, nil)
+			req.SetPathValue("id", accountID.String())
+			respWriter := httptest.NewRecorder()
+
+			h.GetAccountBalance(respWriter, req)
+
+			assert.Equal(t, tt.wantStatus, respWriter.Code)
+			if respWriter.Code == http.StatusOK {
+				var resp GetAccountBalanceResponse
+				assert.NoError(t, json.Unmarshal(respWriter.Body.Bytes(), &resp))
+				if assert.Len(t, resp.Balances, 1) {
+					assert.Equal(t, "100.00", resp.Balances[0].Balance)
+					assert.Equal(t, takenAt, *resp.Balances[0].TakenAt)
+				}
+			}
+		})
+	}
+}
+
+func TestAccountHandler_DeleteAccount(t *testing.T) {
+	uid := uuid.New()
+
+	tests := []struct {
+		name       string
+		pathValue  string
+		setupMock  func(m *usecase.MockAccountUseCase)
+		wantStatus int
+	}{
+		{
+			name:      "success returns 204",
+			pathValue: uid.String(),
+			setupMock: func(m *usecase.MockAccountUseCase) {
+				m.EXPECT().DeleteAccount(gomock.Any(), uid).Return(nil).Times(1)
+			},
+			wantStatus: http.StatusNoContent,
+		},
+		{
+			name:       "invalid UUID returns 400",
+			pathValue:  "invalid",
+			setupMock:  func(m *usecase.MockAccountUseCase) {},
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:      "unknown account returns 404",
+			pathValue: uid.String(),
+			setupMock: func(m *usecase.MockAccountUseCase) {
+				m.EXPECT().DeleteAccount(gomock.Any(), uid).Return(repository.ErrNotFound).Times(1)
+			},
+			wantStatus: http.StatusNotFound,
+		},
+		{
+			name:      "nonzero balance returns 409",
+			pathValue: uid.String(),
+			setupMock: func(m *usecase.MockAccountUseCase) {
+				m.EXPECT().DeleteAccount(gomock.Any(), uid).Return(entity.ErrAccountHasBalance).Times(1)
+			},
+			wantStatus: http.StatusConflict,
+		},
+		{
+			name:      "open orders returns 409",
+			pathValue: uid.String(),
+			setupMock: func(m *usecase.MockAccountUseCase) {
+				m.EXPECT().DeleteAccount(gomock.Any(), uid).Return(entity.ErrAccountHasOpenOrders).Times(1)
+			},
+			wantStatus: http.StatusConflict,
+		},
+		{
+			name:      "usecase error returns 500",
+			pathValue: uid.String(),
+			setupMock: func(m *usecase.MockAccountUseCase) {
+				m.EXPECT().DeleteAccount(gomock.Any(), uid).Return(assert.AnError).Times(1)
+			},
+			wantStatus: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockUC := usecase.NewMockAccountUseCase(ctrl)
+			h := NewAccountHandler(zap.NewNop().Sugar(), mockUC, nil)
+
+			tt.setupMock(mockUC)
+
+			req := httptest.NewRequest(http.MethodDelete, "/accounts/{id}", nil)
+			req.SetPathValue("id", tt.pathValue)
+			respWriter := httptest.NewRecorder()
+
+			h.DeleteAccount(respWriter, req)
+
+			assert.Equal(t, tt.wantStatus, respWriter.Code)
+		})
+	}
+}
+
+func TestAccountHandler_AdjustBalance(t *testing.T) {
+	accountID := uuid.New()
+
+	tests := []struct {
+		name        string
+		asset       string
+		body        string
+		setupMock   func(m *usecase.MockAccountUseCase)
+		wantStatus  int
+		wantBalance string
+	}{
+		{
+			name: "credit returns the new balance",
+			body: `{"amount":"25.5"}`,
+			setupMock: func(m *usecase.MockAccountUseCase) {
+				m.EXPECT().AdjustBalance(gomock.Any(), accountID, "BRL", decimal.RequireFromString("25.5")).Return(&entity.Wallet{
+					AccountID:   accountID,
+					AssetSymbol: "BRL",
+					Balance:     decimal.RequireFromString("125.5"),
+				}, nil).Times(1)
+			},
+			wantStatus:  http.StatusOK,
+			wantBalance: "125.5",
+		},
+		{
+			name:  "lowercase asset is normalized",
+			asset: "brl",
+			body:  `{"amount":"25.5"}`,
+			setupMock: func(m *usecase.MockAccountUseCase) {
+				m.EXPECT().AdjustBalance(gomock.Any(), accountID, "BRL", decimal.RequireFromString("25.5")).Return(&entity.Wallet{
+					AccountID:   accountID,
+					AssetSymbol: "BRL",
+					Balance:     decimal.RequireFromString("125.5"),
+				}, nil).Times(1)
+			},
+			wantStatus:  http.StatusOK,
+			wantBalance: "125.5",
+		},
+		{
+			name:       "invalid asset returns 400",
+			asset:      "Bitcoin Cash",
+			body:       `{"amount":"1"}`,
+			setupMock:  func(m *usecase.MockAccountUseCase) {},
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name: "over-debit returns 400",
+			body: `{"amount":"-1000"}`,
+			setupMock: func(m *usecase.MockAccountUseCase) {
+				m.EXPECT().AdjustBalance(gomock.Any(), accountID, "BRL", decimal.RequireFromString("-1000")).Return(nil, entity.ErrInsufficientBalance).Times(1)
+			},
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name: "missing wallet returns 404",
+			body: `{"amount":"1"}`,
+			setupMock: func(m *usecase.MockAccountUseCase) {
+				m.EXPECT().AdjustBalance(gomock.Any(), accountID, "BRL", decimal.RequireFromString("1")).Return(nil, repository.ErrNotFound).Times(1)
+			},
+			wantStatus: http.StatusNotFound,
+		},
+		{
+			name:       "malformed amount returns 400",
+			body:       `{"amount":"ten"}`,
+			setupMock:  func(m *usecase.MockAccountUseCase) {},
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "extreme exponent amount returns 400",
+			body:       `{"amount":"1e1000000"}`,
+			setupMock:  func(m *usecase.MockAccountUseCase) {},
+			wantStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockUC := usecase.NewMockAccountUseCase(ctrl)
+			mockUC.EXPECT().GetReservedBalances(gomock.Any()).Return(map[string]decimal.Decimal{}, nil).AnyTimes()
+			tt.setupMock(mockUC)
+
+			h := NewAccountHandler(zap.NewNop().Sugar(), mockUC, nil)
+
+			asset := tt.asset
+			if asset == "" {
+				asset = "BRL"
+			}
+			req := httptest.NewRequest(http.MethodPost, "/admin/accounts/{id}/wallets/{asset}/adjust", strings.NewReader(tt.body))
+			req.SetPathValue("id", accountID.String())
+			req.SetPathValue("asset", asset)
+			respWriter := httptest.NewRecorder()
+
+			h.AdjustBalance(respWriter, req)
+
+			assert.Equal(t, tt.wantStatus, respWriter.Code)
+			if tt.wantStatus != http.StatusOK {
+				return
+			}
+
+			var resp GetAssetBalanceResponse
+			assert.NoError(t, json.Unmarshal(respWriter.Body.Bytes(), &resp))
+			assert.Equal(t, tt.wantBalance, resp.Balance)
+		})
+	}
+}
@@ -0,0 +1,21 @@
+package handler
+
+import (
+	"crypto/subtle"
+	"net/http"
+)
+
+const AdminTokenHeader = "X-Admin-Token"
+
+// RequireAdminToken only lets the request through when it carries the
+// configured admin token. An empty token disables every admin route.
+func RequireAdminToken(token string, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		provided := r.Header.Get(AdminTokenHeader)
+		if token == "" || subtle.ConstantTimeCompare([]byte(provided), []byte(token)) != 1 {
+			errorHandler(w, http.StatusUnauthorized, "Unauthorized")
+			return
+		}
+		next(w, r)
+	}
+}
@@ -0,0 +1,21 @@
+package handler
+
+import (
+	"mime"
+	"net/http"
+	"strings"
+)
+
+const csvContentType = "text/csv"
+
+// acceptsCSV reports whether the client asked for CSV through the Accept
+// header. Anything else, including no header, gets the JSON default.
+func acceptsCSV(r *http.Request) bool {
+	for _, part := range strings.Split(r.Header.Get("Accept"), ",") {
+		mediaType, _, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err == nil && mediaType == csvContentType {
+			return true
+		}
+	}
+	return false
+}
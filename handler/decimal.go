@@ -0,0 +1,53 @@
+package handler
+
+import (
+	"errors"
+
+	"github.com/shopspring/decimal"
+)
+
+const (
+	// maxDecimalLength caps the length of a numeric string before it is
+	// parsed, so a huge coefficient is never built.
+	maxDecimalLength = 64
+	// maxDecimalExponent bounds both the integer digits and the decimal
+	// places of a parsed value. Anything outside it is far beyond what the
+	// decimal(20,8) columns hold, and values like 1e1000000 or 1e-1000000
+	// would make every later rescale or String call allocate millions of
+	// digits.
+	maxDecimalExponent = 32
+)
+
+var errDecimalOutOfRange = errors.New("decimal out of range")
+
+// parseDecimal parses a numeric request field. Malformed strings, including
+// NaN and Inf, fail as decimal.NewFromString does; finite values that are
+// too long or whose magnitude or scale is beyond maxDecimalExponent fail
+// with errDecimalOutOfRange.
+func parseDecimal(s string) (decimal.Decimal, error) {
+	if len(s) > maxDecimalLength {
+		return decimal.Zero, errDecimalOutOfRange
+	}
+
+	d, err := decimal.NewFromString(s)
+	if err != nil {
+		return decimal.Zero, err
+	}
+
+	// Compare in int64 so the exponent sum cannot overflow int32.
+	exp := int64(d.Exponent())
+	if exp < -maxDecimalExponent || int64(d.NumDigits())+exp > maxDecimalExponent {
+		return decimal.Zero, errDecimalOutOfRange
+	}
+	return d, nil
+}
+
+// invalidDecimalMessage is the 400 message for a field parseDecimal
+// rejected: an out of range value names the field, anything else gets the
+// field's usual format message.
+func invalidDecimalMessage(err error, field, format string) string {
+	if errors.Is(err, errDecimalOutOfRange) {
+		return field + " is out of range"
+	}
+	return format
+}
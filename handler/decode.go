@@ -0,0 +1,40 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"sync/atomic"
+)
+
+// maxJSONBodyBytes is the largest body read for a request that carries a
+// single JSON object. Batch endpoints such as the order import set their own.
+const maxJSONBodyBytes = 64 << 10
+
+var strictJSON atomic.Bool
+
+// SetStrictJSON makes request bodies with fields the API does not accept
+// fail with a 400 naming the field, instead of the field being ignored. It
+// is off by default so existing clients that send extra fields keep working.
+func SetStrictJSON(strict bool) {
+	strictJSON.Store(strict)
+}
+
+// decodeJSON decodes the request body into v, rejecting unknown fields when
+// strict decoding is on.
+func decodeJSON(r *http.Request, v any) error {
+	decoder := json.NewDecoder(r.Body)
+	if strictJSON.Load() {
+		decoder.DisallowUnknownFields()
+	}
+	return decoder.Decode(v)
+}
+
+// invalidBodyMessage is the 400 message for a body decodeJSON rejected. An
+// unknown field is named so the client can spot the typo.
+func invalidBodyMessage(err error) string {
+	if field, ok := strings.CutPrefix(err.Error(), "json: unknown field "); ok {
+		return "Unknown field " + field
+	}
+	return "Invalid request body"
+}
@@ -2,10 +2,149 @@ package handler
 
 import (
 	"encoding/json"
+	"mime"
 	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/lucas-moura1/mercadobitcoin-challenge/entity"
+)
+
+// ErrorResponse is the JSON body of an error. Code is set for domain errors
+// (see entity.Error) so clients can branch on it rather than on the message.
+type ErrorResponse struct {
+	Error string `json:"error"`
+	Code  string `json:"code,omitempty"`
+}
+
+// ValidationErrorResponse reports every rule an order violates. Error is the
+// first of them, as in any other error response, so clients reading only
+// "error" see what they always did.
+type ValidationErrorResponse struct {
+	Error  string   `json:"error"`
+	Errors []string `json:"errors"`
+}
+
+const (
+	jsonContentType      = "application/json"
+	plainTextContentType = "text/plain; charset=utf-8"
 )
 
+// errorHandler writes the error response: {"error": err} as JSON, or err as
+// plain text when the client asked for it (see NegotiateErrors). The content
+// type is set before the status, and the status before the body.
 func errorHandler(w http.ResponseWriter, status int, err string) {
+	writeError(w, status, ErrorResponse{Error: err})
+}
+
+// domainErrorHandler answers with the status and code of the entity.Error in
+// err's chain, so handlers need not list which domain errors mean what.
+// Errors that are not domain errors get fallback.
+func domainErrorHandler(w http.ResponseWriter, err error, fallback int) {
+	domainErr, ok := entity.AsError(err)
+	if !ok {
+		errorHandler(w, fallback, err.Error())
+		return
+	}
+	writeError(w, domainErr.Status, ErrorResponse{Error: err.Error(), Code: domainErr.Code})
+}
+
+func writeError(w http.ResponseWriter, status int, response ErrorResponse) {
+	if wantsPlainErrors(w) {
+		writePlainError(w, status, response.Error)
+		return
+	}
+
+	w.Header().Set("Content-Type", jsonContentType)
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(response)
+}
+
+// validationErrorHandler writes a 400 listing each violation joined in err,
+// as returned by entity.Order.ValidateAll. As plain text, each violation is
+// on its own line.
+func validationErrorHandler(w http.ResponseWriter, err error) {
+	violations := []error{err}
+	if joined, ok := err.(interface{ Unwrap() []error }); ok {
+		violations = joined.Unwrap()
+	}
+
+	response := ValidationErrorResponse{Errors: make([]string, len(violations))}
+	for i, violation := range violations {
+		response.Errors[i] = violation.Error()
+	}
+	response.Error = response.Errors[0]
+
+	if wantsPlainErrors(w) {
+		writePlainError(w, http.StatusBadRequest, strings.Join(response.Errors, "\n"))
+		return
+	}
+
+	w.Header().Set("Content-Type", jsonContentType)
+	w.WriteHeader(http.StatusBadRequest)
+	json.NewEncoder(w).Encode(response)
+}
+
+func writePlainError(w http.ResponseWriter, status int, err string) {
+	w.Header().Set("Content-Type", plainTextContentType)
+	w.Header().Set("X-Content-Type-Options", "nosniff")
 	w.WriteHeader(status)
-	json.NewEncoder(w).Encode(map[string]string{"error": err})
+	w.Write([]byte(err + "\n"))
+}
+
+// plainErrorWriter marks a response whose errors are written as plain text.
+type plainErrorWriter struct {
+	http.ResponseWriter
+}
+
+func wantsPlainErrors(w http.ResponseWriter) bool {
+	_, ok := w.(*plainErrorWriter)
+	return ok
+}
+
+// NegotiateErrors has errors written as plain text for clients whose Accept
+// header prefers text/plain to application/json; everyone else gets JSON.
+// Successful responses are not affected. It must wrap the handler directly,
+// inside any middleware that replaces the ResponseWriter, as WithTimeout
+// does.
+func NegotiateErrors(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if prefersPlainText(r) && !wantsPlainErrors(w) {
+			w = &plainErrorWriter{ResponseWriter: w}
+		}
+		next(w, r)
+	}
+}
+
+// prefersPlainText reports whether the Accept header ranks text/plain above
+// application/json. A missing header, */* or equal weights mean JSON.
+func prefersPlainText(r *http.Request) bool {
+	accept := r.Header.Get("Accept")
+	if accept == "" {
+		return false
+	}
+
+	var plain, json float64
+	for _, part := range strings.Split(accept, ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+
+		q := 1.0
+		if raw, ok := params["q"]; ok {
+			if q, err = strconv.ParseFloat(raw, 64); err != nil {
+				continue
+			}
+		}
+
+		switch mediaType {
+		case "text/plain", "text/*":
+			plain = max(plain, q)
+		case jsonContentType, "application/*":
+			json = max(json, q)
+		}
+	}
+
+	return plain > json
 }
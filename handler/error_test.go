@@ -0,0 +1,216 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/lucas-moura1/mercadobitcoin-challenge/entity"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestErrorHandler(t *testing.T) {
+	tests := []struct {
+		name            string
+		accept          string
+		wantContentType string
+		wantBody        string
+	}{
+		{
+			name:            "no accept header gets json",
+			wantContentType: "application/json",
+			wantBody:        `{"error":"Order not found"}` + "\n",
+		},
+		{
+			name:            "any type gets json",
+			accept:          "*/*",
+			wantContentType: "application/json",
+			wantBody:        `{"error":"Order not found"}` + "\n",
+		},
+		{
+			name:            "json preferred over plain text",
+			accept:          "application/json, text/plain;q=0.5",
+			wantContentType: "application/json",
+			wantBody:        `{"error":"Order not found"}` + "\n",
+		},
+		{
+			name:            "equal weights get json",
+			accept:          "text/plain, application/json",
+			wantContentType: "application/json",
+			wantBody:        `{"error":"Order not found"}` + "\n",
+		},
+		{
+			name:            "plain text",
+			accept:          "text/plain",
+			wantContentType: "text/plain; charset=utf-8",
+			wantBody:        "Order not found\n",
+		},
+		{
+			name:            "plain text preferred over json",
+			accept:          "application/json;q=0.8, text/plain",
+			wantContentType: "text/plain; charset=utf-8",
+			wantBody:        "Order not found\n",
+		},
+		{
+			name:            "any text",
+			accept:          "text/*",
+			wantContentType: "text/plain; charset=utf-8",
+			wantBody:        "Order not found\n",
+		},
+		{
+			name:            "malformed entries are ignored",
+			accept:          "text/plain;q=abc, ;;",
+			wantContentType: "application/json",
+			wantBody:        `{"error":"Order not found"}` + "\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := NegotiateErrors(func(w http.ResponseWriter, r *http.Request) {
+				errorHandler(w, http.StatusNotFound, "Order not found")
+			})
+
+			req := httptest.NewRequest(http.MethodGet, "/orders/id/1/fills", nil)
+			if tt.accept != "" {
+				req.Header.Set("Accept", tt.accept)
+			}
+			rw := httptest.NewRecorder()
+
+			h(rw, req)
+
+			assert.Equal(t, http.StatusNotFound, rw.Code)
+			assert.Equal(t, tt.wantContentType, rw.Header().Get("Content-Type"))
+			assert.Equal(t, tt.wantBody, rw.Body.String())
+		})
+	}
+}
+
+// headerOrderRecorder records the order in which the content type, the
+// status and the body are written.
+type headerOrderRecorder struct {
+	*httptest.ResponseRecorder
+	statusWritten       bool
+	contentTypeAtStatus string
+	wroteBodyFirst      bool
+}
+
+func (r *headerOrderRecorder) WriteHeader(status int) {
+	r.statusWritten = true
+	r.contentTypeAtStatus = r.Header().Get("Content-Type")
+	r.ResponseRecorder.WriteHeader(status)
+}
+
+func (r *headerOrderRecorder) Write(b []byte) (int, error) {
+	if !r.statusWritten {
+		r.wroteBodyFirst = true
+	}
+	return r.ResponseRecorder.Write(b)
+}
+
+func TestErrorHandler_StatusBeforeBody(t *testing.T) {
+	for _, accept := range []string{"application/json", "text/plain"} {
+		t.Run(accept, func(t *testing.T) {
+			rw := &headerOrderRecorder{ResponseRecorder: httptest.NewRecorder()}
+			req := httptest.NewRequest(http.MethodGet, "/time", nil)
+			req.Header.Set("Accept", accept)
+
+			NegotiateErrors(func(w http.ResponseWriter, r *http.Request) {
+				errorHandler(w, http.StatusConflict, "order is already filled")
+			})(rw, req)
+
+			assert.False(t, rw.wroteBodyFirst, "body written before the status")
+			assert.Equal(t, http.StatusConflict, rw.Code)
+			assert.NotEmpty(t, rw.contentTypeAtStatus, "content type set after the status")
+		})
+	}
+}
+
+func TestValidationErrorHandler(t *testing.T) {
+	err := errors.Join(errors.New("price must be greater than zero"), errors.New("invalid instrument pair format"))
+
+	t.Run("json", func(t *testing.T) {
+		rw := httptest.NewRecorder()
+		validationErrorHandler(rw, err)
+
+		assert.Equal(t, http.StatusBadRequest, rw.Code)
+		assert.Equal(t, "application/json", rw.Header().Get("Content-Type"))
+		assert.JSONEq(t, `{"error":"price must be greater than zero","errors":["price must be greater than zero","invalid instrument pair format"]}`, rw.Body.String())
+	})
+
+	t.Run("plain text", func(t *testing.T) {
+		rw := httptest.NewRecorder()
+		validationErrorHandler(&plainErrorWriter{ResponseWriter: rw}, err)
+
+		assert.Equal(t, http.StatusBadRequest, rw.Code)
+		assert.Equal(t, "text/plain; charset=utf-8", rw.Header().Get("Content-Type"))
+		assert.Equal(t, "price must be greater than zero\ninvalid instrument pair format\n", rw.Body.String())
+	})
+}
+
+func TestDomainErrorHandler(t *testing.T) {
+	tests := []struct {
+		name       string
+		err        error
+		plain      bool
+		wantStatus int
+		wantBody   string
+	}{
+		{
+			name:       "domain error gets its status and code",
+			err:        entity.ErrOrderNotOpen,
+			wantStatus: http.StatusConflict,
+			wantBody:   `{"error":"order is not open","code":"ORDER_NOT_OPEN"}` + "\n",
+		},
+		{
+			name:       "wrapped domain error keeps the wrapping message",
+			err:        fmt.Errorf("failed to cancel: %w", entity.ErrMarketHalted),
+			wantStatus: http.StatusServiceUnavailable,
+			wantBody:   `{"error":"failed to cancel: ` + entity.ErrMarketHalted.Error() + `","code":"MARKET_HALTED"}` + "\n",
+		},
+		{
+			name:       "other errors get the fallback without a code",
+			err:        errors.New("connection refused"),
+			wantStatus: http.StatusInternalServerError,
+			wantBody:   `{"error":"connection refused"}` + "\n",
+		},
+		{
+			name:       "plain text carries only the message",
+			err:        entity.ErrInvalidSignature,
+			plain:      true,
+			wantStatus: http.StatusUnauthorized,
+			wantBody:   entity.ErrInvalidSignature.Error() + "\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rw := httptest.NewRecorder()
+			var w http.ResponseWriter = rw
+			if tt.plain {
+				w = &plainErrorWriter{ResponseWriter: rw}
+			}
+
+			domainErrorHandler(w, tt.err, http.StatusInternalServerError)
+
+			assert.Equal(t, tt.wantStatus, rw.Code)
+			assert.Equal(t, tt.wantBody, rw.Body.String())
+		})
+	}
+}
+
+func TestNewRouter_NegotiatesErrorsOutsideTimeout(t *testing.T) {
+	router := NewRouter(RouterConfig{Maintenance: NewMaintenance(true)})
+
+	req := httptest.NewRequest(http.MethodPost, "/orders", nil)
+	req.Header.Set("Accept", "text/plain")
+	rw := httptest.NewRecorder()
+
+	router.ServeHTTP(rw, req)
+
+	assert.Equal(t, http.StatusServiceUnavailable, rw.Code)
+	assert.Equal(t, "text/plain; charset=utf-8", rw.Header().Get("Content-Type"))
+	assert.Equal(t, "Order placement is paused for maintenance\n", rw.Body.String())
+}
@@ -0,0 +1,54 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/lucas-moura1/mercadobitcoin-challenge/usecase"
+	"go.uber.org/zap"
+)
+
+type eventHandler struct {
+	log          *zap.SugaredLogger
+	eventUseCase usecase.EventUseCase
+}
+
+func NewEventHandler(log *zap.SugaredLogger, eventUseCase usecase.EventUseCase) *eventHandler {
+	return &eventHandler{log: log, eventUseCase: eventUseCase}
+}
+
+func (h *eventHandler) GetEvents(w http.ResponseWriter, r *http.Request) {
+	var since int64
+	if v := r.URL.Query().Get("since"); v != "" {
+		parsed, err := strconv.ParseInt(v, 10, 64)
+		if err != nil || parsed < 0 {
+			h.log.Errorw("invalid since parameter", "since", v)
+			errorHandler(w, http.StatusBadRequest, "Invalid since parameter")
+			return
+		}
+		since = parsed
+	}
+
+	limit, err := queryLimit(r)
+	if err != nil {
+		h.log.Errorw("invalid limit parameter", "limit", r.URL.Query().Get("limit"))
+		errorHandler(w, http.StatusBadRequest, "Invalid limit parameter")
+		return
+	}
+
+	events, err := h.eventUseCase.GetEventsSince(since, limit)
+	if err != nil {
+		h.log.Errorw("failed to get events", "since", since, "error", err)
+		errorHandler(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	// The log is append-only, so the cursor always points past the last event
+	// seen and consumers keep polling from it.
+	lastSequence := since
+	if len(events) > 0 {
+		lastSequence = events[len(events)-1].Sequence
+	}
+
+	writeList(w, events, strconv.FormatInt(lastSequence, 10))
+}
@@ -0,0 +1,133 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/lucas-moura1/mercadobitcoin-challenge/entity"
+	"github.com/lucas-moura1/mercadobitcoin-challenge/usecase"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+	"go.uber.org/zap"
+)
+
+func TestEventHandler_GetEvents(t *testing.T) {
+	tests := []struct {
+		name             string
+		query            string
+		setupMock        func(m *usecase.MockEventUseCase)
+		wantStatus       int
+		wantLastSequence string
+		wantCount        int
+	}{
+		{
+			name:  "success returns events after since",
+			query: "?since=4&limit=2",
+			setupMock: func(m *usecase.MockEventUseCase) {
+				m.EXPECT().GetEventsSince(int64(4), 2).Return([]*entity.Event{
+					{Sequence: 5, EventType: string(entity.EventTypeOrderCreated), AggregateID: uuid.New(), Payload: []byte(`{}`)},
+					{Sequence: 6, EventType: string(entity.EventTypeTradeExecuted), AggregateID: uuid.New(), Payload: []byte(`{}`)},
+				}, nil).Times(1)
+			},
+			wantStatus:       http.StatusOK,
+			wantLastSequence: "6",
+			wantCount:        2,
+		},
+		{
+			name:  "no new events keeps since as last sequence",
+			query: "?since=9",
+			setupMock: func(m *usecase.MockEventUseCase) {
+				m.EXPECT().GetEventsSince(int64(9), 0).Return(nil, nil).Times(1)
+			},
+			wantStatus:       http.StatusOK,
+			wantLastSequence: "9",
+			wantCount:        0,
+		},
+		{
+			name:       "invalid since returns 400",
+			query:      "?since=abc",
+			setupMock:  func(m *usecase.MockEventUseCase) {},
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "invalid limit returns 400",
+			query:      "?limit=-1",
+			setupMock:  func(m *usecase.MockEventUseCase) {},
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:  "usecase error returns 500",
+			query: "",
+			setupMock: func(m *usecase.MockEventUseCase) {
+				m.EXPECT().GetEventsSince(int64(0), 0).Return(nil, assert.AnError).Times(1)
+			},
+			wantStatus: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockUC := usecase.NewMockEventUseCase(ctrl)
+			h := NewEventHandler(zap.NewNop().Sugar(), mockUC)
+
+			tt.setupMock(mockUC)
+
+			req := httptest.NewRequest(http.MethodGet, "/admin/events"+tt.query, nil)
+			respWriter := httptest.NewRecorder()
+
+			h.GetEvents(respWriter, req)
+
+			assert.Equal(t, tt.wantStatus, respWriter.Code)
+			if respWriter.Code == http.StatusOK {
+				var resp ListResponse[*entity.Event]
+				err := json.Unmarshal(respWriter.Body.Bytes(), &resp)
+				assert.NoError(t, err)
+				if assert.NotNil(t, resp.Pagination.NextCursor) {
+					assert.Equal(t, tt.wantLastSequence, *resp.Pagination.NextCursor)
+				}
+				assert.Equal(t, tt.wantCount, resp.Pagination.Count)
+				assert.Len(t, resp.Data, tt.wantCount)
+			}
+		})
+	}
+}
+
+func TestRequireAdminToken(t *testing.T) {
+	tests := []struct {
+		name       string
+		configured string
+		provided   string
+		wantStatus int
+	}{
+		{name: "valid token passes", configured: "secret", provided: "secret", wantStatus: http.StatusOK},
+		{name: "wrong token returns 401", configured: "secret", provided: "other", wantStatus: http.StatusUnauthorized},
+		{name: "missing token returns 401", configured: "secret", provided: "", wantStatus: http.StatusUnauthorized},
+		{name: "unconfigured token rejects everything", configured: "", provided: "", wantStatus: http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			next := func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusOK)
+			}
+
+			req := httptest.NewRequest(http.MethodGet, "/admin/events", nil)
+			if tt.provided != "" {
+				req.Header.Set(AdminTokenHeader, tt.provided)
+			}
+			respWriter := httptest.NewRecorder()
+
+			RequireAdminToken(tt.configured, next)(respWriter, req)
+
+			assert.Equal(t, tt.wantStatus, respWriter.Code)
+		})
+	}
+}
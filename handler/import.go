@@ -0,0 +1,171 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lucas-moura1/mercadobitcoin-challenge/entity"
+)
+
+const (
+	// DefaultMaxImportOrders caps the rows of one import request when
+	// ImportLimits leaves it unset.
+	DefaultMaxImportOrders = 10000
+	// maxImportRowBytes is the body allowance per row: a row with every
+	// field set is well under it, so only bodies with more rows than the cap,
+	// or padded rows, run out.
+	maxImportRowBytes = 1024
+)
+
+// ImportLimits configures POST /admin/orders/import. Zero values fall back
+// to DefaultMaxImportOrders and the use case's default batch size.
+type ImportLimits struct {
+	// MaxOrders is the most rows one request may carry.
+	MaxOrders int
+	// BatchSize is how many rows are inserted per statement.
+	BatchSize int
+}
+
+func (l ImportLimits) maxOrders() int {
+	if l.MaxOrders <= 0 {
+		return DefaultMaxImportOrders
+	}
+	return l.MaxOrders
+}
+
+type ImportOrdersRequest struct {
+	Orders []*ImportOrderRow `json:"orders"`
+}
+
+// ImportOrderRow is one resting limit order to import.
+type ImportOrderRow struct {
+	AccountID      uuid.UUID  `json:"account_id"`
+	InstrumentPair string     `json:"instrument_pair"`
+	OrderType      string     `json:"order_type"`
+	Price          string     `json:"price"`
+	Quantity       string     `json:"quantity"`
+	ExpiresAt      *time.Time `json:"expires_at,omitempty"`
+}
+
+type ImportOrdersResponse struct {
+	Imported int                     `json:"imported"`
+	Failed   []*ImportFailedResponse `json:"failed"`
+}
+
+// ImportFailedResponse is a row that was not imported, by its index in the
+// request. Code is set for domain errors.
+type ImportFailedResponse struct {
+	Index int    `json:"index"`
+	Code  string `json:"code,omitempty"`
+	Error string `json:"error"`
+}
+
+// ImportOrders seeds the book with resting orders that bypass matching.
+// Rows that fail are reported one by one and do not stop the others.
+func (h *orderHandler) ImportOrders(w http.ResponseWriter, r *http.Request) {
+	maxOrders := h.imports.maxOrders()
+
+	r.Body = http.MaxBytesReader(w, r.Body, int64(maxOrders)*maxImportRowBytes)
+	req := new(ImportOrdersRequest)
+	if err := decodeJSON(r, req); err != nil {
+		h.log.Errorw("failed to decode request", "error", err)
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			errorHandler(w, http.StatusRequestEntityTooLarge, "Request body too large")
+			return
+		}
+		errorHandler(w, http.StatusBadRequest, invalidBodyMessage(err))
+		return
+	}
+	if len(req.Orders) == 0 {
+		errorHandler(w, http.StatusBadRequest, "orders must not be empty")
+		return
+	}
+	if len(req.Orders) > maxOrders {
+		errorHandler(w, http.StatusRequestEntityTooLarge, fmt.Sprintf("at most %d orders can be imported at once", maxOrders))
+		return
+	}
+
+	response := &ImportOrdersResponse{Failed: []*ImportFailedResponse{}}
+
+	// indexes maps each parsed order back to its row.
+	orders := make([]*entity.Order, 0, len(req.Orders))
+	indexes := make([]int, 0, len(req.Orders))
+	for i, row := range req.Orders {
+		order, message := h.orderFromImportRow(row)
+		if order == nil {
+			response.Failed = append(response.Failed, &ImportFailedResponse{Index: i, Error: message})
+			continue
+		}
+		orders = append(orders, order)
+		indexes = append(indexes, i)
+	}
+
+	if len(orders) > 0 {
+		result, err := h.orderUseCase.ImportOrders(r.Context(), orders, h.imports.BatchSize)
+		if err != nil {
+			h.log.Errorw("failed to import orders", "count", len(orders), "error", err)
+			errorHandler(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+
+		response.Imported = result.Imported
+		for _, failure := range result.Failures {
+			failed := &ImportFailedResponse{Index: indexes[failure.Index], Error: failure.Err.Error()}
+			if domainErr, ok := entity.AsError(failure.Err); ok {
+				failed.Code = domainErr.Code
+			}
+			response.Failed = append(response.Failed, failed)
+		}
+		sort.Slice(response.Failed, func(i, j int) bool {
+			return response.Failed[i].Index < response.Failed[j].Index
+		})
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// orderFromImportRow parses the numeric fields of row into a new order. On a
+// malformed field it returns nil and the reason.
+func (h *orderHandler) orderFromImportRow(row *ImportOrderRow) (*entity.Order, string) {
+	if row == nil {
+		return nil, "Invalid order"
+	}
+
+	price, err := parseDecimal(row.Price)
+	if err != nil {
+		return nil, invalidDecimalMessage(err, "price", "Invalid price format")
+	}
+	quantity, err := parseDecimal(row.Quantity)
+	if err != nil {
+		return nil, invalidDecimalMessage(err, "quantity", "Invalid quantity format")
+	}
+
+	if places, ok := h.instruments.PriceDecimalPlaces(row.InstrumentPair); ok && entity.ExceedsPlaces(price, places) {
+		return nil, fmt.Sprintf("price has more than %d decimal places", places)
+	}
+	if places, ok := h.instruments.QuantityDecimalPlaces(row.InstrumentPair); ok && entity.ExceedsPlaces(quantity, places) {
+		return nil, fmt.Sprintf("quantity has more than %d decimal places", places)
+	}
+
+	var expiresAt *time.Time
+	if row.ExpiresAt != nil {
+		utc := row.ExpiresAt.UTC()
+		expiresAt = &utc
+	}
+
+	return &entity.Order{
+		AccountID:      row.AccountID,
+		InstrumentPair: row.InstrumentPair,
+		OrderType:      normalizeOrderType(row.OrderType),
+		Price:          price,
+		Quantity:       quantity,
+		ExpiresAt:      expiresAt,
+	}, ""
+}
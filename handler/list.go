@@ -0,0 +1,36 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+// ListResponse is the envelope every list endpoint responds with.
+type ListResponse[T any] struct {
+	Data       []T        `json:"data"`
+	Pagination Pagination `json:"pagination"`
+}
+
+// Pagination describes the page in Data. NextCursor is null when the
+// endpoint has no further page to offer.
+type Pagination struct {
+	NextCursor *string `json:"next_cursor"`
+	Count      int     `json:"count"`
+}
+
+func writeList[T any](w http.ResponseWriter, data []T, nextCursor string) {
+	if data == nil {
+		data = []T{}
+	}
+
+	response := ListResponse[T]{
+		Data:       data,
+		Pagination: Pagination{Count: len(data)},
+	}
+	if nextCursor != "" {
+		response.Pagination.NextCursor = &nextCursor
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
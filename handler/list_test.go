@@ -0,0 +1,41 @@
+package handler
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWriteList(t *testing.T) {
+	tests := []struct {
+		name       string
+		data       []string
+		nextCursor string
+		wantBody   string
+	}{
+		{
+			name:       "wraps data with count and cursor",
+			data:       []string{"a", "b"},
+			nextCursor: "42",
+			wantBody:   `{"data":["a","b"],"pagination":{"next_cursor":"42","count":2}}`,
+		},
+		{
+			name:     "nil data is an empty list with null cursor",
+			data:     nil,
+			wantBody: `{"data":[],"pagination":{"next_cursor":null,"count":0}}`,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			respWriter := httptest.NewRecorder()
+
+			writeList(respWriter, tt.data, tt.nextCursor)
+
+			assert.Equal(t, "application/json", respWriter.Header().Get("Content-Type"))
+			assert.JSONEq(t, tt.wantBody, respWriter.Body.String())
+		})
+	}
+}
@@ -0,0 +1,65 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"sync/atomic"
+)
+
+// Maintenance is a process-wide switch that freezes order placement while
+// leaving cancels and reads available.
+type Maintenance struct {
+	enabled atomic.Bool
+}
+
+func NewMaintenance(enabled bool) *Maintenance {
+	m := &Maintenance{}
+	m.enabled.Store(enabled)
+	return m
+}
+
+func (m *Maintenance) Enabled() bool {
+	return m.enabled.Load()
+}
+
+// Block answers 503 instead of calling next while maintenance is enabled.
+func (m *Maintenance) Block(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if m.Enabled() {
+			w.Header().Set("Retry-After", "60")
+			errorHandler(w, http.StatusServiceUnavailable, "Order placement is paused for maintenance")
+			return
+		}
+		next(w, r)
+	}
+}
+
+type MaintenanceRequest struct {
+	Enabled *bool `json:"enabled"`
+}
+
+type MaintenanceResponse struct {
+	Enabled bool `json:"enabled"`
+}
+
+func (m *Maintenance) GetMaintenance(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(MaintenanceResponse{Enabled: m.Enabled()})
+}
+
+func (m *Maintenance) SetMaintenance(w http.ResponseWriter, r *http.Request) {
+	req := new(MaintenanceRequest)
+	if err := decodeJSON(r, req); err != nil {
+		errorHandler(w, http.StatusBadRequest, invalidBodyMessage(err))
+		return
+	}
+	if req.Enabled == nil {
+		errorHandler(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	m.enabled.Store(*req.Enabled)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(MaintenanceResponse{Enabled: m.Enabled()})
+}
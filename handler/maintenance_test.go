@@ -0,0 +1,151 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lucas-moura1/mercadobitcoin-challenge/entity"
+	"github.com/lucas-moura1/mercadobitcoin-challenge/usecase"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+	"go.uber.org/zap"
+)
+
+func TestMaintenance_Router(t *testing.T) {
+	accountID := uuid.New()
+	orderID := uuid.New()
+	orderBody := `{"account_id":"` + accountID.String() + `","instrument_pair":"ETH_BRL","order_type":"BUY","price":"10","quantity":"1"}`
+
+	tests := []struct {
+		name       string
+		method     string
+		path       string
+		body       string
+		expect     func(m routerMocks)
+		wantStatus int
+	}{
+		{
+			name: "create is blocked", method: http.MethodPost, path: "/v1/orders", body: orderBody,
+			wantStatus: http.StatusServiceUnavailable,
+		},
+		{
+			name: "replace is blocked", method: http.MethodPost, path: "/v1/orders/replace",
+			body:       `{"order_id":"` + orderID.String() + `","account_id":"` + accountID.String() + `"}`,
+			wantStatus: http.StatusServiceUnavailable,
+		},
+		{
+			name: "cancel still works", method: http.MethodPost, path: "/v1/orders/" + orderID.String() + "/cancel",
+			expect: func(m routerMocks) {
+				m.orders.EXPECT().CancelOrder(gomock.Any(), orderID, accountID).Return(nil)
+			},
+			wantStatus: http.StatusOK,
+		},
+		{
+			name: "order book still works", method: http.MethodGet, path: "/v1/orders/ETH_BRL",
+			expect: func(m routerMocks) {
+				m.orders.EXPECT().GetOrderBook("ETH_BRL", decimal.Zero).Return(&usecase.OrderBook{InstrumentPair: "ETH_BRL"}, nil)
+			},
+			wantStatus: http.StatusOK,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			log := zap.NewNop().Sugar()
+			m := routerMocks{
+				orders:   usecase.NewMockOrderUseCase(ctrl),
+				accounts: usecase.NewMockAccountUseCase(ctrl),
+				trades:   usecase.NewMockTradeUseCase(ctrl),
+				events:   usecase.NewMockEventUseCase(ctrl),
+			}
+			apiKeyUC := usecase.NewMockApiKeyUseCase(ctrl)
+			apiKeyUC.EXPECT().Authenticate(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+				Return(&entity.ApiKey{AccountID: accountID}, nil).AnyTimes()
+			if tt.expect != nil {
+				tt.expect(m)
+			}
+
+			router := NewRouter(RouterConfig{
+				Prefix:        "/v1",
+				ReadTimeout:   time.Second,
+				WriteTimeout:  time.Second,
+				ApiKeyUseCase: apiKeyUC,
+				Maintenance:   NewMaintenance(true),
+				Orders:        NewOrderHandler(log, m.orders, nil, ImportLimits{}),
+				Accounts:      NewAccountHandler(log, m.accounts, nil),
+				Trades:        NewTradeHandler(log, m.trades, nil),
+				Events:        NewEventHandler(log, m.events),
+			})
+
+			respWriter := httptest.NewRecorder()
+			router.ServeHTTP(respWriter, httptest.NewRequest(tt.method, tt.path, strings.NewReader(tt.body)))
+
+			assert.Equal(t, tt.wantStatus, respWriter.Code)
+		})
+	}
+}
+
+func TestMaintenance_Toggle(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	log := zap.NewNop().Sugar()
+	accountID := uuid.New()
+	orderUC := usecase.NewMockOrderUseCase(ctrl)
+	apiKeyUC := usecase.NewMockApiKeyUseCase(ctrl)
+	apiKeyUC.EXPECT().Authenticate(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+		Return(&entity.ApiKey{AccountID: accountID}, nil).AnyTimes()
+	orderUC.EXPECT().CreateOrder(gomock.Any(), gomock.Any()).Return(assert.AnError).Times(1)
+
+	maintenance := NewMaintenance(false)
+	router := NewRouter(RouterConfig{
+		Prefix:        "/v1",
+		ReadTimeout:   time.Second,
+		WriteTimeout:  time.Second,
+		AdminToken:    "admin",
+		ApiKeyUseCase: apiKeyUC,
+		Maintenance:   maintenance,
+		Orders:        NewOrderHandler(log, orderUC, nil, ImportLimits{}),
+		Accounts:      NewAccountHandler(log, usecase.NewMockAccountUseCase(ctrl), nil),
+		Trades:        NewTradeHandler(log, usecase.NewMockTradeUseCase(ctrl), nil),
+		Events:        NewEventHandler(log, usecase.NewMockEventUseCase(ctrl)),
+	})
+
+	serve := func(method, path, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set(AdminTokenHeader, "admin")
+		respWriter := httptest.NewRecorder()
+		router.ServeHTTP(respWriter, req)
+		return respWriter
+	}
+	createBody := `{"account_id":"` + accountID.String() + `","instrument_pair":"ETH_BRL","order_type":"BUY","price":"10","quantity":"1"}`
+
+	// Disabled: the create reaches the use case.
+	assert.NotEqual(t, http.StatusServiceUnavailable, serve(http.MethodPost, "/v1/orders", createBody).Code)
+
+	respWriter := serve(http.MethodPost, "/v1/admin/maintenance", `{"enabled":true}`)
+	assert.Equal(t, http.StatusOK, respWriter.Code)
+	assert.True(t, maintenance.Enabled())
+
+	respWriter = serve(http.MethodGet, "/v1/admin/maintenance", "")
+	var resp MaintenanceResponse
+	assert.NoError(t, json.NewDecoder(respWriter.Body).Decode(&resp))
+	assert.True(t, resp.Enabled)
+
+	respWriter = serve(http.MethodPost, "/v1/orders", createBody)
+	assert.Equal(t, http.StatusServiceUnavailable, respWriter.Code)
+	assert.Equal(t, "60", respWriter.Header().Get("Retry-After"))
+
+	assert.Equal(t, http.StatusBadRequest, serve(http.MethodPost, "/v1/admin/maintenance", `{}`).Code)
+	assert.True(t, maintenance.Enabled())
+}
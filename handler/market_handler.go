@@ -0,0 +1,62 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/lucas-moura1/mercadobitcoin-challenge/usecase"
+	"go.uber.org/zap"
+)
+
+type marketHandler struct {
+	log               *zap.SugaredLogger
+	marketHaltUseCase usecase.MarketHaltUseCase
+}
+
+func NewMarketHandler(log *zap.SugaredLogger, marketHaltUseCase usecase.MarketHaltUseCase) *marketHandler {
+	return &marketHandler{log: log, marketHaltUseCase: marketHaltUseCase}
+}
+
+type MarketStatusResponse struct {
+	InstrumentPair string `json:"instrument_pair"`
+	Halted         bool   `json:"halted"`
+}
+
+func (h *marketHandler) HaltMarket(w http.ResponseWriter, r *http.Request) {
+	h.setHalted(w, r, true)
+}
+
+func (h *marketHandler) ResumeMarket(w http.ResponseWriter, r *http.Request) {
+	h.setHalted(w, r, false)
+}
+
+func (h *marketHandler) setHalted(w http.ResponseWriter, r *http.Request, halted bool) {
+	instrumentPair := r.PathValue("instrument_pair")
+
+	update := h.marketHaltUseCase.Resume
+	if halted {
+		update = h.marketHaltUseCase.Halt
+	}
+	if err := update(instrumentPair); err != nil {
+		h.log.Errorw("failed to update market halt", "instrument_pair", instrumentPair, "halted", halted, "error", err)
+		errorHandler(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(MarketStatusResponse{
+		InstrumentPair: instrumentPair,
+		Halted:         h.marketHaltUseCase.IsHalted(instrumentPair),
+	})
+}
+
+func (h *marketHandler) GetHaltedMarkets(w http.ResponseWriter, r *http.Request) {
+	pairs := h.marketHaltUseCase.Halted()
+
+	response := make([]MarketStatusResponse, len(pairs))
+	for i, pair := range pairs {
+		response[i] = MarketStatusResponse{InstrumentPair: pair, Halted: true}
+	}
+
+	writeList(w, response, "")
+}
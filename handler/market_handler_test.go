@@ -0,0 +1,106 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/lucas-moura1/mercadobitcoin-challenge/entity"
+	"github.com/lucas-moura1/mercadobitcoin-challenge/usecase"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+	"go.uber.org/zap"
+)
+
+func TestMarketHandler_HaltAndResume(t *testing.T) {
+	tests := []struct {
+		name       string
+		halt       bool
+		pair       string
+		mockSetup  func(m *usecase.MockMarketHaltUseCase)
+		wantStatus int
+		wantHalted bool
+	}{
+		{
+			name: "halt returns the halted status",
+			halt: true,
+			pair: "BTC_BRL",
+			mockSetup: func(m *usecase.MockMarketHaltUseCase) {
+				m.EXPECT().Halt("BTC_BRL").Return(nil).Times(1)
+				m.EXPECT().IsHalted("BTC_BRL").Return(true).Times(1)
+			},
+			wantStatus: http.StatusOK,
+			wantHalted: true,
+		},
+		{
+			name: "resume returns the live status",
+			halt: false,
+			pair: "BTC_BRL",
+			mockSetup: func(m *usecase.MockMarketHaltUseCase) {
+				m.EXPECT().Resume("BTC_BRL").Return(nil).Times(1)
+				m.EXPECT().IsHalted("BTC_BRL").Return(false).Times(1)
+			},
+			wantStatus: http.StatusOK,
+		},
+		{
+			name: "invalid pair returns 400",
+			halt: true,
+			pair: "BTCBRL",
+			mockSetup: func(m *usecase.MockMarketHaltUseCase) {
+				m.EXPECT().Halt("BTCBRL").Return(entity.ErrInvalidPairFormat).Times(1)
+			},
+			wantStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockUC := usecase.NewMockMarketHaltUseCase(ctrl)
+			tt.mockSetup(mockUC)
+			h := NewMarketHandler(zap.NewNop().Sugar(), mockUC)
+
+			req := httptest.NewRequest(http.MethodPost, "/admin/markets/{instrument_pair}/halt", nil)
+			req.SetPathValue("instrument_pair", tt.pair)
+			respWriter := httptest.NewRecorder()
+
+			if tt.halt {
+				h.HaltMarket(respWriter, req)
+			} else {
+				h.ResumeMarket(respWriter, req)
+			}
+
+			assert.Equal(t, tt.wantStatus, respWriter.Code)
+			if respWriter.Code == http.StatusOK {
+				var resp MarketStatusResponse
+				assert.NoError(t, json.Unmarshal(respWriter.Body.Bytes(), &resp))
+				assert.Equal(t, tt.pair, resp.InstrumentPair)
+				assert.Equal(t, tt.wantHalted, resp.Halted)
+			}
+		})
+	}
+}
+
+func TestMarketHandler_GetHaltedMarkets(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockUC := usecase.NewMockMarketHaltUseCase(ctrl)
+	mockUC.EXPECT().Halted().Return([]string{"BTC_BRL", "ETH_BRL"}).Times(1)
+	h := NewMarketHandler(zap.NewNop().Sugar(), mockUC)
+
+	respWriter := httptest.NewRecorder()
+	h.GetHaltedMarkets(respWriter, httptest.NewRequest(http.MethodGet, "/admin/markets/halted", nil))
+
+	assert.Equal(t, http.StatusOK, respWriter.Code)
+	var resp ListResponse[MarketStatusResponse]
+	assert.NoError(t, json.Unmarshal(respWriter.Body.Bytes(), &resp))
+	assert.Equal(t, []MarketStatusResponse{
+		{InstrumentPair: "BTC_BRL", Halted: true},
+		{InstrumentPair: "ETH_BRL", Halted: true},
+	}, resp.Data)
+}
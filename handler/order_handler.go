@@ -3,10 +3,14 @@ package handler
 import (
 	"encoding/json"
 	"errors"
+	"fmt"
 	"net/http"
+	"strings"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/lucas-moura1/mercadobitcoin-challenge/entity"
+	"github.com/lucas-moura1/mercadobitcoin-challenge/repository"
 	"github.com/lucas-moura1/mercadobitcoin-challenge/usecase"
 	"github.com/shopspring/decimal"
 	"go.uber.org/zap"
@@ -15,10 +19,17 @@ import (
 type orderHandler struct {
 	log          *zap.SugaredLogger
 	orderUseCase usecase.OrderUseCase
+	instruments  *entity.InstrumentConfig
+	imports      ImportLimits
 }
 
-func NewOrderHandler(log *zap.SugaredLogger, orderUseCase usecase.OrderUseCase) *orderHandler {
-	return &orderHandler{log: log, orderUseCase: orderUseCase}
+func NewOrderHandler(
+	log *zap.SugaredLogger,
+	orderUseCase usecase.OrderUseCase,
+	instruments *entity.InstrumentConfig,
+	imports ImportLimits,
+) *orderHandler {
+	return &orderHandler{log: log, orderUseCase: orderUseCase, instruments: instruments, imports: imports}
 }
 
 type CreateOrderRequest struct {
@@ -27,6 +38,29 @@ type CreateOrderRequest struct {
 	OrderType      string    `json:"order_type"`
 	Price          string    `json:"price"`
 	Quantity       string    `json:"quantity"`
+	// MinFillQuantity is optional; omitted or empty means no minimum.
+	MinFillQuantity string `json:"min_fill_quantity,omitempty"`
+	// QuoteQuantity makes a buy a market buy that spends this much quote
+	// asset; price and quantity are then omitted.
+	QuoteQuantity string `json:"quote_quantity,omitempty"`
+	// ExpiresAt makes the order good-till-date; omitted means
+	// good-till-cancelled.
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+}
+
+// orderTypeAliases maps the bid/ask terminology some clients use onto the
+// canonical order types. Anything else is passed through and left to order
+// validation.
+var orderTypeAliases = map[string]entity.OrderType{
+	string(entity.BookSideBid): entity.OrderTypeBuy,
+	string(entity.BookSideAsk): entity.OrderTypeSell,
+}
+
+func normalizeOrderType(orderType string) string {
+	if canonical, ok := orderTypeAliases[orderType]; ok {
+		return string(canonical)
+	}
+	return orderType
 }
 
 type CreateOrderResponse struct {
@@ -35,57 +69,243 @@ type CreateOrderResponse struct {
 	OrderType      string    `json:"order_type"`
 	Price          string    `json:"price"`
 	Quantity       string    `json:"quantity"`
-	Status         string    `json:"status"`
+	// QuoteQuantity is the budget of a market buy, omitted for limit orders.
+	QuoteQuantity *string    `json:"quote_quantity,omitempty"`
+	Status        string     `json:"status"`
+	ExpiresAt     *time.Time `json:"expires_at,omitempty"`
+	CreatedAt     time.Time  `json:"created_at"`
+	UpdatedAt     time.Time  `json:"updated_at"`
 }
 
 func (h *orderHandler) CreateOrder(w http.ResponseWriter, r *http.Request) {
 	req := new(CreateOrderRequest)
-	if err := json.NewDecoder(r.Body).Decode(req); err != nil {
+	if err := decodeJSON(r, req); err != nil {
 		h.log.Errorw("failed to decode request", "error", err)
-		errorHandler(w, http.StatusBadRequest, "Invalid request body")
+		errorHandler(w, http.StatusBadRequest, invalidBodyMessage(err))
 		return
 	}
 
-	price, err := decimal.NewFromString(req.Price)
-	if err != nil {
-		h.log.Errorw("invalid price format", "error", err)
-		errorHandler(w, http.StatusBadRequest, "Invalid price format")
+	if signedAccountMismatch(r, req.AccountID) {
+		errorHandler(w, http.StatusForbidden, "API key does not belong to account")
 		return
 	}
 
-	quantity, err := decimal.NewFromString(req.Quantity)
-	if err != nil {
-		h.log.Errorw("invalid quantity format", "error", err)
-		errorHandler(w, http.StatusBadRequest, "Invalid quantity format")
+	order, ok := h.orderFromRequest(w, req)
+	if !ok {
 		return
 	}
 
-	order := &entity.Order{
-		AccountID:      req.AccountID,
-		InstrumentPair: req.InstrumentPair,
-		OrderType:      req.OrderType,
-		Price:          price,
-		Quantity:       quantity,
+	// The use case fills in fields such as a market buy's base quantity, so
+	// violations are checked on the order as submitted, with its pair
+	// normalized the way the use case does.
+	submitted := *order
+	if pair, err := entity.NormalizeInstrumentPair(submitted.InstrumentPair); err == nil {
+		submitted.InstrumentPair = pair
 	}
 
-	if err := h.orderUseCase.CreateOrder(order); err != nil {
+	if err := h.orderUseCase.CreateOrder(r.Context(), order); err != nil {
 		h.log.Errorw("failed to create order", "error", err)
-		errorHandler(w, http.StatusBadRequest, err.Error())
+		// The engine stops at the first invalid field; report them all so
+		// a client can fix the order in one go. Rejections that are not
+		// about the order itself, such as a halted market, keep their status.
+		if domainErr, ok := entity.AsError(err); !ok || domainErr.Status == http.StatusBadRequest {
+			if violations := submitted.ValidateAll(); violations != nil {
+				validationErrorHandler(w, violations)
+				return
+			}
+		}
+		domainErrorHandler(w, err, http.StatusInternalServerError)
 		return
 	}
 
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(h.createOrderResponse(order))
+}
+
+// orderFromRequest parses the numeric fields of req into a new order. On a
+// malformed field it writes a 400 and returns false.
+func (h *orderHandler) orderFromRequest(w http.ResponseWriter, req *CreateOrderRequest) (*entity.Order, bool) {
+	var err error
+
+	quoteQuantity := decimal.Zero
+	if req.QuoteQuantity != "" {
+		quoteQuantity, err = parseDecimal(req.QuoteQuantity)
+		if err != nil {
+			h.log.Errorw("invalid quote quantity format", "error", err)
+			errorHandler(w, http.StatusBadRequest, invalidDecimalMessage(err, "quote quantity", "Invalid quote quantity format"))
+			return nil, false
+		}
+		if !h.checkPriceDecimalPlaces(w, req.InstrumentPair, "quote quantity", quoteQuantity) {
+			return nil, false
+		}
+	}
+
+	// A market buy spends its quote budget at market, so it carries neither
+	// a price nor a base quantity.
+	marketBuy := req.QuoteQuantity != ""
+	if marketBuy && req.Quantity != "" {
+		h.log.Errorw("quote quantity combined with quantity", "quantity", req.Quantity)
+		errorHandler(w, http.StatusBadRequest, entity.ErrQuoteQuantityWithQuantity.Error())
+		return nil, false
+	}
+	if marketBuy && req.Price != "" {
+		h.log.Errorw("quote quantity combined with price", "price", req.Price)
+		errorHandler(w, http.StatusBadRequest, entity.ErrQuoteQuantityWithPrice.Error())
+		return nil, false
+	}
+
+	price := decimal.Zero
+	if !marketBuy {
+		price, err = parseDecimal(req.Price)
+		if err != nil {
+			h.log.Errorw("invalid price format", "error", err)
+			errorHandler(w, http.StatusBadRequest, invalidDecimalMessage(err, "price", "Invalid price format"))
+			return nil, false
+		}
+		if !h.checkPriceDecimalPlaces(w, req.InstrumentPair, "price", price) {
+			return nil, false
+		}
+	}
+
+	quantity := decimal.Zero
+	if !marketBuy {
+		quantity, err = parseDecimal(req.Quantity)
+		if err != nil {
+			h.log.Errorw("invalid quantity format", "error", err)
+			errorHandler(w, http.StatusBadRequest, invalidDecimalMessage(err, "quantity", "Invalid quantity format"))
+			return nil, false
+		}
+		if !h.checkQuantityDecimalPlaces(w, req.InstrumentPair, "quantity", quantity) {
+			return nil, false
+		}
+	}
+
+	minFill := decimal.Zero
+	if req.MinFillQuantity != "" {
+		minFill, err = parseDecimal(req.MinFillQuantity)
+		if err != nil {
+			h.log.Errorw("invalid min fill quantity format", "error", err)
+			errorHandler(w, http.StatusBadRequest, invalidDecimalMessage(err, "min fill quantity", "Invalid min fill quantity format"))
+			return nil, false
+		}
+		if !h.checkQuantityDecimalPlaces(w, req.InstrumentPair, "min fill quantity", minFill) {
+			return nil, false
+		}
+	}
+
+	var expiresAt *time.Time
+	if req.ExpiresAt != nil {
+		utc := req.ExpiresAt.UTC()
+		expiresAt = &utc
+	}
+
+	return &entity.Order{
+		AccountID:       req.AccountID,
+		InstrumentPair:  req.InstrumentPair,
+		OrderType:       normalizeOrderType(req.OrderType),
+		Price:           price,
+		Quantity:        quantity,
+		MinFillQuantity: minFill,
+		QuoteQuantity:   quoteQuantity,
+		ExpiresAt:       expiresAt,
+	}, true
+}
+
+// checkPriceDecimalPlaces writes a 400 and returns false when a quote
+// denominated value is more precise than the pair's quote asset allows.
+func (h *orderHandler) checkPriceDecimalPlaces(w http.ResponseWriter, pair, field string, value decimal.Decimal) bool {
+	places, ok := h.instruments.PriceDecimalPlaces(pair)
+	return h.checkDecimalPlaces(w, field, value, places, ok)
+}
+
+// checkQuantityDecimalPlaces writes a 400 and returns false when a base
+// quantity is more precise than the pair's base asset allows, which could
+// leave a remainder that can never be settled.
+func (h *orderHandler) checkQuantityDecimalPlaces(w http.ResponseWriter, pair, field string, value decimal.Decimal) bool {
+	places, ok := h.instruments.QuantityDecimalPlaces(pair)
+	return h.checkDecimalPlaces(w, field, value, places, ok)
+}
+
+func (h *orderHandler) checkDecimalPlaces(w http.ResponseWriter, field string, value decimal.Decimal, places int32, limited bool) bool {
+	if !limited || !entity.ExceedsPlaces(value, places) {
+		return true
+	}
+
+	h.log.Errorw("too many decimal places", "field", field, "value", value, "max_decimal_places", places)
+	errorHandler(w, http.StatusBadRequest, fmt.Sprintf("%s has more than %d decimal places", field, places))
+	return false
+}
+
+func (h *orderHandler) createOrderResponse(order *entity.Order) *CreateOrderResponse {
 	response := &CreateOrderResponse{
 		OrderID:        order.ID,
 		InstrumentPair: order.InstrumentPair,
 		OrderType:      order.OrderType,
-		Price:          order.Price.String(),
-		Quantity:       order.Quantity.String(),
+		Price:          h.instruments.FormatPrice(order.InstrumentPair, order.Price),
+		Quantity:       h.instruments.FormatQuantity(order.InstrumentPair, order.Quantity),
 		Status:         order.Status,
+		ExpiresAt:      order.ExpiresAt,
+		CreatedAt:      order.CreatedAt,
+		UpdatedAt:      order.UpdatedAt,
+	}
+	if order.IsMarketBuy() {
+		quote := h.instruments.FormatPrice(order.InstrumentPair, order.QuoteQuantity)
+		response.QuoteQuantity = &quote
+	}
+	return response
+}
+
+// ReplaceOrderRequest names the order to cancel and carries the full spec of
+// the order that replaces it.
+type ReplaceOrderRequest struct {
+	OrderID uuid.UUID `json:"order_id"`
+	CreateOrderRequest
+}
+
+type ReplaceOrderResponse struct {
+	CancelledOrderID uuid.UUID            `json:"cancelled_order_id"`
+	CancelledStatus  string               `json:"cancelled_status"`
+	Order            *CreateOrderResponse `json:"order"`
+}
+
+func (h *orderHandler) ReplaceOrder(w http.ResponseWriter, r *http.Request) {
+	req := new(ReplaceOrderRequest)
+	if err := decodeJSON(r, req); err != nil {
+		h.log.Errorw("failed to decode request", "error", err)
+		errorHandler(w, http.StatusBadRequest, invalidBodyMessage(err))
+		return
+	}
+
+	if signedAccountMismatch(r, req.AccountID) {
+		errorHandler(w, http.StatusForbidden, "API key does not belong to account")
+		return
+	}
+
+	order, ok := h.orderFromRequest(w, &req.CreateOrderRequest)
+	if !ok {
+		return
+	}
+
+	cancelled, err := h.orderUseCase.ReplaceOrder(r.Context(), req.OrderID, order)
+	if err != nil {
+		h.log.Errorw("failed to replace order", "order_id", req.OrderID, "error", err)
+		switch {
+		case errors.Is(err, repository.ErrNotFound):
+			errorHandler(w, http.StatusNotFound, "Order not found")
+		default:
+			domainErrorHandler(w, err, http.StatusBadRequest)
+		}
+		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusCreated)
-	json.NewEncoder(w).Encode(response)
+	json.NewEncoder(w).Encode(ReplaceOrderResponse{
+		CancelledOrderID: cancelled.ID,
+		CancelledStatus:  cancelled.Status,
+		Order:            h.createOrderResponse(order),
+	})
 }
 
 func (h *orderHandler) CancelOrder(w http.ResponseWriter, r *http.Request) {
@@ -97,65 +317,945 @@ func (h *orderHandler) CancelOrder(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if err := h.orderUseCase.CancelOrder(orderID); err != nil {
-		errorHandler(w, http.StatusInternalServerError, err.Error())
+	// A signed cancel may only touch the signing account's orders; the use
+	// case checks the owner when it reads the order.
+	owner, _ := signedAccount(r)
+	if err := h.orderUseCase.CancelOrder(r.Context(), orderID, owner); err != nil {
+		h.log.Errorw("failed to cancel order", "id", orderID, "error", err)
+		switch {
+		case errors.Is(err, repository.ErrNotFound):
+			errorHandler(w, http.StatusNotFound, "Order not found")
+		default:
+			domainErrorHandler(w, err, http.StatusInternalServerError)
+		}
 		return
 	}
 
 	w.WriteHeader(http.StatusOK)
 }
 
+type CancelOrdersRequest struct {
+	AccountID      uuid.UUID `json:"account_id"`
+	InstrumentPair string    `json:"instrument_pair"`
+	Side           string    `json:"side"`
+}
+
+type CancelOrdersResponse struct {
+	CancelledOrderIDs []uuid.UUID `json:"cancelled_order_ids"`
+}
+
+func (h *orderHandler) CancelOrders(w http.ResponseWriter, r *http.Request) {
+	req := new(CancelOrdersRequest)
+	if err := decodeJSON(r, req); err != nil {
+		h.log.Errorw("failed to decode request", "error", err)
+		errorHandler(w, http.StatusBadRequest, invalidBodyMessage(err))
+		return
+	}
+
+	if signedAccountMismatch(r, req.AccountID) {
+		errorHandler(w, http.StatusForbidden, "API key does not belong to account")
+		return
+	}
+
+	req.Side = normalizeOrderType(req.Side)
+
+	cancelled, err := h.orderUseCase.CancelOrders(r.Context(), req.AccountID, req.InstrumentPair, req.Side)
+	if err != nil {
+		h.log.Errorw("failed to cancel orders",
+			"account_id", req.AccountID,
+			"instrument_pair", req.InstrumentPair,
+			"side", req.Side,
+			"error", err,
+		)
+		domainErrorHandler(w, err, http.StatusInternalServerError)
+		return
+	}
+
+	if cancelled == nil {
+		cancelled = []uuid.UUID{}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(CancelOrdersResponse{CancelledOrderIDs: cancelled})
+}
+
+// OrderBookResponse is the aggregated book. Checksum is left out of an
+// inverted book, whose levels are not the ones it was computed over.
 type OrderBookResponse struct {
 	InstrumentPair string           `json:"instrument_pair"`
 	Bids           []OrderBookLevel `json:"bids"`
 	Asks           []OrderBookLevel `json:"asks"`
+	Checksum       *uint32          `json:"checksum,omitempty"`
 }
 
 type OrderBookLevel struct {
 	Price    string `json:"price"`
 	Quantity string `json:"quantity"`
 }
+
+// OrderBookSideResponse is the book restricted to one side with side=bid or
+// side=ask: the other side's key is left out rather than sent empty.
+type OrderBookSideResponse struct {
+	InstrumentPair string            `json:"instrument_pair"`
+	Bids           *[]OrderBookLevel `json:"bids,omitempty"`
+	Asks           *[]OrderBookLevel `json:"asks,omitempty"`
+	Checksum       *uint32           `json:"checksum,omitempty"`
+}
+
 func (h *orderHandler) GetOrderBook(w http.ResponseWriter, r *http.Request) {
 	instrumentPair := r.PathValue("instrument_pair")
-	orderBook, err := h.orderUseCase.GetOrderBook(instrumentPair)
+
+	invert, err := queryInvert(r)
+	if err != nil {
+		h.log.Errorw("invalid invert parameter", "invert", r.URL.Query().Get("invert"))
+		errorHandler(w, http.StatusBadRequest, "Invalid invert parameter")
+		return
+	}
+
+	side := r.URL.Query().Get("side")
+	if side != "" && side != string(entity.BookSideBid) && side != string(entity.BookSideAsk) {
+		h.log.Errorw("invalid side parameter", "side", side)
+		errorHandler(w, http.StatusBadRequest, entity.ErrInvalidSide.Error())
+		return
+	}
+
+	minQuantity := decimal.Zero
+	if v := r.URL.Query().Get("min_quantity"); v != "" {
+		minQuantity, err = parseDecimal(v)
+		if err != nil {
+			h.log.Errorw("invalid min_quantity parameter", "min_quantity", v)
+			errorHandler(w, http.StatusBadRequest, invalidDecimalMessage(err, "min_quantity", "Invalid min_quantity parameter"))
+			return
+		}
+	}
+
+	var orderBook *usecase.OrderBook
+	if side == "" {
+		orderBook, err = h.orderUseCase.GetOrderBook(instrumentPair, minQuantity)
+	} else {
+		// Inverting swaps the sides, so the side asked of the inverted book
+		// is the other side of the book as stored.
+		bookSide := side
+		if invert {
+			bookSide = oppositeBookSide(side)
+		}
+		orderBook, err = h.orderUseCase.GetOrderBookSide(instrumentPair, bookSide, minQuantity)
+	}
 	if err != nil {
 		h.log.Errorw("failed to get order book",
 			"instrument_pair", instrumentPair,
 			"error", err,
 		)
-		if errors.Is(err, entity.ErrInvalidPairFormat) {
+		domainErrorHandler(w, err, http.StatusInternalServerError)
+		return
+	}
+
+	response := h.orderBookResponse(orderBook)
+	if invert {
+		response, err = h.invertOrderBook(orderBook)
+		if err != nil {
+			h.log.Errorw("failed to invert order book", "instrument_pair", instrumentPair, "error", err)
 			errorHandler(w, http.StatusBadRequest, err.Error())
 			return
 		}
-		errorHandler(w, http.StatusInternalServerError, err.Error())
-		return
 	}
 
-	if orderBook == nil {
-		errorHandler(w, http.StatusNotFound, "Order book not found")
-		return
+	w.Header().Set("Content-Type", "application/json")
+	switch side {
+	case string(entity.BookSideBid):
+		json.NewEncoder(w).Encode(OrderBookSideResponse{InstrumentPair: response.InstrumentPair, Bids: &response.Bids, Checksum: response.Checksum})
+	case string(entity.BookSideAsk):
+		json.NewEncoder(w).Encode(OrderBookSideResponse{InstrumentPair: response.InstrumentPair, Asks: &response.Asks, Checksum: response.Checksum})
+	default:
+		json.NewEncoder(w).Encode(response)
 	}
+}
+
+func oppositeBookSide(side string) string {
+	if side == string(entity.BookSideBid) {
+		return string(entity.BookSideAsk)
+	}
+	return string(entity.BookSideBid)
+}
 
+func (h *orderHandler) orderBookResponse(orderBook *usecase.OrderBook) OrderBookResponse {
 	response := OrderBookResponse{
 		InstrumentPair: orderBook.InstrumentPair,
 		Bids:           make([]OrderBookLevel, len(orderBook.Bids)),
 		Asks:           make([]OrderBookLevel, len(orderBook.Asks)),
+		Checksum:       &orderBook.Checksum,
 	}
 
 	for i, bid := range orderBook.Bids {
 		response.Bids[i] = OrderBookLevel{
-			Price:    bid.Price.String(),
-			Quantity: bid.Quantity.String(),
+			Price:    h.instruments.FormatPrice(orderBook.InstrumentPair, bid.Price),
+			Quantity: h.instruments.FormatQuantity(orderBook.InstrumentPair, bid.Quantity),
 		}
 	}
 
 	for i, ask := range orderBook.Asks {
 		response.Asks[i] = OrderBookLevel{
-			Price:    ask.Price.String(),
-			Quantity: ask.Quantity.String(),
+			Price:    h.instruments.FormatPrice(orderBook.InstrumentPair, ask.Price),
+			Quantity: h.instruments.FormatQuantity(orderBook.InstrumentPair, ask.Quantity),
+		}
+	}
+
+	return response
+}
+
+// invertOrderBook presents the book as its reciprocal market: BASE_QUOTE
+// becomes QUOTE_BASE, prices become 1/price and quantities become the quote
+// amount of each level. Bids of the original book are offers to sell the
+// quote asset, so they become asks, and asks become bids. Level order is kept,
+// since inverting the price reverses the sort and swapping sides reverses it
+// back.
+func (h *orderHandler) invertOrderBook(orderBook *usecase.OrderBook) (OrderBookResponse, error) {
+	inverted, err := entity.InvertInstrumentPair(orderBook.InstrumentPair)
+	if err != nil {
+		return OrderBookResponse{}, err
+	}
+
+	invertLevels := func(levels []*usecase.OrderBookEntry) []OrderBookLevel {
+		result := make([]OrderBookLevel, len(levels))
+		for i, level := range levels {
+			result[i] = OrderBookLevel{
+				Price:    h.instruments.FormatInversePrice(orderBook.InstrumentPair, level.Price),
+				Quantity: h.instruments.FormatQuantity(inverted, level.Price.Mul(level.Quantity)),
+			}
+		}
+		return result
+	}
+
+	return OrderBookResponse{
+		InstrumentPair: inverted,
+		Bids:           invertLevels(orderBook.Asks),
+		Asks:           invertLevels(orderBook.Bids),
+	}, nil
+}
+
+type RawOrderBookResponse struct {
+	InstrumentPair string              `json:"instrument_pair"`
+	Bids           []RawOrderBookEntry `json:"bids"`
+	Asks           []RawOrderBookEntry `json:"asks"`
+}
+
+type RawOrderBookEntry struct {
+	OrderID   uuid.UUID `json:"order_id"`
+	Price     string    `json:"price"`
+	Quantity  string    `json:"quantity"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func (h *orderHandler) GetRawOrderBook(w http.ResponseWriter, r *http.Request) {
+	instrumentPair := r.PathValue("instrument_pair")
+
+	depth, err := queryPositiveInt(r, "depth")
+	if err != nil {
+		h.log.Errorw("invalid depth parameter", "depth", r.URL.Query().Get("depth"))
+		errorHandler(w, http.StatusBadRequest, "Invalid depth parameter")
+		return
+	}
+
+	book, err := h.orderUseCase.GetRawOrderBook(instrumentPair, depth)
+	if err != nil {
+		h.log.Errorw("failed to get raw order book",
+			"instrument_pair", instrumentPair,
+			"error", err,
+		)
+		domainErrorHandler(w, err, http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(h.rawOrderBookResponse(book))
+}
+
+func (h *orderHandler) rawOrderBookResponse(book *usecase.RawOrderBook) RawOrderBookResponse {
+	return RawOrderBookResponse{
+		InstrumentPair: book.InstrumentPair,
+		Bids:           h.rawOrderBookEntries(book.InstrumentPair, book.Bids),
+		Asks:           h.rawOrderBookEntries(book.InstrumentPair, book.Asks),
+	}
+}
+
+func (h *orderHandler) rawOrderBookEntries(instrumentPair string, entries []*usecase.RawOrderBookEntry) []RawOrderBookEntry {
+	response := make([]RawOrderBookEntry, len(entries))
+	for i, entry := range entries {
+		response[i] = RawOrderBookEntry{
+			OrderID:   entry.OrderID,
+			Price:     h.instruments.FormatPrice(instrumentPair, entry.Price),
+			Quantity:  h.instruments.FormatQuantity(instrumentPair, entry.RemainingQuantity),
+			CreatedAt: entry.CreatedAt,
 		}
 	}
+	return response
+}
+
+// BookResponse is either view of the book, tagged with the view it holds:
+// bids and asks are price levels for "aggregated" and individual orders for
+// "raw".
+type BookResponse struct {
+	View           usecase.BookView `json:"view"`
+	InstrumentPair string           `json:"instrument_pair"`
+	Bids           any              `json:"bids"`
+	Asks           any              `json:"asks"`
+	Checksum       *uint32          `json:"checksum,omitempty"`
+}
+
+func (h *orderHandler) GetBook(w http.ResponseWriter, r *http.Request) {
+	instrumentPair := r.PathValue("instrument_pair")
+
+	view := usecase.BookView(r.URL.Query().Get("view"))
+	if view == "" {
+		view = usecase.BookViewAggregated
+	}
+
+	depth, err := queryPositiveInt(r, "depth")
+	if err != nil {
+		h.log.Errorw("invalid depth parameter", "depth", r.URL.Query().Get("depth"))
+		errorHandler(w, http.StatusBadRequest, "Invalid depth parameter")
+		return
+	}
+
+	book, err := h.orderUseCase.GetBook(instrumentPair, view, depth)
+	if err != nil {
+		h.log.Errorw("failed to get book",
+			"instrument_pair", instrumentPair,
+			"view", view,
+			"error", err,
+		)
+		domainErrorHandler(w, err, http.StatusInternalServerError)
+		return
+	}
+
+	response := BookResponse{View: book.View}
+	if book.Raw != nil {
+		raw := h.rawOrderBookResponse(book.Raw)
+		response.InstrumentPair, response.Bids, response.Asks = raw.InstrumentPair, raw.Bids, raw.Asks
+	} else {
+		aggregated := h.orderBookResponse(book.Aggregated)
+		response.InstrumentPair, response.Bids, response.Asks = aggregated.InstrumentPair, aggregated.Bids, aggregated.Asks
+		response.Checksum = aggregated.Checksum
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+type DepthResponse struct {
+	InstrumentPair string `json:"instrument_pair"`
+	Side           string `json:"side"`
+	Price          string `json:"price"`
+	Quantity       string `json:"quantity"`
+}
+
+func (h *orderHandler) GetDepth(w http.ResponseWriter, r *http.Request) {
+	instrumentPair := r.PathValue("instrument_pair")
+	side := r.URL.Query().Get("side")
+
+	price, err := parseDecimal(r.URL.Query().Get("price"))
+	if err != nil {
+		h.log.Errorw("invalid price format", "error", err)
+		errorHandler(w, http.StatusBadRequest, invalidDecimalMessage(err, "price", "Invalid price format"))
+		return
+	}
+
+	quantity, err := h.orderUseCase.GetDepth(instrumentPair, side, price)
+	if err != nil {
+		h.log.Errorw("failed to get depth",
+			"instrument_pair", instrumentPair,
+			"side", side,
+			"error", err,
+		)
+		domainErrorHandler(w, err, http.StatusInternalServerError)
+		return
+	}
+
+	response := DepthResponse{
+		InstrumentPair: instrumentPair,
+		Side:           side,
+		Price:          h.instruments.FormatPrice(instrumentPair, price),
+		Quantity:       h.instruments.FormatQuantity(instrumentPair, quantity),
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+type ImbalanceResponse struct {
+	InstrumentPair string `json:"instrument_pair"`
+	Depth          int    `json:"depth"`
+	BidQuantity    string `json:"bid_quantity"`
+	AskQuantity    string `json:"ask_quantity"`
+	Imbalance      string `json:"imbalance"`
+	// Ratio is null when both sides are empty.
+	Ratio *string `json:"ratio"`
+}
+
+func (h *orderHandler) GetImbalance(w http.ResponseWriter, r *http.Request) {
+	instrumentPair := r.PathValue("instrument_pair")
+
+	depth, err := queryPositiveInt(r, "depth")
+	if err != nil {
+		h.log.Errorw("invalid depth parameter", "depth", r.URL.Query().Get("depth"))
+		errorHandler(w, http.StatusBadRequest, "Invalid depth parameter")
+		return
+	}
+
+	imbalance, err := h.orderUseCase.GetImbalance(instrumentPair, depth)
+	if err != nil {
+		h.log.Errorw("failed to get order book imbalance",
+			"instrument_pair", instrumentPair,
+			"depth", depth,
+			"error", err,
+		)
+		domainErrorHandler(w, err, http.StatusInternalServerError)
+		return
+	}
+
+	response := ImbalanceResponse{
+		InstrumentPair: instrumentPair,
+		Depth:          depth,
+		BidQuantity:    h.instruments.FormatQuantity(instrumentPair, imbalance.BidQuantity),
+		AskQuantity:    h.instruments.FormatQuantity(instrumentPair, imbalance.AskQuantity),
+		Imbalance:      h.instruments.FormatQuantity(instrumentPair, imbalance.Imbalance),
+	}
+	if imbalance.Ratio != nil {
+		ratio := imbalance.Ratio.StringFixed(4)
+		response.Ratio = &ratio
+	}
 
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(response)
 }
+
+type CostEstimateResponse struct {
+	InstrumentPair string `json:"instrument_pair"`
+	Side           string `json:"side"`
+	Quantity       string `json:"quantity"`
+	FilledQuantity string `json:"filled_quantity"`
+	// AveragePrice is null when nothing would fill.
+	AveragePrice  *string `json:"average_price"`
+	TotalCost     string  `json:"total_cost"`
+	FullyFillable bool    `json:"fully_fillable"`
+}
+
+// EstimateCost answers what filling quantity on side (buy or sell, or the
+// bid/ask aliases) would cost against the current book, without placing an
+// order.
+func (h *orderHandler) EstimateCost(w http.ResponseWriter, r *http.Request) {
+	instrumentPair := r.PathValue("instrument_pair")
+	side := strings.ToLower(r.URL.Query().Get("side"))
+	orderType := strings.ToUpper(normalizeOrderType(side))
+
+	quantity, err := parseDecimal(r.URL.Query().Get("quantity"))
+	if err != nil {
+		h.log.Errorw("invalid quantity format", "error", err)
+		errorHandler(w, http.StatusBadRequest, invalidDecimalMessage(err, "quantity", "Invalid quantity format"))
+		return
+	}
+
+	estimate, err := h.orderUseCase.EstimateCost(instrumentPair, orderType, quantity)
+	if err != nil {
+		h.log.Errorw("failed to estimate cost",
+			"instrument_pair", instrumentPair,
+			"side", side,
+			"error", err,
+		)
+		domainErrorHandler(w, err, http.StatusInternalServerError)
+		return
+	}
+
+	response := CostEstimateResponse{
+		InstrumentPair: instrumentPair,
+		Side:           strings.ToLower(estimate.OrderType),
+		Quantity:       h.instruments.FormatQuantity(instrumentPair, estimate.Quantity),
+		FilledQuantity: h.instruments.FormatQuantity(instrumentPair, estimate.FilledQuantity),
+		TotalCost:      h.instruments.FormatPrice(instrumentPair, estimate.TotalCost),
+		FullyFillable:  estimate.FullyFillable,
+	}
+	if estimate.FilledQuantity.IsPositive() {
+		averagePrice := h.instruments.FormatPrice(instrumentPair, estimate.AveragePrice)
+		response.AveragePrice = &averagePrice
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+type OrderFillsResponse struct {
+	Order OrderResponse  `json:"order"`
+	Fills []FillResponse `json:"fills"`
+}
+
+type OrderResponse struct {
+	ID                uuid.UUID `json:"id"`
+	AccountID         uuid.UUID `json:"account_id"`
+	InstrumentPair    string    `json:"instrument_pair"`
+	OrderType         string    `json:"order_type"`
+	Price             string    `json:"price"`
+	Quantity          string    `json:"quantity"`
+	RemainingQuantity string    `json:"remaining_quantity"`
+	Status            string    `json:"status"`
+	CreatedAt         time.Time `json:"created_at"`
+	UpdatedAt         time.Time `json:"updated_at"`
+}
+
+type FillResponse struct {
+	TradeID           uuid.UUID `json:"trade_id"`
+	Price             string    `json:"price"`
+	Quantity          string    `json:"quantity"`
+	RemainingQuantity string    `json:"remaining_quantity"`
+	ExecutedAt        time.Time `json:"executed_at"`
+}
+
+func (h *orderHandler) GetOrderFills(w http.ResponseWriter, r *http.Request) {
+	orderID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		h.log.Errorw("invalid order id", "error", err)
+		errorHandler(w, http.StatusBadRequest, "Invalid order ID")
+		return
+	}
+
+	result, err := h.orderUseCase.GetOrderFills(orderID)
+	if err != nil {
+		h.log.Errorw("failed to get order fills", "id", orderID, "error", err)
+		if errors.Is(err, repository.ErrNotFound) {
+			errorHandler(w, http.StatusNotFound, "Order not found")
+			return
+		}
+		errorHandler(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	order := result.Order
+	response := OrderFillsResponse{
+		Order: OrderResponse{
+			ID:                order.ID,
+			AccountID:         order.AccountID,
+			InstrumentPair:    order.InstrumentPair,
+			OrderType:         order.OrderType,
+			Price:             h.instruments.FormatPrice(order.InstrumentPair, order.Price),
+			Quantity:          h.instruments.FormatQuantity(order.InstrumentPair, order.Quantity),
+			RemainingQuantity: h.instruments.FormatQuantity(order.InstrumentPair, order.RemainingQuantity),
+			Status:            order.Status,
+			CreatedAt:         order.CreatedAt,
+			UpdatedAt:         order.UpdatedAt,
+		},
+		Fills: make([]FillResponse, len(result.Fills)),
+	}
+	for i, fill := range result.Fills {
+		response.Fills[i] = FillResponse{
+			TradeID:           fill.Trade.ID,
+			Price:             h.instruments.FormatPrice(order.InstrumentPair, fill.Trade.Price),
+			Quantity:          h.instruments.FormatQuantity(order.InstrumentPair, fill.Trade.Quantity),
+			RemainingQuantity: h.instruments.FormatQuantity(order.InstrumentPair, fill.RemainingQuantity),
+			ExecutedAt:        fill.Trade.ExecutedAt,
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+type QueuePositionResponse struct {
+	OrderID        uuid.UUID `json:"order_id"`
+	InstrumentPair string    `json:"instrument_pair"`
+	OrderType      string    `json:"order_type"`
+	Price          string    `json:"price"`
+	Position       int64     `json:"position"`
+	OrdersAhead    int64     `json:"orders_ahead"`
+	QuantityAhead  string    `json:"quantity_ahead"`
+}
+
+func (h *orderHandler) GetQueuePosition(w http.ResponseWriter, r *http.Request) {
+	orderID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		h.log.Errorw("invalid order id", "error", err)
+		errorHandler(w, http.StatusBadRequest, "Invalid order ID")
+		return
+	}
+
+	position, err := h.orderUseCase.GetQueuePosition(orderID)
+	if err != nil {
+		h.log.Errorw("failed to get queue position", "id", orderID, "error", err)
+		switch {
+		case errors.Is(err, repository.ErrNotFound):
+			errorHandler(w, http.StatusNotFound, "Order not found")
+		default:
+			domainErrorHandler(w, err, http.StatusInternalServerError)
+		}
+		return
+	}
+
+	order := position.Order
+	response := QueuePositionResponse{
+		OrderID:        order.ID,
+		InstrumentPair: order.InstrumentPair,
+		OrderType:      order.OrderType,
+		Price:          h.instruments.FormatPrice(order.InstrumentPair, order.Price),
+		Position:       position.Position,
+		OrdersAhead:    position.OrdersAhead,
+		QuantityAhead:  h.instruments.FormatQuantity(order.InstrumentPair, position.QuantityAhead),
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+type MatchCandidatesResponse struct {
+	OrderID        uuid.UUID                 `json:"order_id"`
+	InstrumentPair string                    `json:"instrument_pair"`
+	OrderType      string                    `json:"order_type"`
+	Price          string                    `json:"price"`
+	Candidates     []*MatchCandidateResponse `json:"candidates"`
+}
+
+type MatchCandidateResponse struct {
+	OrderID           uuid.UUID `json:"order_id"`
+	AccountID         uuid.UUID `json:"account_id"`
+	Price             string    `json:"price"`
+	RemainingQuantity string    `json:"remaining_quantity"`
+	CreatedAt         time.Time `json:"created_at"`
+	AgeSeconds        int64     `json:"age_seconds"`
+}
+
+// GetMatchCandidates lists, for debugging, the resting orders an order would
+// match against right now, in priority order. Nothing is executed.
+func (h *orderHandler) GetMatchCandidates(w http.ResponseWriter, r *http.Request) {
+	orderID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		h.log.Errorw("invalid order id", "error", err)
+		errorHandler(w, http.StatusBadRequest, "Invalid order ID")
+		return
+	}
+
+	limit, err := queryLimit(r)
+	if err != nil {
+		errorHandler(w, http.StatusBadRequest, "limit must be a positive integer")
+		return
+	}
+
+	candidates, err := h.orderUseCase.GetMatchCandidates(orderID, limit)
+	if err != nil {
+		h.log.Errorw("failed to get match candidates", "id", orderID, "error", err)
+		if errors.Is(err, repository.ErrNotFound) {
+			errorHandler(w, http.StatusNotFound, "Order not found")
+			return
+		}
+		errorHandler(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	order := candidates.Order
+	response := MatchCandidatesResponse{
+		OrderID:        order.ID,
+		InstrumentPair: order.InstrumentPair,
+		OrderType:      order.OrderType,
+		Price:          h.instruments.FormatPrice(order.InstrumentPair, order.Price),
+		Candidates:     make([]*MatchCandidateResponse, len(candidates.Candidates)),
+	}
+	for i, candidate := range candidates.Candidates {
+		response.Candidates[i] = &MatchCandidateResponse{
+			OrderID:           candidate.Order.ID,
+			AccountID:         candidate.Order.AccountID,
+			Price:             h.instruments.FormatPrice(order.InstrumentPair, candidate.Order.Price),
+			RemainingQuantity: h.instruments.FormatQuantity(order.InstrumentPair, candidate.Order.RemainingQuantity),
+			CreatedAt:         candidate.Order.CreatedAt,
+			AgeSeconds:        int64(candidate.Age / time.Second),
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+type OrderSummaryResponse struct {
+	InstrumentPair  string `json:"instrument_pair"`
+	Open            int64  `json:"open"`
+	PartiallyFilled int64  `json:"partially_filled"`
+	Filled          int64  `json:"filled"`
+	Cancelled       int64  `json:"cancelled"`
+}
+
+func (h *orderHandler) GetOrderSummary(w http.ResponseWriter, r *http.Request) {
+	instrumentPair := r.PathValue("instrument_pair")
+
+	from, err := queryTime(r, "from")
+	if err != nil {
+		h.log.Errorw("invalid from parameter", "from", r.URL.Query().Get("from"))
+		errorHandler(w, http.StatusBadRequest, "Invalid from parameter")
+		return
+	}
+
+	to, err := queryTime(r, "to")
+	if err != nil {
+		h.log.Errorw("invalid to parameter", "to", r.URL.Query().Get("to"))
+		errorHandler(w, http.StatusBadRequest, "Invalid to parameter")
+		return
+	}
+
+	summary, err := h.orderUseCase.GetOrderSummary(instrumentPair, from, to)
+	if err != nil {
+		h.log.Errorw("failed to get order summary", "instrument_pair", instrumentPair, "error", err)
+		domainErrorHandler(w, err, http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(OrderSummaryResponse{
+		InstrumentPair:  summary.InstrumentPair,
+		Open:            summary.Open,
+		PartiallyFilled: summary.PartiallyFilled,
+		Filled:          summary.Filled,
+		Cancelled:       summary.Cancelled,
+	})
+}
+
+const defaultSpreadHistoryWindow = 24 * time.Hour
+
+// SpreadSnapshotResponse is one point of a spread history. A side that had no
+// resting orders is null, and so is the spread.
+type SpreadSnapshotResponse struct {
+	TakenAt time.Time `json:"taken_at"`
+	BestBid *string   `json:"best_bid"`
+	BestAsk *string   `json:"best_ask"`
+	Spread  *string   `json:"spread"`
+}
+
+type SpreadHistoryResponse struct {
+	InstrumentPair string                   `json:"instrument_pair"`
+	From           time.Time                `json:"from"`
+	To             time.Time                `json:"to"`
+	Snapshots      []SpreadSnapshotResponse `json:"snapshots"`
+}
+
+func (h *orderHandler) GetSpreadHistory(w http.ResponseWriter, r *http.Request) {
+	instrumentPair := r.PathValue("instrument_pair")
+
+	window := defaultSpreadHistoryWindow
+	if v := r.URL.Query().Get("window"); v != "" {
+		parsed, err := time.ParseDuration(v)
+		if err != nil {
+			h.log.Errorw("invalid window parameter", "window", v, "error", err)
+			errorHandler(w, http.StatusBadRequest, "Invalid window parameter")
+			return
+		}
+		window = parsed
+	}
+
+	var interval time.Duration
+	if v := r.URL.Query().Get("interval"); v != "" {
+		parsed, err := time.ParseDuration(v)
+		if err != nil {
+			h.log.Errorw("invalid interval parameter", "interval", v, "error", err)
+			errorHandler(w, http.StatusBadRequest, "Invalid interval parameter")
+			return
+		}
+		interval = parsed
+	}
+
+	history, err := h.orderUseCase.GetSpreadHistory(instrumentPair, window, interval)
+	if err != nil {
+		h.log.Errorw("failed to get spread history",
+			"instrument_pair", instrumentPair,
+			"window", window,
+			"interval", interval,
+			"error", err,
+		)
+		domainErrorHandler(w, err, http.StatusInternalServerError)
+		return
+	}
+
+	response := SpreadHistoryResponse{
+		InstrumentPair: instrumentPair,
+		From:           history.From,
+		To:             history.To,
+		Snapshots:      make([]SpreadSnapshotResponse, len(history.Snapshots)),
+	}
+	for i, snapshot := range history.Snapshots {
+		point := SpreadSnapshotResponse{TakenAt: snapshot.TakenAt}
+		if snapshot.BestBid.Valid {
+			bid := h.instruments.FormatPrice(instrumentPair, snapshot.BestBid.Decimal)
+			point.BestBid = &bid
+		}
+		if snapshot.BestAsk.Valid {
+			ask := h.instruments.FormatPrice(instrumentPair, snapshot.BestAsk.Decimal)
+			point.BestAsk = &ask
+		}
+		if spread, ok := snapshot.Spread(); ok {
+			formatted := h.instruments.FormatPrice(instrumentPair, spread)
+			point.Spread = &formatted
+		}
+		response.Snapshots[i] = point
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+type RejectionResponse struct {
+	ID              uuid.UUID `json:"id"`
+	InstrumentPair  string    `json:"instrument_pair"`
+	OrderType       string    `json:"order_type"`
+	Price           string    `json:"price"`
+	Quantity        string    `json:"quantity"`
+	MinFillQuantity string    `json:"min_fill_quantity"`
+	Reason          string    `json:"reason"`
+	Message         string    `json:"message"`
+	RejectedAt      time.Time `json:"rejected_at"`
+}
+
+type FilledOrderResponse struct {
+	OrderResponse
+	BaseFilled     string `json:"base_filled"`
+	QuoteExchanged string `json:"quote_exchanged"`
+	AveragePrice   string `json:"average_price"`
+}
+
+func (h *orderHandler) GetFilledOrders(w http.ResponseWriter, r *http.Request) {
+	accountID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		h.log.Errorw("invalid account id", "error", err)
+		errorHandler(w, http.StatusBadRequest, "Invalid account ID")
+		return
+	}
+
+	limit, err := queryLimit(r)
+	if err != nil {
+		h.log.Errorw("invalid limit parameter", "limit", r.URL.Query().Get("limit"))
+		errorHandler(w, http.StatusBadRequest, "Invalid limit parameter")
+		return
+	}
+
+	var before uuid.UUID
+	if v := r.URL.Query().Get("cursor"); v != "" {
+		if before, err = uuid.Parse(v); err != nil {
+			h.log.Errorw("invalid cursor parameter", "cursor", v)
+			errorHandler(w, http.StatusBadRequest, "Invalid cursor parameter")
+			return
+		}
+	}
+
+	page, err := h.orderUseCase.GetFilledOrders(accountID, before, limit)
+	if err != nil {
+		h.log.Errorw("failed to get filled orders", "account_id", accountID, "error", err)
+		errorHandler(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	response := make([]FilledOrderResponse, len(page.Orders))
+	for i, filled := range page.Orders {
+		order := filled.Order
+		response[i] = FilledOrderResponse{
+			OrderResponse: OrderResponse{
+				ID:                order.ID,
+				AccountID:         order.AccountID,
+				InstrumentPair:    order.InstrumentPair,
+				OrderType:         order.OrderType,
+				Price:             h.instruments.FormatPrice(order.InstrumentPair, order.Price),
+				Quantity:          h.instruments.FormatQuantity(order.InstrumentPair, order.Quantity),
+				RemainingQuantity: h.instruments.FormatQuantity(order.InstrumentPair, order.RemainingQuantity),
+				Status:            order.Status,
+				CreatedAt:         order.CreatedAt,
+				UpdatedAt:         order.UpdatedAt,
+			},
+			BaseFilled:     h.instruments.FormatQuantity(order.InstrumentPair, filled.BaseFilled),
+			QuoteExchanged: h.instruments.FormatPrice(order.InstrumentPair, filled.QuoteExchanged),
+			AveragePrice:   h.instruments.FormatPrice(order.InstrumentPair, filled.AveragePrice),
+		}
+	}
+
+	var nextCursor string
+	if page.NextCursor != uuid.Nil {
+		nextCursor = page.NextCursor.String()
+	}
+	writeList(w, response, nextCursor)
+}
+
+type AccountMarketResponse struct {
+	InstrumentPair string                    `json:"instrument_pair"`
+	OpenOrders     int64                     `json:"open_orders"`
+	LastTrade      *AccountLastTradeResponse `json:"last_trade"`
+}
+
+type AccountLastTradeResponse struct {
+	TradeID    uuid.UUID `json:"trade_id"`
+	Price      string    `json:"price"`
+	Quantity   string    `json:"quantity"`
+	ExecutedAt time.Time `json:"executed_at"`
+}
+
+func (h *orderHandler) GetAccountMarkets(w http.ResponseWriter, r *http.Request) {
+	accountID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		h.log.Errorw("invalid account id", "error", err)
+		errorHandler(w, http.StatusBadRequest, "Invalid account ID")
+		return
+	}
+
+	markets, err := h.orderUseCase.GetAccountMarkets(accountID)
+	if err != nil {
+		h.log.Errorw("failed to get account markets", "account_id", accountID, "error", err)
+		errorHandler(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	response := make([]AccountMarketResponse, len(markets))
+	for i, market := range markets {
+		response[i] = AccountMarketResponse{
+			InstrumentPair: market.InstrumentPair,
+			OpenOrders:     market.OpenOrders,
+		}
+		if trade := market.LastTrade; trade != nil {
+			response[i].LastTrade = &AccountLastTradeResponse{
+				TradeID:    trade.ID,
+				Price:      h.instruments.FormatPrice(trade.InstrumentPair, trade.Price),
+				Quantity:   h.instruments.FormatQuantity(trade.InstrumentPair, trade.Quantity),
+				ExecutedAt: trade.ExecutedAt,
+			}
+		}
+	}
+
+	writeList(w, response, "")
+}
+
+func (h *orderHandler) GetAccountRejections(w http.ResponseWriter, r *http.Request) {
+	accountID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		h.log.Errorw("invalid account id", "error", err)
+		errorHandler(w, http.StatusBadRequest, "Invalid account ID")
+		return
+	}
+
+	limit, err := queryLimit(r)
+	if err != nil {
+		h.log.Errorw("invalid limit parameter", "limit", r.URL.Query().Get("limit"))
+		errorHandler(w, http.StatusBadRequest, "Invalid limit parameter")
+		return
+	}
+
+	rejections, err := h.orderUseCase.GetRejections(accountID, limit)
+	if err != nil {
+		h.log.Errorw("failed to get order rejections", "account_id", accountID, "error", err)
+		errorHandler(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	response := make([]RejectionResponse, len(rejections))
+	for i, rejection := range rejections {
+		response[i] = RejectionResponse{
+			ID:              rejection.ID,
+			InstrumentPair:  rejection.InstrumentPair,
+			OrderType:       rejection.OrderType,
+			Price:           h.instruments.FormatPrice(rejection.InstrumentPair, rejection.Price),
+			Quantity:        h.instruments.FormatQuantity(rejection.InstrumentPair, rejection.Quantity),
+			MinFillQuantity: h.instruments.FormatQuantity(rejection.InstrumentPair, rejection.MinFillQuantity),
+			Reason:          rejection.Reason,
+			Message:         rejection.Message,
+			RejectedAt:      rejection.CreatedAt,
+		}
+	}
+
+	writeList(w, response, "")
+}
@@ -1,14 +1,18 @@
 package handler
 
 import (
+	"context"
 	"encoding/json"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/lucas-moura1/mercadobitcoin-challenge/entity"
+	"github.com/lucas-moura1/mercadobitcoin-challenge/repository"
 	"github.com/lucas-moura1/mercadobitcoin-challenge/usecase"
 	"github.com/shopspring/decimal"
 	"github.com/stretchr/testify/assert"
@@ -24,11 +28,11 @@ func TestOrderHandler_CancelOrder(t *testing.T) {
 		wantStatus int
 	}{
 		{
-			name:      "success returns 200",
+			name:      "success returns 200, also for an already cancelled order",
 			pathValue: uuid.New().String(),
 			setupMock: func(m *usecase.MockOrderUseCase, id string) {
 				uid, _ := uuid.Parse(id)
-				m.EXPECT().CancelOrder(uid).Return(nil).Times(1)
+				m.EXPECT().CancelOrder(gomock.Any(), uid, uuid.Nil).Return(nil).Times(1)
 			},
 			wantStatus: http.StatusOK,
 		},
@@ -38,12 +42,39 @@ func TestOrderHandler_CancelOrder(t *testing.T) {
 			setupMock:  func(m *usecase.MockOrderUseCase, id string) {},
 			wantStatus: http.StatusBadRequest,
 		},
+		{
+			name:      "unknown order returns 404",
+			pathValue: uuid.New().String(),
+			setupMock: func(m *usecase.MockOrderUseCase, id string) {
+				uid, _ := uuid.Parse(id)
+				m.EXPECT().CancelOrder(gomock.Any(), uid, uuid.Nil).Return(repository.ErrNotFound).Times(1)
+			},
+			wantStatus: http.StatusNotFound,
+		},
+		{
+			name:      "filled order returns 409",
+			pathValue: uuid.New().String(),
+			setupMock: func(m *usecase.MockOrderUseCase, id string) {
+				uid, _ := uuid.Parse(id)
+				m.EXPECT().CancelOrder(gomock.Any(), uid, uuid.Nil).Return(entity.ErrOrderFilled).Times(1)
+			},
+			wantStatus: http.StatusConflict,
+		},
+		{
+			name:      "partially filled order returns 409",
+			pathValue: uuid.New().String(),
+			setupMock: func(m *usecase.MockOrderUseCase, id string) {
+				uid, _ := uuid.Parse(id)
+				m.EXPECT().CancelOrder(gomock.Any(), uid, uuid.Nil).Return(entity.ErrOrderNotOpen).Times(1)
+			},
+			wantStatus: http.StatusConflict,
+		},
 		{
 			name:      "usecase error returns 500",
 			pathValue: uuid.New().String(),
 			setupMock: func(m *usecase.MockOrderUseCase, id string) {
 				uid, _ := uuid.Parse(id)
-				m.EXPECT().CancelOrder(uid).Return(assert.AnError).Times(1)
+				m.EXPECT().CancelOrder(gomock.Any(), uid, uuid.Nil).Return(assert.AnError).Times(1)
 			},
 			wantStatus: http.StatusInternalServerError,
 		},
@@ -56,7 +87,7 @@ func TestOrderHandler_CancelOrder(t *testing.T) {
 			defer ctrl.Finish()
 
 			mockUC := usecase.NewMockOrderUseCase(ctrl)
-			h := NewOrderHandler(zap.NewNop().Sugar(), mockUC)
+			h := NewOrderHandler(zap.NewNop().Sugar(), mockUC, nil, ImportLimits{})
 
 			req := httptest.NewRequest(http.MethodPost, "/orders/{id}/cancel", nil)
 			req.SetPathValue("id", tt.pathValue)
@@ -71,6 +102,75 @@ func TestOrderHandler_CancelOrder(t *testing.T) {
 	}
 }
 
+func TestOrderHandler_GetOrderBook_MinQuantity(t *testing.T) {
+	tests := []struct {
+		name       string
+		query      string
+		mockSetup  func(m *usecase.MockOrderUseCase)
+		wantStatus int
+	}{
+		{
+			name:  "threshold is passed to the usecase",
+			query: "?min_quantity=0.5",
+			mockSetup: func(m *usecase.MockOrderUseCase) {
+				m.EXPECT().GetOrderBook("BTC_BRL", decimal.RequireFromString("0.5")).
+					Return(&usecase.OrderBook{InstrumentPair: "BTC_BRL"}, nil).Times(1)
+			},
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:  "threshold applies to a single side",
+			query: "?side=ask&min_quantity=2",
+			mockSetup: func(m *usecase.MockOrderUseCase) {
+				m.EXPECT().GetOrderBookSide("BTC_BRL", "ask", decimal.RequireFromString("2")).
+					Return(&usecase.OrderBook{InstrumentPair: "BTC_BRL"}, nil).Times(1)
+			},
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "malformed threshold returns 400",
+			query:      "?min_quantity=abc",
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "extreme exponent threshold returns 400",
+			query:      "?min_quantity=1e-1000000",
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:  "negative threshold returns 400",
+			query: "?min_quantity=-1",
+			mockSetup: func(m *usecase.MockOrderUseCase) {
+				m.EXPECT().GetOrderBook("BTC_BRL", decimal.RequireFromString("-1")).
+					Return(nil, entity.ErrInvalidMinQuantity).Times(1)
+			},
+			wantStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockUC := usecase.NewMockOrderUseCase(ctrl)
+			h := NewOrderHandler(zap.NewNop().Sugar(), mockUC, nil, ImportLimits{})
+			if tt.mockSetup != nil {
+				tt.mockSetup(mockUC)
+			}
+
+			req := httptest.NewRequest(http.MethodGet, "/orders/{instrument_pair}"+tt.query, nil)
+			req.SetPathValue("instrument_pair", "BTC_BRL")
+			respWriter := httptest.NewRecorder()
+
+			h.GetOrderBook(respWriter, req)
+
+			assert.Equal(t, tt.wantStatus, respWriter.Code)
+		})
+	}
+}
+
 func TestOrderHandler_GetOrderBook(t *testing.T) {
 	tests := []struct {
 		name       string
@@ -82,7 +182,7 @@ func TestOrderHandler_GetOrderBook(t *testing.T) {
 			name: "invalid instrument pair returns 400",
 			pair: "BTCBRL",
 			mockSetup: func(m *usecase.MockOrderUseCase, pair string) {
-				m.EXPECT().GetOrderBook(pair).Return(nil, entity.ErrInvalidPairFormat).Times(1)
+				m.EXPECT().GetOrderBook(pair, decimal.Zero).Return(nil, entity.ErrInvalidPairFormat).Times(1)
 			},
 			wantStatus: http.StatusBadRequest,
 		},
@@ -90,17 +190,17 @@ func TestOrderHandler_GetOrderBook(t *testing.T) {
 			name: "usecase error returns 500",
 			pair: "BTC_BRL",
 			mockSetup: func(m *usecase.MockOrderUseCase, pair string) {
-				m.EXPECT().GetOrderBook(pair).Return(nil, assert.AnError).Times(1)
+				m.EXPECT().GetOrderBook(pair, decimal.Zero).Return(nil, assert.AnError).Times(1)
 			},
 			wantStatus: http.StatusInternalServerError,
 		},
 		{
-			name: "not found (nil orderbook) returns 404",
-			pair: "BTC_BRL",
+			name: "unsupported pair returns 400",
+			pair: "XRP_BRL",
 			mockSetup: func(m *usecase.MockOrderUseCase, pair string) {
-				m.EXPECT().GetOrderBook(pair).Return(nil, nil).Times(1)
+				m.EXPECT().GetOrderBook(pair, decimal.Zero).Return(nil, fmt.Errorf("%w: XRP", entity.ErrUnsupportedAsset)).Times(1)
 			},
-			wantStatus: http.StatusNotFound,
+			wantStatus: http.StatusBadRequest,
 		},
 		{
 			name: "success returns 200 and body",
@@ -117,7 +217,7 @@ func TestOrderHandler_GetOrderBook(t *testing.T) {
 						{Price: decimal.RequireFromString("103"), Quantity: decimal.RequireFromString("0.2")},
 					},
 				}
-				m.EXPECT().GetOrderBook(pair).Return(ob, nil).Times(1)
+				m.EXPECT().GetOrderBook(pair, decimal.Zero).Return(ob, nil).Times(1)
 			},
 			wantStatus: http.StatusOK,
 		},
@@ -130,7 +230,7 @@ func TestOrderHandler_GetOrderBook(t *testing.T) {
 			defer ctrl.Finish()
 
 			mockUC := usecase.NewMockOrderUseCase(ctrl)
-			h := NewOrderHandler(zap.NewNop().Sugar(), mockUC)
+			h := NewOrderHandler(zap.NewNop().Sugar(), mockUC, nil, ImportLimits{})
 
 			if tt.mockSetup != nil {
 				tt.mockSetup(mockUC, tt.pair)
@@ -181,7 +281,7 @@ func TestOrderHandler_CreateOrder(t *testing.T) {
 			body: `{"account_id":"` + uid + `","instrument_pair":"BTC_BRL","order_type":"buy","price":"200000.00","quantity":"0.50"}`,
 			mockSetup: func(m *usecase.MockOrderUseCase) {
 				m.EXPECT().
-					CreateOrder(gomock.Any()).
+					CreateOrder(gomock.Any(), gomock.Any()).
 					Return(nil).
 					Times(1)
 			},
@@ -206,15 +306,26 @@ func TestOrderHandler_CreateOrder(t *testing.T) {
 			wantStatus: http.StatusBadRequest,
 		},
 		{
-			name: "usecase returns error returns 400",
-			body: `{"account_id":"` + uid + `","instrument_pair":"BTC_BRL","order_type":"buy","price":"200000","quantity":"0.5"}`,
+			name: "usecase returns error returns 500",
+			body: `{"account_id":"` + uid + `","instrument_pair":"BTC_BRL","order_type":"BUY","price":"200000","quantity":"0.5"}`,
 			mockSetup: func(m *usecase.MockOrderUseCase) {
 				m.EXPECT().
-					CreateOrder(gomock.Any()).
+					CreateOrder(gomock.Any(), gomock.Any()).
 					Return(assert.AnError).
 					Times(1)
 			},
-			wantStatus: http.StatusBadRequest,
+			wantStatus: http.StatusInternalServerError,
+		},
+		{
+			name: "halted market returns 503",
+			body: `{"account_id":"` + uid + `","instrument_pair":"BTC_BRL","order_type":"buy","price":"200000","quantity":"0.5"}`,
+			mockSetup: func(m *usecase.MockOrderUseCase) {
+				m.EXPECT().
+					CreateOrder(gomock.Any(), gomock.Any()).
+					Return(entity.ErrMarketHalted).
+					Times(1)
+			},
+			wantStatus: http.StatusServiceUnavailable,
 		},
 	}
 
@@ -225,7 +336,7 @@ func TestOrderHandler_CreateOrder(t *testing.T) {
 			defer ctrl.Finish()
 
 			mockUC := usecase.NewMockOrderUseCase(ctrl)
-			h := NewOrderHandler(zap.NewNop().Sugar(), mockUC)
+			h := NewOrderHandler(zap.NewNop().Sugar(), mockUC, nil, ImportLimits{})
 
 			tt.mockSetup(mockUC)
 
@@ -252,3 +363,2177 @@ func TestOrderHandler_CreateOrder(t *testing.T) {
 		})
 	}
 }
+
+func TestOrderHandler_CreateOrder_UnknownField(t *testing.T) {
+	body := `{"account_id":"` + uuid.New().String() + `","instrument_pair":"BTC_BRL","order_type":"BUY","price":"200000","quantity":"0.5","qty":"0.5"}`
+
+	tests := []struct {
+		name       string
+		strict     bool
+		wantStatus int
+		wantError  string
+	}{
+		{
+			name:       "ignored by default",
+			strict:     false,
+			wantStatus: http.StatusCreated,
+		},
+		{
+			name:       "rejected with the field named when strict",
+			strict:     true,
+			wantStatus: http.StatusBadRequest,
+			wantError:  `Unknown field "qty"`,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			SetStrictJSON(tt.strict)
+			t.Cleanup(func() { SetStrictJSON(false) })
+
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockUC := usecase.NewMockOrderUseCase(ctrl)
+			if tt.wantStatus == http.StatusCreated {
+				mockUC.EXPECT().CreateOrder(gomock.Any(), gomock.Any()).Return(nil).Times(1)
+			}
+			h := NewOrderHandler(zap.NewNop().Sugar(), mockUC, nil, ImportLimits{})
+
+			req := httptest.NewRequest(http.MethodPost, "/orders", strings.NewReader(body))
+			respWriter := httptest.NewRecorder()
+
+			h.CreateOrder(respWriter, req)
+
+			assert.Equal(t, tt.wantStatus, respWriter.Code)
+			if tt.wantError != "" {
+				var resp map[string]string
+				assert.NoError(t, json.Unmarshal(respWriter.Body.Bytes(), &resp))
+				assert.Equal(t, tt.wantError, resp["error"])
+			}
+		})
+	}
+}
+
+func TestOrderHandler_CreateOrder_QuoteQuantity(t *testing.T) {
+	uid := uuid.New().String()
+	prefix := `{"account_id":"` + uid + `","instrument_pair":"BTC_BRL","order_type":"BUY",`
+
+	tests := []struct {
+		name       string
+		body       string
+		wantCalled bool
+		wantStatus int
+		wantError  string
+	}{
+		{
+			name:       "market buy passes the budget without price or quantity",
+			body:       prefix + `"quote_quantity":"10000"}`,
+			wantCalled: true,
+			wantStatus: http.StatusCreated,
+		},
+		{
+			name:       "quote quantity with quantity returns 400",
+			body:       prefix + `"quote_quantity":"10000","quantity":"0.5"}`,
+			wantStatus: http.StatusBadRequest,
+			wantError:  entity.ErrQuoteQuantityWithQuantity.Error(),
+		},
+		{
+			name:       "quote quantity with price returns 400",
+			body:       prefix + `"quote_quantity":"10000","price":"200000"}`,
+			wantStatus: http.StatusBadRequest,
+			wantError:  entity.ErrQuoteQuantityWithPrice.Error(),
+		},
+		{
+			name:       "invalid quote quantity format returns 400",
+			body:       prefix + `"quote_quantity":"lots"}`,
+			wantStatus: http.StatusBadRequest,
+			wantError:  "Invalid quote quantity format",
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockUC := usecase.NewMockOrderUseCase(ctrl)
+			instruments := entity.NewInstrumentConfig(entity.Asset{Symbol: "BTC", Scale: 8}, entity.Asset{Symbol: "BRL", Scale: 2})
+			h := NewOrderHandler(zap.NewNop().Sugar(), mockUC, instruments, ImportLimits{})
+			if tt.wantCalled {
+				mockUC.EXPECT().CreateOrder(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, order *entity.Order) error {
+					assert.True(t, entity.DecimalEqual(decimal.RequireFromString("10000"), order.QuoteQuantity))
+					assert.True(t, order.Price.IsZero())
+					assert.True(t, order.Quantity.IsZero())
+					// Matching sets the base bought.
+					order.Quantity = decimal.RequireFromString("0.05")
+					order.Status = string(entity.OrderStatusFilled)
+					return nil
+				}).Times(1)
+			}
+
+			req := httptest.NewRequest(http.MethodPost, "/orders", strings.NewReader(tt.body))
+			respWriter := httptest.NewRecorder()
+
+			h.CreateOrder(respWriter, req)
+
+			assert.Equal(t, tt.wantStatus, respWriter.Code)
+			if tt.wantError != "" {
+				assert.JSONEq(t, `{"error":"`+tt.wantError+`"}`, respWriter.Body.String())
+			}
+			if respWriter.Code == http.StatusCreated {
+				var resp CreateOrderResponse
+				assert.NoError(t, json.Unmarshal(respWriter.Body.Bytes(), &resp))
+				assert.Equal(t, "0.05000000", resp.Quantity)
+				if assert.NotNil(t, resp.QuoteQuantity) {
+					assert.Equal(t, "10000.00", *resp.QuoteQuantity)
+				}
+			}
+		})
+	}
+}
+
+func TestOrderHandler_CreateOrder_ExpiresAt(t *testing.T) {
+	prefix := `{"account_id":"` + uuid.New().String() + `","instrument_pair":"BTC_BRL","order_type":"SELL","price":"100","quantity":"1",`
+	want := time.Date(2030, 1, 1, 3, 0, 0, 0, time.UTC)
+
+	t.Run("expiry is passed through in UTC", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockUC := usecase.NewMockOrderUseCase(ctrl)
+		mockUC.EXPECT().CreateOrder(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, order *entity.Order) error {
+			if assert.NotNil(t, order.ExpiresAt) {
+				assert.Equal(t, want, *order.ExpiresAt)
+			}
+			return nil
+		}).Times(1)
+		h := NewOrderHandler(zap.NewNop().Sugar(), mockUC, nil, ImportLimits{})
+
+		req := httptest.NewRequest(http.MethodPost, "/orders", strings.NewReader(prefix+`"expires_at":"2030-01-01T00:00:00-03:00"}`))
+		respWriter := httptest.NewRecorder()
+
+		h.CreateOrder(respWriter, req)
+
+		assert.Equal(t, http.StatusCreated, respWriter.Code)
+		var resp CreateOrderResponse
+		assert.NoError(t, json.Unmarshal(respWriter.Body.Bytes(), &resp))
+		if assert.NotNil(t, resp.ExpiresAt) {
+			assert.True(t, want.Equal(*resp.ExpiresAt))
+		}
+	})
+
+	t.Run("malformed expiry returns 400", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		h := NewOrderHandler(zap.NewNop().Sugar(), usecase.NewMockOrderUseCase(ctrl), nil, ImportLimits{})
+
+		req := httptest.NewRequest(http.MethodPost, "/orders", strings.NewReader(prefix+`"expires_at":"tomorrow"}`))
+		respWriter := httptest.NewRecorder()
+
+		h.CreateOrder(respWriter, req)
+
+		assert.Equal(t, http.StatusBadRequest, respWriter.Code)
+	})
+}
+
+func TestOrderHandler_CreateOrder_Timestamps(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	createdAt := time.Date(2026, 1, 1, 12, 0, 0, 123456000, time.UTC)
+	updatedAt := createdAt.Add(time.Millisecond)
+
+	mockUC := usecase.NewMockOrderUseCase(ctrl)
+	mockUC.EXPECT().CreateOrder(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, order *entity.Order) error {
+		order.CreatedAt, order.UpdatedAt = createdAt, updatedAt
+		return nil
+	}).Times(1)
+	h := NewOrderHandler(zap.NewNop().Sugar(), mockUC, nil, ImportLimits{})
+
+	body := `{"account_id":"` + uuid.New().String() + `","instrument_pair":"BTC_BRL","order_type":"BUY","price":"100","quantity":"1"}`
+	req := httptest.NewRequest(http.MethodPost, "/orders", strings.NewReader(body))
+	respWriter := httptest.NewRecorder()
+
+	h.CreateOrder(respWriter, req)
+
+	assert.Equal(t, http.StatusCreated, respWriter.Code)
+	var resp CreateOrderResponse
+	assert.NoError(t, json.Unmarshal(respWriter.Body.Bytes(), &resp))
+	assert.False(t, resp.CreatedAt.IsZero())
+	assert.True(t, createdAt.Equal(resp.CreatedAt))
+	assert.True(t, updatedAt.Equal(resp.UpdatedAt))
+}
+
+func TestOrderHandler_CreateOrder_ReportsAllViolations(t *testing.T) {
+	tests := []struct {
+		name       string
+		body       string
+		useCaseErr error
+		wantBody   string
+	}{
+		{
+			name:       "every violation is listed",
+			body:       `{"account_id":"` + uuid.New().String() + `","instrument_pair":"BTCBRL","order_type":"BUY","price":"0","quantity":"1"}`,
+			useCaseErr: entity.ErrInvalidPrice,
+			wantBody:   `{"error":"price must be greater than zero","errors":["price must be greater than zero","invalid instrument pair format"]}`,
+		},
+		{
+			name:       "other rejections keep the plain error",
+			body:       `{"account_id":"` + uuid.New().String() + `","instrument_pair":"BTC_BRL","order_type":"BUY","price":"100","quantity":"1"}`,
+			useCaseErr: entity.ErrInsufficientBalance,
+			wantBody:   `{"error":"insufficient balance","code":"INSUFFICIENT_BALANCE"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockUC := usecase.NewMockOrderUseCase(ctrl)
+			mockUC.EXPECT().CreateOrder(gomock.Any(), gomock.Any()).Return(tt.useCaseErr).Times(1)
+			h := NewOrderHandler(zap.NewNop().Sugar(), mockUC, nil, ImportLimits{})
+
+			req := httptest.NewRequest(http.MethodPost, "/orders", strings.NewReader(tt.body))
+			respWriter := httptest.NewRecorder()
+
+			h.CreateOrder(respWriter, req)
+
+			assert.Equal(t, http.StatusBadRequest, respWriter.Code)
+			assert.JSONEq(t, tt.wantBody, respWriter.Body.String())
+		})
+	}
+}
+
+func TestOrderHandler_CreateOrder_MarketBuyFailureIsNotAViolation(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockUC := usecase.NewMockOrderUseCase(ctrl)
+	mockUC.EXPECT().CreateOrder(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, order *entity.Order) error {
+		// Planning the market buy sets the base quantity before the
+		// failure.
+		order.Quantity = decimal.RequireFromString("0.05")
+		return assert.AnError
+	}).Times(1)
+	h := NewOrderHandler(zap.NewNop().Sugar(), mockUC, nil, ImportLimits{})
+
+	body := `{"account_id":"` + uuid.New().String() + `","instrument_pair":"btc_brl","order_type":"BUY","quote_quantity":"10000"}`
+	req := httptest.NewRequest(http.MethodPost, "/orders", strings.NewReader(body))
+	respWriter := httptest.NewRecorder()
+
+	h.CreateOrder(respWriter, req)
+
+	assert.Equal(t, http.StatusInternalServerError, respWriter.Code)
+}
+
+func TestOrderHandler_CreateOrder_SideAliases(t *testing.T) {
+	tests := []struct {
+		name          string
+		orderType     string
+		wantOrderType string
+		useCaseErr    error
+		wantStatus    int
+	}{
+		{
+			name:          "bid is normalized to BUY",
+			orderType:     "bid",
+			wantOrderType: "BUY",
+			wantStatus:    http.StatusCreated,
+		},
+		{
+			name:          "ask is normalized to SELL",
+			orderType:     "ask",
+			wantOrderType: "SELL",
+			wantStatus:    http.StatusCreated,
+		},
+		{
+			name:          "canonical side is kept",
+			orderType:     "SELL",
+			wantOrderType: "SELL",
+			wantStatus:    http.StatusCreated,
+		},
+		{
+			name:          "unknown side is rejected",
+			orderType:     "offer",
+			wantOrderType: "offer",
+			useCaseErr:    entity.ErrInvalidOrderType,
+			wantStatus:    http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockUC := usecase.NewMockOrderUseCase(ctrl)
+			h := NewOrderHandler(zap.NewNop().Sugar(), mockUC, nil, ImportLimits{})
+
+			mockUC.EXPECT().
+				CreateOrder(gomock.Any(), gomock.Any()).
+				DoAndReturn(func(_ context.Context, o *entity.Order) error {
+					assert.Equal(t, tt.wantOrderType, o.OrderType)
+					return tt.useCaseErr
+				}).
+				Times(1)
+
+			body := `{"account_id":"` + uuid.New().String() + `","instrument_pair":"BTC_BRL","order_type":"` + tt.orderType + `","price":"200000","quantity":"0.5"}`
+			req := httptest.NewRequest(http.MethodPost, "/orders", strings.NewReader(body))
+			respWriter := httptest.NewRecorder()
+
+			h.CreateOrder(respWriter, req)
+
+			assert.Equal(t, tt.wantStatus, respWriter.Code)
+			if respWriter.Code == http.StatusCreated {
+				var resp CreateOrderResponse
+				assert.NoError(t, json.Unmarshal(respWriter.Body.Bytes(), &resp))
+				assert.Equal(t, tt.wantOrderType, resp.OrderType)
+			}
+		})
+	}
+}
+
+func TestOrderHandler_GetOrderBook_InstrumentScale(t *testing.T) {
+	instruments := entity.NewInstrumentConfig(
+		entity.Asset{Symbol: "BTC", Scale: 8},
+		entity.Asset{Symbol: "ETH", Scale: 4},
+		entity.Asset{Symbol: "BRL", Scale: 2},
+	)
+
+	tests := []struct {
+		name         string
+		pair         string
+		price        string
+		quantity     string
+		wantPrice    string
+		wantQuantity string
+	}{
+		{
+			name:         "BTC_BRL prices at 2 and quantities at 8",
+			pair:         "BTC_BRL",
+			price:        "100",
+			quantity:     "1.4",
+			wantPrice:    "100.00",
+			wantQuantity: "1.40000000",
+		},
+		{
+			name:         "ETH_BTC prices at 8 and quantities at 4",
+			pair:         "ETH_BTC",
+			price:        "0.06",
+			quantity:     "2.5",
+			wantPrice:    "0.06000000",
+			wantQuantity: "2.5000",
+		},
+		{
+			name:         "summed BTC quantity past 8 decimals is rounded to 8",
+			pair:         "BTC_BRL",
+			price:        "100",
+			quantity:     "1.000000005",
+			wantPrice:    "100.00",
+			wantQuantity: "1.00000001",
+		},
+		{
+			name:         "summed ETH quantity past 4 decimals is rounded to 4",
+			pair:         "ETH_BTC",
+			price:        "0.06",
+			quantity:     "2.50004999",
+			wantPrice:    "0.06000000",
+			wantQuantity: "2.5000",
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockUC := usecase.NewMockOrderUseCase(ctrl)
+			h := NewOrderHandler(zap.NewNop().Sugar(), mockUC, instruments, ImportLimits{})
+
+			level := &usecase.OrderBookEntry{
+				Price:    decimal.RequireFromString(tt.price),
+				Quantity: decimal.RequireFromString(tt.quantity),
+			}
+			mockUC.EXPECT().GetOrderBook(tt.pair, decimal.Zero).Return(&usecase.OrderBook{
+				InstrumentPair: tt.pair,
+				Bids:           []*usecase.OrderBookEntry{level},
+				Asks:           []*usecase.OrderBookEntry{level},
+			}, nil).Times(1)
+
+			req := httptest.NewRequest(http.MethodGet, "/orders/{instrument_pair}", nil)
+			req.SetPathValue("instrument_pair", tt.pair)
+			respWriter := httptest.NewRecorder()
+
+			h.GetOrderBook(respWriter, req)
+
+			assert.Equal(t, http.StatusOK, respWriter.Code)
+			var resp OrderBookResponse
+			assert.NoError(t, json.Unmarshal(respWriter.Body.Bytes(), &resp))
+			for _, lvl := range append(resp.Bids, resp.Asks...) {
+				assert.Equal(t, tt.wantPrice, lvl.Price)
+				assert.Equal(t, tt.wantQuantity, lvl.Quantity)
+			}
+		})
+	}
+}
+
+func TestOrderHandler_GetOrderBook_Invert(t *testing.T) {
+	instruments := entity.NewInstrumentConfig(entity.Asset{Symbol: "BTC", Scale: 8}, entity.Asset{Symbol: "BRL", Scale: 2})
+	book := &usecase.OrderBook{
+		InstrumentPair: "BTC_BRL",
+		Bids: []*usecase.OrderBookEntry{
+			{Price: decimal.RequireFromString("100000"), Quantity: decimal.RequireFromString("0.5")},
+			{Price: decimal.RequireFromString("99000"), Quantity: decimal.RequireFromString("1")},
+		},
+		Asks: []*usecase.OrderBookEntry{
+			{Price: decimal.RequireFromString("101000"), Quantity: decimal.RequireFromString("0.2")},
+			{Price: decimal.RequireFromString("103000"), Quantity: decimal.RequireFromString("0.1")},
+		},
+		Checksum: 42,
+	}
+
+	get := func(t *testing.T, query string) (int, OrderBookResponse) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockUC := usecase.NewMockOrderUseCase(ctrl)
+		mockUC.EXPECT().GetOrderBook("BTC_BRL", decimal.Zero).Return(book, nil).MaxTimes(1)
+		h := NewOrderHandler(zap.NewNop().Sugar(), mockUC, instruments, ImportLimits{})
+
+		req := httptest.NewRequest(http.MethodGet, "/orders/{instrument_pair}"+query, nil)
+		req.SetPathValue("instrument_pair", "BTC_BRL")
+		respWriter := httptest.NewRecorder()
+
+		h.GetOrderBook(respWriter, req)
+
+		var resp OrderBookResponse
+		if respWriter.Code == http.StatusOK {
+			assert.NoError(t, json.Unmarshal(respWriter.Body.Bytes(), &resp))
+		}
+		return respWriter.Code, resp
+	}
+
+	status, plain := get(t, "?invert=false")
+	assert.Equal(t, http.StatusOK, status)
+	status, inverted := get(t, "?invert=true")
+	assert.Equal(t, http.StatusOK, status)
+
+	assert.Equal(t, "BTC_BRL", plain.InstrumentPair)
+	assert.Equal(t, "BRL_BTC", inverted.InstrumentPair)
+
+	// The checksum covers the book as stored, so the inverted view has none.
+	if assert.NotNil(t, plain.Checksum) {
+		assert.Equal(t, uint32(42), *plain.Checksum)
+	}
+	assert.Nil(t, inverted.Checksum)
+
+	// Asks become bids and bids become asks, each keeping its level order:
+	// BRL per BTC turns into BTC per BRL at BTC scale, and BTC sizes turn
+	// into the BRL amount of the level at BRL scale.
+	assert.Equal(t, []OrderBookLevel{
+		{Price: "101000.00", Quantity: "0.20000000"},
+		{Price: "103000.00", Quantity: "0.10000000"},
+	}, plain.Asks)
+	assert.Equal(t, []OrderBookLevel{
+		{Price: "0.00000990", Quantity: "20200.00"},
+		{Price: "0.00000971", Quantity: "10300.00"},
+	}, inverted.Bids)
+
+	assert.Equal(t, []OrderBookLevel{
+		{Price: "100000.00", Quantity: "0.50000000"},
+		{Price: "99000.00", Quantity: "1.00000000"},
+	}, plain.Bids)
+	assert.Equal(t, []OrderBookLevel{
+		{Price: "0.00001000", Quantity: "50000.00"},
+		{Price: "0.00001010", Quantity: "99000.00"},
+	}, inverted.Asks)
+
+	status, _ = get(t, "?invert=maybe")
+	assert.Equal(t, http.StatusBadRequest, status)
+}
+
+func TestOrderHandler_GetOrderBook_Side(t *testing.T) {
+	instruments := entity.NewInstrumentConfig(entity.Asset{Symbol: "BTC", Scale: 8}, entity.Asset{Symbol: "BRL", Scale: 2})
+	bidsOnly := &usecase.OrderBook{
+		InstrumentPair: "BTC_BRL",
+		Bids:           []*usecase.OrderBookEntry{{Price: decimal.RequireFromString("100000"), Quantity: decimal.RequireFromString("0.5")}},
+		Asks:           []*usecase.OrderBookEntry{},
+		Checksum:       12345,
+	}
+	noAsks := &usecase.OrderBook{InstrumentPair: "BTC_BRL", Bids: []*usecase.OrderBookEntry{}, Asks: []*usecase.OrderBookEntry{}, Checksum: 6789}
+
+	tests := []struct {
+		name       string
+		query      string
+		setupMock  func(m *usecase.MockOrderUseCase)
+		wantStatus int
+		wantBody   string
+	}{
+		{
+			name:  "bid returns only bids",
+			query: "?side=bid",
+			setupMock: func(m *usecase.MockOrderUseCase) {
+				m.EXPECT().GetOrderBookSide("BTC_BRL", "bid", decimal.Zero).Return(bidsOnly, nil).Times(1)
+			},
+			wantStatus: http.StatusOK,
+			wantBody:   `{"instrument_pair":"BTC_BRL","bids":[{"price":"100000.00","quantity":"0.50000000"}],"checksum":12345}`,
+		},
+		{
+			name:  "empty side is sent empty",
+			query: "?side=ask",
+			setupMock: func(m *usecase.MockOrderUseCase) {
+				m.EXPECT().GetOrderBookSide("BTC_BRL", "ask", decimal.Zero).Return(noAsks, nil).Times(1)
+			},
+			wantStatus: http.StatusOK,
+			wantBody:   `{"instrument_pair":"BTC_BRL","asks":[],"checksum":6789}`,
+		},
+		{
+			name:  "inverted asks come from the stored bids",
+			query: "?side=ask&invert=true",
+			setupMock: func(m *usecase.MockOrderUseCase) {
+				m.EXPECT().GetOrderBookSide("BTC_BRL", "bid", decimal.Zero).Return(bidsOnly, nil).Times(1)
+			},
+			wantStatus: http.StatusOK,
+			wantBody:   `{"instrument_pair":"BRL_BTC","asks":[{"price":"0.00001000","quantity":"50000.00"}]}`,
+		},
+		{
+			name:       "invalid side returns 400",
+			query:      "?side=buy",
+			setupMock:  func(m *usecase.MockOrderUseCase) {},
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:  "unsupported pair returns 400",
+			query: "?side=bid",
+			setupMock: func(m *usecase.MockOrderUseCase) {
+				m.EXPECT().GetOrderBookSide("BTC_BRL", "bid", decimal.Zero).Return(nil, entity.ErrUnsupportedAsset).Times(1)
+			},
+			wantStatus: http.StatusBadRequest,
+			wantBody:   `{"error":"unsupported asset","code":"UNSUPPORTED_ASSET"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockUC := usecase.NewMockOrderUseCase(ctrl)
+			tt.setupMock(mockUC)
+			h := NewOrderHandler(zap.NewNop().Sugar(), mockUC, instruments, ImportLimits{})
+
+			req := httptest.NewRequest(http.MethodGet, "/orders/{instrument_pair}"+tt.query, nil)
+			req.SetPathValue("instrument_pair", "BTC_BRL")
+			respWriter := httptest.NewRecorder()
+
+			h.GetOrderBook(respWriter, req)
+
+			assert.Equal(t, tt.wantStatus, respWriter.Code)
+			if tt.wantBody != "" {
+				assert.JSONEq(t, tt.wantBody, respWriter.Body.String())
+			}
+		})
+	}
+}
+
+func TestOrderHandler_CreateOrder_InstrumentScale(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	instruments := entity.NewInstrumentConfig(
+		entity.Asset{Symbol: "BTC", Scale: 8},
+		entity.Asset{Symbol: "BRL", Scale: 2},
+	)
+	mockUC := usecase.NewMockOrderUseCase(ctrl)
+	h := NewOrderHandler(zap.NewNop().Sugar(), mockUC, instruments, ImportLimits{})
+
+	mockUC.EXPECT().CreateOrder(gomock.Any(), gomock.Any()).Return(nil).Times(1)
+
+	body := `{"account_id":"` + uuid.New().String() + `","instrument_pair":"BTC_BRL","order_type":"BUY","price":"200000","quantity":"0.5"}`
+	req := httptest.NewRequest(http.MethodPost, "/orders", strings.NewReader(body))
+	respWriter := httptest.NewRecorder()
+
+	h.CreateOrder(respWriter, req)
+
+	assert.Equal(t, http.StatusCreated, respWriter.Code)
+	var resp CreateOrderResponse
+	assert.NoError(t, json.Unmarshal(respWriter.Body.Bytes(), &resp))
+	assert.Equal(t, "200000.00", resp.Price)
+	assert.Equal(t, "0.50000000", resp.Quantity)
+}
+
+func TestOrderHandler_CancelOrders(t *testing.T) {
+	accountID := uuid.New()
+	cancelledID := uuid.New()
+
+	tests := []struct {
+		name       string
+		body       string
+		setupMock  func(m *usecase.MockOrderUseCase)
+		wantStatus int
+		wantIDs    []uuid.UUID
+	}{
+		{
+			name: "success returns cancelled ids",
+			body: `{"account_id":"` + accountID.String() + `","instrument_pair":"BTC_BRL","side":"BUY"}`,
+			setupMock: func(m *usecase.MockOrderUseCase) {
+				m.EXPECT().CancelOrders(gomock.Any(), accountID, "BTC_BRL", "BUY").Return([]uuid.UUID{cancelledID}, nil).Times(1)
+			},
+			wantStatus: http.StatusOK,
+			wantIDs:    []uuid.UUID{cancelledID},
+		},
+		{
+			name: "nothing to cancel returns empty list",
+			body: `{"account_id":"` + accountID.String() + `","instrument_pair":"BTC_BRL","side":"SELL"}`,
+			setupMock: func(m *usecase.MockOrderUseCase) {
+				m.EXPECT().CancelOrders(gomock.Any(), accountID, "BTC_BRL", "SELL").Return(nil, nil).Times(1)
+			},
+			wantStatus: http.StatusOK,
+			wantIDs:    []uuid.UUID{},
+		},
+		{
+			name:       "invalid JSON body returns 400",
+			body:       "{",
+			setupMock:  func(m *usecase.MockOrderUseCase) {},
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name: "invalid side returns 400",
+			body: `{"account_id":"` + accountID.String() + `","instrument_pair":"BTC_BRL","side":"HOLD"}`,
+			setupMock: func(m *usecase.MockOrderUseCase) {
+				m.EXPECT().CancelOrders(gomock.Any(), accountID, "BTC_BRL", "HOLD").Return(nil, entity.ErrInvalidOrderType).Times(1)
+			},
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name: "usecase error returns 500",
+			body: `{"account_id":"` + accountID.String() + `","instrument_pair":"BTC_BRL","side":"BUY"}`,
+			setupMock: func(m *usecase.MockOrderUseCase) {
+				m.EXPECT().CancelOrders(gomock.Any(), accountID, "BTC_BRL", "BUY").Return(nil, assert.AnError).Times(1)
+			},
+			wantStatus: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockUC := usecase.NewMockOrderUseCase(ctrl)
+			h := NewOrderHandler(zap.NewNop().Sugar(), mockUC, nil, ImportLimits{})
+
+			tt.setupMock(mockUC)
+
+			req := httptest.NewRequest(http.MethodPost, "/orders/cancel", strings.NewReader(tt.body))
+			respWriter := httptest.NewRecorder()
+
+			h.CancelOrders(respWriter, req)
+
+			assert.Equal(t, tt.wantStatus, respWriter.Code)
+			if respWriter.Code == http.StatusOK {
+				var resp CancelOrdersResponse
+				assert.NoError(t, json.Unmarshal(respWriter.Body.Bytes(), &resp))
+				assert.Equal(t, tt.wantIDs, resp.CancelledOrderIDs)
+			}
+		})
+	}
+}
+
+func TestOrderHandler_GetRawOrderBook(t *testing.T) {
+	bidID, askID := uuid.New(), uuid.New()
+	createdAt := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name       string
+		query      string
+		setupMock  func(m *usecase.MockOrderUseCase)
+		wantStatus int
+	}{
+		{
+			name:  "success returns formatted entries",
+			query: "?depth=10",
+			setupMock: func(m *usecase.MockOrderUseCase) {
+				m.EXPECT().GetRawOrderBook("BTC_BRL", 10).
+					Return(&usecase.RawOrderBook{
+						InstrumentPair: "BTC_BRL",
+						Bids: []*usecase.RawOrderBookEntry{
+							{OrderID: bidID, Price: decimal.RequireFromString("100"), RemainingQuantity: decimal.RequireFromString("0.5"), CreatedAt: createdAt},
+						},
+						Asks: []*usecase.RawOrderBookEntry{
+							{OrderID: askID, Price: decimal.RequireFromString("101"), RemainingQuantity: decimal.RequireFromString("1"), CreatedAt: createdAt},
+						},
+					}, nil).Times(1)
+			},
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "invalid depth returns 400",
+			query:      "?depth=-1",
+			setupMock:  func(m *usecase.MockOrderUseCase) {},
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:  "unsupported pair returns 400",
+			query: "",
+			setupMock: func(m *usecase.MockOrderUseCase) {
+				m.EXPECT().GetRawOrderBook("BTC_BRL", 0).
+					Return(nil, entity.ErrUnsupportedAsset).Times(1)
+			},
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:  "usecase error returns 500",
+			query: "",
+			setupMock: func(m *usecase.MockOrderUseCase) {
+				m.EXPECT().GetRawOrderBook("BTC_BRL", 0).
+					Return(nil, assert.AnError).Times(1)
+			},
+			wantStatus: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockUC := usecase.NewMockOrderUseCase(ctrl)
+			h := NewOrderHandler(zap.NewNop().Sugar(), mockUC, entity.NewInstrumentConfig(
+				entity.Asset{Symbol: "BTC", Scale: 8},
+				entity.Asset{Symbol: "BRL", Scale: 2},
+			), ImportLimits{})
+
+			tt.setupMock(mockUC)
+
+			req := httptest.NewRequest(http.MethodGet, "/orders/{instrument_pair}/raw"+tt.query, nil)
+			req.SetPathValue("instrument_pair", "BTC_BRL")
+			respWriter := httptest.NewRecorder()
+
+			h.GetRawOrderBook(respWriter, req)
+
+			assert.Equal(t, tt.wantStatus, respWriter.Code)
+			if respWriter.Code == http.StatusOK {
+				var resp RawOrderBookResponse
+				assert.NoError(t, json.Unmarshal(respWriter.Body.Bytes(), &resp))
+				assert.Equal(t, []RawOrderBookEntry{{OrderID: bidID, Price: "100.00", Quantity: "0.50000000", CreatedAt: createdAt}}, resp.Bids)
+				assert.Equal(t, []RawOrderBookEntry{{OrderID: askID, Price: "101.00", Quantity: "1.00000000", CreatedAt: createdAt}}, resp.Asks)
+			}
+		})
+	}
+}
+
+func TestOrderHandler_GetBook(t *testing.T) {
+	bidID := uuid.New()
+	createdAt := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name       string
+		query      string
+		setupMock  func(m *usecase.MockOrderUseCase)
+		wantStatus int
+		wantBody   string
+	}{
+		{
+			name:  "aggregated by default",
+			query: "",
+			setupMock: func(m *usecase.MockOrderUseCase) {
+				m.EXPECT().GetBook("BTC_BRL", usecase.BookViewAggregated, 0).
+					Return(&usecase.Book{View: usecase.BookViewAggregated, Aggregated: &usecase.OrderBook{
+						InstrumentPair: "BTC_BRL",
+						Bids:           []*usecase.OrderBookEntry{{Price: decimal.RequireFromString("100"), Quantity: decimal.RequireFromString("1.5")}},
+						Asks:           []*usecase.OrderBookEntry{},
+						Checksum:       7,
+					}}, nil).Times(1)
+			},
+			wantStatus: http.StatusOK,
+			wantBody:   `{"view":"aggregated","instrument_pair":"BTC_BRL","bids":[{"price":"100.00","quantity":"1.50000000"}],"asks":[],"checksum":7}`,
+		},
+		{
+			name:  "raw view with depth",
+			query: "?view=raw&depth=5",
+			setupMock: func(m *usecase.MockOrderUseCase) {
+				m.EXPECT().GetBook("BTC_BRL", usecase.BookViewRaw, 5).
+					Return(&usecase.Book{View: usecase.BookViewRaw, Raw: &usecase.RawOrderBook{
+						InstrumentPair: "BTC_BRL",
+						Bids: []*usecase.RawOrderBookEntry{
+							{OrderID: bidID, Price: decimal.RequireFromString("100"), RemainingQuantity: decimal.RequireFromString("0.5"), CreatedAt: createdAt},
+						},
+					}}, nil).Times(1)
+			},
+			wantStatus: http.StatusOK,
+			wantBody:   `{"view":"raw","instrument_pair":"BTC_BRL","bids":[{"order_id":"` + bidID.String() + `","price":"100.00","quantity":"0.50000000","created_at":"2024-01-01T12:00:00Z"}],"asks":[]}`,
+		},
+		{
+			name:  "invalid view returns 400",
+			query: "?view=levels",
+			setupMock: func(m *usecase.MockOrderUseCase) {
+				m.EXPECT().GetBook("BTC_BRL", usecase.BookView("levels"), 0).
+					Return(nil, entity.ErrInvalidBookView).Times(1)
+			},
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "invalid depth returns 400",
+			query:      "?view=raw&depth=0",
+			setupMock:  func(m *usecase.MockOrderUseCase) {},
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:  "empty book returns empty sides",
+			query: "?view=aggregated",
+			setupMock: func(m *usecase.MockOrderUseCase) {
+				m.EXPECT().GetBook("BTC_BRL", usecase.BookViewAggregated, 0).
+					Return(&usecase.Book{View: usecase.BookViewAggregated, Aggregated: &usecase.OrderBook{
+						InstrumentPair: "BTC_BRL",
+						Bids:           []*usecase.OrderBookEntry{},
+						Asks:           []*usecase.OrderBookEntry{},
+						Checksum:       0,
+					}}, nil).Times(1)
+			},
+			wantStatus: http.StatusOK,
+			wantBody:   `{"view":"aggregated","instrument_pair":"BTC_BRL","bids":[],"asks":[],"checksum":0}`,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockUC := usecase.NewMockOrderUseCase(ctrl)
+			h := NewOrderHandler(zap.NewNop().Sugar(), mockUC, entity.NewInstrumentConfig(
+				entity.Asset{Symbol: "BTC", Scale: 8},
+				entity.Asset{Symbol: "BRL", Scale: 2},
+			), ImportLimits{})
+
+			tt.setupMock(mockUC)
+
+			req := httptest.NewRequest(http.MethodGet, "/orders/{instrument_pair}/book"+tt.query, nil)
+			req.SetPathValue("instrument_pair", "BTC_BRL")
+			respWriter := httptest.NewRecorder()
+
+			h.GetBook(respWriter, req)
+
+			assert.Equal(t, tt.wantStatus, respWriter.Code)
+			if tt.wantBody != "" {
+				assert.JSONEq(t, tt.wantBody, respWriter.Body.String())
+			}
+		})
+	}
+}
+
+func TestOrderHandler_GetDepth(t *testing.T) {
+	tests := []struct {
+		name       string
+		query      string
+		setupMock  func(m *usecase.MockOrderUseCase)
+		wantStatus int
+		wantQty    string
+	}{
+		{
+			name:  "success returns formatted depth",
+			query: "?side=bid&price=100",
+			setupMock: func(m *usecase.MockOrderUseCase) {
+				m.EXPECT().GetDepth("BTC_BRL", "bid", decimal.RequireFromString("100")).
+					Return(decimal.RequireFromString("1.4"), nil).Times(1)
+			},
+			wantStatus: http.StatusOK,
+			wantQty:    "1.40000000",
+		},
+		{
+			name:  "no liquidity returns zero",
+			query: "?side=ask&price=1",
+			setupMock: func(m *usecase.MockOrderUseCase) {
+				m.EXPECT().GetDepth("BTC_BRL", "ask", decimal.RequireFromString("1")).
+					Return(decimal.Zero, nil).Times(1)
+			},
+			wantStatus: http.StatusOK,
+			wantQty:    "0.00000000",
+		},
+		{
+			name:       "invalid price returns 400",
+			query:      "?side=bid&price=abc",
+			setupMock:  func(m *usecase.MockOrderUseCase) {},
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:  "invalid side returns 400",
+			query: "?side=up&price=100",
+			setupMock: func(m *usecase.MockOrderUseCase) {
+				m.EXPECT().GetDepth("BTC_BRL", "up", decimal.RequireFromString("100")).
+					Return(decimal.Zero, entity.ErrInvalidSide).Times(1)
+			},
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:  "usecase error returns 500",
+			query: "?side=bid&price=100",
+			setupMock: func(m *usecase.MockOrderUseCase) {
+				m.EXPECT().GetDepth("BTC_BRL", "bid", decimal.RequireFromString("100")).
+					Return(decimal.Zero, assert.AnError).Times(1)
+			},
+			wantStatus: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockUC := usecase.NewMockOrderUseCase(ctrl)
+			h := NewOrderHandler(zap.NewNop().Sugar(), mockUC, entity.NewInstrumentConfig(
+				entity.Asset{Symbol: "BTC", Scale: 8},
+				entity.Asset{Symbol: "BRL", Scale: 2},
+			), ImportLimits{})
+
+			tt.setupMock(mockUC)
+
+			req := httptest.NewRequest(http.MethodGet, "/orders/{instrument_pair}/depth"+tt.query, nil)
+			req.SetPathValue("instrument_pair", "BTC_BRL")
+			respWriter := httptest.NewRecorder()
+
+			h.GetDepth(respWriter, req)
+
+			assert.Equal(t, tt.wantStatus, respWriter.Code)
+			if respWriter.Code == http.StatusOK {
+				var resp DepthResponse
+				assert.NoError(t, json.Unmarshal(respWriter.Body.Bytes(), &resp))
+				assert.Equal(t, tt.wantQty, resp.Quantity)
+			}
+		})
+	}
+}
+
+func TestOrderHandler_GetImbalance(t *testing.T) {
+	ratio := decimal.RequireFromString("0.5")
+
+	tests := []struct {
+		name       string
+		query      string
+		setupMock  func(m *usecase.MockOrderUseCase)
+		wantStatus int
+		wantRatio  *string
+	}{
+		{
+			name:  "skewed book returns imbalance and ratio",
+			query: "?depth=2",
+			setupMock: func(m *usecase.MockOrderUseCase) {
+				m.EXPECT().GetImbalance("BTC_BRL", 2).Return(&usecase.Imbalance{
+					InstrumentPair: "BTC_BRL",
+					Depth:          2,
+					BidQuantity:    decimal.RequireFromString("6"),
+					AskQuantity:    decimal.RequireFromString("2"),
+					Imbalance:      decimal.RequireFromString("4"),
+					Ratio:          &ratio,
+				}, nil).Times(1)
+			},
+			wantStatus: http.StatusOK,
+			wantRatio:  func() *string { s := "0.5000"; return &s }(),
+		},
+		{
+			name:  "empty book has a null ratio",
+			query: "",
+			setupMock: func(m *usecase.MockOrderUseCase) {
+				m.EXPECT().GetImbalance("BTC_BRL", 0).Return(&usecase.Imbalance{
+					InstrumentPair: "BTC_BRL",
+					BidQuantity:    decimal.Zero,
+					AskQuantity:    decimal.Zero,
+					Imbalance:      decimal.Zero,
+				}, nil).Times(1)
+			},
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "invalid depth returns 400",
+			query:      "?depth=-1",
+			setupMock:  func(m *usecase.MockOrderUseCase) {},
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:  "unsupported pair returns 400",
+			query: "?depth=2",
+			setupMock: func(m *usecase.MockOrderUseCase) {
+				m.EXPECT().GetImbalance("BTC_BRL", 2).Return(nil, entity.ErrUnsupportedAsset).Times(1)
+			},
+			wantStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockUC := usecase.NewMockOrderUseCase(ctrl)
+			h := NewOrderHandler(zap.NewNop().Sugar(), mockUC, entity.NewInstrumentConfig(
+				entity.Asset{Symbol: "BTC", Scale: 8},
+				entity.Asset{Symbol: "BRL", Scale: 2},
+			), ImportLimits{})
+
+			tt.setupMock(mockUC)
+
+			req := httptest.NewRequest(http.MethodGet, "/orders/{instrument_pair}/imbalance"+tt.query, nil)
+			req.SetPathValue("instrument_pair", "BTC_BRL")
+			respWriter := httptest.NewRecorder()
+
+			h.GetImbalance(respWriter, req)
+
+			assert.Equal(t, tt.wantStatus, respWriter.Code)
+			if respWriter.Code == http.StatusOK {
+				var resp ImbalanceResponse
+				assert.NoError(t, json.Unmarshal(respWriter.Body.Bytes(), &resp))
+				assert.Equal(t, tt.wantRatio, resp.Ratio)
+			}
+		})
+	}
+}
+
+func TestOrderHandler_GetOrderFills(t *testing.T) {
+	orderID := uuid.New()
+	order := &entity.Order{
+		Base:              entity.Base{ID: orderID},
+		InstrumentPair:    "BTC_BRL",
+		OrderType:         string(entity.OrderTypeBuy),
+		Price:             decimal.RequireFromString("100"),
+		Quantity:          decimal.RequireFromString("1"),
+		RemainingQuantity: decimal.RequireFromString("0.5"),
+		Status:            string(entity.OrderStatusPartial),
+	}
+
+	tests := []struct {
+		name          string
+		pathValue     string
+		setupMock     func(m *usecase.MockOrderUseCase)
+		wantStatus    int
+		wantRemaining []string
+	}{
+		{
+			name:      "returns order with running remaining",
+			pathValue: orderID.String(),
+			setupMock: func(m *usecase.MockOrderUseCase) {
+				m.EXPECT().GetOrderFills(orderID).Return(&usecase.OrderFills{
+					Order: order,
+					Fills: []*usecase.Fill{
+						{Trade: &entity.Trade{Price: decimal.RequireFromString("100"), Quantity: decimal.RequireFromString("0.2")}, RemainingQuantity: decimal.RequireFromString("0.8")},
+						{Trade: &entity.Trade{Price: decimal.RequireFromString("100"), Quantity: decimal.RequireFromString("0.3")}, RemainingQuantity: decimal.RequireFromString("0.5")},
+					},
+				}, nil).Times(1)
+			},
+			wantStatus:    http.StatusOK,
+			wantRemaining: []string{"0.8", "0.5"},
+		},
+		{
+			name:      "no fills returns empty list",
+			pathValue: orderID.String(),
+			setupMock: func(m *usecase.MockOrderUseCase) {
+				m.EXPECT().GetOrderFills(orderID).Return(&usecase.OrderFills{Order: order, Fills: []*usecase.Fill{}}, nil).Times(1)
+			},
+			wantStatus:    http.StatusOK,
+			wantRemaining: []string{},
+		},
+		{
+			name:       "invalid UUID returns 400",
+			pathValue:  "nope",
+			setupMock:  func(m *usecase.MockOrderUseCase) {},
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:      "unknown order returns 404",
+			pathValue: orderID.String(),
+			setupMock: func(m *usecase.MockOrderUseCase) {
+				m.EXPECT().GetOrderFills(orderID).Return(nil, repository.ErrNotFound).Times(1)
+			},
+			wantStatus: http.StatusNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockUC := usecase.NewMockOrderUseCase(ctrl)
+			h := NewOrderHandler(zap.NewNop().Sugar(), mockUC, nil, ImportLimits{})
+
+			tt.setupMock(mockUC)
+
+			req := httptest.NewRequest(http.MethodGet, "/orders/id/{id}/fills", nil)
+			req.SetPathValue("id", tt.pathValue)
+			respWriter := httptest.NewRecorder()
+
+			h.GetOrderFills(respWriter, req)
+
+			assert.Equal(t, tt.wantStatus, respWriter.Code)
+			if respWriter.Code == http.StatusOK {
+				var resp OrderFillsResponse
+				assert.NoError(t, json.Unmarshal(respWriter.Body.Bytes(), &resp))
+				assert.Equal(t, orderID, resp.Order.ID)
+				assert.NotNil(t, resp.Fills)
+				remaining := make([]string, len(resp.Fills))
+				for i, fill := range resp.Fills {
+					remaining[i] = fill.RemainingQuantity
+				}
+				assert.Equal(t, tt.wantRemaining, remaining)
+			}
+		})
+	}
+}
+
+func TestOrderHandler_ReplaceOrder(t *testing.T) {
+	uid := uuid.New().String()
+	oldID := uuid.New()
+	body := `{"order_id":"` + oldID.String() + `","account_id":"` + uid + `","instrument_pair":"BTC_BRL","order_type":"buy","price":"200000","quantity":"0.5"}`
+
+	tests := []struct {
+		name       string
+		body       string
+		mockSetup  func(m *usecase.MockOrderUseCase)
+		wantStatus int
+	}{
+		{
+			name: "success returns 201 with both orders",
+			body: body,
+			mockSetup: func(m *usecase.MockOrderUseCase) {
+				m.EXPECT().
+					ReplaceOrder(gomock.Any(), oldID, gomock.Any()).
+					Return(&entity.Order{Base: entity.Base{ID: oldID}, Status: string(entity.OrderStatusCancelled)}, nil).
+					Times(1)
+			},
+			wantStatus: http.StatusCreated,
+		},
+		{
+			name:       "invalid JSON body returns 400",
+			body:       "{",
+			mockSetup:  func(m *usecase.MockOrderUseCase) {},
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "invalid price format returns 400",
+			body:       `{"order_id":"` + oldID.String() + `","account_id":"` + uid + `","instrument_pair":"BTC_BRL","order_type":"buy","price":"abc","quantity":"0.5"}`,
+			mockSetup:  func(m *usecase.MockOrderUseCase) {},
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name: "unknown order returns 404",
+			body: body,
+			mockSetup: func(m *usecase.MockOrderUseCase) {
+				m.EXPECT().ReplaceOrder(gomock.Any(), oldID, gomock.Any()).Return(nil, repository.ErrNotFound).Times(1)
+			},
+			wantStatus: http.StatusNotFound,
+		},
+		{
+			name: "order of another account returns 403",
+			body: body,
+			mockSetup: func(m *usecase.MockOrderUseCase) {
+				m.EXPECT().ReplaceOrder(gomock.Any(), oldID, gomock.Any()).Return(nil, entity.ErrOrderNotOwned).Times(1)
+			},
+			wantStatus: http.StatusForbidden,
+		},
+		{
+			name: "order no longer open returns 409",
+			body: body,
+			mockSetup: func(m *usecase.MockOrderUseCase) {
+				m.EXPECT().ReplaceOrder(gomock.Any(), oldID, gomock.Any()).Return(nil, entity.ErrOrderNotOpen).Times(1)
+			},
+			wantStatus: http.StatusConflict,
+		},
+		{
+			name: "new order rejected returns 400",
+			body: body,
+			mockSetup: func(m *usecase.MockOrderUseCase) {
+				m.EXPECT().ReplaceOrder(gomock.Any(), oldID, gomock.Any()).Return(nil, assert.AnError).Times(1)
+			},
+			wantStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockUC := usecase.NewMockOrderUseCase(ctrl)
+			h := NewOrderHandler(zap.NewNop().Sugar(), mockUC, nil, ImportLimits{})
+
+			tt.mockSetup(mockUC)
+
+			req := httptest.NewRequest(http.MethodPost, "/orders/replace", strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			respWriter := httptest.NewRecorder()
+
+			h.ReplaceOrder(respWriter, req)
+
+			assert.Equal(t, tt.wantStatus, respWriter.Code)
+			if respWriter.Code == http.StatusCreated {
+				var resp ReplaceOrderResponse
+				assert.NoError(t, json.Unmarshal(respWriter.Body.Bytes(), &resp))
+				assert.Equal(t, oldID, resp.CancelledOrderID)
+				assert.Equal(t, string(entity.OrderStatusCancelled), resp.CancelledStatus)
+				if assert.NotNil(t, resp.Order) {
+					assert.Equal(t, "BTC_BRL", resp.Order.InstrumentPair)
+					assert.Equal(t, "200000", resp.Order.Price)
+				}
+			}
+		})
+	}
+}
+
+func TestOrderHandler_GetOrderSummary(t *testing.T) {
+	from := time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)
+	to := from.Add(24 * time.Hour)
+
+	tests := []struct {
+		name       string
+		query      string
+		mockSetup  func(m *usecase.MockOrderUseCase)
+		wantStatus int
+		wantBody   *OrderSummaryResponse
+	}{
+		{
+			name: "success returns counts",
+			mockSetup: func(m *usecase.MockOrderUseCase) {
+				m.EXPECT().GetOrderSummary("BTC_BRL", time.Time{}, time.Time{}).
+					Return(&usecase.OrderSummary{InstrumentPair: "BTC_BRL", Open: 3, Filled: 1}, nil).Times(1)
+			},
+			wantStatus: http.StatusOK,
+			wantBody:   &OrderSummaryResponse{InstrumentPair: "BTC_BRL", Open: 3, Filled: 1},
+		},
+		{
+			name:  "window is passed through",
+			query: "?from=2024-01-02T00:00:00Z&to=2024-01-03T00:00:00Z",
+			mockSetup: func(m *usecase.MockOrderUseCase) {
+				m.EXPECT().GetOrderSummary("BTC_BRL", from, to).
+					Return(&usecase.OrderSummary{InstrumentPair: "BTC_BRL"}, nil).Times(1)
+			},
+			wantStatus: http.StatusOK,
+			wantBody:   &OrderSummaryResponse{InstrumentPair: "BTC_BRL"},
+		},
+		{
+			name:       "invalid from returns 400",
+			query:      "?from=yesterday",
+			mockSetup:  func(m *usecase.MockOrderUseCase) {},
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name: "invalid time range returns 400",
+			mockSetup: func(m *usecase.MockOrderUseCase) {
+				m.EXPECT().GetOrderSummary("BTC_BRL", gomock.Any(), gomock.Any()).Return(nil, entity.ErrInvalidTimeRange).Times(1)
+			},
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name: "usecase error returns 500",
+			mockSetup: func(m *usecase.MockOrderUseCase) {
+				m.EXPECT().GetOrderSummary("BTC_BRL", gomock.Any(), gomock.Any()).Return(nil, assert.AnError).Times(1)
+			},
+			wantStatus: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockUC := usecase.NewMockOrderUseCase(ctrl)
+			h := NewOrderHandler(zap.NewNop().Sugar(), mockUC, nil, ImportLimits{})
+			tt.mockSetup(mockUC)
+
+			req := httptest.NewRequest(http.MethodGet, "/orders/BTC_BRL/summary"+tt.query, nil)
+			req.SetPathValue("instrument_pair", "BTC_BRL")
+			respWriter := httptest.NewRecorder()
+
+			h.GetOrderSummary(respWriter, req)
+
+			assert.Equal(t, tt.wantStatus, respWriter.Code)
+			if tt.wantBody != nil {
+				var resp OrderSummaryResponse
+				assert.NoError(t, json.Unmarshal(respWriter.Body.Bytes(), &resp))
+				assert.Equal(t, *tt.wantBody, resp)
+			}
+		})
+	}
+}
+
+func TestOrderHandler_GetSpreadHistory(t *testing.T) {
+	to := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	history := &usecase.SpreadHistory{
+		InstrumentPair: "BTC_BRL",
+		From:           to.Add(-time.Hour),
+		To:             to,
+		Snapshots: []*entity.SpreadSnapshot{
+			{
+				InstrumentPair: "BTC_BRL",
+				BestBid:        decimal.NewNullDecimal(decimal.RequireFromString("99.5")),
+				BestAsk:        decimal.NewNullDecimal(decimal.RequireFromString("101")),
+				TakenAt:        to.Add(-30 * time.Minute),
+			},
+			{
+				InstrumentPair: "BTC_BRL",
+				BestAsk:        decimal.NewNullDecimal(decimal.RequireFromString("102")),
+				TakenAt:        to.Add(-time.Minute),
+			},
+		},
+	}
+
+	tests := []struct {
+		name       string
+		query      string
+		mockSetup  func(m *usecase.MockOrderUseCase)
+		wantStatus int
+		wantBody   string
+	}{
+		{
+			name:  "success formats each snapshot",
+			query: "?window=1h&interval=5m",
+			mockSetup: func(m *usecase.MockOrderUseCase) {
+				m.EXPECT().GetSpreadHistory("BTC_BRL", time.Hour, 5*time.Minute).Return(history, nil).Times(1)
+			},
+			wantStatus: http.StatusOK,
+			wantBody: `{"instrument_pair":"BTC_BRL","from":"2026-01-01T11:00:00Z","to":"2026-01-01T12:00:00Z","snapshots":[` +
+				`{"taken_at":"2026-01-01T11:30:00Z","best_bid":"99.50","best_ask":"101.00","spread":"1.50"},` +
+				`{"taken_at":"2026-01-01T11:59:00Z","best_bid":null,"best_ask":"102.00","spread":null}]}`,
+		},
+		{
+			name: "defaults and an empty series",
+			mockSetup: func(m *usecase.MockOrderUseCase) {
+				m.EXPECT().GetSpreadHistory("BTC_BRL", defaultSpreadHistoryWindow, time.Duration(0)).
+					Return(&usecase.SpreadHistory{InstrumentPair: "BTC_BRL", From: to.Add(-24 * time.Hour), To: to}, nil).Times(1)
+			},
+			wantStatus: http.StatusOK,
+			wantBody:   `{"instrument_pair":"BTC_BRL","from":"2025-12-31T12:00:00Z","to":"2026-01-01T12:00:00Z","snapshots":[]}`,
+		},
+		{
+			name:       "invalid interval returns 400",
+			query:      "?interval=often",
+			mockSetup:  func(m *usecase.MockOrderUseCase) {},
+			wantStatus: http.StatusBadRequest,
+			wantBody:   `{"error":"Invalid interval parameter"}`,
+		},
+		{
+			name: "interval longer than the window returns 400",
+			mockSetup: func(m *usecase.MockOrderUseCase) {
+				m.EXPECT().GetSpreadHistory("BTC_BRL", gomock.Any(), gomock.Any()).Return(nil, entity.ErrInvalidSampleInterval).Times(1)
+			},
+			wantStatus: http.StatusBadRequest,
+			wantBody:   `{"error":"sample interval must be between zero and the window","code":"INVALID_SAMPLE_INTERVAL"}`,
+		},
+	}
+
+	instruments := entity.NewInstrumentConfig(
+		entity.Asset{Symbol: "BTC", Scale: 8},
+		entity.Asset{Symbol: "BRL", Scale: 2},
+	)
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockUC := usecase.NewMockOrderUseCase(ctrl)
+			h := NewOrderHandler(zap.NewNop().Sugar(), mockUC, instruments, ImportLimits{})
+			tt.mockSetup(mockUC)
+
+			req := httptest.NewRequest(http.MethodGet, "/orders/BTC_BRL/spread-history"+tt.query, nil)
+			req.SetPathValue("instrument_pair", "BTC_BRL")
+			respWriter := httptest.NewRecorder()
+
+			h.GetSpreadHistory(respWriter, req)
+
+			assert.Equal(t, tt.wantStatus, respWriter.Code)
+			assert.JSONEq(t, tt.wantBody, respWriter.Body.String())
+		})
+	}
+}
+
+func TestOrderHandler_CreateOrder_DecimalPlaces(t *testing.T) {
+	instruments := entity.NewInstrumentConfig(
+		entity.Asset{Symbol: "BTC", Scale: 8},
+		entity.Asset{Symbol: "ETH", Scale: 4},
+		entity.Asset{Symbol: "BRL", Scale: 2},
+	)
+	uid := uuid.New().String()
+
+	tests := []struct {
+		name       string
+		pair       string
+		price      string
+		quantity   string
+		minFill    string
+		wantStatus int
+		wantError  string
+	}{
+		{name: "at the limit is accepted", pair: "BTC_BRL", price: "200000.12", quantity: "0.12345678", wantStatus: http.StatusCreated},
+		{name: "trailing zeros are accepted", pair: "BTC_BRL", price: "200000.1000000000", quantity: "0.5", wantStatus: http.StatusCreated},
+		{name: "over-precise price is rejected", pair: "BTC_BRL", price: "200000.123", quantity: "0.5", wantStatus: http.StatusBadRequest, wantError: "price has more than 2 decimal places"},
+		{name: "over-precise quantity is rejected", pair: "BTC_BRL", price: "200000", quantity: "0.000000001", wantStatus: http.StatusBadRequest, wantError: "quantity has more than 8 decimal places"},
+		{name: "over-precise min fill is rejected", pair: "BTC_BRL", price: "200000", quantity: "0.5", minFill: "0.100000001", wantStatus: http.StatusBadRequest, wantError: "min fill quantity has more than 8 decimal places"},
+		{name: "scale 4 base at the limit is accepted", pair: "ETH_BRL", price: "15000.25", quantity: "1.0005", minFill: "0.0001", wantStatus: http.StatusCreated},
+		{name: "scale 4 base rejects a fifth decimal", pair: "ETH_BRL", price: "15000", quantity: "1.00005", wantStatus: http.StatusBadRequest, wantError: "quantity has more than 4 decimal places"},
+		{name: "scale 4 base rejects eight decimals", pair: "ETH_BRL", price: "15000", quantity: "0.12345678", wantStatus: http.StatusBadRequest, wantError: "quantity has more than 4 decimal places"},
+		{name: "scale 4 base rejects an over-precise min fill", pair: "ETH_BRL", price: "15000", quantity: "1", minFill: "0.00001", wantStatus: http.StatusBadRequest, wantError: "min fill quantity has more than 4 decimal places"},
+		{name: "lowercase pair uses its assets' scales", pair: "eth_brl", price: "15000", quantity: "1.00005", wantStatus: http.StatusBadRequest, wantError: "quantity has more than 4 decimal places"},
+		{name: "scale 8 quote allows an eight decimal price", pair: "ETH_BTC", price: "0.05123456", quantity: "1.5", wantStatus: http.StatusCreated},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockUC := usecase.NewMockOrderUseCase(ctrl)
+			h := NewOrderHandler(zap.NewNop().Sugar(), mockUC, instruments, ImportLimits{})
+			if tt.wantStatus == http.StatusCreated {
+				mockUC.EXPECT().CreateOrder(gomock.Any(), gomock.Any()).Return(nil).Times(1)
+			}
+
+			body := `{"account_id":"` + uid + `","instrument_pair":"` + tt.pair + `","order_type":"BUY","price":"` + tt.price +
+				`","quantity":"` + tt.quantity + `","min_fill_quantity":"` + tt.minFill + `"}`
+			respWriter := httptest.NewRecorder()
+
+			h.CreateOrder(respWriter, httptest.NewRequest(http.MethodPost, "/orders", strings.NewReader(body)))
+
+			assert.Equal(t, tt.wantStatus, respWriter.Code)
+			if tt.wantError != "" {
+				var resp map[string]string
+				assert.NoError(t, json.Unmarshal(respWriter.Body.Bytes(), &resp))
+				assert.Equal(t, tt.wantError, resp["error"])
+			}
+		})
+	}
+}
+
+func TestOrderHandler_CreateOrder_ExtremeDecimals(t *testing.T) {
+	uid := uuid.New().String()
+
+	tests := []struct {
+		name       string
+		price      string
+		quantity   string
+		minFill    string
+		wantStatus int
+		wantError  string
+	}{
+		{name: "huge positive exponent price is rejected", price: "1e1000000", quantity: "0.5", wantStatus: http.StatusBadRequest, wantError: "price is out of range"},
+		{name: "huge negative exponent quantity is rejected", price: "200000", quantity: "1e-1000000", wantStatus: http.StatusBadRequest, wantError: "quantity is out of range"},
+		{name: "zero with huge exponent is rejected", price: "200000", quantity: "0e-1000000", wantStatus: http.StatusBadRequest, wantError: "quantity is out of range"},
+		{name: "exponent overflowing int32 is rejected", price: "200000", quantity: "1e9999999999", wantStatus: http.StatusBadRequest, wantError: "Invalid quantity format"},
+		{name: "overlong min fill is rejected", price: "200000", quantity: "0.5", minFill: "0." + strings.Repeat("0", 70) + "1", wantStatus: http.StatusBadRequest, wantError: "min fill quantity is out of range"},
+		{name: "NaN price is rejected", price: "NaN", quantity: "0.5", wantStatus: http.StatusBadRequest, wantError: "Invalid price format"},
+		{name: "Inf quantity is rejected", price: "200000", quantity: "Inf", wantStatus: http.StatusBadRequest, wantError: "Invalid quantity format"},
+		{name: "small exponent within range is accepted", price: "2e5", quantity: "5e-1", wantStatus: http.StatusCreated},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockUC := usecase.NewMockOrderUseCase(ctrl)
+			h := NewOrderHandler(zap.NewNop().Sugar(), mockUC, nil, ImportLimits{})
+			if tt.wantStatus == http.StatusCreated {
+				mockUC.EXPECT().CreateOrder(gomock.Any(), gomock.Any()).Return(nil).Times(1)
+			}
+
+			body := `{"account_id":"` + uid + `","instrument_pair":"BTC_BRL","order_type":"BUY","price":"` + tt.price +
+				`","quantity":"` + tt.quantity + `","min_fill_quantity":"` + tt.minFill + `"}`
+			respWriter := httptest.NewRecorder()
+
+			h.CreateOrder(respWriter, httptest.NewRequest(http.MethodPost, "/orders", strings.NewReader(body)))
+
+			assert.Equal(t, tt.wantStatus, respWriter.Code)
+			if tt.wantError != "" {
+				var resp map[string]string
+				assert.NoError(t, json.Unmarshal(respWriter.Body.Bytes(), &resp))
+				assert.Equal(t, tt.wantError, resp["error"])
+			}
+		})
+	}
+}
+
+func TestOrderHandler_GetAccountRejections(t *testing.T) {
+	accountID := uuid.New()
+
+	tests := []struct {
+		name       string
+		pathValue  string
+		query      string
+		mockSetup  func(m *usecase.MockOrderUseCase)
+		wantStatus int
+		wantCount  int
+	}{
+		{
+			name:      "success returns rejections",
+			pathValue: accountID.String(),
+			query:     "?limit=10",
+			mockSetup: func(m *usecase.MockOrderUseCase) {
+				m.EXPECT().GetRejections(accountID, 10).Return([]*entity.OrderRejection{
+					{
+						AccountID:      accountID,
+						InstrumentPair: "BTC_BRL",
+						OrderType:      "BUY",
+						Price:          decimal.RequireFromString("200"),
+						Quantity:       decimal.RequireFromString("1"),
+						Reason:         string(entity.RejectionInsufficientBalance),
+						Message:        entity.ErrInsufficientBalance.Error(),
+					},
+				}, nil).Times(1)
+			},
+			wantStatus: http.StatusOK,
+			wantCount:  1,
+		},
+		{
+			name:       "invalid account id returns 400",
+			pathValue:  "not-a-uuid",
+			mockSetup:  func(m *usecase.MockOrderUseCase) {},
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "invalid limit returns 400",
+			pathValue:  accountID.String(),
+			query:      "?limit=-1",
+			mockSetup:  func(m *usecase.MockOrderUseCase) {},
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:      "usecase error returns 500",
+			pathValue: accountID.String(),
+			mockSetup: func(m *usecase.MockOrderUseCase) {
+				m.EXPECT().GetRejections(accountID, 0).Return(nil, assert.AnError).Times(1)
+			},
+			wantStatus: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockUC := usecase.NewMockOrderUseCase(ctrl)
+			h := NewOrderHandler(zap.NewNop().Sugar(), mockUC, nil, ImportLimits{})
+			tt.mockSetup(mockUC)
+
+			req := httptest.NewRequest(http.MethodGet, "/accounts/{id}/rejections"+tt.query, nil)
+			req.SetPathValue("id", tt.pathValue)
+			respWriter := httptest.NewRecorder()
+
+			h.GetAccountRejections(respWriter, req)
+
+			assert.Equal(t, tt.wantStatus, respWriter.Code)
+			if respWriter.Code == http.StatusOK {
+				var resp ListResponse[RejectionResponse]
+				assert.NoError(t, json.Unmarshal(respWriter.Body.Bytes(), &resp))
+				if assert.Len(t, resp.Data, tt.wantCount) {
+					assert.Equal(t, "INSUFFICIENT_BALANCE", resp.Data[0].Reason)
+					assert.Equal(t, "200", resp.Data[0].Price)
+				}
+			}
+		})
+	}
+}
+
+func TestOrderHandler_ReplaceOrder_ParsesLikeCreate(t *testing.T) {
+	instruments := entity.NewInstrumentConfig(
+		entity.Asset{Symbol: "BTC", Scale: 8},
+		entity.Asset{Symbol: "BRL", Scale: 2},
+	)
+	spec := `"account_id":"` + uuid.New().String() + `","instrument_pair":"BTC_BRL","order_type":"BUY","price":"200000.123456789","quantity":"0.5"`
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	h := NewOrderHandler(zap.NewNop().Sugar(), usecase.NewMockOrderUseCase(ctrl), instruments, ImportLimits{})
+
+	createResp := httptest.NewRecorder()
+	h.CreateOrder(createResp, httptest.NewRequest(http.MethodPost, "/orders", strings.NewReader("{"+spec+"}")))
+
+	replaceResp := httptest.NewRecorder()
+	h.ReplaceOrder(replaceResp, httptest.NewRequest(http.MethodPost, "/orders/replace",
+		strings.NewReader(`{"order_id":"`+uuid.New().String()+`",`+spec+"}")))
+
+	assert.Equal(t, http.StatusBadRequest, createResp.Code)
+	assert.Equal(t, createResp.Code, replaceResp.Code)
+	assert.JSONEq(t, createResp.Body.String(), replaceResp.Body.String())
+}
+
+func TestOrderHandler_EstimateCost(t *testing.T) {
+	tests := []struct {
+		name         string
+		query        string
+		mockSetup    func(m *usecase.MockOrderUseCase)
+		wantStatus   int
+		wantAverage  *string
+		wantFillable bool
+	}{
+		{
+			name:  "partial estimate on a thin book",
+			query: "?side=buy&quantity=10",
+			mockSetup: func(m *usecase.MockOrderUseCase) {
+				m.EXPECT().EstimateCost("BTC_BRL", "BUY", decimal.RequireFromString("10")).Return(&usecase.CostEstimate{
+					InstrumentPair: "BTC_BRL",
+					OrderType:      "BUY",
+					Quantity:       decimal.RequireFromString("10"),
+					FilledQuantity: decimal.RequireFromString("6"),
+					TotalCost:      decimal.RequireFromString("651.4"),
+					AveragePrice:   decimal.RequireFromString("108.5666666666666667"),
+				}, nil).Times(1)
+			},
+			wantStatus:  http.StatusOK,
+			wantAverage: func() *string { s := "108.57"; return &s }(),
+		},
+		{
+			name:  "ask alias maps to a sell and nothing filled has no average",
+			query: "?side=ask&quantity=1",
+			mockSetup: func(m *usecase.MockOrderUseCase) {
+				m.EXPECT().EstimateCost("BTC_BRL", "SELL", decimal.RequireFromString("1")).Return(&usecase.CostEstimate{
+					InstrumentPair: "BTC_BRL",
+					OrderType:      "SELL",
+					Quantity:       decimal.RequireFromString("1"),
+				}, nil).Times(1)
+			},
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "malformed quantity returns 400",
+			query:      "?side=buy&quantity=lots",
+			mockSetup:  func(m *usecase.MockOrderUseCase) {},
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:  "invalid side returns 400",
+			query: "?side=hold&quantity=1",
+			mockSetup: func(m *usecase.MockOrderUseCase) {
+				m.EXPECT().EstimateCost("BTC_BRL", "HOLD", decimal.RequireFromString("1")).Return(nil, entity.ErrInvalidSide).Times(1)
+			},
+			wantStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockUC := usecase.NewMockOrderUseCase(ctrl)
+			instruments := entity.NewInstrumentConfig(entity.Asset{Symbol: "BTC", Scale: 8}, entity.Asset{Symbol: "BRL", Scale: 2})
+			h := NewOrderHandler(zap.NewNop().Sugar(), mockUC, instruments, ImportLimits{})
+			tt.mockSetup(mockUC)
+
+			req := httptest.NewRequest(http.MethodGet, "/orders/{instrument_pair}/estimate"+tt.query, nil)
+			req.SetPathValue("instrument_pair", "BTC_BRL")
+			respWriter := httptest.NewRecorder()
+
+			h.EstimateCost(respWriter, req)
+
+			assert.Equal(t, tt.wantStatus, respWriter.Code)
+			if respWriter.Code != http.StatusOK {
+				return
+			}
+
+			var resp CostEstimateResponse
+			assert.NoError(t, json.Unmarshal(respWriter.Body.Bytes(), &resp))
+			assert.Equal(t, tt.wantAverage, resp.AveragePrice)
+			assert.Equal(t, tt.wantFillable, resp.FullyFillable)
+		})
+	}
+}
+
+func TestOrderHandler_GetQueuePosition(t *testing.T) {
+	orderID := uuid.New()
+
+	tests := []struct {
+		name       string
+		id         string
+		mockSetup  func(m *usecase.MockOrderUseCase)
+		wantStatus int
+	}{
+		{
+			name: "success returns the position at the level",
+			id:   orderID.String(),
+			mockSetup: func(m *usecase.MockOrderUseCase) {
+				m.EXPECT().GetQueuePosition(orderID).Return(&usecase.QueuePosition{
+					Order: &entity.Order{
+						Base:           entity.Base{ID: orderID},
+						InstrumentPair: "BTC_BRL",
+						OrderType:      "BUY",
+						Price:          decimal.RequireFromString("100"),
+					},
+					Position:      3,
+					OrdersAhead:   2,
+					QuantityAhead: decimal.RequireFromString("0.75"),
+				}, nil).Times(1)
+			},
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "invalid id returns 400",
+			id:         "nope",
+			mockSetup:  func(m *usecase.MockOrderUseCase) {},
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name: "unknown order returns 404",
+			id:   orderID.String(),
+			mockSetup: func(m *usecase.MockOrderUseCase) {
+				m.EXPECT().GetQueuePosition(orderID).Return(nil, repository.ErrNotFound).Times(1)
+			},
+			wantStatus: http.StatusNotFound,
+		},
+		{
+			name: "order off the book returns 409",
+			id:   orderID.String(),
+			mockSetup: func(m *usecase.MockOrderUseCase) {
+				m.EXPECT().GetQueuePosition(orderID).Return(nil, entity.ErrOrderNotOpen).Times(1)
+			},
+			wantStatus: http.StatusConflict,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockUC := usecase.NewMockOrderUseCase(ctrl)
+			instruments := entity.NewInstrumentConfig(entity.Asset{Symbol: "BTC", Scale: 8}, entity.Asset{Symbol: "BRL", Scale: 2})
+			h := NewOrderHandler(zap.NewNop().Sugar(), mockUC, instruments, ImportLimits{})
+			tt.mockSetup(mockUC)
+
+			req := httptest.NewRequest(http.MethodGet, "/orders/id/{id}/queue-position", nil)
+			req.SetPathValue("id", tt.id)
+			respWriter := httptest.NewRecorder()
+
+			h.GetQueuePosition(respWriter, req)
+
+			assert.Equal(t, tt.wantStatus, respWriter.Code)
+			if respWriter.Code != http.StatusOK {
+				return
+			}
+
+			var resp QueuePositionResponse
+			assert.NoError(t, json.Unmarshal(respWriter.Body.Bytes(), &resp))
+			assert.Equal(t, orderID, resp.OrderID)
+			assert.Equal(t, "100.00", resp.Price)
+			assert.Equal(t, int64(3), resp.Position)
+			assert.Equal(t, int64(2), resp.OrdersAhead)
+			assert.Equal(t, "0.75000000", resp.QuantityAhead)
+		})
+	}
+}
+
+func TestOrderHandler_ImportOrders(t *testing.T) {
+	accountID := uuid.New()
+	row := func(orderType, price, quantity string) string {
+		return `{"account_id":"` + accountID.String() + `","instrument_pair":"BTC_BRL","order_type":"` + orderType +
+			`","price":"` + price + `","quantity":"` + quantity + `"}`
+	}
+
+	tests := []struct {
+		name       string
+		body       string
+		maxOrders  int
+		batchSize  int
+		mockSetup  func(m *usecase.MockOrderUseCase)
+		wantStatus int
+		wantBody   string
+	}{
+		{
+			name:      "imports valid rows and reports the others by index",
+			body:      `{"orders":[` + row("bid", "99", "1") + `,` + row("SELL", "abc", "1") + `,` + row("SELL", "101", "0.5") + `,` + row("SELL", "102", "1e1000000") + `]}`,
+			batchSize: 250,
+			mockSetup: func(m *usecase.MockOrderUseCase) {
+				m.EXPECT().ImportOrders(gomock.Any(), gomock.Any(), 250).DoAndReturn(func(_ context.Context, orders []*entity.Order, batchSize int) (*usecase.ImportResult, error) {
+					if assert.Len(t, orders, 2) {
+						assert.Equal(t, string(entity.OrderTypeBuy), orders[0].OrderType)
+						assert.Equal(t, "101", orders[1].Price.String())
+					}
+					return &usecase.ImportResult{
+						Imported: 1,
+						Failures: []*usecase.ImportFailure{{Index: 1, Err: entity.ErrWalletNotFound}},
+					}, nil
+				}).Times(1)
+			},
+			wantStatus: http.StatusOK,
+			wantBody: `{"imported":1,"failed":[
+				{"index":1,"error":"Invalid price format"},
+				{"index":2,"code":"WALLET_NOT_FOUND","error":"wallet not found for required asset"},
+				{"index":3,"error":"quantity is out of range"}
+			]}`,
+		},
+		{
+			name:       "no parsable rows skips the usecase",
+			body:       `{"orders":[` + row("BUY", "", "1") + `]}`,
+			wantStatus: http.StatusOK,
+			wantBody:   `{"imported":0,"failed":[{"index":0,"error":"Invalid price format"}]}`,
+		},
+		{
+			name:       "empty import returns 400",
+			body:       `{"orders":[]}`,
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "malformed body returns 400",
+			body:       `{"orders":`,
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "more rows than the cap returns 413",
+			body:       `{"orders":[` + row("BUY", "99", "1") + `,` + row("BUY", "98", "1") + `,` + row("BUY", "97", "1") + `]}`,
+			maxOrders:  2,
+			wantStatus: http.StatusRequestEntityTooLarge,
+			wantBody:   `{"error":"at most 2 orders can be imported at once"}`,
+		},
+		{
+			name:       "body over the size allowance returns 413",
+			body:       `{"orders":[` + row("BUY", "99", "1") + `],"pad":"` + strings.Repeat("x", 2*maxImportRowBytes) + `"}`,
+			maxOrders:  1,
+			wantStatus: http.StatusRequestEntityTooLarge,
+			wantBody:   `{"error":"Request body too large"}`,
+		},
+		{
+			name: "usecase error returns 500",
+			body: `{"orders":[` + row("BUY", "99", "1") + `]}`,
+			mockSetup: func(m *usecase.MockOrderUseCase) {
+				m.EXPECT().ImportOrders(gomock.Any(), gomock.Len(1), 0).Return(nil, assert.AnError).Times(1)
+			},
+			wantStatus: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockUC := usecase.NewMockOrderUseCase(ctrl)
+			if tt.mockSetup != nil {
+				tt.mockSetup(mockUC)
+			}
+			h := NewOrderHandler(zap.NewNop().Sugar(), mockUC, nil, ImportLimits{MaxOrders: tt.maxOrders, BatchSize: tt.batchSize})
+
+			respWriter := httptest.NewRecorder()
+			h.ImportOrders(respWriter, httptest.NewRequest(http.MethodPost, "/admin/orders/import", strings.NewReader(tt.body)))
+
+			assert.Equal(t, tt.wantStatus, respWriter.Code)
+			if tt.wantBody != "" {
+				assert.JSONEq(t, tt.wantBody, respWriter.Body.String())
+			}
+		})
+	}
+}
+
+func TestOrderHandler_GetMatchCandidates(t *testing.T) {
+	orderID, candidateID, accountID := uuid.New(), uuid.New(), uuid.New()
+	createdAt := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name       string
+		id         string
+		query      string
+		mockSetup  func(m *usecase.MockOrderUseCase)
+		wantStatus int
+	}{
+		{
+			name:  "success lists candidates in priority order",
+			id:    orderID.String(),
+			query: "?limit=10",
+			mockSetup: func(m *usecase.MockOrderUseCase) {
+				m.EXPECT().GetMatchCandidates(orderID, 10).Return(&usecase.MatchCandidates{
+					Order: &entity.Order{
+						Base:           entity.Base{ID: orderID},
+						InstrumentPair: "BTC_BRL",
+						OrderType:      "BUY",
+						Price:          decimal.RequireFromString("102"),
+					},
+					Candidates: []*usecase.MatchCandidate{{
+						Order: &entity.Order{
+							Base:              entity.Base{ID: candidateID, CreatedAt: createdAt},
+							AccountID:         accountID,
+							Price:             decimal.RequireFromString("100"),
+							RemainingQuantity: decimal.RequireFromString("0.7"),
+						},
+						Age: 90 * time.Second,
+					}},
+				}, nil).Times(1)
+			},
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "invalid id returns 400",
+			id:         "nope",
+			mockSetup:  func(m *usecase.MockOrderUseCase) {},
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "invalid limit returns 400",
+			id:         orderID.String(),
+			query:      "?limit=0",
+			mockSetup:  func(m *usecase.MockOrderUseCase) {},
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name: "unknown order returns 404",
+			id:   orderID.String(),
+			mockSetup: func(m *usecase.MockOrderUseCase) {
+				m.EXPECT().GetMatchCandidates(orderID, 0).Return(nil, repository.ErrNotFound).Times(1)
+			},
+			wantStatus: http.StatusNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockUC := usecase.NewMockOrderUseCase(ctrl)
+			instruments := entity.NewInstrumentConfig(entity.Asset{Symbol: "BTC", Scale: 8}, entity.Asset{Symbol: "BRL", Scale: 2})
+			h := NewOrderHandler(zap.NewNop().Sugar(), mockUC, instruments, ImportLimits{})
+			tt.mockSetup(mockUC)
+
+			req := httptest.NewRequest(http.MethodGet, "/admin/orders/{id}/match-candidates"+tt.query, nil)
+			req.SetPathValue("id", tt.id)
+			respWriter := httptest.NewRecorder()
+
+			h.GetMatchCandidates(respWriter, req)
+
+			assert.Equal(t, tt.wantStatus, respWriter.Code)
+			if respWriter.Code != http.StatusOK {
+				return
+			}
+
+			var resp MatchCandidatesResponse
+			assert.NoError(t, json.Unmarshal(respWriter.Body.Bytes(), &resp))
+			assert.Equal(t, orderID, resp.OrderID)
+			assert.Equal(t, "102.00", resp.Price)
+			if assert.Len(t, resp.Candidates, 1) {
+				candidate := resp.Candidates[0]
+				assert.Equal(t, candidateID, candidate.OrderID)
+				assert.Equal(t, accountID, candidate.AccountID)
+				assert.Equal(t, "100.00", candidate.Price)
+				assert.Equal(t, "0.70000000", candidate.RemainingQuantity)
+				assert.True(t, createdAt.Equal(candidate.CreatedAt))
+				assert.Equal(t, int64(90), candidate.AgeSeconds)
+			}
+		})
+	}
+}
+
+func TestOrderHandler_GetFilledOrders(t *testing.T) {
+	accountID := uuid.New()
+	orderID := uuid.New()
+	cursor := uuid.New()
+
+	tests := []struct {
+		name       string
+		query      string
+		mockSetup  func(m *usecase.MockOrderUseCase)
+		wantStatus int
+		wantCursor *string
+	}{
+		{
+			name:  "success returns realized amounts and the next cursor",
+			query: "?limit=1",
+			mockSetup: func(m *usecase.MockOrderUseCase) {
+				m.EXPECT().GetFilledOrders(accountID, uuid.Nil, 1).Return(&usecase.FilledOrdersPage{
+					Orders: []*usecase.FilledOrder{
+						{
+							Order: &entity.Order{
+								Base:           entity.Base{ID: orderID},
+								AccountID:      accountID,
+								InstrumentPair: "BTC_BRL",
+								OrderType:      "BUY",
+								Status:         string(entity.OrderStatusPartial),
+							},
+							BaseFilled:     decimal.RequireFromString("0.75"),
+							QuoteExchanged: decimal.RequireFromString("77.5"),
+							AveragePrice:   decimal.RequireFromString("103.3333333333333333"),
+						},
+					},
+					NextCursor: orderID,
+				}, nil).Times(1)
+			},
+			wantStatus: http.StatusOK,
+			wantCursor: func() *string { s := orderID.String(); return &s }(),
+		},
+		{
+			name:  "cursor is passed through",
+			query: "?cursor=" + cursor.String(),
+			mockSetup: func(m *usecase.MockOrderUseCase) {
+				m.EXPECT().GetFilledOrders(accountID, cursor, 0).Return(&usecase.FilledOrdersPage{}, nil).Times(1)
+			},
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "invalid cursor returns 400",
+			query:      "?cursor=nope",
+			mockSetup:  func(m *usecase.MockOrderUseCase) {},
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:  "usecase error returns 500",
+			query: "",
+			mockSetup: func(m *usecase.MockOrderUseCase) {
+				m.EXPECT().GetFilledOrders(accountID, uuid.Nil, 0).Return(nil, assert.AnError).Times(1)
+			},
+			wantStatus: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockUC := usecase.NewMockOrderUseCase(ctrl)
+			instruments := entity.NewInstrumentConfig(entity.Asset{Symbol: "BTC", Scale: 8}, entity.Asset{Symbol: "BRL", Scale: 2})
+			h := NewOrderHandler(zap.NewNop().Sugar(), mockUC, instruments, ImportLimits{})
+			tt.mockSetup(mockUC)
+
+			req := httptest.NewRequest(http.MethodGet, "/accounts/{id}/orders/filled"+tt.query, nil)
+			req.SetPathValue("id", accountID.String())
+			respWriter := httptest.NewRecorder()
+
+			h.GetFilledOrders(respWriter, req)
+
+			assert.Equal(t, tt.wantStatus, respWriter.Code)
+			if respWriter.Code != http.StatusOK {
+				return
+			}
+
+			var resp ListResponse[FilledOrderResponse]
+			assert.NoError(t, json.Unmarshal(respWriter.Body.Bytes(), &resp))
+			assert.Equal(t, tt.wantCursor, resp.Pagination.NextCursor)
+			if len(resp.Data) > 0 {
+				assert.Equal(t, orderID, resp.Data[0].ID)
+				assert.Equal(t, "0.75000000", resp.Data[0].BaseFilled)
+				assert.Equal(t, "77.50", resp.Data[0].QuoteExchanged)
+				assert.Equal(t, "103.33", resp.Data[0].AveragePrice)
+			}
+		})
+	}
+}
+
+func TestOrderHandler_GetAccountMarkets(t *testing.T) {
+	accountID := uuid.New()
+	tradeID := uuid.New()
+	executedAt := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	tests := []struct {
+		name       string
+		id         string
+		mockSetup  func(m *usecase.MockOrderUseCase)
+		wantStatus int
+		wantCount  int
+	}{
+		{
+			name: "success lists markets with and without trades",
+			id:   accountID.String(),
+			mockSetup: func(m *usecase.MockOrderUseCase) {
+				m.EXPECT().GetAccountMarkets(accountID).Return([]*usecase.AccountMarket{
+					{
+						InstrumentPair: "BTC_BRL",
+						OpenOrders:     1,
+						LastTrade: &entity.Trade{
+							ID:             tradeID,
+							InstrumentPair: "BTC_BRL",
+							Price:          decimal.RequireFromString("110"),
+							Quantity:       decimal.RequireFromString("0.2"),
+							ExecutedAt:     executedAt,
+						},
+					},
+					{InstrumentPair: "ETH_BRL", OpenOrders: 2},
+				}, nil).Times(1)
+			},
+			wantStatus: http.StatusOK,
+			wantCount:  2,
+		},
+		{
+			name: "new account returns an empty list",
+			id:   accountID.String(),
+			mockSetup: func(m *usecase.MockOrderUseCase) {
+				m.EXPECT().GetAccountMarkets(accountID).Return([]*usecase.AccountMarket{}, nil).Times(1)
+			},
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "invalid account id returns 400",
+			id:         "nope",
+			mockSetup:  func(m *usecase.MockOrderUseCase) {},
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name: "usecase error returns 500",
+			id:   accountID.String(),
+			mockSetup: func(m *usecase.MockOrderUseCase) {
+				m.EXPECT().GetAccountMarkets(accountID).Return(nil, assert.AnError).Times(1)
+			},
+			wantStatus: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockUC := usecase.NewMockOrderUseCase(ctrl)
+			instruments := entity.NewInstrumentConfig(entity.Asset{Symbol: "BTC", Scale: 8}, entity.Asset{Symbol: "BRL", Scale: 2})
+			h := NewOrderHandler(zap.NewNop().Sugar(), mockUC, instruments, ImportLimits{})
+			tt.mockSetup(mockUC)
+
+			req := httptest.NewRequest(http.MethodGet, "/accounts/{id}/markets", nil)
+			req.SetPathValue("id", tt.id)
+			respWriter := httptest.NewRecorder()
+
+			h.GetAccountMarkets(respWriter, req)
+
+			assert.Equal(t, tt.wantStatus, respWriter.Code)
+			if respWriter.Code != http.StatusOK {
+				return
+			}
+
+			var resp ListResponse[AccountMarketResponse]
+			assert.NoError(t, json.Unmarshal(respWriter.Body.Bytes(), &resp))
+			assert.NotNil(t, resp.Data)
+			assert.Len(t, resp.Data, tt.wantCount)
+			if tt.wantCount > 0 {
+				got := resp.Data[0]
+				assert.Equal(t, "BTC_BRL", got.InstrumentPair)
+				assert.Equal(t, int64(1), got.OpenOrders)
+				if assert.NotNil(t, got.LastTrade) {
+					assert.Equal(t, tradeID, got.LastTrade.TradeID)
+					assert.Equal(t, "110.00", got.LastTrade.Price)
+					assert.Equal(t, "0.20000000", got.LastTrade.Quantity)
+					assert.True(t, executedAt.Equal(got.LastTrade.ExecutedAt))
+				}
+				assert.Nil(t, resp.Data[1].LastTrade)
+			}
+		})
+	}
+}
@@ -0,0 +1,61 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+var errInvalidQueryParam = errors.New("invalid query parameter")
+
+// queryLimit reads the optional "limit" query parameter. Zero means the
+// caller did not send one and the use case default applies.
+func queryLimit(r *http.Request) (int, error) {
+	return queryPositiveInt(r, "limit")
+}
+
+// queryInvert reads the optional "invert" query parameter, false when it is
+// absent.
+func queryInvert(r *http.Request) (bool, error) {
+	v := r.URL.Query().Get("invert")
+	if v == "" {
+		return false, nil
+	}
+
+	invert, err := strconv.ParseBool(v)
+	if err != nil {
+		return false, errInvalidQueryParam
+	}
+	return invert, nil
+}
+
+// queryPositiveInt reads an optional positive integer query parameter,
+// returning zero when it is absent.
+func queryPositiveInt(r *http.Request, name string) (int, error) {
+	v := r.URL.Query().Get(name)
+	if v == "" {
+		return 0, nil
+	}
+
+	n, err := strconv.Atoi(v)
+	if err != nil || n <= 0 {
+		return 0, errInvalidQueryParam
+	}
+	return n, nil
+}
+
+// queryTime reads an optional RFC3339 query parameter in UTC, returning the
+// zero time when it is absent.
+func queryTime(r *http.Request, name string) (time.Time, error) {
+	v := r.URL.Query().Get(name)
+	if v == "" {
+		return time.Time{}, nil
+	}
+
+	t, err := time.Parse(time.RFC3339, v)
+	if err != nil {
+		return time.Time{}, errInvalidQueryParam
+	}
+	return t.UTC(), nil
+}
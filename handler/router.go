@@ -0,0 +1,101 @@
+package handler
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/lucas-moura1/mercadobitcoin-challenge/usecase"
+)
+
+// RouterConfig holds the handlers and settings the API routes are built from.
+type RouterConfig struct {
+	// Prefix is prepended to every route path, e.g. "/v1". Empty serves the
+	// routes at the root.
+	Prefix        string
+	ReadTimeout   time.Duration
+	WriteTimeout  time.Duration
+	AdminToken    string
+	ApiKeyUseCase usecase.ApiKeyUseCase
+	// Maintenance pauses order placement when enabled. Nil means a switch
+	// that starts disabled.
+	Maintenance *Maintenance
+
+	Orders   *orderHandler
+	Accounts *accountHandler
+	Trades   *tradeHandler
+	Events   *eventHandler
+	Markets  *marketHandler
+}
+
+// NewRouter registers every API route under cfg.Prefix. Signed routes are
+// matched on the prefixed path, so clients sign the path they actually send.
+func NewRouter(cfg RouterConfig) http.Handler {
+	mux := http.NewServeMux()
+
+	maintenance := cfg.Maintenance
+	if maintenance == nil {
+		maintenance = NewMaintenance(false)
+	}
+
+	// Errors written outside WithTimeout, such as by maintenance.Block,
+	// are negotiated here; WithTimeout negotiates again for the handlers
+	// behind it.
+	handle := func(method, path string, h http.HandlerFunc) {
+		mux.HandleFunc(method+" "+cfg.Prefix+path, NegotiateErrors(h))
+	}
+	read := func(h http.HandlerFunc) http.HandlerFunc {
+		return WithTimeout(cfg.ReadTimeout, h)
+	}
+	write := func(h http.HandlerFunc) http.HandlerFunc {
+		return WithTimeout(cfg.WriteTimeout, h)
+	}
+	signedWrite := func(h http.HandlerFunc) http.HandlerFunc {
+		return WithTimeout(cfg.WriteTimeout, RequireSignature(cfg.ApiKeyUseCase, h))
+	}
+	admin := func(h http.HandlerFunc) http.HandlerFunc {
+		return RequireAdminToken(cfg.AdminToken, h)
+	}
+
+	handle(http.MethodPost, "/orders", maintenance.Block(signedWrite(cfg.Orders.CreateOrder)))
+	handle(http.MethodPost, "/orders/replace", maintenance.Block(signedWrite(cfg.Orders.ReplaceOrder)))
+	handle(http.MethodPost, "/orders/cancel", signedWrite(cfg.Orders.CancelOrders))
+	handle(http.MethodPost, "/orders/{id}/cancel", signedWrite(cfg.Orders.CancelOrder))
+	handle(http.MethodGet, "/orders/{instrument_pair}", read(cfg.Orders.GetOrderBook))
+	handle(http.MethodGet, "/orders/id/{id}/fills", read(cfg.Orders.GetOrderFills))
+	handle(http.MethodGet, "/orders/id/{id}/queue-position", read(cfg.Orders.GetQueuePosition))
+	handle(http.MethodGet, "/orders/{instrument_pair}/raw", read(cfg.Orders.GetRawOrderBook))
+	handle(http.MethodGet, "/orders/{instrument_pair}/book", read(cfg.Orders.GetBook))
+	handle(http.MethodGet, "/orders/{instrument_pair}/depth", read(cfg.Orders.GetDepth))
+	handle(http.MethodGet, "/orders/{instrument_pair}/imbalance", read(cfg.Orders.GetImbalance))
+	handle(http.MethodGet, "/orders/{instrument_pair}/estimate", read(cfg.Orders.EstimateCost))
+	handle(http.MethodGet, "/orders/{instrument_pair}/summary", read(cfg.Orders.GetOrderSummary))
+	handle(http.MethodGet, "/orders/{instrument_pair}/spread-history", read(cfg.Orders.GetSpreadHistory))
+	handle(http.MethodGet, "/orders/{instrument_pair}/trades", read(cfg.Trades.GetTradesByInstrumentPair))
+	handle(http.MethodGet, "/orders/{instrument_pair}/candles", read(cfg.Trades.GetCandles))
+	handle(http.MethodGet, "/orders/{instrument_pair}/vwap", read(cfg.Trades.GetVWAP))
+	handle(http.MethodGet, "/orders/{instrument_pair}/ticks", read(cfg.Trades.GetTicks))
+
+	handle(http.MethodGet, "/accounts/{id}/balance", read(cfg.Accounts.GetAccountBalance))
+	handle(http.MethodGet, "/accounts/{id}/balance/{asset}", read(cfg.Accounts.GetAssetBalance))
+	handle(http.MethodGet, "/accounts/{id}/equity", read(cfg.Accounts.GetAccountEquity))
+	handle(http.MethodGet, "/accounts/{id}/trades", read(cfg.Trades.GetAccountTrades))
+	handle(http.MethodGet, "/accounts/{id}/pnl", read(cfg.Trades.GetPnL))
+	handle(http.MethodGet, "/accounts/{id}/rejections", read(cfg.Orders.GetAccountRejections))
+	handle(http.MethodGet, "/accounts/{id}/orders/filled", read(cfg.Orders.GetFilledOrders))
+	handle(http.MethodGet, "/accounts/{id}/markets", read(cfg.Orders.GetAccountMarkets))
+	handle(http.MethodDelete, "/accounts/{id}", signedWrite(cfg.Accounts.DeleteAccount))
+
+	handle(http.MethodGet, "/time", read(GetServerTime))
+
+	handle(http.MethodPost, "/admin/accounts/{id}/wallets/{asset}/adjust", write(admin(cfg.Accounts.AdjustBalance)))
+	handle(http.MethodGet, "/admin/events", read(admin(cfg.Events.GetEvents)))
+	handle(http.MethodPost, "/admin/orders/import", write(admin(cfg.Orders.ImportOrders)))
+	handle(http.MethodGet, "/admin/orders/{id}/match-candidates", read(admin(cfg.Orders.GetMatchCandidates)))
+	handle(http.MethodGet, "/admin/maintenance", read(admin(maintenance.GetMaintenance)))
+	handle(http.MethodPost, "/admin/maintenance", write(admin(maintenance.SetMaintenance)))
+	handle(http.MethodGet, "/admin/markets/halted", read(admin(cfg.Markets.GetHaltedMarkets)))
+	handle(http.MethodPost, "/admin/markets/{instrument_pair}/halt", write(admin(cfg.Markets.HaltMarket)))
+	handle(http.MethodPost, "/admin/markets/{instrument_pair}/resume", write(admin(cfg.Markets.ResumeMarket)))
+
+	return mux
+}
@@ -0,0 +1,405 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lucas-moura1/mercadobitcoin-challenge/entity"
+	"github.com/lucas-moura1/mercadobitcoin-challenge/usecase"
+	"github.com/lucas-moura1/mercadobitcoin-challenge/usecase/usecasetest"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+	"go.uber.org/zap"
+)
+
+func TestNewRouter(t *testing.T) {
+	tests := []struct {
+		name       string
+		prefix     string
+		method     string
+		path       string
+		hitsBook   bool
+		wantStatus int
+	}{
+		{name: "versioned path is served", prefix: "/v1", method: http.MethodGet, path: "/v1/orders/BTC_BRL", hitsBook: true, wantStatus: http.StatusOK},
+		{name: "unversioned path is not found", prefix: "/v1", method: http.MethodGet, path: "/orders/BTC_BRL", wantStatus: http.StatusNotFound},
+		{name: "other version is not found", prefix: "/v1", method: http.MethodGet, path: "/v2/orders/BTC_BRL", wantStatus: http.StatusNotFound},
+		{name: "signed route still requires a signature", prefix: "/v1", method: http.MethodPost, path: "/v1/orders", wantStatus: http.StatusUnauthorized},
+		{name: "server time is served", prefix: "/v1", method: http.MethodGet, path: "/v1/time", wantStatus: http.StatusOK},
+		{name: "empty prefix serves the root", prefix: "", method: http.MethodGet, path: "/orders/BTC_BRL", hitsBook: true, wantStatus: http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			log := zap.NewNop().Sugar()
+			orderUC := usecase.NewMockOrderUseCase(ctrl)
+			apiKeyUC := usecase.NewMockApiKeyUseCase(ctrl)
+
+			if tt.hitsBook {
+				orderUC.EXPECT().GetOrderBook("BTC_BRL", decimal.Zero).Return(&usecase.OrderBook{}, nil).Times(1)
+			}
+			apiKeyUC.EXPECT().Authenticate(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+				Return(nil, entity.ErrInvalidSignature).AnyTimes()
+
+			router := NewRouter(RouterConfig{
+				Prefix:        tt.prefix,
+				ReadTimeout:   time.Second,
+				WriteTimeout:  time.Second,
+				ApiKeyUseCase: apiKeyUC,
+				Orders:        NewOrderHandler(log, orderUC, nil, ImportLimits{}),
+				Accounts:      NewAccountHandler(log, usecase.NewMockAccountUseCase(ctrl), nil),
+				Trades:        NewTradeHandler(log, usecase.NewMockTradeUseCase(ctrl), nil),
+				Events:        NewEventHandler(log, usecase.NewMockEventUseCase(ctrl)),
+			})
+
+			respWriter := httptest.NewRecorder()
+			router.ServeHTTP(respWriter, httptest.NewRequest(tt.method, tt.path, nil))
+
+			assert.Equal(t, tt.wantStatus, respWriter.Code)
+		})
+	}
+}
+
+type routerMocks struct {
+	orders   *usecase.MockOrderUseCase
+	accounts *usecase.MockAccountUseCase
+	trades   *usecase.MockTradeUseCase
+	events   *usecase.MockEventUseCase
+	markets  *usecase.MockMarketHaltUseCase
+}
+
+func TestNewRouter_Routes(t *testing.T) {
+	accountID := uuid.New()
+	orderID := uuid.New()
+	orderBody := `"account_id":"` + accountID.String() + `","instrument_pair":"ETH_BRL","order_type":"BUY","price":"10","quantity":"1"`
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		body   string
+		expect func(m routerMocks)
+	}{
+		{
+			name: "create order", method: http.MethodPost, path: "/v1/orders", body: "{" + orderBody + "}",
+			expect: func(m routerMocks) {
+				m.orders.EXPECT().CreateOrder(gomock.Any(), gomock.Any()).Return(assert.AnError)
+			},
+		},
+		{
+			name: "replace order", method: http.MethodPost, path: "/v1/orders/replace",
+			body: `{"order_id":"` + orderID.String() + `",` + orderBody + "}",
+			expect: func(m routerMocks) {
+				m.orders.EXPECT().ReplaceOrder(gomock.Any(), orderID, gomock.Any()).Return(nil, assert.AnError)
+			},
+		},
+		{
+			name: "cancel orders", method: http.MethodPost, path: "/v1/orders/cancel",
+			body: `{"account_id":"` + accountID.String() + `","instrument_pair":"ETH_BRL","side":"SELL"}`,
+			expect: func(m routerMocks) {
+				m.orders.EXPECT().CancelOrders(gomock.Any(), accountID, "ETH_BRL", "SELL").Return(nil, assert.AnError)
+			},
+		},
+		{
+			name: "cancel order", method: http.MethodPost, path: "/v1/orders/" + orderID.String() + "/cancel",
+			expect: func(m routerMocks) {
+				m.orders.EXPECT().CancelOrder(gomock.Any(), orderID, accountID).Return(assert.AnError)
+			},
+		},
+		{
+			name: "order book", method: http.MethodGet, path: "/v1/orders/ETH_BRL",
+			expect: func(m routerMocks) {
+				m.orders.EXPECT().GetOrderBook("ETH_BRL", decimal.Zero).Return(nil, assert.AnError)
+			},
+		},
+		{
+			name: "order fills", method: http.MethodGet, path: "/v1/orders/id/" + orderID.String() + "/fills",
+			expect: func(m routerMocks) {
+				m.orders.EXPECT().GetOrderFills(orderID).Return(nil, assert.AnError)
+			},
+		},
+		{
+			name: "queue position", method: http.MethodGet, path: "/v1/orders/id/" + orderID.String() + "/queue-position",
+			expect: func(m routerMocks) {
+				m.orders.EXPECT().GetQueuePosition(orderID).Return(nil, assert.AnError)
+			},
+		},
+		{
+			name: "estimate", method: http.MethodGet, path: "/v1/orders/BTC_BRL/estimate?side=buy&quantity=2",
+			expect: func(m routerMocks) {
+				m.orders.EXPECT().EstimateCost("BTC_BRL", "BUY", decimal.RequireFromString("2")).Return(nil, assert.AnError)
+			},
+		},
+		{
+			name: "raw order book", method: http.MethodGet, path: "/v1/orders/ETH_BRL/raw?depth=3",
+			expect: func(m routerMocks) {
+				m.orders.EXPECT().GetRawOrderBook("ETH_BRL", 3).Return(nil, assert.AnError)
+			},
+		},
+		{
+			name: "book", method: http.MethodGet, path: "/v1/orders/ETH_BRL/book?view=raw&depth=3",
+			expect: func(m routerMocks) {
+				m.orders.EXPECT().GetBook("ETH_BRL", usecase.BookViewRaw, 3).Return(nil, assert.AnError)
+			},
+		},
+		{
+			name: "depth", method: http.MethodGet, path: "/v1/orders/ETH_BRL/depth?side=bid&price=10",
+			expect: func(m routerMocks) {
+				m.orders.EXPECT().GetDepth("ETH_BRL", "bid", gomock.Any()).Return(decimal.Zero, assert.AnError)
+			},
+		},
+		{
+			name: "imbalance", method: http.MethodGet, path: "/v1/orders/ETH_BRL/imbalance?depth=5",
+			expect: func(m routerMocks) {
+				m.orders.EXPECT().GetImbalance("ETH_BRL", 5).Return(nil, assert.AnError)
+			},
+		},
+		{
+			name: "order summary", method: http.MethodGet, path: "/v1/orders/ETH_BRL/summary",
+			expect: func(m routerMocks) {
+				m.orders.EXPECT().GetOrderSummary("ETH_BRL", time.Time{}, time.Time{}).Return(nil, assert.AnError)
+			},
+		},
+		{
+			name: "spread history", method: http.MethodGet, path: "/v1/orders/ETH_BRL/spread-history",
+			expect: func(m routerMocks) {
+				m.orders.EXPECT().GetSpreadHistory("ETH_BRL", gomock.Any(), gomock.Any()).Return(nil, assert.AnError)
+			},
+		},
+		{
+			name: "pair trades", method: http.MethodGet, path: "/v1/orders/ETH_BRL/trades",
+			expect: func(m routerMocks) {
+				m.trades.EXPECT().GetTradesByInstrumentPair("ETH_BRL", gomock.Any()).Return(nil, assert.AnError)
+			},
+		},
+		{
+			name: "candles", method: http.MethodGet, path: "/v1/orders/ETH_BRL/candles?interval=5m",
+			expect: func(m routerMocks) {
+				m.trades.EXPECT().GetCandles("ETH_BRL", "5m", gomock.Any(), gomock.Any()).Return(nil, assert.AnError)
+			},
+		},
+		{
+			name: "vwap", method: http.MethodGet, path: "/v1/orders/ETH_BRL/vwap?window=15m",
+			expect: func(m routerMocks) {
+				m.trades.EXPECT().GetVWAP("ETH_BRL", 15*time.Minute).Return(nil, assert.AnError)
+			},
+		},
+		{
+			name: "ticks", method: http.MethodGet, path: "/v1/orders/ETH_BRL/ticks?since=" + orderID.String() + "&limit=5",
+			expect: func(m routerMocks) {
+				m.trades.EXPECT().GetTicks("ETH_BRL", orderID, 5).Return(nil, assert.AnError)
+			},
+		},
+		{
+			name: "account balance", method: http.MethodGet, path: "/v1/accounts/" + accountID.String() + "/balance",
+			expect: func(m routerMocks) {
+				m.accounts.EXPECT().GetAccountBalance(accountID).Return(nil, assert.AnError)
+			},
+		},
+		{
+			name: "asset balance", method: http.MethodGet, path: "/v1/accounts/" + accountID.String() + "/balance/BTC",
+			expect: func(m routerMocks) {
+				m.accounts.EXPECT().GetAssetBalance(accountID, "BTC").Return(nil, assert.AnError)
+			},
+		},
+		{
+			name: "account equity", method: http.MethodGet, path: "/v1/accounts/" + accountID.String() + "/equity?quote=BRL",
+			expect: func(m routerMocks) {
+				m.accounts.EXPECT().GetAccountEquity(accountID, "BRL").Return(nil, assert.AnError)
+			},
+		},
+		{
+			name: "account trades", method: http.MethodGet, path: "/v1/accounts/" + accountID.String() + "/trades",
+			expect: func(m routerMocks) {
+				m.trades.EXPECT().GetTradesByAccount(accountID, gomock.Any()).Return(nil, assert.AnError)
+			},
+		},
+		{
+			name: "account pnl", method: http.MethodGet, path: "/v1/accounts/" + accountID.String() + "/pnl?pair=BTC_BRL&method=fifo",
+			expect: func(m routerMocks) {
+				m.trades.EXPECT().GetPnL(accountID, "BTC_BRL", usecase.CostBasisFIFO).Return(nil, assert.AnError)
+			},
+		},
+		{
+			name: "account rejections", method: http.MethodGet, path: "/v1/accounts/" + accountID.String() + "/rejections?limit=5",
+			expect: func(m routerMocks) {
+				m.orders.EXPECT().GetRejections(accountID, 5).Return(nil, assert.AnError)
+			},
+		},
+		{
+			name: "filled orders", method: http.MethodGet,
+			path: "/v1/accounts/" + accountID.String() + "/orders/filled?limit=5&cursor=" + orderID.String(),
+			expect: func(m routerMocks) {
+				m.orders.EXPECT().GetFilledOrders(accountID, orderID, 5).Return(nil, assert.AnError)
+			},
+		},
+		{
+			name: "account markets", method: http.MethodGet, path: "/v1/accounts/" + accountID.String() + "/markets",
+			expect: func(m routerMocks) {
+				m.orders.EXPECT().GetAccountMarkets(accountID).Return(nil, assert.AnError)
+			},
+		},
+		{
+			name: "delete account", method: http.MethodDelete, path: "/v1/accounts/" + accountID.String(),
+			expect: func(m routerMocks) {
+				m.accounts.EXPECT().DeleteAccount(gomock.Any(), accountID).Return(assert.AnError)
+			},
+		},
+		{
+			name: "adjust wallet", method: http.MethodPost, path: "/v1/admin/accounts/" + accountID.String() + "/wallets/BRL/adjust",
+			body: `{"amount":"-10"}`,
+			expect: func(m routerMocks) {
+				m.accounts.EXPECT().AdjustBalance(gomock.Any(), accountID, "BRL", decimal.RequireFromString("-10")).Return(nil, assert.AnError)
+			},
+		},
+		{
+			name: "admin events", method: http.MethodGet, path: "/v1/admin/events?since=7",
+			expect: func(m routerMocks) {
+				m.events.EXPECT().GetEventsSince(int64(7), gomock.Any()).Return(nil, assert.AnError)
+			},
+		},
+		{
+			name: "import orders", method: http.MethodPost, path: "/v1/admin/orders/import",
+			body: `{"orders":[{"account_id":"` + accountID.String() + `","instrument_pair":"BTC_BRL","order_type":"BUY","price":"100","quantity":"1"}]}`,
+			expect: func(m routerMocks) {
+				m.orders.EXPECT().ImportOrders(gomock.Any(), gomock.Len(1), 0).Return(nil, assert.AnError)
+			},
+		},
+		{
+			name: "match candidates", method: http.MethodGet, path: "/v1/admin/orders/" + orderID.String() + "/match-candidates?limit=5",
+			expect: func(m routerMocks) {
+				m.orders.EXPECT().GetMatchCandidates(orderID, 5).Return(nil, assert.AnError)
+			},
+		},
+		{
+			name: "halted markets", method: http.MethodGet, path: "/v1/admin/markets/halted",
+			expect: func(m routerMocks) {
+				m.markets.EXPECT().Halted().Return(nil)
+			},
+		},
+		{
+			name: "halt market", method: http.MethodPost, path: "/v1/admin/markets/ETH_BRL/halt",
+			expect: func(m routerMocks) {
+				m.markets.EXPECT().Halt("ETH_BRL").Return(assert.AnError)
+			},
+		},
+		{
+			name: "resume market", method: http.MethodPost, path: "/v1/admin/markets/ETH_BRL/resume",
+			expect: func(m routerMocks) {
+				m.markets.EXPECT().Resume("ETH_BRL").Return(assert.AnError)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			log := zap.NewNop().Sugar()
+			m := routerMocks{
+				orders:   usecase.NewMockOrderUseCase(ctrl),
+				accounts: usecase.NewMockAccountUseCase(ctrl),
+				trades:   usecase.NewMockTradeUseCase(ctrl),
+				events:   usecase.NewMockEventUseCase(ctrl),
+				markets:  usecase.NewMockMarketHaltUseCase(ctrl),
+			}
+			apiKeyUC := usecase.NewMockApiKeyUseCase(ctrl)
+			apiKeyUC.EXPECT().Authenticate(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+				Return(&entity.ApiKey{AccountID: accountID}, nil).AnyTimes()
+
+			// Each expectation must be met exactly once, which proves the
+			// request reached that handler with the path values it was given.
+			tt.expect(m)
+
+			router := NewRouter(RouterConfig{
+				Prefix:        "/v1",
+				ReadTimeout:   time.Second,
+				WriteTimeout:  time.Second,
+				AdminToken:    "admin",
+				ApiKeyUseCase: apiKeyUC,
+				Orders:        NewOrderHandler(log, m.orders, nil, ImportLimits{}),
+				Accounts:      NewAccountHandler(log, m.accounts, nil),
+				Trades:        NewTradeHandler(log, m.trades, nil),
+				Events:        NewEventHandler(log, m.events),
+				Markets:       NewMarketHandler(log, m.markets),
+			})
+
+			req := httptest.NewRequest(tt.method, tt.path, strings.NewReader(tt.body))
+			req.Header.Set(AdminTokenHeader, "admin")
+			respWriter := httptest.NewRecorder()
+			router.ServeHTTP(respWriter, req)
+
+			assert.NotEqual(t, http.StatusNotFound, respWriter.Code)
+			assert.NotEqual(t, http.StatusMethodNotAllowed, respWriter.Code)
+		})
+	}
+}
+
+func TestNewRouter_InMemory(t *testing.T) {
+	log := zap.NewNop().Sugar()
+	instruments := entity.NewInstrumentConfig(
+		entity.Asset{Symbol: "BTC", Scale: 8},
+		entity.Asset{Symbol: "BRL", Scale: 2},
+	)
+	accountID := uuid.New()
+	accounts := usecasetest.NewAccountUseCase()
+	accounts.Fund(accountID, "BRL", decimal.RequireFromString("1000"))
+
+	router := NewRouter(RouterConfig{
+		Prefix:        "/v1",
+		ReadTimeout:   time.Second,
+		WriteTimeout:  time.Second,
+		ApiKeyUseCase: usecasetest.NewApiKeyUseCase(map[string]uuid.UUID{"key": accountID}),
+		Orders:        NewOrderHandler(log, usecasetest.NewOrderUseCase(nil), instruments, ImportLimits{}),
+		Accounts:      NewAccountHandler(log, accounts, instruments),
+	})
+
+	place := func(orderType, price, quantity string) *httptest.ResponseRecorder {
+		body := `{"account_id":"` + accountID.String() + `","instrument_pair":"btc_brl","order_type":"` + orderType +
+			`","price":"` + price + `","quantity":"` + quantity + `"}`
+		req := httptest.NewRequest(http.MethodPost, "/v1/orders", strings.NewReader(body))
+		req.Header.Set(ApiKeyHeader, "key")
+		respWriter := httptest.NewRecorder()
+		router.ServeHTTP(respWriter, req)
+		return respWriter
+	}
+
+	created := place("BUY", "100", "0.5")
+	assert.Equal(t, http.StatusCreated, created.Code, created.Body.String())
+	var order CreateOrderResponse
+	assert.NoError(t, json.Unmarshal(created.Body.Bytes(), &order))
+	assert.NotEqual(t, uuid.Nil, order.OrderID)
+	assert.Equal(t, "BTC_BRL", order.InstrumentPair)
+	assert.Equal(t, string(entity.OrderStatusOpen), order.Status)
+	assert.Equal(t, "100.00", order.Price)
+	assert.Equal(t, "0.50000000", order.Quantity)
+
+	assert.Equal(t, http.StatusCreated, place("BUY", "100", "0.25").Code)
+	assert.Equal(t, http.StatusCreated, place("SELL", "105", "1").Code)
+	assert.Equal(t, http.StatusBadRequest, place("BUY", "-1", "1").Code)
+
+	respWriter := httptest.NewRecorder()
+	router.ServeHTTP(respWriter, httptest.NewRequest(http.MethodGet, "/v1/orders/BTC_BRL", nil))
+	assert.Equal(t, http.StatusOK, respWriter.Code)
+	var book OrderBookResponse
+	assert.NoError(t, json.Unmarshal(respWriter.Body.Bytes(), &book))
+	assert.Equal(t, []OrderBookLevel{{Price: "100.00", Quantity: "0.75000000"}}, book.Bids)
+	assert.Equal(t, []OrderBookLevel{{Price: "105.00", Quantity: "1.00000000"}}, book.Asks)
+
+	respWriter = httptest.NewRecorder()
+	router.ServeHTTP(respWriter, httptest.NewRequest(http.MethodGet, "/v1/accounts/"+accountID.String()+"/balance/BRL", nil))
+	assert.Equal(t, http.StatusOK, respWriter.Code)
+	assert.JSONEq(t, `{"account_id":"`+accountID.String()+`","asset":"BRL","balance":"1000.00","available":"1000.00","reserved":"0.00"}`, respWriter.Body.String())
+}
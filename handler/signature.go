@@ -0,0 +1,71 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/lucas-moura1/mercadobitcoin-challenge/usecase"
+)
+
+const (
+	ApiKeyHeader    = "X-API-Key"
+	TimestampHeader = "X-Timestamp"
+	SignatureHeader = "X-Signature"
+)
+
+type signedAccountKey struct{}
+
+// RequireSignature only lets the request through when it is signed with an
+// API key's secret, see entity.SignRequest. The key's account is stored in
+// the request context for handlers to check ownership against.
+func RequireSignature(apiKeyUseCase usecase.ApiKeyUseCase, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// The body is read whole to check the signature, so it is bounded
+		// first.
+		r.Body = http.MaxBytesReader(w, r.Body, maxJSONBodyBytes)
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			var maxBytesErr *http.MaxBytesError
+			if errors.As(err, &maxBytesErr) {
+				errorHandler(w, http.StatusRequestEntityTooLarge, "Request body too large")
+				return
+			}
+			errorHandler(w, http.StatusBadRequest, "Invalid request body")
+			return
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+
+		apiKey, err := apiKeyUseCase.Authenticate(
+			r.Header.Get(ApiKeyHeader),
+			r.Header.Get(TimestampHeader),
+			r.Header.Get(SignatureHeader),
+			r.Method,
+			r.URL.RequestURI(),
+			body,
+		)
+		if err != nil {
+			domainErrorHandler(w, err, http.StatusInternalServerError)
+			return
+		}
+
+		next(w, r.WithContext(context.WithValue(r.Context(), signedAccountKey{}, apiKey.AccountID)))
+	}
+}
+
+// signedAccount returns the account of the API key the request was signed
+// with. Unsigned requests have none.
+func signedAccount(r *http.Request) (uuid.UUID, bool) {
+	signed, ok := r.Context().Value(signedAccountKey{}).(uuid.UUID)
+	return signed, ok
+}
+
+// signedAccountMismatch reports whether the request was signed by an API key
+// of a different account than accountID. Unsigned requests never mismatch.
+func signedAccountMismatch(r *http.Request, accountID uuid.UUID) bool {
+	signed, ok := signedAccount(r)
+	return ok && signed != accountID
+}
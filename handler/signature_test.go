@@ -0,0 +1,181 @@
+package handler
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/lucas-moura1/mercadobitcoin-challenge/entity"
+	"github.com/lucas-moura1/mercadobitcoin-challenge/usecase"
+	"github.com/lucas-moura1/mercadobitcoin-challenge/usecase/usecasetest"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+	"go.uber.org/zap"
+)
+
+func TestRequireSignature(t *testing.T) {
+	accountID := uuid.New()
+	body := `{"account_id":"` + accountID.String() + `"}`
+
+	tests := []struct {
+		name       string
+		setupMock  func(m *usecase.MockApiKeyUseCase)
+		wantStatus int
+		wantNext   bool
+	}{
+		{
+			name: "valid signature passes body and account through",
+			setupMock: func(m *usecase.MockApiKeyUseCase) {
+				m.EXPECT().
+					Authenticate("key", "1700000000", "sig", http.MethodPost, "/orders?x=1", []byte(body)).
+					Return(&entity.ApiKey{AccountID: accountID}, nil).
+					Times(1)
+			},
+			wantStatus: http.StatusOK,
+			wantNext:   true,
+		},
+		{
+			name: "stale timestamp returns 401",
+			setupMock: func(m *usecase.MockApiKeyUseCase) {
+				m.EXPECT().Authenticate(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+					Return(nil, entity.ErrStaleTimestamp).Times(1)
+			},
+			wantStatus: http.StatusUnauthorized,
+		},
+		{
+			name: "bad signature returns 401",
+			setupMock: func(m *usecase.MockApiKeyUseCase) {
+				m.EXPECT().Authenticate(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+					Return(nil, entity.ErrInvalidSignature).Times(1)
+			},
+			wantStatus: http.StatusUnauthorized,
+		},
+		{
+			name: "usecase error returns 500",
+			setupMock: func(m *usecase.MockApiKeyUseCase) {
+				m.EXPECT().Authenticate(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+					Return(nil, assert.AnError).Times(1)
+			},
+			wantStatus: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockUC := usecase.NewMockApiKeyUseCase(ctrl)
+			tt.setupMock(mockUC)
+
+			called := false
+			next := func(w http.ResponseWriter, r *http.Request) {
+				called = true
+				got, _ := io.ReadAll(r.Body)
+				assert.Equal(t, body, string(got))
+				assert.False(t, signedAccountMismatch(r, accountID))
+				assert.True(t, signedAccountMismatch(r, uuid.New()))
+			}
+
+			req := httptest.NewRequest(http.MethodPost, "/orders?x=1", strings.NewReader(body))
+			req.Header.Set(ApiKeyHeader, "key")
+			req.Header.Set(TimestampHeader, "1700000000")
+			req.Header.Set(SignatureHeader, "sig")
+			respWriter := httptest.NewRecorder()
+
+			RequireSignature(mockUC, next)(respWriter, req)
+
+			assert.Equal(t, tt.wantStatus, respWriter.Code)
+			assert.Equal(t, tt.wantNext, called)
+		})
+	}
+}
+
+func TestRequireSignature_BodyTooLarge(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	// The body is rejected before the signature is checked.
+	mockUC := usecase.NewMockApiKeyUseCase(ctrl)
+	called := false
+	next := func(w http.ResponseWriter, r *http.Request) { called = true }
+
+	req := httptest.NewRequest(http.MethodPost, "/orders", strings.NewReader(strings.Repeat(" ", maxJSONBodyBytes+1)))
+	req.Header.Set(ApiKeyHeader, "key")
+	req.Header.Set(TimestampHeader, "1700000000")
+	req.Header.Set(SignatureHeader, "sig")
+	respWriter := httptest.NewRecorder()
+
+	RequireSignature(mockUC, next)(respWriter, req)
+
+	assert.Equal(t, http.StatusRequestEntityTooLarge, respWriter.Code)
+	assert.False(t, called)
+}
+
+func TestOrderHandler_CreateOrder_SignedAccountMismatch(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	orderUC := usecase.NewMockOrderUseCase(ctrl)
+	apiKeyUC := usecase.NewMockApiKeyUseCase(ctrl)
+	apiKeyUC.EXPECT().Authenticate(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+		Return(&entity.ApiKey{AccountID: uuid.New()}, nil).Times(1)
+
+	h := NewOrderHandler(zap.NewNop().Sugar(), orderUC, nil, ImportLimits{})
+
+	body := `{"account_id":"` + uuid.New().String() + `","instrument_pair":"BTC_BRL","order_type":"BUY","price":"100","quantity":"1"}`
+	req := httptest.NewRequest(http.MethodPost, "/orders", strings.NewReader(body))
+	respWriter := httptest.NewRecorder()
+
+	RequireSignature(apiKeyUC, h.CreateOrder)(respWriter, req)
+
+	assert.Equal(t, http.StatusForbidden, respWriter.Code)
+}
+
+func TestOrderHandler_CancelOrder_SignedAccountMismatch(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ownerID, intruderID := uuid.New(), uuid.New()
+	orderUC := usecasetest.NewOrderUseCase(nil)
+	order := &entity.Order{
+		AccountID:      ownerID,
+		InstrumentPair: "BTC_BRL",
+		OrderType:      string(entity.OrderTypeSell),
+		Price:          decimal.RequireFromString("100"),
+		Quantity:       decimal.RequireFromString("1"),
+	}
+	assert.NoError(t, orderUC.CreateOrder(context.Background(), order))
+
+	h := NewOrderHandler(zap.NewNop().Sugar(), orderUC, nil, ImportLimits{})
+	cancel := func(signer uuid.UUID) int {
+		apiKeyUC := usecase.NewMockApiKeyUseCase(ctrl)
+		apiKeyUC.EXPECT().Authenticate(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+			Return(&entity.ApiKey{AccountID: signer}, nil).Times(1)
+
+		req := httptest.NewRequest(http.MethodPost, "/orders/"+order.ID.String()+"/cancel", nil)
+		req.SetPathValue("id", order.ID.String())
+		respWriter := httptest.NewRecorder()
+		RequireSignature(apiKeyUC, h.CancelOrder)(respWriter, req)
+		return respWriter.Code
+	}
+
+	asks := func() int {
+		book, err := orderUC.GetOrderBook("BTC_BRL", decimal.Zero)
+		assert.NoError(t, err)
+		return len(book.Asks)
+	}
+
+	// Another account's key cannot cancel the order, and it stays resting.
+	assert.Equal(t, http.StatusForbidden, cancel(intruderID))
+	assert.Equal(t, 1, asks())
+
+	assert.Equal(t, http.StatusOK, cancel(ownerID))
+	assert.Zero(t, asks())
+}
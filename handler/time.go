@@ -0,0 +1,22 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+)
+
+type ServerTimeResponse struct {
+	ServerTime time.Time `json:"server_time"`
+	// Timestamp is ServerTime in Unix seconds, the unit of X-Timestamp.
+	Timestamp int64 `json:"timestamp"`
+}
+
+// GetServerTime returns the server clock so clients can sign requests with a
+// timestamp inside the accepted window. It touches no dependencies.
+func GetServerTime(w http.ResponseWriter, r *http.Request) {
+	now := time.Now().UTC()
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(ServerTimeResponse{ServerTime: now, Timestamp: now.Unix()})
+}
@@ -0,0 +1,31 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetServerTime(t *testing.T) {
+	respWriter := httptest.NewRecorder()
+	GetServerTime(respWriter, httptest.NewRequest(http.MethodGet, "/time", nil))
+
+	assert.Equal(t, http.StatusOK, respWriter.Code)
+	assert.Equal(t, "application/json", respWriter.Header().Get("Content-Type"))
+
+	var resp map[string]any
+	if !assert.NoError(t, json.Unmarshal(respWriter.Body.Bytes(), &resp)) {
+		return
+	}
+
+	serverTime, err := time.Parse(time.RFC3339, resp["server_time"].(string))
+	if assert.NoError(t, err) {
+		assert.WithinDuration(t, time.Now(), serverTime, 2*time.Second)
+		assert.Equal(t, time.UTC, serverTime.Location())
+		assert.Equal(t, float64(serverTime.Unix()), resp["timestamp"])
+	}
+}
@@ -0,0 +1,45 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+)
+
+const timeoutMessage = "Request timed out"
+
+// WithTimeout answers 503 with the usual error body when next has not
+// finished within timeout. The request context is cancelled at that point;
+// anything next writes afterwards is discarded. Write use cases run their
+// transaction with that context, so a write cut off by the timeout is rolled
+// back rather than committed behind the 503. The timeout body follows the
+// same content negotiation as errorHandler, and next is wrapped in
+// NegotiateErrors, since the writer it gets is not the one passed in.
+func WithTimeout(timeout time.Duration, next http.HandlerFunc) http.HandlerFunc {
+	body, _ := json.Marshal(map[string]string{"error": timeoutMessage})
+	jsonTimeout := http.TimeoutHandler(NegotiateErrors(next), timeout, string(body))
+	plainTimeout := http.TimeoutHandler(NegotiateErrors(next), timeout, timeoutMessage+"\n")
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		if prefersPlainText(r) {
+			plainTimeout.ServeHTTP(&timeoutContentType{ResponseWriter: w, contentType: plainTextContentType}, r)
+			return
+		}
+		jsonTimeout.ServeHTTP(&timeoutContentType{ResponseWriter: w, contentType: jsonContentType}, r)
+	}
+}
+
+// timeoutContentType labels the body http.TimeoutHandler writes on timeout,
+// which it sends without a content type. Only a 503 with no content type is
+// labelled; responses of the wrapped handler already carry theirs.
+type timeoutContentType struct {
+	http.ResponseWriter
+	contentType string
+}
+
+func (w *timeoutContentType) WriteHeader(status int) {
+	if status == http.StatusServiceUnavailable && w.Header().Get("Content-Type") == "" {
+		w.Header().Set("Content-Type", w.contentType)
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
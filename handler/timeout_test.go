@@ -0,0 +1,92 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/lucas-moura1/mercadobitcoin-challenge/usecase"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+	"go.uber.org/zap"
+)
+
+func TestWithTimeout(t *testing.T) {
+	tests := []struct {
+		name       string
+		delay      time.Duration
+		wantStatus int
+		wantError  string
+	}{
+		{
+			name:       "fast handler response is passed through",
+			delay:      0,
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "slow handler returns 503 with error body",
+			delay:      200 * time.Millisecond,
+			wantStatus: http.StatusServiceUnavailable,
+			wantError:  "Request timed out",
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			done := make(chan struct{})
+			mockUC := usecase.NewMockOrderUseCase(ctrl)
+			mockUC.EXPECT().
+				GetOrderBook("BTC_BRL", decimal.Zero).
+				DoAndReturn(func(string, decimal.Decimal) (*usecase.OrderBook, error) {
+					defer close(done)
+					time.Sleep(tt.delay)
+					return &usecase.OrderBook{InstrumentPair: "BTC_BRL"}, nil
+				}).
+				Times(1)
+
+			h := NewOrderHandler(zap.NewNop().Sugar(), mockUC, nil, ImportLimits{})
+			wrapped := WithTimeout(20*time.Millisecond, h.GetOrderBook)
+
+			req := httptest.NewRequest(http.MethodGet, "/orders/BTC_BRL", nil)
+			req.SetPathValue("instrument_pair", "BTC_BRL")
+			rw := httptest.NewRecorder()
+
+			wrapped(rw, req)
+			<-done
+
+			assert.Equal(t, tt.wantStatus, rw.Code)
+			if tt.wantError != "" {
+				var resp map[string]string
+				assert.NoError(t, json.Unmarshal(rw.Body.Bytes(), &resp))
+				assert.Equal(t, tt.wantError, resp["error"])
+				assert.Equal(t, "application/json", rw.Header().Get("Content-Type"))
+			}
+		})
+	}
+}
+
+func TestWithTimeout_PlainText(t *testing.T) {
+	release := make(chan struct{})
+	defer close(release)
+
+	wrapped := WithTimeout(20*time.Millisecond, func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/time", nil)
+	req.Header.Set("Accept", "text/plain")
+	rw := httptest.NewRecorder()
+
+	wrapped(rw, req)
+
+	assert.Equal(t, http.StatusServiceUnavailable, rw.Code)
+	assert.Equal(t, "text/plain; charset=utf-8", rw.Header().Get("Content-Type"))
+	assert.Equal(t, "Request timed out\n", rw.Body.String())
+}
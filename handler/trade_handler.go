@@ -0,0 +1,378 @@
+package handler
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lucas-moura1/mercadobitcoin-challenge/entity"
+	"github.com/lucas-moura1/mercadobitcoin-challenge/usecase"
+	"go.uber.org/zap"
+)
+
+const (
+	defaultCandlesWindow = 24 * time.Hour
+	defaultVWAPWindow    = time.Hour
+)
+
+type tradeHandler struct {
+	log          *zap.SugaredLogger
+	tradeUseCase usecase.TradeUseCase
+	instruments  *entity.InstrumentConfig
+}
+
+func NewTradeHandler(
+	log *zap.SugaredLogger,
+	tradeUseCase usecase.TradeUseCase,
+	instruments *entity.InstrumentConfig,
+) *tradeHandler {
+	return &tradeHandler{log: log, tradeUseCase: tradeUseCase, instruments: instruments}
+}
+
+type TradeResponse struct {
+	ID             uuid.UUID `json:"id"`
+	InstrumentPair string    `json:"instrument_pair"`
+	BuyerOrderID   uuid.UUID `json:"buyer_order_id"`
+	SellerOrderID  uuid.UUID `json:"seller_order_id"`
+	Price          string    `json:"price"`
+	Quantity       string    `json:"quantity"`
+	ExecutedAt     time.Time `json:"executed_at"`
+}
+
+func (h *tradeHandler) GetTradesByInstrumentPair(w http.ResponseWriter, r *http.Request) {
+	instrumentPair := r.PathValue("instrument_pair")
+
+	limit, err := queryLimit(r)
+	if err != nil {
+		h.log.Errorw("invalid limit parameter", "limit", r.URL.Query().Get("limit"))
+		errorHandler(w, http.StatusBadRequest, "Invalid limit parameter")
+		return
+	}
+
+	trades, err := h.tradeUseCase.GetTradesByInstrumentPair(instrumentPair, limit)
+	if err != nil {
+		h.log.Errorw("failed to get trades",
+			"instrument_pair", instrumentPair,
+			"error", err,
+		)
+		domainErrorHandler(w, err, http.StatusInternalServerError)
+		return
+	}
+
+	h.writeTrades(w, r, trades)
+}
+
+func (h *tradeHandler) GetAccountTrades(w http.ResponseWriter, r *http.Request) {
+	accountID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		h.log.Errorw("invalid account id", "error", err)
+		errorHandler(w, http.StatusBadRequest, "Invalid account ID")
+		return
+	}
+
+	limit, err := queryLimit(r)
+	if err != nil {
+		h.log.Errorw("invalid limit parameter", "limit", r.URL.Query().Get("limit"))
+		errorHandler(w, http.StatusBadRequest, "Invalid limit parameter")
+		return
+	}
+
+	trades, err := h.tradeUseCase.GetTradesByAccount(accountID, limit)
+	if err != nil {
+		h.log.Errorw("failed to get account trades", "account_id", accountID, "error", err)
+		errorHandler(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	h.writeTrades(w, r, trades)
+}
+
+func (h *tradeHandler) writeTrades(w http.ResponseWriter, r *http.Request, trades []*entity.Trade) {
+	if acceptsCSV(r) {
+		h.writeTradesCSV(w, trades)
+		return
+	}
+
+	response := make([]TradeResponse, len(trades))
+	for i, trade := range trades {
+		response[i] = TradeResponse{
+			ID:             trade.ID,
+			InstrumentPair: trade.InstrumentPair,
+			BuyerOrderID:   trade.BuyerOrderID,
+			SellerOrderID:  trade.SellerOrderID,
+			Price:          h.instruments.FormatPrice(trade.InstrumentPair, trade.Price),
+			Quantity:       h.instruments.FormatQuantity(trade.InstrumentPair, trade.Quantity),
+			ExecutedAt:     trade.ExecutedAt,
+		}
+	}
+
+	writeList(w, response, "")
+}
+
+// csvFlushEvery bounds how many CSV rows are buffered before they are sent.
+const csvFlushEvery = 100
+
+var tradesCSVHeader = []string{"executed_at", "instrument_pair", "buyer_order_id", "seller_order_id", "price", "quantity"}
+
+// writeTradesCSV streams trades as CSV rows, flushing to the client as it
+// goes instead of rendering the whole body first.
+func (h *tradeHandler) writeTradesCSV(w http.ResponseWriter, trades []*entity.Trade) {
+	w.Header().Set("Content-Type", csvContentType)
+
+	writer := csv.NewWriter(w)
+	flusher, _ := w.(http.Flusher)
+	flush := func() {
+		writer.Flush()
+		if flusher != nil {
+			flusher.Flush()
+		}
+	}
+
+	writer.Write(tradesCSVHeader)
+	for i, trade := range trades {
+		writer.Write([]string{
+			trade.ExecutedAt.UTC().Format(time.RFC3339Nano),
+			trade.InstrumentPair,
+			trade.BuyerOrderID.String(),
+			trade.SellerOrderID.String(),
+			h.instruments.FormatPrice(trade.InstrumentPair, trade.Price),
+			h.instruments.FormatQuantity(trade.InstrumentPair, trade.Quantity),
+		})
+		if (i+1)%csvFlushEvery == 0 {
+			flush()
+		}
+	}
+	flush()
+
+	if err := writer.Error(); err != nil {
+		h.log.Errorw("failed to write trades csv", "error", err)
+	}
+}
+
+type Candle struct {
+	OpenTime time.Time `json:"open_time"`
+	Open     string    `json:"open"`
+	High     string    `json:"high"`
+	Low      string    `json:"low"`
+	Close    string    `json:"close"`
+	Volume   string    `json:"volume"`
+}
+
+func (h *tradeHandler) GetCandles(w http.ResponseWriter, r *http.Request) {
+	instrumentPair := r.PathValue("instrument_pair")
+	query := r.URL.Query()
+
+	interval := query.Get("interval")
+	if interval == "" {
+		interval = "1m"
+	}
+
+	to := time.Now().UTC()
+	if v := query.Get("to"); v != "" {
+		parsed, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			h.log.Errorw("invalid to parameter", "to", v, "error", err)
+			errorHandler(w, http.StatusBadRequest, "Invalid to parameter")
+			return
+		}
+		to = parsed
+	}
+
+	from := to.Add(-defaultCandlesWindow)
+	if v := query.Get("from"); v != "" {
+		parsed, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			h.log.Errorw("invalid from parameter", "from", v, "error", err)
+			errorHandler(w, http.StatusBadRequest, "Invalid from parameter")
+			return
+		}
+		from = parsed
+	}
+
+	candles, err := h.tradeUseCase.GetCandles(instrumentPair, interval, from, to)
+	if err != nil {
+		h.log.Errorw("failed to get candles",
+			"instrument_pair", instrumentPair,
+			"interval", interval,
+			"error", err,
+		)
+		domainErrorHandler(w, err, http.StatusInternalServerError)
+		return
+	}
+
+	response := make([]Candle, len(candles))
+	for i, c := range candles {
+		response[i] = Candle{
+			OpenTime: c.OpenTime,
+			Open:     h.instruments.FormatPrice(instrumentPair, c.Open),
+			High:     h.instruments.FormatPrice(instrumentPair, c.High),
+			Low:      h.instruments.FormatPrice(instrumentPair, c.Low),
+			Close:    h.instruments.FormatPrice(instrumentPair, c.Close),
+			Volume:   h.instruments.FormatQuantity(instrumentPair, c.Volume),
+		}
+	}
+
+	writeList(w, response, "")
+}
+
+type VWAPResponse struct {
+	InstrumentPair string    `json:"instrument_pair"`
+	From           time.Time `json:"from"`
+	To             time.Time `json:"to"`
+	// VWAP is null when no trades executed in the window.
+	VWAP *string `json:"vwap"`
+}
+
+func (h *tradeHandler) GetVWAP(w http.ResponseWriter, r *http.Request) {
+	instrumentPair := r.PathValue("instrument_pair")
+
+	window := defaultVWAPWindow
+	if v := r.URL.Query().Get("window"); v != "" {
+		parsed, err := time.ParseDuration(v)
+		if err != nil {
+			h.log.Errorw("invalid window parameter", "window", v, "error", err)
+			errorHandler(w, http.StatusBadRequest, "Invalid window parameter")
+			return
+		}
+		window = parsed
+	}
+
+	vwap, err := h.tradeUseCase.GetVWAP(instrumentPair, window)
+	if err != nil {
+		h.log.Errorw("failed to get vwap",
+			"instrument_pair", instrumentPair,
+			"window", window,
+			"error", err,
+		)
+		domainErrorHandler(w, err, http.StatusInternalServerError)
+		return
+	}
+
+	response := VWAPResponse{
+		InstrumentPair: instrumentPair,
+		From:           vwap.From,
+		To:             vwap.To,
+	}
+	if vwap.Price != nil {
+		price := h.instruments.FormatPrice(instrumentPair, *vwap.Price)
+		response.VWAP = &price
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+type PnLResponse struct {
+	AccountID        uuid.UUID `json:"account_id"`
+	InstrumentPair   string    `json:"instrument_pair"`
+	Method           string    `json:"method"`
+	TotalBought      string    `json:"total_bought"`
+	TotalSold        string    `json:"total_sold"`
+	AverageBuyPrice  string    `json:"average_buy_price"`
+	AverageSellPrice string    `json:"average_sell_price"`
+	RealizedPnL      string    `json:"realized_pnl"`
+}
+
+func (h *tradeHandler) GetPnL(w http.ResponseWriter, r *http.Request) {
+	accountID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		h.log.Errorw("invalid account id", "error", err)
+		errorHandler(w, http.StatusBadRequest, "Invalid account ID")
+		return
+	}
+
+	instrumentPair := r.URL.Query().Get("pair")
+	method := usecase.CostBasisMethod(strings.ToLower(r.URL.Query().Get("method")))
+
+	pnl, err := h.tradeUseCase.GetPnL(accountID, instrumentPair, method)
+	if err != nil {
+		h.log.Errorw("failed to get pnl",
+			"account_id", accountID,
+			"instrument_pair", instrumentPair,
+			"method", method,
+			"error", err,
+		)
+		domainErrorHandler(w, err, http.StatusInternalServerError)
+		return
+	}
+
+	_, quote, _ := entity.SplitInstrumentPair(pnl.InstrumentPair)
+	response := PnLResponse{
+		AccountID:        pnl.AccountID,
+		InstrumentPair:   pnl.InstrumentPair,
+		Method:           string(pnl.Method),
+		TotalBought:      h.instruments.FormatQuantity(pnl.InstrumentPair, pnl.TotalBought),
+		TotalSold:        h.instruments.FormatQuantity(pnl.InstrumentPair, pnl.TotalSold),
+		AverageBuyPrice:  h.instruments.FormatPrice(pnl.InstrumentPair, pnl.AverageBuyPrice),
+		AverageSellPrice: h.instruments.FormatPrice(pnl.InstrumentPair, pnl.AverageSellPrice),
+		RealizedPnL:      h.instruments.FormatAmount(quote, pnl.RealizedPnL),
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+type TickResponse struct {
+	TradeID    uuid.UUID `json:"trade_id"`
+	Price      string    `json:"price"`
+	ExecutedAt time.Time `json:"executed_at"`
+}
+
+func (h *tradeHandler) GetTicks(w http.ResponseWriter, r *http.Request) {
+	instrumentPair := r.PathValue("instrument_pair")
+
+	limit, err := queryLimit(r)
+	if err != nil {
+		h.log.Errorw("invalid limit parameter", "limit", r.URL.Query().Get("limit"))
+		errorHandler(w, http.StatusBadRequest, "Invalid limit parameter")
+		return
+	}
+
+	var since uuid.UUID
+	if v := r.URL.Query().Get("since"); v != "" {
+		if since, err = uuid.Parse(v); err != nil {
+			h.log.Errorw("invalid since parameter", "since", v)
+			errorHandler(w, http.StatusBadRequest, "Invalid since parameter")
+			return
+		}
+	}
+
+	invert, err := queryInvert(r)
+	if err != nil {
+		h.log.Errorw("invalid invert parameter", "invert", r.URL.Query().Get("invert"))
+		errorHandler(w, http.StatusBadRequest, "Invalid invert parameter")
+		return
+	}
+
+	series, err := h.tradeUseCase.GetTicks(instrumentPair, since, limit)
+	if err != nil {
+		h.log.Errorw("failed to get ticks",
+			"instrument_pair", instrumentPair,
+			"since", since,
+			"error", err,
+		)
+		domainErrorHandler(w, err, http.StatusInternalServerError)
+		return
+	}
+
+	response := make([]TickResponse, len(series.Ticks))
+	for i, tick := range series.Ticks {
+		response[i] = TickResponse{
+			TradeID:    tick.TradeID,
+			Price:      h.instruments.FormatPrice(instrumentPair, tick.Price),
+			ExecutedAt: tick.ExecutedAt,
+		}
+		if invert {
+			response[i].Price = h.instruments.FormatInversePrice(instrumentPair, tick.Price)
+		}
+	}
+
+	var nextCursor string
+	if series.NextCursor != uuid.Nil {
+		nextCursor = series.NextCursor.String()
+	}
+	writeList(w, response, nextCursor)
+}
@@ -0,0 +1,574 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lucas-moura1/mercadobitcoin-challenge/entity"
+	"github.com/lucas-moura1/mercadobitcoin-challenge/usecase"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+	"go.uber.org/zap"
+)
+
+func TestTradeHandler_GetCandles(t *testing.T) {
+	from := time.Date(2025, 1, 1, 10, 0, 0, 0, time.UTC)
+	to := from.Add(time.Hour)
+	query := "?interval=1m&from=" + from.Format(time.RFC3339) + "&to=" + to.Format(time.RFC3339)
+
+	tests := []struct {
+		name       string
+		pair       string
+		query      string
+		setupMock  func(m *usecase.MockTradeUseCase)
+		wantStatus int
+	}{
+		{
+			name:  "success returns 200 and candles",
+			pair:  "BTC_BRL",
+			query: query,
+			setupMock: func(m *usecase.MockTradeUseCase) {
+				m.EXPECT().GetCandles("BTC_BRL", "1m", from, to).Return([]*entity.Candle{
+					{
+						OpenTime: from,
+						Open:     decimal.RequireFromString("100"),
+						High:     decimal.RequireFromString("105"),
+						Low:      decimal.RequireFromString("95"),
+						Close:    decimal.RequireFromString("102"),
+						Volume:   decimal.RequireFromString("2.75"),
+					},
+				}, nil).Times(1)
+			},
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "invalid from returns 400",
+			pair:       "BTC_BRL",
+			query:      "?from=yesterday",
+			setupMock:  func(m *usecase.MockTradeUseCase) {},
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:  "unsupported interval returns 400",
+			pair:  "BTC_BRL",
+			query: "?interval=7m&from=" + from.Format(time.RFC3339) + "&to=" + to.Format(time.RFC3339),
+			setupMock: func(m *usecase.MockTradeUseCase) {
+				m.EXPECT().GetCandles("BTC_BRL", "7m", from, to).Return(nil, entity.ErrInvalidInterval).Times(1)
+			},
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:  "usecase error returns 500",
+			pair:  "BTC_BRL",
+			query: query,
+			setupMock: func(m *usecase.MockTradeUseCase) {
+				m.EXPECT().GetCandles("BTC_BRL", "1m", from, to).Return(nil, assert.AnError).Times(1)
+			},
+			wantStatus: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockUC := usecase.NewMockTradeUseCase(ctrl)
+			h := NewTradeHandler(zap.NewNop().Sugar(), mockUC, nil)
+
+			tt.setupMock(mockUC)
+
+			req := httptest.NewRequest(http.MethodGet, "/orders/{instrument_pair}/candles"+tt.query, nil)
+			req.SetPathValue("instrument_pair", tt.pair)
+			respWriter := httptest.NewRecorder()
+
+			h.GetCandles(respWriter, req)
+
+			assert.Equal(t, tt.wantStatus, respWriter.Code)
+			if respWriter.Code == http.StatusOK {
+				var resp ListResponse[Candle]
+				assert.NoError(t, json.Unmarshal(respWriter.Body.Bytes(), &resp))
+				assert.Equal(t, 1, resp.Pagination.Count)
+				if assert.Len(t, resp.Data, 1) {
+					c := resp.Data[0]
+					assert.True(t, from.Equal(c.OpenTime))
+					assert.Equal(t, "100", c.Open)
+					assert.Equal(t, "105", c.High)
+					assert.Equal(t, "95", c.Low)
+					assert.Equal(t, "102", c.Close)
+					assert.Equal(t, "2.75", c.Volume)
+				}
+			}
+		})
+	}
+}
+
+func TestTradeHandler_GetTradesByInstrumentPair(t *testing.T) {
+	tests := []struct {
+		name       string
+		pair       string
+		query      string
+		setupMock  func(m *usecase.MockTradeUseCase)
+		wantStatus int
+		wantLen    int
+	}{
+		{
+			name:  "success returns trades",
+			pair:  "BTC_BRL",
+			query: "?limit=5",
+			setupMock: func(m *usecase.MockTradeUseCase) {
+				m.EXPECT().GetTradesByInstrumentPair("BTC_BRL", 5).Return([]*entity.Trade{
+					{ID: uuid.New(), InstrumentPair: "BTC_BRL", Price: decimal.RequireFromString("100"), Quantity: decimal.RequireFromString("0.5")},
+				}, nil).Times(1)
+			},
+			wantStatus: http.StatusOK,
+			wantLen:    1,
+		},
+		{
+			name:       "invalid limit returns 400",
+			pair:       "BTC_BRL",
+			query:      "?limit=zero",
+			setupMock:  func(m *usecase.MockTradeUseCase) {},
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:  "invalid pair returns 400",
+			pair:  "BTCBRL",
+			query: "",
+			setupMock: func(m *usecase.MockTradeUseCase) {
+				m.EXPECT().GetTradesByInstrumentPair("BTCBRL", 0).Return(nil, entity.ErrInvalidPairFormat).Times(1)
+			},
+			wantStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockUC := usecase.NewMockTradeUseCase(ctrl)
+			h := NewTradeHandler(zap.NewNop().Sugar(), mockUC, nil)
+
+			tt.setupMock(mockUC)
+
+			req := httptest.NewRequest(http.MethodGet, "/orders/{instrument_pair}/trades"+tt.query, nil)
+			req.SetPathValue("instrument_pair", tt.pair)
+			respWriter := httptest.NewRecorder()
+
+			h.GetTradesByInstrumentPair(respWriter, req)
+
+			assert.Equal(t, tt.wantStatus, respWriter.Code)
+			if respWriter.Code == http.StatusOK {
+				var resp ListResponse[TradeResponse]
+				assert.NoError(t, json.Unmarshal(respWriter.Body.Bytes(), &resp))
+				assert.Equal(t, tt.wantLen, resp.Pagination.Count)
+				if assert.Len(t, resp.Data, tt.wantLen) {
+					assert.Equal(t, "100", resp.Data[0].Price)
+					assert.Equal(t, "0.5", resp.Data[0].Quantity)
+				}
+			}
+		})
+	}
+}
+
+func TestTradeHandler_GetTradesByInstrumentPair_CSV(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	buyerID, sellerID := uuid.New(), uuid.New()
+	executedAt := time.Date(2025, 1, 1, 10, 0, 0, 0, time.UTC)
+
+	mockUC := usecase.NewMockTradeUseCase(ctrl)
+	mockUC.EXPECT().GetTradesByInstrumentPair("BTC_BRL", 0).Return([]*entity.Trade{
+		{
+			ID:             uuid.New(),
+			InstrumentPair: "BTC_BRL",
+			BuyerOrderID:   buyerID,
+			SellerOrderID:  sellerID,
+			Price:          decimal.RequireFromString("100"),
+			Quantity:       decimal.RequireFromString("0.5"),
+			ExecutedAt:     executedAt,
+		},
+	}, nil).Times(1)
+
+	h := NewTradeHandler(zap.NewNop().Sugar(), mockUC, entity.NewInstrumentConfig(
+		entity.Asset{Symbol: "BTC", Scale: 8},
+		entity.Asset{Symbol: "BRL", Scale: 2},
+	))
+
+	req := httptest.NewRequest(http.MethodGet, "/orders/{instrument_pair}/trades", nil)
+	req.SetPathValue("instrument_pair", "BTC_BRL")
+	req.Header.Set("Accept", "text/csv;q=0.9, application/json;q=0.5")
+	respWriter := httptest.NewRecorder()
+
+	h.GetTradesByInstrumentPair(respWriter, req)
+
+	assert.Equal(t, http.StatusOK, respWriter.Code)
+	assert.Equal(t, "text/csv", respWriter.Header().Get("Content-Type"))
+	assert.Equal(t,
+		"executed_at,instrument_pair,buyer_order_id,seller_order_id,price,quantity\n"+
+			"2025-01-01T10:00:00Z,BTC_BRL,"+buyerID.String()+","+sellerID.String()+",100.00,0.50000000\n",
+		respWriter.Body.String())
+}
+
+func TestTradeHandler_GetAccountTrades(t *testing.T) {
+	accountID := uuid.New()
+
+	tests := []struct {
+		name       string
+		pathValue  string
+		setupMock  func(m *usecase.MockTradeUseCase)
+		wantStatus int
+	}{
+		{
+			name:      "success returns 200",
+			pathValue: accountID.String(),
+			setupMock: func(m *usecase.MockTradeUseCase) {
+				m.EXPECT().GetTradesByAccount(accountID, 0).Return(nil, nil).Times(1)
+			},
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "invalid UUID returns 400",
+			pathValue:  "nope",
+			setupMock:  func(m *usecase.MockTradeUseCase) {},
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:      "usecase error returns 500",
+			pathValue: accountID.String(),
+			setupMock: func(m *usecase.MockTradeUseCase) {
+				m.EXPECT().GetTradesByAccount(accountID, 0).Return(nil, assert.AnError).Times(1)
+			},
+			wantStatus: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockUC := usecase.NewMockTradeUseCase(ctrl)
+			h := NewTradeHandler(zap.NewNop().Sugar(), mockUC, nil)
+
+			tt.setupMock(mockUC)
+
+			req := httptest.NewRequest(http.MethodGet, "/accounts/{id}/trades", nil)
+			req.SetPathValue("id", tt.pathValue)
+			respWriter := httptest.NewRecorder()
+
+			h.GetAccountTrades(respWriter, req)
+
+			assert.Equal(t, tt.wantStatus, respWriter.Code)
+			if respWriter.Code == http.StatusOK {
+				var resp ListResponse[TradeResponse]
+				assert.NoError(t, json.Unmarshal(respWriter.Body.Bytes(), &resp))
+				assert.NotNil(t, resp.Data)
+			}
+		})
+	}
+}
+
+func TestTradeHandler_GetVWAP(t *testing.T) {
+	to := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	price := decimal.RequireFromString("106.7777777777777778")
+	instruments := entity.NewInstrumentConfig(
+		entity.Asset{Symbol: "BTC", Scale: 8},
+		entity.Asset{Symbol: "BRL", Scale: 2},
+	)
+
+	tests := []struct {
+		name       string
+		query      string
+		setupMock  func(m *usecase.MockTradeUseCase)
+		wantStatus int
+		wantVWAP   *string
+	}{
+		{
+			name: "default window returns formatted vwap",
+			setupMock: func(m *usecase.MockTradeUseCase) {
+				m.EXPECT().GetVWAP("BTC_BRL", time.Hour).
+					Return(&usecase.VWAP{InstrumentPair: "BTC_BRL", From: to.Add(-time.Hour), To: to, Price: &price}, nil).Times(1)
+			},
+			wantStatus: http.StatusOK,
+			wantVWAP:   func() *string { s := "106.78"; return &s }(),
+		},
+		{
+			name:  "no trades returns null vwap",
+			query: "?window=15m",
+			setupMock: func(m *usecase.MockTradeUseCase) {
+				m.EXPECT().GetVWAP("BTC_BRL", 15*time.Minute).
+					Return(&usecase.VWAP{InstrumentPair: "BTC_BRL", From: to.Add(-15 * time.Minute), To: to}, nil).Times(1)
+			},
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "unparseable window returns 400",
+			query:      "?window=an-hour",
+			setupMock:  func(m *usecase.MockTradeUseCase) {},
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:  "rejected window returns 400",
+			query: "?window=-1h",
+			setupMock: func(m *usecase.MockTradeUseCase) {
+				m.EXPECT().GetVWAP("BTC_BRL", -time.Hour).Return(nil, entity.ErrInvalidWindow).Times(1)
+			},
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name: "usecase error returns 500",
+			setupMock: func(m *usecase.MockTradeUseCase) {
+				m.EXPECT().GetVWAP("BTC_BRL", time.Hour).Return(nil, assert.AnError).Times(1)
+			},
+			wantStatus: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockUC := usecase.NewMockTradeUseCase(ctrl)
+			h := NewTradeHandler(zap.NewNop().Sugar(), mockUC, instruments)
+			tt.setupMock(mockUC)
+
+			req := httptest.NewRequest(http.MethodGet, "/orders/BTC_BRL/vwap"+tt.query, nil)
+			req.SetPathValue("instrument_pair", "BTC_BRL")
+			respWriter := httptest.NewRecorder()
+
+			h.GetVWAP(respWriter, req)
+
+			assert.Equal(t, tt.wantStatus, respWriter.Code)
+			if respWriter.Code == http.StatusOK {
+				var resp VWAPResponse
+				assert.NoError(t, json.Unmarshal(respWriter.Body.Bytes(), &resp))
+				assert.Equal(t, tt.wantVWAP, resp.VWAP)
+				assert.Equal(t, to, resp.To)
+			}
+		})
+	}
+}
+
+func TestTradeHandler_GetPnL(t *testing.T) {
+	accountID := uuid.New()
+	instruments := entity.NewInstrumentConfig(
+		entity.Asset{Symbol: "BTC", Scale: 8},
+		entity.Asset{Symbol: "BRL", Scale: 2},
+	)
+
+	tests := []struct {
+		name       string
+		id         string
+		query      string
+		setupMock  func(m *usecase.MockTradeUseCase)
+		wantStatus int
+		wantBody   string
+	}{
+		{
+			name:  "success returns formatted summary",
+			id:    accountID.String(),
+			query: "?pair=BTC_BRL&method=FIFO",
+			setupMock: func(m *usecase.MockTradeUseCase) {
+				m.EXPECT().GetPnL(accountID, "BTC_BRL", usecase.CostBasisFIFO).Return(&usecase.PnL{
+					AccountID:        accountID,
+					InstrumentPair:   "BTC_BRL",
+					Method:           usecase.CostBasisFIFO,
+					TotalBought:      decimal.RequireFromString("2"),
+					TotalSold:        decimal.RequireFromString("1.5"),
+					AverageBuyPrice:  decimal.RequireFromString("150"),
+					AverageSellPrice: decimal.RequireFromString("300"),
+					RealizedPnL:      decimal.RequireFromString("250"),
+				}, nil).Times(1)
+			},
+			wantStatus: http.StatusOK,
+			wantBody: `{"account_id":"` + accountID.String() + `","instrument_pair":"BTC_BRL","method":"fifo",
+				"total_bought":"2.00000000","total_sold":"1.50000000","average_buy_price":"150.00",
+				"average_sell_price":"300.00","realized_pnl":"250.00"}`,
+		},
+		{
+			name:       "invalid account id returns 400",
+			id:         "not-a-uuid",
+			query:      "?pair=BTC_BRL",
+			setupMock:  func(m *usecase.MockTradeUseCase) {},
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:  "unknown method returns 400",
+			id:    accountID.String(),
+			query: "?pair=BTC_BRL&method=lifo",
+			setupMock: func(m *usecase.MockTradeUseCase) {
+				m.EXPECT().GetPnL(accountID, "BTC_BRL", usecase.CostBasisMethod("lifo")).Return(nil, entity.ErrInvalidCostBasis).Times(1)
+			},
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name: "missing pair returns 400",
+			id:   accountID.String(),
+			setupMock: func(m *usecase.MockTradeUseCase) {
+				m.EXPECT().GetPnL(accountID, "", usecase.CostBasisMethod("")).Return(nil, entity.ErrInvalidPairFormat).Times(1)
+			},
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:  "usecase error returns 500",
+			id:    accountID.String(),
+			query: "?pair=BTC_BRL",
+			setupMock: func(m *usecase.MockTradeUseCase) {
+				m.EXPECT().GetPnL(accountID, "BTC_BRL", usecase.CostBasisMethod("")).Return(nil, assert.AnError).Times(1)
+			},
+			wantStatus: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockUC := usecase.NewMockTradeUseCase(ctrl)
+			tt.setupMock(mockUC)
+			h := NewTradeHandler(zap.NewNop().Sugar(), mockUC, instruments)
+
+			req := httptest.NewRequest(http.MethodGet, "/accounts/"+tt.id+"/pnl"+tt.query, nil)
+			req.SetPathValue("id", tt.id)
+			respWriter := httptest.NewRecorder()
+
+			h.GetPnL(respWriter, req)
+
+			assert.Equal(t, tt.wantStatus, respWriter.Code)
+			if tt.wantBody != "" {
+				assert.JSONEq(t, tt.wantBody, respWriter.Body.String())
+			}
+		})
+	}
+}
+
+func TestTradeHandler_GetTicks(t *testing.T) {
+	tradeID := uuid.New()
+	executedAt := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	instruments := entity.NewInstrumentConfig(
+		entity.Asset{Symbol: "BTC", Scale: 8},
+		entity.Asset{Symbol: "BRL", Scale: 2},
+	)
+
+	tests := []struct {
+		name       string
+		query      string
+		setupMock  func(m *usecase.MockTradeUseCase)
+		wantStatus int
+		wantCount  int
+		wantPrice  string
+		wantCursor *string
+	}{
+		{
+			name:  "ticks are formatted with the next cursor",
+			query: "?limit=10",
+			setupMock: func(m *usecase.MockTradeUseCase) {
+				m.EXPECT().GetTicks("BTC_BRL", uuid.Nil, 10).Return(&usecase.TickSeries{
+					Ticks:      []*usecase.Tick{{TradeID: tradeID, Price: decimal.RequireFromString("101.5"), ExecutedAt: executedAt}},
+					NextCursor: tradeID,
+				}, nil).Times(1)
+			},
+			wantStatus: http.StatusOK,
+			wantCount:  1,
+			wantPrice:  "101.50",
+			wantCursor: func() *string { s := tradeID.String(); return &s }(),
+		},
+		{
+			name:  "invert reports the reciprocal price at the base scale",
+			query: "?invert=true",
+			setupMock: func(m *usecase.MockTradeUseCase) {
+				m.EXPECT().GetTicks("BTC_BRL", uuid.Nil, 0).Return(&usecase.TickSeries{
+					Ticks: []*usecase.Tick{{TradeID: tradeID, Price: decimal.RequireFromString("101.5"), ExecutedAt: executedAt}},
+				}, nil).Times(1)
+			},
+			wantStatus: http.StatusOK,
+			wantCount:  1,
+			wantPrice:  "0.00985222",
+		},
+		{
+			name:       "unparseable invert returns 400",
+			query:      "?invert=yes",
+			setupMock:  func(m *usecase.MockTradeUseCase) {},
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:  "no trades since the cursor returns an empty series",
+			query: "?since=" + tradeID.String(),
+			setupMock: func(m *usecase.MockTradeUseCase) {
+				m.EXPECT().GetTicks("BTC_BRL", tradeID, 0).
+					Return(&usecase.TickSeries{Ticks: []*usecase.Tick{}, NextCursor: tradeID}, nil).Times(1)
+			},
+			wantStatus: http.StatusOK,
+			wantCursor: func() *string { s := tradeID.String(); return &s }(),
+		},
+		{
+			name:       "unparseable since returns 400",
+			query:      "?since=7",
+			setupMock:  func(m *usecase.MockTradeUseCase) {},
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:  "unknown cursor returns 400",
+			query: "?since=" + tradeID.String(),
+			setupMock: func(m *usecase.MockTradeUseCase) {
+				m.EXPECT().GetTicks("BTC_BRL", tradeID, 0).Return(nil, entity.ErrInvalidCursor).Times(1)
+			},
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name: "usecase error returns 500",
+			setupMock: func(m *usecase.MockTradeUseCase) {
+				m.EXPECT().GetTicks("BTC_BRL", uuid.Nil, 0).Return(nil, assert.AnError).Times(1)
+			},
+			wantStatus: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockUC := usecase.NewMockTradeUseCase(ctrl)
+			h := NewTradeHandler(zap.NewNop().Sugar(), mockUC, instruments)
+			tt.setupMock(mockUC)
+
+			req := httptest.NewRequest(http.MethodGet, "/orders/BTC_BRL/ticks"+tt.query, nil)
+			req.SetPathValue("instrument_pair", "BTC_BRL")
+			respWriter := httptest.NewRecorder()
+
+			h.GetTicks(respWriter, req)
+
+			assert.Equal(t, tt.wantStatus, respWriter.Code)
+			if respWriter.Code != http.StatusOK {
+				return
+			}
+
+			var resp ListResponse[TickResponse]
+			assert.NoError(t, json.Unmarshal(respWriter.Body.Bytes(), &resp))
+			assert.Len(t, resp.Data, tt.wantCount)
+			assert.Equal(t, tt.wantCursor, resp.Pagination.NextCursor)
+			if tt.wantCount > 0 {
+				assert.Equal(t, tradeID, resp.Data[0].TradeID)
+				assert.Equal(t, tt.wantPrice, resp.Data[0].Price)
+				assert.Equal(t, executedAt, resp.Data[0].ExecutedAt)
+			}
+		})
+	}
+}
@@ -1,6 +1,9 @@
 package repository
 
 import (
+	"errors"
+
+	"github.com/google/uuid"
 	"github.com/lucas-moura1/mercadobitcoin-challenge/entity"
 	"go.uber.org/zap"
 	"gorm.io/gorm"
@@ -25,3 +28,45 @@ func (r *accountRepository) Create(account *entity.Account) error {
 
 	return nil
 }
+
+func (r *accountRepository) GetByID(tx *gorm.DB, id uuid.UUID) (*entity.Account, error) {
+	db := r.db
+	if tx != nil {
+		db = tx
+	}
+
+	account := new(entity.Account)
+	err := db.Where("id = ? AND deleted_at IS NULL", id).First(account).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			r.log.Warnw("account not found", "id", id)
+			return nil, ErrNotFound
+		}
+		r.log.Errorw("failed to get account", "id", id, "error", err)
+		return nil, err
+	}
+
+	return account, nil
+}
+
+func (r *accountRepository) SoftDelete(tx *gorm.DB, id uuid.UUID) error {
+	r.log.Debugw("soft deleting account", "id", id)
+
+	db := r.db
+	if tx != nil {
+		db = tx
+	}
+
+	resp := db.Model(&entity.Account{}).
+		Where("id = ? AND deleted_at IS NULL", id).
+		Update("deleted_at", entity.NowUTC())
+	if resp.Error != nil {
+		r.log.Errorw("failed to soft delete account", "id", id, "error", resp.Error)
+		return resp.Error
+	}
+	if resp.RowsAffected == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
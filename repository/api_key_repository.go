@@ -0,0 +1,44 @@
+package repository
+
+import (
+	"errors"
+
+	"github.com/lucas-moura1/mercadobitcoin-challenge/entity"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+type apiKeyRepository struct {
+	log *zap.SugaredLogger
+	db  *gorm.DB
+}
+
+func NewApiKeyRepository(log *zap.SugaredLogger, db *gorm.DB) ApiKeyRepository {
+	return &apiKeyRepository{log: log, db: db}
+}
+
+func (r *apiKeyRepository) Create(apiKey *entity.ApiKey) error {
+	r.log.Debugw("creating api key", "account_id", apiKey.AccountID)
+
+	if err := r.db.Create(apiKey).Error; err != nil {
+		r.log.Errorw("failed to create api key", "error", err)
+		return err
+	}
+
+	return nil
+}
+
+func (r *apiKeyRepository) GetByKey(key string) (*entity.ApiKey, error) {
+	apiKey := new(entity.ApiKey)
+	err := r.db.Where("key = ? AND deleted_at IS NULL", key).First(apiKey).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			r.log.Warnw("api key not found")
+			return nil, ErrNotFound
+		}
+		r.log.Errorw("failed to get api key", "error", err)
+		return nil, err
+	}
+
+	return apiKey, nil
+}
@@ -0,0 +1,8 @@
+package repository
+
+import "errors"
+
+// ErrNotFound is returned by single-record lookups (GetByID,
+// GetByAccountAndAsset) when no row matches. List queries return an empty
+// slice instead.
+var ErrNotFound = errors.New("record not found")
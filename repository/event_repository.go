@@ -0,0 +1,77 @@
+package repository
+
+import (
+	"github.com/lucas-moura1/mercadobitcoin-challenge/entity"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// eventAppendLockKey is the Postgres advisory lock held by every transaction
+// that appends events. Holding it until commit makes sequences visible in the
+// order they were assigned, so a reader that has seen sequence N never later
+// finds a committed event below N.
+const eventAppendLockKey = 7_401_374
+
+// LockEventAppends takes the event append lock for the rest of tx. A
+// transaction that appends events must call it after its last row lock, right
+// before inserting them and committing: the holder then never waits on a row
+// lock, so it cannot deadlock with a writer that is waiting for the lock.
+// SQLite already allows a single writer at a time, so it is a no-op there.
+func LockEventAppends(tx *gorm.DB) error {
+	if tx.Dialector.Name() != "postgres" {
+		return nil
+	}
+	return tx.Exec("SELECT pg_advisory_xact_lock(?)", eventAppendLockKey).Error
+}
+
+type eventRepository struct {
+	log *zap.SugaredLogger
+	db  *gorm.DB
+}
+
+func NewEventRepository(log *zap.SugaredLogger, db *gorm.DB) EventRepository {
+	return &eventRepository{log: log, db: db}
+}
+
+func (r *eventRepository) Append(tx *gorm.DB, event *entity.Event) error {
+	r.log.Debugw("appending event",
+		"event_type", event.EventType,
+		"aggregate_id", event.AggregateID,
+	)
+
+	var err error
+	if tx != nil {
+		err = tx.Create(event).Error
+	} else {
+		err = r.db.Transaction(func(tx *gorm.DB) error {
+			if err := LockEventAppends(tx); err != nil {
+				return err
+			}
+			return tx.Create(event).Error
+		})
+	}
+	if err != nil {
+		r.log.Errorw("failed to append event",
+			"event_type", event.EventType,
+			"error", err,
+		)
+		return err
+	}
+
+	return nil
+}
+
+func (r *eventRepository) GetSince(sequence int64, limit int) ([]*entity.Event, error) {
+	var events []*entity.Event
+
+	err := r.db.Where("sequence > ?", sequence).
+		Order("sequence ASC").
+		Limit(limit).
+		Find(&events).Error
+	if err != nil {
+		r.log.Errorw("failed to get events", "since", sequence, "error", err)
+		return nil, err
+	}
+
+	return events, nil
+}
@@ -1,6 +1,8 @@
 package repository
 
 import (
+	"time"
+
 	"github.com/google/uuid"
 	"github.com/lucas-moura1/mercadobitcoin-challenge/entity"
 	"github.com/shopspring/decimal"
@@ -9,22 +11,50 @@ import (
 
 type AccountRepository interface {
 	Create(account *entity.Account) error
+	GetByID(tx *gorm.DB, id uuid.UUID) (*entity.Account, error)
+	SoftDelete(tx *gorm.DB, id uuid.UUID) error
+}
+
+type ApiKeyRepository interface {
+	Create(apiKey *entity.ApiKey) error
+	GetByKey(key string) (*entity.ApiKey, error)
 }
 
 type WalletRepository interface {
 	Create(tx *gorm.DB, wallet *entity.Wallet) error
+	CreateIfNotExists(tx *gorm.DB, wallet *entity.Wallet) (bool, error)
 	GetByAccountID(accountID uuid.UUID) ([]*entity.Wallet, error)
+	GetByAccountIDForUpdate(tx *gorm.DB, accountID uuid.UUID) ([]*entity.Wallet, error)
+	GetByAccountIDPaged(accountID uuid.UUID, afterAsset string, limit int) ([]*entity.Wallet, error)
 	GetByAccountAndAsset(tx *gorm.DB, accountID uuid.UUID, assetSymbol string) (*entity.Wallet, error)
+	GetByAccountAndAssetForUpdate(tx *gorm.DB, accountID uuid.UUID, assetSymbol string) (*entity.Wallet, error)
 	AddToBalance(tx *gorm.DB, accountID uuid.UUID, assetSymbol string, amount decimal.Decimal) error
 	SubtractFromBalance(tx *gorm.DB, accountID uuid.UUID, assetSymbol string, amount decimal.Decimal) error
+	SoftDeleteByAccountID(tx *gorm.DB, accountID uuid.UUID) error
+	Snapshot(takenAt time.Time) (int, error)
+	GetSnapshotsAt(accountID uuid.UUID, at time.Time) ([]*entity.WalletSnapshot, error)
 }
 
 type OrderRepository interface {
 	Create(tx *gorm.DB, order *entity.Order) error
+	CreateInBatches(tx *gorm.DB, orders []*entity.Order, batchSize int) error
 	GetByID(id uuid.UUID, status ...string) (*entity.Order, error)
-	GetOpenOrdersByInstrumentPair(instrumentPair string) ([]*entity.Order, error)
-	UpdateStatus(id uuid.UUID, status string) error
-	UpdateRemainingAndStatus(tx *gorm.DB, id uuid.UUID, quantity decimal.Decimal, status string) error
+	GetOpenOrdersByInstrumentPair(instrumentPair string, now time.Time, createdBefore time.Time) ([]*entity.Order, error)
+	CountOpenByAccountID(tx *gorm.DB, accountID uuid.UUID) (int64, error)
+	CountOpenByAccountPerPair(accountID uuid.UUID) (map[string]int64, error)
+	GetExpired(now time.Time, createdBefore time.Time, limit int) ([]*entity.Order, error)
+	CountByStatus(instrumentPair string, from time.Time, to time.Time) (map[string]int64, error)
+	GetByAccountPairSide(tx *gorm.DB, accountID uuid.UUID, instrumentPair string, orderType string, status ...string) ([]*entity.Order, error)
+	GetByAccountAndStatus(accountID uuid.UUID, before uuid.UUID, limit int, status ...string) ([]*entity.Order, error)
+	GetQueueAhead(order *entity.Order) (int64, decimal.Decimal, error)
+	GetOpenBuyNotional(tx *gorm.DB, accountID uuid.UUID, quoteAsset string) (decimal.Decimal, error)
+	GetReservedAmount(tx *gorm.DB, accountID uuid.UUID, asset string) (decimal.Decimal, error)
+	GetReservedAmounts(accountID uuid.UUID) (map[string]decimal.Decimal, error)
+	SnapshotSpreads(takenAt time.Time, createdBefore time.Time) (int, error)
+	GetSpreadSnapshots(instrumentPair string, from time.Time, to time.Time) ([]*entity.SpreadSnapshot, error)
+	UpdateStatus(tx *gorm.DB, id uuid.UUID, status string) error
+	UpdateStatusFrom(tx *gorm.DB, id uuid.UUID, fromStatus string, status string) (bool, error)
+	UpdateRemainingAndStatus(tx *gorm.DB, id uuid.UUID, quantity decimal.Decimal, reserved decimal.Decimal, status string) error
 	GetMatchingOrders(
 		tx *gorm.DB,
 		accountID uuid.UUID,
@@ -32,11 +62,31 @@ type OrderRepository interface {
 		orderType string,
 		price decimal.Decimal,
 		isBuyOrder bool,
+		limit int,
+		now time.Time,
+		createdBefore time.Time,
 	) ([]*entity.Order, error)
 }
 
+type OrderRejectionRepository interface {
+	Create(rejection *entity.OrderRejection) error
+	GetByAccountID(accountID uuid.UUID, limit int) ([]*entity.OrderRejection, error)
+}
+
 type TradeRepository interface {
 	Create(tx *gorm.DB, trade *entity.Trade) error
+	GetByAccountID(accountID uuid.UUID, limit int) ([]*entity.Trade, error)
+	GetByAccountPairSide(accountID uuid.UUID, instrumentPair string, orderType string) ([]*entity.Trade, error)
+	GetLatestByAccountPerPair(accountID uuid.UUID) ([]*entity.Trade, error)
+	GetByID(id uuid.UUID) (*entity.Trade, error)
+	GetByOrderID(orderID uuid.UUID) ([]*entity.Trade, error)
+	GetByInstrumentPairSince(instrumentPair string, since *entity.Trade, limit int) ([]*entity.Trade, error)
+	GetByInstrumentPair(instrumentPair string, limit int) ([]*entity.Trade, error)
+	GetCandles(instrumentPair string, interval time.Duration, from time.Time, to time.Time) ([]*entity.Candle, error)
+	VWAP(instrumentPair string, from time.Time, to time.Time) (*decimal.Decimal, error)
 }
 
-
+type EventRepository interface {
+	Append(tx *gorm.DB, event *entity.Event) error
+	GetSince(sequence int64, limit int) ([]*entity.Event, error)
+}
@@ -11,6 +11,7 @@ package repository
 
 import (
 	reflect "reflect"
+	time "time"
 
 	uuid "github.com/google/uuid"
 	entity "github.com/lucas-moura1/mercadobitcoin-challenge/entity"
@@ -57,6 +58,88 @@ func (mr *MockAccountRepositoryMockRecorder) Create(account any) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockAccountRepository)(nil).Create), account)
 }
 
+// GetByID mocks base method.
+func (m *MockAccountRepository) GetByID(tx *gorm.DB, id uuid.UUID) (*entity.Account, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetByID", tx, id)
+	ret0, _ := ret[0].(*entity.Account)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetByID indicates an expected call of GetByID.
+func (mr *MockAccountRepositoryMockRecorder) GetByID(tx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByID", reflect.TypeOf((*MockAccountRepository)(nil).GetByID), tx, id)
+}
+
+// SoftDelete mocks base method.
+func (m *MockAccountRepository) SoftDelete(tx *gorm.DB, id uuid.UUID) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SoftDelete", tx, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SoftDelete indicates an expected call of SoftDelete.
+func (mr *MockAccountRepositoryMockRecorder) SoftDelete(tx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SoftDelete", reflect.TypeOf((*MockAccountRepository)(nil).SoftDelete), tx, id)
+}
+
+// MockApiKeyRepository is a mock of ApiKeyRepository interface.
+type MockApiKeyRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockApiKeyRepositoryMockRecorder
+	isgomock struct{}
+}
+
+// MockApiKeyRepositoryMockRecorder is the mock recorder for MockApiKeyRepository.
+type MockApiKeyRepositoryMockRecorder struct {
+	mock *MockApiKeyRepository
+}
+
+// NewMockApiKeyRepository creates a new mock instance.
+func NewMockApiKeyRepository(ctrl *gomock.Controller) *MockApiKeyRepository {
+	mock := &MockApiKeyRepository{ctrl: ctrl}
+	mock.recorder = &MockApiKeyRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockApiKeyRepository) EXPECT() *MockApiKeyRepositoryMockRecorder {
+	return m.recorder
+}
+
+// Create mocks base method.
+func (m *MockApiKeyRepository) Create(apiKey *entity.ApiKey) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Create", apiKey)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Create indicates an expected call of Create.
+func (mr *MockApiKeyRepositoryMockRecorder) Create(apiKey any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockApiKeyRepository)(nil).Create), apiKey)
+}
+
+// GetByKey mocks base method.
+func (m *MockApiKeyRepository) GetByKey(key string) (*entity.ApiKey, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetByKey", key)
+	ret0, _ := ret[0].(*entity.ApiKey)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetByKey indicates an expected call of GetByKey.
+func (mr *MockApiKeyRepositoryMockRecorder) GetByKey(key any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByKey", reflect.TypeOf((*MockApiKeyRepository)(nil).GetByKey), key)
+}
+
 // MockWalletRepository is a mock of WalletRepository interface.
 type MockWalletRepository struct {
 	ctrl     *gomock.Controller
@@ -109,6 +192,21 @@ func (mr *MockWalletRepositoryMockRecorder) Create(tx, wallet any) *gomock.Call
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockWalletRepository)(nil).Create), tx, wallet)
 }
 
+// CreateIfNotExists mocks base method.
+func (m *MockWalletRepository) CreateIfNotExists(tx *gorm.DB, wallet *entity.Wallet) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateIfNotExists", tx, wallet)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateIfNotExists indicates an expected call of CreateIfNotExists.
+func (mr *MockWalletRepositoryMockRecorder) CreateIfNotExists(tx, wallet any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateIfNotExists", reflect.TypeOf((*MockWalletRepository)(nil).CreateIfNotExists), tx, wallet)
+}
+
 // GetByAccountAndAsset mocks base method.
 func (m *MockWalletRepository) GetByAccountAndAsset(tx *gorm.DB, accountID uuid.UUID, assetSymbol string) (*entity.Wallet, error) {
 	m.ctrl.T.Helper()
@@ -124,6 +222,21 @@ func (mr *MockWalletRepositoryMockRecorder) GetByAccountAndAsset(tx, accountID,
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByAccountAndAsset", reflect.TypeOf((*MockWalletRepository)(nil).GetByAccountAndAsset), tx, accountID, assetSymbol)
 }
 
+// GetByAccountAndAssetForUpdate mocks base method.
+func (m *MockWalletRepository) GetByAccountAndAssetForUpdate(tx *gorm.DB, accountID uuid.UUID, assetSymbol string) (*entity.Wallet, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetByAccountAndAssetForUpdate", tx, accountID, assetSymbol)
+	ret0, _ := ret[0].(*entity.Wallet)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetByAccountAndAssetForUpdate indicates an expected call of GetByAccountAndAssetForUpdate.
+func (mr *MockWalletRepositoryMockRecorder) GetByAccountAndAssetForUpdate(tx, accountID, assetSymbol any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByAccountAndAssetForUpdate", reflect.TypeOf((*MockWalletRepository)(nil).GetByAccountAndAssetForUpdate), tx, accountID, assetSymbol)
+}
+
 // GetByAccountID mocks base method.
 func (m *MockWalletRepository) GetByAccountID(accountID uuid.UUID) ([]*entity.Wallet, error) {
 	m.ctrl.T.Helper()
@@ -139,6 +252,80 @@ func (mr *MockWalletRepositoryMockRecorder) GetByAccountID(accountID any) *gomoc
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByAccountID", reflect.TypeOf((*MockWalletRepository)(nil).GetByAccountID), accountID)
 }
 
+// GetByAccountIDForUpdate mocks base method.
+func (m *MockWalletRepository) GetByAccountIDForUpdate(tx *gorm.DB, accountID uuid.UUID) ([]*entity.Wallet, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetByAccountIDForUpdate", tx, accountID)
+	ret0, _ := ret[0].([]*entity.Wallet)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetByAccountIDForUpdate indicates an expected call of GetByAccountIDForUpdate.
+func (mr *MockWalletRepositoryMockRecorder) GetByAccountIDForUpdate(tx, accountID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByAccountIDForUpdate", reflect.TypeOf((*MockWalletRepository)(nil).GetByAccountIDForUpdate), tx, accountID)
+}
+
+// GetByAccountIDPaged mocks base method.
+func (m *MockWalletRepository) GetByAccountIDPaged(accountID uuid.UUID, afterAsset string, limit int) ([]*entity.Wallet, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetByAccountIDPaged", accountID, afterAsset, limit)
+	ret0, _ := ret[0].([]*entity.Wallet)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetByAccountIDPaged indicates an expected call of GetByAccountIDPaged.
+func (mr *MockWalletRepositoryMockRecorder) GetByAccountIDPaged(accountID, afterAsset, limit any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByAccountIDPaged", reflect.TypeOf((*MockWalletRepository)(nil).GetByAccountIDPaged), accountID, afterAsset, limit)
+}
+
+// GetSnapshotsAt mocks base method.
+func (m *MockWalletRepository) GetSnapshotsAt(accountID uuid.UUID, at time.Time) ([]*entity.WalletSnapshot, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetSnapshotsAt", accountID, at)
+	ret0, _ := ret[0].([]*entity.WalletSnapshot)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetSnapshotsAt indicates an expected call of GetSnapshotsAt.
+func (mr *MockWalletRepositoryMockRecorder) GetSnapshotsAt(accountID, at any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetSnapshotsAt", reflect.TypeOf((*MockWalletRepository)(nil).GetSnapshotsAt), accountID, at)
+}
+
+// Snapshot mocks base method.
+func (m *MockWalletRepository) Snapshot(takenAt time.Time) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Snapshot", takenAt)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Snapshot indicates an expected call of Snapshot.
+func (mr *MockWalletRepositoryMockRecorder) Snapshot(takenAt any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Snapshot", reflect.TypeOf((*MockWalletRepository)(nil).Snapshot), takenAt)
+}
+
+// SoftDeleteByAccountID mocks base method.
+func (m *MockWalletRepository) SoftDeleteByAccountID(tx *gorm.DB, accountID uuid.UUID) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SoftDeleteByAccountID", tx, accountID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SoftDeleteByAccountID indicates an expected call of SoftDeleteByAccountID.
+func (mr *MockWalletRepositoryMockRecorder) SoftDeleteByAccountID(tx, accountID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SoftDeleteByAccountID", reflect.TypeOf((*MockWalletRepository)(nil).SoftDeleteByAccountID), tx, accountID)
+}
+
 // SubtractFromBalance mocks base method.
 func (m *MockWalletRepository) SubtractFromBalance(tx *gorm.DB, accountID uuid.UUID, assetSymbol string, amount decimal.Decimal) error {
 	m.ctrl.T.Helper()
@@ -177,6 +364,51 @@ func (m *MockOrderRepository) EXPECT() *MockOrderRepositoryMockRecorder {
 	return m.recorder
 }
 
+// CountByStatus mocks base method.
+func (m *MockOrderRepository) CountByStatus(instrumentPair string, from, to time.Time) (map[string]int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CountByStatus", instrumentPair, from, to)
+	ret0, _ := ret[0].(map[string]int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CountByStatus indicates an expected call of CountByStatus.
+func (mr *MockOrderRepositoryMockRecorder) CountByStatus(instrumentPair, from, to any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CountByStatus", reflect.TypeOf((*MockOrderRepository)(nil).CountByStatus), instrumentPair, from, to)
+}
+
+// CountOpenByAccountID mocks base method.
+func (m *MockOrderRepository) CountOpenByAccountID(tx *gorm.DB, accountID uuid.UUID) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CountOpenByAccountID", tx, accountID)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CountOpenByAccountID indicates an expected call of CountOpenByAccountID.
+func (mr *MockOrderRepositoryMockRecorder) CountOpenByAccountID(tx, accountID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CountOpenByAccountID", reflect.TypeOf((*MockOrderRepository)(nil).CountOpenByAccountID), tx, accountID)
+}
+
+// CountOpenByAccountPerPair mocks base method.
+func (m *MockOrderRepository) CountOpenByAccountPerPair(accountID uuid.UUID) (map[string]int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CountOpenByAccountPerPair", accountID)
+	ret0, _ := ret[0].(map[string]int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CountOpenByAccountPerPair indicates an expected call of CountOpenByAccountPerPair.
+func (mr *MockOrderRepositoryMockRecorder) CountOpenByAccountPerPair(accountID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CountOpenByAccountPerPair", reflect.TypeOf((*MockOrderRepository)(nil).CountOpenByAccountPerPair), accountID)
+}
+
 // Create mocks base method.
 func (m *MockOrderRepository) Create(tx *gorm.DB, order *entity.Order) error {
 	m.ctrl.T.Helper()
@@ -191,6 +423,60 @@ func (mr *MockOrderRepositoryMockRecorder) Create(tx, order any) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockOrderRepository)(nil).Create), tx, order)
 }
 
+// CreateInBatches mocks base method.
+func (m *MockOrderRepository) CreateInBatches(tx *gorm.DB, orders []*entity.Order, batchSize int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateInBatches", tx, orders, batchSize)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateInBatches indicates an expected call of CreateInBatches.
+func (mr *MockOrderRepositoryMockRecorder) CreateInBatches(tx, orders, batchSize any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateInBatches", reflect.TypeOf((*MockOrderRepository)(nil).CreateInBatches), tx, orders, batchSize)
+}
+
+// GetByAccountAndStatus mocks base method.
+func (m *MockOrderRepository) GetByAccountAndStatus(accountID, before uuid.UUID, limit int, status ...string) ([]*entity.Order, error) {
+	m.ctrl.T.Helper()
+	varargs := []any{accountID, before, limit}
+	for _, a := range status {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "GetByAccountAndStatus", varargs...)
+	ret0, _ := ret[0].([]*entity.Order)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetByAccountAndStatus indicates an expected call of GetByAccountAndStatus.
+func (mr *MockOrderRepositoryMockRecorder) GetByAccountAndStatus(accountID, before, limit any, status ...any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]any{accountID, before, limit}, status...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByAccountAndStatus", reflect.TypeOf((*MockOrderRepository)(nil).GetByAccountAndStatus), varargs...)
+}
+
+// GetByAccountPairSide mocks base method.
+func (m *MockOrderRepository) GetByAccountPairSide(tx *gorm.DB, accountID uuid.UUID, instrumentPair, orderType string, status ...string) ([]*entity.Order, error) {
+	m.ctrl.T.Helper()
+	varargs := []any{tx, accountID, instrumentPair, orderType}
+	for _, a := range status {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "GetByAccountPairSide", varargs...)
+	ret0, _ := ret[0].([]*entity.Order)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetByAccountPairSide indicates an expected call of GetByAccountPairSide.
+func (mr *MockOrderRepositoryMockRecorder) GetByAccountPairSide(tx, accountID, instrumentPair, orderType any, status ...any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]any{tx, accountID, instrumentPair, orderType}, status...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByAccountPairSide", reflect.TypeOf((*MockOrderRepository)(nil).GetByAccountPairSide), varargs...)
+}
+
 // GetByID mocks base method.
 func (m *MockOrderRepository) GetByID(id uuid.UUID, status ...string) (*entity.Order, error) {
 	m.ctrl.T.Helper()
@@ -211,62 +497,236 @@ func (mr *MockOrderRepositoryMockRecorder) GetByID(id any, status ...any) *gomoc
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByID", reflect.TypeOf((*MockOrderRepository)(nil).GetByID), varargs...)
 }
 
+// GetExpired mocks base method.
+func (m *MockOrderRepository) GetExpired(now, createdBefore time.Time, limit int) ([]*entity.Order, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetExpired", now, createdBefore, limit)
+	ret0, _ := ret[0].([]*entity.Order)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetExpired indicates an expected call of GetExpired.
+func (mr *MockOrderRepositoryMockRecorder) GetExpired(now, createdBefore, limit any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetExpired", reflect.TypeOf((*MockOrderRepository)(nil).GetExpired), now, createdBefore, limit)
+}
+
 // GetMatchingOrders mocks base method.
-func (m *MockOrderRepository) GetMatchingOrders(tx *gorm.DB, accountID uuid.UUID, instrumentPair, orderType string, price decimal.Decimal, isBuyOrder bool) ([]*entity.Order, error) {
+func (m *MockOrderRepository) GetMatchingOrders(tx *gorm.DB, accountID uuid.UUID, instrumentPair, orderType string, price decimal.Decimal, isBuyOrder bool, limit int, now, createdBefore time.Time) ([]*entity.Order, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetMatchingOrders", tx, accountID, instrumentPair, orderType, price, isBuyOrder)
+	ret := m.ctrl.Call(m, "GetMatchingOrders", tx, accountID, instrumentPair, orderType, price, isBuyOrder, limit, now, createdBefore)
 	ret0, _ := ret[0].([]*entity.Order)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // GetMatchingOrders indicates an expected call of GetMatchingOrders.
-func (mr *MockOrderRepositoryMockRecorder) GetMatchingOrders(tx, accountID, instrumentPair, orderType, price, isBuyOrder any) *gomock.Call {
+func (mr *MockOrderRepositoryMockRecorder) GetMatchingOrders(tx, accountID, instrumentPair, orderType, price, isBuyOrder, limit, now, createdBefore any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetMatchingOrders", reflect.TypeOf((*MockOrderRepository)(nil).GetMatchingOrders), tx, accountID, instrumentPair, orderType, price, isBuyOrder)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetMatchingOrders", reflect.TypeOf((*MockOrderRepository)(nil).GetMatchingOrders), tx, accountID, instrumentPair, orderType, price, isBuyOrder, limit, now, createdBefore)
+}
+
+// GetOpenBuyNotional mocks base method.
+func (m *MockOrderRepository) GetOpenBuyNotional(tx *gorm.DB, accountID uuid.UUID, quoteAsset string) (decimal.Decimal, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetOpenBuyNotional", tx, accountID, quoteAsset)
+	ret0, _ := ret[0].(decimal.Decimal)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetOpenBuyNotional indicates an expected call of GetOpenBuyNotional.
+func (mr *MockOrderRepositoryMockRecorder) GetOpenBuyNotional(tx, accountID, quoteAsset any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetOpenBuyNotional", reflect.TypeOf((*MockOrderRepository)(nil).GetOpenBuyNotional), tx, accountID, quoteAsset)
 }
 
 // GetOpenOrdersByInstrumentPair mocks base method.
-func (m *MockOrderRepository) GetOpenOrdersByInstrumentPair(instrumentPair string) ([]*entity.Order, error) {
+func (m *MockOrderRepository) GetOpenOrdersByInstrumentPair(instrumentPair string, now, createdBefore time.Time) ([]*entity.Order, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetOpenOrdersByInstrumentPair", instrumentPair)
+	ret := m.ctrl.Call(m, "GetOpenOrdersByInstrumentPair", instrumentPair, now, createdBefore)
 	ret0, _ := ret[0].([]*entity.Order)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // GetOpenOrdersByInstrumentPair indicates an expected call of GetOpenOrdersByInstrumentPair.
-func (mr *MockOrderRepositoryMockRecorder) GetOpenOrdersByInstrumentPair(instrumentPair any) *gomock.Call {
+func (mr *MockOrderRepositoryMockRecorder) GetOpenOrdersByInstrumentPair(instrumentPair, now, createdBefore any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetOpenOrdersByInstrumentPair", reflect.TypeOf((*MockOrderRepository)(nil).GetOpenOrdersByInstrumentPair), instrumentPair)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetOpenOrdersByInstrumentPair", reflect.TypeOf((*MockOrderRepository)(nil).GetOpenOrdersByInstrumentPair), instrumentPair, now, createdBefore)
+}
+
+// GetQueueAhead mocks base method.
+func (m *MockOrderRepository) GetQueueAhead(order *entity.Order) (int64, decimal.Decimal, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetQueueAhead", order)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(decimal.Decimal)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// GetQueueAhead indicates an expected call of GetQueueAhead.
+func (mr *MockOrderRepositoryMockRecorder) GetQueueAhead(order any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetQueueAhead", reflect.TypeOf((*MockOrderRepository)(nil).GetQueueAhead), order)
+}
+
+// GetReservedAmount mocks base method.
+func (m *MockOrderRepository) GetReservedAmount(tx *gorm.DB, accountID uuid.UUID, asset string) (decimal.Decimal, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetReservedAmount", tx, accountID, asset)
+	ret0, _ := ret[0].(decimal.Decimal)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetReservedAmount indicates an expected call of GetReservedAmount.
+func (mr *MockOrderRepositoryMockRecorder) GetReservedAmount(tx, accountID, asset any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetReservedAmount", reflect.TypeOf((*MockOrderRepository)(nil).GetReservedAmount), tx, accountID, asset)
+}
+
+// GetReservedAmounts mocks base method.
+func (m *MockOrderRepository) GetReservedAmounts(accountID uuid.UUID) (map[string]decimal.Decimal, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetReservedAmounts", accountID)
+	ret0, _ := ret[0].(map[string]decimal.Decimal)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetReservedAmounts indicates an expected call of GetReservedAmounts.
+func (mr *MockOrderRepositoryMockRecorder) GetReservedAmounts(accountID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetReservedAmounts", reflect.TypeOf((*MockOrderRepository)(nil).GetReservedAmounts), accountID)
+}
+
+// GetSpreadSnapshots mocks base method.
+func (m *MockOrderRepository) GetSpreadSnapshots(instrumentPair string, from, to time.Time) ([]*entity.SpreadSnapshot, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetSpreadSnapshots", instrumentPair, from, to)
+	ret0, _ := ret[0].([]*entity.SpreadSnapshot)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetSpreadSnapshots indicates an expected call of GetSpreadSnapshots.
+func (mr *MockOrderRepositoryMockRecorder) GetSpreadSnapshots(instrumentPair, from, to any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetSpreadSnapshots", reflect.TypeOf((*MockOrderRepository)(nil).GetSpreadSnapshots), instrumentPair, from, to)
+}
+
+// SnapshotSpreads mocks base method.
+func (m *MockOrderRepository) SnapshotSpreads(takenAt, createdBefore time.Time) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SnapshotSpreads", takenAt, createdBefore)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SnapshotSpreads indicates an expected call of SnapshotSpreads.
+func (mr *MockOrderRepositoryMockRecorder) SnapshotSpreads(takenAt, createdBefore any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SnapshotSpreads", reflect.TypeOf((*MockOrderRepository)(nil).SnapshotSpreads), takenAt, createdBefore)
 }
 
 // UpdateRemainingAndStatus mocks base method.
-func (m *MockOrderRepository) UpdateRemainingAndStatus(tx *gorm.DB, id uuid.UUID, quantity decimal.Decimal, status string) error {
+func (m *MockOrderRepository) UpdateRemainingAndStatus(tx *gorm.DB, id uuid.UUID, quantity, reserved decimal.Decimal, status string) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "UpdateRemainingAndStatus", tx, id, quantity, status)
+	ret := m.ctrl.Call(m, "UpdateRemainingAndStatus", tx, id, quantity, reserved, status)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // UpdateRemainingAndStatus indicates an expected call of UpdateRemainingAndStatus.
-func (mr *MockOrderRepositoryMockRecorder) UpdateRemainingAndStatus(tx, id, quantity, status any) *gomock.Call {
+func (mr *MockOrderRepositoryMockRecorder) UpdateRemainingAndStatus(tx, id, quantity, reserved, status any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateRemainingAndStatus", reflect.TypeOf((*MockOrderRepository)(nil).UpdateRemainingAndStatus), tx, id, quantity, status)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateRemainingAndStatus", reflect.TypeOf((*MockOrderRepository)(nil).UpdateRemainingAndStatus), tx, id, quantity, reserved, status)
 }
 
 // UpdateStatus mocks base method.
-func (m *MockOrderRepository) UpdateStatus(id uuid.UUID, status string) error {
+func (m *MockOrderRepository) UpdateStatus(tx *gorm.DB, id uuid.UUID, status string) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "UpdateStatus", id, status)
+	ret := m.ctrl.Call(m, "UpdateStatus", tx, id, status)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // UpdateStatus indicates an expected call of UpdateStatus.
-func (mr *MockOrderRepositoryMockRecorder) UpdateStatus(id, status any) *gomock.Call {
+func (mr *MockOrderRepositoryMockRecorder) UpdateStatus(tx, id, status any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateStatus", reflect.TypeOf((*MockOrderRepository)(nil).UpdateStatus), id, status)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateStatus", reflect.TypeOf((*MockOrderRepository)(nil).UpdateStatus), tx, id, status)
+}
+
+// UpdateStatusFrom mocks base method.
+func (m *MockOrderRepository) UpdateStatusFrom(tx *gorm.DB, id uuid.UUID, fromStatus, status string) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateStatusFrom", tx, id, fromStatus, status)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdateStatusFrom indicates an expected call of UpdateStatusFrom.
+func (mr *MockOrderRepositoryMockRecorder) UpdateStatusFrom(tx, id, fromStatus, status any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateStatusFrom", reflect.TypeOf((*MockOrderRepository)(nil).UpdateStatusFrom), tx, id, fromStatus, status)
+}
+
+// MockOrderRejectionRepository is a mock of OrderRejectionRepository interface.
+type MockOrderRejectionRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockOrderRejectionRepositoryMockRecorder
+	isgomock struct{}
+}
+
+// MockOrderRejectionRepositoryMockRecorder is the mock recorder for MockOrderRejectionRepository.
+type MockOrderRejectionRepositoryMockRecorder struct {
+	mock *MockOrderRejectionRepository
+}
+
+// NewMockOrderRejectionRepository creates a new mock instance.
+func NewMockOrderRejectionRepository(ctrl *gomock.Controller) *MockOrderRejectionRepository {
+	mock := &MockOrderRejectionRepository{ctrl: ctrl}
+	mock.recorder = &MockOrderRejectionRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockOrderRejectionRepository) EXPECT() *MockOrderRejectionRepositoryMockRecorder {
+	return m.recorder
+}
+
+// Create mocks base method.
+func (m *MockOrderRejectionRepository) Create(rejection *entity.OrderRejection) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Create", rejection)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Create indicates an expected call of Create.
+func (mr *MockOrderRejectionRepositoryMockRecorder) Create(rejection any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockOrderRejectionRepository)(nil).Create), rejection)
+}
+
+// GetByAccountID mocks base method.
+func (m *MockOrderRejectionRepository) GetByAccountID(accountID uuid.UUID, limit int) ([]*entity.OrderRejection, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetByAccountID", accountID, limit)
+	ret0, _ := ret[0].([]*entity.OrderRejection)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetByAccountID indicates an expected call of GetByAccountID.
+func (mr *MockOrderRejectionRepositoryMockRecorder) GetByAccountID(accountID, limit any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByAccountID", reflect.TypeOf((*MockOrderRejectionRepository)(nil).GetByAccountID), accountID, limit)
 }
 
 // MockTradeRepository is a mock of TradeRepository interface.
@@ -306,3 +766,191 @@ func (mr *MockTradeRepositoryMockRecorder) Create(tx, trade any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockTradeRepository)(nil).Create), tx, trade)
 }
+
+// GetByAccountID mocks base method.
+func (m *MockTradeRepository) GetByAccountID(accountID uuid.UUID, limit int) ([]*entity.Trade, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetByAccountID", accountID, limit)
+	ret0, _ := ret[0].([]*entity.Trade)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetByAccountID indicates an expected call of GetByAccountID.
+func (mr *MockTradeRepositoryMockRecorder) GetByAccountID(accountID, limit any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByAccountID", reflect.TypeOf((*MockTradeRepository)(nil).GetByAccountID), accountID, limit)
+}
+
+// GetByAccountPairSide mocks base method.
+func (m *MockTradeRepository) GetByAccountPairSide(accountID uuid.UUID, instrumentPair, orderType string) ([]*entity.Trade, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetByAccountPairSide", accountID, instrumentPair, orderType)
+	ret0, _ := ret[0].([]*entity.Trade)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetByAccountPairSide indicates an expected call of GetByAccountPairSide.
+func (mr *MockTradeRepositoryMockRecorder) GetByAccountPairSide(accountID, instrumentPair, orderType any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByAccountPairSide", reflect.TypeOf((*MockTradeRepository)(nil).GetByAccountPairSide), accountID, instrumentPair, orderType)
+}
+
+// GetByID mocks base method.
+func (m *MockTradeRepository) GetByID(id uuid.UUID) (*entity.Trade, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetByID", id)
+	ret0, _ := ret[0].(*entity.Trade)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetByID indicates an expected call of GetByID.
+func (mr *MockTradeRepositoryMockRecorder) GetByID(id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByID", reflect.TypeOf((*MockTradeRepository)(nil).GetByID), id)
+}
+
+// GetByInstrumentPair mocks base method.
+func (m *MockTradeRepository) GetByInstrumentPair(instrumentPair string, limit int) ([]*entity.Trade, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetByInstrumentPair", instrumentPair, limit)
+	ret0, _ := ret[0].([]*entity.Trade)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetByInstrumentPair indicates an expected call of GetByInstrumentPair.
+func (mr *MockTradeRepositoryMockRecorder) GetByInstrumentPair(instrumentPair, limit any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByInstrumentPair", reflect.TypeOf((*MockTradeRepository)(nil).GetByInstrumentPair), instrumentPair, limit)
+}
+
+// GetByInstrumentPairSince mocks base method.
+func (m *MockTradeRepository) GetByInstrumentPairSince(instrumentPair string, since *entity.Trade, limit int) ([]*entity.Trade, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetByInstrumentPairSince", instrumentPair, since, limit)
+	ret0, _ := ret[0].([]*entity.Trade)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetByInstrumentPairSince indicates an expected call of GetByInstrumentPairSince.
+func (mr *MockTradeRepositoryMockRecorder) GetByInstrumentPairSince(instrumentPair, since, limit any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByInstrumentPairSince", reflect.TypeOf((*MockTradeRepository)(nil).GetByInstrumentPairSince), instrumentPair, since, limit)
+}
+
+// GetByOrderID mocks base method.
+func (m *MockTradeRepository) GetByOrderID(orderID uuid.UUID) ([]*entity.Trade, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetByOrderID", orderID)
+	ret0, _ := ret[0].([]*entity.Trade)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetByOrderID indicates an expected call of GetByOrderID.
+func (mr *MockTradeRepositoryMockRecorder) GetByOrderID(orderID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByOrderID", reflect.TypeOf((*MockTradeRepository)(nil).GetByOrderID), orderID)
+}
+
+// GetCandles mocks base method.
+func (m *MockTradeRepository) GetCandles(instrumentPair string, interval time.Duration, from, to time.Time) ([]*entity.Candle, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetCandles", instrumentPair, interval, from, to)
+	ret0, _ := ret[0].([]*entity.Candle)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetCandles indicates an expected call of GetCandles.
+func (mr *MockTradeRepositoryMockRecorder) GetCandles(instrumentPair, interval, from, to any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetCandles", reflect.TypeOf((*MockTradeRepository)(nil).GetCandles), instrumentPair, interval, from, to)
+}
+
+// GetLatestByAccountPerPair mocks base method.
+func (m *MockTradeRepository) GetLatestByAccountPerPair(accountID uuid.UUID) ([]*entity.Trade, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetLatestByAccountPerPair", accountID)
+	ret0, _ := ret[0].([]*entity.Trade)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetLatestByAccountPerPair indicates an expected call of GetLatestByAccountPerPair.
+func (mr *MockTradeRepositoryMockRecorder) GetLatestByAccountPerPair(accountID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetLatestByAccountPerPair", reflect.TypeOf((*MockTradeRepository)(nil).GetLatestByAccountPerPair), accountID)
+}
+
+// VWAP mocks base method.
+func (m *MockTradeRepository) VWAP(instrumentPair string, from, to time.Time) (*decimal.Decimal, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "VWAP", instrumentPair, from, to)
+	ret0, _ := ret[0].(*decimal.Decimal)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// VWAP indicates an expected call of VWAP.
+func (mr *MockTradeRepositoryMockRecorder) VWAP(instrumentPair, from, to any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "VWAP", reflect.TypeOf((*MockTradeRepository)(nil).VWAP), instrumentPair, from, to)
+}
+
+// MockEventRepository is a mock of EventRepository interface.
+type MockEventRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockEventRepositoryMockRecorder
+	isgomock struct{}
+}
+
+// MockEventRepositoryMockRecorder is the mock recorder for MockEventRepository.
+type MockEventRepositoryMockRecorder struct {
+	mock *MockEventRepository
+}
+
+// NewMockEventRepository creates a new mock instance.
+func NewMockEventRepository(ctrl *gomock.Controller) *MockEventRepository {
+	mock := &MockEventRepository{ctrl: ctrl}
+	mock.recorder = &MockEventRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockEventRepository) EXPECT() *MockEventRepositoryMockRecorder {
+	return m.recorder
+}
+
+// Append mocks base method.
+func (m *MockEventRepository) Append(tx *gorm.DB, event *entity.Event) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Append", tx, event)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Append indicates an expected call of Append.
+func (mr *MockEventRepositoryMockRecorder) Append(tx, event any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Append", reflect.TypeOf((*MockEventRepository)(nil).Append), tx, event)
+}
+
+// GetSince mocks base method.
+func (m *MockEventRepository) GetSince(sequence int64, limit int) ([]*entity.Event, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetSince", sequence, limit)
+	ret0, _ := ret[0].([]*entity.Event)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetSince indicates an expected call of GetSince.
+func (mr *MockEventRepositoryMockRecorder) GetSince(sequence, limit any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetSince", reflect.TypeOf((*MockEventRepository)(nil).GetSince), sequence, limit)
+}
@@ -0,0 +1,46 @@
+package repository
+
+import (
+	"github.com/google/uuid"
+	"github.com/lucas-moura1/mercadobitcoin-challenge/entity"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+type orderRejectionRepository struct {
+	log *zap.SugaredLogger
+	db  *gorm.DB
+}
+
+func NewOrderRejectionRepository(log *zap.SugaredLogger, db *gorm.DB) OrderRejectionRepository {
+	return &orderRejectionRepository{log: log, db: db}
+}
+
+func (r *orderRejectionRepository) Create(rejection *entity.OrderRejection) error {
+	r.log.Debugw("recording order rejection",
+		"account_id", rejection.AccountID,
+		"reason", rejection.Reason,
+	)
+
+	if err := r.db.Create(rejection).Error; err != nil {
+		r.log.Errorw("failed to record order rejection", "account_id", rejection.AccountID, "error", err)
+		return err
+	}
+
+	return nil
+}
+
+func (r *orderRejectionRepository) GetByAccountID(accountID uuid.UUID, limit int) ([]*entity.OrderRejection, error) {
+	var rejections []*entity.OrderRejection
+
+	err := r.db.Where("account_id = ?", accountID).
+		Order("created_at DESC, id DESC").
+		Limit(limit).
+		Find(&rejections).Error
+	if err != nil {
+		r.log.Errorw("failed to get order rejections", "account_id", accountID, "error", err)
+		return nil, err
+	}
+
+	return rejections, nil
+}
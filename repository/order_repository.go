@@ -2,12 +2,14 @@ package repository
 
 import (
 	"errors"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/lucas-moura1/mercadobitcoin-challenge/entity"
 	"github.com/shopspring/decimal"
 	"go.uber.org/zap"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type orderRepository struct {
@@ -39,11 +41,35 @@ func (r *orderRepository) Create(tx *gorm.DB, order *entity.Order) error {
 	return nil
 }
 
-func (r *orderRepository) GetOpenOrdersByInstrumentPair(instrumentPair string) ([]*entity.Order, error) {
+// CreateInBatches inserts orders batchSize rows per INSERT statement, for
+// bulk imports that do not go through matching.
+func (r *orderRepository) CreateInBatches(tx *gorm.DB, orders []*entity.Order, batchSize int) error {
+	r.log.Debugw("creating orders in batches", "count", len(orders), "batch_size", batchSize)
+
+	db := r.db
+	if tx != nil {
+		db = tx
+	}
+
+	if err := db.CreateInBatches(orders, batchSize).Error; err != nil {
+		r.log.Errorw("failed to create orders in batches", "count", len(orders), "error", err)
+		return err
+	}
+
+	return nil
+}
+
+// GetOpenOrdersByInstrumentPair returns the pair's resting orders, open or
+// partially filled, leaving out any whose expires_at is not after now or,
+// when createdBefore is not zero, that were created at or before it: an
+// expired order is off the book even before the sweeper cancels it.
+func (r *orderRepository) GetOpenOrdersByInstrumentPair(instrumentPair string, now time.Time, createdBefore time.Time) ([]*entity.Order, error) {
 	var orders []*entity.Order
 
-	err := r.db.Where("instrument_pair = ? AND status = ?",
-		instrumentPair, string(entity.OrderStatusOpen)).Find(&orders).Error
+	err := r.db.Where("instrument_pair = ? AND status IN ?",
+		instrumentPair, []string{string(entity.OrderStatusOpen), string(entity.OrderStatusPartial)}).
+		Where(notExpired(now, createdBefore)).
+		Find(&orders).Error
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			r.log.Warnw("no open orders found", "instrument_pair", instrumentPair)
@@ -60,17 +86,17 @@ func (r *orderRepository) GetOpenOrdersByInstrumentPair(instrumentPair string) (
 }
 
 func (r *orderRepository) GetByID(id uuid.UUID, status ...string) (*entity.Order, error) {
-
-	whereCondition := "id = ?"
+	query := r.db.Where("id = ?", id)
 	if len(status) > 0 {
-		whereCondition += " AND status IN ?"
+		query = query.Where("status IN ?", status)
 	}
+
 	order := new(entity.Order)
-	err := r.db.Where(whereCondition, id, status).First(order).Error
+	err := query.First(order).Error
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			r.log.Warnw("order not found", "id", id)
-			return nil, nil
+			return nil, ErrNotFound
 		}
 		r.log.Errorw("failed to get order", "id", id, "error", err)
 		return nil, err
@@ -79,13 +105,185 @@ func (r *orderRepository) GetByID(id uuid.UUID, status ...string) (*entity.Order
 	return order, nil
 }
 
-func (r *orderRepository) UpdateStatus(id uuid.UUID, status string) error {
+func (r *orderRepository) CountOpenByAccountID(tx *gorm.DB, accountID uuid.UUID) (int64, error) {
+	db := r.db
+	if tx != nil {
+		db = tx
+	}
+
+	var count int64
+	err := db.Model(&entity.Order{}).
+		Where("account_id = ? AND status IN ?", accountID,
+			[]string{string(entity.OrderStatusOpen), string(entity.OrderStatusPartial)}).
+		Count(&count).Error
+	if err != nil {
+		r.log.Errorw("failed to count open orders", "account_id", accountID, "error", err)
+		return 0, err
+	}
+
+	return count, nil
+}
+
+type statusCountRow struct {
+	Status string
+	Count  int64
+}
+
+type pairCountRow struct {
+	InstrumentPair string
+	Count          int64
+}
+
+// CountOpenByAccountPerPair counts the account's open and partially filled
+// orders per instrument pair. Pairs without open orders are left out.
+func (r *orderRepository) CountOpenByAccountPerPair(accountID uuid.UUID) (map[string]int64, error) {
+	var rows []pairCountRow
+	err := r.db.Model(&entity.Order{}).
+		Where("account_id = ? AND status IN ?", accountID,
+			[]string{string(entity.OrderStatusOpen), string(entity.OrderStatusPartial)}).
+		Select("instrument_pair, COUNT(*) AS count").
+		Group("instrument_pair").
+		Scan(&rows).Error
+	if err != nil {
+		r.log.Errorw("failed to count open orders per pair", "account_id", accountID, "error", err)
+		return nil, err
+	}
+
+	counts := make(map[string]int64, len(rows))
+	for _, row := range rows {
+		counts[row.InstrumentPair] = row.Count
+	}
+
+	return counts, nil
+}
+
+// GetExpired returns up to limit open and partially filled orders whose
+// expiry is at or before now, earliest expiry first. When createdBefore is
+// not zero, orders created at or before it are returned too, whatever their
+// expiry, for the maximum order age.
+func (r *orderRepository) GetExpired(now time.Time, createdBefore time.Time, limit int) ([]*entity.Order, error) {
+	var orders []*entity.Order
+
+	expired := r.db.Where("expires_at IS NOT NULL AND expires_at <= ?", now)
+	if !createdBefore.IsZero() {
+		expired = expired.Or("created_at <= ?", createdBefore)
+	}
+
+	err := r.db.
+		Where("status IN ?", []string{string(entity.OrderStatusOpen), string(entity.OrderStatusPartial)}).
+		Where(expired).
+		Order("expires_at ASC, id ASC").
+		Limit(limit).
+		Find(&orders).Error
+	if err != nil {
+		r.log.Errorw("failed to get expired orders", "now", now, "error", err)
+		return nil, err
+	}
+
+	return orders, nil
+}
+
+// CountByStatus counts the orders of a pair per status, limited to orders
+// created in [from, to). A zero from or to leaves that end of the window open.
+func (r *orderRepository) CountByStatus(instrumentPair string, from time.Time, to time.Time) (map[string]int64, error) {
+	query := r.db.Model(&entity.Order{}).Where("instrument_pair = ?", instrumentPair)
+	if !from.IsZero() {
+		query = query.Where("created_at >= ?", from)
+	}
+	if !to.IsZero() {
+		query = query.Where("created_at < ?", to)
+	}
+
+	var rows []statusCountRow
+	if err := query.Select("status, COUNT(*) AS count").Group("status").Scan(&rows).Error; err != nil {
+		r.log.Errorw("failed to count orders by status", "instrument_pair", instrumentPair, "error", err)
+		return nil, err
+	}
+
+	counts := make(map[string]int64, len(rows))
+	for _, row := range rows {
+		counts[row.Status] = row.Count
+	}
+
+	return counts, nil
+}
+
+func (r *orderRepository) GetByAccountPairSide(
+	tx *gorm.DB,
+	accountID uuid.UUID,
+	instrumentPair string,
+	orderType string,
+	status ...string,
+) ([]*entity.Order, error) {
+	var orders []*entity.Order
+
+	db := r.db
+	if tx != nil {
+		db = tx
+	}
+
+	query := db.Where("account_id = ? AND instrument_pair = ? AND order_type = ?",
+		accountID, instrumentPair, orderType)
+	if len(status) > 0 {
+		query = query.Where("status IN ?", status)
+	}
+
+	if err := query.Order("created_at ASC").Find(&orders).Error; err != nil {
+		r.log.Errorw("failed to get orders by account, pair and side",
+			"account_id", accountID,
+			"instrument_pair", instrumentPair,
+			"order_type", orderType,
+			"error", err,
+		)
+		return nil, err
+	}
+
+	return orders, nil
+}
+
+// GetByAccountAndStatus returns up to limit of the account's orders in the
+// given statuses, newest first. Order IDs are time-ordered, so before (when
+// not uuid.Nil) continues a previous page after its last order.
+func (r *orderRepository) GetByAccountAndStatus(
+	accountID uuid.UUID,
+	before uuid.UUID,
+	limit int,
+	status ...string,
+) ([]*entity.Order, error) {
+	var orders []*entity.Order
+
+	query := r.db.Where("account_id = ?", accountID)
+	if len(status) > 0 {
+		query = query.Where("status IN ?", status)
+	}
+	if before != uuid.Nil {
+		query = query.Where("id < ?", before)
+	}
+
+	if err := query.Order("id DESC").Limit(limit).Find(&orders).Error; err != nil {
+		r.log.Errorw("failed to get orders by account and status",
+			"account_id", accountID,
+			"status", status,
+			"error", err,
+		)
+		return nil, err
+	}
+
+	return orders, nil
+}
+
+func (r *orderRepository) UpdateStatus(tx *gorm.DB, id uuid.UUID, status string) error {
 	r.log.Debugw("updating order status",
 		"id", id,
 		"status", status,
 	)
 
-	if err := r.db.Model(&entity.Order{}).
+	db := r.db
+	if tx != nil {
+		db = tx
+	}
+
+	if err := db.Model(&entity.Order{}).
 		Where("id = ?", id).
 		Update("status", status).Error; err != nil {
 		r.log.Errorw("failed to update order status",
@@ -98,10 +296,42 @@ func (r *orderRepository) UpdateStatus(id uuid.UUID, status string) error {
 	return nil
 }
 
-func (r *orderRepository) UpdateRemainingAndStatus(tx *gorm.DB, id uuid.UUID, quantity decimal.Decimal, status string) error {
+// UpdateStatusFrom moves the order to status only if it is still in
+// fromStatus, reporting whether this call performed the transition. Concurrent
+// callers racing on the same order see exactly one true.
+func (r *orderRepository) UpdateStatusFrom(tx *gorm.DB, id uuid.UUID, fromStatus string, status string) (bool, error) {
+	r.log.Debugw("updating order status conditionally",
+		"id", id,
+		"from_status", fromStatus,
+		"status", status,
+	)
+
+	db := r.db
+	if tx != nil {
+		db = tx
+	}
+
+	resp := db.Model(&entity.Order{}).
+		Where("id = ? AND status = ?", id, fromStatus).
+		Update("status", status)
+	if resp.Error != nil {
+		r.log.Errorw("failed to update order status",
+			"id", id,
+			"error", resp.Error,
+		)
+		return false, resp.Error
+	}
+
+	return resp.RowsAffected == 1, nil
+}
+
+// UpdateRemainingAndStatus records a fill: the quantity and the reserved
+// amount the order has left, and its new status.
+func (r *orderRepository) UpdateRemainingAndStatus(tx *gorm.DB, id uuid.UUID, quantity decimal.Decimal, reserved decimal.Decimal, status string) error {
 	r.log.Debugw("updating order remaining quantity and status",
 		"id", id,
 		"quantity", quantity,
+		"reserved", reserved,
 		"status", status,
 	)
 
@@ -114,6 +344,7 @@ func (r *orderRepository) UpdateRemainingAndStatus(tx *gorm.DB, id uuid.UUID, qu
 		Where("id = ?", id).
 		Updates(map[string]interface{}{
 			"remaining_quantity": quantity,
+			"reserved_amount":    reserved,
 			"status":             status,
 		}).Error; err != nil {
 		r.log.Errorw("failed to update order remaining quantity and status",
@@ -126,6 +357,187 @@ func (r *orderRepository) UpdateRemainingAndStatus(tx *gorm.DB, id uuid.UUID, qu
 	return nil
 }
 
+type queueAheadRow struct {
+	Orders   int64
+	Quantity decimal.NullDecimal
+}
+
+// GetQueueAhead returns how many resting orders, and how much remaining
+// quantity, are ahead of order at its price level: same pair, side and
+// price, placed earlier. Ties on created_at go by id, as in matching.
+func (r *orderRepository) GetQueueAhead(order *entity.Order) (int64, decimal.Decimal, error) {
+	var row queueAheadRow
+
+	err := r.db.Model(&entity.Order{}).
+		Select("COUNT(*) AS orders, SUM(remaining_quantity) AS quantity").
+		Where("instrument_pair = ? AND order_type = ? AND price = ? AND status IN ?",
+			order.InstrumentPair, order.OrderType, order.Price,
+			[]string{string(entity.OrderStatusOpen), string(entity.OrderStatusPartial)}).
+		Where("created_at < ? OR (created_at = ? AND id < ?)", order.CreatedAt, order.CreatedAt, order.ID).
+		Scan(&row).Error
+	if err != nil {
+		r.log.Errorw("failed to get queue ahead of order", "id", order.ID, "error", err)
+		return 0, decimal.Zero, err
+	}
+
+	return row.Orders, row.Quantity.Decimal, nil
+}
+
+type notionalRow struct {
+	Notional decimal.NullDecimal
+}
+
+// GetOpenBuyNotional sums price times remaining quantity over the account's
+// open and partially filled buy orders quoted in quoteAsset.
+func (r *orderRepository) GetOpenBuyNotional(tx *gorm.DB, accountID uuid.UUID, quoteAsset string) (decimal.Decimal, error) {
+	var row notionalRow
+
+	db := r.db
+	if tx != nil {
+		db = tx
+	}
+
+	// "_" is a LIKE wildcard, so the pair separator is escaped.
+	err := db.Model(&entity.Order{}).
+		Select("SUM(price * remaining_quantity) AS notional").
+		Where("account_id = ? AND order_type = ? AND status IN ?", accountID, string(entity.OrderTypeBuy),
+			[]string{string(entity.OrderStatusOpen), string(entity.OrderStatusPartial)}).
+		Where(`instrument_pair LIKE ? ESCAPE '\'`, `%\_`+quoteAsset).
+		Scan(&row).Error
+	if err != nil {
+		r.log.Errorw("failed to get open buy notional", "account_id", accountID, "quote_asset", quoteAsset, "error", err)
+		return decimal.Zero, err
+	}
+
+	return row.Notional.Decimal, nil
+}
+
+type reservedRow struct {
+	Reserved decimal.NullDecimal
+}
+
+// GetReservedAmount sums what the account's open and partially filled orders
+// hold of asset. Filled and cancelled orders hold nothing, whatever their
+// last reserved amount, so leaving the book is what releases a reservation.
+func (r *orderRepository) GetReservedAmount(tx *gorm.DB, accountID uuid.UUID, asset string) (decimal.Decimal, error) {
+	var row reservedRow
+
+	db := r.db
+	if tx != nil {
+		db = tx
+	}
+
+	err := db.Model(&entity.Order{}).
+		Select("SUM(reserved_amount) AS reserved").
+		Where("account_id = ? AND reserved_asset = ? AND status IN ?", accountID, asset,
+			[]string{string(entity.OrderStatusOpen), string(entity.OrderStatusPartial)}).
+		Scan(&row).Error
+	if err != nil {
+		r.log.Errorw("failed to get reserved amount", "account_id", accountID, "asset", asset, "error", err)
+		return decimal.Zero, err
+	}
+
+	return row.Reserved.Decimal, nil
+}
+
+type reservedByAssetRow struct {
+	ReservedAsset string
+	Reserved      decimal.NullDecimal
+}
+
+// GetReservedAmounts is GetReservedAmount for every asset at once, keyed by
+// asset. Assets nothing is reserved of are left out.
+func (r *orderRepository) GetReservedAmounts(accountID uuid.UUID) (map[string]decimal.Decimal, error) {
+	var rows []reservedByAssetRow
+
+	err := r.db.Model(&entity.Order{}).
+		Select("reserved_asset, SUM(reserved_amount) AS reserved").
+		Where("account_id = ? AND reserved_asset <> '' AND status IN ?", accountID,
+			[]string{string(entity.OrderStatusOpen), string(entity.OrderStatusPartial)}).
+		Group("reserved_asset").
+		Scan(&rows).Error
+	if err != nil {
+		r.log.Errorw("failed to get reserved amounts", "account_id", accountID, "error", err)
+		return nil, err
+	}
+
+	reserved := make(map[string]decimal.Decimal, len(rows))
+	for _, row := range rows {
+		reserved[row.ReservedAsset] = row.Reserved.Decimal
+	}
+	return reserved, nil
+}
+
+// bestPricesRow is one pair's best bid and ask as read by SnapshotSpreads.
+type bestPricesRow struct {
+	InstrumentPair string
+	BestBid        decimal.NullDecimal
+	BestAsk        decimal.NullDecimal
+}
+
+// SnapshotSpreads records the best bid and ask of every pair with resting
+// orders as of takenAt and returns how many snapshots were written. Orders
+// expired by takenAt are left out, as they are from the book.
+func (r *orderRepository) SnapshotSpreads(takenAt time.Time, createdBefore time.Time) (int, error) {
+	r.log.Debugw("snapshotting spreads", "taken_at", takenAt)
+
+	var rows []bestPricesRow
+	err := r.db.Model(&entity.Order{}).
+		Select("instrument_pair, "+
+			"MAX(CASE WHEN order_type = ? THEN price END) AS best_bid, "+
+			"MIN(CASE WHEN order_type = ? THEN price END) AS best_ask",
+			string(entity.OrderTypeBuy), string(entity.OrderTypeSell)).
+		Where("status IN ?", []string{string(entity.OrderStatusOpen), string(entity.OrderStatusPartial)}).
+		Where(notExpired(takenAt, createdBefore)).
+		Group("instrument_pair").
+		Scan(&rows).Error
+	if err != nil {
+		r.log.Errorw("failed to read best prices for snapshot", "error", err)
+		return 0, err
+	}
+
+	if len(rows) == 0 {
+		return 0, nil
+	}
+
+	snapshots := make([]*entity.SpreadSnapshot, len(rows))
+	for i, row := range rows {
+		snapshots[i] = &entity.SpreadSnapshot{
+			InstrumentPair: row.InstrumentPair,
+			BestBid:        row.BestBid,
+			BestAsk:        row.BestAsk,
+			TakenAt:        takenAt,
+		}
+	}
+
+	if err := r.db.Create(&snapshots).Error; err != nil {
+		r.log.Errorw("failed to create spread snapshots", "error", err)
+		return 0, err
+	}
+
+	return len(snapshots), nil
+}
+
+// GetSpreadSnapshots returns the pair's spread snapshots taken in [from, to),
+// oldest first.
+func (r *orderRepository) GetSpreadSnapshots(instrumentPair string, from time.Time, to time.Time) ([]*entity.SpreadSnapshot, error) {
+	var snapshots []*entity.SpreadSnapshot
+
+	err := r.db.Where("instrument_pair = ? AND taken_at >= ? AND taken_at < ?", instrumentPair, from, to).
+		Order("taken_at ASC").
+		Find(&snapshots).Error
+	if err != nil {
+		r.log.Errorw("failed to get spread snapshots", "instrument_pair", instrumentPair, "error", err)
+		return nil, err
+	}
+
+	return snapshots, nil
+}
+
+// GetMatchingOrders returns the resting orders of orderType an order at price
+// would match, best price first and oldest first within a price, excluding
+// the account's own orders and any expired as of now or, when createdBefore
+// is not zero, created at or before it.
 func (r *orderRepository) GetMatchingOrders(
 	tx *gorm.DB,
 	accountID uuid.UUID,
@@ -133,6 +545,9 @@ func (r *orderRepository) GetMatchingOrders(
 	orderType string,
 	price decimal.Decimal,
 	isBuyOrder bool,
+	limit int,
+	now time.Time,
+	createdBefore time.Time,
 ) ([]*entity.Order, error) {
 	var orders []*entity.Order
 
@@ -142,12 +557,17 @@ func (r *orderRepository) GetMatchingOrders(
 	}
 
 	query := db.Where("instrument_pair = ? AND order_type = ? AND status IN (?) AND account_id <> ?",
-		instrumentPair, orderType, []string{string(entity.OrderStatusOpen), string(entity.OrderStatusPartial)}, accountID)
+		instrumentPair, orderType, []string{string(entity.OrderStatusOpen), string(entity.OrderStatusPartial)}, accountID).
+		Where(notExpired(now, createdBefore))
 
 	if isBuyOrder {
-		query = query.Where("price <= ?", price).Order("price ASC, created_at ASC")
+		query = query.Where("price <= ?", price).Order("price ASC, created_at ASC, id ASC")
 	} else {
-		query = query.Where("price >= ?", price).Order("price DESC, created_at ASC")
+		query = query.Where("price >= ?", price).Order("price DESC, created_at ASC, id ASC")
+	}
+
+	if limit > 0 {
+		query = query.Limit(limit)
 	}
 
 	err := query.Find(&orders).Error
@@ -171,3 +591,13 @@ func (r *orderRepository) GetMatchingOrders(
 
 	return orders, nil
 }
+
+// notExpired keeps orders with no expires_at or one after now. When
+// createdBefore is not zero it also drops orders created at or before it,
+// the same maximum age cutoff GetExpired applies.
+func notExpired(now time.Time, createdBefore time.Time) clause.Expr {
+	if createdBefore.IsZero() {
+		return gorm.Expr("(expires_at IS NULL OR expires_at > ?)", now)
+	}
+	return gorm.Expr("(expires_at IS NULL OR expires_at > ?) AND created_at > ?", now, createdBefore)
+}
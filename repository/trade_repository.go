@@ -1,17 +1,24 @@
 package repository
 
 import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
 	"github.com/lucas-moura1/mercadobitcoin-challenge/entity"
+	"github.com/shopspring/decimal"
 	"go.uber.org/zap"
 	"gorm.io/gorm"
 )
 
 type tradeRepository struct {
 	log *zap.SugaredLogger
+	db  *gorm.DB
 }
 
-func NewTradeRepository(log *zap.SugaredLogger) TradeRepository {
-	return &tradeRepository{log: log}
+func NewTradeRepository(log *zap.SugaredLogger, db *gorm.DB) TradeRepository {
+	return &tradeRepository{log: log, db: db}
 }
 
 func (r *tradeRepository) Create(tx *gorm.DB, trade *entity.Trade) error {
@@ -29,3 +36,242 @@ func (r *tradeRepository) Create(tx *gorm.DB, trade *entity.Trade) error {
 
 	return nil
 }
+
+func (r *tradeRepository) GetByAccountID(accountID uuid.UUID, limit int) ([]*entity.Trade, error) {
+	var trades []*entity.Trade
+
+	accountOrders := r.db.Model(&entity.Order{}).Select("id").Where("account_id = ?", accountID)
+	err := r.db.Where("(buyer_order_id IN (?) OR seller_order_id IN (?)) AND deleted_at IS NULL", accountOrders, accountOrders).
+		Order("executed_at DESC, id DESC").
+		Limit(limit).
+		Find(&trades).Error
+	if err != nil {
+		r.log.Errorw("failed to get trades by account", "account_id", accountID, "error", err)
+		return nil, err
+	}
+
+	return trades, nil
+}
+
+// GetByAccountPairSide returns the pair's trades in which one of the
+// account's orders of orderType took part, oldest first.
+func (r *tradeRepository) GetByAccountPairSide(accountID uuid.UUID, instrumentPair string, orderType string) ([]*entity.Trade, error) {
+	var trades []*entity.Trade
+
+	column := "seller_order_id"
+	if orderType == string(entity.OrderTypeBuy) {
+		column = "buyer_order_id"
+	}
+
+	accountOrders := r.db.Model(&entity.Order{}).Select("id").Where("account_id = ?", accountID)
+	err := r.db.Where(column+" IN (?) AND instrument_pair = ? AND deleted_at IS NULL", accountOrders, instrumentPair).
+		Order("executed_at ASC, id ASC").
+		Find(&trades).Error
+	if err != nil {
+		r.log.Errorw("failed to get trades by account, pair and side",
+			"account_id", accountID,
+			"instrument_pair", instrumentPair,
+			"order_type", orderType,
+			"error", err,
+		)
+		return nil, err
+	}
+
+	return trades, nil
+}
+
+// GetLatestByAccountPerPair returns the account's most recent trade in each
+// instrument pair it has traded, one trade per pair.
+func (r *tradeRepository) GetLatestByAccountPerPair(accountID uuid.UUID) ([]*entity.Trade, error) {
+	var trades []*entity.Trade
+
+	accountOrders := r.db.Model(&entity.Order{}).Select("id").Where("account_id = ?", accountID)
+	accountTrades := "(buyer_order_id IN (?) OR seller_order_id IN (?)) AND deleted_at IS NULL"
+	latest := r.db.Model(&entity.Trade{}).
+		Select("instrument_pair, MAX(executed_at)").
+		Where(accountTrades, accountOrders, accountOrders).
+		Group("instrument_pair")
+	err := r.db.Where(accountTrades, accountOrders, accountOrders).
+		Where("(instrument_pair, executed_at) IN (?)", latest).
+		Order("instrument_pair ASC, executed_at DESC, id DESC").
+		Find(&trades).Error
+	if err != nil {
+		r.log.Errorw("failed to get latest trades by account", "account_id", accountID, "error", err)
+		return nil, err
+	}
+
+	// Trades sharing the latest timestamp are ordered by id, so the first
+	// one seen for a pair wins.
+	unique := trades[:0]
+	for _, trade := range trades {
+		if len(unique) == 0 || unique[len(unique)-1].InstrumentPair != trade.InstrumentPair {
+			unique = append(unique, trade)
+		}
+	}
+
+	return unique, nil
+}
+
+func (r *tradeRepository) GetByID(id uuid.UUID) (*entity.Trade, error) {
+	trade := new(entity.Trade)
+	err := r.db.Where("id = ? AND deleted_at IS NULL", id).First(trade).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrNotFound
+		}
+		r.log.Errorw("failed to get trade", "id", id, "error", err)
+		return nil, err
+	}
+
+	return trade, nil
+}
+
+func (r *tradeRepository) GetByOrderID(orderID uuid.UUID) ([]*entity.Trade, error) {
+	var trades []*entity.Trade
+
+	err := r.db.Where("(buyer_order_id = ? OR seller_order_id = ?) AND deleted_at IS NULL", orderID, orderID).
+		Order("executed_at ASC, id ASC").
+		Find(&trades).Error
+	if err != nil {
+		r.log.Errorw("failed to get trades by order", "order_id", orderID, "error", err)
+		return nil, err
+	}
+
+	return trades, nil
+}
+
+// GetByInstrumentPairSince returns up to limit of the pair's trades executed
+// after since (or from the first trade when since is nil), oldest first.
+func (r *tradeRepository) GetByInstrumentPairSince(instrumentPair string, since *entity.Trade, limit int) ([]*entity.Trade, error) {
+	var trades []*entity.Trade
+
+	query := r.db.Where("instrument_pair = ? AND deleted_at IS NULL", instrumentPair)
+	if since != nil {
+		query = query.Where("executed_at > ? OR (executed_at = ? AND id > ?)",
+			since.ExecutedAt, since.ExecutedAt, since.ID)
+	}
+
+	err := query.Order("executed_at ASC, id ASC").Limit(limit).Find(&trades).Error
+	if err != nil {
+		r.log.Errorw("failed to get trades since cursor", "instrument_pair", instrumentPair, "error", err)
+		return nil, err
+	}
+
+	return trades, nil
+}
+
+func (r *tradeRepository) GetByInstrumentPair(instrumentPair string, limit int) ([]*entity.Trade, error) {
+	var trades []*entity.Trade
+
+	err := r.db.Where("instrument_pair = ? AND deleted_at IS NULL", instrumentPair).
+		Order("executed_at DESC, id DESC").
+		Limit(limit).
+		Find(&trades).Error
+	if err != nil {
+		r.log.Errorw("failed to get trades by instrument pair", "instrument_pair", instrumentPair, "error", err)
+		return nil, err
+	}
+
+	return trades, nil
+}
+
+type vwapRow struct {
+	Notional decimal.NullDecimal
+	Volume   decimal.NullDecimal
+}
+
+// VWAP returns the volume-weighted average price of the pair's trades
+// executed in [from, to), or nil when there were none. Notional and volume
+// are summed by the database and divided here, so the result does not depend
+// on how each dialect rounds a division.
+func (r *tradeRepository) VWAP(instrumentPair string, from time.Time, to time.Time) (*decimal.Decimal, error) {
+	var row vwapRow
+	err := r.db.Model(&entity.Trade{}).
+		Select("SUM(price * quantity) AS notional, SUM(quantity) AS volume").
+		Where("instrument_pair = ? AND executed_at >= ? AND executed_at < ? AND deleted_at IS NULL",
+			instrumentPair, from, to).
+		Scan(&row).Error
+	if err != nil {
+		r.log.Errorw("failed to get vwap", "instrument_pair", instrumentPair, "error", err)
+		return nil, err
+	}
+
+	if !row.Volume.Valid || !row.Volume.Decimal.IsPositive() {
+		return nil, nil
+	}
+
+	vwap := entity.DecimalDiv(row.Notional.Decimal, row.Volume.Decimal)
+	return &vwap, nil
+}
+
+type candleRow struct {
+	Bucket int64
+	Open   decimal.Decimal
+	High   decimal.Decimal
+	Low    decimal.Decimal
+	Close  decimal.Decimal
+	Volume decimal.Decimal
+}
+
+// bucketExpression returns the SQL expression that maps executed_at to the
+// index of its interval since the Unix epoch for the current dialect.
+func (r *tradeRepository) bucketExpression() string {
+	if r.db.Dialector.Name() == "sqlite" {
+		return "CAST(strftime('%s', executed_at) AS INTEGER) / ?"
+	}
+	return "CAST(FLOOR(EXTRACT(EPOCH FROM executed_at) / ?) AS BIGINT)"
+}
+
+func (r *tradeRepository) GetCandles(
+	instrumentPair string,
+	interval time.Duration,
+	from time.Time,
+	to time.Time,
+) ([]*entity.Candle, error) {
+	seconds := int64(interval / time.Second)
+
+	// Open and close are the first and last trade of each bucket, ranked by
+	// execution time (and id to break ties between trades of the same instant).
+	ranked := r.db.Model(&entity.Trade{}).
+		Select(fmt.Sprintf(`%s AS bucket, price, quantity,
+			ROW_NUMBER() OVER (PARTITION BY %s ORDER BY executed_at ASC, id ASC) AS first_rank,
+			ROW_NUMBER() OVER (PARTITION BY %s ORDER BY executed_at DESC, id DESC) AS last_rank`,
+			r.bucketExpression(), r.bucketExpression(), r.bucketExpression()),
+			seconds, seconds, seconds).
+		Where("instrument_pair = ? AND executed_at >= ? AND executed_at < ? AND deleted_at IS NULL",
+			instrumentPair, from, to)
+
+	var rows []candleRow
+	err := r.db.Table("(?) AS ranked", ranked).
+		Select(`bucket,
+			MAX(CASE WHEN first_rank = 1 THEN price END) AS open,
+			MAX(price) AS high,
+			MIN(price) AS low,
+			MAX(CASE WHEN last_rank = 1 THEN price END) AS close,
+			SUM(quantity) AS volume`).
+		Group("bucket").
+		Order("bucket ASC").
+		Scan(&rows).Error
+	if err != nil {
+		r.log.Errorw("failed to get candles",
+			"instrument_pair", instrumentPair,
+			"interval", interval,
+			"error", err,
+		)
+		return nil, err
+	}
+
+	candles := make([]*entity.Candle, len(rows))
+	for i, row := range rows {
+		candles[i] = &entity.Candle{
+			OpenTime: time.Unix(row.Bucket*seconds, 0).UTC(),
+			Open:     row.Open,
+			High:     row.High,
+			Low:      row.Low,
+			Close:    row.Close,
+			Volume:   row.Volume,
+		}
+	}
+
+	return candles, nil
+}
@@ -2,6 +2,7 @@ package repository
 
 import (
 	"errors"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/lucas-moura1/mercadobitcoin-challenge/entity"
@@ -12,12 +13,13 @@ import (
 )
 
 type walletRepository struct {
-	log *zap.SugaredLogger
-	db  *gorm.DB
+	log         *zap.SugaredLogger
+	db          *gorm.DB
+	instruments *entity.InstrumentConfig
 }
 
-func NewWalletRepository(log *zap.SugaredLogger, db *gorm.DB) WalletRepository {
-	return &walletRepository{log: log, db: db}
+func NewWalletRepository(log *zap.SugaredLogger, db *gorm.DB, instruments *entity.InstrumentConfig) WalletRepository {
+	return &walletRepository{log: log, db: db, instruments: instruments}
 }
 
 func (r *walletRepository) chooseDB(tx *gorm.DB) *gorm.DB {
@@ -28,28 +30,45 @@ func (r *walletRepository) chooseDB(tx *gorm.DB) *gorm.DB {
 }
 
 func (r *walletRepository) Create(tx *gorm.DB, wallet *entity.Wallet) error {
+	_, err := r.CreateIfNotExists(tx, wallet)
+	return err
+}
+
+// CreateIfNotExists creates the wallet unless the account already has one for
+// the asset, and reports whether it was created. An existing wallet is left
+// untouched, balance included.
+func (r *walletRepository) CreateIfNotExists(tx *gorm.DB, wallet *entity.Wallet) (bool, error) {
 	r.log.Debugw("creating wallet",
 		"account_id", wallet.AccountID,
 		"asset", wallet.AssetSymbol,
 	)
 	db := r.chooseDB(tx)
 
-	if err := db.
+	result := db.
 		Clauses(clause.OnConflict{
 			Columns:   []clause.Column{{Name: "account_id"}, {Name: "asset_symbol"}},
 			DoNothing: true,
-		}).Create(wallet).Error; err != nil {
-		r.log.Errorw("failed to create wallet", "error", err)
-		return err
+		}).Create(wallet)
+	if result.Error != nil {
+		r.log.Errorw("failed to create wallet", "error", result.Error)
+		return false, result.Error
 	}
 
-	return nil
+	if result.RowsAffected == 0 {
+		r.log.Debugw("wallet already exists",
+			"account_id", wallet.AccountID,
+			"asset", wallet.AssetSymbol,
+		)
+		return false, nil
+	}
+
+	return true, nil
 }
 
 func (r *walletRepository) GetByAccountID(accountID uuid.UUID) ([]*entity.Wallet, error) {
 	var wallets []*entity.Wallet
 
-	err := r.db.Where(&entity.Wallet{AccountID: accountID, DeletedAt: nil}).Find(&wallets).Error
+	err := r.db.Where("account_id = ? AND deleted_at IS NULL", accountID).Find(&wallets).Error
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			r.log.Warnw("no wallets found for account", "account_id", accountID)
@@ -62,6 +81,44 @@ func (r *walletRepository) GetByAccountID(accountID uuid.UUID) ([]*entity.Wallet
 	return wallets, nil
 }
 
+// GetByAccountIDForUpdate returns the account's wallets locked FOR UPDATE in
+// tx, so no balance change can commit until tx ends.
+func (r *walletRepository) GetByAccountIDForUpdate(tx *gorm.DB, accountID uuid.UUID) ([]*entity.Wallet, error) {
+	var wallets []*entity.Wallet
+
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("account_id = ? AND deleted_at IS NULL", accountID).
+		Find(&wallets).Error
+	if err != nil {
+		r.log.Errorw("failed to get wallets for update", "account_id", accountID, "error", err)
+		return nil, err
+	}
+
+	return wallets, nil
+}
+
+// GetByAccountIDPaged returns up to limit of the account's wallets ordered by
+// asset symbol, starting after afterAsset (empty starts from the first).
+func (r *walletRepository) GetByAccountIDPaged(accountID uuid.UUID, afterAsset string, limit int) ([]*entity.Wallet, error) {
+	var wallets []*entity.Wallet
+
+	query := r.db.Where("account_id = ? AND deleted_at IS NULL", accountID)
+	if afterAsset != "" {
+		query = query.Where("asset_symbol > ?", afterAsset)
+	}
+
+	if err := query.Order("asset_symbol ASC").Limit(limit).Find(&wallets).Error; err != nil {
+		r.log.Errorw("failed to get wallets page",
+			"account_id", accountID,
+			"after_asset", afterAsset,
+			"error", err,
+		)
+		return nil, err
+	}
+
+	return wallets, nil
+}
+
 func (r *walletRepository) GetByAccountAndAsset(tx *gorm.DB, accountID uuid.UUID, assetSymbol string) (*entity.Wallet, error) {
 	wallet := new(entity.Wallet)
 	err := tx.Where("account_id = ? AND asset_symbol = ? AND deleted_at IS NULL", accountID, assetSymbol).
@@ -72,7 +129,7 @@ func (r *walletRepository) GetByAccountAndAsset(tx *gorm.DB, accountID uuid.UUID
 				"account_id", accountID,
 				"asset", assetSymbol,
 			)
-			return nil, nil
+			return nil, ErrNotFound
 		}
 		r.log.Errorw("failed to get wallet",
 			"account_id", accountID,
@@ -85,15 +142,26 @@ func (r *walletRepository) GetByAccountAndAsset(tx *gorm.DB, accountID uuid.UUID
 	return wallet, nil
 }
 
+// GetByAccountAndAssetForUpdate is GetByAccountAndAsset holding a row lock on
+// the wallet until tx ends, so a read-check-write on the balance cannot race
+// another transaction.
+func (r *walletRepository) GetByAccountAndAssetForUpdate(tx *gorm.DB, accountID uuid.UUID, assetSymbol string) (*entity.Wallet, error) {
+	return r.GetByAccountAndAsset(tx.Clauses(clause.Locking{Strength: "UPDATE"}), accountID, assetSymbol)
+}
+
 func (r *walletRepository) updateBalance(tx *gorm.DB, accountID uuid.UUID, assetSymbol string, amount decimal.Decimal, isAdd bool) error {
 	r.log.Debugw("updating wallet balance", "account_id", accountID, "asset", assetSymbol, "amount", amount)
-	updateClause := "balance - ?"
-	if isAdd {
-		updateClause = " balance + ?"
+	query := tx.Model(&entity.Wallet{}).Where("account_id = ? AND asset_symbol = ? AND deleted_at IS NULL", accountID, assetSymbol)
+	balance := gorm.Expr("balance + ?", amount)
+	if !isAdd {
+		// A deficit within the asset's epsilon is rounding residue rather than
+		// an overdraft, so it draws the balance to exactly zero.
+		epsilon := r.instruments.BalanceEpsilon(assetSymbol)
+		query = query.Where("balance >= ?", amount.Sub(epsilon))
+		balance = gorm.Expr("CASE WHEN balance < ? THEN 0 ELSE balance - ? END", amount, amount)
 	}
 
-	resp := tx.Model(&entity.Wallet{}).Where("account_id = ? AND asset_symbol = ? AND deleted_at IS NULL", accountID, assetSymbol).
-		Update("balance", gorm.Expr(updateClause, amount))
+	resp := query.Update("balance", balance)
 	if resp.Error != nil {
 		r.log.Errorw("failed to update wallet balance", "account_id", accountID, "asset", assetSymbol, "error", resp.Error)
 		return resp.Error
@@ -123,3 +191,71 @@ func (r *walletRepository) AddToBalance(tx *gorm.DB, accountID uuid.UUID, assetS
 	}
 	return nil
 }
+
+// Snapshot records the current balance of every live wallet as of takenAt
+// and returns how many snapshots were written.
+func (r *walletRepository) Snapshot(takenAt time.Time) (int, error) {
+	r.log.Debugw("snapshotting wallet balances", "taken_at", takenAt)
+
+	var wallets []*entity.Wallet
+	if err := r.db.Where("deleted_at IS NULL").Find(&wallets).Error; err != nil {
+		r.log.Errorw("failed to read wallets for snapshot", "error", err)
+		return 0, err
+	}
+
+	if len(wallets) == 0 {
+		return 0, nil
+	}
+
+	snapshots := make([]*entity.WalletSnapshot, len(wallets))
+	for i, wallet := range wallets {
+		snapshots[i] = &entity.WalletSnapshot{
+			WalletID:    wallet.ID,
+			AccountID:   wallet.AccountID,
+			AssetSymbol: wallet.AssetSymbol,
+			Balance:     wallet.Balance,
+			TakenAt:     takenAt,
+		}
+	}
+
+	if err := r.db.Create(&snapshots).Error; err != nil {
+		r.log.Errorw("failed to create wallet snapshots", "error", err)
+		return 0, err
+	}
+
+	return len(snapshots), nil
+}
+
+// GetSnapshotsAt returns, for each wallet of the account, the latest snapshot
+// taken at or before at.
+func (r *walletRepository) GetSnapshotsAt(accountID uuid.UUID, at time.Time) ([]*entity.WalletSnapshot, error) {
+	var snapshots []*entity.WalletSnapshot
+
+	latest := r.db.Table("wallet_snapshot AS prior").
+		Select("MAX(prior.taken_at)").
+		Where("prior.wallet_id = wallet_snapshot.wallet_id AND prior.taken_at <= ?", at)
+	err := r.db.Where("account_id = ? AND taken_at = (?)", accountID, latest).
+		Order("asset_symbol ASC").
+		Find(&snapshots).Error
+	if err != nil {
+		r.log.Errorw("failed to get wallet snapshots", "account_id", accountID, "at", at, "error", err)
+		return nil, err
+	}
+
+	return snapshots, nil
+}
+
+func (r *walletRepository) SoftDeleteByAccountID(tx *gorm.DB, accountID uuid.UUID) error {
+	r.log.Debugw("soft deleting wallets", "account_id", accountID)
+	db := r.chooseDB(tx)
+
+	err := db.Model(&entity.Wallet{}).
+		Where("account_id = ? AND deleted_at IS NULL", accountID).
+		Update("deleted_at", entity.NowUTC()).Error
+	if err != nil {
+		r.log.Errorw("failed to soft delete wallets", "account_id", accountID, "error", err)
+		return err
+	}
+
+	return nil
+}
@@ -32,7 +32,12 @@ CREATE TABLE "order"
     price DECIMAL(20,8) NOT NULL,
     quantity DECIMAL(20,8) NOT NULL,
     remaining_quantity DECIMAL(20,8) NOT NULL,
+    min_fill_quantity DECIMAL(20,8) NOT NULL DEFAULT 0,
+    quote_quantity DECIMAL(20,8) NOT NULL DEFAULT 0,
+    reserved_asset VARCHAR(10) NOT NULL DEFAULT '',
+    reserved_amount DECIMAL(20,8) NOT NULL DEFAULT 0,
     status VARCHAR(20) NOT NULL CHECK (status IN ('OPEN', 'PARTIALLY_FILLED', 'FILLED', 'CANCELLED')),
+    expires_at TIMESTAMP,
     created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
     updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
     FOREIGN KEY (account_id) REFERENCES account(id)
@@ -43,6 +48,7 @@ CREATE TABLE trade
     id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
     buyer_order_id UUID NOT NULL,
     seller_order_id UUID NOT NULL,
+    instrument_pair VARCHAR(20) NOT NULL,
     price DECIMAL(20,8) NOT NULL,
     quantity DECIMAL(20,8) NOT NULL,
     executed_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
@@ -51,9 +57,74 @@ CREATE TABLE trade
     FOREIGN KEY (seller_order_id) REFERENCES "order"(id)
 );
 
+CREATE TABLE event
+(
+    sequence BIGSERIAL PRIMARY KEY,
+    event_type VARCHAR(30) NOT NULL,
+    aggregate_id UUID NOT NULL,
+    payload JSONB NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE api_key
+(
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    account_id UUID NOT NULL,
+    key VARCHAR(64) NOT NULL UNIQUE,
+    secret VARCHAR(128) NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    deleted_at TIMESTAMP NULL DEFAULT NULL,
+    FOREIGN KEY (account_id) REFERENCES account(id)
+);
+
+CREATE TABLE wallet_snapshot
+(
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    wallet_id UUID NOT NULL,
+    account_id UUID NOT NULL,
+    asset_symbol VARCHAR(10) NOT NULL,
+    balance DECIMAL(20,8) NOT NULL,
+    taken_at TIMESTAMP NOT NULL,
+    FOREIGN KEY (wallet_id) REFERENCES wallet(id),
+    FOREIGN KEY (account_id) REFERENCES account(id)
+);
+
+CREATE TABLE spread_snapshot
+(
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    instrument_pair VARCHAR(20) NOT NULL,
+    best_bid DECIMAL(20,8) NULL,
+    best_ask DECIMAL(20,8) NULL,
+    taken_at TIMESTAMP NOT NULL
+);
+
+CREATE TABLE order_rejection
+(
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    account_id UUID NOT NULL,
+    instrument_pair VARCHAR(20) NOT NULL,
+    order_type VARCHAR(20) NOT NULL,
+    price DECIMAL(20,8) NOT NULL,
+    quantity DECIMAL(20,8) NOT NULL,
+    min_fill_quantity DECIMAL(20,8) NOT NULL DEFAULT 0,
+    reason VARCHAR(30) NOT NULL,
+    message TEXT NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
 -- Indexes
 CREATE INDEX idx_wallet_account_id ON wallet(account_id);
 CREATE INDEX idx_order_account_id_instrument_pair ON "order"(account_id, instrument_pair);
 CREATE INDEX idx_order_match
   ON "order" (instrument_pair, order_type, price, created_at)
   WHERE status IN ('OPEN','PARTIALLY_FILLED');
+CREATE INDEX idx_order_expires_at
+  ON "order" (expires_at)
+  WHERE expires_at IS NOT NULL AND status IN ('OPEN','PARTIALLY_FILLED');
+CREATE INDEX idx_trade_instrument_pair_executed_at ON trade(instrument_pair, executed_at);
+CREATE INDEX idx_wallet_snapshot_wallet_taken_at ON wallet_snapshot(wallet_id, taken_at);
+CREATE INDEX idx_wallet_snapshot_account_taken_at ON wallet_snapshot(account_id, taken_at);
+CREATE INDEX idx_spread_snapshot_pair_taken_at ON spread_snapshot(instrument_pair, taken_at);
+CREATE INDEX idx_order_rejection_account_created_at ON order_rejection(account_id, created_at);
@@ -1,24 +1,45 @@
 package usecase
 
 import (
+	"context"
+	"errors"
+	"time"
+
 	"github.com/google/uuid"
 	"github.com/lucas-moura1/mercadobitcoin-challenge/entity"
 	"github.com/lucas-moura1/mercadobitcoin-challenge/repository"
+	"github.com/shopspring/decimal"
 	"go.uber.org/zap"
+	"gorm.io/gorm"
 )
 
 type accountUseCase struct {
-	log              *zap.SugaredLogger
-	walletRepository repository.WalletRepository
+	log               *zap.SugaredLogger
+	accountRepository repository.AccountRepository
+	walletRepository  repository.WalletRepository
+	orderRepository   repository.OrderRepository
+	tradeRepository   repository.TradeRepository
+	eventRepository   repository.EventRepository
+	db                *gorm.DB
 }
 
 func NewAccountUseCase(
 	log *zap.SugaredLogger,
+	accountRepo repository.AccountRepository,
 	walletRepo repository.WalletRepository,
+	orderRepo repository.OrderRepository,
+	tradeRepo repository.TradeRepository,
+	eventRepo repository.EventRepository,
+	db *gorm.DB,
 ) AccountUseCase {
 	return &accountUseCase{
-		log:              log,
-		walletRepository: walletRepo,
+		log:               log,
+		accountRepository: accountRepo,
+		walletRepository:  walletRepo,
+		orderRepository:   orderRepo,
+		tradeRepository:   tradeRepo,
+		eventRepository:   eventRepo,
+		db:                db,
 	}
 }
 
@@ -31,8 +52,296 @@ func (u *accountUseCase) GetAccountBalance(accountID uuid.UUID) ([]*entity.Walle
 	}
 
 	if len(wallets) == 0 {
-		return nil, nil
+		return nil, repository.ErrNotFound
 	}
 
 	return wallets, nil
 }
+
+// GetAccountBalancePage returns up to limit of the account's wallets after
+// afterAsset, ordered by asset symbol. Like GetAccountBalance it fails with
+// repository.ErrNotFound when the account has no wallets at all.
+func (u *accountUseCase) GetAccountBalancePage(accountID uuid.UUID, afterAsset string, limit int) (*BalancePage, error) {
+	u.log.Infow("fetching account balance page", "account_id", accountID, "after_asset", afterAsset, "limit", limit)
+
+	limit = clampTradesLimit(limit)
+
+	// One extra row tells whether there is a next page.
+	wallets, err := u.walletRepository.GetByAccountIDPaged(accountID, afterAsset, limit+1)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(wallets) == 0 && afterAsset == "" {
+		return nil, repository.ErrNotFound
+	}
+
+	page := &BalancePage{Wallets: wallets}
+	if len(wallets) > limit {
+		page.Wallets = wallets[:limit]
+		page.NextCursor = wallets[limit-1].AssetSymbol
+	}
+
+	return page, nil
+}
+
+// GetAccountEquity values every wallet of the account in quote at the last
+// trade price of its ASSET_QUOTE pair; quote itself is valued at one. Assets
+// that never traded against quote are returned without a price and left out
+// of the total. Like GetAccountBalance it fails with repository.ErrNotFound
+// when the account has no wallets.
+func (u *accountUseCase) GetAccountEquity(accountID uuid.UUID, quote string) (*Equity, error) {
+	quote, err := entity.NormalizeAssetSymbol(quote)
+	if err != nil {
+		return nil, err
+	}
+
+	u.log.Infow("valuing account equity", "account_id", accountID, "quote", quote)
+
+	wallets, err := u.GetAccountBalance(accountID)
+	if err != nil {
+		return nil, err
+	}
+
+	equity := &Equity{AccountID: accountID, Quote: quote, Total: decimal.Zero}
+	for _, wallet := range wallets {
+		valuation := &AssetValuation{AssetSymbol: wallet.AssetSymbol, Balance: wallet.Balance}
+		equity.Assets = append(equity.Assets, valuation)
+
+		price := decimal.NewFromInt(1)
+		if wallet.AssetSymbol != quote {
+			trades, err := u.tradeRepository.GetByInstrumentPair(wallet.AssetSymbol+"_"+quote, 1)
+			if err != nil {
+				return nil, err
+			}
+			if len(trades) == 0 {
+				continue
+			}
+			price = trades[0].Price
+		}
+
+		value := wallet.Balance.Mul(price)
+		valuation.Price = &price
+		valuation.Value = &value
+		equity.Total = equity.Total.Add(value)
+	}
+
+	return equity, nil
+}
+
+// GetAssetBalance returns the account's wallet for a single asset.
+func (u *accountUseCase) GetAssetBalance(accountID uuid.UUID, assetSymbol string) (*entity.Wallet, error) {
+	u.log.Infow("fetching asset balance", "account_id", accountID, "asset", assetSymbol)
+
+	return u.walletRepository.GetByAccountAndAsset(u.db, accountID, assetSymbol)
+}
+
+// GetReservedBalances returns how much of each asset the account's open and
+// partially filled orders hold, keyed by asset. An asset missing from the
+// map has nothing reserved, so its whole balance is available.
+func (u *accountUseCase) GetReservedBalances(accountID uuid.UUID) (map[string]decimal.Decimal, error) {
+	u.log.Infow("fetching reserved balances", "account_id", accountID)
+
+	return u.orderRepository.GetReservedAmounts(accountID)
+}
+
+// AdjustBalance credits (positive amount) or debits (negative amount) the
+// account's wallet for assetSymbol under a row lock and records the change
+// in the event log with the adjustment reason. A credit to an asset the
+// account holds no wallet for creates the wallet, as a deposit would. A
+// debit that would take the balance below zero, or below what the account's
+// resting orders reserve of the asset, fails with
+// entity.ErrInsufficientBalance.
+func (u *accountUseCase) AdjustBalance(ctx context.Context, accountID uuid.UUID, assetSymbol string, amount decimal.Decimal) (*entity.Wallet, error) {
+	u.log.Infow("adjusting wallet balance", "account_id", accountID, "asset", assetSymbol, "amount", amount)
+
+	if amount.IsZero() {
+		return nil, entity.ErrInvalidAdjustment
+	}
+
+	tx, err := beginEventTx(ctx, u.db)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback()
+		}
+	}()
+
+	wallet, err := u.walletRepository.GetByAccountAndAssetForUpdate(tx, accountID, assetSymbol)
+	if errors.Is(err, repository.ErrNotFound) && amount.IsPositive() {
+		wallet, err = u.createWallet(tx, accountID, assetSymbol)
+	}
+	if err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	balance := wallet.Balance.Add(amount)
+	if balance.IsNegative() {
+		tx.Rollback()
+		return nil, entity.ErrInsufficientBalance
+	}
+
+	// A debit may only take what the account's resting orders do not hold.
+	if amount.IsNegative() {
+		reserved, err := u.orderRepository.GetReservedAmount(tx, accountID, wallet.AssetSymbol)
+		if err != nil {
+			tx.Rollback()
+			return nil, err
+		}
+		if entity.DecimalLess(balance, reserved) {
+			u.log.Errorw("adjustment would take reserved balance",
+				"account_id", accountID,
+				"asset", wallet.AssetSymbol,
+				"reserved", reserved)
+			tx.Rollback()
+			return nil, entity.ErrInsufficientBalance
+		}
+	}
+
+	if amount.IsPositive() {
+		err = u.walletRepository.AddToBalance(tx, accountID, assetSymbol, amount)
+	} else {
+		err = u.walletRepository.SubtractFromBalance(tx, accountID, assetSymbol, amount.Neg())
+	}
+	if err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	wallet.Balance = balance
+
+	adjustment := entity.WalletAdjustment{
+		AccountID:   accountID,
+		AssetSymbol: assetSymbol,
+		Amount:      amount,
+		Balance:     balance,
+		Reason:      entity.AdjustmentReason,
+	}
+	if err := appendEvent(u.eventRepository, tx, entity.EventTypeWalletAdjusted, wallet.ID, adjustment); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	if err := commitEventTx(u.eventRepository, tx); err != nil {
+		return nil, err
+	}
+
+	return wallet, nil
+}
+
+// createWallet creates the account's empty wallet for assetSymbol and
+// returns it locked. The account must exist, so a mistyped id fails with
+// repository.ErrNotFound rather than leaving an orphan wallet.
+func (u *accountUseCase) createWallet(tx *gorm.DB, accountID uuid.UUID, assetSymbol string) (*entity.Wallet, error) {
+	if _, err := u.accountRepository.GetByID(tx, accountID); err != nil {
+		return nil, err
+	}
+
+	created, err := u.walletRepository.CreateIfNotExists(tx, &entity.Wallet{
+		AccountID:   accountID,
+		AssetSymbol: assetSymbol,
+		Balance:     decimal.Zero,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if created {
+		u.log.Infow("created missing wallet to receive adjustment", "account_id", accountID, "asset", assetSymbol)
+	}
+
+	return u.walletRepository.GetByAccountAndAssetForUpdate(tx, accountID, assetSymbol)
+}
+
+// GetAccountBalanceAt returns the account's balances from the latest
+// snapshot taken at or before at.
+func (u *accountUseCase) GetAccountBalanceAt(accountID uuid.UUID, at time.Time) ([]*entity.WalletSnapshot, error) {
+	u.log.Infow("fetching account balance snapshot", "account_id", accountID, "at", at)
+
+	snapshots, err := u.walletRepository.GetSnapshotsAt(accountID, at.UTC())
+	if err != nil {
+		return nil, err
+	}
+
+	if len(snapshots) == 0 {
+		return nil, repository.ErrNotFound
+	}
+
+	return snapshots, nil
+}
+
+// SnapshotBalances records every wallet's current balance as of takenAt.
+func (u *accountUseCase) SnapshotBalances(takenAt time.Time) error {
+	count, err := u.walletRepository.Snapshot(takenAt.UTC())
+	if err != nil {
+		u.log.Errorw("failed to snapshot balances", "taken_at", takenAt, "error", err)
+		return err
+	}
+
+	u.log.Infow("snapshotted balances", "taken_at", takenAt, "wallets", count)
+	return nil
+}
+
+// DeleteAccount soft-deletes an account together with its wallets. Accounts
+// holding funds or resting orders must be emptied first.
+func (u *accountUseCase) DeleteAccount(ctx context.Context, accountID uuid.UUID) error {
+	u.log.Infow("deleting account", "account_id", accountID)
+
+	tx, err := beginEventTx(ctx, u.db)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback()
+		}
+	}()
+
+	account, err := u.accountRepository.GetByID(tx, accountID)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	// The wallets stay locked until the deletion commits, so a settlement
+	// cannot credit one after it was found empty.
+	wallets, err := u.walletRepository.GetByAccountIDForUpdate(tx, accountID)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	for _, wallet := range wallets {
+		if !entity.DecimalEqual(wallet.Balance, decimal.Zero) {
+			tx.Rollback()
+			return entity.ErrAccountHasBalance
+		}
+	}
+
+	openOrders, err := u.orderRepository.CountOpenByAccountID(tx, accountID)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	if openOrders > 0 {
+		tx.Rollback()
+		return entity.ErrAccountHasOpenOrders
+	}
+
+	if err := u.walletRepository.SoftDeleteByAccountID(tx, accountID); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	if err := u.accountRepository.SoftDelete(tx, accountID); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	if err := appendEvent(u.eventRepository, tx, entity.EventTypeAccountDeleted, account.ID, account); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return commitEventTx(u.eventRepository, tx)
+}
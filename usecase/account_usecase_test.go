@@ -1,8 +1,10 @@
 package usecase
 
 import (
+	"context"
 	"errors"
 	"testing"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/lucas-moura1/mercadobitcoin-challenge/entity"
@@ -22,6 +24,7 @@ func TestAccountUseCase_GetAccountBalance(t *testing.T) {
 		wantLen     int
 		wantNilResp bool
 		wantErr     bool
+		errIs       error
 	}{
 		{
 			name: "success with wallets",
@@ -36,13 +39,14 @@ func TestAccountUseCase_GetAccountBalance(t *testing.T) {
 			wantErr:     false,
 		},
 		{
-			name: "empty slice returns nil and no error",
+			name: "no wallets returns ErrNotFound",
 			setupMock: func(m *repository.MockWalletRepository) {
 				m.EXPECT().GetByAccountID(accountID).Return(nil, nil)
 			},
 			wantLen:     0,
 			wantNilResp: true,
-			wantErr:     false,
+			wantErr:     true,
+			errIs:       repository.ErrNotFound,
 		},
 		{
 			name: "repository error",
@@ -63,11 +67,14 @@ func TestAccountUseCase_GetAccountBalance(t *testing.T) {
 			mockWalletRepo := repository.NewMockWalletRepository(ctrl)
 
 			tt.setupMock(mockWalletRepo)
-			uc := NewAccountUseCase(zap.NewNop().Sugar(), mockWalletRepo)
+			uc := NewAccountUseCase(zap.NewNop().Sugar(), nil, mockWalletRepo, nil, nil, nil, nil)
 			got, err := uc.GetAccountBalance(accountID)
 
 			if tt.wantErr {
 				assert.Error(t, err)
+				if tt.errIs != nil {
+					assert.ErrorIs(t, err, tt.errIs)
+				}
 				assert.Nil(t, got)
 				return
 			}
@@ -83,3 +90,428 @@ func TestAccountUseCase_GetAccountBalance(t *testing.T) {
 		})
 	}
 }
+
+func TestAccountUseCase_GetAccountEquity(t *testing.T) {
+	accountID := uuid.New()
+	ctrl := gomock.NewController(t)
+	walletRepo := repository.NewMockWalletRepository(ctrl)
+	tradeRepo := repository.NewMockTradeRepository(ctrl)
+
+	walletRepo.EXPECT().GetByAccountID(accountID).Return([]*entity.Wallet{
+		{AccountID: accountID, AssetSymbol: "BRL", Balance: decimal.RequireFromString("1000")},
+		{AccountID: accountID, AssetSymbol: "BTC", Balance: decimal.RequireFromString("0.5")},
+		{AccountID: accountID, AssetSymbol: "DOGE", Balance: decimal.RequireFromString("300")},
+	}, nil)
+	tradeRepo.EXPECT().GetByInstrumentPair("BTC_BRL", 1).
+		Return([]*entity.Trade{{InstrumentPair: "BTC_BRL", Price: decimal.RequireFromString("200000")}}, nil)
+	tradeRepo.EXPECT().GetByInstrumentPair("DOGE_BRL", 1).Return(nil, nil)
+
+	uc := NewAccountUseCase(zap.NewNop().Sugar(), nil, walletRepo, nil, tradeRepo, nil, nil)
+	equity, err := uc.GetAccountEquity(accountID, "brl")
+
+	assert.NoError(t, err)
+	assert.Equal(t, "BRL", equity.Quote)
+	assert.Len(t, equity.Assets, 3)
+
+	brl, btc, doge := equity.Assets[0], equity.Assets[1], equity.Assets[2]
+	assertDecimalEqual(t, "1", brl.Price.String())
+	assertDecimalEqual(t, "1000", brl.Value.String())
+	assertDecimalEqual(t, "200000", btc.Price.String())
+	assertDecimalEqual(t, "100000", btc.Value.String())
+	assert.Nil(t, doge.Price)
+	assert.Nil(t, doge.Value)
+	assertDecimalEqual(t, "300", doge.Balance.String())
+	assertDecimalEqual(t, "101000", equity.Total.String())
+}
+
+func TestAccountUseCase_GetAccountEquity_InvalidQuote(t *testing.T) {
+	uc := NewAccountUseCase(zap.NewNop().Sugar(), nil, nil, nil, nil, nil, nil)
+
+	_, err := uc.GetAccountEquity(uuid.New(), "")
+
+	assert.ErrorIs(t, err, entity.ErrInvalidAssetSymbol)
+}
+
+func TestAccountUseCase_GetReservedBalances(t *testing.T) {
+	log := zap.NewNop().Sugar()
+	db := newMigratedDB(t)
+	walletRepo := repository.NewWalletRepository(log, db, nil)
+	orderRepo := repository.NewOrderRepository(log, db)
+	orderUC := NewOrderUseCase(log, orderRepo, walletRepo, repository.NewTradeRepository(log, db), repository.NewEventRepository(log, db), nil, db, OrderUseCaseConfig{})
+	uc := NewAccountUseCase(log, nil, walletRepo, orderRepo, nil, nil, db)
+
+	accountID := uuid.New()
+	for asset, balance := range map[string]string{"BRL": "1000", "BTC": "2"} {
+		assert.NoError(t, walletRepo.Create(nil, &entity.Wallet{AccountID: accountID, AssetSymbol: asset, Balance: decimal.RequireFromString(balance)}))
+	}
+
+	assert.NoError(t, orderUC.CreateOrder(context.Background(), &entity.Order{
+		AccountID:      accountID,
+		InstrumentPair: "BTC_BRL",
+		OrderType:      string(entity.OrderTypeBuy),
+		Price:          decimal.RequireFromString("100"),
+		Quantity:       decimal.RequireFromString("0.5"),
+	}))
+	assert.NoError(t, orderUC.CreateOrder(context.Background(), &entity.Order{
+		AccountID:      accountID,
+		InstrumentPair: "BTC_BRL",
+		OrderType:      string(entity.OrderTypeBuy),
+		Price:          decimal.RequireFromString("90"),
+		Quantity:       decimal.RequireFromString("1"),
+	}))
+
+	reserved, err := uc.GetReservedBalances(accountID)
+	assert.NoError(t, err)
+	assert.Len(t, reserved, 1)
+	assertDecimalEqual(t, "140", reserved["BRL"].String())
+
+	wallet, err := uc.GetAssetBalance(accountID, "BRL")
+	assert.NoError(t, err)
+	available := wallet.Balance.Sub(reserved["BRL"])
+	assertDecimalEqual(t, "860", available.String())
+	assertDecimalEqual(t, wallet.Balance.String(), available.Add(reserved["BRL"]).String())
+}
+
+func TestAccountUseCase_DeleteAccount(t *testing.T) {
+	tests := []struct {
+		name      string
+		balance   string
+		openOrder bool
+		noAccount bool
+		wantErr   error
+	}{
+		{name: "deletes empty account", balance: "0"},
+		{name: "nonzero balance", balance: "0.00000001", wantErr: entity.ErrAccountHasBalance},
+		{name: "open order", balance: "0", openOrder: true, wantErr: entity.ErrAccountHasOpenOrders},
+		{name: "unknown account", noAccount: true, wantErr: repository.ErrNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			log := zap.NewNop().Sugar()
+			db := newMigratedDB(t)
+			accountRepo := repository.NewAccountRepository(log, db)
+			walletRepo := repository.NewWalletRepository(log, db, nil)
+			orderRepo := repository.NewOrderRepository(log, db)
+			eventRepo := repository.NewEventRepository(log, db)
+			uc := NewAccountUseCase(log, accountRepo, walletRepo, orderRepo, nil, eventRepo, db)
+
+			account := &entity.Account{Name: "Alice"}
+			if !tt.noAccount {
+				assert.NoError(t, accountRepo.Create(account))
+				assert.NoError(t, walletRepo.Create(nil, &entity.Wallet{
+					AccountID:   account.ID,
+					AssetSymbol: "BRL",
+					Balance:     decimal.RequireFromString(tt.balance),
+				}))
+			} else {
+				account.ID = uuid.New()
+			}
+			if tt.openOrder {
+				assert.NoError(t, orderRepo.Create(nil, &entity.Order{
+					AccountID:      account.ID,
+					InstrumentPair: "BTC_BRL",
+					OrderType:      string(entity.OrderTypeBuy),
+					Status:         string(entity.OrderStatusPartial),
+				}))
+			}
+
+			err := uc.DeleteAccount(context.Background(), account.ID)
+
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				if !tt.noAccount {
+					_, err := accountRepo.GetByID(nil, account.ID)
+					assert.NoError(t, err)
+				}
+				return
+			}
+			assert.NoError(t, err)
+
+			_, err = accountRepo.GetByID(nil, account.ID)
+			assert.ErrorIs(t, err, repository.ErrNotFound)
+
+			_, err = uc.GetAccountBalance(account.ID)
+			assert.ErrorIs(t, err, repository.ErrNotFound)
+
+			_, err = walletRepo.GetByAccountAndAsset(db, account.ID, "BRL")
+			assert.ErrorIs(t, err, repository.ErrNotFound)
+
+			events, err := eventRepo.GetSince(0, 10)
+			assert.NoError(t, err)
+			if assert.Len(t, events, 1) {
+				assert.Equal(t, string(entity.EventTypeAccountDeleted), events[0].EventType)
+				assert.Equal(t, account.ID, events[0].AggregateID)
+			}
+		})
+	}
+}
+
+// TestWalletRepository_GetByAccountIDForUpdate_BlocksCredits checks that the
+// wallets DeleteAccount found empty cannot be credited before it commits.
+func TestWalletRepository_GetByAccountIDForUpdate_BlocksCredits(t *testing.T) {
+	db := newPostgresDB(t)
+	walletRepo := repository.NewWalletRepository(zap.NewNop().Sugar(), db, nil)
+
+	accountID := uuid.New()
+	assert.NoError(t, walletRepo.Create(nil, &entity.Wallet{AccountID: accountID, AssetSymbol: "BRL", Balance: decimal.Zero}))
+
+	tx := db.Begin()
+	wallets, err := walletRepo.GetByAccountIDForUpdate(tx, accountID)
+	assert.NoError(t, err)
+	assert.Len(t, wallets, 1)
+
+	credited := make(chan error, 1)
+	go func() {
+		credited <- walletRepo.AddToBalance(nil, accountID, "BRL", decimal.RequireFromString("1"))
+	}()
+
+	select {
+	case <-credited:
+		t.Fatal("credit committed while the wallets were locked")
+	case <-time.After(200 * time.Millisecond):
+	}
+
+	assert.NoError(t, tx.Commit().Error)
+	assert.NoError(t, <-credited)
+}
+
+func TestAccountUseCase_GetAccountBalanceAt(t *testing.T) {
+	log := zap.NewNop().Sugar()
+	db := newMigratedDB(t)
+	accountRepo := repository.NewAccountRepository(log, db)
+	walletRepo := repository.NewWalletRepository(log, db, nil)
+	uc := NewAccountUseCase(log, accountRepo, walletRepo, repository.NewOrderRepository(log, db), nil, nil, db)
+
+	account := &entity.Account{Name: "Alice"}
+	other := &entity.Account{Name: "Bob"}
+	assert.NoError(t, accountRepo.Create(account))
+	assert.NoError(t, accountRepo.Create(other))
+	assert.NoError(t, walletRepo.Create(nil, &entity.Wallet{AccountID: account.ID, AssetSymbol: "BRL", Balance: decimal.RequireFromString("100")}))
+	assert.NoError(t, walletRepo.Create(nil, &entity.Wallet{AccountID: other.ID, AssetSymbol: "BRL", Balance: decimal.RequireFromString("5")}))
+
+	day1 := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	day2 := day1.Add(24 * time.Hour)
+	assert.NoError(t, uc.SnapshotBalances(day1))
+	assert.NoError(t, walletRepo.AddToBalance(nil, account.ID, "BRL", decimal.RequireFromString("50")))
+	assert.NoError(t, walletRepo.Create(nil, &entity.Wallet{AccountID: account.ID, AssetSymbol: "BTC", Balance: decimal.RequireFromString("1")}))
+	assert.NoError(t, uc.SnapshotBalances(day2))
+
+	tests := []struct {
+		name    string
+		at      time.Time
+		want    map[string]string
+		wantErr error
+	}{
+		{name: "before first snapshot", at: day1.Add(-time.Second), wantErr: repository.ErrNotFound},
+		{name: "exactly at first snapshot", at: day1, want: map[string]string{"BRL": "100"}},
+		{name: "between snapshots", at: day1.Add(12 * time.Hour), want: map[string]string{"BRL": "100"}},
+		{name: "after latest snapshot", at: day2.Add(time.Hour), want: map[string]string{"BRL": "150", "BTC": "1"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			snapshots, err := uc.GetAccountBalanceAt(account.ID, tt.at)
+
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+
+			got := make(map[string]string, len(snapshots))
+			for _, s := range snapshots {
+				assert.Equal(t, account.ID, s.AccountID)
+				got[s.AssetSymbol] = s.Balance.String()
+			}
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestWalletRepository_CreateIfNotExists(t *testing.T) {
+	log := zap.NewNop().Sugar()
+	db := newMigratedDB(t)
+	walletRepo := repository.NewWalletRepository(log, db, nil)
+	accountID := uuid.New()
+
+	created, err := walletRepo.CreateIfNotExists(nil, &entity.Wallet{AccountID: accountID, AssetSymbol: "BRL", Balance: decimal.RequireFromString("100")})
+	assert.NoError(t, err)
+	assert.True(t, created, "first create inserts the wallet")
+
+	created, err = walletRepo.CreateIfNotExists(nil, &entity.Wallet{AccountID: accountID, AssetSymbol: "BRL", Balance: decimal.RequireFromString("5")})
+	assert.NoError(t, err)
+	assert.False(t, created, "repeat create reports the existing wallet")
+
+	created, err = walletRepo.CreateIfNotExists(nil, &entity.Wallet{AccountID: accountID, AssetSymbol: "BTC", Balance: decimal.Zero})
+	assert.NoError(t, err)
+	assert.True(t, created, "another asset is a new wallet")
+
+	wallet, err := walletRepo.GetByAccountAndAsset(db, accountID, "BRL")
+	if assert.NoError(t, err) {
+		assert.Equal(t, "100", wallet.Balance.String(), "repeat create must not overwrite the balance")
+	}
+}
+
+func TestAccountUseCase_GetAccountBalancePage(t *testing.T) {
+	log := zap.NewNop().Sugar()
+	db := newMigratedDB(t)
+	walletRepo := repository.NewWalletRepository(log, db, nil)
+	uc := NewAccountUseCase(log, nil, walletRepo, nil, nil, nil, db)
+
+	accountID := uuid.New()
+	assets := []string{"SOL", "BRL", "ADA", "ETH", "BTC", "XRP", "DOT"}
+	for _, asset := range assets {
+		if err := walletRepo.Create(nil, &entity.Wallet{AccountID: accountID, AssetSymbol: asset, Balance: decimal.RequireFromString("1")}); err != nil {
+			t.Fatalf("failed to seed wallet: %v", err)
+		}
+	}
+	// Another account's wallets never show up.
+	if err := walletRepo.Create(nil, &entity.Wallet{AccountID: uuid.New(), AssetSymbol: "BTC", Balance: decimal.Zero}); err != nil {
+		t.Fatalf("failed to seed wallet: %v", err)
+	}
+
+	var seen []string
+	cursor := ""
+	for pages := 0; ; pages++ {
+		if pages > len(assets) {
+			t.Fatal("pagination did not terminate")
+		}
+
+		page, err := uc.GetAccountBalancePage(accountID, cursor, 3)
+		if !assert.NoError(t, err) {
+			return
+		}
+		assert.LessOrEqual(t, len(page.Wallets), 3)
+		for _, wallet := range page.Wallets {
+			seen = append(seen, wallet.AssetSymbol)
+		}
+
+		if page.NextCursor == "" {
+			break
+		}
+		cursor = page.NextCursor
+	}
+
+	assert.Equal(t, []string{"ADA", "BRL", "BTC", "DOT", "ETH", "SOL", "XRP"}, seen, "every wallet exactly once, in asset order")
+
+	past, err := uc.GetAccountBalancePage(accountID, "XRP", 3)
+	assert.NoError(t, err)
+	assert.Empty(t, past.Wallets)
+	assert.Empty(t, past.NextCursor)
+
+	_, err = uc.GetAccountBalancePage(uuid.New(), "", 3)
+	assert.ErrorIs(t, err, repository.ErrNotFound)
+}
+
+func TestAccountUseCase_AdjustBalance(t *testing.T) {
+	log := zap.NewNop().Sugar()
+	db := newMigratedDB(t)
+	accountRepo := repository.NewAccountRepository(log, db)
+	walletRepo := repository.NewWalletRepository(log, db, nil)
+	eventRepo := repository.NewEventRepository(log, db)
+	uc := NewAccountUseCase(log, accountRepo, walletRepo, repository.NewOrderRepository(log, db), nil, eventRepo, db)
+
+	account := &entity.Account{Name: "Alice"}
+	assert.NoError(t, accountRepo.Create(account))
+	accountID := account.ID
+	assert.NoError(t, walletRepo.Create(nil, &entity.Wallet{AccountID: accountID, AssetSymbol: "BRL", Balance: decimal.RequireFromString("100")}))
+
+	balanceOf := func() decimal.Decimal {
+		wallet, err := walletRepo.GetByAccountAndAsset(db, accountID, "BRL")
+		assert.NoError(t, err)
+		return wallet.Balance
+	}
+
+	t.Run("credit", func(t *testing.T) {
+		wallet, err := uc.AdjustBalance(context.Background(), accountID, "BRL", decimal.RequireFromString("25.5"))
+		assert.NoError(t, err)
+		assert.True(t, entity.DecimalEqual(decimal.RequireFromString("125.5"), wallet.Balance), wallet.Balance.String())
+		assert.True(t, entity.DecimalEqual(decimal.RequireFromString("125.5"), balanceOf()))
+	})
+
+	t.Run("debit", func(t *testing.T) {
+		wallet, err := uc.AdjustBalance(context.Background(), accountID, "BRL", decimal.RequireFromString("-125.5"))
+		assert.NoError(t, err)
+		assert.True(t, wallet.Balance.IsZero(), wallet.Balance.String())
+		assert.True(t, balanceOf().IsZero())
+	})
+
+	t.Run("over-debit is rejected", func(t *testing.T) {
+		_, err := uc.AdjustBalance(context.Background(), accountID, "BRL", decimal.RequireFromString("-0.01"))
+		assert.ErrorIs(t, err, entity.ErrInsufficientBalance)
+		assert.True(t, balanceOf().IsZero())
+	})
+
+	t.Run("zero adjustments and debits of missing wallets are rejected", func(t *testing.T) {
+		_, err := uc.AdjustBalance(context.Background(), accountID, "BRL", decimal.Zero)
+		assert.ErrorIs(t, err, entity.ErrInvalidAdjustment)
+
+		_, err = uc.AdjustBalance(context.Background(), accountID, "ETH", decimal.RequireFromString("-1"))
+		assert.ErrorIs(t, err, repository.ErrNotFound)
+	})
+
+	t.Run("credit creates a missing wallet", func(t *testing.T) {
+		wallet, err := uc.AdjustBalance(context.Background(), accountID, "BTC", decimal.RequireFromString("0.5"))
+		assert.NoError(t, err)
+		assertDecimalEqual(t, "0.5", wallet.Balance.String())
+
+		stored, err := walletRepo.GetByAccountAndAsset(db, accountID, "BTC")
+		if assert.NoError(t, err) {
+			assertDecimalEqual(t, "0.5", stored.Balance.String())
+		}
+	})
+
+	t.Run("credit to an unknown account is rejected", func(t *testing.T) {
+		_, err := uc.AdjustBalance(context.Background(), uuid.New(), "BTC", decimal.RequireFromString("1"))
+		assert.ErrorIs(t, err, repository.ErrNotFound)
+	})
+
+	// Only the three applied adjustments are in the event log.
+	events, err := eventRepo.GetSince(0, 10)
+	assert.NoError(t, err)
+	if assert.Len(t, events, 3) {
+		assert.Equal(t, string(entity.EventTypeWalletAdjusted), events[0].EventType)
+		assert.JSONEq(t, `{"account_id":"`+accountID.String()+`","asset_symbol":"BRL","amount":"25.5","balance":"125.5","reason":"adjustment"}`, string(events[0].Payload))
+		assert.JSONEq(t, `{"account_id":"`+accountID.String()+`","asset_symbol":"BRL","amount":"-125.5","balance":"0","reason":"adjustment"}`, string(events[1].Payload))
+		assert.JSONEq(t, `{"account_id":"`+accountID.String()+`","asset_symbol":"BTC","amount":"0.5","balance":"0.5","reason":"adjustment"}`, string(events[2].Payload))
+	}
+}
+
+func TestAccountUseCase_AdjustBalance_KeepsReservedBalance(t *testing.T) {
+	log := zap.NewNop().Sugar()
+	db := newMigratedDB(t)
+	walletRepo := repository.NewWalletRepository(log, db, nil)
+	orderRepo := repository.NewOrderRepository(log, db)
+	uc := NewAccountUseCase(log, nil, walletRepo, orderRepo, nil, repository.NewEventRepository(log, db), db)
+
+	accountID := uuid.New()
+	assert.NoError(t, walletRepo.Create(nil, &entity.Wallet{AccountID: accountID, AssetSymbol: "BRL", Balance: decimal.RequireFromString("100")}))
+
+	// A resting buy holds 40 of the 100 BRL.
+	order := &entity.Order{
+		AccountID:         accountID,
+		InstrumentPair:    "BTC_BRL",
+		OrderType:         string(entity.OrderTypeBuy),
+		Price:             decimal.RequireFromString("40"),
+		Quantity:          decimal.RequireFromString("1"),
+		RemainingQuantity: decimal.RequireFromString("1"),
+		Status:            string(entity.OrderStatusOpen),
+		ReservedAsset:     "BRL",
+		ReservedAmount:    decimal.RequireFromString("40"),
+	}
+	assert.NoError(t, orderRepo.Create(nil, order))
+
+	_, err := uc.AdjustBalance(context.Background(), accountID, "BRL", decimal.RequireFromString("-60.01"))
+	assert.ErrorIs(t, err, entity.ErrInsufficientBalance)
+
+	wallet, err := uc.AdjustBalance(context.Background(), accountID, "BRL", decimal.RequireFromString("-60"))
+	assert.NoError(t, err)
+	assertDecimalEqual(t, "40", wallet.Balance.String())
+
+	// Once the order is cancelled its reservation is free to debit.
+	assert.NoError(t, orderRepo.UpdateStatus(nil, order.ID, string(entity.OrderStatusCancelled)))
+	wallet, err = uc.AdjustBalance(context.Background(), accountID, "BRL", decimal.RequireFromString("-40"))
+	assert.NoError(t, err)
+	assert.True(t, wallet.Balance.IsZero(), wallet.Balance.String())
+}
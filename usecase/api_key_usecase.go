@@ -0,0 +1,62 @@
+package usecase
+
+import (
+	"crypto/hmac"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/lucas-moura1/mercadobitcoin-challenge/entity"
+	"github.com/lucas-moura1/mercadobitcoin-challenge/repository"
+	"go.uber.org/zap"
+)
+
+// MaxSignatureAge is how far a signed request's timestamp may drift from the
+// server clock, in either direction, before it is rejected as a replay.
+const MaxSignatureAge = 30 * time.Second
+
+type apiKeyUseCase struct {
+	log              *zap.SugaredLogger
+	apiKeyRepository repository.ApiKeyRepository
+	clock            Clock
+}
+
+func NewApiKeyUseCase(
+	log *zap.SugaredLogger,
+	apiKeyRepo repository.ApiKeyRepository,
+	clock Clock,
+) ApiKeyUseCase {
+	return &apiKeyUseCase{
+		log:              log,
+		apiKeyRepository: apiKeyRepo,
+		clock:            clockOrSystem(clock),
+	}
+}
+
+func (u *apiKeyUseCase) Authenticate(key, timestamp, signature, method, path string, body []byte) (*entity.ApiKey, error) {
+	seconds, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return nil, entity.ErrStaleTimestamp
+	}
+	age := u.clock.Now().Sub(time.Unix(seconds, 0))
+	if age > MaxSignatureAge || age < -MaxSignatureAge {
+		u.log.Warnw("rejected stale signed request", "key", key, "timestamp", timestamp)
+		return nil, entity.ErrStaleTimestamp
+	}
+
+	apiKey, err := u.apiKeyRepository.GetByKey(key)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, entity.ErrInvalidSignature
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	expected := entity.SignRequest(apiKey.Secret, timestamp, method, path, body)
+	if !hmac.Equal([]byte(expected), []byte(signature)) {
+		u.log.Warnw("rejected request with bad signature", "key", key)
+		return nil, entity.ErrInvalidSignature
+	}
+
+	return apiKey, nil
+}
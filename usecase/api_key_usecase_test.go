@@ -0,0 +1,138 @@
+package usecase
+
+import (
+	"errors"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lucas-moura1/mercadobitcoin-challenge/entity"
+	"github.com/lucas-moura1/mercadobitcoin-challenge/repository"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+	"go.uber.org/zap"
+)
+
+func TestApiKeyUseCase_Authenticate(t *testing.T) {
+	now := time.Unix(1_700_000_000, 0)
+	apiKey := &entity.ApiKey{AccountID: uuid.New(), Key: "key", Secret: "secret"}
+	body := []byte(`{"price":"100"}`)
+	fresh := strconv.FormatInt(now.Unix(), 10)
+	stale := strconv.FormatInt(now.Add(-MaxSignatureAge-time.Second).Unix(), 10)
+
+	tests := []struct {
+		name      string
+		timestamp string
+		signature string
+		body      []byte
+		setupMock func(m *repository.MockApiKeyRepository)
+		wantErr   error
+	}{
+		{
+			name:      "valid signature",
+			timestamp: fresh,
+			signature: entity.SignRequest("secret", fresh, "POST", "/orders", body),
+			body:      body,
+			setupMock: func(m *repository.MockApiKeyRepository) {
+				m.EXPECT().GetByKey("key").Return(apiKey, nil).Times(1)
+			},
+		},
+		{
+			name:      "replayed request with stale timestamp",
+			timestamp: stale,
+			signature: entity.SignRequest("secret", stale, "POST", "/orders", body),
+			body:      body,
+			setupMock: func(m *repository.MockApiKeyRepository) {},
+			wantErr:   entity.ErrStaleTimestamp,
+		},
+		{
+			name:      "malformed timestamp",
+			timestamp: "yesterday",
+			signature: entity.SignRequest("secret", "yesterday", "POST", "/orders", body),
+			body:      body,
+			setupMock: func(m *repository.MockApiKeyRepository) {},
+			wantErr:   entity.ErrStaleTimestamp,
+		},
+		{
+			name:      "tampered body",
+			timestamp: fresh,
+			signature: entity.SignRequest("secret", fresh, "POST", "/orders", body),
+			body:      []byte(`{"price":"1"}`),
+			setupMock: func(m *repository.MockApiKeyRepository) {
+				m.EXPECT().GetByKey("key").Return(apiKey, nil).Times(1)
+			},
+			wantErr: entity.ErrInvalidSignature,
+		},
+		{
+			name:      "unknown key",
+			timestamp: fresh,
+			signature: entity.SignRequest("secret", fresh, "POST", "/orders", body),
+			body:      body,
+			setupMock: func(m *repository.MockApiKeyRepository) {
+				m.EXPECT().GetByKey("key").Return(nil, repository.ErrNotFound).Times(1)
+			},
+			wantErr: entity.ErrInvalidSignature,
+		},
+		{
+			name:      "repository error",
+			timestamp: fresh,
+			signature: entity.SignRequest("secret", fresh, "POST", "/orders", body),
+			body:      body,
+			setupMock: func(m *repository.MockApiKeyRepository) {
+				m.EXPECT().GetByKey("key").Return(nil, errors.New("db error")).Times(1)
+			},
+			wantErr: errors.New("db error"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			apiKeyRepo := repository.NewMockApiKeyRepository(ctrl)
+			tt.setupMock(apiKeyRepo)
+
+			uc := NewApiKeyUseCase(zap.NewNop().Sugar(), apiKeyRepo, newFakeClock(now))
+
+			got, err := uc.Authenticate("key", tt.timestamp, tt.signature, "POST", "/orders", tt.body)
+
+			if tt.wantErr != nil {
+				assert.EqualError(t, err, tt.wantErr.Error())
+				assert.Nil(t, got)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, apiKey.AccountID, got.AccountID)
+		})
+	}
+}
+
+func TestApiKeyUseCase_Authenticate_Expiry(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	signedAt := time.Unix(1_700_000_000, 0)
+	apiKey := &entity.ApiKey{AccountID: uuid.New(), Key: "key", Secret: "secret"}
+	apiKeyRepo := repository.NewMockApiKeyRepository(ctrl)
+	apiKeyRepo.EXPECT().GetByKey("key").Return(apiKey, nil).AnyTimes()
+
+	clock := newFakeClock(signedAt)
+	uc := NewApiKeyUseCase(zap.NewNop().Sugar(), apiKeyRepo, clock)
+
+	timestamp := strconv.FormatInt(signedAt.Unix(), 10)
+	signature := entity.SignRequest("secret", timestamp, "DELETE", "/accounts/1", nil)
+	authenticate := func() error {
+		_, err := uc.Authenticate("key", timestamp, signature, "DELETE", "/accounts/1", nil)
+		return err
+	}
+
+	assert.NoError(t, authenticate())
+
+	clock.Advance(MaxSignatureAge)
+	assert.NoError(t, authenticate(), "still valid at exactly the maximum age")
+
+	clock.Advance(time.Second)
+	assert.ErrorIs(t, authenticate(), entity.ErrStaleTimestamp)
+}
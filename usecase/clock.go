@@ -0,0 +1,26 @@
+package usecase
+
+import "time"
+
+// Clock is where time-dependent use case logic reads the current time from,
+// so tests can control it instead of waiting on the wall clock.
+type Clock interface {
+	Now() time.Time
+}
+
+type systemClock struct{}
+
+func (systemClock) Now() time.Time {
+	return time.Now().UTC()
+}
+
+// SystemClock reads the real wall clock. Use cases fall back to it when no
+// clock is injected.
+var SystemClock Clock = systemClock{}
+
+func clockOrSystem(clock Clock) Clock {
+	if clock == nil {
+		return SystemClock
+	}
+	return clock
+}
@@ -0,0 +1,122 @@
+package usecase
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/google/uuid"
+	"github.com/lucas-moura1/mercadobitcoin-challenge/entity"
+	"github.com/lucas-moura1/mercadobitcoin-challenge/repository"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+const (
+	DefaultEventsLimit = 100
+	MaxEventsLimit     = 1000
+)
+
+type eventUseCase struct {
+	log             *zap.SugaredLogger
+	eventRepository repository.EventRepository
+}
+
+func NewEventUseCase(
+	log *zap.SugaredLogger,
+	eventRepo repository.EventRepository,
+) EventUseCase {
+	return &eventUseCase{
+		log:             log,
+		eventRepository: eventRepo,
+	}
+}
+
+func (u *eventUseCase) GetEventsSince(sequence int64, limit int) ([]*entity.Event, error) {
+	u.log.Infow("fetching events", "since", sequence, "limit", limit)
+
+	if limit <= 0 {
+		limit = DefaultEventsLimit
+	}
+	if limit > MaxEventsLimit {
+		limit = MaxEventsLimit
+	}
+
+	return u.eventRepository.GetSince(sequence, limit)
+}
+
+// pendingEventsKey keys the events a transaction begun by beginEventTx has
+// appended but not yet written.
+type pendingEventsKey struct{}
+
+type pendingEvents struct {
+	events []*entity.Event
+}
+
+// beginEventTx begins a transaction that will append events. Its events are
+// held in memory and only written by commitEventTx, which takes the event
+// append lock right before inserting them and commits. The lock is therefore
+// taken after the transaction's last row lock and held only for the inserts
+// and the commit: writers no longer serialize for their whole transaction,
+// the holder never waits on a row lock, and under REPEATABLE READ or
+// SERIALIZABLE waiting for it cannot turn into a serialization failure.
+// Every statement in the transaction runs with ctx, so cancelling it, as a
+// request timeout does, aborts the running query and rolls the transaction
+// back.
+func beginEventTx(ctx context.Context, db *gorm.DB, opts ...*sql.TxOptions) (*gorm.DB, error) {
+	ctx = context.WithValue(ctx, pendingEventsKey{}, &pendingEvents{})
+	tx := db.WithContext(ctx).Begin(opts...)
+	if tx.Error != nil {
+		return nil, tx.Error
+	}
+	return tx, nil
+}
+
+// commitEventTx writes the events tx has held back under the event append
+// lock and commits. If writing them fails, tx is rolled back.
+func commitEventTx(repo repository.EventRepository, tx *gorm.DB) error {
+	if pending := pendingEventsOf(tx); pending != nil && len(pending.events) > 0 {
+		if err := repository.LockEventAppends(tx); err != nil {
+			tx.Rollback()
+			return err
+		}
+		for _, event := range pending.events {
+			if err := repo.Append(tx, event); err != nil {
+				tx.Rollback()
+				return err
+			}
+		}
+		pending.events = nil
+	}
+	return tx.Commit().Error
+}
+
+func pendingEventsOf(tx *gorm.DB) *pendingEvents {
+	if tx == nil || tx.Statement == nil || tx.Statement.Context == nil {
+		return nil
+	}
+	pending, _ := tx.Statement.Context.Value(pendingEventsKey{}).(*pendingEvents)
+	return pending
+}
+
+// appendEvent records a state change in the event log using the caller's
+// transaction, so the event is committed or rolled back with the change itself.
+// In a transaction begun by beginEventTx the event is held until
+// commitEventTx; in any other it is inserted right away.
+func appendEvent(
+	repo repository.EventRepository,
+	tx *gorm.DB,
+	eventType entity.EventType,
+	aggregateID uuid.UUID,
+	payload any,
+) error {
+	event, err := entity.NewEvent(eventType, aggregateID, payload)
+	if err != nil {
+		return err
+	}
+
+	if pending := pendingEventsOf(tx); pending != nil {
+		pending.events = append(pending.events, event)
+		return nil
+	}
+	return repo.Append(tx, event)
+}
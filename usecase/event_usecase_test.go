@@ -0,0 +1,220 @@
+package usecase
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lucas-moura1/mercadobitcoin-challenge/entity"
+	"github.com/lucas-moura1/mercadobitcoin-challenge/repository"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+func TestEventUseCase_GetEventsSince(t *testing.T) {
+	tests := []struct {
+		name      string
+		since     int64
+		limit     int
+		wantLimit int
+	}{
+		{name: "default limit when zero", since: 0, limit: 0, wantLimit: DefaultEventsLimit},
+		{name: "keeps limit within bounds", since: 10, limit: 50, wantLimit: 50},
+		{name: "caps limit at maximum", since: 10, limit: MaxEventsLimit + 1, wantLimit: MaxEventsLimit},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			eventRepo := repository.NewMockEventRepository(ctrl)
+			eventRepo.EXPECT().
+				GetSince(tt.since, tt.wantLimit).
+				Return([]*entity.Event{{Sequence: tt.since + 1}}, nil).
+				Times(1)
+
+			uc := NewEventUseCase(zap.NewNop().Sugar(), eventRepo)
+			events, err := uc.GetEventsSince(tt.since, tt.limit)
+
+			assert.NoError(t, err)
+			assert.Len(t, events, 1)
+		})
+	}
+}
+
+func TestOrderUseCase_CreateOrder_EventSequence(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	orderRepo := repository.NewMockOrderRepository(ctrl)
+	walletRepo := repository.NewMockWalletRepository(ctrl)
+	tradeRepo := repository.NewMockTradeRepository(ctrl)
+	eventRepo := repository.NewMockEventRepository(ctrl)
+
+	order := &entity.Order{
+		Base:           entity.Base{ID: uuid.New()},
+		AccountID:      uuid.New(),
+		InstrumentPair: "BTC_BRL",
+		OrderType:      string(entity.OrderTypeBuy),
+		Price:          decimal.RequireFromString("100"),
+		Quantity:       decimal.RequireFromString("1"),
+	}
+	maker := &entity.Order{
+		Base:              entity.Base{ID: uuid.New()},
+		AccountID:         uuid.New(),
+		InstrumentPair:    "BTC_BRL",
+		OrderType:         string(entity.OrderTypeSell),
+		Price:             decimal.RequireFromString("100"),
+		Quantity:          decimal.RequireFromString("1"),
+		RemainingQuantity: decimal.RequireFromString("1"),
+		Status:            string(entity.OrderStatusOpen),
+	}
+
+	walletRepo.EXPECT().
+		GetByAccountAndAsset(gomock.Any(), order.AccountID, "BRL").
+		Return(&entity.Wallet{AccountID: order.AccountID, AssetSymbol: "BRL", Balance: decimal.RequireFromString("100")}, nil)
+	orderRepo.EXPECT().GetReservedAmount(gomock.Any(), order.AccountID, "BRL").Return(decimal.Zero, nil)
+	orderRepo.EXPECT().Create(gomock.Any(), order).Return(nil)
+	orderRepo.EXPECT().GetByID(order.ID).Return(order, nil)
+	orderRepo.EXPECT().
+		GetMatchingOrders(gomock.Any(), order.AccountID, "BTC_BRL", "SELL", order.Price, true, DefaultMaxFillsPerOrder+1, gomock.Any(), gomock.Any()).
+		Return([]*entity.Order{maker}, nil)
+	tradeRepo.EXPECT().Create(gomock.Any(), gomock.Any()).Return(nil)
+	orderRepo.EXPECT().UpdateRemainingAndStatus(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(nil).Times(2)
+	walletRepo.EXPECT().SubtractFromBalance(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(nil).Times(2)
+	walletRepo.EXPECT().CreateIfNotExists(gomock.Any(), gomock.Any()).Return(false, nil).Times(2)
+	walletRepo.EXPECT().AddToBalance(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(nil).Times(2)
+
+	var events []*entity.Event
+	eventRepo.EXPECT().
+		Append(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(_ *gorm.DB, e *entity.Event) error {
+			e.Sequence = int64(len(events) + 1)
+			events = append(events, e)
+			return nil
+		}).
+		Times(2)
+
+	uc := NewOrderUseCase(zap.NewNop().Sugar(), orderRepo, walletRepo, tradeRepo, eventRepo, nil, newInMemoryDB(t), OrderUseCaseConfig{})
+	err := uc.CreateOrder(context.Background(), order)
+	assert.NoError(t, err)
+
+	if assert.Len(t, events, 2) {
+		assert.Equal(t, string(entity.EventTypeOrderCreated), events[0].EventType)
+		assert.Equal(t, order.ID, events[0].AggregateID)
+		assert.Equal(t, int64(1), events[0].Sequence)
+
+		assert.Equal(t, string(entity.EventTypeTradeExecuted), events[1].EventType)
+		assert.Equal(t, int64(2), events[1].Sequence)
+
+		var trade entity.Trade
+		assert.NoError(t, json.Unmarshal(events[1].Payload, &trade))
+		assert.Equal(t, order.ID, trade.BuyerOrderID)
+		assert.Equal(t, maker.ID, trade.SellerOrderID)
+		assert.True(t, trade.Quantity.Equal(decimal.RequireFromString("1")))
+	}
+}
+
+func TestEventRepository_Append_WithoutTransaction(t *testing.T) {
+	db := newMigratedDB(t)
+	repo := repository.NewEventRepository(zap.NewNop().Sugar(), db)
+
+	for i := 0; i < 2; i++ {
+		event, err := entity.NewEvent(entity.EventTypeOrderCreated, uuid.New(), map[string]int{"n": i})
+		assert.NoError(t, err)
+		assert.NoError(t, repo.Append(nil, event))
+	}
+
+	events, err := repo.GetSince(0, 10)
+	assert.NoError(t, err)
+	if assert.Len(t, events, 2) {
+		assert.Less(t, events[0].Sequence, events[1].Sequence)
+	}
+}
+
+// TestEventRepository_Append_SerializesUntilCommit checks that a second
+// writer cannot take the append lock while an earlier appender is still
+// open, so a reader never sees sequence N+1 committed before N.
+func TestEventRepository_Append_SerializesUntilCommit(t *testing.T) {
+	db := newPostgresDB(t)
+	repo := repository.NewEventRepository(zap.NewNop().Sugar(), db)
+
+	first, err := entity.NewEvent(entity.EventTypeOrderCreated, uuid.New(), map[string]string{})
+	assert.NoError(t, err)
+	tx := db.Begin()
+	assert.NoError(t, repository.LockEventAppends(tx))
+	assert.NoError(t, repo.Append(tx, first))
+
+	appended := make(chan *entity.Event, 1)
+	go func() {
+		second, _ := entity.NewEvent(entity.EventTypeOrderCreated, uuid.New(), map[string]string{})
+		err := db.Transaction(func(tx *gorm.DB) error {
+			if err := repository.LockEventAppends(tx); err != nil {
+				return err
+			}
+			return repo.Append(tx, second)
+		})
+		if err != nil {
+			appended <- nil
+			return
+		}
+		appended <- second
+	}()
+
+	select {
+	case <-appended:
+		t.Fatal("second append completed while the first transaction was open")
+	case <-time.After(200 * time.Millisecond):
+	}
+
+	events, err := repo.GetSince(0, 10)
+	assert.NoError(t, err)
+	assert.Empty(t, events)
+
+	assert.NoError(t, tx.Commit().Error)
+	second := <-appended
+	if assert.NotNil(t, second) {
+		assert.Greater(t, second.Sequence, first.Sequence)
+	}
+}
+
+// TestEventTx_HoldsEventsUntilCommit checks that a transaction begun with
+// beginEventTx writes its events only at commit, so the append lock is taken
+// after every row lock, and that a rollback drops them.
+func TestEventTx_HoldsEventsUntilCommit(t *testing.T) {
+	db := newMigratedDB(t)
+	repo := repository.NewEventRepository(zap.NewNop().Sugar(), db)
+
+	tx, err := beginEventTx(context.Background(), db)
+	assert.NoError(t, err)
+	assert.NoError(t, appendEvent(repo, tx, entity.EventTypeOrderCreated, uuid.New(), map[string]int{"n": 1}))
+	assert.NoError(t, appendEvent(repo, tx, entity.EventTypeOrderCancelled, uuid.New(), map[string]int{"n": 2}))
+
+	var held int64
+	assert.NoError(t, tx.Model(&entity.Event{}).Count(&held).Error)
+	assert.Zero(t, held)
+
+	assert.NoError(t, commitEventTx(repo, tx))
+
+	events, err := repo.GetSince(0, 10)
+	assert.NoError(t, err)
+	if assert.Len(t, events, 2) {
+		assert.Equal(t, string(entity.EventTypeOrderCreated), events[0].EventType)
+		assert.Equal(t, string(entity.EventTypeOrderCancelled), events[1].EventType)
+	}
+
+	tx, err = beginEventTx(context.Background(), db)
+	assert.NoError(t, err)
+	assert.NoError(t, appendEvent(repo, tx, entity.EventTypeOrderCreated, uuid.New(), map[string]int{"n": 3}))
+	assert.NoError(t, tx.Rollback().Error)
+
+	events, err = repo.GetSince(0, 10)
+	assert.NoError(t, err)
+	assert.Len(t, events, 2)
+}
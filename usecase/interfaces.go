@@ -1,6 +1,9 @@
 package usecase
 
 import (
+	"context"
+	"time"
+
 	"github.com/google/uuid"
 	"github.com/lucas-moura1/mercadobitcoin-challenge/entity"
 	"github.com/shopspring/decimal"
@@ -8,19 +11,91 @@ import (
 )
 
 type OrderUseCase interface {
-	CreateOrder(order *entity.Order) error
-	CancelOrder(id uuid.UUID) error
-	GetOrderBook(instrumentPair string) (*OrderBook, error)
+	CreateOrder(ctx context.Context, order *entity.Order) error
+	ImportOrders(ctx context.Context, orders []*entity.Order, batchSize int) (*ImportResult, error)
+	CancelOrder(ctx context.Context, id uuid.UUID, accountID uuid.UUID) error
+	ReplaceOrder(ctx context.Context, oldID uuid.UUID, newOrder *entity.Order) (*entity.Order, error)
+	CancelOrders(ctx context.Context, accountID uuid.UUID, instrumentPair string, orderType string) ([]uuid.UUID, error)
+	ExpireOrders() (int, error)
+	GetBook(instrumentPair string, view BookView, depth int) (*Book, error)
+	GetOrderBook(instrumentPair string, minQuantity decimal.Decimal) (*OrderBook, error)
+	GetOrderBookSide(instrumentPair string, side string, minQuantity decimal.Decimal) (*OrderBook, error)
+	GetRawOrderBook(instrumentPair string, depth int) (*RawOrderBook, error)
+	GetOrderFills(id uuid.UUID) (*OrderFills, error)
+	GetDepth(instrumentPair string, side string, price decimal.Decimal) (decimal.Decimal, error)
+	GetImbalance(instrumentPair string, depth int) (*Imbalance, error)
+	GetOrderSummary(instrumentPair string, from time.Time, to time.Time) (*OrderSummary, error)
+	GetSpreadHistory(instrumentPair string, window time.Duration, interval time.Duration) (*SpreadHistory, error)
+	SnapshotSpreads(takenAt time.Time) error
+	GetRejections(accountID uuid.UUID, limit int) ([]*entity.OrderRejection, error)
+	GetFilledOrders(accountID uuid.UUID, before uuid.UUID, limit int) (*FilledOrdersPage, error)
+	GetQueuePosition(id uuid.UUID) (*QueuePosition, error)
+	GetMatchCandidates(id uuid.UUID, limit int) (*MatchCandidates, error)
+	GetAccountMarkets(accountID uuid.UUID) ([]*AccountMarket, error)
+	EstimateCost(instrumentPair string, orderType string, quantity decimal.Decimal) (*CostEstimate, error)
 }
 
 type AccountUseCase interface {
 	GetAccountBalance(accountID uuid.UUID) ([]*entity.Wallet, error)
+	GetAccountBalancePage(accountID uuid.UUID, afterAsset string, limit int) (*BalancePage, error)
+	GetAccountEquity(accountID uuid.UUID, quote string) (*Equity, error)
+	GetAssetBalance(accountID uuid.UUID, assetSymbol string) (*entity.Wallet, error)
+	GetReservedBalances(accountID uuid.UUID) (map[string]decimal.Decimal, error)
+	AdjustBalance(ctx context.Context, accountID uuid.UUID, assetSymbol string, amount decimal.Decimal) (*entity.Wallet, error)
+	GetAccountBalanceAt(accountID uuid.UUID, at time.Time) ([]*entity.WalletSnapshot, error)
+	SnapshotBalances(takenAt time.Time) error
+	DeleteAccount(ctx context.Context, accountID uuid.UUID) error
+}
+
+// MarketHaltUseCase halts and resumes order placement on single pairs.
+type MarketHaltUseCase interface {
+	Halt(instrumentPair string) error
+	Resume(instrumentPair string) error
+	IsHalted(instrumentPair string) bool
+	Halted() []string
+}
+
+type ApiKeyUseCase interface {
+	Authenticate(key, timestamp, signature, method, path string, body []byte) (*entity.ApiKey, error)
+}
+
+type TradeUseCase interface {
+	GetTradesByAccount(accountID uuid.UUID, limit int) ([]*entity.Trade, error)
+	GetTradesByInstrumentPair(instrumentPair string, limit int) ([]*entity.Trade, error)
+	GetCandles(instrumentPair string, interval string, from time.Time, to time.Time) ([]*entity.Candle, error)
+	GetVWAP(instrumentPair string, window time.Duration) (*VWAP, error)
+	GetTicks(instrumentPair string, since uuid.UUID, limit int) (*TickSeries, error)
+	GetPnL(accountID uuid.UUID, instrumentPair string, method CostBasisMethod) (*PnL, error)
+}
+
+type EventUseCase interface {
+	GetEventsSince(sequence int64, limit int) ([]*entity.Event, error)
 }
 
+// BookView selects how GetBook presents a pair's resting orders.
+type BookView string
+
+const (
+	BookViewAggregated BookView = "aggregated"
+	BookViewRaw        BookView = "raw"
+)
+
+// Book is one view of a pair's order book. Exactly one of Aggregated and Raw
+// is set, as selected by View.
+type Book struct {
+	View       BookView
+	Aggregated *OrderBook
+	Raw        *RawOrderBook
+}
+
+// OrderBook is a pair's book aggregated by price. Checksum covers the top
+// ChecksumLevels levels of each side, so clients can verify the book they
+// rebuilt against the server's.
 type OrderBook struct {
 	InstrumentPair string
 	Bids           []*OrderBookEntry
 	Asks           []*OrderBookEntry
+	Checksum       uint32
 }
 
 type OrderBookEntry struct {
@@ -28,6 +103,209 @@ type OrderBookEntry struct {
 	Quantity decimal.Decimal
 }
 
+// RawOrderBook lists resting orders one by one instead of aggregated by
+// price, so clients can see their queue position.
+type RawOrderBook struct {
+	InstrumentPair string
+	Bids           []*RawOrderBookEntry
+	Asks           []*RawOrderBookEntry
+}
+
+type RawOrderBookEntry struct {
+	OrderID           uuid.UUID
+	Price             decimal.Decimal
+	RemainingQuantity decimal.Decimal
+	CreatedAt         time.Time
+}
+
+// CostBasisMethod selects which earlier buys a sell is matched against when
+// realizing profit and loss.
+type CostBasisMethod string
+
+const (
+	// CostBasisAverage values each sell against the average price of the
+	// quantity held.
+	CostBasisAverage CostBasisMethod = "average"
+	// CostBasisFIFO values each sell against the oldest buys still held.
+	CostBasisFIFO CostBasisMethod = "fifo"
+)
+
+// PnL is an account's realized profit and loss on a pair, in the quote
+// asset. The average prices are zero for a side with no trades.
+type PnL struct {
+	AccountID        uuid.UUID
+	InstrumentPair   string
+	Method           CostBasisMethod
+	TotalBought      decimal.Decimal
+	TotalSold        decimal.Decimal
+	AverageBuyPrice  decimal.Decimal
+	AverageSellPrice decimal.Decimal
+	RealizedPnL      decimal.Decimal
+}
+
+// VWAP is the volume-weighted average price of a pair over [From, To). Price
+// is nil when no trades executed in the window.
+type VWAP struct {
+	InstrumentPair string
+	From           time.Time
+	To             time.Time
+	Price          *decimal.Decimal
+}
+
+// SpreadHistory is a pair's spread snapshots taken in [From, To), oldest
+// first.
+type SpreadHistory struct {
+	InstrumentPair string
+	From           time.Time
+	To             time.Time
+	Snapshots      []*entity.SpreadSnapshot
+}
+
+// OrderSummary counts a pair's orders by status.
+// CostEstimate is what filling Quantity with an order of OrderType would
+// cost against the current book. FilledQuantity is less than Quantity when
+// the book is too thin; AveragePrice is zero when nothing would fill.
+type CostEstimate struct {
+	InstrumentPair string
+	OrderType      string
+	Quantity       decimal.Decimal
+	FilledQuantity decimal.Decimal
+	TotalCost      decimal.Decimal
+	AveragePrice   decimal.Decimal
+	FullyFillable  bool
+}
+
+// Imbalance is the order-flow imbalance over the top Depth levels of each
+// side of the aggregated book: bid quantity minus ask quantity, and that
+// difference as a Ratio of their sum, from -1 (only asks) to 1 (only bids).
+// Ratio is nil when both sides are empty.
+type Imbalance struct {
+	InstrumentPair string
+	Depth          int
+	BidQuantity    decimal.Decimal
+	AskQuantity    decimal.Decimal
+	Imbalance      decimal.Decimal
+	Ratio          *decimal.Decimal
+}
+
+// ImportResult is the outcome of an order import: how many orders were
+// inserted and why each of the others was not, by its index in the import.
+type ImportResult struct {
+	Imported int
+	Failures []*ImportFailure
+}
+
+type ImportFailure struct {
+	Index int
+	Err   error
+}
+
+// QueuePosition is where a resting order stands at its price level. Position
+// is 1 for the order at the front.
+type QueuePosition struct {
+	Order         *entity.Order
+	Position      int64
+	OrdersAhead   int64
+	QuantityAhead decimal.Decimal
+}
+
+// MatchCandidates are the resting orders Order would match against, best
+// first, as matching would take them.
+type MatchCandidates struct {
+	Order      *entity.Order
+	Candidates []*MatchCandidate
+}
+
+// MatchCandidate is one resting order and how long it has rested.
+type MatchCandidate struct {
+	Order *entity.Order
+	Age   time.Duration
+}
+
+// AccountMarket is an instrument pair an account has traded or has open
+// orders in. LastTrade is nil when the account never traded the pair.
+type AccountMarket struct {
+	InstrumentPair string
+	OpenOrders     int64
+	LastTrade      *entity.Trade
+}
+
+// BalancePage is one page of an account's wallets ordered by asset symbol.
+// NextCursor is the last asset of the page, or empty on the last page.
+type BalancePage struct {
+	Wallets    []*entity.Wallet
+	NextCursor string
+}
+
+// Equity is an account's wallets valued in Quote. Total sums the valued
+// assets only.
+type Equity struct {
+	AccountID uuid.UUID
+	Quote     string
+	Assets    []*AssetValuation
+	Total     decimal.Decimal
+}
+
+// AssetValuation is one wallet of an Equity. Price and Value are nil when
+// the asset has no trades against the quote.
+type AssetValuation struct {
+	AssetSymbol string
+	Balance     decimal.Decimal
+	Price       *decimal.Decimal
+	Value       *decimal.Decimal
+}
+
+// Tick is a trade whose price differs from the trade before it.
+type Tick struct {
+	TradeID    uuid.UUID
+	Price      decimal.Decimal
+	ExecutedAt time.Time
+}
+
+// TickSeries is a page of ticks, oldest first. NextCursor is the last trade
+// examined, which may come after the last tick, or the requested cursor when
+// there were no new trades.
+type TickSeries struct {
+	Ticks      []*Tick
+	NextCursor uuid.UUID
+}
+
+type OrderSummary struct {
+	InstrumentPair  string
+	Open            int64
+	PartiallyFilled int64
+	Filled          int64
+	Cancelled       int64
+}
+
+// OrderFills is an order with its trades in execution order.
+type OrderFills struct {
+	Order *entity.Order
+	Fills []*Fill
+}
+
+// Fill is one trade of an order and what was left of the order after it.
+type Fill struct {
+	Trade             *entity.Trade
+	RemainingQuantity decimal.Decimal
+}
+
+// FilledOrder is an order with at least one fill and what those fills
+// amounted to.
+type FilledOrder struct {
+	Order          *entity.Order
+	BaseFilled     decimal.Decimal
+	QuoteExchanged decimal.Decimal
+	AveragePrice   decimal.Decimal
+}
+
+// FilledOrdersPage is one page of filled orders, newest first. NextCursor is
+// uuid.Nil on the last page.
+type FilledOrdersPage struct {
+	Orders     []*FilledOrder
+	NextCursor uuid.UUID
+}
+
 type TradeExecutor interface {
 	Execute(tx *gorm.DB, order, matchingOrder *entity.Order, qty decimal.Decimal) error
 }
@@ -10,7 +10,9 @@
 package usecase
 
 import (
+	context "context"
 	reflect "reflect"
+	time "time"
 
 	uuid "github.com/google/uuid"
 	entity "github.com/lucas-moura1/mercadobitcoin-challenge/entity"
@@ -44,46 +46,330 @@ func (m *MockOrderUseCase) EXPECT() *MockOrderUseCaseMockRecorder {
 }
 
 // CancelOrder mocks base method.
-func (m *MockOrderUseCase) CancelOrder(id uuid.UUID) error {
+func (m *MockOrderUseCase) CancelOrder(ctx context.Context, id, accountID uuid.UUID) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "CancelOrder", id)
+	ret := m.ctrl.Call(m, "CancelOrder", ctx, id, accountID)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // CancelOrder indicates an expected call of CancelOrder.
-func (mr *MockOrderUseCaseMockRecorder) CancelOrder(id any) *gomock.Call {
+func (mr *MockOrderUseCaseMockRecorder) CancelOrder(ctx, id, accountID any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CancelOrder", reflect.TypeOf((*MockOrderUseCase)(nil).CancelOrder), id)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CancelOrder", reflect.TypeOf((*MockOrderUseCase)(nil).CancelOrder), ctx, id, accountID)
+}
+
+// CancelOrders mocks base method.
+func (m *MockOrderUseCase) CancelOrders(ctx context.Context, accountID uuid.UUID, instrumentPair, orderType string) ([]uuid.UUID, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CancelOrders", ctx, accountID, instrumentPair, orderType)
+	ret0, _ := ret[0].([]uuid.UUID)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CancelOrders indicates an expected call of CancelOrders.
+func (mr *MockOrderUseCaseMockRecorder) CancelOrders(ctx, accountID, instrumentPair, orderType any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CancelOrders", reflect.TypeOf((*MockOrderUseCase)(nil).CancelOrders), ctx, accountID, instrumentPair, orderType)
 }
 
 // CreateOrder mocks base method.
-func (m *MockOrderUseCase) CreateOrder(order *entity.Order) error {
+func (m *MockOrderUseCase) CreateOrder(ctx context.Context, order *entity.Order) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "CreateOrder", order)
+	ret := m.ctrl.Call(m, "CreateOrder", ctx, order)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // CreateOrder indicates an expected call of CreateOrder.
-func (mr *MockOrderUseCaseMockRecorder) CreateOrder(order any) *gomock.Call {
+func (mr *MockOrderUseCaseMockRecorder) CreateOrder(ctx, order any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateOrder", reflect.TypeOf((*MockOrderUseCase)(nil).CreateOrder), ctx, order)
+}
+
+// EstimateCost mocks base method.
+func (m *MockOrderUseCase) EstimateCost(instrumentPair, orderType string, quantity decimal.Decimal) (*CostEstimate, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "EstimateCost", instrumentPair, orderType, quantity)
+	ret0, _ := ret[0].(*CostEstimate)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// EstimateCost indicates an expected call of EstimateCost.
+func (mr *MockOrderUseCaseMockRecorder) EstimateCost(instrumentPair, orderType, quantity any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "EstimateCost", reflect.TypeOf((*MockOrderUseCase)(nil).EstimateCost), instrumentPair, orderType, quantity)
+}
+
+// ExpireOrders mocks base method.
+func (m *MockOrderUseCase) ExpireOrders() (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ExpireOrders")
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ExpireOrders indicates an expected call of ExpireOrders.
+func (mr *MockOrderUseCaseMockRecorder) ExpireOrders() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ExpireOrders", reflect.TypeOf((*MockOrderUseCase)(nil).ExpireOrders))
+}
+
+// GetAccountMarkets mocks base method.
+func (m *MockOrderUseCase) GetAccountMarkets(accountID uuid.UUID) ([]*AccountMarket, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAccountMarkets", accountID)
+	ret0, _ := ret[0].([]*AccountMarket)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetAccountMarkets indicates an expected call of GetAccountMarkets.
+func (mr *MockOrderUseCaseMockRecorder) GetAccountMarkets(accountID any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateOrder", reflect.TypeOf((*MockOrderUseCase)(nil).CreateOrder), order)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAccountMarkets", reflect.TypeOf((*MockOrderUseCase)(nil).GetAccountMarkets), accountID)
+}
+
+// GetBook mocks base method.
+func (m *MockOrderUseCase) GetBook(instrumentPair string, view BookView, depth int) (*Book, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetBook", instrumentPair, view, depth)
+	ret0, _ := ret[0].(*Book)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetBook indicates an expected call of GetBook.
+func (mr *MockOrderUseCaseMockRecorder) GetBook(instrumentPair, view, depth any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetBook", reflect.TypeOf((*MockOrderUseCase)(nil).GetBook), instrumentPair, view, depth)
+}
+
+// GetDepth mocks base method.
+func (m *MockOrderUseCase) GetDepth(instrumentPair, side string, price decimal.Decimal) (decimal.Decimal, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetDepth", instrumentPair, side, price)
+	ret0, _ := ret[0].(decimal.Decimal)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetDepth indicates an expected call of GetDepth.
+func (mr *MockOrderUseCaseMockRecorder) GetDepth(instrumentPair, side, price any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetDepth", reflect.TypeOf((*MockOrderUseCase)(nil).GetDepth), instrumentPair, side, price)
+}
+
+// GetFilledOrders mocks base method.
+func (m *MockOrderUseCase) GetFilledOrders(accountID, before uuid.UUID, limit int) (*FilledOrdersPage, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetFilledOrders", accountID, before, limit)
+	ret0, _ := ret[0].(*FilledOrdersPage)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetFilledOrders indicates an expected call of GetFilledOrders.
+func (mr *MockOrderUseCaseMockRecorder) GetFilledOrders(accountID, before, limit any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetFilledOrders", reflect.TypeOf((*MockOrderUseCase)(nil).GetFilledOrders), accountID, before, limit)
+}
+
+// GetImbalance mocks base method.
+func (m *MockOrderUseCase) GetImbalance(instrumentPair string, depth int) (*Imbalance, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetImbalance", instrumentPair, depth)
+	ret0, _ := ret[0].(*Imbalance)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetImbalance indicates an expected call of GetImbalance.
+func (mr *MockOrderUseCaseMockRecorder) GetImbalance(instrumentPair, depth any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetImbalance", reflect.TypeOf((*MockOrderUseCase)(nil).GetImbalance), instrumentPair, depth)
+}
+
+// GetMatchCandidates mocks base method.
+func (m *MockOrderUseCase) GetMatchCandidates(id uuid.UUID, limit int) (*MatchCandidates, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetMatchCandidates", id, limit)
+	ret0, _ := ret[0].(*MatchCandidates)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetMatchCandidates indicates an expected call of GetMatchCandidates.
+func (mr *MockOrderUseCaseMockRecorder) GetMatchCandidates(id, limit any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetMatchCandidates", reflect.TypeOf((*MockOrderUseCase)(nil).GetMatchCandidates), id, limit)
 }
 
 // GetOrderBook mocks base method.
-func (m *MockOrderUseCase) GetOrderBook(instrumentPair string) (*OrderBook, error) {
+func (m *MockOrderUseCase) GetOrderBook(instrumentPair string, minQuantity decimal.Decimal) (*OrderBook, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetOrderBook", instrumentPair)
+	ret := m.ctrl.Call(m, "GetOrderBook", instrumentPair, minQuantity)
 	ret0, _ := ret[0].(*OrderBook)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // GetOrderBook indicates an expected call of GetOrderBook.
-func (mr *MockOrderUseCaseMockRecorder) GetOrderBook(instrumentPair any) *gomock.Call {
+func (mr *MockOrderUseCaseMockRecorder) GetOrderBook(instrumentPair, minQuantity any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetOrderBook", reflect.TypeOf((*MockOrderUseCase)(nil).GetOrderBook), instrumentPair, minQuantity)
+}
+
+// GetOrderBookSide mocks base method.
+func (m *MockOrderUseCase) GetOrderBookSide(instrumentPair, side string, minQuantity decimal.Decimal) (*OrderBook, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetOrderBookSide", instrumentPair, side, minQuantity)
+	ret0, _ := ret[0].(*OrderBook)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetOrderBookSide indicates an expected call of GetOrderBookSide.
+func (mr *MockOrderUseCaseMockRecorder) GetOrderBookSide(instrumentPair, side, minQuantity any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetOrderBookSide", reflect.TypeOf((*MockOrderUseCase)(nil).GetOrderBookSide), instrumentPair, side, minQuantity)
+}
+
+// GetOrderFills mocks base method.
+func (m *MockOrderUseCase) GetOrderFills(id uuid.UUID) (*OrderFills, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetOrderFills", id)
+	ret0, _ := ret[0].(*OrderFills)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetOrderFills indicates an expected call of GetOrderFills.
+func (mr *MockOrderUseCaseMockRecorder) GetOrderFills(id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetOrderFills", reflect.TypeOf((*MockOrderUseCase)(nil).GetOrderFills), id)
+}
+
+// GetOrderSummary mocks base method.
+func (m *MockOrderUseCase) GetOrderSummary(instrumentPair string, from, to time.Time) (*OrderSummary, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetOrderSummary", instrumentPair, from, to)
+	ret0, _ := ret[0].(*OrderSummary)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetOrderSummary indicates an expected call of GetOrderSummary.
+func (mr *MockOrderUseCaseMockRecorder) GetOrderSummary(instrumentPair, from, to any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetOrderSummary", reflect.TypeOf((*MockOrderUseCase)(nil).GetOrderSummary), instrumentPair, from, to)
+}
+
+// GetQueuePosition mocks base method.
+func (m *MockOrderUseCase) GetQueuePosition(id uuid.UUID) (*QueuePosition, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetQueuePosition", id)
+	ret0, _ := ret[0].(*QueuePosition)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetQueuePosition indicates an expected call of GetQueuePosition.
+func (mr *MockOrderUseCaseMockRecorder) GetQueuePosition(id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetQueuePosition", reflect.TypeOf((*MockOrderUseCase)(nil).GetQueuePosition), id)
+}
+
+// GetRawOrderBook mocks base method.
+func (m *MockOrderUseCase) GetRawOrderBook(instrumentPair string, depth int) (*RawOrderBook, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetRawOrderBook", instrumentPair, depth)
+	ret0, _ := ret[0].(*RawOrderBook)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetRawOrderBook indicates an expected call of GetRawOrderBook.
+func (mr *MockOrderUseCaseMockRecorder) GetRawOrderBook(instrumentPair, depth any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetRawOrderBook", reflect.TypeOf((*MockOrderUseCase)(nil).GetRawOrderBook), instrumentPair, depth)
+}
+
+// GetRejections mocks base method.
+func (m *MockOrderUseCase) GetRejections(accountID uuid.UUID, limit int) ([]*entity.OrderRejection, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetRejections", accountID, limit)
+	ret0, _ := ret[0].([]*entity.OrderRejection)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetRejections indicates an expected call of GetRejections.
+func (mr *MockOrderUseCaseMockRecorder) GetRejections(accountID, limit any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetRejections", reflect.TypeOf((*MockOrderUseCase)(nil).GetRejections), accountID, limit)
+}
+
+// GetSpreadHistory mocks base method.
+func (m *MockOrderUseCase) GetSpreadHistory(instrumentPair string, window, interval time.Duration) (*SpreadHistory, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetSpreadHistory", instrumentPair, window, interval)
+	ret0, _ := ret[0].(*SpreadHistory)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetSpreadHistory indicates an expected call of GetSpreadHistory.
+func (mr *MockOrderUseCaseMockRecorder) GetSpreadHistory(instrumentPair, window, interval any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetSpreadHistory", reflect.TypeOf((*MockOrderUseCase)(nil).GetSpreadHistory), instrumentPair, window, interval)
+}
+
+// ImportOrders mocks base method.
+func (m *MockOrderUseCase) ImportOrders(ctx context.Context, orders []*entity.Order, batchSize int) (*ImportResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ImportOrders", ctx, orders, batchSize)
+	ret0, _ := ret[0].(*ImportResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ImportOrders indicates an expected call of ImportOrders.
+func (mr *MockOrderUseCaseMockRecorder) ImportOrders(ctx, orders, batchSize any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetOrderBook", reflect.TypeOf((*MockOrderUseCase)(nil).GetOrderBook), instrumentPair)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ImportOrders", reflect.TypeOf((*MockOrderUseCase)(nil).ImportOrders), ctx, orders, batchSize)
+}
+
+// ReplaceOrder mocks base method.
+func (m *MockOrderUseCase) ReplaceOrder(ctx context.Context, oldID uuid.UUID, newOrder *entity.Order) (*entity.Order, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReplaceOrder", ctx, oldID, newOrder)
+	ret0, _ := ret[0].(*entity.Order)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ReplaceOrder indicates an expected call of ReplaceOrder.
+func (mr *MockOrderUseCaseMockRecorder) ReplaceOrder(ctx, oldID, newOrder any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReplaceOrder", reflect.TypeOf((*MockOrderUseCase)(nil).ReplaceOrder), ctx, oldID, newOrder)
+}
+
+// SnapshotSpreads mocks base method.
+func (m *MockOrderUseCase) SnapshotSpreads(takenAt time.Time) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SnapshotSpreads", takenAt)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SnapshotSpreads indicates an expected call of SnapshotSpreads.
+func (mr *MockOrderUseCaseMockRecorder) SnapshotSpreads(takenAt any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SnapshotSpreads", reflect.TypeOf((*MockOrderUseCase)(nil).SnapshotSpreads), takenAt)
 }
 
 // MockAccountUseCase is a mock of AccountUseCase interface.
@@ -110,6 +396,35 @@ func (m *MockAccountUseCase) EXPECT() *MockAccountUseCaseMockRecorder {
 	return m.recorder
 }
 
+// AdjustBalance mocks base method.
+func (m *MockAccountUseCase) AdjustBalance(ctx context.Context, accountID uuid.UUID, assetSymbol string, amount decimal.Decimal) (*entity.Wallet, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AdjustBalance", ctx, accountID, assetSymbol, amount)
+	ret0, _ := ret[0].(*entity.Wallet)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// AdjustBalance indicates an expected call of AdjustBalance.
+func (mr *MockAccountUseCaseMockRecorder) AdjustBalance(ctx, accountID, assetSymbol, amount any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AdjustBalance", reflect.TypeOf((*MockAccountUseCase)(nil).AdjustBalance), ctx, accountID, assetSymbol, amount)
+}
+
+// DeleteAccount mocks base method.
+func (m *MockAccountUseCase) DeleteAccount(ctx context.Context, accountID uuid.UUID) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteAccount", ctx, accountID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteAccount indicates an expected call of DeleteAccount.
+func (mr *MockAccountUseCaseMockRecorder) DeleteAccount(ctx, accountID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteAccount", reflect.TypeOf((*MockAccountUseCase)(nil).DeleteAccount), ctx, accountID)
+}
+
 // GetAccountBalance mocks base method.
 func (m *MockAccountUseCase) GetAccountBalance(accountID uuid.UUID) ([]*entity.Wallet, error) {
 	m.ctrl.T.Helper()
@@ -125,6 +440,367 @@ func (mr *MockAccountUseCaseMockRecorder) GetAccountBalance(accountID any) *gomo
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAccountBalance", reflect.TypeOf((*MockAccountUseCase)(nil).GetAccountBalance), accountID)
 }
 
+// GetAccountBalanceAt mocks base method.
+func (m *MockAccountUseCase) GetAccountBalanceAt(accountID uuid.UUID, at time.Time) ([]*entity.WalletSnapshot, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAccountBalanceAt", accountID, at)
+	ret0, _ := ret[0].([]*entity.WalletSnapshot)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetAccountBalanceAt indicates an expected call of GetAccountBalanceAt.
+func (mr *MockAccountUseCaseMockRecorder) GetAccountBalanceAt(accountID, at any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAccountBalanceAt", reflect.TypeOf((*MockAccountUseCase)(nil).GetAccountBalanceAt), accountID, at)
+}
+
+// GetAccountBalancePage mocks base method.
+func (m *MockAccountUseCase) GetAccountBalancePage(accountID uuid.UUID, afterAsset string, limit int) (*BalancePage, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAccountBalancePage", accountID, afterAsset, limit)
+	ret0, _ := ret[0].(*BalancePage)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetAccountBalancePage indicates an expected call of GetAccountBalancePage.
+func (mr *MockAccountUseCaseMockRecorder) GetAccountBalancePage(accountID, afterAsset, limit any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAccountBalancePage", reflect.TypeOf((*MockAccountUseCase)(nil).GetAccountBalancePage), accountID, afterAsset, limit)
+}
+
+// GetAccountEquity mocks base method.
+func (m *MockAccountUseCase) GetAccountEquity(accountID uuid.UUID, quote string) (*Equity, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAccountEquity", accountID, quote)
+	ret0, _ := ret[0].(*Equity)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetAccountEquity indicates an expected call of GetAccountEquity.
+func (mr *MockAccountUseCaseMockRecorder) GetAccountEquity(accountID, quote any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAccountEquity", reflect.TypeOf((*MockAccountUseCase)(nil).GetAccountEquity), accountID, quote)
+}
+
+// GetAssetBalance mocks base method.
+func (m *MockAccountUseCase) GetAssetBalance(accountID uuid.UUID, assetSymbol string) (*entity.Wallet, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAssetBalance", accountID, assetSymbol)
+	ret0, _ := ret[0].(*entity.Wallet)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetAssetBalance indicates an expected call of GetAssetBalance.
+func (mr *MockAccountUseCaseMockRecorder) GetAssetBalance(accountID, assetSymbol any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAssetBalance", reflect.TypeOf((*MockAccountUseCase)(nil).GetAssetBalance), accountID, assetSymbol)
+}
+
+// GetReservedBalances mocks base method.
+func (m *MockAccountUseCase) GetReservedBalances(accountID uuid.UUID) (map[string]decimal.Decimal, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetReservedBalances", accountID)
+	ret0, _ := ret[0].(map[string]decimal.Decimal)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetReservedBalances indicates an expected call of GetReservedBalances.
+func (mr *MockAccountUseCaseMockRecorder) GetReservedBalances(accountID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetReservedBalances", reflect.TypeOf((*MockAccountUseCase)(nil).GetReservedBalances), accountID)
+}
+
+// SnapshotBalances mocks base method.
+func (m *MockAccountUseCase) SnapshotBalances(takenAt time.Time) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SnapshotBalances", takenAt)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SnapshotBalances indicates an expected call of SnapshotBalances.
+func (mr *MockAccountUseCaseMockRecorder) SnapshotBalances(takenAt any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SnapshotBalances", reflect.TypeOf((*MockAccountUseCase)(nil).SnapshotBalances), takenAt)
+}
+
+// MockMarketHaltUseCase is a mock of MarketHaltUseCase interface.
+type MockMarketHaltUseCase struct {
+	ctrl     *gomock.Controller
+	recorder *MockMarketHaltUseCaseMockRecorder
+	isgomock struct{}
+}
+
+// MockMarketHaltUseCaseMockRecorder is the mock recorder for MockMarketHaltUseCase.
+type MockMarketHaltUseCaseMockRecorder struct {
+	mock *MockMarketHaltUseCase
+}
+
+// NewMockMarketHaltUseCase creates a new mock instance.
+func NewMockMarketHaltUseCase(ctrl *gomock.Controller) *MockMarketHaltUseCase {
+	mock := &MockMarketHaltUseCase{ctrl: ctrl}
+	mock.recorder = &MockMarketHaltUseCaseMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockMarketHaltUseCase) EXPECT() *MockMarketHaltUseCaseMockRecorder {
+	return m.recorder
+}
+
+// Halt mocks base method.
+func (m *MockMarketHaltUseCase) Halt(instrumentPair string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Halt", instrumentPair)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Halt indicates an expected call of Halt.
+func (mr *MockMarketHaltUseCaseMockRecorder) Halt(instrumentPair any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Halt", reflect.TypeOf((*MockMarketHaltUseCase)(nil).Halt), instrumentPair)
+}
+
+// Halted mocks base method.
+func (m *MockMarketHaltUseCase) Halted() []string {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Halted")
+	ret0, _ := ret[0].([]string)
+	return ret0
+}
+
+// Halted indicates an expected call of Halted.
+func (mr *MockMarketHaltUseCaseMockRecorder) Halted() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Halted", reflect.TypeOf((*MockMarketHaltUseCase)(nil).Halted))
+}
+
+// IsHalted mocks base method.
+func (m *MockMarketHaltUseCase) IsHalted(instrumentPair string) bool {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "IsHalted", instrumentPair)
+	ret0, _ := ret[0].(bool)
+	return ret0
+}
+
+// IsHalted indicates an expected call of IsHalted.
+func (mr *MockMarketHaltUseCaseMockRecorder) IsHalted(instrumentPair any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IsHalted", reflect.TypeOf((*MockMarketHaltUseCase)(nil).IsHalted), instrumentPair)
+}
+
+// Resume mocks base method.
+func (m *MockMarketHaltUseCase) Resume(instrumentPair string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Resume", instrumentPair)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Resume indicates an expected call of Resume.
+func (mr *MockMarketHaltUseCaseMockRecorder) Resume(instrumentPair any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Resume", reflect.TypeOf((*MockMarketHaltUseCase)(nil).Resume), instrumentPair)
+}
+
+// MockApiKeyUseCase is a mock of ApiKeyUseCase interface.
+type MockApiKeyUseCase struct {
+	ctrl     *gomock.Controller
+	recorder *MockApiKeyUseCaseMockRecorder
+	isgomock struct{}
+}
+
+// MockApiKeyUseCaseMockRecorder is the mock recorder for MockApiKeyUseCase.
+type MockApiKeyUseCaseMockRecorder struct {
+	mock *MockApiKeyUseCase
+}
+
+// NewMockApiKeyUseCase creates a new mock instance.
+func NewMockApiKeyUseCase(ctrl *gomock.Controller) *MockApiKeyUseCase {
+	mock := &MockApiKeyUseCase{ctrl: ctrl}
+	mock.recorder = &MockApiKeyUseCaseMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockApiKeyUseCase) EXPECT() *MockApiKeyUseCaseMockRecorder {
+	return m.recorder
+}
+
+// Authenticate mocks base method.
+func (m *MockApiKeyUseCase) Authenticate(key, timestamp, signature, method, path string, body []byte) (*entity.ApiKey, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Authenticate", key, timestamp, signature, method, path, body)
+	ret0, _ := ret[0].(*entity.ApiKey)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Authenticate indicates an expected call of Authenticate.
+func (mr *MockApiKeyUseCaseMockRecorder) Authenticate(key, timestamp, signature, method, path, body any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Authenticate", reflect.TypeOf((*MockApiKeyUseCase)(nil).Authenticate), key, timestamp, signature, method, path, body)
+}
+
+// MockTradeUseCase is a mock of TradeUseCase interface.
+type MockTradeUseCase struct {
+	ctrl     *gomock.Controller
+	recorder *MockTradeUseCaseMockRecorder
+	isgomock struct{}
+}
+
+// MockTradeUseCaseMockRecorder is the mock recorder for MockTradeUseCase.
+type MockTradeUseCaseMockRecorder struct {
+	mock *MockTradeUseCase
+}
+
+// NewMockTradeUseCase creates a new mock instance.
+func NewMockTradeUseCase(ctrl *gomock.Controller) *MockTradeUseCase {
+	mock := &MockTradeUseCase{ctrl: ctrl}
+	mock.recorder = &MockTradeUseCaseMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockTradeUseCase) EXPECT() *MockTradeUseCaseMockRecorder {
+	return m.recorder
+}
+
+// GetCandles mocks base method.
+func (m *MockTradeUseCase) GetCandles(instrumentPair, interval string, from, to time.Time) ([]*entity.Candle, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetCandles", instrumentPair, interval, from, to)
+	ret0, _ := ret[0].([]*entity.Candle)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetCandles indicates an expected call of GetCandles.
+func (mr *MockTradeUseCaseMockRecorder) GetCandles(instrumentPair, interval, from, to any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetCandles", reflect.TypeOf((*MockTradeUseCase)(nil).GetCandles), instrumentPair, interval, from, to)
+}
+
+// GetPnL mocks base method.
+func (m *MockTradeUseCase) GetPnL(accountID uuid.UUID, instrumentPair string, method CostBasisMethod) (*PnL, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetPnL", accountID, instrumentPair, method)
+	ret0, _ := ret[0].(*PnL)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetPnL indicates an expected call of GetPnL.
+func (mr *MockTradeUseCaseMockRecorder) GetPnL(accountID, instrumentPair, method any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPnL", reflect.TypeOf((*MockTradeUseCase)(nil).GetPnL), accountID, instrumentPair, method)
+}
+
+// GetTicks mocks base method.
+func (m *MockTradeUseCase) GetTicks(instrumentPair string, since uuid.UUID, limit int) (*TickSeries, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetTicks", instrumentPair, since, limit)
+	ret0, _ := ret[0].(*TickSeries)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetTicks indicates an expected call of GetTicks.
+func (mr *MockTradeUseCaseMockRecorder) GetTicks(instrumentPair, since, limit any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTicks", reflect.TypeOf((*MockTradeUseCase)(nil).GetTicks), instrumentPair, since, limit)
+}
+
+// GetTradesByAccount mocks base method.
+func (m *MockTradeUseCase) GetTradesByAccount(accountID uuid.UUID, limit int) ([]*entity.Trade, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetTradesByAccount", accountID, limit)
+	ret0, _ := ret[0].([]*entity.Trade)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetTradesByAccount indicates an expected call of GetTradesByAccount.
+func (mr *MockTradeUseCaseMockRecorder) GetTradesByAccount(accountID, limit any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTradesByAccount", reflect.TypeOf((*MockTradeUseCase)(nil).GetTradesByAccount), accountID, limit)
+}
+
+// GetTradesByInstrumentPair mocks base method.
+func (m *MockTradeUseCase) GetTradesByInstrumentPair(instrumentPair string, limit int) ([]*entity.Trade, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetTradesByInstrumentPair", instrumentPair, limit)
+	ret0, _ := ret[0].([]*entity.Trade)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetTradesByInstrumentPair indicates an expected call of GetTradesByInstrumentPair.
+func (mr *MockTradeUseCaseMockRecorder) GetTradesByInstrumentPair(instrumentPair, limit any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTradesByInstrumentPair", reflect.TypeOf((*MockTradeUseCase)(nil).GetTradesByInstrumentPair), instrumentPair, limit)
+}
+
+// GetVWAP mocks base method.
+func (m *MockTradeUseCase) GetVWAP(instrumentPair string, window time.Duration) (*VWAP, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetVWAP", instrumentPair, window)
+	ret0, _ := ret[0].(*VWAP)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetVWAP indicates an expected call of GetVWAP.
+func (mr *MockTradeUseCaseMockRecorder) GetVWAP(instrumentPair, window any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetVWAP", reflect.TypeOf((*MockTradeUseCase)(nil).GetVWAP), instrumentPair, window)
+}
+
+// MockEventUseCase is a mock of EventUseCase interface.
+type MockEventUseCase struct {
+	ctrl     *gomock.Controller
+	recorder *MockEventUseCaseMockRecorder
+	isgomock struct{}
+}
+
+// MockEventUseCaseMockRecorder is the mock recorder for MockEventUseCase.
+type MockEventUseCaseMockRecorder struct {
+	mock *MockEventUseCase
+}
+
+// NewMockEventUseCase creates a new mock instance.
+func NewMockEventUseCase(ctrl *gomock.Controller) *MockEventUseCase {
+	mock := &MockEventUseCase{ctrl: ctrl}
+	mock.recorder = &MockEventUseCaseMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockEventUseCase) EXPECT() *MockEventUseCaseMockRecorder {
+	return m.recorder
+}
+
+// GetEventsSince mocks base method.
+func (m *MockEventUseCase) GetEventsSince(sequence int64, limit int) ([]*entity.Event, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetEventsSince", sequence, limit)
+	ret0, _ := ret[0].([]*entity.Event)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetEventsSince indicates an expected call of GetEventsSince.
+func (mr *MockEventUseCaseMockRecorder) GetEventsSince(sequence, limit any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetEventsSince", reflect.TypeOf((*MockEventUseCase)(nil).GetEventsSince), sequence, limit)
+}
+
 // MockTradeExecutor is a mock of TradeExecutor interface.
 type MockTradeExecutor struct {
 	ctrl     *gomock.Controller
@@ -150,15 +826,15 @@ func (m *MockTradeExecutor) EXPECT() *MockTradeExecutorMockRecorder {
 }
 
 // Execute mocks base method.
-func (m *MockTradeExecutor) Execute(tx *gorm.DB, taker, maker *entity.Order, qty decimal.Decimal) error {
+func (m *MockTradeExecutor) Execute(tx *gorm.DB, order, matchingOrder *entity.Order, qty decimal.Decimal) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "Execute", tx, taker, maker, qty)
+	ret := m.ctrl.Call(m, "Execute", tx, order, matchingOrder, qty)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // Execute indicates an expected call of Execute.
-func (mr *MockTradeExecutorMockRecorder) Execute(tx, taker, maker, qty any) *gomock.Call {
+func (mr *MockTradeExecutorMockRecorder) Execute(tx, order, matchingOrder, qty any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Execute", reflect.TypeOf((*MockTradeExecutor)(nil).Execute), tx, taker, maker, qty)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Execute", reflect.TypeOf((*MockTradeExecutor)(nil).Execute), tx, order, matchingOrder, qty)
 }
@@ -0,0 +1,71 @@
+package usecase
+
+import (
+	"sort"
+	"sync"
+
+	"github.com/lucas-moura1/mercadobitcoin-challenge/entity"
+	"go.uber.org/zap"
+)
+
+// marketHaltUseCase keeps the set of halted pairs in memory. Halts are per
+// process and do not survive a restart.
+type marketHaltUseCase struct {
+	log    *zap.SugaredLogger
+	mu     sync.RWMutex
+	halted map[string]struct{}
+}
+
+func NewMarketHaltUseCase(log *zap.SugaredLogger) MarketHaltUseCase {
+	return &marketHaltUseCase{
+		log:    log,
+		halted: make(map[string]struct{}),
+	}
+}
+
+func (u *marketHaltUseCase) Halt(instrumentPair string) error {
+	if !entity.IsValidInstrumentPair(instrumentPair) {
+		return entity.ErrInvalidPairFormat
+	}
+
+	u.mu.Lock()
+	u.halted[instrumentPair] = struct{}{}
+	u.mu.Unlock()
+
+	u.log.Warnw("market halted", "instrument_pair", instrumentPair)
+	return nil
+}
+
+func (u *marketHaltUseCase) Resume(instrumentPair string) error {
+	if !entity.IsValidInstrumentPair(instrumentPair) {
+		return entity.ErrInvalidPairFormat
+	}
+
+	u.mu.Lock()
+	delete(u.halted, instrumentPair)
+	u.mu.Unlock()
+
+	u.log.Warnw("market resumed", "instrument_pair", instrumentPair)
+	return nil
+}
+
+func (u *marketHaltUseCase) IsHalted(instrumentPair string) bool {
+	u.mu.RLock()
+	defer u.mu.RUnlock()
+
+	_, ok := u.halted[instrumentPair]
+	return ok
+}
+
+// Halted returns the halted pairs in alphabetical order.
+func (u *marketHaltUseCase) Halted() []string {
+	u.mu.RLock()
+	pairs := make([]string, 0, len(u.halted))
+	for pair := range u.halted {
+		pairs = append(pairs, pair)
+	}
+	u.mu.RUnlock()
+
+	sort.Strings(pairs)
+	return pairs
+}
@@ -0,0 +1,31 @@
+package usecase
+
+import (
+	"testing"
+
+	"github.com/lucas-moura1/mercadobitcoin-challenge/entity"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+func TestMarketHaltUseCase(t *testing.T) {
+	uc := NewMarketHaltUseCase(zap.NewNop().Sugar())
+
+	assert.False(t, uc.IsHalted("BTC_BRL"))
+	assert.Empty(t, uc.Halted())
+
+	assert.NoError(t, uc.Halt("ETH_BRL"))
+	assert.NoError(t, uc.Halt("BTC_BRL"))
+	assert.NoError(t, uc.Halt("BTC_BRL"), "halting twice is a no-op")
+	assert.True(t, uc.IsHalted("BTC_BRL"))
+	assert.False(t, uc.IsHalted("SOL_BRL"))
+	assert.Equal(t, []string{"BTC_BRL", "ETH_BRL"}, uc.Halted())
+
+	assert.NoError(t, uc.Resume("BTC_BRL"))
+	assert.NoError(t, uc.Resume("SOL_BRL"), "resuming a live pair is a no-op")
+	assert.False(t, uc.IsHalted("BTC_BRL"))
+	assert.Equal(t, []string{"ETH_BRL"}, uc.Halted())
+
+	assert.ErrorIs(t, uc.Halt("BTCBRL"), entity.ErrInvalidPairFormat)
+	assert.ErrorIs(t, uc.Resume("BTCBRL"), entity.ErrInvalidPairFormat)
+}
@@ -0,0 +1,231 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"os"
+	"strconv"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/lucas-moura1/mercadobitcoin-challenge/entity"
+	"github.com/lucas-moura1/mercadobitcoin-challenge/repository"
+	"github.com/shopspring/decimal"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+const (
+	propertyAccounts = 4
+	propertySteps    = 150
+)
+
+// propertySeeds returns the seeds the matching property test runs with.
+// MATCHING_PROPERTY_SEED replays a single seed, e.g. one a failure reported.
+func propertySeeds(t *testing.T) []int64 {
+	t.Helper()
+
+	if raw := os.Getenv("MATCHING_PROPERTY_SEED"); raw != "" {
+		seed, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil {
+			t.Fatalf("invalid MATCHING_PROPERTY_SEED %q", raw)
+		}
+		return []int64{seed}
+	}
+	return []int64{1, 2, 3, 4, 5}
+}
+
+// TestOrderUseCase_MatchingProperties feeds random but valid orders and
+// cancels through the real engine and checks the invariants matching must
+// keep after every step. There are no fees, so every asset's total across
+// wallets must stay what it was seeded with.
+func TestOrderUseCase_MatchingProperties(t *testing.T) {
+	for _, seed := range propertySeeds(t) {
+		seed := seed
+		t.Run(fmt.Sprintf("seed=%d", seed), func(t *testing.T) {
+			runMatchingProperties(t, seed)
+		})
+	}
+}
+
+func runMatchingProperties(t *testing.T, seed int64) {
+	log := zap.NewNop().Sugar()
+	db := newMigratedDB(t)
+	orderRepo := repository.NewOrderRepository(log, db)
+	walletRepo := repository.NewWalletRepository(log, db, nil)
+	uc := NewOrderUseCase(log, orderRepo, walletRepo, repository.NewTradeRepository(log, db), repository.NewEventRepository(log, db), nil, db, OrderUseCaseConfig{})
+
+	rng := rand.New(rand.NewSource(seed))
+
+	accounts := make([]uuid.UUID, propertyAccounts)
+	for i := range accounts {
+		accounts[i] = uuid.New()
+		for _, w := range []*entity.Wallet{
+			{AccountID: accounts[i], AssetSymbol: "BTC", Balance: decimal.RequireFromString("5")},
+			{AccountID: accounts[i], AssetSymbol: "BRL", Balance: decimal.RequireFromString("1000")},
+		} {
+			if err := walletRepo.Create(nil, w); err != nil {
+				t.Fatalf("failed to seed wallet: %v", err)
+			}
+		}
+	}
+	totals := walletTotals(t, db)
+
+	for step := 0; step < propertySteps; step++ {
+		var action string
+
+		if rng.Intn(5) == 0 {
+			var open []*entity.Order
+			if err := db.Where("status = ?", entity.OrderStatusOpen).Order("id").Find(&open).Error; err != nil {
+				t.Fatalf("failed to read open orders: %v", err)
+			}
+			if len(open) > 0 {
+				order := open[rng.Intn(len(open))]
+				action = fmt.Sprintf("cancel %s", order.ID)
+				if err := uc.CancelOrder(context.Background(), order.ID, uuid.Nil); err != nil {
+					t.Fatalf("seed %d step %d: %s failed: %v", seed, step, action, err)
+				}
+			}
+		}
+
+		if action == "" {
+			order := randomOrder(rng, accounts)
+			action = fmt.Sprintf("%s %s @ %s by %s", order.OrderType, order.Quantity, order.Price, order.AccountID)
+			// Orders the account cannot fund are refused; that is a valid
+			// outcome and must leave state untouched like any other.
+			_ = uc.CreateOrder(context.Background(), order)
+		}
+
+		if msg := checkMatchingInvariants(t, db, totals); msg != "" {
+			t.Fatalf("seed %d step %d after %s: %s", seed, step, action, msg)
+		}
+	}
+
+	// Guard against a generator that never crosses the book and so never
+	// exercises matching.
+	var trades int64
+	if err := db.Model(&entity.Trade{}).Count(&trades).Error; err != nil {
+		t.Fatalf("failed to count trades: %v", err)
+	}
+	if trades == 0 {
+		t.Fatalf("seed %d produced no trades", seed)
+	}
+}
+
+func randomOrder(rng *rand.Rand, accounts []uuid.UUID) *entity.Order {
+	orderType := entity.OrderTypeBuy
+	if rng.Intn(2) == 0 {
+		orderType = entity.OrderTypeSell
+	}
+
+	return &entity.Order{
+		AccountID:      accounts[rng.Intn(len(accounts))],
+		InstrumentPair: "BTC_BRL",
+		OrderType:      string(orderType),
+		// Prices on a narrow grid so books cross often; quantities in
+		// hundredths so every amount is exact.
+		Price:    decimal.NewFromInt(int64(95 + rng.Intn(11))),
+		Quantity: decimal.New(int64(1+rng.Intn(150)), -2),
+	}
+}
+
+// columnScale is the scale of the decimal(20,8) amount columns. SQLite keeps
+// them as floating point, so sums are compared at the precision Postgres
+// would store.
+const columnScale = 8
+
+func atColumnScale(d decimal.Decimal) decimal.Decimal {
+	return d.Round(columnScale)
+}
+
+func walletTotals(t *testing.T, db *gorm.DB) map[string]decimal.Decimal {
+	t.Helper()
+
+	var wallets []*entity.Wallet
+	if err := db.Find(&wallets).Error; err != nil {
+		t.Fatalf("failed to read wallets: %v", err)
+	}
+
+	totals := make(map[string]decimal.Decimal)
+	for _, wallet := range wallets {
+		totals[wallet.AssetSymbol] = totals[wallet.AssetSymbol].Add(wallet.Balance)
+	}
+	return totals
+}
+
+// checkMatchingInvariants returns a description of the first broken
+// invariant, or an empty string when all hold.
+func checkMatchingInvariants(t *testing.T, db *gorm.DB, want map[string]decimal.Decimal) string {
+	t.Helper()
+
+	var wallets []*entity.Wallet
+	if err := db.Find(&wallets).Error; err != nil {
+		t.Fatalf("failed to read wallets: %v", err)
+	}
+	got := make(map[string]decimal.Decimal)
+	for _, wallet := range wallets {
+		if atColumnScale(wallet.Balance).IsNegative() {
+			return fmt.Sprintf("negative %s balance %s for %s", wallet.AssetSymbol, wallet.Balance, wallet.AccountID)
+		}
+		got[wallet.AssetSymbol] = got[wallet.AssetSymbol].Add(wallet.Balance)
+	}
+	for asset, total := range want {
+		if !entity.DecimalEqual(atColumnScale(got[asset]), atColumnScale(total)) {
+			return fmt.Sprintf("%s not conserved: total %s, want %s", asset, got[asset], total)
+		}
+	}
+
+	var orders []*entity.Order
+	if err := db.Find(&orders).Error; err != nil {
+		t.Fatalf("failed to read orders: %v", err)
+	}
+	var trades []*entity.Trade
+	if err := db.Find(&trades).Error; err != nil {
+		t.Fatalf("failed to read trades: %v", err)
+	}
+
+	filled := make(map[uuid.UUID]decimal.Decimal)
+	for _, trade := range trades {
+		if !trade.Quantity.IsPositive() {
+			return fmt.Sprintf("trade %s has non-positive quantity %s", trade.ID, trade.Quantity)
+		}
+		filled[trade.BuyerOrderID] = filled[trade.BuyerOrderID].Add(trade.Quantity)
+		filled[trade.SellerOrderID] = filled[trade.SellerOrderID].Add(trade.Quantity)
+	}
+
+	var bids, asks []*entity.Order
+	for _, order := range orders {
+		f := filled[order.ID]
+		if atColumnScale(f).GreaterThan(atColumnScale(order.Quantity)) {
+			return fmt.Sprintf("order %s filled %s of %s", order.ID, f, order.Quantity)
+		}
+		if !entity.DecimalEqual(atColumnScale(order.RemainingQuantity), atColumnScale(order.Quantity.Sub(f))) {
+			return fmt.Sprintf("order %s remaining %s, want %s", order.ID, order.RemainingQuantity, order.Quantity.Sub(f))
+		}
+		if (order.Status == string(entity.OrderStatusFilled)) != atColumnScale(order.RemainingQuantity).IsZero() {
+			return fmt.Sprintf("order %s is %s with remaining %s", order.ID, order.Status, order.RemainingQuantity)
+		}
+
+		if order.Status != string(entity.OrderStatusOpen) && order.Status != string(entity.OrderStatusPartial) {
+			continue
+		}
+		if order.OrderType == string(entity.OrderTypeBuy) {
+			bids = append(bids, order)
+		} else {
+			asks = append(asks, order)
+		}
+	}
+
+	// Matching skips the taker's own orders, so only orders of different
+	// accounts may never be left crossed.
+	for _, bid := range bids {
+		for _, ask := range asks {
+			if bid.AccountID != ask.AccountID && bid.Crosses(ask) {
+				return fmt.Sprintf("crossed book: bid %s @ %s and ask %s @ %s", bid.ID, bid.Price, ask.ID, ask.Price)
+			}
+		}
+	}
+
+	return ""
+}
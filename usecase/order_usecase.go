@@ -1,8 +1,14 @@
 package usecase
 
 import (
+	"context"
+	"database/sql"
 	"errors"
+	"fmt"
+	"hash/crc32"
 	"sort"
+	"strings"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/lucas-moura1/mercadobitcoin-challenge/entity"
@@ -12,13 +18,107 @@ import (
 	"gorm.io/gorm"
 )
 
+// DefaultMaxFillsPerOrder caps how many resting orders a single incoming
+// order can fill in one transaction when no explicit limit is configured.
+const DefaultMaxFillsPerOrder = 100
+
+const (
+	DefaultRawBookDepth = 50
+	MaxRawBookDepth     = 500
+)
+
+// MaxSpreadWindow bounds how far back a spread history query may look.
+const MaxSpreadWindow = 30 * 24 * time.Hour
+
+// expiryBatchSize caps how many expired orders one sweep cancels.
+const expiryBatchSize = 500
+
+// DefaultImportBatchSize is how many rows ImportOrders inserts per statement
+// when no batch size is configured.
+const DefaultImportBatchSize = 500
+
+// ExpiryPolicy configures how good-till-date orders expire.
+type ExpiryPolicy struct {
+	// Grace is how long past its expiry an order may wait for a match that
+	// is in flight on its pair before the sweeper cancels it anyway.
+	Grace time.Duration
+	// MaxAge is how long any order may rest before the sweeper cancels it,
+	// a safety net against stale liquidity; an earlier expiry of the
+	// order's own still applies. Zero means no limit.
+	MaxAge time.Duration
+}
+
+// expiresAt returns when order expires under the policy: its own expiry or
+// MaxAge after it was created, whichever comes first. ok is false for an
+// order that never expires.
+func (p ExpiryPolicy) expiresAt(order *entity.Order) (time.Time, bool) {
+	if p.MaxAge <= 0 {
+		if order.ExpiresAt == nil {
+			return time.Time{}, false
+		}
+		return *order.ExpiresAt, true
+	}
+
+	maxAgeAt := order.CreatedAt.Add(p.MaxAge)
+	if order.ExpiresAt != nil && order.ExpiresAt.Before(maxAgeAt) {
+		return *order.ExpiresAt, true
+	}
+	return maxAgeAt, true
+}
+
+// createdBefore returns the creation time at or before which an order has
+// outlived MaxAge at now, or the zero time when there is no limit.
+func (p ExpiryPolicy) createdBefore(now time.Time) time.Time {
+	if p.MaxAge <= 0 {
+		return time.Time{}
+	}
+	return now.Add(-p.MaxAge)
+}
+
 type orderUseCase struct {
 	log              *zap.SugaredLogger
 	orderRepository  repository.OrderRepository
 	walletRepository repository.WalletRepository
 	tradeRepository  repository.TradeRepository
+	eventRepository  repository.EventRepository
+	rejections       repository.OrderRejectionRepository
 	db               *gorm.DB
 	executor         TradeExecutor
+	maxFills         int
+	instruments      *entity.InstrumentConfig
+	isolation        sql.IsolationLevel
+	maxBookLevels    int
+	rejectSelfCross  bool
+	// halts is nil when no pair can be halted.
+	halts MarketHaltUseCase
+	// notionalLimits is nil when no account's open notional is capped.
+	notionalLimits *entity.NotionalLimits
+	clock          Clock
+	expiry         ExpiryPolicy
+	pairs          *pairLocks
+}
+
+// OrderUseCaseConfig holds the settings and optional collaborators
+// NewOrderUseCase builds the order use case with. The zero value applies
+// every default.
+type OrderUseCaseConfig struct {
+	// MaxFills caps how many resting orders one incoming order fills in a
+	// transaction. Zero means DefaultMaxFillsPerOrder.
+	MaxFills    int
+	Instruments *entity.InstrumentConfig
+	// Isolation is the isolation level of the create-and-match transaction.
+	Isolation sql.IsolationLevel
+	// MaxBookLevels caps the levels per side of the published book. Zero
+	// keeps every level.
+	MaxBookLevels   int
+	RejectSelfCross bool
+	// Halts is nil when no pair can be halted.
+	Halts MarketHaltUseCase
+	// NotionalLimits is nil when no account's open notional is capped.
+	NotionalLimits *entity.NotionalLimits
+	// Clock is the system clock when nil.
+	Clock  Clock
+	Expiry ExpiryPolicy
 }
 
 func NewOrderUseCase(
@@ -26,197 +126,1757 @@ func NewOrderUseCase(
 	orderRepo repository.OrderRepository,
 	walletRepo repository.WalletRepository,
 	tradeRepo repository.TradeRepository,
+	eventRepo repository.EventRepository,
+	rejectionRepo repository.OrderRejectionRepository,
 	db *gorm.DB,
+	cfg OrderUseCaseConfig,
 ) OrderUseCase {
 	return &orderUseCase{
 		log:              log,
 		orderRepository:  orderRepo,
 		walletRepository: walletRepo,
 		tradeRepository:  tradeRepo,
+		eventRepository:  eventRepo,
+		rejections:       rejectionRepo,
 		db:               db,
-		executor:         NewTradeExecutor(log, orderRepo, walletRepo, tradeRepo),
+		executor:         NewTradeExecutor(log, orderRepo, walletRepo, tradeRepo, eventRepo, cfg.Instruments),
+		maxFills:         cfg.MaxFills,
+		instruments:      cfg.Instruments,
+		isolation:        cfg.Isolation,
+		maxBookLevels:    cfg.MaxBookLevels,
+		rejectSelfCross:  cfg.RejectSelfCross,
+		halts:            cfg.Halts,
+		notionalLimits:   cfg.NotionalLimits,
+		clock:            clockOrSystem(cfg.Clock),
+		expiry:           cfg.Expiry,
+		pairs:            newPairLocks(),
 	}
 }
 
-func (u *orderUseCase) CreateOrder(order *entity.Order) error {
+func (u *orderUseCase) CreateOrder(ctx context.Context, order *entity.Order) error {
 	u.log.Infow("creating new order",
 		"account_id", order.AccountID,
 		"type", order.OrderType,
 		"instrument_pair", order.InstrumentPair,
 	)
 
-	tx := u.db.Begin()
+	normalizePair(order)
+
+	// Holding the pair lock for the whole match tells the expiry sweeper a
+	// fill is in flight on this book.
+	lock := u.pairs.get(order.InstrumentPair)
+	lock.Lock()
+	defer lock.Unlock()
+
+	// The pair lock only orders takers within this process. Matching reads
+	// resting orders and then rewrites their remaining quantity, so below
+	// REPEATABLE READ takers on two servers can both fill the same maker. The
+	// event append lock does not prevent that: it is taken only at commit. The
+	// level is configurable; see config.SetupMatching.
+	tx, err := beginEventTx(ctx, u.db, &sql.TxOptions{Isolation: u.isolation})
+	if err != nil {
+		return err
+	}
 	defer func() {
 		if r := recover(); r != nil {
 			tx.Rollback()
 		}
 	}()
 
+	if err := u.createAndMatch(tx, order); err != nil {
+		tx.Rollback()
+		u.recordRejection(order, err)
+		return err
+	}
+
+	if err := commitEventTx(u.eventRepository, tx); err != nil {
+		return err
+	}
+
+	u.loadTimestamps(order)
+	return nil
+}
+
+// loadTimestamps replaces the timestamps GORM set on order in memory with the
+// stored ones: the database may keep less precision, and fills move
+// updated_at. The order is committed by then, so a failed read is only
+// logged and the in-memory values are kept.
+func (u *orderUseCase) loadTimestamps(order *entity.Order) {
+	stored, err := u.orderRepository.GetByID(order.ID)
+	if err != nil {
+		u.log.Warnw("failed to read back order timestamps", "order_id", order.ID, "error", err)
+		return
+	}
+	order.CreatedAt, order.UpdatedAt = stored.CreatedAt, stored.UpdatedAt
+}
+
+// recordRejection stores why order was refused, outside the rolled back
+// transaction. It is best-effort: a failure is logged and the caller still
+// returns the original error. Errors that are not a rejection of the order,
+// such as database failures, are not recorded.
+func (u *orderUseCase) recordRejection(order *entity.Order, err error) {
+	if u.rejections == nil {
+		return
+	}
+
+	reason, ok := entity.RejectionReasonFor(err)
+	if !ok {
+		return
+	}
+
+	if err := u.rejections.Create(entity.NewOrderRejection(order, reason, err)); err != nil {
+		u.log.Errorw("failed to record order rejection", "account_id", order.AccountID, "reason", reason, "error", err)
+	}
+}
+
+// GetRejections returns the account's most recent rejected orders, newest
+// first.
+func (u *orderUseCase) GetRejections(accountID uuid.UUID, limit int) ([]*entity.OrderRejection, error) {
+	u.log.Infow("getting order rejections", "account_id", accountID, "limit", limit)
+
+	return u.rejections.GetByAccountID(accountID, clampTradesLimit(limit))
+}
+
+// GetFilledOrders returns a page of the account's filled and partially
+// filled orders, newest first, each with the base quantity filled, the quote
+// amount exchanged and the resulting average price.
+func (u *orderUseCase) GetFilledOrders(accountID uuid.UUID, before uuid.UUID, limit int) (*FilledOrdersPage, error) {
+	u.log.Infow("getting filled orders", "account_id", accountID, "before", before, "limit", limit)
+
+	limit = clampTradesLimit(limit)
+
+	// One extra row tells whether there is a next page.
+	orders, err := u.orderRepository.GetByAccountAndStatus(accountID, before, limit+1,
+		string(entity.OrderStatusFilled), string(entity.OrderStatusPartial))
+	if err != nil {
+		return nil, err
+	}
+
+	page := &FilledOrdersPage{}
+	if len(orders) > limit {
+		orders = orders[:limit]
+		page.NextCursor = orders[limit-1].ID
+	}
+
+	page.Orders = make([]*FilledOrder, len(orders))
+	for i, order := range orders {
+		trades, err := u.tradeRepository.GetByOrderID(order.ID)
+		if err != nil {
+			return nil, err
+		}
+
+		filled := &FilledOrder{Order: order}
+		for _, trade := range trades {
+			filled.BaseFilled = filled.BaseFilled.Add(trade.Quantity)
+			filled.QuoteExchanged = filled.QuoteExchanged.Add(trade.Price.Mul(trade.Quantity))
+		}
+		if filled.BaseFilled.IsPositive() {
+			filled.AveragePrice = entity.DecimalDiv(filled.QuoteExchanged, filled.BaseFilled)
+		}
+		page.Orders[i] = filled
+	}
+
+	return page, nil
+}
+
+// GetAccountMarkets lists the instrument pairs the account has ever traded
+// or has open orders in, sorted by pair, with the open-order count and the
+// latest trade of each. A new account gets an empty list.
+func (u *orderUseCase) GetAccountMarkets(accountID uuid.UUID) ([]*AccountMarket, error) {
+	u.log.Infow("getting account markets", "account_id", accountID)
+
+	openOrders, err := u.orderRepository.CountOpenByAccountPerPair(accountID)
+	if err != nil {
+		return nil, err
+	}
+
+	trades, err := u.tradeRepository.GetLatestByAccountPerPair(accountID)
+	if err != nil {
+		return nil, err
+	}
+
+	markets := make(map[string]*AccountMarket, len(openOrders)+len(trades))
+	for pair, count := range openOrders {
+		markets[pair] = &AccountMarket{InstrumentPair: pair, OpenOrders: count}
+	}
+	for _, trade := range trades {
+		market, ok := markets[trade.InstrumentPair]
+		if !ok {
+			market = &AccountMarket{InstrumentPair: trade.InstrumentPair}
+			markets[trade.InstrumentPair] = market
+		}
+		market.LastTrade = trade
+	}
+
+	result := make([]*AccountMarket, 0, len(markets))
+	for _, market := range markets {
+		result = append(result, market)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].InstrumentPair < result[j].InstrumentPair
+	})
+
+	return result, nil
+}
+
+// GetQueuePosition returns how many orders and how much quantity are ahead
+// of a resting order at its price level under price-time priority. Orders
+// that no longer rest on the book fail with entity.ErrOrderNotOpen.
+func (u *orderUseCase) GetQueuePosition(id uuid.UUID) (*QueuePosition, error) {
+	u.log.Infow("getting queue position", "id", id)
+
+	order, err := u.orderRepository.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if order.Status != string(entity.OrderStatusOpen) && order.Status != string(entity.OrderStatusPartial) {
+		return nil, entity.ErrOrderNotOpen
+	}
+
+	ordersAhead, quantityAhead, err := u.orderRepository.GetQueueAhead(order)
+	if err != nil {
+		return nil, err
+	}
+
+	return &QueuePosition{
+		Order:         order,
+		Position:      ordersAhead + 1,
+		OrdersAhead:   ordersAhead,
+		QuantityAhead: quantityAhead,
+	}, nil
+}
+
+// normalizePair rewrites the order's pair in canonical form, so "btc_brl"
+// trades on the BTC_BRL book. A pair that cannot be normalized is left as
+// is for validation to reject.
+func normalizePair(order *entity.Order) {
+	if pair, err := entity.NormalizeInstrumentPair(order.InstrumentPair); err == nil {
+		order.InstrumentPair = pair
+	}
+}
+
+// GetMatchCandidates lists the resting orders the order with the given id
+// would match against right now, in the order matching would take them, up
+// to limit (the fill cap when zero). It is read only: nothing is executed,
+// and the order's status is not checked, so a filled or cancelled order shows
+// what it would meet if it were resubmitted.
+func (u *orderUseCase) GetMatchCandidates(id uuid.UUID, limit int) (*MatchCandidates, error) {
+	u.log.Infow("getting match candidates", "id", id, "limit", limit)
+
+	order, err := u.orderRepository.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	if limit <= 0 {
+		limit = u.maxFills
+		if limit <= 0 {
+			limit = DefaultMaxFillsPerOrder
+		}
+	}
+
+	price := order.Price
+	if order.IsMarketBuy() {
+		price = decimal.NewFromInt(entity.MaxPrice)
+	}
+	now := u.clock.Now()
+	orders, err := u.orderRepository.GetMatchingOrders(
+		nil,
+		order.AccountID,
+		order.InstrumentPair,
+		order.OppositeType(),
+		price,
+		order.OrderType == string(entity.OrderTypeBuy),
+		limit,
+		now,
+		u.expiry.createdBefore(now),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	candidates := make([]*MatchCandidate, len(orders))
+	for i, resting := range orders {
+		candidates[i] = &MatchCandidate{Order: resting, Age: now.Sub(resting.CreatedAt)}
+	}
+
+	return &MatchCandidates{Order: order, Candidates: candidates}, nil
+}
+
+// createAndMatch validates, stores and matches order inside tx. The caller
+// owns the transaction and must roll it back on error.
+func (u *orderUseCase) createAndMatch(tx *gorm.DB, order *entity.Order) error {
 	if err := order.Validate(); err != nil {
 		u.log.Errorw("invalid order", "error", err)
 		return err
 	}
 
+	if err := u.instruments.ValidatePair(order.InstrumentPair); err != nil {
+		u.log.Errorw("unsupported instrument pair", "instrument_pair", order.InstrumentPair, "error", err)
+		return err
+	}
+
+	if order.ExpiresAt != nil && !order.ExpiresAt.After(u.clock.Now()) {
+		u.log.Errorw("order expiry is not in the future", "expires_at", order.ExpiresAt)
+		return entity.ErrInvalidExpiry
+	}
+
+	if u.halts != nil && u.halts.IsHalted(order.InstrumentPair) {
+		u.log.Warnw("order rejected on halted market", "instrument_pair", order.InstrumentPair)
+		return entity.ErrMarketHalted
+	}
+
+	if err := u.checkSelfCross(order, tx); err != nil {
+		return err
+	}
+
 	if err := u.checkWalletBalance(order, tx); err != nil {
-		tx.Rollback()
 		return err
 	}
 
+	if err := u.checkOpenNotional(order, tx); err != nil {
+		return err
+	}
+
+	var fills []marketFill
+	if order.IsMarketBuy() {
+		var err error
+		if fills, err = u.planMarketBuy(order, tx); err != nil {
+			return err
+		}
+	}
+
 	order.Status = string(entity.OrderStatusOpen)
 	order.RemainingQuantity = order.Quantity
+	if err := order.Reserve(); err != nil {
+		return err
+	}
 
 	if err := u.orderRepository.Create(tx, order); err != nil {
-		tx.Rollback()
 		return err
 	}
 
-	if err := u.matchOrder(order, tx); err != nil {
-		tx.Rollback()
+	if err := appendEvent(u.eventRepository, tx, entity.EventTypeOrderCreated, order.ID, order); err != nil {
 		return err
 	}
 
-	return tx.Commit().Error
+	if order.IsMarketBuy() {
+		return u.executeMarketBuy(tx, order, fills)
+	}
+
+	return u.matchOrder(order, tx)
 }
 
-func (u *orderUseCase) matchOrder(order *entity.Order, tx *gorm.DB) error {
-	u.log.Infow("matching order",
-		"order_id", order.ID,
-		"type", order.OrderType,
-		"price", order.Price,
-	)
+// marketFill is one maker a market buy takes from and the base quantity it
+// takes.
+type marketFill struct {
+	maker    *entity.Order
+	quantity decimal.Decimal
+}
 
-	oppositeOrderType := "SELL"
-	if order.OrderType == "SELL" {
-		oppositeOrderType = "BUY"
+// orderQuantityScale is the scale of the order and trade quantity columns,
+// used for market buys on pairs whose base asset has no configured scale.
+const orderQuantityScale = 8
+
+// planMarketBuy walks the asks best price first and takes from each maker as
+// much base as the remaining quote budget buys, stopping once the budget
+// cannot buy one base unit at the next level. It sets order.Quantity to the
+// total base bought.
+func (u *orderUseCase) planMarketBuy(order *entity.Order, tx *gorm.DB) ([]marketFill, error) {
+	maxFills := u.maxFills
+	if maxFills <= 0 {
+		maxFills = DefaultMaxFillsPerOrder
 	}
-	matchingOrders, err := u.orderRepository.GetMatchingOrders(
+
+	// Every resting order is priced at or below MaxPrice, so this reads the
+	// whole ask side.
+	now := u.clock.Now()
+	makers, err := u.orderRepository.GetMatchingOrders(
 		tx,
 		order.AccountID,
 		order.InstrumentPair,
-		oppositeOrderType,
-		order.Price,
-		order.OrderType == "BUY",
+		string(entity.OrderTypeSell),
+		decimal.NewFromInt(entity.MaxPrice),
+		true,
+		maxFills,
+		now,
+		u.expiry.createdBefore(now),
 	)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	if len(matchingOrders) == 0 {
-		return nil
+	scale, ok := u.instruments.QuantityScale(order.InstrumentPair)
+	if !ok {
+		scale = orderQuantityScale
 	}
 
-	for _, matchingOrder := range matchingOrders {
-		qty := decimal.Min(order.RemainingQuantity, matchingOrder.RemainingQuantity)
-		if err := u.executor.Execute(tx, order, matchingOrder, qty); err != nil {
-			return err
+	var fills []marketFill
+	budget := order.QuoteQuantity
+	order.Quantity = decimal.Zero
+	for _, maker := range makers {
+		if !maker.RemainingQuantity.IsPositive() || !maker.Price.IsPositive() {
+			continue
 		}
-		if order.RemainingQuantity.IsZero() {
+
+		// QuoRem truncates, where DecimalDiv would round, so the base
+		// bought never costs more than the budget left.
+		affordable, _ := budget.QuoRem(maker.Price, scale)
+		quantity := decimal.Min(affordable, maker.RemainingQuantity)
+		if !quantity.IsPositive() {
 			break
 		}
+
+		fills = append(fills, marketFill{maker: maker, quantity: quantity})
+		budget = budget.Sub(maker.Price.Mul(quantity))
+		order.Quantity = order.Quantity.Add(quantity)
 	}
-	return nil
-}
 
-func (u *orderUseCase) CancelOrder(id uuid.UUID) error {
-	u.log.Infow("canceling order", "id", id)
+	u.log.Infow("planned market buy",
+		"account_id", order.AccountID,
+		"quote_quantity", order.QuoteQuantity,
+		"base_quantity", order.Quantity,
+		"unspent", budget,
+		"fills", len(fills),
+	)
 
-	order, err := u.orderRepository.GetByID(id, string(entity.OrderStatusOpen))
-	if err != nil {
-		return err
-	}
-	if order == nil {
-		return nil
-	}
+	return fills, nil
+}
 
-	if err := u.orderRepository.UpdateStatus(id, string(entity.OrderStatusCancelled)); err != nil {
-		return err
+// executeMarketBuy executes the planned fills of a market buy. Whatever the
+// budget could not buy is not rested: a market buy with no fills is
+// cancelled, and one with fills ends FILLED for the base it bought.
+func (u *orderUseCase) executeMarketBuy(tx *gorm.DB, order *entity.Order, fills []marketFill) error {
+	if len(fills) == 0 {
+		u.log.Infow("market buy found no liquidity within budget, cancelling order", "order_id", order.ID)
+		return u.cancelUnfilled(tx, order)
 	}
 
+	for _, fill := range fills {
+		if err := u.executor.Execute(tx, order, fill.maker, fill.quantity); err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
-func (u *orderUseCase) checkWalletBalance(order *entity.Order, tx *gorm.DB) error {
-	requiredAsset, requiredAmount := order.GetRequiredAssetAndAmount()
+// ImportOrders rests orders on the book as they are, for seeding liquidity:
+// there is no matching and no self-cross or notional check. Each order is
+// still validated and must be a limit order on a supported pair. Since it is
+// never matched, it must not cross the opposite side, whether a live order
+// on the book or an order imported before it in the same batch, of any
+// account. Like a placed order, it must also fit the balance its account has
+// left after what open orders and the batch's earlier orders reserve. The
+// valid orders are inserted batchSize rows per statement
+// (DefaultImportBatchSize when not positive), with an ORDER_CREATED event
+// each, in one transaction; the invalid ones are reported by index and left
+// out.
+func (u *orderUseCase) ImportOrders(ctx context.Context, orders []*entity.Order, batchSize int) (*ImportResult, error) {
+	u.log.Infow("importing orders", "count", len(orders), "batch_size", batchSize)
 
-	wallet, err := u.walletRepository.GetByAccountAndAsset(tx, order.AccountID, requiredAsset)
-	if err != nil {
-		return err
+	if batchSize <= 0 {
+		batchSize = DefaultImportBatchSize
 	}
 
-	if wallet == nil {
-		return errors.New("wallet not found for required asset")
+	result := &ImportResult{}
+	prepared := make([]int, 0, len(orders))
+	pairs := make([]string, 0, len(orders))
+	for i, order := range orders {
+		if err := u.prepareImport(order); err != nil {
+			result.Failures = append(result.Failures, &ImportFailure{Index: i, Err: err})
+			continue
+		}
+		prepared = append(prepared, i)
+		pairs = append(pairs, order.InstrumentPair)
 	}
 
-	if wallet.Balance.LessThan(requiredAmount) {
-		u.log.Errorw("insufficient balance",
-			"account_id", order.AccountID,
-			"asset", requiredAsset)
-		return errors.New("insufficient balance")
+	if len(prepared) > 0 {
+		// The book and balance checks must hold until the orders commit, so
+		// no taker may match on these books in between.
+		unlock := u.pairs.lock(pairs...)
+		defer unlock()
+
+		failures, imported, err := u.insertImported(ctx, orders, prepared, batchSize)
+		if err != nil {
+			u.log.Errorw("failed to import orders", "count", len(prepared), "error", err)
+			return nil, err
+		}
+		result.Failures = append(result.Failures, failures...)
+		sort.Slice(result.Failures, func(i, j int) bool { return result.Failures[i].Index < result.Failures[j].Index })
+		result.Imported = imported
 	}
 
-	return nil
+	u.log.Infow("orders imported", "imported", result.Imported, "failed", len(result.Failures))
+	return result, nil
 }
 
-func (u *orderUseCase) GetOrderBook(instrumentPair string) (*OrderBook, error) {
-	u.log.Infow("getting order book", "instrument_pair", instrumentPair)
+// insertImported checks the prepared orders, by their index in orders,
+// against the book and their accounts' balances, then inserts the ones that
+// pass batchSize rows per statement and appends an ORDER_CREATED event for
+// each, all in one transaction. It returns the orders that failed a check.
+func (u *orderUseCase) insertImported(
+	ctx context.Context,
+	orders []*entity.Order,
+	prepared []int,
+	batchSize int,
+) ([]*ImportFailure, int, error) {
+	tx, err := beginEventTx(ctx, u.db)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback()
+		}
+	}()
 
-	if !entity.IsValidInstrumentPair(instrumentPair) {
-		return nil, entity.ErrInvalidPairFormat
+	var failures []*ImportFailure
+	valid := make([]*entity.Order, 0, len(prepared))
+	batch := newImportBatch()
+	for _, i := range prepared {
+		order := orders[i]
+		if err := u.checkImport(tx, order, batch); err != nil {
+			if _, ok := entity.AsError(err); !ok {
+				tx.Rollback()
+				return nil, 0, err
+			}
+			failures = append(failures, &ImportFailure{Index: i, Err: err})
+			continue
+		}
+		batch.add(order)
+		valid = append(valid, order)
 	}
 
-	orders, err := u.orderRepository.GetOpenOrdersByInstrumentPair(instrumentPair)
-	if err != nil {
-		return nil, err
+	if len(valid) == 0 {
+		tx.Rollback()
+		return failures, 0, nil
 	}
 
-	if len(orders) == 0 {
-		return nil, nil
+	if err := u.orderRepository.CreateInBatches(tx, valid, batchSize); err != nil {
+		tx.Rollback()
+		return nil, 0, err
+	}
+	for _, order := range valid {
+		if err := appendEvent(u.eventRepository, tx, entity.EventTypeOrderCreated, order.ID, order); err != nil {
+			tx.Rollback()
+			return nil, 0, err
+		}
 	}
 
-	orderBook := &OrderBook{
-		InstrumentPair: instrumentPair,
-		Bids:           make([]*OrderBookEntry, 0),
-		Asks:           make([]*OrderBookEntry, 0),
+	if err := commitEventTx(u.eventRepository, tx); err != nil {
+		return nil, 0, err
 	}
+	return failures, len(valid), nil
+}
 
-	bidsMap := make(map[string]decimal.Decimal)
-	asksMap := make(map[string]decimal.Decimal)
+// prepareImport validates order for ImportOrders and makes it an open order
+// holding its reservation.
+func (u *orderUseCase) prepareImport(order *entity.Order) error {
+	normalizePair(order)
 
-	for _, order := range orders {
-		if order.OrderType == "BUY" {
-			bidsMap[order.Price.String()] = bidsMap[order.Price.String()].Add(order.RemainingQuantity)
-		} else {
-			asksMap[order.Price.String()] = asksMap[order.Price.String()].Add(order.RemainingQuantity)
-		}
+	if err := order.Validate(); err != nil {
+		return err
 	}
-
-	bidPrices := make([]decimal.Decimal, 0, len(bidsMap))
-	for p := range bidsMap {
-		bidPrices = append(bidPrices, decimal.RequireFromString(p))
+	if order.IsMarketBuy() {
+		return entity.ErrInvalidPrice
 	}
-	sort.Slice(bidPrices, func(i, j int) bool {
-		return bidPrices[i].GreaterThan(bidPrices[j])
-	})
-	for _, p := range bidPrices {
-		orderBook.Bids = append(orderBook.Bids, &OrderBookEntry{
-			Price:    p,
-			Quantity: bidsMap[p.String()],
-		})
+	if err := u.instruments.ValidatePair(order.InstrumentPair); err != nil {
+		return err
+	}
+	if order.ExpiresAt != nil && !order.ExpiresAt.After(u.clock.Now()) {
+		return entity.ErrInvalidExpiry
 	}
 
-	askPrices := make([]decimal.Decimal, 0, len(asksMap))
-	for p := range asksMap {
-		askPrices = append(askPrices, decimal.RequireFromString(p))
+	order.Status = string(entity.OrderStatusOpen)
+	order.RemainingQuantity = order.Quantity
+	return order.Reserve()
+}
+
+// importBatch tracks the orders of an import accepted so far: the best price
+// on each side of each pair, and what each account and asset has left to
+// reserve.
+type importBatch struct {
+	bestBuy   map[string]decimal.Decimal
+	bestSell  map[string]decimal.Decimal
+	available map[string]decimal.Decimal
+	// missing caches the account and asset pairs that have no wallet.
+	missing map[string]bool
+}
+
+func newImportBatch() *importBatch {
+	return &importBatch{
+		bestBuy:   make(map[string]decimal.Decimal),
+		bestSell:  make(map[string]decimal.Decimal),
+		available: make(map[string]decimal.Decimal),
+		missing:   make(map[string]bool),
 	}
-	sort.Slice(askPrices, func(i, j int) bool {
-		return askPrices[i].LessThan(askPrices[j])
-	})
-	for _, p := range askPrices {
-		orderBook.Asks = append(orderBook.Asks, &OrderBookEntry{
-			Price:    p,
-			Quantity: asksMap[p.String()],
-		})
+}
+
+func importWalletKey(order *entity.Order) string {
+	return order.AccountID.String() + "/" + order.ReservedAsset
+}
+
+// crosses reports whether order would match an order accepted earlier in the
+// batch.
+func (b *importBatch) crosses(order *entity.Order) bool {
+	if order.OrderType == string(entity.OrderTypeBuy) {
+		best, ok := b.bestSell[order.InstrumentPair]
+		return ok && order.Price.GreaterThanOrEqual(best)
 	}
+	best, ok := b.bestBuy[order.InstrumentPair]
+	return ok && order.Price.LessThanOrEqual(best)
+}
 
+func (b *importBatch) add(order *entity.Order) {
+	pair := order.InstrumentPair
+	if order.OrderType == string(entity.OrderTypeBuy) {
+		if best, ok := b.bestBuy[pair]; !ok || order.Price.GreaterThan(best) {
+			b.bestBuy[pair] = order.Price
+		}
+	} else if best, ok := b.bestSell[pair]; !ok || order.Price.LessThan(best) {
+		b.bestSell[pair] = order.Price
+	}
+
+	key := importWalletKey(order)
+	b.available[key] = b.available[key].Sub(order.ReservedAmount)
+}
+
+// checkImport rejects an imported order that crosses the opposite side of
+// its book or the batch, or that its account cannot fund.
+func (u *orderUseCase) checkImport(tx *gorm.DB, order *entity.Order, batch *importBatch) error {
+	if batch.crosses(order) {
+		return entity.ErrImportCrossesBook
+	}
+
+	// uuid.Nil excludes no account, so the account's own orders count too.
+	now := u.clock.Now()
+	crossed, err := u.orderRepository.GetMatchingOrders(
+		tx,
+		uuid.Nil,
+		order.InstrumentPair,
+		order.OppositeType(),
+		order.Price,
+		order.OrderType == string(entity.OrderTypeBuy),
+		1,
+		now,
+		u.expiry.createdBefore(now),
+	)
+	if err != nil {
+		return err
+	}
+	if len(crossed) > 0 {
+		return entity.ErrImportCrossesBook
+	}
+
+	key := importWalletKey(order)
+	if batch.missing[key] {
+		return entity.ErrWalletNotFound
+	}
+	available, seen := batch.available[key]
+	if !seen {
+		wallet, err := u.walletRepository.GetByAccountAndAsset(tx, order.AccountID, order.ReservedAsset)
+		if errors.Is(err, repository.ErrNotFound) {
+			batch.missing[key] = true
+			return entity.ErrWalletNotFound
+		}
+		if err != nil {
+			return err
+		}
+		reserved, err := u.orderRepository.GetReservedAmount(tx, order.AccountID, order.ReservedAsset)
+		if err != nil {
+			return err
+		}
+		available = wallet.Balance.Sub(reserved)
+		batch.available[key] = available
+	}
+
+	if entity.DecimalLess(available, order.ReservedAmount) {
+		return entity.ErrInsufficientBalance
+	}
+	return nil
+}
+
+// ReplaceOrder cancels the open or partially filled order oldID and creates
+// and matches newOrder in a single transaction, so either both happen or
+// neither does. Both orders must belong to the same account. It returns the
+// cancelled order.
+func (u *orderUseCase) ReplaceOrder(ctx context.Context, oldID uuid.UUID, newOrder *entity.Order) (*entity.Order, error) {
+	u.log.Infow("replacing order",
+		"old_order_id", oldID,
+		"account_id", newOrder.AccountID,
+		"type", newOrder.OrderType,
+		"instrument_pair", newOrder.InstrumentPair,
+	)
+
+	old, err := u.orderRepository.GetByID(oldID)
+	if err != nil {
+		return nil, err
+	}
+	if old.AccountID != newOrder.AccountID {
+		return nil, entity.ErrOrderNotOwned
+	}
+
+	normalizePair(newOrder)
+
+	// The old order leaves its own book, which may differ from the new
+	// order's, so both are locked against the matcher and expiry sweeper.
+	unlock := u.pairs.lock(old.InstrumentPair, newOrder.InstrumentPair)
+	defer unlock()
+
+	tx, err := beginEventTx(ctx, u.db, &sql.TxOptions{Isolation: u.isolation})
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback()
+		}
+	}()
+
+	if !isCancellable(old.Status) {
+		tx.Rollback()
+		return nil, entity.ErrOrderNotOpen
+	}
+	cancelled, err := u.orderRepository.UpdateStatusFrom(tx, old.ID, old.Status, string(entity.OrderStatusCancelled))
+	if err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	if !cancelled {
+		tx.Rollback()
+		return nil, entity.ErrOrderNotOpen
+	}
+	old.Status = string(entity.OrderStatusCancelled)
+
+	if err := appendEvent(u.eventRepository, tx, entity.EventTypeOrderCancelled, old.ID, old); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	if err := u.createAndMatch(tx, newOrder); err != nil {
+		tx.Rollback()
+		u.recordRejection(newOrder, err)
+		return nil, err
+	}
+
+	if err := commitEventTx(u.eventRepository, tx); err != nil {
+		return nil, err
+	}
+
+	u.loadTimestamps(newOrder)
+	return old, nil
+}
+
+// checkSelfCross rejects order when self-cross rejection is enabled and it
+// would cross one of the account's own resting orders. Matching skips those
+// makers, so the two would otherwise sit locked on the book forever.
+func (u *orderUseCase) checkSelfCross(order *entity.Order, tx *gorm.DB) error {
+	if !u.rejectSelfCross {
+		return nil
+	}
+
+	resting, err := u.orderRepository.GetByAccountPairSide(tx, order.AccountID, order.InstrumentPair, order.OppositeType(),
+		string(entity.OrderStatusOpen), string(entity.OrderStatusPartial))
+	if err != nil {
+		return err
+	}
+
+	for _, r := range resting {
+		if order.Crosses(r) {
+			u.log.Errorw("order crosses own resting order",
+				"account_id", order.AccountID,
+				"resting_order_id", r.ID,
+				"price", order.Price,
+				"resting_price", r.Price,
+			)
+			return entity.ErrSelfCross
+		}
+	}
+
+	return nil
+}
+
+// checkOpenNotional rejects a buy order when it would take the account's
+// committed notional in the order's quote asset, over its open buy orders
+// plus this one, past the account's cap. A market buy never rests, so it
+// commits no notional.
+func (u *orderUseCase) checkOpenNotional(order *entity.Order, tx *gorm.DB) error {
+	if order.OrderType != string(entity.OrderTypeBuy) || order.IsMarketBuy() {
+		return nil
+	}
+	limit, ok := u.notionalLimits.For(order.AccountID)
+	if !ok {
+		return nil
+	}
+
+	_, quoteAsset, err := entity.SplitInstrumentPair(order.InstrumentPair)
+	if err != nil {
+		return err
+	}
+
+	committed, err := u.orderRepository.GetOpenBuyNotional(tx, order.AccountID, quoteAsset)
+	if err != nil {
+		return err
+	}
+
+	if committed.Add(order.Price.Mul(order.Quantity)).GreaterThan(limit) {
+		u.log.Errorw("max open notional exceeded",
+			"account_id", order.AccountID,
+			"quote_asset", quoteAsset,
+			"committed", committed,
+			"limit", limit,
+		)
+		return entity.ErrMaxNotionalExceeded
+	}
+
+	return nil
+}
+
+func (u *orderUseCase) matchOrder(order *entity.Order, tx *gorm.DB) error {
+	u.log.Infow("matching order",
+		"order_id", order.ID,
+		"type", order.OrderType,
+		"price", order.Price,
+	)
+
+	maxFills := u.maxFills
+	if maxFills <= 0 {
+		maxFills = DefaultMaxFillsPerOrder
+	}
+
+	oppositeOrderType := "SELL"
+	if order.OrderType == "SELL" {
+		oppositeOrderType = "BUY"
+	}
+	now := u.clock.Now()
+	matchingOrders, err := u.orderRepository.GetMatchingOrders(
+		tx,
+		order.AccountID,
+		order.InstrumentPair,
+		oppositeOrderType,
+		order.Price,
+		order.OrderType == "BUY",
+		// One extra maker tells us whether the cap actually cut matching short.
+		maxFills+1,
+		now,
+		u.expiry.createdBefore(now),
+	)
+	if err != nil {
+		return err
+	}
+
+	if order.MinFillQuantity.IsPositive() {
+		if available := matchableQuantity(order, matchingOrders, maxFills); available.LessThan(order.MinFillQuantity) {
+			u.log.Infow("min fill not available, cancelling order",
+				"order_id", order.ID,
+				"min_fill_quantity", order.MinFillQuantity,
+				"available", available,
+			)
+			return u.cancelUnfilled(tx, order)
+		}
+	}
+
+	if len(matchingOrders) == 0 {
+		return nil
+	}
+
+	for i, matchingOrder := range matchingOrders {
+		if i == maxFills {
+			u.log.Warnw("fill limit reached, remaining quantity rests on the book",
+				"order_id", order.ID,
+				"max_fills", maxFills,
+				"remaining_quantity", order.RemainingQuantity,
+			)
+			break
+		}
+		// The query already filters by price; a maker outside the limit
+		// means the list is wrong, and everything after it is suspect too.
+		if !order.Crosses(matchingOrder) {
+			u.log.Errorw("matching order outside the limit price, stopping",
+				"order_id", order.ID,
+				"price", order.Price,
+				"matching_order_id", matchingOrder.ID,
+				"matching_price", matchingOrder.Price,
+			)
+			break
+		}
+		qty := decimal.Min(order.RemainingQuantity, matchingOrder.RemainingQuantity)
+		// Only whole base units can settle. A maker whose remainder is finer
+		// than the base scale is skipped here, not handed to Execute, which
+		// would fail the whole taker.
+		if scale, ok := u.instruments.QuantityScale(order.InstrumentPair); ok {
+			qty = qty.Truncate(scale)
+		}
+		if !qty.IsPositive() {
+			u.log.Warnw("skipping match with nothing to fill",
+				"order_id", order.ID,
+				"matching_order_id", matchingOrder.ID,
+				"matching_remaining_quantity", matchingOrder.RemainingQuantity,
+			)
+			continue
+		}
+		if err := u.executor.Execute(tx, order, matchingOrder, qty); err != nil {
+			return err
+		}
+		if entity.DecimalEqual(order.RemainingQuantity, decimal.Zero) {
+			break
+		}
+	}
+	return nil
+}
+
+// matchableQuantity is how much of order would fill right now against the
+// first maxFills matching orders, stopping where matchOrder would at a maker
+// outside the limit price.
+func matchableQuantity(order *entity.Order, matchingOrders []*entity.Order, maxFills int) decimal.Decimal {
+	available := decimal.Zero
+	for i, matchingOrder := range matchingOrders {
+		if i == maxFills || available.GreaterThanOrEqual(order.RemainingQuantity) || !order.Crosses(matchingOrder) {
+			break
+		}
+		if matchingOrder.RemainingQuantity.IsPositive() {
+			available = available.Add(matchingOrder.RemainingQuantity)
+		}
+	}
+	return decimal.Min(available, order.RemainingQuantity)
+}
+
+// cancelUnfilled cancels an order that was just created and has not traded.
+func (u *orderUseCase) cancelUnfilled(tx *gorm.DB, order *entity.Order) error {
+	if err := u.orderRepository.UpdateStatus(tx, order.ID, string(entity.OrderStatusCancelled)); err != nil {
+		return err
+	}
+	order.Status = string(entity.OrderStatusCancelled)
+
+	return appendEvent(u.eventRepository, tx, entity.EventTypeOrderCancelled, order.ID, order)
+}
+
+// ExpireOrders cancels open and partially filled orders whose expiry has
+// passed and returns how many it cancelled. When a match is in flight on a
+// pair, the sweeper leaves that pair's orders still within the grace window
+// to the match rather than racing it, and cancels them on a later sweep if
+// anything remains; orders past the grace are cancelled once the match ends.
+func (u *orderUseCase) ExpireOrders() (int, error) {
+	now := u.clock.Now()
+
+	orders, err := u.orderRepository.GetExpired(now, u.expiry.createdBefore(now), expiryBatchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	byPair := make(map[string][]*entity.Order)
+	var pairs []string
+	for _, order := range orders {
+		if _, ok := byPair[order.InstrumentPair]; !ok {
+			pairs = append(pairs, order.InstrumentPair)
+		}
+		byPair[order.InstrumentPair] = append(byPair[order.InstrumentPair], order)
+	}
+
+	expired := 0
+	for _, pair := range pairs {
+		due := byPair[pair]
+
+		lock := u.pairs.get(pair)
+		if !lock.TryLock() {
+			due = u.expiry.pastGrace(due, now)
+			if len(due) == 0 {
+				u.log.Infow("match in flight, leaving orders within the expiry grace", "instrument_pair", pair)
+				continue
+			}
+			lock.Lock()
+		}
+
+		n, err := u.expirePairOrders(due)
+		lock.Unlock()
+		if err != nil {
+			return expired, err
+		}
+		expired += n
+	}
+
+	if expired > 0 {
+		u.log.Infow("expired orders", "count", expired)
+	}
+	return expired, nil
+}
+
+// pastGrace returns the orders that expired more than Grace before now.
+func (p ExpiryPolicy) pastGrace(orders []*entity.Order, now time.Time) []*entity.Order {
+	var due []*entity.Order
+	for _, order := range orders {
+		expiresAt, ok := p.expiresAt(order)
+		if ok && !expiresAt.Add(p.Grace).After(now) {
+			due = append(due, order)
+		}
+	}
+	return due
+}
+
+// expirePairOrders cancels orders in one transaction. Each cancel only
+// applies if the order still has the status it was read with, so an order a
+// match filled or changed since is left alone.
+func (u *orderUseCase) expirePairOrders(orders []*entity.Order) (int, error) {
+	tx, err := beginEventTx(context.Background(), u.db)
+	if err != nil {
+		return 0, err
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback()
+		}
+	}()
+
+	expired := 0
+	for _, order := range orders {
+		ok, err := u.orderRepository.UpdateStatusFrom(tx, order.ID, order.Status, string(entity.OrderStatusCancelled))
+		if err != nil {
+			tx.Rollback()
+			return 0, err
+		}
+		if !ok {
+			u.log.Infow("order changed before expiry, skipping", "order_id", order.ID)
+			continue
+		}
+		order.Status = string(entity.OrderStatusCancelled)
+
+		if err := appendEvent(u.eventRepository, tx, entity.EventTypeOrderExpired, order.ID, order); err != nil {
+			tx.Rollback()
+			return 0, err
+		}
+		expired++
+	}
+
+	if err := commitEventTx(u.eventRepository, tx); err != nil {
+		return 0, err
+	}
+	return expired, nil
+}
+
+// CancelOrder cancels an open or partially filled order, releasing what it
+// still reserves, and is safe to retry: cancelling an order that is already
+// cancelled succeeds without another state change. A filled order fails with
+// entity.ErrOrderFilled and a missing one with repository.ErrNotFound. Unless
+// accountID is uuid.Nil, the order must belong to it, or the cancel fails
+// with entity.ErrOrderNotOwned.
+func (u *orderUseCase) CancelOrder(ctx context.Context, id uuid.UUID, accountID uuid.UUID) error {
+	u.log.Infow("canceling order", "id", id, "account_id", accountID)
+
+	order, err := u.orderRepository.GetByID(id)
+	if err != nil {
+		return err
+	}
+	if accountID != uuid.Nil && order.AccountID != accountID {
+		return entity.ErrOrderNotOwned
+	}
+
+	// Statuses only move forward, so a fill racing the cancel can send it
+	// round at most once more, from OPEN to PARTIALLY_FILLED.
+	for isCancellable(order.Status) {
+		cancelled, err := u.cancelFrom(ctx, order)
+		if err != nil {
+			return err
+		}
+		if cancelled {
+			return nil
+		}
+
+		u.log.Infow("order status changed before cancel, reading it again", "id", id, "status", order.Status)
+		if order, err = u.orderRepository.GetByID(id); err != nil {
+			return err
+		}
+	}
+
+	return cancelOutcome(order)
+}
+
+// cancelFrom cancels order if it is still in the status it was read with,
+// appending its ORDER_CANCELLED event in the same transaction. It reports
+// false when the order moved first.
+func (u *orderUseCase) cancelFrom(ctx context.Context, order *entity.Order) (bool, error) {
+	tx, err := beginEventTx(ctx, u.db)
+	if err != nil {
+		return false, err
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback()
+		}
+	}()
+
+	cancelled, err := u.orderRepository.UpdateStatusFrom(tx, order.ID, order.Status, string(entity.OrderStatusCancelled))
+	if err != nil || !cancelled {
+		tx.Rollback()
+		return false, err
+	}
+	order.Status = string(entity.OrderStatusCancelled)
+
+	if err := appendEvent(u.eventRepository, tx, entity.EventTypeOrderCancelled, order.ID, order); err != nil {
+		tx.Rollback()
+		return false, err
+	}
+
+	return true, commitEventTx(u.eventRepository, tx)
+}
+
+// isCancellable reports whether an order in status still rests on the book:
+// OPEN, or PARTIALLY_FILLED with a remainder.
+func isCancellable(status string) bool {
+	return status == string(entity.OrderStatusOpen) || status == string(entity.OrderStatusPartial)
+}
+
+// cancelOutcome is the result of cancelling an order that no longer rests on
+// the book.
+func cancelOutcome(order *entity.Order) error {
+	switch order.Status {
+	case string(entity.OrderStatusCancelled):
+		return nil
+	case string(entity.OrderStatusFilled):
+		return entity.ErrOrderFilled
+	default:
+		return entity.ErrOrderNotOpen
+	}
+}
+
+// CancelOrders cancels the account's resting orders on one side of a pair
+// and returns the ids it cancelled. The pair is normalized like an order's
+// and must be supported, so a filter that could match nothing fails instead
+// of cancelling nothing.
+func (u *orderUseCase) CancelOrders(ctx context.Context, accountID uuid.UUID, instrumentPair string, orderType string) ([]uuid.UUID, error) {
+	u.log.Infow("canceling orders",
+		"account_id", accountID,
+		"instrument_pair", instrumentPair,
+		"type", orderType,
+	)
+
+	instrumentPair, err := entity.NormalizeInstrumentPair(instrumentPair)
+	if err != nil {
+		return nil, err
+	}
+	if err := u.instruments.ValidatePair(instrumentPair); err != nil {
+		return nil, err
+	}
+	if orderType != string(entity.OrderTypeBuy) && orderType != string(entity.OrderTypeSell) {
+		return nil, entity.ErrInvalidOrderType
+	}
+
+	tx, err := beginEventTx(ctx, u.db)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback()
+		}
+	}()
+
+	orders, err := u.orderRepository.GetByAccountPairSide(tx, accountID, instrumentPair, orderType,
+		string(entity.OrderStatusOpen), string(entity.OrderStatusPartial))
+	if err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	cancelled := make([]uuid.UUID, 0, len(orders))
+	for _, order := range orders {
+		ok, err := u.orderRepository.UpdateStatusFrom(tx, order.ID, order.Status, string(entity.OrderStatusCancelled))
+		if err != nil {
+			tx.Rollback()
+			return nil, err
+		}
+		if !ok {
+			continue
+		}
+		order.Status = string(entity.OrderStatusCancelled)
+
+		if err := appendEvent(u.eventRepository, tx, entity.EventTypeOrderCancelled, order.ID, order); err != nil {
+			tx.Rollback()
+			return nil, err
+		}
+		cancelled = append(cancelled, order.ID)
+	}
+
+	if err := commitEventTx(u.eventRepository, tx); err != nil {
+		return nil, err
+	}
+
+	return cancelled, nil
+}
+
+func (u *orderUseCase) GetOrderFills(id uuid.UUID) (*OrderFills, error) {
+	u.log.Infow("getting order fills", "id", id)
+
+	order, err := u.orderRepository.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	trades, err := u.tradeRepository.GetByOrderID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	fills := make([]*Fill, len(trades))
+	remaining := order.Quantity
+	for i, trade := range trades {
+		remaining = remaining.Sub(trade.Quantity)
+		fills[i] = &Fill{Trade: trade, RemainingQuantity: remaining}
+	}
+
+	return &OrderFills{Order: order, Fills: fills}, nil
+}
+
+func (u *orderUseCase) checkWalletBalance(order *entity.Order, tx *gorm.DB) error {
+	requiredAsset, requiredAmount, err := order.GetRequiredAssetAndAmount()
+	if err != nil {
+		return err
+	}
+
+	wallet, err := u.walletRepository.GetByAccountAndAsset(tx, order.AccountID, requiredAsset)
+	if errors.Is(err, repository.ErrNotFound) {
+		return entity.ErrWalletNotFound
+	}
+	if err != nil {
+		return err
+	}
+
+	// What the account's resting orders hold is not available to a new one.
+	reserved, err := u.orderRepository.GetReservedAmount(tx, order.AccountID, requiredAsset)
+	if err != nil {
+		return err
+	}
+
+	if entity.DecimalLess(wallet.Balance.Sub(reserved), requiredAmount) {
+		u.log.Errorw("insufficient balance",
+			"account_id", order.AccountID,
+			"asset", requiredAsset,
+			"reserved", reserved)
+		return entity.ErrInsufficientBalance
+	}
+
+	return nil
+}
+
+// GetBook returns the pair's book in the requested view. For the aggregated
+// view depth is the number of price levels per side, never more than the
+// configured level cap, and zero means the cap itself; for the raw view it
+// is the number of orders per side, clamped as in GetRawOrderBook.
+func (u *orderUseCase) GetBook(instrumentPair string, view BookView, depth int) (*Book, error) {
+	u.log.Infow("getting order book", "instrument_pair", instrumentPair, "view", view, "depth", depth)
+
+	switch view {
+	case BookViewAggregated:
+		orderBook, err := u.aggregateOrderBook(instrumentPair, "", u.bookLevels(depth), decimal.Zero)
+		if err != nil {
+			return nil, err
+		}
+		return &Book{View: view, Aggregated: orderBook}, nil
+	case BookViewRaw:
+		rawBook, err := u.rawOrderBook(instrumentPair, depth)
+		if err != nil {
+			return nil, err
+		}
+		return &Book{View: view, Raw: rawBook}, nil
+	default:
+		return nil, entity.ErrInvalidBookView
+	}
+}
+
+// bookLevels resolves a requested aggregated depth against maxBookLevels.
+func (u *orderUseCase) bookLevels(depth int) int {
+	if depth <= 0 {
+		return u.maxBookLevels
+	}
+	if u.maxBookLevels > 0 && depth > u.maxBookLevels {
+		return u.maxBookLevels
+	}
+	return depth
+}
+
+// GetOrderBook returns the aggregated book, leaving out levels whose total
+// quantity is below minQuantity. Zero keeps every level.
+func (u *orderUseCase) GetOrderBook(instrumentPair string, minQuantity decimal.Decimal) (*OrderBook, error) {
+	if minQuantity.IsNegative() {
+		return nil, entity.ErrInvalidMinQuantity
+	}
+
+	u.log.Infow("getting order book", "instrument_pair", instrumentPair, "min_quantity", minQuantity)
+	return u.aggregateOrderBook(instrumentPair, "", u.maxBookLevels, minQuantity)
+}
+
+// GetOrderBookSide returns one side of the aggregated book, bids or asks, with
+// the other left empty. Orders of the other side are skipped rather than
+// aggregated and dropped. minQuantity filters levels as in GetOrderBook.
+func (u *orderUseCase) GetOrderBookSide(instrumentPair string, side string, minQuantity decimal.Decimal) (*OrderBook, error) {
+	u.log.Infow("getting order book side", "instrument_pair", instrumentPair, "side", side, "min_quantity", minQuantity)
+
+	if side != string(entity.BookSideBid) && side != string(entity.BookSideAsk) {
+		return nil, entity.ErrInvalidSide
+	}
+	if minQuantity.IsNegative() {
+		return nil, entity.ErrInvalidMinQuantity
+	}
+
+	return u.aggregateOrderBook(instrumentPair, entity.BookSide(side), u.maxBookLevels, minQuantity)
+}
+
+// aggregateOrderBook sums the pair's resting orders by price level, keeping
+// the best maxLevels levels per side. Zero keeps every level. Levels whose
+// total is below minQuantity are dropped before the cap, so they do not use
+// up levels. A side other than "" builds only that side. A supported pair
+// with no resting orders has an empty book, not a missing one.
+func (u *orderUseCase) aggregateOrderBook(instrumentPair string, side entity.BookSide, maxLevels int, minQuantity decimal.Decimal) (*OrderBook, error) {
+	if !entity.IsValidInstrumentPair(instrumentPair) {
+		return nil, entity.ErrInvalidPairFormat
+	}
+	if err := u.instruments.ValidatePair(instrumentPair); err != nil {
+		return nil, err
+	}
+
+	now := u.clock.Now()
+	orders, err := u.orderRepository.GetOpenOrdersByInstrumentPair(instrumentPair, now, u.expiry.createdBefore(now))
+	if err != nil {
+		return nil, err
+	}
+
+	orderBook := &OrderBook{
+		InstrumentPair: instrumentPair,
+		Bids:           make([]*OrderBookEntry, 0),
+		Asks:           make([]*OrderBookEntry, 0),
+	}
+
+	bidsMap := make(map[string]decimal.Decimal)
+	asksMap := make(map[string]decimal.Decimal)
+
+	for _, order := range orders {
+		// A corrupt row must not poison the level it would be summed into.
+		if !order.Price.IsPositive() || !order.RemainingQuantity.IsPositive() {
+			u.log.Errorw("skipping order with non-positive price or remaining quantity in order book",
+				"order_id", order.ID,
+				"price", order.Price,
+				"remaining_quantity", order.RemainingQuantity,
+			)
+			continue
+		}
+
+		if order.OrderType == "BUY" {
+			if side == entity.BookSideAsk {
+				continue
+			}
+			bidsMap[order.Price.String()] = bidsMap[order.Price.String()].Add(order.RemainingQuantity)
+		} else {
+			if side == entity.BookSideBid {
+				continue
+			}
+			asksMap[order.Price.String()] = asksMap[order.Price.String()].Add(order.RemainingQuantity)
+		}
+	}
+
+	dropDustLevels(bidsMap, minQuantity)
+	dropDustLevels(asksMap, minQuantity)
+
+	bidPrices := make([]decimal.Decimal, 0, len(bidsMap))
+	for p := range bidsMap {
+		price, err := decimal.NewFromString(p)
+		if err != nil {
+			u.log.Errorw("invalid price level in order book", "instrument_pair", instrumentPair, "price", p)
+			return nil, fmt.Errorf("invalid bid price level %q: %w", p, err)
+		}
+		bidPrices = append(bidPrices, price)
+	}
+	sort.Slice(bidPrices, func(i, j int) bool {
+		return bidPrices[i].GreaterThan(bidPrices[j])
+	})
+	bidPrices = capBookLevels(bidPrices, maxLevels)
+	for _, p := range bidPrices {
+		orderBook.Bids = append(orderBook.Bids, &OrderBookEntry{
+			Price:    p,
+			Quantity: bidsMap[p.String()],
+		})
+	}
+
+	askPrices := make([]decimal.Decimal, 0, len(asksMap))
+	for p := range asksMap {
+		price, err := decimal.NewFromString(p)
+		if err != nil {
+			u.log.Errorw("invalid price level in order book", "instrument_pair", instrumentPair, "price", p)
+			return nil, fmt.Errorf("invalid ask price level %q: %w", p, err)
+		}
+		askPrices = append(askPrices, price)
+	}
+	sort.Slice(askPrices, func(i, j int) bool {
+		return askPrices[i].LessThan(askPrices[j])
+	})
+	askPrices = capBookLevels(askPrices, maxLevels)
+	for _, p := range askPrices {
+		orderBook.Asks = append(orderBook.Asks, &OrderBookEntry{
+			Price:    p,
+			Quantity: asksMap[p.String()],
+		})
+	}
+
+	orderBook.Checksum = u.bookChecksum(orderBook)
 	return orderBook, nil
 }
+
+// dropDustLevels removes the levels whose total quantity is below
+// minQuantity.
+func dropDustLevels(levels map[string]decimal.Decimal, minQuantity decimal.Decimal) {
+	if !minQuantity.IsPositive() {
+		return
+	}
+	for price, quantity := range levels {
+		if quantity.LessThan(minQuantity) {
+			delete(levels, price)
+		}
+	}
+}
+
+// capBookLevels keeps the best maxLevels of a side's prices, already sorted
+// best first. Orders at the dropped prices stay in the database and remain
+// matchable; they are only left out of the aggregated book.
+func capBookLevels(prices []decimal.Decimal, maxLevels int) []decimal.Decimal {
+	if maxLevels > 0 && len(prices) > maxLevels {
+		return prices[:maxLevels]
+	}
+	return prices
+}
+
+// ChecksumLevels is how many levels per side the order book checksum covers.
+const ChecksumLevels = 25
+
+// bookChecksum is the CRC32 (IEEE) of the book's top ChecksumLevels levels
+// per side, serialized as the API shows them: each level is
+// "price:quantity" at the pair's price and quantity scales, levels are
+// joined by "," best first, and the bids part comes before the asks part,
+// separated by "|". An empty side is an empty part, so a book with only
+// asks serializes as "|101.00:0.50000000".
+func (u *orderUseCase) bookChecksum(book *OrderBook) uint32 {
+	side := func(levels []*OrderBookEntry) string {
+		parts := make([]string, 0, min(len(levels), ChecksumLevels))
+		for _, level := range levels[:min(len(levels), ChecksumLevels)] {
+			parts = append(parts, u.instruments.FormatPrice(book.InstrumentPair, level.Price)+":"+
+				u.instruments.FormatQuantity(book.InstrumentPair, level.Quantity))
+		}
+		return strings.Join(parts, ",")
+	}
+
+	return crc32.ChecksumIEEE([]byte(side(book.Bids) + "|" + side(book.Asks)))
+}
+
+// GetRawOrderBook returns the individual resting orders of a pair, at most
+// depth per side, in the order they would be matched: best price first and,
+// within a price, oldest first.
+func (u *orderUseCase) GetRawOrderBook(instrumentPair string, depth int) (*RawOrderBook, error) {
+	book, err := u.GetBook(instrumentPair, BookViewRaw, depth)
+	if err != nil {
+		return nil, err
+	}
+	return book.Raw, nil
+}
+
+func (u *orderUseCase) rawOrderBook(instrumentPair string, depth int) (*RawOrderBook, error) {
+	if !entity.IsValidInstrumentPair(instrumentPair) {
+		return nil, entity.ErrInvalidPairFormat
+	}
+	if err := u.instruments.ValidatePair(instrumentPair); err != nil {
+		return nil, err
+	}
+
+	now := u.clock.Now()
+	orders, err := u.orderRepository.GetOpenOrdersByInstrumentPair(instrumentPair, now, u.expiry.createdBefore(now))
+	if err != nil {
+		return nil, err
+	}
+
+	var bids, asks []*entity.Order
+	for _, order := range orders {
+		if order.OrderType == string(entity.OrderTypeBuy) {
+			bids = append(bids, order)
+		} else {
+			asks = append(asks, order)
+		}
+	}
+
+	sortPriceTime(bids, func(a, b decimal.Decimal) bool { return a.GreaterThan(b) })
+	sortPriceTime(asks, func(a, b decimal.Decimal) bool { return a.LessThan(b) })
+
+	depth = clampRawBookDepth(depth)
+	return &RawOrderBook{
+		InstrumentPair: instrumentPair,
+		Bids:           rawOrderBookEntries(bids, depth),
+		Asks:           rawOrderBookEntries(asks, depth),
+	}, nil
+}
+
+func sortPriceTime(orders []*entity.Order, better func(a, b decimal.Decimal) bool) {
+	sort.SliceStable(orders, func(i, j int) bool {
+		if !orders[i].Price.Equal(orders[j].Price) {
+			return better(orders[i].Price, orders[j].Price)
+		}
+		if !orders[i].CreatedAt.Equal(orders[j].CreatedAt) {
+			return orders[i].CreatedAt.Before(orders[j].CreatedAt)
+		}
+		return orders[i].ID.String() < orders[j].ID.String()
+	})
+}
+
+func rawOrderBookEntries(orders []*entity.Order, depth int) []*RawOrderBookEntry {
+	if len(orders) > depth {
+		orders = orders[:depth]
+	}
+
+	entries := make([]*RawOrderBookEntry, len(orders))
+	for i, order := range orders {
+		entries[i] = &RawOrderBookEntry{
+			OrderID:           order.ID,
+			Price:             order.Price,
+			RemainingQuantity: order.RemainingQuantity,
+			CreatedAt:         order.CreatedAt,
+		}
+	}
+	return entries
+}
+
+func clampRawBookDepth(depth int) int {
+	if depth <= 0 {
+		return DefaultRawBookDepth
+	}
+	if depth > MaxRawBookDepth {
+		return MaxRawBookDepth
+	}
+	return depth
+}
+
+// GetDepth sums the quantity resting at or better than price on one side of
+// the aggregated book: bids priced at or above it, asks at or below it.
+// Every level counts, whatever the cap on the published book.
+func (u *orderUseCase) GetDepth(instrumentPair string, side string, price decimal.Decimal) (decimal.Decimal, error) {
+	u.log.Infow("getting depth",
+		"instrument_pair", instrumentPair,
+		"side", side,
+		"price", price,
+	)
+
+	if side != string(entity.BookSideBid) && side != string(entity.BookSideAsk) {
+		return decimal.Zero, entity.ErrInvalidSide
+	}
+	if !price.IsPositive() {
+		return decimal.Zero, entity.ErrInvalidPrice
+	}
+
+	orderBook, err := u.aggregateOrderBook(instrumentPair, "", 0, decimal.Zero)
+	if err != nil {
+		return decimal.Zero, err
+	}
+
+	total := decimal.Zero
+	if side == string(entity.BookSideBid) {
+		for _, bid := range orderBook.Bids {
+			if bid.Price.LessThan(price) {
+				break
+			}
+			total = total.Add(bid.Quantity)
+		}
+		return total, nil
+	}
+
+	for _, ask := range orderBook.Asks {
+		if ask.Price.GreaterThan(price) {
+			break
+		}
+		total = total.Add(ask.Quantity)
+	}
+	return total, nil
+}
+
+// GetImbalance sums the quantity of the best depth levels of each side of
+// the aggregated book and reports how far it leans to bids or asks. Zero
+// depth takes every level, whatever the cap on the published book.
+func (u *orderUseCase) GetImbalance(instrumentPair string, depth int) (*Imbalance, error) {
+	u.log.Infow("getting order book imbalance", "instrument_pair", instrumentPair, "depth", depth)
+
+	orderBook, err := u.aggregateOrderBook(instrumentPair, "", 0, decimal.Zero)
+	if err != nil {
+		return nil, err
+	}
+
+	imbalance := &Imbalance{
+		InstrumentPair: instrumentPair,
+		Depth:          depth,
+		BidQuantity:    sumLevels(orderBook.Bids, depth),
+		AskQuantity:    sumLevels(orderBook.Asks, depth),
+	}
+	imbalance.Imbalance = imbalance.BidQuantity.Sub(imbalance.AskQuantity)
+
+	if total := imbalance.BidQuantity.Add(imbalance.AskQuantity); total.IsPositive() {
+		ratio := entity.DecimalDiv(imbalance.Imbalance, total)
+		imbalance.Ratio = &ratio
+	}
+
+	return imbalance, nil
+}
+
+// sumLevels adds up the quantity of the first depth levels, or of every
+// level when depth is zero.
+func sumLevels(levels []*OrderBookEntry, depth int) decimal.Decimal {
+	if depth > 0 && depth < len(levels) {
+		levels = levels[:depth]
+	}
+
+	total := decimal.Zero
+	for _, level := range levels {
+		total = total.Add(level.Quantity)
+	}
+	return total
+}
+
+// EstimateCost walks the aggregated book on the side an order of orderType
+// would take from, best price first, and returns what filling quantity would
+// cost without placing anything. Every level is walked, whatever the cap on
+// the published book. When the book is too thin the estimate covers what is
+// available and FullyFillable is false.
+func (u *orderUseCase) EstimateCost(instrumentPair string, orderType string, quantity decimal.Decimal) (*CostEstimate, error) {
+	u.log.Infow("estimating cost",
+		"instrument_pair", instrumentPair,
+		"type", orderType,
+		"quantity", quantity,
+	)
+
+	if orderType != string(entity.OrderTypeBuy) && orderType != string(entity.OrderTypeSell) {
+		return nil, entity.ErrInvalidSide
+	}
+	if !quantity.IsPositive() {
+		return nil, entity.ErrInvalidQuantity
+	}
+
+	estimate := &CostEstimate{
+		InstrumentPair: instrumentPair,
+		OrderType:      orderType,
+		Quantity:       quantity,
+	}
+
+	orderBook, err := u.aggregateOrderBook(instrumentPair, "", 0, decimal.Zero)
+	if err != nil {
+		return nil, err
+	}
+
+	levels := orderBook.Asks
+	if orderType == string(entity.OrderTypeSell) {
+		levels = orderBook.Bids
+	}
+
+	remaining := quantity
+	for _, level := range levels {
+		if !remaining.IsPositive() {
+			break
+		}
+		take := decimal.Min(remaining, level.Quantity)
+		estimate.FilledQuantity = estimate.FilledQuantity.Add(take)
+		estimate.TotalCost = estimate.TotalCost.Add(take.Mul(level.Price))
+		remaining = remaining.Sub(take)
+	}
+
+	if estimate.FilledQuantity.IsPositive() {
+		estimate.AveragePrice = entity.DecimalDiv(estimate.TotalCost, estimate.FilledQuantity)
+	}
+	estimate.FullyFillable = !remaining.IsPositive()
+
+	return estimate, nil
+}
+
+// GetOrderSummary counts the pair's orders by status, limited to orders
+// created in [from, to). Zero times leave that end of the window open.
+func (u *orderUseCase) GetOrderSummary(instrumentPair string, from time.Time, to time.Time) (*OrderSummary, error) {
+	u.log.Infow("getting order summary",
+		"instrument_pair", instrumentPair,
+		"from", from,
+		"to", to,
+	)
+
+	if !entity.IsValidInstrumentPair(instrumentPair) {
+		return nil, entity.ErrInvalidPairFormat
+	}
+	if !from.IsZero() && !to.IsZero() && !from.Before(to) {
+		return nil, entity.ErrInvalidTimeRange
+	}
+
+	counts, err := u.orderRepository.CountByStatus(instrumentPair, from.UTC(), to.UTC())
+	if err != nil {
+		return nil, err
+	}
+
+	return &OrderSummary{
+		InstrumentPair:  instrumentPair,
+		Open:            counts[string(entity.OrderStatusOpen)],
+		PartiallyFilled: counts[string(entity.OrderStatusPartial)],
+		Filled:          counts[string(entity.OrderStatusFilled)],
+		Cancelled:       counts[string(entity.OrderStatusCancelled)],
+	}, nil
+}
+
+// SnapshotSpreads records the best bid and ask of every pair as of takenAt.
+func (u *orderUseCase) SnapshotSpreads(takenAt time.Time) error {
+	count, err := u.orderRepository.SnapshotSpreads(takenAt.UTC(), u.expiry.createdBefore(takenAt.UTC()))
+	if err != nil {
+		u.log.Errorw("failed to snapshot spreads", "taken_at", takenAt, "error", err)
+		return err
+	}
+
+	u.log.Infow("snapshotted spreads", "taken_at", takenAt, "pairs", count)
+	return nil
+}
+
+// GetSpreadHistory returns the pair's spread snapshots over the window
+// ending now. With a nonzero interval, only the last snapshot of each
+// interval (aligned to the Unix epoch) is kept; zero returns them all. No
+// snapshots gives an empty series.
+func (u *orderUseCase) GetSpreadHistory(instrumentPair string, window time.Duration, interval time.Duration) (*SpreadHistory, error) {
+	u.log.Infow("getting spread history",
+		"instrument_pair", instrumentPair,
+		"window", window,
+		"interval", interval,
+	)
+
+	if !entity.IsValidInstrumentPair(instrumentPair) {
+		return nil, entity.ErrInvalidPairFormat
+	}
+	if window <= 0 || window > MaxSpreadWindow {
+		return nil, entity.ErrInvalidWindow
+	}
+	if interval < 0 || interval > window {
+		return nil, entity.ErrInvalidSampleInterval
+	}
+
+	to := u.clock.Now().UTC()
+	from := to.Add(-window)
+
+	snapshots, err := u.orderRepository.GetSpreadSnapshots(instrumentPair, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	return &SpreadHistory{
+		InstrumentPair: instrumentPair,
+		From:           from,
+		To:             to,
+		Snapshots:      sampleSnapshots(snapshots, interval),
+	}, nil
+}
+
+// sampleSnapshots keeps the last of each run of snapshots, oldest first, that
+// fall in the same interval.
+func sampleSnapshots(snapshots []*entity.SpreadSnapshot, interval time.Duration) []*entity.SpreadSnapshot {
+	if interval == 0 {
+		return append([]*entity.SpreadSnapshot{}, snapshots...)
+	}
+
+	sampled := []*entity.SpreadSnapshot{}
+	for i, snapshot := range snapshots {
+		last := i == len(snapshots)-1
+		if last || !snapshots[i+1].TakenAt.Truncate(interval).Equal(snapshot.TakenAt.Truncate(interval)) {
+			sampled = append(sampled, snapshot)
+		}
+	}
+	return sampled
+}
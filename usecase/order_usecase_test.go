@@ -1,8 +1,17 @@
 package usecase
 
 import (
+	"context"
+	"database/sql"
 	"errors"
+	"fmt"
+	"hash/crc32"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/lucas-moura1/mercadobitcoin-challenge/entity"
@@ -11,87 +20,275 @@ import (
 	"github.com/stretchr/testify/assert"
 	"go.uber.org/mock/gomock"
 	"go.uber.org/zap"
+	"gorm.io/driver/postgres"
 	"gorm.io/driver/sqlite"
 	"gorm.io/gorm"
 )
 
+// assertDecimalEqual parses want and got and fails unless they are the same
+// number, whatever their scale, so "1.5" matches "1.50000000". Decimals in
+// these tests must be asserted through it rather than by string equality.
+func assertDecimalEqual(t *testing.T, want, got string) bool {
+	t.Helper()
+	wantDecimal, err := decimal.NewFromString(want)
+	if err != nil {
+		t.Fatalf("invalid expected decimal %q: %v", want, err)
+	}
+	gotDecimal, err := decimal.NewFromString(got)
+	if err != nil {
+		return assert.Fail(t, fmt.Sprintf("invalid decimal %q: %v", got, err))
+	}
+	if !wantDecimal.Equal(gotDecimal) {
+		return assert.Fail(t, fmt.Sprintf("decimals differ:\nexpected: %s\nactual  : %s", want, got))
+	}
+	return true
+}
+
 func newInMemoryDB(t *testing.T) *gorm.DB {
 	t.Helper()
-	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{NowFunc: entity.NowUTC})
+	if err != nil {
+		t.Fatalf("failed to open sqlite in-memory db: %v", err)
+	}
+	return db
+}
+
+// newMigratedDB returns an isolated in-memory database with the schema in
+// place, for tests that exercise the real repositories.
+func newMigratedDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	return openMigratedDB(t, fmt.Sprintf("file:%s?mode=memory&cache=shared", uuid.NewString()))
+}
+
+// newMigratedFileDB is like newMigratedDB but backed by a file, so concurrent
+// transactions wait on each other instead of failing with a locked table.
+func newMigratedFileDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	return openMigratedDB(t, filepath.Join(t.TempDir(), "test.db")+"?_busy_timeout=5000&_journal_mode=WAL")
+}
+
+func openMigratedDB(t *testing.T, dsn string) *gorm.DB {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open(dsn), &gorm.Config{NowFunc: entity.NowUTC})
 	if err != nil {
 		t.Fatalf("failed to open sqlite in-memory db: %v", err)
 	}
+	if err := db.AutoMigrate(&entity.Account{}, &entity.Wallet{}, &entity.WalletSnapshot{}, &entity.SpreadSnapshot{}, &entity.Order{}, &entity.Trade{}, &entity.Event{}, &entity.OrderRejection{}); err != nil {
+		t.Fatalf("failed to migrate sqlite in-memory db: %v", err)
+	}
+	if err := db.Exec("CREATE UNIQUE INDEX idx_wallet_account_asset ON wallet(account_id, asset_symbol)").Error; err != nil {
+		t.Fatalf("failed to create wallet index: %v", err)
+	}
+	return db
+}
+
+// newPostgresDB migrates a throwaway schema on the Postgres server named by
+// TEST_POSTGRES_DSN. Isolation levels are not meaningful on SQLite, so tests
+// that depend on them are skipped when it is unset.
+func newPostgresDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	dsn := os.Getenv("TEST_POSTGRES_DSN")
+	if dsn == "" {
+		t.Skip("TEST_POSTGRES_DSN not set")
+	}
+
+	admin, err := gorm.Open(postgres.Open(dsn), &gorm.Config{NowFunc: entity.NowUTC})
+	if err != nil {
+		t.Fatalf("failed to connect to postgres: %v", err)
+	}
+	schema := "test_" + strings.ReplaceAll(uuid.NewString(), "-", "")
+	if err := admin.Exec("CREATE SCHEMA " + schema).Error; err != nil {
+		t.Fatalf("failed to create schema: %v", err)
+	}
+	t.Cleanup(func() { admin.Exec("DROP SCHEMA " + schema + " CASCADE") })
+
+	db, err := gorm.Open(postgres.Open(dsn+" search_path="+schema), &gorm.Config{NowFunc: entity.NowUTC})
+	if err != nil {
+		t.Fatalf("failed to connect to postgres schema: %v", err)
+	}
+	if err := db.AutoMigrate(&entity.Account{}, &entity.Wallet{}, &entity.WalletSnapshot{}, &entity.Order{}, &entity.Trade{}, &entity.Event{}); err != nil {
+		t.Fatalf("failed to migrate postgres schema: %v", err)
+	}
+	if err := db.Exec("CREATE UNIQUE INDEX idx_wallet_account_asset ON wallet(account_id, asset_symbol)").Error; err != nil {
+		t.Fatalf("failed to create wallet index: %v", err)
+	}
 	return db
 }
 
 func TestOrderUseCase_CancelOrder(t *testing.T) {
-	orderID := uuid.New()
+	orderID, ownerID := uuid.New(), uuid.New()
+
+	orderIn := func(status entity.OrderStatus) *entity.Order {
+		return &entity.Order{Base: entity.Base{ID: orderID}, AccountID: ownerID, Status: string(status)}
+	}
 
 	tests := []struct {
-		name        string
-		setupMock   func(or *repository.MockOrderRepository)
-		wantErr     bool
-		wantNilResp bool
+		name      string
+		accountID uuid.UUID
+		setupMock func(or *repository.MockOrderRepository, er *repository.MockEventRepository)
+		wantErr   error
 	}{
+		{
+			name:      "success - cancelled by its owner",
+			accountID: ownerID,
+			setupMock: func(or *repository.MockOrderRepository, er *repository.MockEventRepository) {
+				or.EXPECT().GetByID(orderID).Return(orderIn(entity.OrderStatusOpen), nil).Times(1)
+				or.EXPECT().
+					UpdateStatusFrom(gomock.Any(), orderID, string(entity.OrderStatusOpen), string(entity.OrderStatusCancelled)).
+					Return(true, nil).
+					Times(1)
+				er.EXPECT().Append(gomock.Any(), gomock.Any()).Return(nil).Times(1)
+			},
+		},
+		{
+			name:      "error - order belongs to another account",
+			accountID: uuid.New(),
+			setupMock: func(or *repository.MockOrderRepository, er *repository.MockEventRepository) {
+				or.EXPECT().GetByID(orderID).Return(orderIn(entity.OrderStatusOpen), nil).Times(1)
+			},
+			wantErr: entity.ErrOrderNotOwned,
+		},
 		{
 			name: "success - cancels open order",
-			setupMock: func(or *repository.MockOrderRepository) {
+			setupMock: func(or *repository.MockOrderRepository, er *repository.MockEventRepository) {
 				or.EXPECT().
-					GetByID(orderID, string(entity.OrderStatusOpen)).
-					Return(&entity.Order{
-						Base:   entity.Base{ID: orderID},
-						Status: string(entity.OrderStatusOpen),
-					}, nil).
+					GetByID(orderID).
+					Return(orderIn(entity.OrderStatusOpen), nil).
 					Times(1)
 
 				or.EXPECT().
-					UpdateStatus(orderID, string(entity.OrderStatusCancelled)).
-					Return(nil).
+					UpdateStatusFrom(gomock.Any(), orderID, string(entity.OrderStatusOpen), string(entity.OrderStatusCancelled)).
+					Return(true, nil).
+					Times(1)
+
+				er.EXPECT().
+					Append(gomock.Any(), gomock.Any()).
+					DoAndReturn(func(_ *gorm.DB, e *entity.Event) error {
+						assert.Equal(t, string(entity.EventTypeOrderCancelled), e.EventType)
+						assert.Equal(t, orderID, e.AggregateID)
+						return nil
+					}).
 					Times(1)
 			},
-			wantErr:     false,
-			wantNilResp: false,
 		},
 		{
-			name: "no-op - order not found",
-			setupMock: func(or *repository.MockOrderRepository) {
+			name: "no-op - order already cancelled",
+			setupMock: func(or *repository.MockOrderRepository, er *repository.MockEventRepository) {
 				or.EXPECT().
-					GetByID(orderID, string(entity.OrderStatusOpen)).
-					Return(nil, nil).
+					GetByID(orderID).
+					Return(orderIn(entity.OrderStatusCancelled), nil).
 					Times(1)
 			},
-			wantErr:     false,
-			wantNilResp: true,
+		},
+		{
+			name: "no-op - concurrent cancel already moved the order",
+			setupMock: func(or *repository.MockOrderRepository, er *repository.MockEventRepository) {
+				gomock.InOrder(
+					or.EXPECT().GetByID(orderID).Return(orderIn(entity.OrderStatusOpen), nil),
+					or.EXPECT().GetByID(orderID).Return(orderIn(entity.OrderStatusCancelled), nil),
+				)
+
+				or.EXPECT().
+					UpdateStatusFrom(gomock.Any(), orderID, string(entity.OrderStatusOpen), string(entity.OrderStatusCancelled)).
+					Return(false, nil).
+					Times(1)
+			},
+		},
+		{
+			name: "error - concurrent fill already moved the order",
+			setupMock: func(or *repository.MockOrderRepository, er *repository.MockEventRepository) {
+				gomock.InOrder(
+					or.EXPECT().GetByID(orderID).Return(orderIn(entity.OrderStatusOpen), nil),
+					or.EXPECT().GetByID(orderID).Return(orderIn(entity.OrderStatusFilled), nil),
+				)
+
+				or.EXPECT().
+					UpdateStatusFrom(gomock.Any(), orderID, string(entity.OrderStatusOpen), string(entity.OrderStatusCancelled)).
+					Return(false, nil).
+					Times(1)
+			},
+			wantErr: entity.ErrOrderFilled,
+		},
+		{
+			name: "error - order filled",
+			setupMock: func(or *repository.MockOrderRepository, er *repository.MockEventRepository) {
+				or.EXPECT().
+					GetByID(orderID).
+					Return(orderIn(entity.OrderStatusFilled), nil).
+					Times(1)
+			},
+			wantErr: entity.ErrOrderFilled,
+		},
+		{
+			name: "success - cancels the remainder of a partially filled order",
+			setupMock: func(or *repository.MockOrderRepository, er *repository.MockEventRepository) {
+				or.EXPECT().
+					GetByID(orderID).
+					Return(orderIn(entity.OrderStatusPartial), nil).
+					Times(1)
+
+				or.EXPECT().
+					UpdateStatusFrom(gomock.Any(), orderID, string(entity.OrderStatusPartial), string(entity.OrderStatusCancelled)).
+					Return(true, nil).
+					Times(1)
+
+				er.EXPECT().Append(gomock.Any(), gomock.Any()).Return(nil).Times(1)
+			},
+		},
+		{
+			name: "success - concurrent fill left a remainder to cancel",
+			setupMock: func(or *repository.MockOrderRepository, er *repository.MockEventRepository) {
+				gomock.InOrder(
+					or.EXPECT().GetByID(orderID).Return(orderIn(entity.OrderStatusOpen), nil),
+					or.EXPECT().GetByID(orderID).Return(orderIn(entity.OrderStatusPartial), nil),
+				)
+
+				or.EXPECT().
+					UpdateStatusFrom(gomock.Any(), orderID, string(entity.OrderStatusOpen), string(entity.OrderStatusCancelled)).
+					Return(false, nil).
+					Times(1)
+				or.EXPECT().
+					UpdateStatusFrom(gomock.Any(), orderID, string(entity.OrderStatusPartial), string(entity.OrderStatusCancelled)).
+					Return(true, nil).
+					Times(1)
+
+				er.EXPECT().Append(gomock.Any(), gomock.Any()).Return(nil).Times(1)
+			},
+		},
+		{
+			name: "error - order not found",
+			setupMock: func(or *repository.MockOrderRepository, er *repository.MockEventRepository) {
+				or.EXPECT().
+					GetByID(orderID).
+					Return(nil, repository.ErrNotFound).
+					Times(1)
+			},
+			wantErr: repository.ErrNotFound,
 		},
 		{
 			name: "error - GetByID fails",
-			setupMock: func(or *repository.MockOrderRepository) {
+			setupMock: func(or *repository.MockOrderRepository, er *repository.MockEventRepository) {
 				or.EXPECT().
-					GetByID(orderID, string(entity.OrderStatusOpen)).
-					Return(nil, errors.New("db error")).
+					GetByID(orderID).
+					Return(nil, assert.AnError).
 					Times(1)
 			},
-			wantErr:     true,
-			wantNilResp: true,
+			wantErr: assert.AnError,
 		},
 		{
 			name: "error - UpdateStatus fails",
-			setupMock: func(or *repository.MockOrderRepository) {
+			setupMock: func(or *repository.MockOrderRepository, er *repository.MockEventRepository) {
 				or.EXPECT().
-					GetByID(orderID, string(entity.OrderStatusOpen)).
-					Return(&entity.Order{
-						Base:   entity.Base{ID: orderID},
-						Status: string(entity.OrderStatusOpen),
-					}, nil).
+					GetByID(orderID).
+					Return(orderIn(entity.OrderStatusOpen), nil).
 					Times(1)
 
 				or.EXPECT().
-					UpdateStatus(orderID, string(entity.OrderStatusCancelled)).
-					Return(errors.New("update failed")).
+					UpdateStatusFrom(gomock.Any(), orderID, string(entity.OrderStatusOpen), string(entity.OrderStatusCancelled)).
+					Return(false, assert.AnError).
 					Times(1)
 			},
-			wantErr:     true,
-			wantNilResp: false,
+			wantErr: assert.AnError,
 		},
 	}
 
@@ -102,27 +299,198 @@ func TestOrderUseCase_CancelOrder(t *testing.T) {
 			orderRepo := repository.NewMockOrderRepository(ctrl)
 			walletRepo := repository.NewMockWalletRepository(ctrl)
 			tradeRepo := repository.NewMockTradeRepository(ctrl)
+			eventRepo := repository.NewMockEventRepository(ctrl)
 
-			tt.setupMock(orderRepo)
+			tt.setupMock(orderRepo, eventRepo)
 			uc := NewOrderUseCase(
 				zap.NewNop().Sugar(),
 				orderRepo,
 				walletRepo,
 				tradeRepo,
+				eventRepo,
 				nil,
-			)
+				newInMemoryDB(t),
+				OrderUseCaseConfig{})
 
-			err := uc.CancelOrder(orderID)
+			err := uc.CancelOrder(context.Background(), orderID, tt.accountID)
 
-			if tt.wantErr {
-				assert.Error(t, err)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
 				return
 			}
-			assert.Nil(t, err)
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestOrderUseCase_CancelOrder_Concurrent(t *testing.T) {
+	log := zap.NewNop().Sugar()
+	db := newMigratedFileDB(t)
+	orderRepo := repository.NewOrderRepository(log, db)
+	uc := NewOrderUseCase(log, orderRepo, repository.NewWalletRepository(log, db, nil), repository.NewTradeRepository(log, db), repository.NewEventRepository(log, db), nil, db, OrderUseCaseConfig{})
+
+	order := &entity.Order{
+		AccountID:         uuid.New(),
+		InstrumentPair:    "BTC_BRL",
+		OrderType:         string(entity.OrderTypeBuy),
+		Price:             decimal.RequireFromString("100"),
+		Quantity:          decimal.RequireFromString("1"),
+		RemainingQuantity: decimal.RequireFromString("1"),
+		Status:            string(entity.OrderStatusOpen),
+	}
+	if err := orderRepo.Create(nil, order); err != nil {
+		t.Fatalf("failed to seed order: %v", err)
+	}
+
+	const callers = 8
+	var wg sync.WaitGroup
+	errs := make(chan error, callers)
+	for i := 0; i < callers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs <- uc.CancelOrder(context.Background(), order.ID, uuid.Nil)
+		}()
+	}
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		assert.NoError(t, err)
+	}
+
+	got, err := orderRepo.GetByID(order.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, string(entity.OrderStatusCancelled), got.Status)
+
+	var cancels int64
+	assert.NoError(t, db.Model(&entity.Event{}).
+		Where("event_type = ? AND aggregate_id = ?", string(entity.EventTypeOrderCancelled), order.ID).
+		Count(&cancels).Error)
+	assert.Equal(t, int64(1), cancels)
+}
+
+func TestOrderUseCase_CreateOrder_ConcurrentTakersIsolation(t *testing.T) {
+	for _, isolation := range []sql.IsolationLevel{sql.LevelRepeatableRead, sql.LevelSerializable} {
+		t.Run(isolation.String(), func(t *testing.T) {
+			log := zap.NewNop().Sugar()
+			db := newPostgresDB(t)
+			orderRepo := repository.NewOrderRepository(log, db)
+			walletRepo := repository.NewWalletRepository(log, db, nil)
+			tradeRepo := repository.NewTradeRepository(log, db)
+			uc := NewOrderUseCase(log, orderRepo, walletRepo, tradeRepo, repository.NewEventRepository(log, db), nil, db, OrderUseCaseConfig{Isolation: isolation})
+
+			seller, buyers := uuid.New(), []uuid.UUID{uuid.New(), uuid.New(), uuid.New(), uuid.New()}
+			assert.NoError(t, walletRepo.Create(nil, &entity.Wallet{AccountID: seller, AssetSymbol: "BTC", Balance: decimal.RequireFromString("1")}))
+			assert.NoError(t, walletRepo.Create(nil, &entity.Wallet{AccountID: seller, AssetSymbol: "BRL", Balance: decimal.Zero}))
+			for _, buyer := range buyers {
+				assert.NoError(t, walletRepo.Create(nil, &entity.Wallet{AccountID: buyer, AssetSymbol: "BRL", Balance: decimal.RequireFromString("1000")}))
+				assert.NoError(t, walletRepo.Create(nil, &entity.Wallet{AccountID: buyer, AssetSymbol: "BTC", Balance: decimal.Zero}))
+			}
+
+			maker := &entity.Order{
+				AccountID:      seller,
+				InstrumentPair: "BTC_BRL",
+				OrderType:      string(entity.OrderTypeSell),
+				Price:          decimal.RequireFromString("100"),
+				Quantity:       decimal.RequireFromString("1"),
+			}
+			assert.NoError(t, uc.CreateOrder(context.Background(), maker))
+
+			var wg sync.WaitGroup
+			for _, buyer := range buyers {
+				wg.Add(1)
+				go func(buyer uuid.UUID) {
+					defer wg.Done()
+					// A taker that loses a serialization conflict fails and
+					// rolls back; it must never fill the maker a second time.
+					uc.CreateOrder(context.Background(), &entity.Order{
+						AccountID:      buyer,
+						InstrumentPair: "BTC_BRL",
+						OrderType:      string(entity.OrderTypeBuy),
+						Price:          decimal.RequireFromString("100"),
+						Quantity:       decimal.RequireFromString("1"),
+					})
+				}(buyer)
+			}
+			wg.Wait()
+
+			trades, err := tradeRepo.GetByOrderID(maker.ID)
+			assert.NoError(t, err)
+			filled := decimal.Zero
+			for _, trade := range trades {
+				filled = filled.Add(trade.Quantity)
+			}
+			assert.True(t, filled.LessThanOrEqual(maker.Quantity), "maker filled %s of %s", filled, maker.Quantity)
+
+			sellerBTC, err := walletRepo.GetByAccountAndAsset(db, seller, "BTC")
+			assert.NoError(t, err)
+			assert.False(t, sellerBTC.Balance.IsNegative(), "seller BTC balance %s", sellerBTC.Balance)
 		})
 	}
 }
 
+// TestOrderUseCase_CancelRacingTaker_NoDeadlock cancels a maker while a
+// taker fills it. The cancel locks the maker row and the taker the event log
+// before either appends, so unless both take the event lock first the two
+// wait on each other and Postgres aborts one as a deadlock.
+func TestOrderUseCase_CancelRacingTaker_NoDeadlock(t *testing.T) {
+	log := zap.NewNop().Sugar()
+	db := newPostgresDB(t)
+	orderRepo := repository.NewOrderRepository(log, db)
+	walletRepo := repository.NewWalletRepository(log, db, nil)
+	uc := NewOrderUseCase(log, orderRepo, walletRepo, repository.NewTradeRepository(log, db), repository.NewEventRepository(log, db), nil, db, OrderUseCaseConfig{})
+
+	seller, buyer := uuid.New(), uuid.New()
+	assert.NoError(t, walletRepo.Create(nil, &entity.Wallet{AccountID: seller, AssetSymbol: "BTC", Balance: decimal.RequireFromString("100")}))
+	assert.NoError(t, walletRepo.Create(nil, &entity.Wallet{AccountID: seller, AssetSymbol: "BRL", Balance: decimal.Zero}))
+	assert.NoError(t, walletRepo.Create(nil, &entity.Wallet{AccountID: buyer, AssetSymbol: "BRL", Balance: decimal.RequireFromString("100000")}))
+	assert.NoError(t, walletRepo.Create(nil, &entity.Wallet{AccountID: buyer, AssetSymbol: "BTC", Balance: decimal.Zero}))
+
+	for i := 0; i < 20; i++ {
+		maker := &entity.Order{
+			AccountID:      seller,
+			InstrumentPair: "BTC_BRL",
+			OrderType:      string(entity.OrderTypeSell),
+			Price:          decimal.RequireFromString("100"),
+			Quantity:       decimal.RequireFromString("1"),
+		}
+		if !assert.NoError(t, uc.CreateOrder(context.Background(), maker)) {
+			return
+		}
+
+		var wg sync.WaitGroup
+		var takerErr, cancelErr error
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			takerErr = uc.CreateOrder(context.Background(), &entity.Order{
+				AccountID:      buyer,
+				InstrumentPair: "BTC_BRL",
+				OrderType:      string(entity.OrderTypeBuy),
+				Price:          decimal.RequireFromString("100"),
+				Quantity:       decimal.RequireFromString("0.5"),
+			})
+		}()
+		go func() {
+			defer wg.Done()
+			cancelErr = uc.CancelOrder(context.Background(), maker.ID, seller)
+		}()
+		wg.Wait()
+
+		assert.NoError(t, takerErr)
+		assert.NoError(t, cancelErr)
+
+		got, err := orderRepo.GetByID(maker.ID)
+		assert.NoError(t, err)
+		assert.Equal(t, string(entity.OrderStatusCancelled), got.Status)
+
+		// Whatever the taker left resting would fill the next maker.
+		_, err = uc.CancelOrders(context.Background(), buyer, "BTC_BRL", string(entity.OrderTypeBuy))
+		assert.NoError(t, err)
+	}
+}
+
 func TestOrderUseCase_GetOrderBook(t *testing.T) {
 	tests := []struct {
 		name           string
@@ -146,7 +514,7 @@ func TestOrderUseCase_GetOrderBook(t *testing.T) {
 					{OrderType: string(entity.OrderTypeSell), Price: decimal.RequireFromString("101"), RemainingQuantity: decimal.RequireFromString("0.3")},
 				}
 				or.EXPECT().
-					GetOpenOrdersByInstrumentPair("BTC_BRL").
+					GetOpenOrdersByInstrumentPair("BTC_BRL", gomock.Any(), gomock.Any()).
 					Return(orders, nil).
 					Times(1)
 			},
@@ -166,26 +534,13 @@ func TestOrderUseCase_GetOrderBook(t *testing.T) {
 			instrumentPair: "BTC_BRL",
 			mockSetup: func(or *repository.MockOrderRepository) {
 				or.EXPECT().
-					GetOpenOrdersByInstrumentPair("BTC_BRL").
+					GetOpenOrdersByInstrumentPair("BTC_BRL", gomock.Any(), gomock.Any()).
 					Return(nil, errors.New("db error")).
 					Times(1)
 			},
 			wantErr:     true,
 			wantNilResp: true,
 		},
-		{
-			name:           "no open orders",
-			instrumentPair: "BTC_BRL",
-			mockSetup: func(or *repository.MockOrderRepository) {
-				or.EXPECT().
-					GetOpenOrdersByInstrumentPair("BTC_BRL").
-					Return(nil, nil).
-					Times(1)
-			},
-			wantErr:     false,
-			errIs:       nil,
-			wantNilResp: true,
-		},
 	}
 
 	for _, tt := range tests {
@@ -198,9 +553,9 @@ func TestOrderUseCase_GetOrderBook(t *testing.T) {
 
 			tt.mockSetup(orderRepo)
 
-			uc := NewOrderUseCase(zap.NewNop().Sugar(), orderRepo, walletRepo, tradeRepo, nil)
+			uc := NewOrderUseCase(zap.NewNop().Sugar(), orderRepo, walletRepo, tradeRepo, nil, nil, nil, OrderUseCaseConfig{})
 
-			ob, err := uc.GetOrderBook(tt.instrumentPair)
+			ob, err := uc.GetOrderBook(tt.instrumentPair, decimal.Zero)
 
 			if tt.wantErr {
 				assert.Error(t, err)
@@ -220,22 +575,109 @@ func TestOrderUseCase_GetOrderBook(t *testing.T) {
 			assert.Equal(t, "BTC_BRL", ob.InstrumentPair)
 
 			if assert.Len(t, ob.Bids, 2) {
-				assert.Equal(t, "100", ob.Bids[0].Price.String())
-				assert.Equal(t, "1.4", ob.Bids[0].Quantity.String()) // 1.0 + 0.4
-				assert.Equal(t, "99", ob.Bids[1].Price.String())
-				assert.Equal(t, "2", ob.Bids[1].Quantity.String())
+				assertDecimalEqual(t, "100", ob.Bids[0].Price.String())
+				assertDecimalEqual(t, "1.4", ob.Bids[0].Quantity.String()) // 1.0 + 0.4
+				assertDecimalEqual(t, "99", ob.Bids[1].Price.String())
+				assertDecimalEqual(t, "2", ob.Bids[1].Quantity.String())
 			}
 
 			if assert.Len(t, ob.Asks, 2) {
-				assert.Equal(t, "101", ob.Asks[0].Price.String())
-				assert.Equal(t, "0.8", ob.Asks[0].Quantity.String()) // 0.5 + 0.3
-				assert.Equal(t, "103", ob.Asks[1].Price.String())
-				assert.Equal(t, "0.2", ob.Asks[1].Quantity.String())
+				assertDecimalEqual(t, "101", ob.Asks[0].Price.String())
+				assertDecimalEqual(t, "0.8", ob.Asks[0].Quantity.String()) // 0.5 + 0.3
+				assertDecimalEqual(t, "103", ob.Asks[1].Price.String())
+				assertDecimalEqual(t, "0.2", ob.Asks[1].Quantity.String())
 			}
 		})
 	}
 }
 
+func TestOrderUseCase_GetOrderBook_PartiallyFilledRests(t *testing.T) {
+	uc, _, buyerID, sellerID := newExpiryTestUseCase(t, newFakeClock(time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)), ExpiryPolicy{})
+
+	maker := &entity.Order{
+		AccountID:      sellerID,
+		InstrumentPair: "BTC_BRL",
+		OrderType:      string(entity.OrderTypeSell),
+		Price:          decimal.RequireFromString("100"),
+		Quantity:       decimal.RequireFromString("1"),
+	}
+	assert.NoError(t, uc.CreateOrder(context.Background(), maker))
+	assert.NoError(t, uc.CreateOrder(context.Background(), &entity.Order{
+		AccountID:      buyerID,
+		InstrumentPair: "BTC_BRL",
+		OrderType:      string(entity.OrderTypeBuy),
+		Price:          decimal.RequireFromString("100"),
+		Quantity:       decimal.RequireFromString("0.4"),
+	}))
+
+	stored, err := uc.orderRepository.GetByID(maker.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, string(entity.OrderStatusPartial), stored.Status)
+
+	// The maker's 0.6 remainder is still on the book everywhere it is read.
+	book, err := uc.GetOrderBook("BTC_BRL", decimal.Zero)
+	assert.NoError(t, err)
+	if assert.Len(t, book.Asks, 1) {
+		assertDecimalEqual(t, "100", book.Asks[0].Price.String())
+		assertDecimalEqual(t, "0.6", book.Asks[0].Quantity.String())
+	}
+	assert.Empty(t, book.Bids)
+	assert.NotEqual(t, uc.bookChecksum(&OrderBook{InstrumentPair: "BTC_BRL"}), uc.bookChecksum(book))
+
+	raw, err := uc.GetRawOrderBook("BTC_BRL", 0)
+	assert.NoError(t, err)
+	if assert.Len(t, raw.Asks, 1) {
+		assert.Equal(t, maker.ID, raw.Asks[0].OrderID)
+	}
+
+	depth, err := uc.GetDepth("BTC_BRL", string(entity.BookSideAsk), decimal.RequireFromString("100"))
+	assert.NoError(t, err)
+	assertDecimalEqual(t, "0.6", depth.String())
+
+	imbalance, err := uc.GetImbalance("BTC_BRL", 1)
+	assert.NoError(t, err)
+	assertDecimalEqual(t, "0.6", imbalance.AskQuantity.String())
+
+	estimate, err := uc.EstimateCost("BTC_BRL", "BUY", decimal.RequireFromString("0.5"))
+	assert.NoError(t, err)
+	assert.True(t, estimate.FullyFillable)
+	assertDecimalEqual(t, "50", estimate.TotalCost.String())
+}
+
+func TestOrderUseCase_GetOrderBook_NoLiquidity(t *testing.T) {
+	instruments := entity.NewInstrumentConfig(entity.Asset{Symbol: "BTC", Scale: 8}, entity.Asset{Symbol: "BRL", Scale: 2})
+
+	t.Run("supported pair without orders has an empty book", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+		orderRepo := repository.NewMockOrderRepository(ctrl)
+		orderRepo.EXPECT().GetOpenOrdersByInstrumentPair("BTC_BRL", gomock.Any(), gomock.Any()).Return(nil, nil).Times(1)
+		uc := NewOrderUseCase(zap.NewNop().Sugar(), orderRepo, nil, nil, nil, nil, nil, OrderUseCaseConfig{Instruments: instruments})
+
+		ob, err := uc.GetOrderBook("BTC_BRL", decimal.Zero)
+
+		if assert.NoError(t, err) {
+			assert.Equal(t, "BTC_BRL", ob.InstrumentPair)
+			assert.NotNil(t, ob.Bids)
+			assert.Empty(t, ob.Bids)
+			assert.NotNil(t, ob.Asks)
+			assert.Empty(t, ob.Asks)
+		}
+	})
+
+	t.Run("unsupported pair", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+		orderRepo := repository.NewMockOrderRepository(ctrl)
+		uc := NewOrderUseCase(zap.NewNop().Sugar(), orderRepo, nil, nil, nil, nil, nil, OrderUseCaseConfig{Instruments: instruments})
+
+		ob, err := uc.GetOrderBook("XRP_BRL", decimal.Zero)
+
+		assert.ErrorIs(t, err, entity.ErrUnsupportedAsset)
+		assert.Nil(t, ob)
+	})
+}
+
 func TestOrderUseCase_CreateOrder(t *testing.T) {
 	accountID := uuid.New()
 	validBuy := &entity.Order{
@@ -288,6 +730,10 @@ func TestOrderUseCase_CreateOrder(t *testing.T) {
 					GetByAccountAndAsset(gomock.Any(), o.AccountID, "BRL").
 					Return(&entity.Wallet{AccountID: o.AccountID, AssetSymbol: "BRL", Balance: required}, nil).
 					Times(1)
+				or.EXPECT().
+					GetReservedAmount(gomock.Any(), o.AccountID, "BRL").
+					Return(decimal.Zero, nil).
+					Times(1)
 
 				or.EXPECT().
 					Create(gomock.Any(), o).
@@ -295,9 +741,14 @@ func TestOrderUseCase_CreateOrder(t *testing.T) {
 					Times(1)
 
 				or.EXPECT().
-					GetMatchingOrders(gomock.Any(), o.AccountID, o.InstrumentPair, "SELL", o.Price, true).
+					GetMatchingOrders(gomock.Any(), o.AccountID, o.InstrumentPair, "SELL", o.Price, true, DefaultMaxFillsPerOrder+1, gomock.Any(), gomock.Any()).
 					Return([]*entity.Order{}, nil).
 					Times(1)
+
+				or.EXPECT().
+					GetByID(o.ID).
+					Return(o, nil).
+					Times(1)
 			},
 			wantErr: false,
 		},
@@ -314,6 +765,10 @@ func TestOrderUseCase_CreateOrder(t *testing.T) {
 					GetByAccountAndAsset(gomock.Any(), o.AccountID, "BTC").
 					Return(&entity.Wallet{AccountID: o.AccountID, AssetSymbol: "BTC", Balance: o.Quantity}, nil).
 					Times(1)
+				or.EXPECT().
+					GetReservedAmount(gomock.Any(), o.AccountID, "BTC").
+					Return(decimal.Zero, nil).
+					Times(1)
 
 				or.EXPECT().
 					Create(gomock.Any(), o).
@@ -321,9 +776,14 @@ func TestOrderUseCase_CreateOrder(t *testing.T) {
 					Times(1)
 
 				or.EXPECT().
-					GetMatchingOrders(gomock.Any(), o.AccountID, o.InstrumentPair, "BUY", o.Price, false).
+					GetMatchingOrders(gomock.Any(), o.AccountID, o.InstrumentPair, "BUY", o.Price, false, DefaultMaxFillsPerOrder+1, gomock.Any(), gomock.Any()).
 					Return([]*entity.Order{}, nil).
 					Times(1)
+
+				or.EXPECT().
+					GetByID(o.ID).
+					Return(o, nil).
+					Times(1)
 			},
 			wantErr: false,
 		},
@@ -350,7 +810,7 @@ func TestOrderUseCase_CreateOrder(t *testing.T) {
 			) {
 				wr.EXPECT().
 					GetByAccountAndAsset(gomock.Any(), o.AccountID, "BRL").
-					Return(nil, nil).
+					Return(nil, repository.ErrNotFound).
 					Times(1)
 			},
 			wantErr: true,
@@ -370,6 +830,10 @@ func TestOrderUseCase_CreateOrder(t *testing.T) {
 					GetByAccountAndAsset(gomock.Any(), o.AccountID, "BRL").
 					Return(&entity.Wallet{AccountID: o.AccountID, AssetSymbol: "BRL", Balance: insufficient}, nil).
 					Times(1)
+				or.EXPECT().
+					GetReservedAmount(gomock.Any(), o.AccountID, "BRL").
+					Return(decimal.Zero, nil).
+					Times(1)
 			},
 			wantErr: true,
 		},
@@ -387,6 +851,10 @@ func TestOrderUseCase_CreateOrder(t *testing.T) {
 					GetByAccountAndAsset(gomock.Any(), o.AccountID, "BRL").
 					Return(&entity.Wallet{AccountID: o.AccountID, AssetSymbol: "BRL", Balance: required}, nil).
 					Times(1)
+				or.EXPECT().
+					GetReservedAmount(gomock.Any(), o.AccountID, "BRL").
+					Return(decimal.Zero, nil).
+					Times(1)
 
 				or.EXPECT().
 					Create(gomock.Any(), o).
@@ -409,6 +877,10 @@ func TestOrderUseCase_CreateOrder(t *testing.T) {
 					GetByAccountAndAsset(gomock.Any(), o.AccountID, "BRL").
 					Return(&entity.Wallet{AccountID: o.AccountID, AssetSymbol: "BRL", Balance: required}, nil).
 					Times(1)
+				or.EXPECT().
+					GetReservedAmount(gomock.Any(), o.AccountID, "BRL").
+					Return(decimal.Zero, nil).
+					Times(1)
 
 				or.EXPECT().
 					Create(gomock.Any(), o).
@@ -416,7 +888,7 @@ func TestOrderUseCase_CreateOrder(t *testing.T) {
 					Times(1)
 
 				or.EXPECT().
-					GetMatchingOrders(gomock.Any(), o.AccountID, o.InstrumentPair, "SELL", o.Price, true).
+					GetMatchingOrders(gomock.Any(), o.AccountID, o.InstrumentPair, "SELL", o.Price, true, DefaultMaxFillsPerOrder+1, gomock.Any(), gomock.Any()).
 					Return(nil, assert.AnError).
 					Times(1)
 			},
@@ -434,11 +906,13 @@ func TestOrderUseCase_CreateOrder(t *testing.T) {
 			orderRepo := repository.NewMockOrderRepository(ctrl)
 			walletRepo := repository.NewMockWalletRepository(ctrl)
 			tradeRepo := repository.NewMockTradeRepository(ctrl)
+			eventRepo := repository.NewMockEventRepository(ctrl)
+			eventRepo.EXPECT().Append(gomock.Any(), gomock.Any()).Return(nil).AnyTimes()
 
 			tt.mockSetup(orderRepo, walletRepo, tradeRepo, tt.args.order)
 
-			uc := NewOrderUseCase(zap.NewNop().Sugar(), orderRepo, walletRepo, tradeRepo, db)
-			err := uc.CreateOrder(tt.args.order)
+			uc := NewOrderUseCase(zap.NewNop().Sugar(), orderRepo, walletRepo, tradeRepo, eventRepo, nil, db, OrderUseCaseConfig{})
+			err := uc.CreateOrder(context.Background(), tt.args.order)
 
 			if tt.wantErr {
 				assert.Error(t, err)
@@ -494,7 +968,7 @@ func TestOrderUseCase_matchOrder(t *testing.T) {
 					RemainingQuantity: decimal.RequireFromString("0.4"),
 				}
 				or.EXPECT().
-					GetMatchingOrders(gomock.Any(), o.AccountID, o.InstrumentPair, "SELL", o.Price, true).
+					GetMatchingOrders(gomock.Any(), o.AccountID, o.InstrumentPair, "SELL", o.Price, true, DefaultMaxFillsPerOrder+1, gomock.Any(), gomock.Any()).
 					Return([]*entity.Order{m1}, nil).
 					Times(1)
 				return []*entity.Order{m1}
@@ -522,7 +996,7 @@ func TestOrderUseCase_matchOrder(t *testing.T) {
 				m2 := &entity.Order{AccountID: uuid.New(), OrderType: string(entity.OrderTypeBuy), Price: decimal.RequireFromString("102"), RemainingQuantity: decimal.RequireFromString("0.6")}
 				m3 := &entity.Order{AccountID: uuid.New(), OrderType: string(entity.OrderTypeBuy), Price: decimal.RequireFromString("103"), RemainingQuantity: decimal.RequireFromString("0.5")}
 				or.EXPECT().
-					GetMatchingOrders(gomock.Any(), o.AccountID, o.InstrumentPair, "BUY", o.Price, false).
+					GetMatchingOrders(gomock.Any(), o.AccountID, o.InstrumentPair, "BUY", o.Price, false, DefaultMaxFillsPerOrder+1, gomock.Any(), gomock.Any()).
 					Return([]*entity.Order{m1, m2, m3}, nil).
 					Times(1)
 				return []*entity.Order{m1, m2, m3}
@@ -535,6 +1009,64 @@ func TestOrderUseCase_matchOrder(t *testing.T) {
 			},
 			wantErr: false,
 		},
+		{
+			name: "stale zero-remaining maker is skipped",
+			order: &entity.Order{
+				AccountID:         accountID,
+				InstrumentPair:    "BTC_BRL",
+				OrderType:         string(entity.OrderTypeBuy),
+				Price:             decimal.RequireFromString("100"),
+				Quantity:          decimal.RequireFromString("1.0"),
+				RemainingQuantity: decimal.RequireFromString("1.0"),
+			},
+			mockSetup: func(or *repository.MockOrderRepository, o *entity.Order) []*entity.Order {
+				stale := &entity.Order{AccountID: uuid.New(), OrderType: string(entity.OrderTypeSell), Price: decimal.RequireFromString("99"), RemainingQuantity: decimal.Zero}
+				m1 := &entity.Order{AccountID: uuid.New(), OrderType: string(entity.OrderTypeSell), Price: decimal.RequireFromString("100"), RemainingQuantity: decimal.RequireFromString("0.5")}
+				or.EXPECT().
+					GetMatchingOrders(gomock.Any(), o.AccountID, o.InstrumentPair, "SELL", o.Price, true, DefaultMaxFillsPerOrder+1, gomock.Any(), gomock.Any()).
+					Return([]*entity.Order{stale, m1}, nil).
+					Times(1)
+				return []*entity.Order{stale, m1}
+			},
+			execSetup: func(exec *MockTradeExecutor, o *entity.Order, matches []*entity.Order, captured *[]decimal.Decimal) {
+				exec.EXPECT().
+					Execute(gomock.Any(), o, matches[1], gomock.AssignableToTypeOf(decimal.Zero)).
+					DoAndReturn(func(_ *gorm.DB, _, _ *entity.Order, qty decimal.Decimal) error {
+						*captured = append(*captured, qty)
+						return nil
+					}).
+					Times(1)
+			},
+			wantErr: false,
+		},
+		{
+			name: "maker outside the limit stops matching",
+			order: &entity.Order{
+				AccountID:         accountID,
+				InstrumentPair:    "BTC_BRL",
+				OrderType:         string(entity.OrderTypeBuy),
+				Price:             decimal.RequireFromString("100"),
+				Quantity:          decimal.RequireFromString("1.0"),
+				RemainingQuantity: decimal.RequireFromString("1.0"),
+			},
+			mockSetup: func(or *repository.MockOrderRepository, o *entity.Order) []*entity.Order {
+				m1 := &entity.Order{AccountID: uuid.New(), OrderType: string(entity.OrderTypeSell), Price: decimal.RequireFromString("99"), RemainingQuantity: decimal.RequireFromString("0.4")}
+				outside := &entity.Order{AccountID: uuid.New(), OrderType: string(entity.OrderTypeSell), Price: decimal.RequireFromString("101"), RemainingQuantity: decimal.RequireFromString("0.5")}
+				m3 := &entity.Order{AccountID: uuid.New(), OrderType: string(entity.OrderTypeSell), Price: decimal.RequireFromString("100"), RemainingQuantity: decimal.RequireFromString("0.5")}
+				or.EXPECT().
+					GetMatchingOrders(gomock.Any(), o.AccountID, o.InstrumentPair, "SELL", o.Price, true, DefaultMaxFillsPerOrder+1, gomock.Any(), gomock.Any()).
+					Return([]*entity.Order{m1, outside, m3}, nil).
+					Times(1)
+				return []*entity.Order{m1, outside, m3}
+			},
+			execSetup: func(exec *MockTradeExecutor, o *entity.Order, matches []*entity.Order, captured *[]decimal.Decimal) {
+				exec.EXPECT().
+					Execute(gomock.Any(), o, matches[0], gomock.AssignableToTypeOf(decimal.Zero)).
+					Return(nil).
+					Times(1)
+			},
+			wantErr: false,
+		},
 		{
 			name: "repository error bubbles up",
 			order: &entity.Order{
@@ -547,7 +1079,7 @@ func TestOrderUseCase_matchOrder(t *testing.T) {
 			},
 			mockSetup: func(or *repository.MockOrderRepository, o *entity.Order) []*entity.Order {
 				or.EXPECT().
-					GetMatchingOrders(gomock.Any(), o.AccountID, o.InstrumentPair, "SELL", o.Price, true).
+					GetMatchingOrders(gomock.Any(), o.AccountID, o.InstrumentPair, "SELL", o.Price, true, DefaultMaxFillsPerOrder+1, gomock.Any(), gomock.Any()).
 					Return(nil, errors.New("db error")).
 					Times(1)
 				return nil
@@ -568,7 +1100,7 @@ func TestOrderUseCase_matchOrder(t *testing.T) {
 			},
 			mockSetup: func(or *repository.MockOrderRepository, o *entity.Order) []*entity.Order {
 				or.EXPECT().
-					GetMatchingOrders(gomock.Any(), o.AccountID, o.InstrumentPair, "SELL", o.Price, true).
+					GetMatchingOrders(gomock.Any(), o.AccountID, o.InstrumentPair, "SELL", o.Price, true, DefaultMaxFillsPerOrder+1, gomock.Any(), gomock.Any()).
 					Return([]*entity.Order{}, nil).
 					Times(1)
 				return []*entity.Order{}
@@ -590,7 +1122,7 @@ func TestOrderUseCase_matchOrder(t *testing.T) {
 			mockSetup: func(or *repository.MockOrderRepository, o *entity.Order) []*entity.Order {
 				m1 := &entity.Order{AccountID: uuid.New(), OrderType: string(entity.OrderTypeSell), Price: decimal.RequireFromString("100"), RemainingQuantity: decimal.RequireFromString("0.7")}
 				or.EXPECT().
-					GetMatchingOrders(gomock.Any(), o.AccountID, o.InstrumentPair, "SELL", o.Price, true).
+					GetMatchingOrders(gomock.Any(), o.AccountID, o.InstrumentPair, "SELL", o.Price, true, DefaultMaxFillsPerOrder+1, gomock.Any(), gomock.Any()).
 					Return([]*entity.Order{m1}, nil).
 					Times(1)
 				return []*entity.Order{m1}
@@ -621,6 +1153,7 @@ func TestOrderUseCase_matchOrder(t *testing.T) {
 				orderRepository: orderRepo,
 				db:              db,
 				executor:        exec,
+				clock:           SystemClock,
 			}
 
 			tx := db.Begin()
@@ -637,3 +1170,3158 @@ func TestOrderUseCase_matchOrder(t *testing.T) {
 		})
 	}
 }
+
+func TestOrderUseCase_CancelOrders(t *testing.T) {
+	log := zap.NewNop().Sugar()
+	db := newMigratedDB(t)
+	orderRepo := repository.NewOrderRepository(log, db)
+	uc := NewOrderUseCase(log, orderRepo, repository.NewWalletRepository(log, db, nil), repository.NewTradeRepository(log, db), repository.NewEventRepository(log, db), nil, db, OrderUseCaseConfig{})
+
+	accountID := uuid.New()
+	otherAccountID := uuid.New()
+	newOrder := func(accountID uuid.UUID, pair string, orderType entity.OrderType, status entity.OrderStatus) *entity.Order {
+		o := &entity.Order{
+			AccountID:         accountID,
+			InstrumentPair:    pair,
+			OrderType:         string(orderType),
+			Price:             decimal.RequireFromString("100"),
+			Quantity:          decimal.RequireFromString("1"),
+			RemainingQuantity: decimal.RequireFromString("1"),
+			Status:            string(status),
+		}
+		if err := orderRepo.Create(nil, o); err != nil {
+			t.Fatalf("failed to seed order: %v", err)
+		}
+		return o
+	}
+
+	buy1 := newOrder(accountID, "BTC_BRL", entity.OrderTypeBuy, entity.OrderStatusOpen)
+	buy2 := newOrder(accountID, "BTC_BRL", entity.OrderTypeBuy, entity.OrderStatusOpen)
+	sell := newOrder(accountID, "BTC_BRL", entity.OrderTypeSell, entity.OrderStatusOpen)
+	otherPair := newOrder(accountID, "ETH_BRL", entity.OrderTypeBuy, entity.OrderStatusOpen)
+	otherAccount := newOrder(otherAccountID, "BTC_BRL", entity.OrderTypeBuy, entity.OrderStatusOpen)
+	filled := newOrder(accountID, "BTC_BRL", entity.OrderTypeBuy, entity.OrderStatusFilled)
+
+	// The pair filter is normalized like an order's.
+	cancelled, err := uc.CancelOrders(context.Background(), accountID, "btc_brl", string(entity.OrderTypeBuy))
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, []uuid.UUID{buy1.ID, buy2.ID}, cancelled)
+
+	wantStatus := map[uuid.UUID]entity.OrderStatus{
+		buy1.ID:         entity.OrderStatusCancelled,
+		buy2.ID:         entity.OrderStatusCancelled,
+		sell.ID:         entity.OrderStatusOpen,
+		otherPair.ID:    entity.OrderStatusOpen,
+		otherAccount.ID: entity.OrderStatusOpen,
+		filled.ID:       entity.OrderStatusFilled,
+	}
+	for id, want := range wantStatus {
+		got, err := orderRepo.GetByID(id)
+		assert.NoError(t, err)
+		assert.Equal(t, string(want), got.Status)
+	}
+
+	var events []*entity.Event
+	assert.NoError(t, db.Where("event_type = ?", string(entity.EventTypeOrderCancelled)).Find(&events).Error)
+	assert.Len(t, events, 2)
+}
+
+func TestOrderUseCase_CancelOrders_Validation(t *testing.T) {
+	instruments := entity.NewInstrumentConfig(entity.Asset{Symbol: "BTC", Scale: 8}, entity.Asset{Symbol: "BRL", Scale: 2})
+	uc := NewOrderUseCase(zap.NewNop().Sugar(), nil, nil, nil, nil, nil, nil, OrderUseCaseConfig{Instruments: instruments})
+
+	_, err := uc.CancelOrders(context.Background(), uuid.New(), "BTCBRL", string(entity.OrderTypeBuy))
+	assert.ErrorIs(t, err, entity.ErrInvalidPairFormat)
+
+	_, err = uc.CancelOrders(context.Background(), uuid.New(), "ETH_BRL", string(entity.OrderTypeBuy))
+	assert.ErrorIs(t, err, entity.ErrUnsupportedAsset)
+
+	_, err = uc.CancelOrders(context.Background(), uuid.New(), "BTC_BRL", "HOLD")
+	assert.ErrorIs(t, err, entity.ErrInvalidOrderType)
+}
+
+func TestOrderUseCase_CreateOrder_FillLimit(t *testing.T) {
+	log := zap.NewNop().Sugar()
+	db := newMigratedDB(t)
+	orderRepo := repository.NewOrderRepository(log, db)
+	walletRepo := repository.NewWalletRepository(log, db, nil)
+	tradeRepo := repository.NewTradeRepository(log, db)
+
+	const maxFills = 3
+	uc := NewOrderUseCase(log, orderRepo, walletRepo, tradeRepo, repository.NewEventRepository(log, db), nil, db, OrderUseCaseConfig{MaxFills: maxFills})
+
+	makerID, takerID := uuid.New(), uuid.New()
+	for _, w := range []*entity.Wallet{
+		{AccountID: makerID, AssetSymbol: "BTC", Balance: decimal.RequireFromString("100")},
+		{AccountID: makerID, AssetSymbol: "BRL", Balance: decimal.Zero},
+		{AccountID: takerID, AssetSymbol: "BTC", Balance: decimal.Zero},
+		{AccountID: takerID, AssetSymbol: "BRL", Balance: decimal.RequireFromString("1000000")},
+	} {
+		if err := walletRepo.Create(nil, w); err != nil {
+			t.Fatalf("failed to seed wallet: %v", err)
+		}
+	}
+
+	for i := 0; i < maxFills+5; i++ {
+		maker := &entity.Order{
+			AccountID:         makerID,
+			InstrumentPair:    "BTC_BRL",
+			OrderType:         string(entity.OrderTypeSell),
+			Price:             decimal.RequireFromString("100"),
+			Quantity:          decimal.RequireFromString("1"),
+			RemainingQuantity: decimal.RequireFromString("1"),
+			Status:            string(entity.OrderStatusOpen),
+		}
+		if err := orderRepo.Create(nil, maker); err != nil {
+			t.Fatalf("failed to seed maker: %v", err)
+		}
+	}
+
+	taker := &entity.Order{
+		AccountID:      takerID,
+		InstrumentPair: "BTC_BRL",
+		OrderType:      string(entity.OrderTypeBuy),
+		Price:          decimal.RequireFromString("100"),
+		Quantity:       decimal.RequireFromString("50"),
+	}
+	assert.NoError(t, uc.CreateOrder(context.Background(), taker))
+
+	var trades int64
+	assert.NoError(t, db.Model(&entity.Trade{}).Count(&trades).Error)
+	assert.Equal(t, int64(maxFills), trades)
+
+	got, err := orderRepo.GetByID(taker.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, string(entity.OrderStatusPartial), got.Status)
+	assert.True(t, got.RemainingQuantity.Equal(decimal.RequireFromString("47")))
+
+	resting, err := orderRepo.GetByAccountPairSide(nil, makerID, "BTC_BRL", string(entity.OrderTypeSell), string(entity.OrderStatusOpen))
+	assert.NoError(t, err)
+	assert.Len(t, resting, 5)
+}
+
+func TestOrderUseCase_GetDepth(t *testing.T) {
+	book := []*entity.Order{
+		{OrderType: string(entity.OrderTypeBuy), Price: decimal.RequireFromString("100"), RemainingQuantity: decimal.RequireFromString("1.0")},
+		{OrderType: string(entity.OrderTypeBuy), Price: decimal.RequireFromString("100"), RemainingQuantity: decimal.RequireFromString("0.4")},
+		{OrderType: string(entity.OrderTypeBuy), Price: decimal.RequireFromString("99"), RemainingQuantity: decimal.RequireFromString("2.0")},
+		{OrderType: string(entity.OrderTypeBuy), Price: decimal.RequireFromString("97"), RemainingQuantity: decimal.RequireFromString("3.0")},
+
+		{OrderType: string(entity.OrderTypeSell), Price: decimal.RequireFromString("101"), RemainingQuantity: decimal.RequireFromString("0.5")},
+		{OrderType: string(entity.OrderTypeSell), Price: decimal.RequireFromString("101"), RemainingQuantity: decimal.RequireFromString("0.3")},
+		{OrderType: string(entity.OrderTypeSell), Price: decimal.RequireFromString("103"), RemainingQuantity: decimal.RequireFromString("0.2")},
+		{OrderType: string(entity.OrderTypeSell), Price: decimal.RequireFromString("110"), RemainingQuantity: decimal.RequireFromString("5")},
+	}
+
+	tests := []struct {
+		name      string
+		pair      string
+		side      string
+		price     string
+		orders    []*entity.Order
+		skipRepo  bool
+		wantErr   error
+		wantDepth string
+	}{
+		{name: "bids at or above top level", pair: "BTC_BRL", side: "bid", price: "100", orders: book, wantDepth: "1.4"},
+		{name: "bids across levels", pair: "BTC_BRL", side: "bid", price: "98", orders: book, wantDepth: "3.4"},
+		{name: "bids below whole book", pair: "BTC_BRL", side: "bid", price: "1", orders: book, wantDepth: "6.4"},
+		{name: "no bid qualifies", pair: "BTC_BRL", side: "bid", price: "100.5", orders: book, wantDepth: "0"},
+		{name: "asks across levels", pair: "BTC_BRL", side: "ask", price: "103", orders: book, wantDepth: "1"},
+		{name: "asks between levels", pair: "BTC_BRL", side: "ask", price: "105", orders: book, wantDepth: "1"},
+		{name: "no ask qualifies", pair: "BTC_BRL", side: "ask", price: "100", orders: book, wantDepth: "0"},
+		{name: "empty book returns zero", pair: "BTC_BRL", side: "ask", price: "100", orders: nil, wantDepth: "0"},
+		{name: "invalid side", pair: "BTC_BRL", side: "buy", price: "100", skipRepo: true, wantErr: entity.ErrInvalidSide},
+		{name: "non-positive price", pair: "BTC_BRL", side: "bid", price: "0", skipRepo: true, wantErr: entity.ErrInvalidPrice},
+		{name: "invalid pair", pair: "BTCBRL", side: "bid", price: "100", skipRepo: true, wantErr: entity.ErrInvalidPairFormat},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			orderRepo := repository.NewMockOrderRepository(ctrl)
+			if !tt.skipRepo {
+				orderRepo.EXPECT().
+					GetOpenOrdersByInstrumentPair(tt.pair, gomock.Any(), gomock.Any()).
+					Return(tt.orders, nil).
+					Times(1)
+			}
+
+			uc := NewOrderUseCase(zap.NewNop().Sugar(), orderRepo, nil, nil, nil, nil, nil, OrderUseCaseConfig{})
+
+			depth, err := uc.GetDepth(tt.pair, tt.side, decimal.RequireFromString(tt.price))
+
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+			assertDecimalEqual(t, tt.wantDepth, depth.String())
+		})
+	}
+}
+
+func TestOrderUseCase_GetDepth_PastMaxBookLevels(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	orderRepo := repository.NewMockOrderRepository(ctrl)
+	orderRepo.EXPECT().
+		GetOpenOrdersByInstrumentPair("BTC_BRL", gomock.Any(), gomock.Any()).
+		Return([]*entity.Order{
+			{OrderType: string(entity.OrderTypeBuy), Price: decimal.RequireFromString("100"), RemainingQuantity: decimal.RequireFromString("1")},
+			{OrderType: string(entity.OrderTypeBuy), Price: decimal.RequireFromString("99"), RemainingQuantity: decimal.RequireFromString("2")},
+			{OrderType: string(entity.OrderTypeBuy), Price: decimal.RequireFromString("98"), RemainingQuantity: decimal.RequireFromString("3")},
+			{OrderType: string(entity.OrderTypeBuy), Price: decimal.RequireFromString("97"), RemainingQuantity: decimal.RequireFromString("4")},
+		}, nil).
+		Times(1)
+
+	uc := NewOrderUseCase(zap.NewNop().Sugar(), orderRepo, nil, nil, nil, nil, nil, OrderUseCaseConfig{MaxBookLevels: 2})
+
+	depth, err := uc.GetDepth("BTC_BRL", string(entity.BookSideBid), decimal.RequireFromString("97"))
+
+	assert.NoError(t, err)
+	assertDecimalEqual(t, "10", depth.String())
+}
+
+func TestOrderUseCase_GetImbalance(t *testing.T) {
+	skewed := []*entity.Order{
+		{OrderType: string(entity.OrderTypeBuy), Price: decimal.RequireFromString("100"), RemainingQuantity: decimal.RequireFromString("3")},
+		{OrderType: string(entity.OrderTypeBuy), Price: decimal.RequireFromString("100"), RemainingQuantity: decimal.RequireFromString("1")},
+		{OrderType: string(entity.OrderTypeBuy), Price: decimal.RequireFromString("99"), RemainingQuantity: decimal.RequireFromString("2")},
+		{OrderType: string(entity.OrderTypeBuy), Price: decimal.RequireFromString("98"), RemainingQuantity: decimal.RequireFromString("10")},
+
+		{OrderType: string(entity.OrderTypeSell), Price: decimal.RequireFromString("101"), RemainingQuantity: decimal.RequireFromString("1")},
+		{OrderType: string(entity.OrderTypeSell), Price: decimal.RequireFromString("102"), RemainingQuantity: decimal.RequireFromString("1")},
+		{OrderType: string(entity.OrderTypeSell), Price: decimal.RequireFromString("110"), RemainingQuantity: decimal.RequireFromString("2")},
+	}
+	bidsOnly := skewed[:4]
+
+	tests := []struct {
+		name          string
+		depth         int
+		orders        []*entity.Order
+		wantBid       string
+		wantAsk       string
+		wantImbalance string
+		wantRatio     string
+	}{
+		{name: "top two levels lean to bids", depth: 2, orders: skewed, wantBid: "6", wantAsk: "2", wantImbalance: "4", wantRatio: "0.5"},
+		{name: "zero depth takes every level", depth: 0, orders: skewed, wantBid: "16", wantAsk: "4", wantImbalance: "12", wantRatio: "0.6"},
+		{name: "depth past the book takes every level", depth: 50, orders: skewed, wantBid: "16", wantAsk: "4", wantImbalance: "12", wantRatio: "0.6"},
+		{name: "empty ask side", depth: 1, orders: bidsOnly, wantBid: "4", wantAsk: "0", wantImbalance: "4", wantRatio: "1"},
+		{name: "empty book has no ratio", depth: 5, orders: nil, wantBid: "0", wantAsk: "0", wantImbalance: "0"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			orderRepo := repository.NewMockOrderRepository(ctrl)
+			orderRepo.EXPECT().
+				GetOpenOrdersByInstrumentPair("BTC_BRL", gomock.Any(), gomock.Any()).
+				Return(tt.orders, nil).
+				Times(1)
+
+			uc := NewOrderUseCase(zap.NewNop().Sugar(), orderRepo, nil, nil, nil, nil, nil, OrderUseCaseConfig{})
+
+			imbalance, err := uc.GetImbalance("BTC_BRL", tt.depth)
+
+			assert.NoError(t, err)
+			assertDecimalEqual(t, tt.wantBid, imbalance.BidQuantity.String())
+			assertDecimalEqual(t, tt.wantAsk, imbalance.AskQuantity.String())
+			assertDecimalEqual(t, tt.wantImbalance, imbalance.Imbalance.String())
+			if tt.wantRatio == "" {
+				assert.Nil(t, imbalance.Ratio)
+				return
+			}
+			assertDecimalEqual(t, tt.wantRatio, imbalance.Ratio.String())
+		})
+	}
+}
+
+func TestOrderUseCase_GetImbalance_PastMaxBookLevels(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	orderRepo := repository.NewMockOrderRepository(ctrl)
+	orderRepo.EXPECT().
+		GetOpenOrdersByInstrumentPair("BTC_BRL", gomock.Any(), gomock.Any()).
+		Return([]*entity.Order{
+			{OrderType: string(entity.OrderTypeBuy), Price: decimal.RequireFromString("100"), RemainingQuantity: decimal.RequireFromString("1")},
+			{OrderType: string(entity.OrderTypeBuy), Price: decimal.RequireFromString("99"), RemainingQuantity: decimal.RequireFromString("1")},
+			{OrderType: string(entity.OrderTypeBuy), Price: decimal.RequireFromString("98"), RemainingQuantity: decimal.RequireFromString("6")},
+
+			{OrderType: string(entity.OrderTypeSell), Price: decimal.RequireFromString("101"), RemainingQuantity: decimal.RequireFromString("1")},
+			{OrderType: string(entity.OrderTypeSell), Price: decimal.RequireFromString("102"), RemainingQuantity: decimal.RequireFromString("1")},
+		}, nil).
+		Times(1)
+
+	uc := NewOrderUseCase(zap.NewNop().Sugar(), orderRepo, nil, nil, nil, nil, nil, OrderUseCaseConfig{MaxBookLevels: 2})
+
+	imbalance, err := uc.GetImbalance("BTC_BRL", 0)
+
+	assert.NoError(t, err)
+	assertDecimalEqual(t, "8", imbalance.BidQuantity.String())
+	assertDecimalEqual(t, "2", imbalance.AskQuantity.String())
+	assertDecimalEqual(t, "0.6", imbalance.Ratio.String())
+}
+
+func TestOrderUseCase_GetImbalance_InvalidPair(t *testing.T) {
+	uc := NewOrderUseCase(zap.NewNop().Sugar(), nil, nil, nil, nil, nil, nil, OrderUseCaseConfig{})
+
+	_, err := uc.GetImbalance("BTCBRL", 5)
+
+	assert.ErrorIs(t, err, entity.ErrInvalidPairFormat)
+}
+
+func TestOrderUseCase_GetOrderBook_FullPrecisionLevels(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	orderRepo := repository.NewMockOrderRepository(ctrl)
+	orderRepo.EXPECT().GetOpenOrdersByInstrumentPair("BTC_BRL", gomock.Any(), gomock.Any()).Return([]*entity.Order{
+		{OrderType: string(entity.OrderTypeBuy), Price: decimal.RequireFromString("100"), RemainingQuantity: decimal.RequireFromString("0.600000002")},
+		{OrderType: string(entity.OrderTypeBuy), Price: decimal.RequireFromString("100"), RemainingQuantity: decimal.RequireFromString("0.400000003")},
+	}, nil).Times(1)
+
+	uc := NewOrderUseCase(zap.NewNop().Sugar(), orderRepo, nil, nil, nil, nil, nil, OrderUseCaseConfig{})
+
+	// The level keeps every decimal of the sum; only responses round it to
+	// the base asset's scale.
+	orderBook, err := uc.GetOrderBook("BTC_BRL", decimal.Zero)
+	assert.NoError(t, err)
+	if assert.Len(t, orderBook.Bids, 1) {
+		assertDecimalEqual(t, "1.000000005", orderBook.Bids[0].Quantity.String())
+	}
+}
+
+func TestOrderUseCase_GetOrderBook_Checksum(t *testing.T) {
+	instruments := entity.NewInstrumentConfig(entity.Asset{Symbol: "BTC", Scale: 8}, entity.Asset{Symbol: "BRL", Scale: 2})
+	order := func(orderType entity.OrderType, price, quantity string) *entity.Order {
+		return &entity.Order{
+			OrderType:         string(orderType),
+			Price:             decimal.RequireFromString(price),
+			RemainingQuantity: decimal.RequireFromString(quantity),
+		}
+	}
+	fixed := []*entity.Order{
+		order(entity.OrderTypeBuy, "100", "1"),
+		order(entity.OrderTypeBuy, "100", "0.4"),
+		order(entity.OrderTypeBuy, "99", "2"),
+		order(entity.OrderTypeSell, "101", "0.5"),
+	}
+
+	checksum := func(orders []*entity.Order) uint32 {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		orderRepo := repository.NewMockOrderRepository(ctrl)
+		orderRepo.EXPECT().GetOpenOrdersByInstrumentPair("BTC_BRL", gomock.Any(), gomock.Any()).Return(orders, nil).Times(1)
+		uc := NewOrderUseCase(zap.NewNop().Sugar(), orderRepo, nil, nil, nil, nil, nil, OrderUseCaseConfig{Instruments: instruments})
+
+		book, err := uc.GetOrderBook("BTC_BRL", decimal.Zero)
+		if !assert.NoError(t, err) {
+			return 0
+		}
+		return book.Checksum
+	}
+
+	// The documented serialization of the fixed book.
+	want := crc32.ChecksumIEEE([]byte("100.00:1.40000000,99.00:2.00000000|101.00:0.50000000"))
+	assert.Equal(t, want, checksum(fixed))
+
+	// Order of the rows and the scale they are stored at do not matter.
+	reordered := []*entity.Order{
+		order(entity.OrderTypeSell, "101.000", "0.50"),
+		order(entity.OrderTypeBuy, "99", "2.0"),
+		order(entity.OrderTypeBuy, "100", "0.4"),
+		order(entity.OrderTypeBuy, "100.0", "1"),
+	}
+	assert.Equal(t, want, checksum(reordered))
+
+	changedQuantity := append(fixed[:3:3], order(entity.OrderTypeSell, "101", "0.6"))
+	assert.NotEqual(t, want, checksum(changedQuantity))
+
+	changedPrice := append(fixed[:3:3], order(entity.OrderTypeSell, "102", "0.5"))
+	assert.NotEqual(t, want, checksum(changedPrice))
+}
+
+func TestOrderUseCase_GetOrderBook_MinQuantity(t *testing.T) {
+	book := []*entity.Order{
+		{OrderType: string(entity.OrderTypeBuy), Price: decimal.RequireFromString("100"), RemainingQuantity: decimal.RequireFromString("0.001")},
+		{OrderType: string(entity.OrderTypeBuy), Price: decimal.RequireFromString("99"), RemainingQuantity: decimal.RequireFromString("0.6")},
+		{OrderType: string(entity.OrderTypeBuy), Price: decimal.RequireFromString("99"), RemainingQuantity: decimal.RequireFromString("0.4")},
+		{OrderType: string(entity.OrderTypeBuy), Price: decimal.RequireFromString("98"), RemainingQuantity: decimal.RequireFromString("0.5")},
+		{OrderType: string(entity.OrderTypeSell), Price: decimal.RequireFromString("101"), RemainingQuantity: decimal.RequireFromString("0.002")},
+		{OrderType: string(entity.OrderTypeSell), Price: decimal.RequireFromString("102"), RemainingQuantity: decimal.RequireFromString("3")},
+	}
+
+	t.Run("dust levels are left out, larger ones remain", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		orderRepo := repository.NewMockOrderRepository(ctrl)
+		orderRepo.EXPECT().GetOpenOrdersByInstrumentPair("BTC_BRL", gomock.Any(), gomock.Any()).Return(book, nil).Times(1)
+		uc := NewOrderUseCase(zap.NewNop().Sugar(), orderRepo, nil, nil, nil, nil, nil, OrderUseCaseConfig{})
+
+		ob, err := uc.GetOrderBook("BTC_BRL", decimal.RequireFromString("0.5"))
+		assert.NoError(t, err)
+
+		// 99 is two orders that are only above the threshold together.
+		if assert.Len(t, ob.Bids, 2) {
+			assertDecimalEqual(t, "99", ob.Bids[0].Price.String())
+			assertDecimalEqual(t, "1", ob.Bids[0].Quantity.String())
+			assertDecimalEqual(t, "98", ob.Bids[1].Price.String())
+		}
+		if assert.Len(t, ob.Asks, 1) {
+			assertDecimalEqual(t, "102", ob.Asks[0].Price.String())
+		}
+	})
+
+	t.Run("filter runs before the level cap", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		orderRepo := repository.NewMockOrderRepository(ctrl)
+		orderRepo.EXPECT().GetOpenOrdersByInstrumentPair("BTC_BRL", gomock.Any(), gomock.Any()).Return(book, nil).Times(1)
+		uc := NewOrderUseCase(zap.NewNop().Sugar(), orderRepo, nil, nil, nil, nil, nil, OrderUseCaseConfig{MaxBookLevels: 1})
+
+		ob, err := uc.GetOrderBook("BTC_BRL", decimal.RequireFromString("0.01"))
+		assert.NoError(t, err)
+
+		if assert.Len(t, ob.Bids, 1) {
+			assertDecimalEqual(t, "99", ob.Bids[0].Price.String())
+		}
+		if assert.Len(t, ob.Asks, 1) {
+			assertDecimalEqual(t, "102", ob.Asks[0].Price.String())
+		}
+	})
+
+	t.Run("negative threshold is rejected", func(t *testing.T) {
+		uc := NewOrderUseCase(zap.NewNop().Sugar(), nil, nil, nil, nil, nil, nil, OrderUseCaseConfig{})
+
+		_, err := uc.GetOrderBook("BTC_BRL", decimal.RequireFromString("-1"))
+		assert.ErrorIs(t, err, entity.ErrInvalidMinQuantity)
+
+		_, err = uc.GetOrderBookSide("BTC_BRL", "bid", decimal.RequireFromString("-1"))
+		assert.ErrorIs(t, err, entity.ErrInvalidMinQuantity)
+	})
+}
+
+func TestOrderUseCase_GetOrderBookSide(t *testing.T) {
+	book := []*entity.Order{
+		{OrderType: string(entity.OrderTypeBuy), Price: decimal.RequireFromString("100"), RemainingQuantity: decimal.RequireFromString("1")},
+		{OrderType: string(entity.OrderTypeBuy), Price: decimal.RequireFromString("99"), RemainingQuantity: decimal.RequireFromString("2")},
+		{OrderType: string(entity.OrderTypeSell), Price: decimal.RequireFromString("101"), RemainingQuantity: decimal.RequireFromString("0.5")},
+	}
+
+	tests := []struct {
+		name     string
+		side     string
+		wantBids int
+		wantAsks int
+		wantErr  error
+	}{
+		{name: "bids only", side: "bid", wantBids: 2},
+		{name: "asks only", side: "ask", wantAsks: 1},
+		{name: "invalid side", side: "BUY", wantErr: entity.ErrInvalidSide},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			orderRepo := repository.NewMockOrderRepository(ctrl)
+			if tt.wantErr == nil {
+				orderRepo.EXPECT().GetOpenOrdersByInstrumentPair("BTC_BRL", gomock.Any(), gomock.Any()).Return(book, nil).Times(1)
+			}
+			uc := NewOrderUseCase(zap.NewNop().Sugar(), orderRepo, nil, nil, nil, nil, nil, OrderUseCaseConfig{})
+
+			got, err := uc.GetOrderBookSide("BTC_BRL", tt.side, decimal.Zero)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			if assert.NoError(t, err) {
+				assert.Len(t, got.Bids, tt.wantBids)
+				assert.Len(t, got.Asks, tt.wantAsks)
+			}
+		})
+	}
+}
+
+func TestOrderUseCase_GetBook(t *testing.T) {
+	base := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	book := []*entity.Order{
+		{OrderType: string(entity.OrderTypeBuy), Price: decimal.RequireFromString("100"), RemainingQuantity: decimal.RequireFromString("1"), Base: entity.Base{ID: uuid.New(), CreatedAt: base}},
+		{OrderType: string(entity.OrderTypeBuy), Price: decimal.RequireFromString("100"), RemainingQuantity: decimal.RequireFromString("0.5"), Base: entity.Base{ID: uuid.New(), CreatedAt: base.Add(time.Second)}},
+		{OrderType: string(entity.OrderTypeBuy), Price: decimal.RequireFromString("99"), RemainingQuantity: decimal.RequireFromString("2"), Base: entity.Base{ID: uuid.New(), CreatedAt: base}},
+		{OrderType: string(entity.OrderTypeBuy), Price: decimal.RequireFromString("98"), RemainingQuantity: decimal.RequireFromString("3"), Base: entity.Base{ID: uuid.New(), CreatedAt: base}},
+		{OrderType: string(entity.OrderTypeSell), Price: decimal.RequireFromString("101"), RemainingQuantity: decimal.RequireFromString("0.5"), Base: entity.Base{ID: uuid.New(), CreatedAt: base}},
+	}
+
+	tests := []struct {
+		name          string
+		view          BookView
+		depth         int
+		maxBookLevels int
+		wantBids      int
+		wantErr       error
+	}{
+		{name: "aggregated full depth", view: BookViewAggregated, wantBids: 3},
+		{name: "aggregated with depth", view: BookViewAggregated, depth: 2, wantBids: 2},
+		{name: "aggregated default uses level cap", view: BookViewAggregated, maxBookLevels: 1, wantBids: 1},
+		{name: "aggregated depth never exceeds level cap", view: BookViewAggregated, depth: 3, maxBookLevels: 2, wantBids: 2},
+		{name: "raw lists orders one by one", view: BookViewRaw, wantBids: 4},
+		{name: "raw with depth", view: BookViewRaw, depth: 2, wantBids: 2},
+		{name: "raw ignores level cap", view: BookViewRaw, maxBookLevels: 1, wantBids: 4},
+		{name: "unknown view", view: BookView("levels"), wantErr: entity.ErrInvalidBookView},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			orderRepo := repository.NewMockOrderRepository(ctrl)
+			if tt.wantErr == nil {
+				orderRepo.EXPECT().GetOpenOrdersByInstrumentPair("BTC_BRL", gomock.Any(), gomock.Any()).Return(book, nil).Times(1)
+			}
+
+			uc := NewOrderUseCase(zap.NewNop().Sugar(), orderRepo, nil, nil, nil, nil, nil, OrderUseCaseConfig{MaxBookLevels: tt.maxBookLevels})
+
+			got, err := uc.GetBook("BTC_BRL", tt.view, tt.depth)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				assert.Nil(t, got)
+				return
+			}
+			if !assert.NoError(t, err) {
+				return
+			}
+
+			assert.Equal(t, tt.view, got.View)
+			if tt.view == BookViewRaw {
+				assert.Nil(t, got.Aggregated)
+				assert.Len(t, got.Raw.Bids, tt.wantBids)
+				assert.Len(t, got.Raw.Asks, 1)
+				assert.Equal(t, book[0].ID, got.Raw.Bids[0].OrderID)
+				return
+			}
+			assert.Nil(t, got.Raw)
+			assert.Len(t, got.Aggregated.Bids, tt.wantBids)
+			assertDecimalEqual(t, "1.5", got.Aggregated.Bids[0].Quantity.String())
+		})
+	}
+}
+
+func TestOrderUseCase_EstimateCost(t *testing.T) {
+	book := []*entity.Order{
+		{OrderType: string(entity.OrderTypeBuy), Price: decimal.RequireFromString("100"), RemainingQuantity: decimal.RequireFromString("1.0")},
+		{OrderType: string(entity.OrderTypeBuy), Price: decimal.RequireFromString("100"), RemainingQuantity: decimal.RequireFromString("0.4")},
+		{OrderType: string(entity.OrderTypeBuy), Price: decimal.RequireFromString("99"), RemainingQuantity: decimal.RequireFromString("2.0")},
+
+		{OrderType: string(entity.OrderTypeSell), Price: decimal.RequireFromString("101"), RemainingQuantity: decimal.RequireFromString("0.5")},
+		{OrderType: string(entity.OrderTypeSell), Price: decimal.RequireFromString("101"), RemainingQuantity: decimal.RequireFromString("0.3")},
+		{OrderType: string(entity.OrderTypeSell), Price: decimal.RequireFromString("103"), RemainingQuantity: decimal.RequireFromString("0.2")},
+		{OrderType: string(entity.OrderTypeSell), Price: decimal.RequireFromString("110"), RemainingQuantity: decimal.RequireFromString("5")},
+	}
+
+	tests := []struct {
+		name         string
+		orderType    string
+		quantity     string
+		orders       []*entity.Order
+		skipRepo     bool
+		wantErr      error
+		wantFilled   string
+		wantCost     string
+		wantAverage  string
+		wantFillable bool
+	}{
+		{
+			name: "buy across two ask levels", orderType: "BUY", quantity: "0.9", orders: book,
+			wantFilled: "0.9", wantCost: "91.1", wantAverage: "101.2222222222222222", wantFillable: true,
+		},
+		{
+			name: "sell across two bid levels", orderType: "SELL", quantity: "2", orders: book,
+			wantFilled: "2", wantCost: "199.4", wantAverage: "99.7", wantFillable: true,
+		},
+		{
+			name: "buy larger than the book returns the partial estimate", orderType: "BUY", quantity: "10", orders: book,
+			wantFilled: "6", wantCost: "651.4", wantAverage: "108.5666666666666667", wantFillable: false,
+		},
+		{
+			name: "empty book fills nothing", orderType: "BUY", quantity: "1", orders: nil,
+			wantFilled: "0", wantCost: "0", wantAverage: "0", wantFillable: false,
+		},
+		{name: "invalid side", orderType: "HOLD", quantity: "1", skipRepo: true, wantErr: entity.ErrInvalidSide},
+		{name: "non-positive quantity", orderType: "BUY", quantity: "0", skipRepo: true, wantErr: entity.ErrInvalidQuantity},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			orderRepo := repository.NewMockOrderRepository(ctrl)
+			if !tt.skipRepo {
+				orderRepo.EXPECT().
+					GetOpenOrdersByInstrumentPair("BTC_BRL", gomock.Any(), gomock.Any()).
+					Return(tt.orders, nil).
+					Times(1)
+			}
+
+			// The published book is capped at one level; the estimate must
+			// still walk all of them.
+			uc := NewOrderUseCase(zap.NewNop().Sugar(), orderRepo, nil, nil, nil, nil, nil, OrderUseCaseConfig{MaxBookLevels: 1})
+
+			estimate, err := uc.EstimateCost("BTC_BRL", tt.orderType, decimal.RequireFromString(tt.quantity))
+
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+			assertDecimalEqual(t, tt.wantFilled, estimate.FilledQuantity.String())
+			assertDecimalEqual(t, tt.wantCost, estimate.TotalCost.String())
+			assertDecimalEqual(t, tt.wantAverage, estimate.AveragePrice.String())
+			assert.Equal(t, tt.wantFillable, estimate.FullyFillable)
+		})
+	}
+}
+
+func TestOrderUseCase_GetOrderBook_MaxLevels(t *testing.T) {
+	log := zap.NewNop().Sugar()
+	db := newMigratedDB(t)
+	orderRepo := repository.NewOrderRepository(log, db)
+	walletRepo := repository.NewWalletRepository(log, db, nil)
+	tradeRepo := repository.NewTradeRepository(log, db)
+	uc := NewOrderUseCase(log, orderRepo, walletRepo, tradeRepo, repository.NewEventRepository(log, db), nil, db, OrderUseCaseConfig{MaxBookLevels: 2})
+
+	seller, buyer := uuid.New(), uuid.New()
+	assert.NoError(t, walletRepo.Create(nil, &entity.Wallet{AccountID: seller, AssetSymbol: "BTC", Balance: decimal.RequireFromString("1")}))
+	assert.NoError(t, walletRepo.Create(nil, &entity.Wallet{AccountID: seller, AssetSymbol: "BRL", Balance: decimal.Zero}))
+	assert.NoError(t, walletRepo.Create(nil, &entity.Wallet{AccountID: buyer, AssetSymbol: "BRL", Balance: decimal.RequireFromString("1000")}))
+	assert.NoError(t, walletRepo.Create(nil, &entity.Wallet{AccountID: buyer, AssetSymbol: "BTC", Balance: decimal.Zero}))
+
+	for _, price := range []string{"102", "100", "101"} {
+		assert.NoError(t, uc.CreateOrder(context.Background(), &entity.Order{
+			AccountID:      seller,
+			InstrumentPair: "BTC_BRL",
+			OrderType:      string(entity.OrderTypeSell),
+			Price:          decimal.RequireFromString(price),
+			Quantity:       decimal.RequireFromString("0.1"),
+		}))
+	}
+
+	book, err := uc.GetOrderBook("BTC_BRL", decimal.Zero)
+	assert.NoError(t, err)
+	if assert.Len(t, book.Asks, 2) {
+		assertDecimalEqual(t, "100", book.Asks[0].Price.String())
+		assertDecimalEqual(t, "101", book.Asks[1].Price.String())
+	}
+
+	depth, err := uc.GetDepth("BTC_BRL", string(entity.BookSideAsk), decimal.RequireFromString("102"))
+	assert.NoError(t, err)
+	assertDecimalEqual(t, "0.3", depth.String())
+
+	taker := &entity.Order{
+		AccountID:      buyer,
+		InstrumentPair: "BTC_BRL",
+		OrderType:      string(entity.OrderTypeBuy),
+		Price:          decimal.RequireFromString("102"),
+		Quantity:       decimal.RequireFromString("0.3"),
+	}
+	assert.NoError(t, uc.CreateOrder(context.Background(), taker))
+	assert.Equal(t, string(entity.OrderStatusFilled), taker.Status)
+
+	trades, err := tradeRepo.GetByOrderID(taker.ID)
+	assert.NoError(t, err)
+	if assert.Len(t, trades, 3) {
+		assert.Equal(t, "102", trades[2].Price.String())
+	}
+}
+
+func TestOrderUseCase_ImportOrders(t *testing.T) {
+	log := zap.NewNop().Sugar()
+	db := newMigratedDB(t)
+	orderRepo := repository.NewOrderRepository(log, db)
+	walletRepo := repository.NewWalletRepository(log, db, nil)
+	tradeRepo := repository.NewTradeRepository(log, db)
+	uc := NewOrderUseCase(log, orderRepo, walletRepo, tradeRepo, repository.NewEventRepository(log, db), nil, db, OrderUseCaseConfig{})
+
+	maker, unfunded, other := uuid.New(), uuid.New(), uuid.New()
+	assert.NoError(t, walletRepo.Create(nil, &entity.Wallet{AccountID: maker, AssetSymbol: "BTC", Balance: decimal.RequireFromString("0.75")}))
+	assert.NoError(t, walletRepo.Create(nil, &entity.Wallet{AccountID: maker, AssetSymbol: "BRL", Balance: decimal.RequireFromString("295")}))
+	assert.NoError(t, orderRepo.Create(nil, &entity.Order{
+		AccountID:         other,
+		InstrumentPair:    "BTC_BRL",
+		OrderType:         string(entity.OrderTypeBuy),
+		Price:             decimal.RequireFromString("100"),
+		Quantity:          decimal.RequireFromString("1"),
+		RemainingQuantity: decimal.RequireFromString("1"),
+		Status:            string(entity.OrderStatusOpen),
+	}))
+
+	order := func(accountID uuid.UUID, orderType entity.OrderType, price, quantity string) *entity.Order {
+		return &entity.Order{
+			AccountID:      accountID,
+			InstrumentPair: "btc_brl",
+			OrderType:      string(orderType),
+			Price:          decimal.RequireFromString(price),
+			Quantity:       decimal.RequireFromString(quantity),
+		}
+	}
+	orders := []*entity.Order{
+		order(maker, entity.OrderTypeBuy, "99", "1"),
+		order(maker, entity.OrderTypeBuy, "98", "2"),
+		order(maker, entity.OrderTypeSell, "0", "1"),
+		order(maker, entity.OrderTypeSell, "101", "0.5"),
+		order(unfunded, entity.OrderTypeSell, "102", "1"),
+		// Crosses the 99 bid imported above; imports are not matched.
+		order(maker, entity.OrderTypeSell, "97", "0.25"),
+		// Only 0.25 BTC is left once the 101 ask is reserved.
+		order(maker, entity.OrderTypeSell, "103", "0.5"),
+		// Crosses the 100 bid already resting on the book.
+		order(maker, entity.OrderTypeSell, "100", "0.1"),
+	}
+
+	// A batch size of 2 spreads the three valid orders over two inserts.
+	result, err := uc.ImportOrders(context.Background(), orders, 2)
+	assert.NoError(t, err)
+	assert.Equal(t, 3, result.Imported)
+	if assert.Len(t, result.Failures, 5) {
+		assert.Equal(t, 2, result.Failures[0].Index)
+		assert.ErrorIs(t, result.Failures[0].Err, entity.ErrInvalidPrice)
+		assert.Equal(t, 4, result.Failures[1].Index)
+		assert.ErrorIs(t, result.Failures[1].Err, entity.ErrWalletNotFound)
+		assert.Equal(t, 5, result.Failures[2].Index)
+		assert.ErrorIs(t, result.Failures[2].Err, entity.ErrImportCrossesBook)
+		assert.Equal(t, 6, result.Failures[3].Index)
+		assert.ErrorIs(t, result.Failures[3].Err, entity.ErrInsufficientBalance)
+		assert.Equal(t, 7, result.Failures[4].Index)
+		assert.ErrorIs(t, result.Failures[4].Err, entity.ErrImportCrossesBook)
+	}
+
+	book, err := uc.GetOrderBook("BTC_BRL", decimal.Zero)
+	assert.NoError(t, err)
+	if assert.Len(t, book.Bids, 3) {
+		assertDecimalEqual(t, "100", book.Bids[0].Price.String())
+		assertDecimalEqual(t, "99", book.Bids[1].Price.String())
+		assertDecimalEqual(t, "98", book.Bids[2].Price.String())
+		assertDecimalEqual(t, "2", book.Bids[2].Quantity.String())
+	}
+	if assert.Len(t, book.Asks, 1) {
+		assertDecimalEqual(t, "101", book.Asks[0].Price.String())
+	}
+
+	var trades int64
+	assert.NoError(t, db.Model(&entity.Trade{}).Count(&trades).Error)
+	assert.Zero(t, trades)
+
+	// Each imported order gets its ORDER_CREATED event, the rejected ones none.
+	for i, o := range orders {
+		var created int64
+		assert.NoError(t, db.Model(&entity.Event{}).
+			Where("event_type = ? AND aggregate_id = ?", string(entity.EventTypeOrderCreated), o.ID).
+			Count(&created).Error)
+		if i == 0 || i == 1 || i == 3 {
+			assert.Equal(t, int64(1), created, "order %d", i)
+		} else {
+			assert.Zero(t, created, "order %d", i)
+		}
+	}
+
+	// Imported orders hold their reservation like placed ones.
+	reserved, err := orderRepo.GetReservedAmount(nil, maker, "BRL")
+	assert.NoError(t, err)
+	assertDecimalEqual(t, "295", reserved.String())
+}
+
+func TestOrderUseCase_GetRawOrderBook(t *testing.T) {
+	t0 := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	newOrder := func(orderType, price string, createdAt time.Time) *entity.Order {
+		return &entity.Order{
+			Base:              entity.Base{ID: uuid.New(), CreatedAt: createdAt},
+			OrderType:         orderType,
+			Price:             decimal.RequireFromString(price),
+			RemainingQuantity: decimal.RequireFromString("1"),
+		}
+	}
+
+	bidLate := newOrder("BUY", "100", t0.Add(2*time.Second))
+	bidEarly := newOrder("BUY", "100", t0)
+	bidBest := newOrder("BUY", "101", t0.Add(5*time.Second))
+	bidWorst := newOrder("BUY", "99", t0.Add(-time.Second))
+	askLate := newOrder("SELL", "102", t0.Add(3*time.Second))
+	askBest := newOrder("SELL", "101.5", t0.Add(4*time.Second))
+	askEarly := newOrder("SELL", "102", t0.Add(time.Second))
+	book := []*entity.Order{bidLate, askLate, bidEarly, askBest, bidBest, askEarly, bidWorst}
+
+	ids := func(entries []*RawOrderBookEntry) []uuid.UUID {
+		out := make([]uuid.UUID, len(entries))
+		for i, e := range entries {
+			out[i] = e.OrderID
+		}
+		return out
+	}
+
+	tests := []struct {
+		name     string
+		pair     string
+		depth    int
+		orders   []*entity.Order
+		skipRepo bool
+		wantErr  error
+		wantBids []uuid.UUID
+		wantAsks []uuid.UUID
+	}{
+		{
+			name:     "sorted by price then time",
+			pair:     "BTC_BRL",
+			orders:   book,
+			wantBids: []uuid.UUID{bidBest.ID, bidEarly.ID, bidLate.ID, bidWorst.ID},
+			wantAsks: []uuid.UUID{askBest.ID, askEarly.ID, askLate.ID},
+		},
+		{
+			name:     "depth caps each side",
+			pair:     "BTC_BRL",
+			depth:    2,
+			orders:   book,
+			wantBids: []uuid.UUID{bidBest.ID, bidEarly.ID},
+			wantAsks: []uuid.UUID{askBest.ID, askEarly.ID},
+		},
+		{name: "empty book", pair: "BTC_BRL", orders: nil, wantBids: []uuid.UUID{}, wantAsks: []uuid.UUID{}},
+		{name: "invalid pair", pair: "BTCBRL", skipRepo: true, wantErr: entity.ErrInvalidPairFormat},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			orderRepo := repository.NewMockOrderRepository(ctrl)
+			if !tt.skipRepo {
+				orderRepo.EXPECT().
+					GetOpenOrdersByInstrumentPair(tt.pair, gomock.Any(), gomock.Any()).
+					Return(append([]*entity.Order(nil), tt.orders...), nil).
+					Times(1)
+			}
+
+			uc := NewOrderUseCase(zap.NewNop().Sugar(), orderRepo, nil, nil, nil, nil, nil, OrderUseCaseConfig{})
+
+			raw, err := uc.GetRawOrderBook(tt.pair, tt.depth)
+
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				assert.Nil(t, raw)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.wantBids, ids(raw.Bids))
+			assert.Equal(t, tt.wantAsks, ids(raw.Asks))
+		})
+	}
+}
+
+func TestOrderUseCase_GetOrderBook_MalformedPrice(t *testing.T) {
+	log := zap.NewNop().Sugar()
+	db := newMigratedDB(t)
+	orderRepo := repository.NewOrderRepository(log, db)
+	uc := NewOrderUseCase(log, orderRepo, nil, nil, nil, nil, db, OrderUseCaseConfig{})
+
+	err := db.Exec(`INSERT INTO "order" (id, account_id, instrument_pair, order_type, price, quantity, remaining_quantity, status)
+		VALUES (?, ?, 'BTC_BRL', 'BUY', 'not-a-price', '1', '1', 'OPEN')`, uuid.New(), uuid.New()).Error
+	if err != nil {
+		t.Fatalf("failed to seed malformed order: %v", err)
+	}
+
+	assert.NotPanics(t, func() {
+		ob, err := uc.GetOrderBook("BTC_BRL", decimal.Zero)
+		assert.Error(t, err)
+		assert.Nil(t, ob)
+	})
+}
+
+func TestOrderUseCase_GetOrderBook_SkipsNonPositiveRows(t *testing.T) {
+	log := zap.NewNop().Sugar()
+	db := newMigratedDB(t)
+	orderRepo := repository.NewOrderRepository(log, db)
+	uc := NewOrderUseCase(log, orderRepo, nil, nil, nil, nil, db, OrderUseCaseConfig{})
+
+	for _, row := range []struct{ orderType, price, remaining string }{
+		{"BUY", "100", "1"},
+		{"BUY", "100", "-0.4"},
+		{"BUY", "-5", "2"},
+		{"SELL", "110", "0.5"},
+		{"SELL", "110", "0"},
+	} {
+		err := db.Exec(`INSERT INTO "order" (id, account_id, instrument_pair, order_type, price, quantity, remaining_quantity, status)
+			VALUES (?, ?, 'BTC_BRL', ?, ?, '1', ?, 'OPEN')`, uuid.New(), uuid.New(), row.orderType, row.price, row.remaining).Error
+		if err != nil {
+			t.Fatalf("failed to seed order: %v", err)
+		}
+	}
+
+	ob, err := uc.GetOrderBook("BTC_BRL", decimal.Zero)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	if assert.Len(t, ob.Bids, 1) {
+		assertDecimalEqual(t, "100", ob.Bids[0].Price.String())
+		assertDecimalEqual(t, "1", ob.Bids[0].Quantity.String())
+	}
+	if assert.Len(t, ob.Asks, 1) {
+		assertDecimalEqual(t, "110", ob.Asks[0].Price.String())
+		assertDecimalEqual(t, "0.5", ob.Asks[0].Quantity.String())
+	}
+}
+
+func TestOrderUseCase_CreateOrder_PartialThenFilled(t *testing.T) {
+	log := zap.NewNop().Sugar()
+	db := newMigratedDB(t)
+	orderRepo := repository.NewOrderRepository(log, db)
+	walletRepo := repository.NewWalletRepository(log, db, nil)
+	uc := NewOrderUseCase(log, orderRepo, walletRepo, repository.NewTradeRepository(log, db), repository.NewEventRepository(log, db), nil, db, OrderUseCaseConfig{})
+
+	sellerID, firstBuyerID, secondBuyerID := uuid.New(), uuid.New(), uuid.New()
+	seedWallets := map[uuid.UUID]map[string]string{
+		sellerID:      {"BTC": "1", "BRL": "0"},
+		firstBuyerID:  {"BTC": "0", "BRL": "1000"},
+		secondBuyerID: {"BTC": "0", "BRL": "1000"},
+	}
+	for accountID, balances := range seedWallets {
+		for asset, balance := range balances {
+			w := &entity.Wallet{AccountID: accountID, AssetSymbol: asset, Balance: decimal.RequireFromString(balance)}
+			if err := walletRepo.Create(nil, w); err != nil {
+				t.Fatalf("failed to seed wallet: %v", err)
+			}
+		}
+	}
+
+	newOrder := func(accountID uuid.UUID, orderType entity.OrderType, quantity string) *entity.Order {
+		return &entity.Order{
+			AccountID:      accountID,
+			InstrumentPair: "BTC_BRL",
+			OrderType:      string(orderType),
+			Price:          decimal.RequireFromString("100"),
+			Quantity:       decimal.RequireFromString(quantity),
+		}
+	}
+	assertOrder := func(id uuid.UUID, status entity.OrderStatus, remaining string) {
+		t.Helper()
+		got, err := orderRepo.GetByID(id)
+		if assert.NoError(t, err) {
+			assert.Equal(t, string(status), got.Status)
+			assertDecimalEqual(t, remaining, got.RemainingQuantity.String())
+		}
+	}
+	countTrades := func() int64 {
+		var n int64
+		assert.NoError(t, db.Model(&entity.Trade{}).Count(&n).Error)
+		return n
+	}
+
+	maker := newOrder(sellerID, entity.OrderTypeSell, "1")
+	assert.NoError(t, uc.CreateOrder(context.Background(), maker))
+	assertOrder(maker.ID, entity.OrderStatusOpen, "1")
+
+	firstTaker := newOrder(firstBuyerID, entity.OrderTypeBuy, "0.4")
+	assert.NoError(t, uc.CreateOrder(context.Background(), firstTaker))
+	assertOrder(maker.ID, entity.OrderStatusPartial, "0.6")
+	assertOrder(firstTaker.ID, entity.OrderStatusFilled, "0")
+	assert.Equal(t, int64(1), countTrades())
+
+	secondTaker := newOrder(secondBuyerID, entity.OrderTypeBuy, "0.6")
+	assert.NoError(t, uc.CreateOrder(context.Background(), secondTaker))
+	assertOrder(maker.ID, entity.OrderStatusFilled, "0")
+	assertOrder(secondTaker.ID, entity.OrderStatusFilled, "0")
+	assert.Equal(t, int64(2), countTrades())
+
+	wantBalances := map[uuid.UUID]map[string]string{
+		sellerID:      {"BTC": "0", "BRL": "100"},
+		firstBuyerID:  {"BTC": "0.4", "BRL": "960"},
+		secondBuyerID: {"BTC": "0.6", "BRL": "940"},
+	}
+	for accountID, balances := range wantBalances {
+		for asset, want := range balances {
+			wallet, err := walletRepo.GetByAccountAndAsset(db, accountID, asset)
+			if assert.NoError(t, err) {
+				assert.True(t, wallet.Balance.Equal(decimal.RequireFromString(want)),
+					"%s balance for %s: got %s, want %s", asset, accountID, wallet.Balance, want)
+			}
+		}
+	}
+
+	var tradeEvents int64
+	assert.NoError(t, db.Model(&entity.Event{}).
+		Where("event_type = ?", string(entity.EventTypeTradeExecuted)).
+		Count(&tradeEvents).Error)
+	assert.Equal(t, int64(2), tradeEvents)
+}
+
+func TestOrderUseCase_CreateOrder_MinFill(t *testing.T) {
+	tests := []struct {
+		name          string
+		minFill       string
+		wantStatus    entity.OrderStatus
+		wantRemaining string
+		wantTrades    int64
+	}{
+		{name: "available equals minimum", minFill: "1", wantStatus: entity.OrderStatusPartial, wantRemaining: "1", wantTrades: 2},
+		{name: "available just below minimum", minFill: "1.00000001", wantStatus: entity.OrderStatusCancelled, wantRemaining: "2", wantTrades: 0},
+		{name: "available above minimum", minFill: "0.5", wantStatus: entity.OrderStatusPartial, wantRemaining: "1", wantTrades: 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			log := zap.NewNop().Sugar()
+			db := newMigratedDB(t)
+			orderRepo := repository.NewOrderRepository(log, db)
+			walletRepo := repository.NewWalletRepository(log, db, nil)
+			uc := NewOrderUseCase(log, orderRepo, walletRepo, repository.NewTradeRepository(log, db), repository.NewEventRepository(log, db), nil, db, OrderUseCaseConfig{})
+
+			sellerID, buyerID := uuid.New(), uuid.New()
+			for _, w := range []*entity.Wallet{
+				{AccountID: sellerID, AssetSymbol: "BTC", Balance: decimal.RequireFromString("1")},
+				{AccountID: sellerID, AssetSymbol: "BRL", Balance: decimal.Zero},
+				{AccountID: buyerID, AssetSymbol: "BTC", Balance: decimal.Zero},
+				{AccountID: buyerID, AssetSymbol: "BRL", Balance: decimal.RequireFromString("1000")},
+			} {
+				if err := walletRepo.Create(nil, w); err != nil {
+					t.Fatalf("failed to seed wallet: %v", err)
+				}
+			}
+
+			// 1 BTC of liquidity split across two resting asks.
+			for _, qty := range []string{"0.6", "0.4"} {
+				maker := &entity.Order{
+					AccountID:         sellerID,
+					InstrumentPair:    "BTC_BRL",
+					OrderType:         string(entity.OrderTypeSell),
+					Price:             decimal.RequireFromString("100"),
+					Quantity:          decimal.RequireFromString(qty),
+					RemainingQuantity: decimal.RequireFromString(qty),
+					Status:            string(entity.OrderStatusOpen),
+				}
+				if err := orderRepo.Create(nil, maker); err != nil {
+					t.Fatalf("failed to seed maker: %v", err)
+				}
+			}
+
+			taker := &entity.Order{
+				AccountID:       buyerID,
+				InstrumentPair:  "BTC_BRL",
+				OrderType:       string(entity.OrderTypeBuy),
+				Price:           decimal.RequireFromString("100"),
+				Quantity:        decimal.RequireFromString("2"),
+				MinFillQuantity: decimal.RequireFromString(tt.minFill),
+			}
+			assert.NoError(t, uc.CreateOrder(context.Background(), taker))
+			assert.Equal(t, string(tt.wantStatus), taker.Status)
+
+			got, err := orderRepo.GetByID(taker.ID)
+			if assert.NoError(t, err) {
+				assert.Equal(t, string(tt.wantStatus), got.Status)
+				assertDecimalEqual(t, tt.wantRemaining, got.RemainingQuantity.String())
+			}
+
+			var trades int64
+			assert.NoError(t, db.Model(&entity.Trade{}).Count(&trades).Error)
+			assert.Equal(t, tt.wantTrades, trades)
+		})
+	}
+}
+
+func TestOrderUseCase_GetOrderFills(t *testing.T) {
+	log := zap.NewNop().Sugar()
+	db := newMigratedDB(t)
+	orderRepo := repository.NewOrderRepository(log, db)
+	walletRepo := repository.NewWalletRepository(log, db, nil)
+	uc := NewOrderUseCase(log, orderRepo, walletRepo, repository.NewTradeRepository(log, db), repository.NewEventRepository(log, db), nil, db, OrderUseCaseConfig{})
+
+	buyerID, sellerID := uuid.New(), uuid.New()
+	for _, w := range []*entity.Wallet{
+		{AccountID: buyerID, AssetSymbol: "BTC", Balance: decimal.Zero},
+		{AccountID: buyerID, AssetSymbol: "BRL", Balance: decimal.RequireFromString("1000")},
+		{AccountID: sellerID, AssetSymbol: "BTC", Balance: decimal.RequireFromString("1")},
+		{AccountID: sellerID, AssetSymbol: "BRL", Balance: decimal.Zero},
+	} {
+		if err := walletRepo.Create(nil, w); err != nil {
+			t.Fatalf("failed to seed wallet: %v", err)
+		}
+	}
+
+	newOrder := func(accountID uuid.UUID, orderType entity.OrderType, quantity string) *entity.Order {
+		return &entity.Order{
+			AccountID:      accountID,
+			InstrumentPair: "BTC_BRL",
+			OrderType:      string(orderType),
+			Price:          decimal.RequireFromString("100"),
+			Quantity:       decimal.RequireFromString(quantity),
+		}
+	}
+
+	maker := newOrder(buyerID, entity.OrderTypeBuy, "1")
+	assert.NoError(t, uc.CreateOrder(context.Background(), maker))
+
+	unfilled, err := uc.GetOrderFills(maker.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, maker.ID, unfilled.Order.ID)
+	assert.Empty(t, unfilled.Fills)
+
+	for _, qty := range []string{"0.2", "0.3", "0.5"} {
+		assert.NoError(t, uc.CreateOrder(context.Background(), newOrder(sellerID, entity.OrderTypeSell, qty)))
+	}
+
+	got, err := uc.GetOrderFills(maker.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, string(entity.OrderStatusFilled), got.Order.Status)
+
+	wantQuantities := []string{"0.2", "0.3", "0.5"}
+	wantRemaining := []string{"0.8", "0.5", "0"}
+	if assert.Len(t, got.Fills, 3) {
+		for i, fill := range got.Fills {
+			assertDecimalEqual(t, wantQuantities[i], fill.Trade.Quantity.String())
+			assertDecimalEqual(t, wantRemaining[i], fill.RemainingQuantity.String())
+		}
+	}
+
+	_, err = uc.GetOrderFills(uuid.New())
+	assert.ErrorIs(t, err, repository.ErrNotFound)
+}
+
+func TestOrderUseCase_GetFilledOrders(t *testing.T) {
+	log := zap.NewNop().Sugar()
+	db := newMigratedDB(t)
+	orderRepo := repository.NewOrderRepository(log, db)
+	walletRepo := repository.NewWalletRepository(log, db, nil)
+	uc := NewOrderUseCase(log, orderRepo, walletRepo, repository.NewTradeRepository(log, db), repository.NewEventRepository(log, db), nil, db, OrderUseCaseConfig{})
+
+	buyerID, sellerID := uuid.New(), uuid.New()
+	for _, w := range []*entity.Wallet{
+		{AccountID: buyerID, AssetSymbol: "BTC", Balance: decimal.Zero},
+		{AccountID: buyerID, AssetSymbol: "BRL", Balance: decimal.RequireFromString("1000")},
+		{AccountID: sellerID, AssetSymbol: "BTC", Balance: decimal.RequireFromString("2")},
+		{AccountID: sellerID, AssetSymbol: "BRL", Balance: decimal.Zero},
+	} {
+		if err := walletRepo.Create(nil, w); err != nil {
+			t.Fatalf("failed to seed wallet: %v", err)
+		}
+	}
+
+	newOrder := func(accountID uuid.UUID, orderType entity.OrderType, price, quantity string) *entity.Order {
+		return &entity.Order{
+			AccountID:      accountID,
+			InstrumentPair: "BTC_BRL",
+			OrderType:      string(orderType),
+			Price:          decimal.RequireFromString(price),
+			Quantity:       decimal.RequireFromString(quantity),
+		}
+	}
+
+	// Two resting sells at different prices, then one buy that sweeps both
+	// and rests with the rest of its quantity.
+	assert.NoError(t, uc.CreateOrder(context.Background(), newOrder(sellerID, entity.OrderTypeSell, "100", "0.5")))
+	assert.NoError(t, uc.CreateOrder(context.Background(), newOrder(sellerID, entity.OrderTypeSell, "110", "0.25")))
+	taker := newOrder(buyerID, entity.OrderTypeBuy, "120", "1")
+	assert.NoError(t, uc.CreateOrder(context.Background(), taker))
+
+	// An order without fills is left out.
+	assert.NoError(t, uc.CreateOrder(context.Background(), newOrder(buyerID, entity.OrderTypeBuy, "1", "1")))
+
+	page, err := uc.GetFilledOrders(buyerID, uuid.Nil, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, uuid.Nil, page.NextCursor)
+	if assert.Len(t, page.Orders, 1) {
+		got := page.Orders[0]
+		assert.Equal(t, taker.ID, got.Order.ID)
+		assert.Equal(t, string(entity.OrderStatusPartial), got.Order.Status)
+		assert.True(t, entity.DecimalEqual(decimal.RequireFromString("0.75"), got.BaseFilled), got.BaseFilled.String())
+		// 0.5 * 100 + 0.25 * 110
+		assert.True(t, entity.DecimalEqual(decimal.RequireFromString("77.5"), got.QuoteExchanged), got.QuoteExchanged.String())
+		assert.Equal(t, "103.3333333333333333", got.AveragePrice.String())
+	}
+
+	// The seller's two makers are paged newest first.
+	first, err := uc.GetFilledOrders(sellerID, uuid.Nil, 1)
+	assert.NoError(t, err)
+	if assert.Len(t, first.Orders, 1) {
+		assert.Equal(t, "110", first.Orders[0].AveragePrice.String())
+		assert.Equal(t, first.Orders[0].Order.ID, first.NextCursor)
+	}
+
+	second, err := uc.GetFilledOrders(sellerID, first.NextCursor, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, uuid.Nil, second.NextCursor)
+	if assert.Len(t, second.Orders, 1) {
+		assert.Equal(t, "100", second.Orders[0].AveragePrice.String())
+	}
+}
+
+func TestOrderUseCase_CreateOrder_MarketBuyQuoteQuantity(t *testing.T) {
+	tests := []struct {
+		name         string
+		asks         [][2]string // price, quantity
+		budget       string
+		wantStatus   entity.OrderStatus
+		wantBase     string
+		wantSpent    string
+		wantMakerQty []string // remaining quantity of each ask afterwards
+	}{
+		{
+			name:         "budget spent across three levels",
+			asks:         [][2]string{{"100", "0.1"}, {"110", "0.2"}, {"120", "0.5"}},
+			budget:       "50",
+			wantStatus:   entity.OrderStatusFilled,
+			wantBase:     "0.45",
+			wantSpent:    "50",
+			wantMakerQty: []string{"0", "0", "0.35"},
+		},
+		{
+			name:         "base is truncated so the budget is never overspent",
+			asks:         [][2]string{{"3", "10"}},
+			budget:       "10",
+			wantStatus:   entity.OrderStatusFilled,
+			wantBase:     "3.33333333",
+			wantSpent:    "9.99999999",
+			wantMakerQty: []string{"6.66666667"},
+		},
+		{
+			name:         "book runs out before the budget",
+			asks:         [][2]string{{"100", "0.1"}, {"200", "0.1"}},
+			budget:       "500",
+			wantStatus:   entity.OrderStatusFilled,
+			wantBase:     "0.2",
+			wantSpent:    "30",
+			wantMakerQty: []string{"0", "0"},
+		},
+		{
+			name:       "empty book cancels the order",
+			budget:     "50",
+			wantStatus: entity.OrderStatusCancelled,
+			wantBase:   "0",
+			wantSpent:  "0",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			log := zap.NewNop().Sugar()
+			db := newMigratedDB(t)
+			instruments := entity.NewInstrumentConfig(entity.Asset{Symbol: "BTC", Scale: 8}, entity.Asset{Symbol: "BRL", Scale: 2})
+			orderRepo := repository.NewOrderRepository(log, db)
+			walletRepo := repository.NewWalletRepository(log, db, instruments)
+			tradeRepo := repository.NewTradeRepository(log, db)
+			uc := NewOrderUseCase(log, orderRepo, walletRepo, tradeRepo, repository.NewEventRepository(log, db), nil, db, OrderUseCaseConfig{Instruments: instruments})
+
+			buyerID, sellerID := uuid.New(), uuid.New()
+			for _, w := range []*entity.Wallet{
+				{AccountID: buyerID, AssetSymbol: "BTC", Balance: decimal.Zero},
+				{AccountID: buyerID, AssetSymbol: "BRL", Balance: decimal.RequireFromString("1000")},
+				{AccountID: sellerID, AssetSymbol: "BTC", Balance: decimal.RequireFromString("100")},
+				{AccountID: sellerID, AssetSymbol: "BRL", Balance: decimal.Zero},
+			} {
+				if err := walletRepo.Create(nil, w); err != nil {
+					t.Fatalf("failed to seed wallet: %v", err)
+				}
+			}
+
+			makers := make([]*entity.Order, len(tt.asks))
+			for i, ask := range tt.asks {
+				makers[i] = &entity.Order{
+					AccountID:      sellerID,
+					InstrumentPair: "BTC_BRL",
+					OrderType:      string(entity.OrderTypeSell),
+					Price:          decimal.RequireFromString(ask[0]),
+					Quantity:       decimal.RequireFromString(ask[1]),
+				}
+				assert.NoError(t, uc.CreateOrder(context.Background(), makers[i]))
+			}
+
+			order := &entity.Order{
+				AccountID:      buyerID,
+				InstrumentPair: "BTC_BRL",
+				OrderType:      string(entity.OrderTypeBuy),
+				QuoteQuantity:  decimal.RequireFromString(tt.budget),
+			}
+			assert.NoError(t, uc.CreateOrder(context.Background(), order))
+
+			// SQLite keeps decimals as floats, so values read back are
+			// compared at the column scale.
+			stored, err := orderRepo.GetByID(order.ID)
+			if assert.NoError(t, err) {
+				assert.Equal(t, string(tt.wantStatus), stored.Status)
+				assert.True(t, entity.DecimalEqual(decimal.RequireFromString(tt.wantBase), stored.Quantity.Round(8)), stored.Quantity.String())
+				assert.True(t, stored.RemainingQuantity.Round(8).IsZero(), stored.RemainingQuantity.String())
+			}
+
+			trades, err := tradeRepo.GetByOrderID(order.ID)
+			assert.NoError(t, err)
+			spent, bought := decimal.Zero, decimal.Zero
+			for _, trade := range trades {
+				spent = spent.Add(trade.Price.Mul(trade.Quantity))
+				bought = bought.Add(trade.Quantity)
+			}
+			assert.True(t, entity.DecimalEqual(decimal.RequireFromString(tt.wantSpent), spent.Round(8)), spent.String())
+			assert.True(t, entity.DecimalEqual(decimal.RequireFromString(tt.wantBase), bought.Round(8)), bought.String())
+
+			brl, err := walletRepo.GetByAccountAndAsset(db, buyerID, "BRL")
+			if assert.NoError(t, err) {
+				want := decimal.RequireFromString("1000").Sub(decimal.RequireFromString(tt.wantSpent))
+				assert.True(t, entity.DecimalEqual(want, brl.Balance.Round(8)), brl.Balance.String())
+			}
+			btc, err := walletRepo.GetByAccountAndAsset(db, buyerID, "BTC")
+			if assert.NoError(t, err) {
+				assert.True(t, entity.DecimalEqual(decimal.RequireFromString(tt.wantBase), btc.Balance.Round(8)), btc.Balance.String())
+			}
+
+			for i, maker := range makers {
+				got, err := orderRepo.GetByID(maker.ID)
+				if assert.NoError(t, err) {
+					assert.True(t, entity.DecimalEqual(decimal.RequireFromString(tt.wantMakerQty[i]), got.RemainingQuantity.Round(8)),
+						"maker %d remaining %s", i, got.RemainingQuantity)
+				}
+			}
+		})
+	}
+}
+
+func TestOrderUseCase_CreateOrder_MarketBuyOverBalance(t *testing.T) {
+	log := zap.NewNop().Sugar()
+	db := newMigratedDB(t)
+	walletRepo := repository.NewWalletRepository(log, db, nil)
+	uc := NewOrderUseCase(log, repository.NewOrderRepository(log, db), walletRepo, repository.NewTradeRepository(log, db), repository.NewEventRepository(log, db), nil, db, OrderUseCaseConfig{})
+
+	buyerID := uuid.New()
+	if err := walletRepo.Create(nil, &entity.Wallet{AccountID: buyerID, AssetSymbol: "BRL", Balance: decimal.RequireFromString("100")}); err != nil {
+		t.Fatalf("failed to seed wallet: %v", err)
+	}
+
+	err := uc.CreateOrder(context.Background(), &entity.Order{
+		AccountID:      buyerID,
+		InstrumentPair: "BTC_BRL",
+		OrderType:      string(entity.OrderTypeBuy),
+		QuoteQuantity:  decimal.RequireFromString("100.01"),
+	})
+	assert.ErrorIs(t, err, entity.ErrInsufficientBalance)
+}
+
+// newExpiryTestUseCase wires an order use case over a fresh file-backed
+// SQLite database, so the sweeper can read while a match holds a write
+// transaction, with a fake clock. It seeds a buyer and a seller with enough
+// of both assets to trade BTC_BRL.
+func newExpiryTestUseCase(t *testing.T, clock Clock, expiry ExpiryPolicy) (*orderUseCase, *gorm.DB, uuid.UUID, uuid.UUID) {
+	t.Helper()
+
+	log := zap.NewNop().Sugar()
+	db := newMigratedFileDB(t)
+	walletRepo := repository.NewWalletRepository(log, db, nil)
+	uc := NewOrderUseCase(log, repository.NewOrderRepository(log, db), walletRepo, repository.NewTradeRepository(log, db),
+		repository.NewEventRepository(log, db), nil, db, OrderUseCaseConfig{Clock: clock, Expiry: expiry}).(*orderUseCase)
+
+	buyerID, sellerID := uuid.New(), uuid.New()
+	for _, w := range []*entity.Wallet{
+		{AccountID: buyerID, AssetSymbol: "BTC", Balance: decimal.Zero},
+		{AccountID: buyerID, AssetSymbol: "BRL", Balance: decimal.RequireFromString("1000")},
+		{AccountID: sellerID, AssetSymbol: "BTC", Balance: decimal.RequireFromString("10")},
+		{AccountID: sellerID, AssetSymbol: "BRL", Balance: decimal.Zero},
+	} {
+		if err := walletRepo.Create(nil, w); err != nil {
+			t.Fatalf("failed to seed wallet: %v", err)
+		}
+	}
+
+	return uc, db, buyerID, sellerID
+}
+
+// pausingExecutor blocks the first Execute until release is closed, so a
+// test can act while a match is in flight.
+type pausingExecutor struct {
+	TradeExecutor
+	started chan struct{}
+	release chan struct{}
+	once    sync.Once
+}
+
+func (e *pausingExecutor) Execute(tx *gorm.DB, order, matchingOrder *entity.Order, qty decimal.Decimal) error {
+	e.once.Do(func() {
+		close(e.started)
+		<-e.release
+	})
+	return e.TradeExecutor.Execute(tx, order, matchingOrder, qty)
+}
+
+func eventTypesFor(t *testing.T, db *gorm.DB, aggregateID uuid.UUID) []string {
+	t.Helper()
+
+	var events []*entity.Event
+	if err := db.Where("aggregate_id = ?", aggregateID).Order("sequence ASC").Find(&events).Error; err != nil {
+		t.Fatalf("failed to read events: %v", err)
+	}
+	types := make([]string, len(events))
+	for i, event := range events {
+		types[i] = event.EventType
+	}
+	return types
+}
+
+func TestOrderUseCase_ExpireOrders(t *testing.T) {
+	start := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	clock := newFakeClock(start)
+	uc, db, _, sellerID := newExpiryTestUseCase(t, clock, ExpiryPolicy{Grace: 2 * time.Second})
+
+	sell := func(expiresAt *time.Time) *entity.Order {
+		return &entity.Order{
+			AccountID:      sellerID,
+			InstrumentPair: "BTC_BRL",
+			OrderType:      string(entity.OrderTypeSell),
+			Price:          decimal.RequireFromString("100"),
+			Quantity:       decimal.RequireFromString("1"),
+			ExpiresAt:      expiresAt,
+		}
+	}
+
+	past := start.Add(-time.Second)
+	assert.ErrorIs(t, uc.CreateOrder(context.Background(), sell(&past)), entity.ErrInvalidExpiry)
+
+	expiresAt := start.Add(time.Minute)
+	gtd := sell(&expiresAt)
+	gtc := sell(nil)
+	assert.NoError(t, uc.CreateOrder(context.Background(), gtd))
+	assert.NoError(t, uc.CreateOrder(context.Background(), gtc))
+
+	// Not yet expired.
+	n, err := uc.ExpireOrders()
+	assert.NoError(t, err)
+	assert.Zero(t, n)
+
+	clock.Advance(time.Minute)
+	n, err = uc.ExpireOrders()
+	assert.NoError(t, err)
+	assert.Equal(t, 1, n)
+
+	stored, err := uc.orderRepository.GetByID(gtd.ID)
+	if assert.NoError(t, err) {
+		assert.Equal(t, string(entity.OrderStatusCancelled), stored.Status)
+		assert.True(t, expiresAt.Equal(*stored.ExpiresAt))
+	}
+	assert.Equal(t, []string{string(entity.EventTypeOrderCreated), string(entity.EventTypeOrderExpired)}, eventTypesFor(t, db, gtd.ID))
+
+	stored, err = uc.orderRepository.GetByID(gtc.ID)
+	if assert.NoError(t, err) {
+		assert.Equal(t, string(entity.OrderStatusOpen), stored.Status)
+	}
+
+	// A second sweep finds nothing left to expire.
+	n, err = uc.ExpireOrders()
+	assert.NoError(t, err)
+	assert.Zero(t, n)
+}
+
+func TestOrderUseCase_ExpireOrders_MaxAge(t *testing.T) {
+	// created_at is stamped by the database clock, so the fake clock starts
+	// from the real time.
+	start := time.Now().UTC()
+	clock := newFakeClock(start)
+	uc, db, buyerID, _ := newExpiryTestUseCase(t, clock, ExpiryPolicy{MaxAge: time.Hour})
+
+	buy := func(expiresAt *time.Time) *entity.Order {
+		return &entity.Order{
+			AccountID:      buyerID,
+			InstrumentPair: "BTC_BRL",
+			OrderType:      string(entity.OrderTypeBuy),
+			Price:          decimal.RequireFromString("100"),
+			Quantity:       decimal.RequireFromString("2"),
+			ExpiresAt:      expiresAt,
+		}
+	}
+
+	// An explicit expiry earlier than the max age is the one that applies.
+	early := start.Add(10 * time.Minute)
+	gtd := buy(&early)
+	gtc := buy(nil)
+	assert.NoError(t, uc.CreateOrder(context.Background(), gtd))
+	assert.NoError(t, uc.CreateOrder(context.Background(), gtc))
+	assert.Equal(t, "400", reservedAmount(t, uc, buyerID, "BRL").String())
+
+	clock.Advance(10 * time.Minute)
+	n, err := uc.ExpireOrders()
+	assert.NoError(t, err)
+	assert.Equal(t, 1, n)
+	assert.Equal(t, "200", reservedAmount(t, uc, buyerID, "BRL").String())
+
+	// The order without an expiry rests until it outlives the max age.
+	clock.Advance(49 * time.Minute)
+	n, err = uc.ExpireOrders()
+	assert.NoError(t, err)
+	assert.Zero(t, n)
+
+	clock.Advance(2 * time.Minute)
+	n, err = uc.ExpireOrders()
+	assert.NoError(t, err)
+	assert.Equal(t, 1, n)
+
+	stored, err := uc.orderRepository.GetByID(gtc.ID)
+	if assert.NoError(t, err) {
+		assert.Equal(t, string(entity.OrderStatusCancelled), stored.Status)
+		assert.Nil(t, stored.ExpiresAt)
+	}
+	assert.Equal(t, []string{string(entity.EventTypeOrderCreated), string(entity.EventTypeOrderExpired)}, eventTypesFor(t, db, gtc.ID))
+
+	// Its reservation is released with it, freeing the whole balance.
+	assert.True(t, reservedAmount(t, uc, buyerID, "BRL").IsZero())
+	assert.NoError(t, uc.CreateOrder(context.Background(), &entity.Order{
+		AccountID:      buyerID,
+		InstrumentPair: "BTC_BRL",
+		OrderType:      string(entity.OrderTypeBuy),
+		Price:          decimal.RequireFromString("100"),
+		Quantity:       decimal.RequireFromString("10"),
+	}))
+}
+
+func TestOrderUseCase_CreateOrder_ExpiredUnsweptOrderDoesNotMatch(t *testing.T) {
+	start := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	clock := newFakeClock(start)
+	uc, db, buyerID, sellerID := newExpiryTestUseCase(t, clock, ExpiryPolicy{})
+
+	expiresAt := start.Add(time.Minute)
+	maker := &entity.Order{
+		AccountID:      sellerID,
+		InstrumentPair: "BTC_BRL",
+		OrderType:      string(entity.OrderTypeSell),
+		Price:          decimal.RequireFromString("100"),
+		Quantity:       decimal.RequireFromString("1"),
+		ExpiresAt:      &expiresAt,
+	}
+	assert.NoError(t, uc.CreateOrder(context.Background(), maker))
+	live := &entity.Order{
+		AccountID:      sellerID,
+		InstrumentPair: "BTC_BRL",
+		OrderType:      string(entity.OrderTypeSell),
+		Price:          decimal.RequireFromString("101"),
+		Quantity:       decimal.RequireFromString("1"),
+	}
+	assert.NoError(t, uc.CreateOrder(context.Background(), live))
+
+	// Past the maker's expiry, with no sweep run yet.
+	clock.Advance(time.Minute)
+
+	book, err := uc.GetOrderBook("BTC_BRL", decimal.Zero)
+	if assert.NoError(t, err) && assert.Len(t, book.Asks, 1) {
+		assertDecimalEqual(t, "101", book.Asks[0].Price.String())
+	}
+
+	taker := &entity.Order{
+		AccountID:      buyerID,
+		InstrumentPair: "BTC_BRL",
+		OrderType:      string(entity.OrderTypeBuy),
+		Price:          decimal.RequireFromString("100"),
+		Quantity:       decimal.RequireFromString("1"),
+	}
+	assert.NoError(t, uc.CreateOrder(context.Background(), taker))
+	assert.Equal(t, string(entity.OrderStatusOpen), taker.Status)
+
+	trades, err := uc.tradeRepository.GetByOrderID(maker.ID)
+	assert.NoError(t, err)
+	assert.Empty(t, trades)
+
+	// The maker is still stored OPEN until the sweeper cancels it.
+	stored, err := uc.orderRepository.GetByID(maker.ID)
+	if assert.NoError(t, err) {
+		assert.Equal(t, string(entity.OrderStatusOpen), stored.Status)
+	}
+	n, err := uc.ExpireOrders()
+	assert.NoError(t, err)
+	assert.Equal(t, 1, n)
+	assert.Equal(t, []string{string(entity.EventTypeOrderCreated), string(entity.EventTypeOrderExpired)}, eventTypesFor(t, db, maker.ID))
+}
+
+func TestOrderUseCase_CreateOrder_MaxAgeUnsweptOrderDoesNotMatch(t *testing.T) {
+	// created_at is stamped by the database clock, so the fake clock starts
+	// from the real time.
+	start := time.Now().UTC()
+	clock := newFakeClock(start)
+	uc, _, buyerID, sellerID := newExpiryTestUseCase(t, clock, ExpiryPolicy{MaxAge: time.Hour})
+
+	maker := &entity.Order{
+		AccountID:      sellerID,
+		InstrumentPair: "BTC_BRL",
+		OrderType:      string(entity.OrderTypeSell),
+		Price:          decimal.RequireFromString("100"),
+		Quantity:       decimal.RequireFromString("1"),
+	}
+	assert.NoError(t, uc.CreateOrder(context.Background(), maker))
+
+	// Past the max age, with no sweep run yet.
+	clock.Advance(time.Hour + time.Minute)
+
+	book, err := uc.GetOrderBook("BTC_BRL", decimal.Zero)
+	if assert.NoError(t, err) {
+		assert.Empty(t, book.Asks)
+	}
+
+	taker := &entity.Order{
+		AccountID:      buyerID,
+		InstrumentPair: "BTC_BRL",
+		OrderType:      string(entity.OrderTypeBuy),
+		Price:          decimal.RequireFromString("100"),
+		Quantity:       decimal.RequireFromString("1"),
+	}
+	assert.NoError(t, uc.CreateOrder(context.Background(), taker))
+	assert.Equal(t, string(entity.OrderStatusOpen), taker.Status)
+
+	trades, err := uc.tradeRepository.GetByOrderID(maker.ID)
+	assert.NoError(t, err)
+	assert.Empty(t, trades)
+}
+
+func TestOrderUseCase_ExpireOrders_MatchAtExpiryNotDoubleHandled(t *testing.T) {
+	start := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	clock := newFakeClock(start)
+	uc, db, buyerID, sellerID := newExpiryTestUseCase(t, clock, ExpiryPolicy{Grace: 2 * time.Second})
+
+	expiresAt := start.Add(time.Minute)
+	maker := &entity.Order{
+		AccountID:      sellerID,
+		InstrumentPair: "BTC_BRL",
+		OrderType:      string(entity.OrderTypeSell),
+		Price:          decimal.RequireFromString("100"),
+		Quantity:       decimal.RequireFromString("1"),
+		ExpiresAt:      &expiresAt,
+	}
+	assert.NoError(t, uc.CreateOrder(context.Background(), maker))
+
+	// The taker reaches the book just before the maker's expiry, and its
+	// match is paused mid-fill with the pair lock held while the expiry
+	// passes.
+	clock.Advance(time.Minute - time.Millisecond)
+	exec := &pausingExecutor{TradeExecutor: uc.executor, started: make(chan struct{}), release: make(chan struct{})}
+	uc.executor = exec
+	matched := make(chan error)
+	go func() {
+		matched <- uc.CreateOrder(context.Background(), &entity.Order{
+			AccountID:      buyerID,
+			InstrumentPair: "BTC_BRL",
+			OrderType:      string(entity.OrderTypeBuy),
+			Price:          decimal.RequireFromString("100"),
+			Quantity:       decimal.RequireFromString("1"),
+		})
+	}()
+	<-exec.started
+	clock.Advance(time.Millisecond)
+
+	// The sweeper sees the match in flight and leaves the maker, still
+	// within the grace, to it.
+	n, err := uc.ExpireOrders()
+	assert.NoError(t, err)
+	assert.Zero(t, n)
+
+	close(exec.release)
+	assert.NoError(t, <-matched)
+
+	n, err = uc.ExpireOrders()
+	assert.NoError(t, err)
+	assert.Zero(t, n)
+
+	stored, err := uc.orderRepository.GetByID(maker.ID)
+	if assert.NoError(t, err) {
+		assert.Equal(t, string(entity.OrderStatusFilled), stored.Status)
+	}
+	assert.Equal(t, []string{string(entity.EventTypeOrderCreated)}, eventTypesFor(t, db, maker.ID))
+
+	trades, err := uc.tradeRepository.GetByOrderID(maker.ID)
+	assert.NoError(t, err)
+	assert.Len(t, trades, 1)
+}
+
+// TestOrderUseCase_CreateOrder_CancelledContextRollsBack cancels the request
+// context while the taker's match is in flight, as a timed-out request does,
+// and checks that nothing the transaction wrote is committed.
+func TestOrderUseCase_CreateOrder_CancelledContextRollsBack(t *testing.T) {
+	clock := newFakeClock(time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC))
+	uc, db, buyerID, sellerID := newExpiryTestUseCase(t, clock, ExpiryPolicy{})
+
+	maker := &entity.Order{
+		AccountID:      sellerID,
+		InstrumentPair: "BTC_BRL",
+		OrderType:      string(entity.OrderTypeSell),
+		Price:          decimal.RequireFromString("100"),
+		Quantity:       decimal.RequireFromString("1"),
+	}
+	assert.NoError(t, uc.CreateOrder(context.Background(), maker))
+
+	exec := &pausingExecutor{TradeExecutor: uc.executor, started: make(chan struct{}), release: make(chan struct{})}
+	uc.executor = exec
+	ctx, cancel := context.WithCancel(context.Background())
+	taker := &entity.Order{
+		AccountID:      buyerID,
+		InstrumentPair: "BTC_BRL",
+		OrderType:      string(entity.OrderTypeBuy),
+		Price:          decimal.RequireFromString("100"),
+		Quantity:       decimal.RequireFromString("1"),
+	}
+	matched := make(chan error)
+	go func() {
+		matched <- uc.CreateOrder(ctx, taker)
+	}()
+	<-exec.started
+	cancel()
+	close(exec.release)
+	assert.Error(t, <-matched)
+
+	_, err := uc.orderRepository.GetByID(taker.ID)
+	assert.ErrorIs(t, err, repository.ErrNotFound)
+	stored, err := uc.orderRepository.GetByID(maker.ID)
+	if assert.NoError(t, err) {
+		assert.Equal(t, string(entity.OrderStatusOpen), stored.Status)
+	}
+	trades, err := uc.tradeRepository.GetByOrderID(maker.ID)
+	assert.NoError(t, err)
+	assert.Empty(t, trades)
+	assert.Empty(t, eventTypesFor(t, db, taker.ID))
+}
+
+func TestOrderUseCase_CreateOrder_VanishedLiquidityIsNotMatched(t *testing.T) {
+	uc, db, buyerID, sellerID := newExpiryTestUseCase(t, nil, ExpiryPolicy{})
+
+	newOrder := func(accountID uuid.UUID, orderType entity.OrderType) *entity.Order {
+		return &entity.Order{
+			AccountID:      accountID,
+			InstrumentPair: "BTC_BRL",
+			OrderType:      string(orderType),
+			Price:          decimal.RequireFromString("100"),
+			Quantity:       decimal.RequireFromString("1"),
+		}
+	}
+
+	// The ask is on the book when the book is read, then gone by the time
+	// the taker arrives.
+	maker := newOrder(sellerID, entity.OrderTypeSell)
+	assert.NoError(t, uc.CreateOrder(context.Background(), maker))
+	book, err := uc.GetOrderBook("BTC_BRL", decimal.Zero)
+	if assert.NoError(t, err) {
+		assert.Len(t, book.Asks, 1)
+	}
+	assert.NoError(t, uc.CancelOrder(context.Background(), maker.ID, uuid.Nil))
+
+	taker := newOrder(buyerID, entity.OrderTypeBuy)
+	assert.NoError(t, uc.CreateOrder(context.Background(), taker))
+	assert.Equal(t, string(entity.OrderStatusOpen), taker.Status)
+
+	var trades int64
+	assert.NoError(t, db.Model(&entity.Trade{}).Count(&trades).Error)
+	assert.Zero(t, trades)
+}
+
+func TestOrderUseCase_CreateOrder_ReadsBackTimestamps(t *testing.T) {
+	log := zap.NewNop().Sugar()
+	db := newMigratedDB(t)
+	walletRepo := repository.NewWalletRepository(log, db, nil)
+	uc := NewOrderUseCase(log, repository.NewOrderRepository(log, db), walletRepo, repository.NewTradeRepository(log, db), repository.NewEventRepository(log, db), nil, db, OrderUseCaseConfig{})
+
+	buyerID, sellerID := uuid.New(), uuid.New()
+	for _, w := range []*entity.Wallet{
+		{AccountID: buyerID, AssetSymbol: "BRL", Balance: decimal.RequireFromString("1000")},
+		{AccountID: sellerID, AssetSymbol: "BTC", Balance: decimal.RequireFromString("1")},
+	} {
+		if err := walletRepo.Create(nil, w); err != nil {
+			t.Fatalf("failed to seed wallet: %v", err)
+		}
+	}
+
+	maker := &entity.Order{
+		AccountID:      sellerID,
+		InstrumentPair: "BTC_BRL",
+		OrderType:      string(entity.OrderTypeSell),
+		Price:          decimal.RequireFromString("100"),
+		Quantity:       decimal.RequireFromString("0.5"),
+	}
+	assert.NoError(t, uc.CreateOrder(context.Background(), maker))
+
+	// The taker is filled after its insert, so updated_at moves in the
+	// database only.
+	taker := &entity.Order{
+		AccountID:      buyerID,
+		InstrumentPair: "BTC_BRL",
+		OrderType:      string(entity.OrderTypeBuy),
+		Price:          decimal.RequireFromString("100"),
+		Quantity:       decimal.RequireFromString("0.5"),
+	}
+	assert.NoError(t, uc.CreateOrder(context.Background(), taker))
+
+	var stored entity.Order
+	if err := db.First(&stored, "id = ?", taker.ID).Error; err != nil {
+		t.Fatalf("failed to read order: %v", err)
+	}
+	assert.False(t, taker.CreatedAt.IsZero())
+	assert.True(t, stored.CreatedAt.Equal(taker.CreatedAt), "created_at %s, stored %s", taker.CreatedAt, stored.CreatedAt)
+	assert.True(t, stored.UpdatedAt.Equal(taker.UpdatedAt), "updated_at %s, stored %s", taker.UpdatedAt, stored.UpdatedAt)
+}
+
+func TestOrderUseCase_CreateOrder_CreatesMissingReceivingWallet(t *testing.T) {
+	log := zap.NewNop().Sugar()
+	db := newMigratedDB(t)
+	walletRepo := repository.NewWalletRepository(log, db, nil)
+	uc := NewOrderUseCase(log, repository.NewOrderRepository(log, db), walletRepo, repository.NewTradeRepository(log, db), repository.NewEventRepository(log, db), nil, db, OrderUseCaseConfig{})
+
+	// Neither side holds the asset it is about to receive.
+	buyerID, sellerID := uuid.New(), uuid.New()
+	for _, w := range []*entity.Wallet{
+		{AccountID: buyerID, AssetSymbol: "BRL", Balance: decimal.RequireFromString("1000")},
+		{AccountID: sellerID, AssetSymbol: "BTC", Balance: decimal.RequireFromString("1")},
+	} {
+		if err := walletRepo.Create(nil, w); err != nil {
+			t.Fatalf("failed to seed wallet: %v", err)
+		}
+	}
+
+	maker := &entity.Order{
+		AccountID:      sellerID,
+		InstrumentPair: "BTC_BRL",
+		OrderType:      string(entity.OrderTypeSell),
+		Price:          decimal.RequireFromString("100"),
+		Quantity:       decimal.RequireFromString("0.5"),
+	}
+	assert.NoError(t, uc.CreateOrder(context.Background(), maker))
+
+	taker := &entity.Order{
+		AccountID:      buyerID,
+		InstrumentPair: "BTC_BRL",
+		OrderType:      string(entity.OrderTypeBuy),
+		Price:          decimal.RequireFromString("100"),
+		Quantity:       decimal.RequireFromString("0.5"),
+	}
+	assert.NoError(t, uc.CreateOrder(context.Background(), taker))
+	assert.Equal(t, string(entity.OrderStatusFilled), taker.Status)
+
+	for _, want := range []struct {
+		accountID uuid.UUID
+		asset     string
+		balance   string
+	}{
+		{sellerID, "BTC", "0.5"},
+		{sellerID, "BRL", "50"},
+		{buyerID, "BTC", "0.5"},
+		{buyerID, "BRL", "950"},
+	} {
+		wallet, err := walletRepo.GetByAccountAndAsset(db, want.accountID, want.asset)
+		if assert.NoError(t, err, "%s wallet of %s", want.asset, want.accountID) {
+			assertDecimalEqual(t, want.balance, wallet.Balance.Round(8).String())
+		}
+	}
+}
+
+func TestOrderUseCase_CreateOrder_SettlementFailureRollsBack(t *testing.T) {
+	log := zap.NewNop().Sugar()
+	db := newMigratedDB(t)
+	orderRepo := repository.NewOrderRepository(log, db)
+	walletRepo := repository.NewWalletRepository(log, db, nil)
+	uc := NewOrderUseCase(log, orderRepo, walletRepo, repository.NewTradeRepository(log, db), repository.NewEventRepository(log, db), nil, db, OrderUseCaseConfig{})
+
+	// The seller's BRL wallet is closed, and a closed wallet is not
+	// recreated, so the last settlement leg (crediting the seller's quote)
+	// fails after the first three have been applied.
+	buyerID, sellerID := uuid.New(), uuid.New()
+	closedAt := time.Now().UTC()
+	for _, w := range []*entity.Wallet{
+		{AccountID: buyerID, AssetSymbol: "BTC", Balance: decimal.Zero},
+		{AccountID: buyerID, AssetSymbol: "BRL", Balance: decimal.RequireFromString("1000")},
+		{AccountID: sellerID, AssetSymbol: "BTC", Balance: decimal.RequireFromString("1")},
+		{AccountID: sellerID, AssetSymbol: "BRL", Balance: decimal.Zero, DeletedAt: &closedAt},
+	} {
+		if err := walletRepo.Create(nil, w); err != nil {
+			t.Fatalf("failed to seed wallet: %v", err)
+		}
+	}
+
+	maker := &entity.Order{
+		AccountID:      sellerID,
+		InstrumentPair: "BTC_BRL",
+		OrderType:      string(entity.OrderTypeSell),
+		Price:          decimal.RequireFromString("100"),
+		Quantity:       decimal.RequireFromString("0.5"),
+	}
+	assert.NoError(t, uc.CreateOrder(context.Background(), maker))
+
+	var eventsBefore int64
+	assert.NoError(t, db.Model(&entity.Event{}).Count(&eventsBefore).Error)
+
+	taker := &entity.Order{
+		AccountID:      buyerID,
+		InstrumentPair: "BTC_BRL",
+		OrderType:      string(entity.OrderTypeBuy),
+		Price:          decimal.RequireFromString("100"),
+		Quantity:       decimal.RequireFromString("0.5"),
+	}
+	assert.Error(t, uc.CreateOrder(context.Background(), taker))
+
+	var trades int64
+	assert.NoError(t, db.Model(&entity.Trade{}).Count(&trades).Error)
+	assert.Zero(t, trades)
+
+	var eventsAfter int64
+	assert.NoError(t, db.Model(&entity.Event{}).Count(&eventsAfter).Error)
+	assert.Equal(t, eventsBefore, eventsAfter)
+
+	_, err := orderRepo.GetByID(taker.ID)
+	assert.ErrorIs(t, err, repository.ErrNotFound)
+
+	stored, err := orderRepo.GetByID(maker.ID)
+	if assert.NoError(t, err) {
+		assert.Equal(t, string(entity.OrderStatusOpen), stored.Status)
+		assert.True(t, entity.DecimalEqual(decimal.RequireFromString("0.5"), stored.RemainingQuantity), stored.RemainingQuantity.String())
+	}
+
+	for _, want := range []struct {
+		accountID uuid.UUID
+		asset     string
+		balance   string
+	}{
+		{sellerID, "BTC", "1"},
+		{buyerID, "BTC", "0"},
+		{buyerID, "BRL", "1000"},
+	} {
+		wallet, err := walletRepo.GetByAccountAndAsset(db, want.accountID, want.asset)
+		if assert.NoError(t, err) {
+			assert.True(t, entity.DecimalEqual(decimal.RequireFromString(want.balance), wallet.Balance),
+				"%s balance moved to %s", want.asset, wallet.Balance)
+		}
+	}
+}
+
+func TestOrderUseCase_GetAccountMarkets(t *testing.T) {
+	log := zap.NewNop().Sugar()
+	db := newMigratedDB(t)
+	orderRepo := repository.NewOrderRepository(log, db)
+	walletRepo := repository.NewWalletRepository(log, db, nil)
+	uc := NewOrderUseCase(log, orderRepo, walletRepo, repository.NewTradeRepository(log, db), repository.NewEventRepository(log, db), nil, db, OrderUseCaseConfig{})
+
+	traderID, counterpartyID := uuid.New(), uuid.New()
+	for _, w := range []*entity.Wallet{
+		{AccountID: traderID, AssetSymbol: "BTC", Balance: decimal.Zero},
+		{AccountID: traderID, AssetSymbol: "ETH", Balance: decimal.RequireFromString("5")},
+		{AccountID: traderID, AssetSymbol: "BRL", Balance: decimal.RequireFromString("1000")},
+		{AccountID: counterpartyID, AssetSymbol: "BTC", Balance: decimal.RequireFromString("2")},
+		{AccountID: counterpartyID, AssetSymbol: "ETH", Balance: decimal.Zero},
+		{AccountID: counterpartyID, AssetSymbol: "BRL", Balance: decimal.RequireFromString("1000")},
+	} {
+		if err := walletRepo.Create(nil, w); err != nil {
+			t.Fatalf("failed to seed wallet: %v", err)
+		}
+	}
+
+	newOrder := func(accountID uuid.UUID, pair string, orderType entity.OrderType, price, quantity string) *entity.Order {
+		return &entity.Order{
+			AccountID:      accountID,
+			InstrumentPair: pair,
+			OrderType:      string(orderType),
+			Price:          decimal.RequireFromString(price),
+			Quantity:       decimal.RequireFromString(quantity),
+		}
+	}
+
+	newAccount, err := uc.GetAccountMarkets(traderID)
+	assert.NoError(t, err)
+	assert.Empty(t, newAccount)
+
+	// BTC_BRL: two fills, the later one at 110, then a resting bid.
+	assert.NoError(t, uc.CreateOrder(context.Background(), newOrder(counterpartyID, "BTC_BRL", entity.OrderTypeSell, "100", "0.1")))
+	assert.NoError(t, uc.CreateOrder(context.Background(), newOrder(traderID, "BTC_BRL", entity.OrderTypeBuy, "100", "0.1")))
+	assert.NoError(t, uc.CreateOrder(context.Background(), newOrder(counterpartyID, "BTC_BRL", entity.OrderTypeSell, "110", "0.2")))
+	assert.NoError(t, uc.CreateOrder(context.Background(), newOrder(traderID, "BTC_BRL", entity.OrderTypeBuy, "110", "0.2")))
+	assert.NoError(t, uc.CreateOrder(context.Background(), newOrder(traderID, "BTC_BRL", entity.OrderTypeBuy, "50", "1")))
+
+	// ETH_BRL: two resting asks and no trades.
+	assert.NoError(t, uc.CreateOrder(context.Background(), newOrder(traderID, "ETH_BRL", entity.OrderTypeSell, "20", "1")))
+	assert.NoError(t, uc.CreateOrder(context.Background(), newOrder(traderID, "ETH_BRL", entity.OrderTypeSell, "21", "1")))
+
+	markets, err := uc.GetAccountMarkets(traderID)
+	assert.NoError(t, err)
+	if assert.Len(t, markets, 2) {
+		assert.Equal(t, "BTC_BRL", markets[0].InstrumentPair)
+		assert.Equal(t, int64(1), markets[0].OpenOrders)
+		if assert.NotNil(t, markets[0].LastTrade) {
+			assert.Equal(t, "110", markets[0].LastTrade.Price.String())
+			assert.Equal(t, "0.2", markets[0].LastTrade.Quantity.String())
+		}
+
+		assert.Equal(t, "ETH_BRL", markets[1].InstrumentPair)
+		assert.Equal(t, int64(2), markets[1].OpenOrders)
+		assert.Nil(t, markets[1].LastTrade)
+	}
+
+	// The counterparty traded BTC_BRL but has nothing left open.
+	markets, err = uc.GetAccountMarkets(counterpartyID)
+	assert.NoError(t, err)
+	if assert.Len(t, markets, 1) {
+		assert.Equal(t, "BTC_BRL", markets[0].InstrumentPair)
+		assert.Equal(t, int64(0), markets[0].OpenOrders)
+		assert.NotNil(t, markets[0].LastTrade)
+	}
+}
+
+func TestOrderUseCase_CreateOrder_TimestampsInUTC(t *testing.T) {
+	// Run as if the server were in a zone far from UTC.
+	local := time.Local
+	time.Local = time.FixedZone("UTC-3", -3*60*60)
+	t.Cleanup(func() { time.Local = local })
+
+	log := zap.NewNop().Sugar()
+	db := newMigratedDB(t)
+	orderRepo := repository.NewOrderRepository(log, db)
+	walletRepo := repository.NewWalletRepository(log, db, nil)
+	uc := NewOrderUseCase(log, orderRepo, walletRepo, repository.NewTradeRepository(log, db), repository.NewEventRepository(log, db), nil, db, OrderUseCaseConfig{})
+
+	accountID := uuid.New()
+	if err := walletRepo.Create(nil, &entity.Wallet{AccountID: accountID, AssetSymbol: "BRL", Balance: decimal.RequireFromString("100")}); err != nil {
+		t.Fatalf("failed to seed wallet: %v", err)
+	}
+
+	order := &entity.Order{
+		AccountID:      accountID,
+		InstrumentPair: "BTC_BRL",
+		OrderType:      string(entity.OrderTypeBuy),
+		Price:          decimal.RequireFromString("100"),
+		Quantity:       decimal.RequireFromString("0.5"),
+	}
+	assert.NoError(t, uc.CreateOrder(context.Background(), order))
+	assert.Equal(t, time.UTC, order.CreatedAt.Location())
+
+	stored, err := orderRepo.GetByID(order.ID)
+	if assert.NoError(t, err) {
+		assert.Equal(t, time.UTC, stored.CreatedAt.Location())
+		assert.Equal(t, time.UTC, stored.UpdatedAt.Location())
+		assert.True(t, stored.CreatedAt.Equal(order.CreatedAt))
+	}
+}
+
+func TestOrderUseCase_CreateOrder_MaxOpenNotional(t *testing.T) {
+	log := zap.NewNop().Sugar()
+	db := newMigratedDB(t)
+	orderRepo := repository.NewOrderRepository(log, db)
+	walletRepo := repository.NewWalletRepository(log, db, nil)
+
+	cappedID, overriddenID := uuid.New(), uuid.New()
+	limits := entity.NewNotionalLimits(decimal.RequireFromString("100"), map[uuid.UUID]decimal.Decimal{
+		overriddenID: decimal.RequireFromString("200"),
+	})
+	uc := NewOrderUseCase(log, orderRepo, walletRepo, repository.NewTradeRepository(log, db), repository.NewEventRepository(log, db), nil, db, OrderUseCaseConfig{NotionalLimits: limits})
+
+	for _, accountID := range []uuid.UUID{cappedID, overriddenID} {
+		if err := walletRepo.Create(nil, &entity.Wallet{AccountID: accountID, AssetSymbol: "BRL", Balance: decimal.RequireFromString("1000")}); err != nil {
+			t.Fatalf("failed to seed wallet: %v", err)
+		}
+	}
+
+	newBuy := func(accountID uuid.UUID, quantity string) *entity.Order {
+		return &entity.Order{
+			AccountID:      accountID,
+			InstrumentPair: "BTC_BRL",
+			OrderType:      string(entity.OrderTypeBuy),
+			Price:          decimal.RequireFromString("100"),
+			Quantity:       decimal.RequireFromString(quantity),
+		}
+	}
+
+	// 50 committed, then 60 more would take the account to 110 of 100.
+	assert.NoError(t, uc.CreateOrder(context.Background(), newBuy(cappedID, "0.5")))
+	assert.ErrorIs(t, uc.CreateOrder(context.Background(), newBuy(cappedID, "0.6")), entity.ErrMaxNotionalExceeded)
+	assert.NoError(t, uc.CreateOrder(context.Background(), newBuy(cappedID, "0.5")))
+
+	// The override replaces the default for its account.
+	assert.NoError(t, uc.CreateOrder(context.Background(), newBuy(overriddenID, "0.5")))
+	assert.NoError(t, uc.CreateOrder(context.Background(), newBuy(overriddenID, "0.6")))
+
+	var open int64
+	assert.NoError(t, db.Model(&entity.Order{}).Where("account_id = ?", cappedID).Count(&open).Error)
+	assert.Equal(t, int64(2), open)
+}
+
+func TestOrderUseCase_GetQueuePosition(t *testing.T) {
+	log := zap.NewNop().Sugar()
+	db := newMigratedDB(t)
+	orderRepo := repository.NewOrderRepository(log, db)
+	walletRepo := repository.NewWalletRepository(log, db, nil)
+	uc := NewOrderUseCase(log, orderRepo, walletRepo, repository.NewTradeRepository(log, db), repository.NewEventRepository(log, db), nil, db, OrderUseCaseConfig{})
+
+	accounts := []uuid.UUID{uuid.New(), uuid.New(), uuid.New()}
+	for _, accountID := range accounts {
+		if err := walletRepo.Create(nil, &entity.Wallet{AccountID: accountID, AssetSymbol: "BRL", Balance: decimal.RequireFromString("1000")}); err != nil {
+			t.Fatalf("failed to seed wallet: %v", err)
+		}
+	}
+
+	newBuy := func(accountID uuid.UUID, price, quantity string) *entity.Order {
+		return &entity.Order{
+			AccountID:      accountID,
+			InstrumentPair: "BTC_BRL",
+			OrderType:      string(entity.OrderTypeBuy),
+			Price:          decimal.RequireFromString(price),
+			Quantity:       decimal.RequireFromString(quantity),
+		}
+	}
+
+	// Three bids at 100 from different accounts, plus a better bid at 101
+	// that sits on another level and must not count.
+	first := newBuy(accounts[0], "100", "0.5")
+	second := newBuy(accounts[1], "100", "0.25")
+	third := newBuy(accounts[2], "100", "1")
+	better := newBuy(accounts[0], "101", "2")
+	for _, order := range []*entity.Order{first, second, better, third} {
+		assert.NoError(t, uc.CreateOrder(context.Background(), order))
+	}
+
+	tests := []struct {
+		order        *entity.Order
+		wantPosition int64
+		wantQuantity string
+	}{
+		{order: first, wantPosition: 1, wantQuantity: "0"},
+		{order: second, wantPosition: 2, wantQuantity: "0.5"},
+		{order: third, wantPosition: 3, wantQuantity: "0.75"},
+		{order: better, wantPosition: 1, wantQuantity: "0"},
+	}
+	for _, tt := range tests {
+		got, err := uc.GetQueuePosition(tt.order.ID)
+		if assert.NoError(t, err) {
+			assert.Equal(t, tt.order.ID, got.Order.ID)
+			assert.Equal(t, tt.wantPosition, got.Position)
+			assert.Equal(t, tt.wantPosition-1, got.OrdersAhead)
+			assert.True(t, entity.DecimalEqual(decimal.RequireFromString(tt.wantQuantity), got.QuantityAhead), got.QuantityAhead.String())
+		}
+	}
+
+	// Once the front order leaves the book, the others move up.
+	assert.NoError(t, uc.CancelOrder(context.Background(), first.ID, uuid.Nil))
+	got, err := uc.GetQueuePosition(third.ID)
+	if assert.NoError(t, err) {
+		assert.Equal(t, int64(2), got.Position)
+		assert.True(t, entity.DecimalEqual(decimal.RequireFromString("0.25"), got.QuantityAhead), got.QuantityAhead.String())
+	}
+
+	_, err = uc.GetQueuePosition(first.ID)
+	assert.ErrorIs(t, err, entity.ErrOrderNotOpen)
+}
+
+func TestOrderUseCase_GetMatchCandidates(t *testing.T) {
+	log := zap.NewNop().Sugar()
+	db := newMigratedDB(t)
+	orderRepo := repository.NewOrderRepository(log, db)
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	uc := NewOrderUseCase(log, orderRepo, nil, nil, nil, nil, db, OrderUseCaseConfig{Clock: newFakeClock(now)})
+
+	buyerID, sellerID := uuid.New(), uuid.New()
+	seed := func(accountID uuid.UUID, orderType entity.OrderType, status entity.OrderStatus, price, quantity string, age time.Duration) *entity.Order {
+		order := &entity.Order{
+			Base:              entity.Base{CreatedAt: now.Add(-age)},
+			AccountID:         accountID,
+			InstrumentPair:    "BTC_BRL",
+			OrderType:         string(orderType),
+			Status:            string(status),
+			Price:             decimal.RequireFromString(price),
+			Quantity:          decimal.RequireFromString(quantity),
+			RemainingQuantity: decimal.RequireFromString(quantity),
+		}
+		if err := db.Create(order).Error; err != nil {
+			t.Fatalf("failed to seed order: %v", err)
+		}
+		return order
+	}
+
+	worse := seed(sellerID, entity.OrderTypeSell, entity.OrderStatusOpen, "101", "1", 3*time.Minute)
+	later := seed(sellerID, entity.OrderTypeSell, entity.OrderStatusPartial, "100", "0.5", time.Minute)
+	earlier := seed(sellerID, entity.OrderTypeSell, entity.OrderStatusOpen, "100", "0.7", 2*time.Minute)
+	seed(sellerID, entity.OrderTypeSell, entity.OrderStatusOpen, "105", "1", 5*time.Minute)
+	seed(sellerID, entity.OrderTypeSell, entity.OrderStatusCancelled, "99", "1", 5*time.Minute)
+	seed(buyerID, entity.OrderTypeSell, entity.OrderStatusOpen, "99", "1", 5*time.Minute)
+	buy := seed(buyerID, entity.OrderTypeBuy, entity.OrderStatusCancelled, "102", "3", 0)
+
+	tests := []struct {
+		name    string
+		limit   int
+		wantIDs []uuid.UUID
+	}{
+		{name: "price then time priority", wantIDs: []uuid.UUID{earlier.ID, later.ID, worse.ID}},
+		{name: "limited", limit: 2, wantIDs: []uuid.UUID{earlier.ID, later.ID}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := uc.GetMatchCandidates(buy.ID, tt.limit)
+			if !assert.NoError(t, err) {
+				return
+			}
+			assert.Equal(t, buy.ID, got.Order.ID)
+
+			ids := make([]uuid.UUID, len(got.Candidates))
+			for i, candidate := range got.Candidates {
+				ids[i] = candidate.Order.ID
+			}
+			assert.Equal(t, tt.wantIDs, ids)
+			assert.Equal(t, 2*time.Minute, got.Candidates[0].Age)
+		})
+	}
+
+	t.Run("unknown order", func(t *testing.T) {
+		_, err := uc.GetMatchCandidates(uuid.New(), 0)
+		assert.ErrorIs(t, err, repository.ErrNotFound)
+	})
+
+	t.Run("nothing was changed", func(t *testing.T) {
+		stored, err := orderRepo.GetByID(earlier.ID)
+		if assert.NoError(t, err) {
+			assert.Equal(t, string(entity.OrderStatusOpen), stored.Status)
+			assertDecimalEqual(t, "0.7", stored.RemainingQuantity.String())
+		}
+	})
+}
+
+func TestOrderUseCase_CreateOrder_UnsupportedAsset(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	// No repository expectations: the order must be rejected before any lookup.
+	orderRepo := repository.NewMockOrderRepository(ctrl)
+	walletRepo := repository.NewMockWalletRepository(ctrl)
+	instruments := entity.NewInstrumentConfig(entity.Asset{Symbol: "BTC", Scale: 8}, entity.Asset{Symbol: "BRL", Scale: 2})
+	uc := NewOrderUseCase(zap.NewNop().Sugar(), orderRepo, walletRepo, nil, nil, nil, newInMemoryDB(t), OrderUseCaseConfig{Instruments: instruments})
+
+	err := uc.CreateOrder(context.Background(), &entity.Order{
+		AccountID:      uuid.New(),
+		InstrumentPair: "DOGE_BRL",
+		OrderType:      string(entity.OrderTypeBuy),
+		Price:          decimal.RequireFromString("1"),
+		Quantity:       decimal.RequireFromString("10"),
+	})
+
+	assert.ErrorIs(t, err, entity.ErrUnsupportedAsset)
+}
+
+func TestOrderUseCase_ReplaceOrder(t *testing.T) {
+	tests := []struct {
+		name          string
+		replacerOwned bool
+		newQuantity   string
+		wantErr       error
+		wantOldStatus entity.OrderStatus
+		wantOrders    int64
+		wantEvents    int64
+	}{
+		{name: "success", replacerOwned: true, newQuantity: "1", wantOldStatus: entity.OrderStatusCancelled, wantOrders: 2, wantEvents: 2},
+		{name: "new order fails leaves old open", replacerOwned: true, newQuantity: "100", wantOldStatus: entity.OrderStatusOpen, wantOrders: 1},
+		{name: "order of another account", replacerOwned: false, newQuantity: "1", wantErr: entity.ErrOrderNotOwned, wantOldStatus: entity.OrderStatusOpen, wantOrders: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			log := zap.NewNop().Sugar()
+			db := newMigratedDB(t)
+			orderRepo := repository.NewOrderRepository(log, db)
+			walletRepo := repository.NewWalletRepository(log, db, nil)
+			uc := NewOrderUseCase(log, orderRepo, walletRepo, repository.NewTradeRepository(log, db), repository.NewEventRepository(log, db), nil, db, OrderUseCaseConfig{})
+
+			buyerID := uuid.New()
+			for _, w := range []*entity.Wallet{
+				{AccountID: buyerID, AssetSymbol: "BTC", Balance: decimal.Zero},
+				{AccountID: buyerID, AssetSymbol: "BRL", Balance: decimal.RequireFromString("1000")},
+			} {
+				if err := walletRepo.Create(nil, w); err != nil {
+					t.Fatalf("failed to seed wallet: %v", err)
+				}
+			}
+
+			old := &entity.Order{
+				AccountID:         buyerID,
+				InstrumentPair:    "BTC_BRL",
+				OrderType:         string(entity.OrderTypeBuy),
+				Price:             decimal.RequireFromString("90"),
+				Quantity:          decimal.RequireFromString("1"),
+				RemainingQuantity: decimal.RequireFromString("1"),
+				Status:            string(entity.OrderStatusOpen),
+			}
+			if err := orderRepo.Create(nil, old); err != nil {
+				t.Fatalf("failed to seed order: %v", err)
+			}
+
+			replacer := buyerID
+			if !tt.replacerOwned {
+				replacer = uuid.New()
+			}
+			newOrder := &entity.Order{
+				AccountID:      replacer,
+				InstrumentPair: "BTC_BRL",
+				OrderType:      string(entity.OrderTypeBuy),
+				Price:          decimal.RequireFromString("95"),
+				Quantity:       decimal.RequireFromString(tt.newQuantity),
+			}
+
+			cancelled, err := uc.ReplaceOrder(context.Background(), old.ID, newOrder)
+			switch {
+			case tt.wantErr != nil:
+				assert.ErrorIs(t, err, tt.wantErr)
+			case tt.wantOldStatus == entity.OrderStatusOpen:
+				assert.Error(t, err)
+			default:
+				if assert.NoError(t, err) {
+					assert.Equal(t, old.ID, cancelled.ID)
+					assert.Equal(t, string(entity.OrderStatusOpen), newOrder.Status)
+				}
+			}
+
+			got, err := orderRepo.GetByID(old.ID)
+			if assert.NoError(t, err) {
+				assert.Equal(t, string(tt.wantOldStatus), got.Status)
+			}
+
+			var orders, events int64
+			assert.NoError(t, db.Model(&entity.Order{}).Count(&orders).Error)
+			assert.NoError(t, db.Model(&entity.Event{}).Count(&events).Error)
+			assert.Equal(t, tt.wantOrders, orders)
+			assert.Equal(t, tt.wantEvents, events)
+		})
+	}
+}
+
+func TestOrderUseCase_ReplaceOrder_NotOpen(t *testing.T) {
+	log := zap.NewNop().Sugar()
+	db := newMigratedDB(t)
+	orderRepo := repository.NewOrderRepository(log, db)
+	walletRepo := repository.NewWalletRepository(log, db, nil)
+	uc := NewOrderUseCase(log, orderRepo, walletRepo, repository.NewTradeRepository(log, db), repository.NewEventRepository(log, db), nil, db, OrderUseCaseConfig{})
+
+	_, err := uc.ReplaceOrder(context.Background(), uuid.New(), &entity.Order{AccountID: uuid.New()})
+	assert.ErrorIs(t, err, repository.ErrNotFound)
+
+	old := &entity.Order{
+		AccountID:         uuid.New(),
+		InstrumentPair:    "BTC_BRL",
+		OrderType:         string(entity.OrderTypeBuy),
+		Price:             decimal.RequireFromString("90"),
+		Quantity:          decimal.RequireFromString("1"),
+		RemainingQuantity: decimal.Zero,
+		Status:            string(entity.OrderStatusFilled),
+	}
+	if err := orderRepo.Create(nil, old); err != nil {
+		t.Fatalf("failed to seed order: %v", err)
+	}
+
+	_, err = uc.ReplaceOrder(context.Background(), old.ID, &entity.Order{AccountID: old.AccountID})
+	assert.ErrorIs(t, err, entity.ErrOrderNotOpen)
+}
+
+// TestOrderUseCase_ReplaceOrder_LocksOldPair checks that moving an order to
+// another pair waits for the old pair's lock, since the old order leaves
+// that book.
+func TestOrderUseCase_ReplaceOrder_LocksOldPair(t *testing.T) {
+	uc, _, buyerID, _ := newExpiryTestUseCase(t, newFakeClock(time.Now()), ExpiryPolicy{})
+
+	newOrder := func(pair string) *entity.Order {
+		return &entity.Order{
+			AccountID:      buyerID,
+			InstrumentPair: pair,
+			OrderType:      string(entity.OrderTypeBuy),
+			Price:          decimal.RequireFromString("10"),
+			Quantity:       decimal.RequireFromString("1"),
+		}
+	}
+	resting := newOrder("BTC_BRL")
+	assert.NoError(t, uc.CreateOrder(context.Background(), resting))
+
+	oldLock := uc.pairs.get("BTC_BRL")
+	oldLock.Lock()
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := uc.ReplaceOrder(context.Background(), resting.ID, newOrder("ETH_BRL"))
+		done <- err
+	}()
+
+	select {
+	case <-done:
+		t.Fatal("replace finished while the old pair was locked")
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	oldLock.Unlock()
+	assert.NoError(t, <-done)
+}
+
+func TestPairLocks_LockOrdersPairs(t *testing.T) {
+	locks := newPairLocks()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 100; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			locks.lock("BTC_BRL", "ETH_BRL")()
+		}()
+		go func() {
+			defer wg.Done()
+			locks.lock("ETH_BRL", "BTC_BRL", "ETH_BRL")()
+		}()
+	}
+	wg.Wait()
+}
+
+func TestOrderUseCase_CreateOrder_SelfCross(t *testing.T) {
+	tests := []struct {
+		name            string
+		rejectSelfCross bool
+		buyPrice        string
+		wantErr         error
+	}{
+		{name: "buy crossing own sell is rejected", rejectSelfCross: true, buyPrice: "101", wantErr: entity.ErrSelfCross},
+		{name: "buy at own sell price is rejected", rejectSelfCross: true, buyPrice: "100", wantErr: entity.ErrSelfCross},
+		{name: "buy below own sell rests", rejectSelfCross: true, buyPrice: "99"},
+		{name: "check disabled lets buy rest", rejectSelfCross: false, buyPrice: "101"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			log := zap.NewNop().Sugar()
+			db := newMigratedDB(t)
+			orderRepo := repository.NewOrderRepository(log, db)
+			walletRepo := repository.NewWalletRepository(log, db, nil)
+			uc := NewOrderUseCase(log, orderRepo, walletRepo, repository.NewTradeRepository(log, db), repository.NewEventRepository(log, db), nil, db, OrderUseCaseConfig{RejectSelfCross: tt.rejectSelfCross})
+
+			accountID := uuid.New()
+			for _, w := range []*entity.Wallet{
+				{AccountID: accountID, AssetSymbol: "BTC", Balance: decimal.RequireFromString("1")},
+				{AccountID: accountID, AssetSymbol: "BRL", Balance: decimal.RequireFromString("1000")},
+			} {
+				if err := walletRepo.Create(nil, w); err != nil {
+					t.Fatalf("failed to seed wallet: %v", err)
+				}
+			}
+
+			sell := &entity.Order{
+				AccountID:         accountID,
+				InstrumentPair:    "BTC_BRL",
+				OrderType:         string(entity.OrderTypeSell),
+				Price:             decimal.RequireFromString("100"),
+				Quantity:          decimal.RequireFromString("1"),
+				RemainingQuantity: decimal.RequireFromString("1"),
+				Status:            string(entity.OrderStatusOpen),
+			}
+			if err := orderRepo.Create(nil, sell); err != nil {
+				t.Fatalf("failed to seed order: %v", err)
+			}
+
+			buy := &entity.Order{
+				AccountID:      accountID,
+				InstrumentPair: "BTC_BRL",
+				OrderType:      string(entity.OrderTypeBuy),
+				Price:          decimal.RequireFromString(tt.buyPrice),
+				Quantity:       decimal.RequireFromString("1"),
+			}
+			err := uc.CreateOrder(context.Background(), buy)
+
+			var orders int64
+			assert.NoError(t, db.Model(&entity.Order{}).Count(&orders).Error)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				assert.Equal(t, int64(1), orders)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, string(entity.OrderStatusOpen), buy.Status)
+			assert.Equal(t, int64(2), orders)
+		})
+	}
+}
+
+func TestOrderUseCase_GetOrderSummary(t *testing.T) {
+	log := zap.NewNop().Sugar()
+	db := newMigratedDB(t)
+	orderRepo := repository.NewOrderRepository(log, db)
+	uc := NewOrderUseCase(log, orderRepo, nil, nil, nil, nil, db, OrderUseCaseConfig{})
+
+	day := time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)
+	for _, seed := range []struct {
+		pair      string
+		status    entity.OrderStatus
+		createdAt time.Time
+	}{
+		{"BTC_BRL", entity.OrderStatusOpen, day.Add(time.Hour)},
+		{"BTC_BRL", entity.OrderStatusOpen, day.Add(2 * time.Hour)},
+		{"BTC_BRL", entity.OrderStatusPartial, day.Add(3 * time.Hour)},
+		{"BTC_BRL", entity.OrderStatusFilled, day.Add(-time.Hour)},
+		{"BTC_BRL", entity.OrderStatusFilled, day.Add(4 * time.Hour)},
+		{"ETH_BRL", entity.OrderStatusCancelled, day.Add(time.Hour)},
+	} {
+		order := &entity.Order{
+			Base:              entity.Base{CreatedAt: seed.createdAt},
+			AccountID:         uuid.New(),
+			InstrumentPair:    seed.pair,
+			OrderType:         string(entity.OrderTypeBuy),
+			Price:             decimal.RequireFromString("100"),
+			Quantity:          decimal.RequireFromString("1"),
+			RemainingQuantity: decimal.RequireFromString("1"),
+			Status:            string(seed.status),
+		}
+		if err := orderRepo.Create(nil, order); err != nil {
+			t.Fatalf("failed to seed order: %v", err)
+		}
+	}
+
+	tests := []struct {
+		name    string
+		pair    string
+		from    time.Time
+		to      time.Time
+		want    *OrderSummary
+		wantErr error
+	}{
+		{
+			name: "all time",
+			pair: "BTC_BRL",
+			want: &OrderSummary{InstrumentPair: "BTC_BRL", Open: 2, PartiallyFilled: 1, Filled: 2},
+		},
+		{
+			name: "within window",
+			pair: "BTC_BRL",
+			from: day,
+			to:   day.Add(3 * time.Hour),
+			want: &OrderSummary{InstrumentPair: "BTC_BRL", Open: 2},
+		},
+		{
+			name: "only from",
+			pair: "BTC_BRL",
+			from: day.Add(3 * time.Hour),
+			want: &OrderSummary{InstrumentPair: "BTC_BRL", PartiallyFilled: 1, Filled: 1},
+		},
+		{
+			name: "pair without orders returns zeros",
+			pair: "SOL_BRL",
+			want: &OrderSummary{InstrumentPair: "SOL_BRL"},
+		},
+		{
+			name:    "invalid pair",
+			pair:    "BTCBRL",
+			wantErr: entity.ErrInvalidPairFormat,
+		},
+		{
+			name:    "empty window",
+			pair:    "BTC_BRL",
+			from:    day,
+			to:      day,
+			wantErr: entity.ErrInvalidTimeRange,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := uc.GetOrderSummary(tt.pair, tt.from, tt.to)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestOrderUseCase_CreateOrder_RecordsRejection(t *testing.T) {
+	log := zap.NewNop().Sugar()
+	db := newMigratedDB(t)
+	orderRepo := repository.NewOrderRepository(log, db)
+	walletRepo := repository.NewWalletRepository(log, db, nil)
+	rejectionRepo := repository.NewOrderRejectionRepository(log, db)
+	uc := NewOrderUseCase(log, orderRepo, walletRepo, repository.NewTradeRepository(log, db), repository.NewEventRepository(log, db), rejectionRepo, db, OrderUseCaseConfig{})
+
+	accountID := uuid.New()
+	for _, w := range []*entity.Wallet{
+		{AccountID: accountID, AssetSymbol: "BTC", Balance: decimal.Zero},
+		{AccountID: accountID, AssetSymbol: "BRL", Balance: decimal.RequireFromString("100")},
+	} {
+		if err := walletRepo.Create(nil, w); err != nil {
+			t.Fatalf("failed to seed wallet: %v", err)
+		}
+	}
+
+	accepted := &entity.Order{
+		AccountID:      accountID,
+		InstrumentPair: "BTC_BRL",
+		OrderType:      string(entity.OrderTypeBuy),
+		Price:          decimal.RequireFromString("50"),
+		Quantity:       decimal.RequireFromString("1"),
+	}
+	assert.NoError(t, uc.CreateOrder(context.Background(), accepted))
+
+	rejected := &entity.Order{
+		AccountID:      accountID,
+		InstrumentPair: "BTC_BRL",
+		OrderType:      string(entity.OrderTypeBuy),
+		Price:          decimal.RequireFromString("200"),
+		Quantity:       decimal.RequireFromString("1"),
+	}
+	assert.ErrorIs(t, uc.CreateOrder(context.Background(), rejected), entity.ErrInsufficientBalance)
+
+	rejections, err := uc.GetRejections(accountID, 0)
+	if assert.NoError(t, err) && assert.Len(t, rejections, 1) {
+		got := rejections[0]
+		assert.Equal(t, string(entity.RejectionInsufficientBalance), got.Reason)
+		assert.Equal(t, entity.ErrInsufficientBalance.Error(), got.Message)
+		assert.Equal(t, "BTC_BRL", got.InstrumentPair)
+		assert.Equal(t, string(entity.OrderTypeBuy), got.OrderType)
+		assert.True(t, got.Price.Equal(decimal.RequireFromString("200")))
+		assert.True(t, got.Quantity.Equal(decimal.RequireFromString("1")))
+		assert.False(t, got.CreatedAt.IsZero())
+	}
+
+	var orders int64
+	assert.NoError(t, db.Model(&entity.Order{}).Count(&orders).Error)
+	assert.Equal(t, int64(1), orders, "the rejected order itself is not stored")
+}
+
+func TestOrderUseCase_ReplaceOrder_ValidatesLikeCreate(t *testing.T) {
+	instruments := entity.NewInstrumentConfig(
+		entity.Asset{Symbol: "BTC", Scale: 8},
+		entity.Asset{Symbol: "BRL", Scale: 2},
+	)
+
+	tests := []struct {
+		name    string
+		pair    string
+		price   string
+		qty     string
+		wantErr error
+	}{
+		{name: "non-positive price", pair: "BTC_BRL", price: "0", qty: "1", wantErr: entity.ErrInvalidPrice},
+		{name: "price above maximum", pair: "BTC_BRL", price: "100000001", qty: "0.000001", wantErr: entity.ErrMaxPrice},
+		{name: "quantity above maximum", pair: "BTC_BRL", price: "1", qty: "1001", wantErr: entity.ErrMaxQuantity},
+		{name: "unsupported asset", pair: "DOGE_BRL", price: "1", qty: "1", wantErr: entity.ErrUnsupportedAsset},
+		{name: "insufficient balance", pair: "BTC_BRL", price: "1000", qty: "1", wantErr: entity.ErrInsufficientBalance},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			log := zap.NewNop().Sugar()
+			db := newMigratedDB(t)
+			orderRepo := repository.NewOrderRepository(log, db)
+			walletRepo := repository.NewWalletRepository(log, db, nil)
+			uc := NewOrderUseCase(log, orderRepo, walletRepo, repository.NewTradeRepository(log, db), repository.NewEventRepository(log, db), nil, db, OrderUseCaseConfig{Instruments: instruments})
+
+			accountID := uuid.New()
+			if err := walletRepo.Create(nil, &entity.Wallet{AccountID: accountID, AssetSymbol: "BRL", Balance: decimal.RequireFromString("500")}); err != nil {
+				t.Fatalf("failed to seed wallet: %v", err)
+			}
+
+			old := &entity.Order{
+				AccountID:         accountID,
+				InstrumentPair:    "BTC_BRL",
+				OrderType:         string(entity.OrderTypeBuy),
+				Price:             decimal.RequireFromString("90"),
+				Quantity:          decimal.RequireFromString("1"),
+				RemainingQuantity: decimal.RequireFromString("1"),
+				Status:            string(entity.OrderStatusOpen),
+			}
+			if err := orderRepo.Create(nil, old); err != nil {
+				t.Fatalf("failed to seed order: %v", err)
+			}
+
+			spec := func() *entity.Order {
+				return &entity.Order{
+					AccountID:      accountID,
+					InstrumentPair: tt.pair,
+					OrderType:      string(entity.OrderTypeBuy),
+					Price:          decimal.RequireFromString(tt.price),
+					Quantity:       decimal.RequireFromString(tt.qty),
+				}
+			}
+
+			createErr := uc.CreateOrder(context.Background(), spec())
+			_, replaceErr := uc.ReplaceOrder(context.Background(), old.ID, spec())
+
+			assert.ErrorIs(t, createErr, tt.wantErr)
+			assert.ErrorIs(t, replaceErr, tt.wantErr)
+			if createErr != nil && replaceErr != nil {
+				assert.Equal(t, createErr.Error(), replaceErr.Error())
+			}
+
+			got, err := orderRepo.GetByID(old.ID)
+			if assert.NoError(t, err) {
+				assert.Equal(t, string(entity.OrderStatusOpen), got.Status, "a rejected replace leaves the old order resting")
+			}
+		})
+	}
+}
+
+func TestOrderUseCase_MarketHalted(t *testing.T) {
+	log := zap.NewNop().Sugar()
+	db := newMigratedDB(t)
+	orderRepo := repository.NewOrderRepository(log, db)
+	walletRepo := repository.NewWalletRepository(log, db, nil)
+	halts := NewMarketHaltUseCase(log)
+	uc := NewOrderUseCase(log, orderRepo, walletRepo, repository.NewTradeRepository(log, db), repository.NewEventRepository(log, db), nil, db, OrderUseCaseConfig{Halts: halts})
+
+	accountID := uuid.New()
+	for _, w := range []*entity.Wallet{
+		{AccountID: accountID, AssetSymbol: "BTC", Balance: decimal.Zero},
+		{AccountID: accountID, AssetSymbol: "ETH", Balance: decimal.Zero},
+		{AccountID: accountID, AssetSymbol: "BRL", Balance: decimal.RequireFromString("1000")},
+	} {
+		if err := walletRepo.Create(nil, w); err != nil {
+			t.Fatalf("failed to seed wallet: %v", err)
+		}
+	}
+
+	newOrder := func(pair string) *entity.Order {
+		return &entity.Order{
+			AccountID:      accountID,
+			InstrumentPair: pair,
+			OrderType:      string(entity.OrderTypeBuy),
+			Price:          decimal.RequireFromString("10"),
+			Quantity:       decimal.RequireFromString("1"),
+		}
+	}
+
+	resting := newOrder("BTC_BRL")
+	assert.NoError(t, uc.CreateOrder(context.Background(), resting))
+
+	assert.NoError(t, halts.Halt("BTC_BRL"))
+
+	assert.ErrorIs(t, uc.CreateOrder(context.Background(), newOrder("BTC_BRL")), entity.ErrMarketHalted)
+	assert.NoError(t, uc.CreateOrder(context.Background(), newOrder("ETH_BRL")), "other pairs keep trading")
+
+	_, err := uc.ReplaceOrder(context.Background(), resting.ID, newOrder("BTC_BRL"))
+	assert.ErrorIs(t, err, entity.ErrMarketHalted)
+	stillOpen, err := orderRepo.GetByID(resting.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, string(entity.OrderStatusOpen), stillOpen.Status, "a failed replace keeps the old order")
+
+	var stored int64
+	assert.NoError(t, db.Model(&entity.Order{}).Where("instrument_pair = ?", "BTC_BRL").Count(&stored).Error)
+	assert.Equal(t, int64(1), stored)
+
+	assert.NoError(t, uc.CancelOrder(context.Background(), resting.ID, uuid.Nil), "cancels are allowed on a halted pair")
+
+	assert.NoError(t, halts.Resume("BTC_BRL"))
+	assert.NoError(t, uc.CreateOrder(context.Background(), newOrder("BTC_BRL")))
+}
+
+func TestOrderUseCase_CreateOrder_NormalizesSymbols(t *testing.T) {
+	log := zap.NewNop().Sugar()
+	db := newMigratedDB(t)
+	orderRepo := repository.NewOrderRepository(log, db)
+	walletRepo := repository.NewWalletRepository(log, db, nil)
+	uc := NewOrderUseCase(log, orderRepo, walletRepo, repository.NewTradeRepository(log, db), repository.NewEventRepository(log, db), nil, db, OrderUseCaseConfig{})
+
+	// Wallets created with sloppy symbols are stored canonically, so a
+	// lowercase pair still finds them and trades on the BTC_BRL book.
+	buyerID, sellerID := uuid.New(), uuid.New()
+	for _, w := range []*entity.Wallet{
+		{AccountID: buyerID, AssetSymbol: "btc", Balance: decimal.Zero},
+		{AccountID: buyerID, AssetSymbol: " BRL ", Balance: decimal.RequireFromString("1000")},
+		{AccountID: sellerID, AssetSymbol: "Btc", Balance: decimal.RequireFromString("1")},
+		{AccountID: sellerID, AssetSymbol: "brl", Balance: decimal.Zero},
+	} {
+		if err := walletRepo.Create(nil, w); err != nil {
+			t.Fatalf("failed to seed wallet: %v", err)
+		}
+	}
+	assert.Error(t, walletRepo.Create(nil, &entity.Wallet{AccountID: buyerID, AssetSymbol: "B$"}))
+
+	maker := &entity.Order{
+		AccountID:      sellerID,
+		InstrumentPair: "BTC_BRL",
+		OrderType:      string(entity.OrderTypeSell),
+		Price:          decimal.RequireFromString("100"),
+		Quantity:       decimal.RequireFromString("1"),
+	}
+	assert.NoError(t, uc.CreateOrder(context.Background(), maker))
+
+	taker := &entity.Order{
+		AccountID:      buyerID,
+		InstrumentPair: " btc_brl",
+		OrderType:      string(entity.OrderTypeBuy),
+		Price:          decimal.RequireFromString("100"),
+		Quantity:       decimal.RequireFromString("1"),
+	}
+	assert.NoError(t, uc.CreateOrder(context.Background(), taker))
+	assert.Equal(t, "BTC_BRL", taker.InstrumentPair)
+	assert.Equal(t, string(entity.OrderStatusFilled), taker.Status)
+
+	wallet, err := walletRepo.GetByAccountAndAsset(db, buyerID, "BTC")
+	if assert.NoError(t, err) {
+		assert.True(t, entity.DecimalEqual(decimal.RequireFromString("1"), wallet.Balance.Round(8)), wallet.Balance.String())
+	}
+
+	invalid := &entity.Order{
+		AccountID:      buyerID,
+		InstrumentPair: "BTC_B R L",
+		OrderType:      string(entity.OrderTypeBuy),
+		Price:          decimal.RequireFromString("100"),
+		Quantity:       decimal.RequireFromString("1"),
+	}
+	assert.ErrorIs(t, uc.CreateOrder(context.Background(), invalid), entity.ErrInvalidPairFormat)
+}
+
+func TestOrderUseCase_Reservation_ReleasedOnCancel(t *testing.T) {
+	start := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	uc, _, buyerID, _ := newExpiryTestUseCase(t, newFakeClock(start), ExpiryPolicy{})
+
+	buy := func(quantity string) *entity.Order {
+		return &entity.Order{
+			AccountID:      buyerID,
+			InstrumentPair: "BTC_BRL",
+			OrderType:      string(entity.OrderTypeBuy),
+			Price:          decimal.RequireFromString("100"),
+			Quantity:       decimal.RequireFromString(quantity),
+		}
+	}
+
+	// A resting buy holds its quote amount, so a second buy the free
+	// balance cannot cover is refused until the first is cancelled.
+	resting := buy("9")
+	assert.NoError(t, uc.CreateOrder(context.Background(), resting))
+	assert.Equal(t, "BRL", resting.ReservedAsset)
+	assert.Equal(t, "900", reservedAmount(t, uc, buyerID, "BRL").String())
+
+	assert.ErrorIs(t, uc.CreateOrder(context.Background(), buy("2")), entity.ErrInsufficientBalance)
+
+	assert.NoError(t, uc.CancelOrder(context.Background(), resting.ID, uuid.Nil))
+	assert.True(t, reservedAmount(t, uc, buyerID, "BRL").IsZero())
+	assert.NoError(t, uc.CreateOrder(context.Background(), buy("2")))
+}
+
+func TestOrderUseCase_Reservation_PartialFillReleasesResidual(t *testing.T) {
+	start := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	clock := newFakeClock(start)
+	uc, db, buyerID, sellerID := newExpiryTestUseCase(t, clock, ExpiryPolicy{})
+
+	maker := &entity.Order{
+		AccountID:      sellerID,
+		InstrumentPair: "BTC_BRL",
+		OrderType:      string(entity.OrderTypeSell),
+		Price:          decimal.RequireFromString("33"),
+		Quantity:       decimal.RequireFromString("0.1"),
+	}
+	assert.NoError(t, uc.CreateOrder(context.Background(), maker))
+
+	// 0.3 at 33.33333333 reserves 9.999999999, rounded up to 10. The fill
+	// of 0.1 trades at the maker's 33 but is taken out of the reservation
+	// at the order's own price, leaving 6.66666667 rather than 0.2 times
+	// the price recomputed.
+	expiresAt := start.Add(time.Minute)
+	taker := &entity.Order{
+		AccountID:      buyerID,
+		InstrumentPair: "BTC_BRL",
+		OrderType:      string(entity.OrderTypeBuy),
+		Price:          decimal.RequireFromString("33.33333333"),
+		Quantity:       decimal.RequireFromString("0.3"),
+		ExpiresAt:      &expiresAt,
+	}
+	assert.NoError(t, uc.CreateOrder(context.Background(), taker))
+	assert.Equal(t, string(entity.OrderStatusPartial), taker.Status)
+	assert.Equal(t, "6.66666667", taker.ReservedAmount.String())
+
+	stored, err := uc.orderRepository.GetByID(taker.ID)
+	if assert.NoError(t, err) {
+		assert.Equal(t, "6.66666667", stored.ReservedAmount.Round(8).String())
+	}
+	assert.Equal(t, "6.66666667", reservedAmount(t, uc, buyerID, "BRL").String())
+
+	wallet, err := uc.walletRepository.GetByAccountAndAsset(db, buyerID, "BRL")
+	assert.NoError(t, err)
+	assert.Equal(t, "996.7", wallet.Balance.Round(8).String())
+
+	// The maker was filled, so it holds nothing either.
+	assert.True(t, reservedAmount(t, uc, sellerID, "BTC").IsZero())
+
+	// Partially filled orders are cancelled by expiry; cancelling returns
+	// exactly the residual, leaving the whole balance free.
+	clock.Advance(time.Minute)
+	n, err := uc.ExpireOrders()
+	assert.NoError(t, err)
+	assert.Equal(t, 1, n)
+	assert.True(t, reservedAmount(t, uc, buyerID, "BRL").IsZero())
+
+	wallet, err = uc.walletRepository.GetByAccountAndAsset(db, buyerID, "BRL")
+	assert.NoError(t, err)
+	assert.Equal(t, "996.7", wallet.Balance.Round(8).String())
+}
+
+func TestOrderUseCase_CreateOrder_SkipsMakerRemainderBelowScale(t *testing.T) {
+	instruments := entity.NewInstrumentConfig(entity.Asset{Symbol: "ETH", Scale: 4}, entity.Asset{Symbol: "BRL", Scale: 2})
+	log := zap.NewNop().Sugar()
+	db := newMigratedDB(t)
+	orderRepo := repository.NewOrderRepository(log, db)
+	walletRepo := repository.NewWalletRepository(log, db, instruments)
+	uc := NewOrderUseCase(log, orderRepo, walletRepo, repository.NewTradeRepository(log, db), repository.NewEventRepository(log, db),
+		nil, db, OrderUseCaseConfig{Instruments: instruments})
+
+	buyerID, sellerID, dustID := uuid.New(), uuid.New(), uuid.New()
+	for _, w := range []*entity.Wallet{
+		{AccountID: buyerID, AssetSymbol: "BRL", Balance: decimal.RequireFromString("1000")},
+		{AccountID: buyerID, AssetSymbol: "ETH", Balance: decimal.Zero},
+		{AccountID: sellerID, AssetSymbol: "ETH", Balance: decimal.RequireFromString("10")},
+		{AccountID: sellerID, AssetSymbol: "BRL", Balance: decimal.Zero},
+		{AccountID: dustID, AssetSymbol: "ETH", Balance: decimal.RequireFromString("0.00005")},
+		{AccountID: dustID, AssetSymbol: "BRL", Balance: decimal.Zero},
+	} {
+		assert.NoError(t, walletRepo.Create(nil, w))
+	}
+
+	// The best ask is a remainder of 0.00005 ETH, finer than the 4 places
+	// ETH settles at, left by an order placed before decimal places were
+	// checked per pair.
+	dust := &entity.Order{
+		AccountID:         dustID,
+		InstrumentPair:    "ETH_BRL",
+		OrderType:         string(entity.OrderTypeSell),
+		Price:             decimal.RequireFromString("99"),
+		Quantity:          decimal.RequireFromString("1.00005"),
+		RemainingQuantity: decimal.RequireFromString("0.00005"),
+		Status:            string(entity.OrderStatusPartial),
+		ReservedAsset:     "ETH",
+		ReservedAmount:    decimal.RequireFromString("0.00005"),
+	}
+	assert.NoError(t, orderRepo.Create(nil, dust))
+	assert.NoError(t, uc.CreateOrder(context.Background(), &entity.Order{
+		AccountID:      sellerID,
+		InstrumentPair: "ETH_BRL",
+		OrderType:      string(entity.OrderTypeSell),
+		Price:          decimal.RequireFromString("100"),
+		Quantity:       decimal.RequireFromString("5"),
+	}))
+
+	taker := &entity.Order{
+		AccountID:      buyerID,
+		InstrumentPair: "ETH_BRL",
+		OrderType:      string(entity.OrderTypeBuy),
+		Price:          decimal.RequireFromString("101"),
+		Quantity:       decimal.RequireFromString("2"),
+	}
+	assert.NoError(t, uc.CreateOrder(context.Background(), taker))
+	assert.Equal(t, string(entity.OrderStatusFilled), taker.Status)
+
+	// The dust maker is skipped and left as it was.
+	stored, err := orderRepo.GetByID(dust.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, string(entity.OrderStatusPartial), stored.Status)
+	assertDecimalEqual(t, "0.00005", stored.RemainingQuantity.String())
+
+	wallet, err := walletRepo.GetByAccountAndAsset(db, buyerID, "ETH")
+	assert.NoError(t, err)
+	assertDecimalEqual(t, "2", wallet.Balance.String())
+}
+
+func TestOrderUseCase_Reservation_CancelPartialReleasesResidual(t *testing.T) {
+	start := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	uc, db, buyerID, sellerID := newExpiryTestUseCase(t, newFakeClock(start), ExpiryPolicy{})
+
+	buy := func(quantity string) *entity.Order {
+		return &entity.Order{
+			AccountID:      buyerID,
+			InstrumentPair: "BTC_BRL",
+			OrderType:      string(entity.OrderTypeBuy),
+			Price:          decimal.RequireFromString("100"),
+			Quantity:       decimal.RequireFromString(quantity),
+		}
+	}
+
+	assert.NoError(t, uc.CreateOrder(context.Background(), &entity.Order{
+		AccountID:      sellerID,
+		InstrumentPair: "BTC_BRL",
+		OrderType:      string(entity.OrderTypeSell),
+		Price:          decimal.RequireFromString("100"),
+		Quantity:       decimal.RequireFromString("0.1"),
+	}))
+
+	taker := buy("9")
+	assert.NoError(t, uc.CreateOrder(context.Background(), taker))
+	assert.Equal(t, string(entity.OrderStatusPartial), taker.Status)
+	assertDecimalEqual(t, "890", reservedAmount(t, uc, buyerID, "BRL").String())
+
+	wallet, err := uc.walletRepository.GetByAccountAndAsset(db, buyerID, "BRL")
+	assert.NoError(t, err)
+	assertDecimalEqual(t, "990", wallet.Balance.String())
+
+	// The remainder holds what the fill left, so a buy of the rest of the
+	// balance is refused until it is cancelled.
+	assert.ErrorIs(t, uc.CreateOrder(context.Background(), buy("9.9")), entity.ErrInsufficientBalance)
+
+	assert.NoError(t, uc.CancelOrder(context.Background(), taker.ID, uuid.Nil))
+	stored, err := uc.orderRepository.GetByID(taker.ID)
+	if assert.NoError(t, err) {
+		assert.Equal(t, string(entity.OrderStatusCancelled), stored.Status)
+		assertDecimalEqual(t, "0.1", stored.Quantity.Sub(stored.RemainingQuantity).String())
+	}
+	assert.True(t, reservedAmount(t, uc, buyerID, "BRL").IsZero())
+
+	// Retrying is a no-op, and the whole balance is available again.
+	assert.NoError(t, uc.CancelOrder(context.Background(), taker.ID, uuid.Nil))
+	rest := buy("9.9")
+	assert.NoError(t, uc.CreateOrder(context.Background(), rest))
+
+	// Cancelling a side cancels partially filled orders too.
+	assert.NoError(t, uc.CreateOrder(context.Background(), &entity.Order{
+		AccountID:      sellerID,
+		InstrumentPair: "BTC_BRL",
+		OrderType:      string(entity.OrderTypeSell),
+		Price:          decimal.RequireFromString("100"),
+		Quantity:       decimal.RequireFromString("0.9"),
+	}))
+	stored, err = uc.orderRepository.GetByID(rest.ID)
+	if assert.NoError(t, err) {
+		assert.Equal(t, string(entity.OrderStatusPartial), stored.Status)
+	}
+	ids, err := uc.CancelOrders(context.Background(), buyerID, "BTC_BRL", string(entity.OrderTypeBuy))
+	assert.NoError(t, err)
+	assert.Equal(t, []uuid.UUID{rest.ID}, ids)
+	assert.True(t, reservedAmount(t, uc, buyerID, "BRL").IsZero())
+}
+
+func reservedAmount(t *testing.T, uc *orderUseCase, accountID uuid.UUID, asset string) decimal.Decimal {
+	t.Helper()
+
+	amount, err := uc.orderRepository.GetReservedAmount(nil, accountID, asset)
+	assert.NoError(t, err)
+	return amount.Round(8)
+}
+
+func TestOrderUseCase_GetSpreadHistory(t *testing.T) {
+	log := zap.NewNop().Sugar()
+	db := newMigratedDB(t)
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	uc := NewOrderUseCase(log, repository.NewOrderRepository(log, db), nil, nil, nil, nil, db, OrderUseCaseConfig{Clock: newFakeClock(now)})
+
+	price := func(value string) decimal.NullDecimal {
+		if value == "" {
+			return decimal.NullDecimal{}
+		}
+		return decimal.NewNullDecimal(decimal.RequireFromString(value))
+	}
+	seed := func(pair, bid, ask string, age time.Duration) {
+		snapshot := &entity.SpreadSnapshot{InstrumentPair: pair, BestBid: price(bid), BestAsk: price(ask), TakenAt: now.Add(-age)}
+		if err := db.Create(snapshot).Error; err != nil {
+			t.Fatalf("failed to seed spread snapshot: %v", err)
+		}
+	}
+
+	seed("BTC_BRL", "99", "101", 2*time.Hour)
+	seed("BTC_BRL", "98", "102", 50*time.Minute)
+	seed("BTC_BRL", "99", "100", 40*time.Minute)
+	seed("BTC_BRL", "", "103", 20*time.Minute)
+	seed("ETH_BRL", "10", "11", 10*time.Minute)
+
+	type point struct {
+		bid, ask, spread string
+	}
+	points := func(history *SpreadHistory) []point {
+		got := make([]point, len(history.Snapshots))
+		for i, snapshot := range history.Snapshots {
+			if snapshot.BestBid.Valid {
+				got[i].bid = snapshot.BestBid.Decimal.String()
+			}
+			if snapshot.BestAsk.Valid {
+				got[i].ask = snapshot.BestAsk.Decimal.String()
+			}
+			if spread, ok := snapshot.Spread(); ok {
+				got[i].spread = spread.String()
+			}
+		}
+		return got
+	}
+
+	tests := []struct {
+		name     string
+		pair     string
+		interval time.Duration
+		want     []point
+	}{
+		{
+			name: "every snapshot in the window",
+			pair: "BTC_BRL",
+			want: []point{{"98", "102", "4"}, {"99", "100", "1"}, {"", "103", ""}},
+		},
+		{
+			name:     "last snapshot of each interval",
+			pair:     "BTC_BRL",
+			interval: 30 * time.Minute,
+			want:     []point{{"99", "100", "1"}, {"", "103", ""}},
+		},
+		{
+			name: "no snapshots",
+			pair: "SOL_BRL",
+			want: []point{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			history, err := uc.GetSpreadHistory(tt.pair, time.Hour, tt.interval)
+			if !assert.NoError(t, err) {
+				return
+			}
+			assert.Equal(t, now.Add(-time.Hour), history.From)
+			assert.Equal(t, now, history.To)
+			assert.Equal(t, tt.want, points(history))
+		})
+	}
+
+	t.Run("invalid arguments", func(t *testing.T) {
+		_, err := uc.GetSpreadHistory("BTCBRL", time.Hour, 0)
+		assert.ErrorIs(t, err, entity.ErrInvalidPairFormat)
+		_, err = uc.GetSpreadHistory("BTC_BRL", 0, 0)
+		assert.ErrorIs(t, err, entity.ErrInvalidWindow)
+		_, err = uc.GetSpreadHistory("BTC_BRL", time.Hour, 2*time.Hour)
+		assert.ErrorIs(t, err, entity.ErrInvalidSampleInterval)
+	})
+}
+
+func TestOrderUseCase_SnapshotSpreads(t *testing.T) {
+	log := zap.NewNop().Sugar()
+	db := newMigratedDB(t)
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	uc := NewOrderUseCase(log, repository.NewOrderRepository(log, db), nil, nil, nil, nil, db, OrderUseCaseConfig{Clock: newFakeClock(now)})
+
+	seed := func(pair string, orderType entity.OrderType, status entity.OrderStatus, price string, expiresAt *time.Time) {
+		order := &entity.Order{
+			AccountID:         uuid.New(),
+			InstrumentPair:    pair,
+			OrderType:         string(orderType),
+			Status:            string(status),
+			Price:             decimal.RequireFromString(price),
+			Quantity:          decimal.NewFromInt(1),
+			RemainingQuantity: decimal.NewFromInt(1),
+			ExpiresAt:         expiresAt,
+		}
+		if err := db.Create(order).Error; err != nil {
+			t.Fatalf("failed to seed order: %v", err)
+		}
+	}
+	expired := now.Add(-2 * time.Minute)
+
+	seed("BTC_BRL", entity.OrderTypeBuy, entity.OrderStatusOpen, "99", nil)
+	seed("BTC_BRL", entity.OrderTypeBuy, entity.OrderStatusPartial, "99.5", nil)
+	seed("BTC_BRL", entity.OrderTypeBuy, entity.OrderStatusCancelled, "100.5", nil)
+	seed("BTC_BRL", entity.OrderTypeBuy, entity.OrderStatusOpen, "100.8", &expired)
+	seed("BTC_BRL", entity.OrderTypeSell, entity.OrderStatusOpen, "101", nil)
+	seed("BTC_BRL", entity.OrderTypeSell, entity.OrderStatusOpen, "102", nil)
+	seed("ETH_BRL", entity.OrderTypeSell, entity.OrderStatusOpen, "11", nil)
+
+	if !assert.NoError(t, uc.SnapshotSpreads(now.Add(-time.Minute))) {
+		return
+	}
+
+	history, err := uc.GetSpreadHistory("BTC_BRL", time.Hour, 0)
+	if assert.NoError(t, err) && assert.Len(t, history.Snapshots, 1) {
+		snapshot := history.Snapshots[0]
+		assertDecimalEqual(t, "99.5", snapshot.BestBid.Decimal.String())
+		assertDecimalEqual(t, "101", snapshot.BestAsk.Decimal.String())
+		assert.True(t, snapshot.TakenAt.Equal(now.Add(-time.Minute)))
+	}
+
+	history, err = uc.GetSpreadHistory("ETH_BRL", time.Hour, 0)
+	if assert.NoError(t, err) && assert.Len(t, history.Snapshots, 1) {
+		_, ok := history.Snapshots[0].Spread()
+		assert.False(t, history.Snapshots[0].BestBid.Valid)
+		assert.False(t, ok)
+	}
+}
@@ -0,0 +1,55 @@
+package usecase
+
+import (
+	"sort"
+	"sync"
+)
+
+// pairLocks serializes work on the book of each instrument pair within this
+// process, so the expiry sweeper can tell whether a match is in flight.
+// Matching correctness across processes still rests on the database
+// transaction.
+type pairLocks struct {
+	mu    sync.Mutex
+	locks map[string]*sync.Mutex
+}
+
+func newPairLocks() *pairLocks {
+	return &pairLocks{locks: make(map[string]*sync.Mutex)}
+}
+
+func (p *pairLocks) get(pair string) *sync.Mutex {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	lock, ok := p.locks[pair]
+	if !ok {
+		lock = new(sync.Mutex)
+		p.locks[pair] = lock
+	}
+	return lock
+}
+
+// lock takes the lock of every distinct pair in name order, so callers that
+// need several books cannot deadlock each other, and returns the function
+// that releases them.
+func (p *pairLocks) lock(pairs ...string) func() {
+	names := append([]string(nil), pairs...)
+	sort.Strings(names)
+
+	var held []*sync.Mutex
+	for i, pair := range names {
+		if i > 0 && pair == names[i-1] {
+			continue
+		}
+		lock := p.get(pair)
+		lock.Lock()
+		held = append(held, lock)
+	}
+
+	return func() {
+		for i := len(held) - 1; i >= 0; i-- {
+			held[i].Unlock()
+		}
+	}
+}
@@ -1,8 +1,7 @@
 package usecase
 
 import (
-	"strings"
-
+	"github.com/google/uuid"
 	"github.com/lucas-moura1/mercadobitcoin-challenge/entity"
 	"github.com/lucas-moura1/mercadobitcoin-challenge/repository"
 	"github.com/shopspring/decimal"
@@ -15,6 +14,10 @@ type tradeExecutor struct {
 	orderRepo  repository.OrderRepository
 	walletRepo repository.WalletRepository
 	tradeRepo  repository.TradeRepository
+	eventRepo  repository.EventRepository
+	// instruments gives the base asset scale settlement rounds to. Nil
+	// settles quantities as they are.
+	instruments *entity.InstrumentConfig
 }
 
 func NewTradeExecutor(
@@ -22,20 +25,32 @@ func NewTradeExecutor(
 	orderRepo repository.OrderRepository,
 	walletRepo repository.WalletRepository,
 	tradeRepo repository.TradeRepository,
+	eventRepo repository.EventRepository,
+	instruments *entity.InstrumentConfig,
 ) TradeExecutor {
-	return &tradeExecutor{log: log, orderRepo: orderRepo, walletRepo: walletRepo, tradeRepo: tradeRepo}
+	return &tradeExecutor{log: log, orderRepo: orderRepo, walletRepo: walletRepo, tradeRepo: tradeRepo, eventRepo: eventRepo, instruments: instruments}
 }
 
 func (e *tradeExecutor) Execute(tx *gorm.DB, order, matchingOrder *entity.Order, qty decimal.Decimal) error {
+	// The trade, both remaining quantities and settlement all use the one
+	// truncated quantity, so the orders never fill more than was settled.
+	// matchOrder already skips fills that truncate to zero; this guard only
+	// stops a caller that did not.
+	qty = e.settledQuantity(order.InstrumentPair, qty)
+	if !qty.IsPositive() {
+		return entity.ErrInvalidQuantity
+	}
+
 	buyID, sellID := order.ID, matchingOrder.ID
 	if order.OrderType == "SELL" {
 		buyID, sellID = matchingOrder.ID, order.ID
 	}
 	trade := &entity.Trade{
-		BuyerOrderID:  buyID,
-		SellerOrderID: sellID,
-		Price:         matchingOrder.Price,
-		Quantity:      qty,
+		BuyerOrderID:   buyID,
+		SellerOrderID:  sellID,
+		InstrumentPair: order.InstrumentPair,
+		Price:          matchingOrder.Price,
+		Quantity:       qty,
 	}
 	if err := e.tradeRepo.Create(tx, trade); err != nil {
 		return err
@@ -43,8 +58,14 @@ func (e *tradeExecutor) Execute(tx *gorm.DB, order, matchingOrder *entity.Order,
 
 	e.log.Debugw("executed trade", "trade_id", trade.ID, "quantity", qty, "price", matchingOrder.Price)
 
+	if err := appendEvent(e.eventRepo, tx, entity.EventTypeTradeExecuted, trade.ID, trade); err != nil {
+		return err
+	}
+
 	order.RemainingQuantity = order.RemainingQuantity.Sub(qty)
 	matchingOrder.RemainingQuantity = matchingOrder.RemainingQuantity.Sub(qty)
+	order.ConsumeReservation(qty)
+	matchingOrder.ConsumeReservation(qty)
 
 	if err := e.updateOrderStatus(tx, order); err != nil {
 		return err
@@ -61,15 +82,15 @@ func (e *tradeExecutor) Execute(tx *gorm.DB, order, matchingOrder *entity.Order,
 func (e *tradeExecutor) updateOrderStatus(tx *gorm.DB, o *entity.Order) error {
 	var newStatus string
 	switch {
-	case o.RemainingQuantity.IsZero():
+	case entity.DecimalEqual(o.RemainingQuantity, decimal.Zero):
 		newStatus = string(entity.OrderStatusFilled)
-	case o.RemainingQuantity.Equal(o.Quantity):
+	case entity.DecimalEqual(o.RemainingQuantity, o.Quantity):
 		newStatus = string(entity.OrderStatusOpen)
 	default:
 		newStatus = string(entity.OrderStatusPartial)
 	}
 
-	if err := e.orderRepo.UpdateRemainingAndStatus(tx, o.ID, o.RemainingQuantity, newStatus); err != nil {
+	if err := e.orderRepo.UpdateRemainingAndStatus(tx, o.ID, o.RemainingQuantity, o.ReservedAmount, newStatus); err != nil {
 		return err
 	}
 
@@ -78,30 +99,79 @@ func (e *tradeExecutor) updateOrderStatus(tx *gorm.DB, o *entity.Order) error {
 }
 
 func (e *tradeExecutor) settle(tx *gorm.DB, order, matchingOrder *entity.Order, qty decimal.Decimal) error {
-	parts := strings.Split(order.InstrumentPair, "_")
-	base, quote := parts[0], parts[1]
+	base, quote, err := entity.SplitInstrumentPair(order.InstrumentPair)
+	if err != nil {
+		e.log.Errorw("cannot settle order with malformed pair",
+			"order_id", order.ID,
+			"instrument_pair", order.InstrumentPair,
+		)
+		return err
+	}
 
 	buyer, seller := order, matchingOrder
 	if order.OrderType == "SELL" {
 		buyer, seller = matchingOrder, order
 	}
 
+	// Both base legs move the same qty, so what the seller gives is exactly
+	// what the buyer gets.
 	total := matchingOrder.Price.Mul(qty)
 
 	if err := e.walletRepo.SubtractFromBalance(tx, seller.AccountID, base, qty); err != nil {
 		return err
 	}
-	if err := e.walletRepo.AddToBalance(tx, buyer.AccountID, base, qty); err != nil {
+	if err := e.credit(tx, buyer.AccountID, base, qty); err != nil {
 		return err
 	}
 
 	if err := e.walletRepo.SubtractFromBalance(tx, buyer.AccountID, quote, total); err != nil {
 		return err
 	}
-	if err := e.walletRepo.AddToBalance(tx, seller.AccountID, quote, total); err != nil {
+	if err := e.credit(tx, seller.AccountID, quote, total); err != nil {
 		return err
 	}
 
 	e.log.Debugw("settled trade")
 	return nil
 }
+
+// credit adds amount to the account's wallet for asset, first creating the
+// wallet with a zero balance inside tx if the account has none, so a trade is
+// not rolled back only because the receiving side never held the asset.
+func (e *tradeExecutor) credit(tx *gorm.DB, accountID uuid.UUID, asset string, amount decimal.Decimal) error {
+	created, err := e.walletRepo.CreateIfNotExists(tx, &entity.Wallet{
+		AccountID:   accountID,
+		AssetSymbol: asset,
+		Balance:     decimal.Zero,
+	})
+	if err != nil {
+		return err
+	}
+	if created {
+		e.log.Infow("created missing wallet to receive settlement", "account_id", accountID, "asset", asset)
+	}
+
+	return e.walletRepo.AddToBalance(tx, accountID, asset, amount)
+}
+
+// settledQuantity truncates qty to the base asset's scale, the precision a
+// base balance can hold. A qty finer than that is logged, since the digits
+// dropped are not settled to either side. Pairs without a configured scale
+// settle qty unchanged.
+func (e *tradeExecutor) settledQuantity(pair string, qty decimal.Decimal) decimal.Decimal {
+	scale, ok := e.instruments.QuantityScale(pair)
+	if !ok {
+		return qty
+	}
+
+	settled := qty.Truncate(scale)
+	if !entity.DecimalEqual(settled, qty) {
+		e.log.Warnw("fill quantity exceeds base asset scale, settling truncated quantity",
+			"instrument_pair", pair,
+			"quantity", qty,
+			"settled_quantity", settled,
+			"scale", scale,
+		)
+	}
+	return settled
+}
@@ -44,6 +44,27 @@ func TestTradeExecutor_updateOrderStatus(t *testing.T) {
 			initialStat: string(entity.OrderStatusOpen),
 			wantStatus:  string(entity.OrderStatusPartial),
 		},
+		{
+			name:        "FILLED when remaining is zero at a different scale",
+			qty:         "1.00000000",
+			remaining:   "0.000",
+			initialStat: string(entity.OrderStatusPartial),
+			wantStatus:  string(entity.OrderStatusFilled),
+		},
+		{
+			name:        "OPEN when remaining equals quantity at a different scale",
+			qty:         "1.0",
+			remaining:   "1.00000000",
+			initialStat: string(entity.OrderStatusOpen),
+			wantStatus:  string(entity.OrderStatusOpen),
+		},
+		{
+			name:        "PARTIALLY_FILLED when remaining differs only past the quantity scale",
+			qty:         "1.0",
+			remaining:   "0.99999999",
+			initialStat: string(entity.OrderStatusOpen),
+			wantStatus:  string(entity.OrderStatusPartial),
+		},
 		{
 			name:        "repository error returned and status not updated",
 			qty:         "1.0",
@@ -74,7 +95,7 @@ func TestTradeExecutor_updateOrderStatus(t *testing.T) {
 			}
 
 			orderRepo.EXPECT().
-				UpdateRemainingAndStatus(gomock.Any(), id, rem, tt.wantStatus).
+				UpdateRemainingAndStatus(gomock.Any(), id, rem, gomock.Any(), tt.wantStatus).
 				Return(tt.repoErr).
 				Times(1)
 
@@ -125,8 +146,10 @@ func TestTradeExecutor_settle(t *testing.T) {
 				total := f.price.Mul(f.qty)
 				gomock.InOrder(
 					wr.EXPECT().SubtractFromBalance(nil, f.sellerID, "BTC", f.qty).Return(nil),
+					wr.EXPECT().CreateIfNotExists(nil, gomock.Any()).Return(false, nil),
 					wr.EXPECT().AddToBalance(nil, f.buyerID, "BTC", f.qty).Return(nil),
 					wr.EXPECT().SubtractFromBalance(nil, f.buyerID, "BRL", total).Return(nil),
+					wr.EXPECT().CreateIfNotExists(nil, gomock.Any()).Return(false, nil),
 					wr.EXPECT().AddToBalance(nil, f.sellerID, "BRL", total).Return(nil),
 				)
 			},
@@ -144,8 +167,10 @@ func TestTradeExecutor_settle(t *testing.T) {
 				total := f.price.Mul(f.qty)
 				gomock.InOrder(
 					wr.EXPECT().SubtractFromBalance(nil, f.buyerID, "BTC", f.qty).Return(nil),
+					wr.EXPECT().CreateIfNotExists(nil, gomock.Any()).Return(false, nil),
 					wr.EXPECT().AddToBalance(nil, f.sellerID, "BTC", f.qty).Return(nil),
 					wr.EXPECT().SubtractFromBalance(nil, f.sellerID, "BRL", total).Return(nil),
+					wr.EXPECT().CreateIfNotExists(nil, gomock.Any()).Return(false, nil),
 					wr.EXPECT().AddToBalance(nil, f.buyerID, "BRL", total).Return(nil),
 				)
 			},
@@ -175,10 +200,52 @@ func TestTradeExecutor_settle(t *testing.T) {
 			},
 			mockSetup: func(wr *repository.MockWalletRepository, f fields) {
 				wr.EXPECT().SubtractFromBalance(nil, f.sellerID, "BTC", f.qty).Return(nil)
+				wr.EXPECT().CreateIfNotExists(nil, gomock.Any()).Return(false, nil)
 				wr.EXPECT().AddToBalance(nil, f.buyerID, "BTC", f.qty).Return(assert.AnError)
 			},
 			wantErr: true,
 		},
+		{
+			name: "missing receiving wallet is created before crediting",
+			f: fields{
+				orderType: string(entity.OrderTypeBuy),
+				buyerID:   uuid.New(),
+				sellerID:  uuid.New(),
+				price:     decimal.RequireFromString("100"),
+				qty:       decimal.RequireFromString("0.1"),
+			},
+			mockSetup: func(wr *repository.MockWalletRepository, f fields) {
+				total := f.price.Mul(f.qty)
+				isWallet := func(accountID uuid.UUID, asset string) gomock.Matcher {
+					return gomock.Cond(func(w *entity.Wallet) bool {
+						return w.AccountID == accountID && w.AssetSymbol == asset && w.Balance.IsZero()
+					})
+				}
+				gomock.InOrder(
+					wr.EXPECT().SubtractFromBalance(nil, f.sellerID, "BTC", f.qty).Return(nil),
+					wr.EXPECT().CreateIfNotExists(nil, isWallet(f.buyerID, "BTC")).Return(true, nil),
+					wr.EXPECT().AddToBalance(nil, f.buyerID, "BTC", f.qty).Return(nil),
+					wr.EXPECT().SubtractFromBalance(nil, f.buyerID, "BRL", total).Return(nil),
+					wr.EXPECT().CreateIfNotExists(nil, isWallet(f.sellerID, "BRL")).Return(true, nil),
+					wr.EXPECT().AddToBalance(nil, f.sellerID, "BRL", total).Return(nil),
+				)
+			},
+		},
+		{
+			name: "error creating receiving wallet stops before crediting",
+			f: fields{
+				orderType: string(entity.OrderTypeBuy),
+				buyerID:   uuid.New(),
+				sellerID:  uuid.New(),
+				price:     decimal.RequireFromString("100"),
+				qty:       decimal.RequireFromString("0.1"),
+			},
+			mockSetup: func(wr *repository.MockWalletRepository, f fields) {
+				wr.EXPECT().SubtractFromBalance(nil, f.sellerID, "BTC", f.qty).Return(nil)
+				wr.EXPECT().CreateIfNotExists(nil, gomock.Any()).Return(false, assert.AnError)
+			},
+			wantErr: true,
+		},
 		{
 			name: "error at quote subtract stops before final add",
 			f: fields{
@@ -191,6 +258,7 @@ func TestTradeExecutor_settle(t *testing.T) {
 			mockSetup: func(wr *repository.MockWalletRepository, f fields) {
 				total := f.price.Mul(f.qty)
 				wr.EXPECT().SubtractFromBalance(nil, f.sellerID, "BTC", f.qty).Return(nil)
+				wr.EXPECT().CreateIfNotExists(nil, gomock.Any()).Return(false, nil)
 				wr.EXPECT().AddToBalance(nil, f.buyerID, "BTC", f.qty).Return(nil)
 				wr.EXPECT().SubtractFromBalance(nil, f.buyerID, "BRL", total).Return(assert.AnError)
 			},
@@ -208,8 +276,10 @@ func TestTradeExecutor_settle(t *testing.T) {
 			mockSetup: func(wr *repository.MockWalletRepository, f fields) {
 				total := f.price.Mul(f.qty)
 				wr.EXPECT().SubtractFromBalance(nil, f.sellerID, "BTC", f.qty).Return(nil)
+				wr.EXPECT().CreateIfNotExists(nil, gomock.Any()).Return(false, nil)
 				wr.EXPECT().AddToBalance(nil, f.buyerID, "BTC", f.qty).Return(nil)
 				wr.EXPECT().SubtractFromBalance(nil, f.buyerID, "BRL", total).Return(nil)
+				wr.EXPECT().CreateIfNotExists(nil, gomock.Any()).Return(false, nil)
 				wr.EXPECT().AddToBalance(nil, f.sellerID, "BRL", total).Return(assert.AnError)
 			},
 			wantErr: true,
@@ -253,6 +323,91 @@ func TestTradeExecutor_settle(t *testing.T) {
 	}
 }
 
+func TestTradeExecutor_settle_MalformedPair(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	// No wallet expectations: a malformed pair must fail before any leg.
+	exec := &tradeExecutor{
+		log:        zap.NewNop().Sugar(),
+		walletRepo: repository.NewMockWalletRepository(ctrl),
+	}
+
+	order := &entity.Order{
+		AccountID:      uuid.New(),
+		InstrumentPair: "BTC",
+		OrderType:      string(entity.OrderTypeBuy),
+		Price:          decimal.RequireFromString("100"),
+	}
+	matching := &entity.Order{
+		AccountID:      uuid.New(),
+		InstrumentPair: "BTC",
+		OrderType:      string(entity.OrderTypeSell),
+		Price:          decimal.RequireFromString("100"),
+	}
+
+	assert.NotPanics(t, func() {
+		err := exec.settle(nil, order, matching, decimal.RequireFromString("1"))
+		assert.ErrorIs(t, err, entity.ErrInvalidPairFormat)
+	})
+}
+
+func TestTradeExecutor_settle_BalanceEpsilon(t *testing.T) {
+	instruments := entity.NewInstrumentConfig(
+		entity.Asset{Symbol: "BTC", Scale: 8},
+		entity.Asset{Symbol: "BRL", Scale: 2},
+	)
+
+	tests := []struct {
+		name         string
+		buyerBRL     string
+		wantErr      bool
+		wantBuyerBRL string
+	}{
+		{name: "exact balance", buyerBRL: "50", wantBuyerBRL: "0"},
+		{name: "deficit equal to epsilon draws to zero", buyerBRL: "49.99", wantBuyerBRL: "0"},
+		{name: "deficit beyond epsilon fails", buyerBRL: "49.989", wantErr: true},
+		{name: "surplus is kept", buyerBRL: "60", wantBuyerBRL: "10"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			log := zap.NewNop().Sugar()
+			db := newMigratedDB(t)
+			walletRepo := repository.NewWalletRepository(log, db, instruments)
+
+			buyer, seller := uuid.New(), uuid.New()
+			wallets := []*entity.Wallet{
+				{AccountID: buyer, AssetSymbol: "BRL", Balance: decimal.RequireFromString(tt.buyerBRL)},
+				{AccountID: buyer, AssetSymbol: "BTC", Balance: decimal.Zero},
+				{AccountID: seller, AssetSymbol: "BTC", Balance: decimal.RequireFromString("1")},
+				{AccountID: seller, AssetSymbol: "BRL", Balance: decimal.Zero},
+			}
+			for _, w := range wallets {
+				assert.NoError(t, walletRepo.Create(nil, w))
+			}
+
+			exec := &tradeExecutor{log: log, walletRepo: walletRepo}
+			order := &entity.Order{AccountID: buyer, InstrumentPair: "BTC_BRL", OrderType: string(entity.OrderTypeBuy)}
+			matching := &entity.Order{AccountID: seller, InstrumentPair: "BTC_BRL", OrderType: string(entity.OrderTypeSell), Price: decimal.RequireFromString("100")}
+
+			tx := db.Begin()
+			err := exec.settle(tx, order, matching, decimal.RequireFromString("0.5"))
+			if tt.wantErr {
+				assert.Error(t, err)
+				tx.Rollback()
+				return
+			}
+			assert.NoError(t, err)
+			assert.NoError(t, tx.Commit().Error)
+
+			wallet, err := walletRepo.GetByAccountAndAsset(db, buyer, "BRL")
+			assert.NoError(t, err)
+			assert.True(t, decimal.RequireFromString(tt.wantBuyerBRL).Equal(wallet.Balance), "got %s", wallet.Balance)
+		})
+	}
+}
+
 func TestTradeExecutor_Execute_TableDriven(t *testing.T) {
 	type args struct {
 		matchingType   string
@@ -282,12 +437,14 @@ func TestTradeExecutor_Execute_TableDriven(t *testing.T) {
 					Create(gomock.Nil(), gomock.Any()).
 					Return(nil).Times(1)
 
-				or.EXPECT().UpdateRemainingAndStatus(gomock.Nil(), matching.ID, gomock.Any(), gomock.Any()).Return(nil).Times(1)
-				or.EXPECT().UpdateRemainingAndStatus(gomock.Nil(), order.ID, gomock.Any(), gomock.Any()).Return(nil).Times(1)
+				or.EXPECT().UpdateRemainingAndStatus(gomock.Nil(), matching.ID, gomock.Any(), gomock.Any(), gomock.Any()).Return(nil).Times(1)
+				or.EXPECT().UpdateRemainingAndStatus(gomock.Nil(), order.ID, gomock.Any(), gomock.Any(), gomock.Any()).Return(nil).Times(1)
 
 				wr.EXPECT().SubtractFromBalance(gomock.Nil(), order.AccountID, gomock.Any(), gomock.Any()).Return(nil).Times(1)
+				wr.EXPECT().CreateIfNotExists(gomock.Nil(), gomock.Any()).Return(false, nil).Times(1)
 				wr.EXPECT().AddToBalance(gomock.Nil(), matching.AccountID, gomock.Any(), gomock.Any()).Return(nil).Times(1)
 				wr.EXPECT().SubtractFromBalance(gomock.Nil(), matching.AccountID, gomock.Any(), gomock.Any()).Return(nil).Times(1)
+				wr.EXPECT().CreateIfNotExists(gomock.Nil(), gomock.Any()).Return(false, nil).Times(1)
 				wr.EXPECT().AddToBalance(gomock.Nil(), order.AccountID, gomock.Any(), gomock.Any()).Return(nil).Times(1)
 			},
 		},
@@ -305,12 +462,14 @@ func TestTradeExecutor_Execute_TableDriven(t *testing.T) {
 					Create(gomock.Nil(), gomock.Any()).
 					Return(nil).Times(1)
 
-				or.EXPECT().UpdateRemainingAndStatus(gomock.Nil(), matching.ID, gomock.Any(), gomock.Any()).Return(nil).Times(1)
-				or.EXPECT().UpdateRemainingAndStatus(gomock.Nil(), order.ID, gomock.Any(), gomock.Any()).Return(nil).Times(1)
+				or.EXPECT().UpdateRemainingAndStatus(gomock.Nil(), matching.ID, gomock.Any(), gomock.Any(), gomock.Any()).Return(nil).Times(1)
+				or.EXPECT().UpdateRemainingAndStatus(gomock.Nil(), order.ID, gomock.Any(), gomock.Any(), gomock.Any()).Return(nil).Times(1)
 
 				wr.EXPECT().SubtractFromBalance(gomock.Nil(), matching.AccountID, gomock.Any(), gomock.Any()).Return(nil).Times(1)
+				wr.EXPECT().CreateIfNotExists(gomock.Nil(), gomock.Any()).Return(false, nil).Times(1)
 				wr.EXPECT().AddToBalance(gomock.Nil(), order.AccountID, gomock.Any(), gomock.Any()).Return(nil).Times(1)
 				wr.EXPECT().SubtractFromBalance(gomock.Nil(), order.AccountID, gomock.Any(), gomock.Any()).Return(nil).Times(1)
+				wr.EXPECT().CreateIfNotExists(gomock.Nil(), gomock.Any()).Return(false, nil).Times(1)
 				wr.EXPECT().AddToBalance(gomock.Nil(), matching.AccountID, gomock.Any(), gomock.Any()).Return(nil).Times(1)
 			},
 		},
@@ -325,11 +484,13 @@ func TestTradeExecutor_Execute_TableDriven(t *testing.T) {
 			},
 			setup: func(or *repository.MockOrderRepository, wr *repository.MockWalletRepository, tr *repository.MockTradeRepository, order, matching *entity.Order, qty, price decimal.Decimal) {
 				tr.EXPECT().Create(gomock.Nil(), gomock.Any()).Return(nil).Times(1)
-				or.EXPECT().UpdateRemainingAndStatus(gomock.Nil(), matching.ID, gomock.Any(), gomock.Any()).Return(nil).Times(1)
-				or.EXPECT().UpdateRemainingAndStatus(gomock.Nil(), order.ID, gomock.Any(), gomock.Any()).Return(nil).Times(1)
+				or.EXPECT().UpdateRemainingAndStatus(gomock.Nil(), matching.ID, gomock.Any(), gomock.Any(), gomock.Any()).Return(nil).Times(1)
+				or.EXPECT().UpdateRemainingAndStatus(gomock.Nil(), order.ID, gomock.Any(), gomock.Any(), gomock.Any()).Return(nil).Times(1)
 				wr.EXPECT().SubtractFromBalance(gomock.Nil(), order.AccountID, gomock.Any(), gomock.Any()).Return(nil).Times(1)
+				wr.EXPECT().CreateIfNotExists(gomock.Nil(), gomock.Any()).Return(false, nil).Times(1)
 				wr.EXPECT().AddToBalance(gomock.Nil(), matching.AccountID, gomock.Any(), gomock.Any()).Return(nil).Times(1)
 				wr.EXPECT().SubtractFromBalance(gomock.Nil(), matching.AccountID, gomock.Any(), gomock.Any()).Return(nil).Times(1)
+				wr.EXPECT().CreateIfNotExists(gomock.Nil(), gomock.Any()).Return(false, nil).Times(1)
 				wr.EXPECT().AddToBalance(gomock.Nil(), order.AccountID, gomock.Any(), gomock.Any()).Return(nil).Times(1)
 			},
 		},
@@ -358,7 +519,7 @@ func TestTradeExecutor_Execute_TableDriven(t *testing.T) {
 			},
 			setup: func(or *repository.MockOrderRepository, wr *repository.MockWalletRepository, tr *repository.MockTradeRepository, order, matching *entity.Order, qty, price decimal.Decimal) {
 				tr.EXPECT().Create(gomock.Nil(), gomock.Any()).Return(nil).Times(1)
-				or.EXPECT().UpdateRemainingAndStatus(gomock.Nil(), order.ID, gomock.Any(), gomock.Any()).Return(assert.AnError).Times(1)
+				or.EXPECT().UpdateRemainingAndStatus(gomock.Nil(), order.ID, gomock.Any(), gomock.Any(), gomock.Any()).Return(assert.AnError).Times(1)
 			},
 			wantErr: true,
 		},
@@ -373,8 +534,8 @@ func TestTradeExecutor_Execute_TableDriven(t *testing.T) {
 			},
 			setup: func(or *repository.MockOrderRepository, wr *repository.MockWalletRepository, tr *repository.MockTradeRepository, order, matching *entity.Order, qty, price decimal.Decimal) {
 				tr.EXPECT().Create(gomock.Nil(), gomock.Any()).Return(nil).Times(1)
-				or.EXPECT().UpdateRemainingAndStatus(gomock.Nil(), order.ID, gomock.Any(), gomock.Any()).Return(nil).Times(1)
-				or.EXPECT().UpdateRemainingAndStatus(gomock.Nil(), matching.ID, gomock.Any(), gomock.Any()).Return(assert.AnError).Times(1)
+				or.EXPECT().UpdateRemainingAndStatus(gomock.Nil(), order.ID, gomock.Any(), gomock.Any(), gomock.Any()).Return(nil).Times(1)
+				or.EXPECT().UpdateRemainingAndStatus(gomock.Nil(), matching.ID, gomock.Any(), gomock.Any(), gomock.Any()).Return(assert.AnError).Times(1)
 			},
 			wantErr: true,
 		},
@@ -420,11 +581,15 @@ func TestTradeExecutor_Execute_TableDriven(t *testing.T) {
 
 			tt.setup(orderRepo, walletRepo, tradeRepo, order, matching, qty, price)
 
+			eventRepo := repository.NewMockEventRepository(ctrl)
+			eventRepo.EXPECT().Append(gomock.Nil(), gomock.Any()).Return(nil).AnyTimes()
+
 			exec := &tradeExecutor{
 				log:        zap.NewNop().Sugar(),
 				orderRepo:  orderRepo,
 				walletRepo: walletRepo,
 				tradeRepo:  tradeRepo,
+				eventRepo:  eventRepo,
 			}
 
 			err := exec.Execute(nil, order, matching, qty)
@@ -437,3 +602,85 @@ func TestTradeExecutor_Execute_TableDriven(t *testing.T) {
 		})
 	}
 }
+
+func TestTradeExecutor_Execute_TruncatesBelowScaleOnce(t *testing.T) {
+	instruments := entity.NewInstrumentConfig(
+		entity.Asset{Symbol: "BTC", Scale: 4},
+		entity.Asset{Symbol: "BRL", Scale: 2},
+	)
+	log := zap.NewNop().Sugar()
+	db := newMigratedDB(t)
+	orderRepo := repository.NewOrderRepository(log, db)
+	walletRepo := repository.NewWalletRepository(log, db, instruments)
+	tradeRepo := repository.NewTradeRepository(log, db)
+
+	buyer, seller := uuid.New(), uuid.New()
+	wallets := []*entity.Wallet{
+		{AccountID: buyer, AssetSymbol: "BRL", Balance: decimal.RequireFromString("1000")},
+		{AccountID: buyer, AssetSymbol: "BTC", Balance: decimal.Zero},
+		{AccountID: seller, AssetSymbol: "BTC", Balance: decimal.RequireFromString("1")},
+		{AccountID: seller, AssetSymbol: "BRL", Balance: decimal.Zero},
+	}
+	for _, w := range wallets {
+		assert.NoError(t, walletRepo.Create(nil, w))
+	}
+
+	order := &entity.Order{
+		AccountID:         buyer,
+		InstrumentPair:    "BTC_BRL",
+		OrderType:         string(entity.OrderTypeBuy),
+		Price:             decimal.RequireFromString("100"),
+		Quantity:          decimal.RequireFromString("1"),
+		RemainingQuantity: decimal.RequireFromString("1"),
+		Status:            string(entity.OrderStatusOpen),
+	}
+	matching := &entity.Order{
+		AccountID:         seller,
+		InstrumentPair:    "BTC_BRL",
+		OrderType:         string(entity.OrderTypeSell),
+		Price:             decimal.RequireFromString("100"),
+		Quantity:          decimal.RequireFromString("1"),
+		RemainingQuantity: decimal.RequireFromString("1"),
+		Status:            string(entity.OrderStatusOpen),
+	}
+	assert.NoError(t, orderRepo.Create(nil, order))
+	assert.NoError(t, orderRepo.Create(nil, matching))
+
+	exec := NewTradeExecutor(log, orderRepo, walletRepo, tradeRepo, repository.NewEventRepository(log, db), instruments)
+	assert.NoError(t, db.Transaction(func(tx *gorm.DB) error {
+		return exec.Execute(tx, order, matching, decimal.RequireFromString("0.123456789"))
+	}))
+
+	// The trade row and both orders record the truncated fill, the same
+	// amount that was settled.
+	trades, err := tradeRepo.GetByOrderID(order.ID)
+	assert.NoError(t, err)
+	if assert.Len(t, trades, 1) {
+		assertDecimalEqual(t, "0.1234", trades[0].Quantity.String())
+	}
+	for _, o := range []*entity.Order{order, matching} {
+		stored, err := orderRepo.GetByID(o.ID)
+		assert.NoError(t, err)
+		assertDecimalEqual(t, "0.8766", stored.RemainingQuantity.String())
+	}
+
+	balance := func(accountID uuid.UUID, asset string) decimal.Decimal {
+		wallet, err := walletRepo.GetByAccountAndAsset(db, accountID, asset)
+		assert.NoError(t, err)
+		return wallet.Balance.Round(8)
+	}
+
+	buyerBTC, sellerBTC := balance(buyer, "BTC"), balance(seller, "BTC")
+	assertDecimalEqual(t, "0.1234", buyerBTC.String())
+	assertDecimalEqual(t, "0.8766", sellerBTC.String())
+	assertDecimalEqual(t, "1", buyerBTC.Add(sellerBTC).String())
+
+	buyerBRL, sellerBRL := balance(buyer, "BRL"), balance(seller, "BRL")
+	assertDecimalEqual(t, "12.34", sellerBRL.String())
+	assertDecimalEqual(t, "1000", buyerBRL.Add(sellerBRL).String())
+
+	// A fill entirely below the scale would settle nothing, so it is refused.
+	assert.ErrorIs(t, db.Transaction(func(tx *gorm.DB) error {
+		return exec.Execute(tx, order, matching, decimal.RequireFromString("0.00001"))
+	}), entity.ErrInvalidQuantity)
+}
@@ -0,0 +1,294 @@
+package usecase
+
+import (
+	"bytes"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lucas-moura1/mercadobitcoin-challenge/entity"
+	"github.com/lucas-moura1/mercadobitcoin-challenge/repository"
+	"github.com/shopspring/decimal"
+	"go.uber.org/zap"
+)
+
+const (
+	DefaultTradesLimit = 100
+	MaxTradesLimit     = 1000
+)
+
+// MaxVWAPWindow bounds how far back a VWAP query may look.
+const MaxVWAPWindow = 30 * 24 * time.Hour
+
+type tradeUseCase struct {
+	log             *zap.SugaredLogger
+	tradeRepository repository.TradeRepository
+	clock           Clock
+}
+
+func NewTradeUseCase(
+	log *zap.SugaredLogger,
+	tradeRepo repository.TradeRepository,
+	clock Clock,
+) TradeUseCase {
+	return &tradeUseCase{
+		log:             log,
+		tradeRepository: tradeRepo,
+		clock:           clockOrSystem(clock),
+	}
+}
+
+func (u *tradeUseCase) GetTradesByAccount(accountID uuid.UUID, limit int) ([]*entity.Trade, error) {
+	u.log.Infow("getting trades by account", "account_id", accountID, "limit", limit)
+
+	return u.tradeRepository.GetByAccountID(accountID, clampTradesLimit(limit))
+}
+
+func (u *tradeUseCase) GetTradesByInstrumentPair(instrumentPair string, limit int) ([]*entity.Trade, error) {
+	u.log.Infow("getting trades by instrument pair", "instrument_pair", instrumentPair, "limit", limit)
+
+	if !entity.IsValidInstrumentPair(instrumentPair) {
+		return nil, entity.ErrInvalidPairFormat
+	}
+
+	return u.tradeRepository.GetByInstrumentPair(instrumentPair, clampTradesLimit(limit))
+}
+
+// GetTicks returns the points where the pair's last-trade price changed,
+// after the trade since (uuid.Nil starts from the first trade). Up to limit
+// ticks are returned; runs of trades at an unchanged price are skipped.
+func (u *tradeUseCase) GetTicks(instrumentPair string, since uuid.UUID, limit int) (*TickSeries, error) {
+	u.log.Infow("getting ticks", "instrument_pair", instrumentPair, "since", since, "limit", limit)
+
+	if !entity.IsValidInstrumentPair(instrumentPair) {
+		return nil, entity.ErrInvalidPairFormat
+	}
+	limit = clampTradesLimit(limit)
+
+	var last *entity.Trade
+	if since != uuid.Nil {
+		cursor, err := u.tradeRepository.GetByID(since)
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, entity.ErrInvalidCursor
+		}
+		if err != nil {
+			return nil, err
+		}
+		if cursor.InstrumentPair != instrumentPair {
+			return nil, entity.ErrInvalidCursor
+		}
+		last = cursor
+	}
+
+	series := &TickSeries{Ticks: []*Tick{}, NextCursor: since}
+	for len(series.Ticks) < limit {
+		trades, err := u.tradeRepository.GetByInstrumentPairSince(instrumentPair, last, limit)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, trade := range trades {
+			if last == nil || !entity.DecimalEqual(trade.Price, last.Price) {
+				series.Ticks = append(series.Ticks, &Tick{
+					TradeID:    trade.ID,
+					Price:      trade.Price,
+					ExecutedAt: trade.ExecutedAt,
+				})
+			}
+			last = trade
+			series.NextCursor = trade.ID
+
+			if len(series.Ticks) == limit {
+				break
+			}
+		}
+
+		if len(trades) < limit {
+			break
+		}
+	}
+
+	return series, nil
+}
+
+func clampTradesLimit(limit int) int {
+	if limit <= 0 {
+		return DefaultTradesLimit
+	}
+	if limit > MaxTradesLimit {
+		return MaxTradesLimit
+	}
+	return limit
+}
+
+func (u *tradeUseCase) GetCandles(instrumentPair string, interval string, from time.Time, to time.Time) ([]*entity.Candle, error) {
+	u.log.Infow("getting candles",
+		"instrument_pair", instrumentPair,
+		"interval", interval,
+		"from", from,
+		"to", to,
+	)
+
+	if !entity.IsValidInstrumentPair(instrumentPair) {
+		return nil, entity.ErrInvalidPairFormat
+	}
+
+	bucket, err := entity.ParseCandleInterval(interval)
+	if err != nil {
+		return nil, err
+	}
+
+	if !from.Before(to) {
+		return nil, entity.ErrInvalidTimeRange
+	}
+
+	return u.tradeRepository.GetCandles(instrumentPair, bucket, from.UTC(), to.UTC())
+}
+
+// GetVWAP returns the pair's volume-weighted average price over the window
+// ending now.
+func (u *tradeUseCase) GetVWAP(instrumentPair string, window time.Duration) (*VWAP, error) {
+	u.log.Infow("getting vwap", "instrument_pair", instrumentPair, "window", window)
+
+	if !entity.IsValidInstrumentPair(instrumentPair) {
+		return nil, entity.ErrInvalidPairFormat
+	}
+	if window <= 0 || window > MaxVWAPWindow {
+		return nil, entity.ErrInvalidWindow
+	}
+
+	to := u.clock.Now().UTC()
+	from := to.Add(-window)
+
+	price, err := u.tradeRepository.VWAP(instrumentPair, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	return &VWAP{InstrumentPair: instrumentPair, From: from, To: to, Price: price}, nil
+}
+
+// GetPnL walks the account's trades on the pair in execution order and
+// realizes profit and loss on each sell against what was bought before it,
+// matched by method (CostBasisAverage when empty). The part of a sell beyond
+// the quantity held, such as coins deposited rather than bought, has no cost
+// basis and realizes nothing. No fees are charged, so none are deducted.
+func (u *tradeUseCase) GetPnL(accountID uuid.UUID, instrumentPair string, method CostBasisMethod) (*PnL, error) {
+	u.log.Infow("getting pnl", "account_id", accountID, "instrument_pair", instrumentPair, "method", method)
+
+	if !entity.IsValidInstrumentPair(instrumentPair) {
+		return nil, entity.ErrInvalidPairFormat
+	}
+	if method == "" {
+		method = CostBasisAverage
+	}
+	if method != CostBasisAverage && method != CostBasisFIFO {
+		return nil, entity.ErrInvalidCostBasis
+	}
+
+	buys, err := u.tradeRepository.GetByAccountPairSide(accountID, instrumentPair, string(entity.OrderTypeBuy))
+	if err != nil {
+		return nil, err
+	}
+	sells, err := u.tradeRepository.GetByAccountPairSide(accountID, instrumentPair, string(entity.OrderTypeSell))
+	if err != nil {
+		return nil, err
+	}
+
+	pnl := &PnL{AccountID: accountID, InstrumentPair: instrumentPair, Method: method}
+	basis := &costBasis{method: method}
+	boughtNotional, soldNotional := decimal.Zero, decimal.Zero
+
+	// A trade between two of the account's own orders is in both lists; it
+	// is taken as a buy first, so the sell finds it held.
+	for len(buys) > 0 || len(sells) > 0 {
+		if len(sells) == 0 || (len(buys) > 0 && !executedBefore(sells[0], buys[0])) {
+			trade := buys[0]
+			buys = buys[1:]
+			basis.buy(trade.Quantity, trade.Price)
+			pnl.TotalBought = pnl.TotalBought.Add(trade.Quantity)
+			boughtNotional = boughtNotional.Add(trade.Price.Mul(trade.Quantity))
+			continue
+		}
+
+		trade := sells[0]
+		sells = sells[1:]
+		pnl.RealizedPnL = pnl.RealizedPnL.Add(basis.sell(trade.Quantity, trade.Price))
+		pnl.TotalSold = pnl.TotalSold.Add(trade.Quantity)
+		soldNotional = soldNotional.Add(trade.Price.Mul(trade.Quantity))
+	}
+
+	if pnl.TotalBought.IsPositive() {
+		pnl.AverageBuyPrice = entity.DecimalDiv(boughtNotional, pnl.TotalBought)
+	}
+	if pnl.TotalSold.IsPositive() {
+		pnl.AverageSellPrice = entity.DecimalDiv(soldNotional, pnl.TotalSold)
+	}
+	return pnl, nil
+}
+
+// executedBefore orders trades by execution time, then by their
+// time-ordered id.
+func executedBefore(a, b *entity.Trade) bool {
+	if !a.ExecutedAt.Equal(b.ExecutedAt) {
+		return a.ExecutedAt.Before(b.ExecutedAt)
+	}
+	return bytes.Compare(a.ID[:], b.ID[:]) < 0
+}
+
+// costBasis tracks the quantity held on a pair and what it cost: as lots,
+// oldest first, for CostBasisFIFO, or as one running total for
+// CostBasisAverage.
+type costBasis struct {
+	method   CostBasisMethod
+	lots     []*costLot
+	quantity decimal.Decimal
+	cost     decimal.Decimal
+}
+
+type costLot struct {
+	quantity decimal.Decimal
+	price    decimal.Decimal
+}
+
+func (b *costBasis) buy(quantity, price decimal.Decimal) {
+	if b.method == CostBasisFIFO {
+		b.lots = append(b.lots, &costLot{quantity: quantity, price: price})
+		return
+	}
+	b.quantity = b.quantity.Add(quantity)
+	b.cost = b.cost.Add(price.Mul(quantity))
+}
+
+// sell removes up to quantity from what is held and returns the profit of
+// selling it at price.
+func (b *costBasis) sell(quantity, price decimal.Decimal) decimal.Decimal {
+	if b.method == CostBasisFIFO {
+		realized := decimal.Zero
+		for quantity.IsPositive() && len(b.lots) > 0 {
+			lot := b.lots[0]
+			taken := decimal.Min(quantity, lot.quantity)
+			realized = realized.Add(price.Sub(lot.price).Mul(taken))
+			quantity = quantity.Sub(taken)
+			lot.quantity = lot.quantity.Sub(taken)
+			if !lot.quantity.IsPositive() {
+				b.lots = b.lots[1:]
+			}
+		}
+		return realized
+	}
+
+	taken := decimal.Min(quantity, b.quantity)
+	if !taken.IsPositive() {
+		return decimal.Zero
+	}
+	// Selling everything takes the whole cost, so no rounding is left
+	// behind in it.
+	cost := b.cost
+	if taken.LessThan(b.quantity) {
+		cost = entity.DecimalDiv(b.cost.Mul(taken), b.quantity)
+	}
+	b.quantity = b.quantity.Sub(taken)
+	b.cost = b.cost.Sub(cost)
+	return price.Mul(taken).Sub(cost)
+}
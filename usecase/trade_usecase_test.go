@@ -0,0 +1,557 @@
+package usecase
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lucas-moura1/mercadobitcoin-challenge/entity"
+	"github.com/lucas-moura1/mercadobitcoin-challenge/repository"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+	"go.uber.org/zap"
+)
+
+func TestTradeUseCase_GetCandles(t *testing.T) {
+	to := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	from := to.Add(-time.Hour)
+
+	tests := []struct {
+		name      string
+		pair      string
+		interval  string
+		from      time.Time
+		to        time.Time
+		mockSetup func(tr *repository.MockTradeRepository)
+		wantErr   error
+	}{
+		{
+			name:     "delegates with parsed interval",
+			pair:     "BTC_BRL",
+			interval: "5m",
+			from:     from,
+			to:       to,
+			mockSetup: func(tr *repository.MockTradeRepository) {
+				tr.EXPECT().GetCandles("BTC_BRL", 5*time.Minute, from, to).Return([]*entity.Candle{{OpenTime: from}}, nil).Times(1)
+			},
+		},
+		{
+			name:      "unsupported interval",
+			pair:      "BTC_BRL",
+			interval:  "7m",
+			from:      from,
+			to:        to,
+			mockSetup: func(tr *repository.MockTradeRepository) {},
+			wantErr:   entity.ErrInvalidInterval,
+		},
+		{
+			name:      "invalid pair",
+			pair:      "BTCBRL",
+			interval:  "1m",
+			from:      from,
+			to:        to,
+			mockSetup: func(tr *repository.MockTradeRepository) {},
+			wantErr:   entity.ErrInvalidPairFormat,
+		},
+		{
+			name:      "from not before to",
+			pair:      "BTC_BRL",
+			interval:  "1m",
+			from:      to,
+			to:        to,
+			mockSetup: func(tr *repository.MockTradeRepository) {},
+			wantErr:   entity.ErrInvalidTimeRange,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			tradeRepo := repository.NewMockTradeRepository(ctrl)
+			tt.mockSetup(tradeRepo)
+
+			uc := NewTradeUseCase(zap.NewNop().Sugar(), tradeRepo, nil)
+			candles, err := uc.GetCandles(tt.pair, tt.interval, tt.from, tt.to)
+
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Len(t, candles, 1)
+		})
+	}
+}
+
+func TestTradeUseCase_GetCandles_BucketBoundaries(t *testing.T) {
+	log := zap.NewNop().Sugar()
+	db := newMigratedDB(t)
+	tradeRepo := repository.NewTradeRepository(log, db)
+	uc := NewTradeUseCase(log, tradeRepo, nil)
+
+	start := time.Date(2025, 1, 1, 10, 0, 0, 0, time.UTC)
+	trades := []struct {
+		pair  string
+		at    time.Time
+		price string
+		qty   string
+	}{
+		{"BTC_BRL", start, "100", "1"},
+		{"BTC_BRL", start.Add(20 * time.Second), "105", "0.5"},
+		{"BTC_BRL", start.Add(40 * time.Second), "95", "0.25"},
+		{"BTC_BRL", start.Add(59 * time.Second), "102", "1"},
+		{"BTC_BRL", start.Add(time.Minute), "110", "2"},
+		{"BTC_BRL", start.Add(3 * time.Minute), "90", "1"},
+		{"ETH_BRL", start.Add(10 * time.Second), "5", "10"},
+	}
+	for _, tr := range trades {
+		err := tradeRepo.Create(db, &entity.Trade{
+			BuyerOrderID:   uuid.New(),
+			SellerOrderID:  uuid.New(),
+			InstrumentPair: tr.pair,
+			Price:          decimal.RequireFromString(tr.price),
+			Quantity:       decimal.RequireFromString(tr.qty),
+			ExecutedAt:     tr.at,
+		})
+		assert.NoError(t, err)
+	}
+
+	candles, err := uc.GetCandles("BTC_BRL", "1m", start, start.Add(3*time.Minute))
+	assert.NoError(t, err)
+
+	if assert.Len(t, candles, 2) {
+		first := candles[0]
+		assert.Equal(t, start, first.OpenTime)
+		assert.True(t, first.Open.Equal(decimal.RequireFromString("100")), "open = %s", first.Open)
+		assert.True(t, first.High.Equal(decimal.RequireFromString("105")), "high = %s", first.High)
+		assert.True(t, first.Low.Equal(decimal.RequireFromString("95")), "low = %s", first.Low)
+		assert.True(t, first.Close.Equal(decimal.RequireFromString("102")), "close = %s", first.Close)
+		assert.True(t, first.Volume.Equal(decimal.RequireFromString("2.75")), "volume = %s", first.Volume)
+
+		second := candles[1]
+		assert.Equal(t, start.Add(time.Minute), second.OpenTime)
+		assert.True(t, second.Open.Equal(decimal.RequireFromString("110")))
+		assert.True(t, second.Close.Equal(decimal.RequireFromString("110")))
+		assert.True(t, second.Volume.Equal(decimal.RequireFromString("2")))
+	}
+}
+
+func TestTradeUseCase_GetVWAP(t *testing.T) {
+	log := zap.NewNop().Sugar()
+	db := newMigratedDB(t)
+	tradeRepo := repository.NewTradeRepository(log, db)
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	uc := NewTradeUseCase(log, tradeRepo, newFakeClock(now))
+
+	for _, tr := range []struct {
+		pair  string
+		at    time.Time
+		price string
+		qty   string
+	}{
+		{"BTC_BRL", now.Add(-50 * time.Minute), "100", "1"},
+		{"BTC_BRL", now.Add(-30 * time.Minute), "110", "3"},
+		{"BTC_BRL", now.Add(-time.Minute), "101", "0.5"},
+		{"BTC_BRL", now.Add(-2 * time.Hour), "1000", "10"},
+		{"ETH_BRL", now.Add(-10 * time.Minute), "5", "10"},
+	} {
+		err := tradeRepo.Create(db, &entity.Trade{
+			BuyerOrderID:   uuid.New(),
+			SellerOrderID:  uuid.New(),
+			InstrumentPair: tr.pair,
+			Price:          decimal.RequireFromString(tr.price),
+			Quantity:       decimal.RequireFromString(tr.qty),
+			ExecutedAt:     tr.at,
+		})
+		assert.NoError(t, err)
+	}
+
+	tests := []struct {
+		name     string
+		pair     string
+		window   time.Duration
+		wantVWAP string
+		wantErr  error
+	}{
+		// (100*1 + 110*3 + 101*0.5) / 4.5 = 480.5 / 4.5
+		{name: "trades in the last hour", pair: "BTC_BRL", window: time.Hour, wantVWAP: "106.7777777777777778"},
+		{name: "narrow window", pair: "BTC_BRL", window: 5 * time.Minute, wantVWAP: "101"},
+		{name: "no trades returns nil", pair: "SOL_BRL", window: time.Hour},
+		{name: "invalid pair", pair: "BTCBRL", window: time.Hour, wantErr: entity.ErrInvalidPairFormat},
+		{name: "non-positive window", pair: "BTC_BRL", window: 0, wantErr: entity.ErrInvalidWindow},
+		{name: "window too large", pair: "BTC_BRL", window: MaxVWAPWindow + time.Hour, wantErr: entity.ErrInvalidWindow},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := uc.GetVWAP(tt.pair, tt.window)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			if !assert.NoError(t, err) {
+				return
+			}
+			assert.Equal(t, now, got.To)
+			assert.Equal(t, now.Add(-tt.window), got.From)
+			if tt.wantVWAP == "" {
+				assert.Nil(t, got.Price)
+				return
+			}
+			if assert.NotNil(t, got.Price) {
+				assert.True(t, got.Price.Equal(decimal.RequireFromString(tt.wantVWAP)), "vwap = %s", got.Price)
+			}
+		})
+	}
+}
+
+func TestTradeUseCase_GetTicks(t *testing.T) {
+	log := zap.NewNop().Sugar()
+	db := newMigratedDB(t)
+	tradeRepo := repository.NewTradeRepository(log, db)
+	uc := NewTradeUseCase(log, tradeRepo, nil)
+	start := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	var tradeIDs []uuid.UUID
+	for i, price := range []string{"100", "100", "101", "101.00", "99", "100", "100"} {
+		trade := &entity.Trade{
+			BuyerOrderID:   uuid.New(),
+			SellerOrderID:  uuid.New(),
+			InstrumentPair: "BTC_BRL",
+			Price:          decimal.RequireFromString(price),
+			Quantity:       decimal.RequireFromString("1"),
+			ExecutedAt:     start.Add(time.Duration(i) * time.Minute),
+		}
+		assert.NoError(t, tradeRepo.Create(db, trade))
+		tradeIDs = append(tradeIDs, trade.ID)
+	}
+	assert.NoError(t, tradeRepo.Create(db, &entity.Trade{
+		BuyerOrderID:   uuid.New(),
+		SellerOrderID:  uuid.New(),
+		InstrumentPair: "ETH_BRL",
+		Price:          decimal.RequireFromString("5"),
+		Quantity:       decimal.RequireFromString("1"),
+		ExecutedAt:     start,
+	}))
+
+	prices := func(series *TickSeries) []string {
+		got := make([]string, len(series.Ticks))
+		for i, tick := range series.Ticks {
+			got[i] = tick.Price.String()
+		}
+		return got
+	}
+
+	all, err := uc.GetTicks("BTC_BRL", uuid.Nil, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"100", "101", "99", "100"}, prices(all))
+	assert.Equal(t, tradeIDs[6], all.NextCursor)
+	if assert.Len(t, all.Ticks, 4) {
+		assert.Equal(t, tradeIDs[2], all.Ticks[1].TradeID)
+		assert.True(t, start.Add(2*time.Minute).Equal(all.Ticks[1].ExecutedAt))
+	}
+
+	// Paging two ticks at a time yields the same series; runs of unchanged
+	// prices that straddle a page are not repeated.
+	first, err := uc.GetTicks("BTC_BRL", uuid.Nil, 2)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"100", "101"}, prices(first))
+	assert.Equal(t, tradeIDs[2], first.NextCursor)
+
+	second, err := uc.GetTicks("BTC_BRL", first.NextCursor, 2)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"99", "100"}, prices(second))
+
+	empty, err := uc.GetTicks("BTC_BRL", tradeIDs[6], 0)
+	assert.NoError(t, err)
+	assert.Empty(t, empty.Ticks)
+	assert.Equal(t, tradeIDs[6], empty.NextCursor)
+
+	none, err := uc.GetTicks("SOL_BRL", uuid.Nil, 0)
+	assert.NoError(t, err)
+	assert.Empty(t, none.Ticks)
+
+	_, err = uc.GetTicks("BTC_BRL", uuid.New(), 0)
+	assert.ErrorIs(t, err, entity.ErrInvalidCursor)
+
+	_, err = uc.GetTicks("ETH_BRL", tradeIDs[0], 0)
+	assert.ErrorIs(t, err, entity.ErrInvalidCursor)
+
+	_, err = uc.GetTicks("BTCBRL", uuid.Nil, 0)
+	assert.ErrorIs(t, err, entity.ErrInvalidPairFormat)
+}
+
+func TestTradeUseCase_GetTradesByAccount(t *testing.T) {
+	accountID := uuid.New()
+
+	tests := []struct {
+		name      string
+		limit     int
+		wantLimit int
+		repoErr   error
+	}{
+		{name: "default limit", limit: 0, wantLimit: DefaultTradesLimit},
+		{name: "explicit limit", limit: 10, wantLimit: 10},
+		{name: "caps limit", limit: MaxTradesLimit + 1, wantLimit: MaxTradesLimit},
+		{name: "repository error", limit: 10, wantLimit: 10, repoErr: assert.AnError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			tradeRepo := repository.NewMockTradeRepository(ctrl)
+			tradeRepo.EXPECT().
+				GetByAccountID(accountID, tt.wantLimit).
+				Return([]*entity.Trade{{ID: uuid.New()}}, tt.repoErr).
+				Times(1)
+
+			uc := NewTradeUseCase(zap.NewNop().Sugar(), tradeRepo, nil)
+			trades, err := uc.GetTradesByAccount(accountID, tt.limit)
+
+			if tt.repoErr != nil {
+				assert.ErrorIs(t, err, tt.repoErr)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Len(t, trades, 1)
+		})
+	}
+}
+
+func TestTradeUseCase_GetTradesByInstrumentPair(t *testing.T) {
+	tests := []struct {
+		name      string
+		pair      string
+		mockSetup func(tr *repository.MockTradeRepository)
+		wantErr   error
+		wantLen   int
+	}{
+		{
+			name: "returns trades for pair",
+			pair: "BTC_BRL",
+			mockSetup: func(tr *repository.MockTradeRepository) {
+				tr.EXPECT().
+					GetByInstrumentPair("BTC_BRL", DefaultTradesLimit).
+					Return([]*entity.Trade{{InstrumentPair: "BTC_BRL"}, {InstrumentPair: "BTC_BRL"}}, nil).
+					Times(1)
+			},
+			wantLen: 2,
+		},
+		{
+			name:      "invalid pair does not hit repository",
+			pair:      "BTC",
+			mockSetup: func(tr *repository.MockTradeRepository) {},
+			wantErr:   entity.ErrInvalidPairFormat,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			tradeRepo := repository.NewMockTradeRepository(ctrl)
+			tt.mockSetup(tradeRepo)
+
+			uc := NewTradeUseCase(zap.NewNop().Sugar(), tradeRepo, nil)
+			trades, err := uc.GetTradesByInstrumentPair(tt.pair, 0)
+
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Len(t, trades, tt.wantLen)
+		})
+	}
+}
+
+func TestTradeRepository_GetByAccountID(t *testing.T) {
+	log := zap.NewNop().Sugar()
+	db := newMigratedDB(t)
+	orderRepo := repository.NewOrderRepository(log, db)
+	tradeRepo := repository.NewTradeRepository(log, db)
+
+	accountID := uuid.New()
+	newOrder := func(accountID uuid.UUID) *entity.Order {
+		o := &entity.Order{AccountID: accountID, InstrumentPair: "BTC_BRL", OrderType: string(entity.OrderTypeBuy), Status: string(entity.OrderStatusFilled)}
+		assert.NoError(t, orderRepo.Create(nil, o))
+		return o
+	}
+	mine, theirs, other := newOrder(accountID), newOrder(uuid.New()), newOrder(uuid.New())
+
+	asBuyer := &entity.Trade{BuyerOrderID: mine.ID, SellerOrderID: theirs.ID, InstrumentPair: "BTC_BRL"}
+	asSeller := &entity.Trade{BuyerOrderID: theirs.ID, SellerOrderID: mine.ID, InstrumentPair: "BTC_BRL"}
+	unrelated := &entity.Trade{BuyerOrderID: theirs.ID, SellerOrderID: other.ID, InstrumentPair: "BTC_BRL"}
+	for _, tr := range []*entity.Trade{asBuyer, asSeller, unrelated} {
+		assert.NoError(t, tradeRepo.Create(db, tr))
+	}
+
+	trades, err := tradeRepo.GetByAccountID(accountID, 10)
+	assert.NoError(t, err)
+
+	ids := make([]uuid.UUID, len(trades))
+	for i, tr := range trades {
+		ids[i] = tr.ID
+	}
+	assert.ElementsMatch(t, []uuid.UUID{asBuyer.ID, asSeller.ID}, ids)
+}
+
+func TestTradeUseCase_GetPnL(t *testing.T) {
+	log := zap.NewNop().Sugar()
+	db := newMigratedDB(t)
+	orderRepo := repository.NewOrderRepository(log, db)
+	tradeRepo := repository.NewTradeRepository(log, db)
+	uc := NewTradeUseCase(log, tradeRepo, nil)
+
+	accountID, counterparty := uuid.New(), uuid.New()
+	newOrder := func(accountID uuid.UUID, pair string, orderType entity.OrderType) *entity.Order {
+		o := &entity.Order{AccountID: accountID, InstrumentPair: pair, OrderType: string(orderType), Status: string(entity.OrderStatusFilled)}
+		assert.NoError(t, orderRepo.Create(nil, o))
+		return o
+	}
+	buy, sell := newOrder(accountID, "BTC_BRL", entity.OrderTypeBuy), newOrder(accountID, "BTC_BRL", entity.OrderTypeSell)
+	theirBuy, theirSell := newOrder(counterparty, "BTC_BRL", entity.OrderTypeBuy), newOrder(counterparty, "BTC_BRL", entity.OrderTypeSell)
+	ethBuy, ethSell := newOrder(accountID, "ETH_BRL", entity.OrderTypeBuy), newOrder(counterparty, "ETH_BRL", entity.OrderTypeSell)
+
+	// Bought 1 at 100 and 1 at 200, then sold 1.5 at 300. The ETH trade is
+	// another pair and must not count.
+	start := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	for i, tr := range []*entity.Trade{
+		{BuyerOrderID: buy.ID, SellerOrderID: theirSell.ID, InstrumentPair: "BTC_BRL", Price: decimal.RequireFromString("100"), Quantity: decimal.RequireFromString("1")},
+		{BuyerOrderID: ethBuy.ID, SellerOrderID: ethSell.ID, InstrumentPair: "ETH_BRL", Price: decimal.RequireFromString("10"), Quantity: decimal.RequireFromString("5")},
+		{BuyerOrderID: buy.ID, SellerOrderID: theirSell.ID, InstrumentPair: "BTC_BRL", Price: decimal.RequireFromString("200"), Quantity: decimal.RequireFromString("1")},
+		{BuyerOrderID: theirBuy.ID, SellerOrderID: sell.ID, InstrumentPair: "BTC_BRL", Price: decimal.RequireFromString("300"), Quantity: decimal.RequireFromString("1.5")},
+	} {
+		tr.ExecutedAt = start.Add(time.Duration(i) * time.Minute)
+		assert.NoError(t, tradeRepo.Create(db, tr))
+	}
+
+	tests := []struct {
+		name       string
+		accountID  uuid.UUID
+		method     CostBasisMethod
+		wantMethod CostBasisMethod
+		bought     string
+		sold       string
+		avgBuy     string
+		avgSell    string
+		realized   string
+	}{
+		// 1.5 at the 150 average cost.
+		{name: "average cost", accountID: accountID, method: CostBasisAverage, wantMethod: CostBasisAverage, bought: "2", sold: "1.5", avgBuy: "150", avgSell: "300", realized: "225"},
+		// 1 at 100, then 0.5 at 200.
+		{name: "fifo", accountID: accountID, method: CostBasisFIFO, wantMethod: CostBasisFIFO, bought: "2", sold: "1.5", avgBuy: "150", avgSell: "300", realized: "250"},
+		{name: "average cost is the default", accountID: accountID, wantMethod: CostBasisAverage, bought: "2", sold: "1.5", avgBuy: "150", avgSell: "300", realized: "225"},
+		{name: "counterparty sees its own side", accountID: counterparty, method: CostBasisFIFO, wantMethod: CostBasisFIFO, bought: "1.5", sold: "2", avgBuy: "300", avgSell: "150", realized: "0"},
+		{name: "no trades is all zeros", accountID: uuid.New(), wantMethod: CostBasisAverage, bought: "0", sold: "0", avgBuy: "0", avgSell: "0", realized: "0"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pnl, err := uc.GetPnL(tt.accountID, "BTC_BRL", tt.method)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.wantMethod, pnl.Method)
+			assertDecimalEqual(t, tt.bought, pnl.TotalBought.String())
+			assertDecimalEqual(t, tt.sold, pnl.TotalSold.String())
+			assertDecimalEqual(t, tt.avgBuy, pnl.AverageBuyPrice.String())
+			assertDecimalEqual(t, tt.avgSell, pnl.AverageSellPrice.String())
+			assertDecimalEqual(t, tt.realized, pnl.RealizedPnL.String())
+		})
+	}
+}
+
+func TestTradeUseCase_GetPnL_Sequences(t *testing.T) {
+	at := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	trade := func(minute int, price, quantity string) *entity.Trade {
+		return &entity.Trade{
+			ID:             uuid.Must(uuid.NewV7()),
+			InstrumentPair: "BTC_BRL",
+			Price:          decimal.RequireFromString(price),
+			Quantity:       decimal.RequireFromString(quantity),
+			ExecutedAt:     at.Add(time.Duration(minute) * time.Minute),
+		}
+	}
+	selfTrade := trade(0, "100", "1")
+
+	tests := []struct {
+		name     string
+		buys     []*entity.Trade
+		sells    []*entity.Trade
+		method   CostBasisMethod
+		realized string
+	}{
+		{
+			// Only the 1 bought has a cost basis; the other 1 sold realizes
+			// nothing.
+			name:     "sell beyond the quantity held",
+			buys:     []*entity.Trade{trade(0, "100", "1")},
+			sells:    []*entity.Trade{trade(1, "150", "2")},
+			method:   CostBasisAverage,
+			realized: "50",
+		},
+		{
+			name:     "sell before the buy has no basis",
+			buys:     []*entity.Trade{trade(1, "100", "1")},
+			sells:    []*entity.Trade{trade(0, "150", "1")},
+			method:   CostBasisFIFO,
+			realized: "0",
+		},
+		{
+			// 20 on the first sell; the 40 buy brings the average of what is
+			// held down to 70, so the second realizes 50. FIFO still takes
+			// it from the 100 lot.
+			name:     "average after a partial sell",
+			buys:     []*entity.Trade{trade(0, "100", "2"), trade(2, "40", "1")},
+			sells:    []*entity.Trade{trade(1, "120", "1"), trade(3, "120", "1")},
+			method:   CostBasisAverage,
+			realized: "70",
+		},
+		{
+			name:     "fifo after a partial sell",
+			buys:     []*entity.Trade{trade(0, "100", "2"), trade(2, "40", "1")},
+			sells:    []*entity.Trade{trade(1, "120", "1"), trade(3, "120", "1")},
+			method:   CostBasisFIFO,
+			realized: "40",
+		},
+		{
+			name:     "self trade is bought before it is sold",
+			buys:     []*entity.Trade{selfTrade},
+			sells:    []*entity.Trade{selfTrade},
+			method:   CostBasisFIFO,
+			realized: "0",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			accountID := uuid.New()
+			tradeRepo := repository.NewMockTradeRepository(ctrl)
+			tradeRepo.EXPECT().GetByAccountPairSide(accountID, "BTC_BRL", string(entity.OrderTypeBuy)).Return(tt.buys, nil)
+			tradeRepo.EXPECT().GetByAccountPairSide(accountID, "BTC_BRL", string(entity.OrderTypeSell)).Return(tt.sells, nil)
+			uc := NewTradeUseCase(zap.NewNop().Sugar(), tradeRepo, nil)
+
+			pnl, err := uc.GetPnL(accountID, "BTC_BRL", tt.method)
+			assert.NoError(t, err)
+			assertDecimalEqual(t, tt.realized, pnl.RealizedPnL.String())
+		})
+	}
+
+	t.Run("invalid input is rejected", func(t *testing.T) {
+		uc := NewTradeUseCase(zap.NewNop().Sugar(), nil, nil)
+
+		_, err := uc.GetPnL(uuid.New(), "BTC_BRL", "lifo")
+		assert.ErrorIs(t, err, entity.ErrInvalidCostBasis)
+
+		_, err = uc.GetPnL(uuid.New(), "", CostBasisAverage)
+		assert.ErrorIs(t, err, entity.ErrInvalidPairFormat)
+	})
+}
@@ -0,0 +1,116 @@
+package usecasetest
+
+import (
+	"context"
+	"sort"
+	"sync"
+
+	"github.com/google/uuid"
+	"github.com/lucas-moura1/mercadobitcoin-challenge/entity"
+	"github.com/lucas-moura1/mercadobitcoin-challenge/repository"
+	"github.com/lucas-moura1/mercadobitcoin-challenge/usecase"
+	"github.com/shopspring/decimal"
+)
+
+type walletKey struct {
+	accountID uuid.UUID
+	asset     string
+}
+
+// AccountUseCase keeps wallets in memory. Wallets are created with Fund.
+type AccountUseCase struct {
+	usecase.AccountUseCase
+
+	mu      sync.Mutex
+	wallets map[walletKey]*entity.Wallet
+}
+
+func NewAccountUseCase() *AccountUseCase {
+	return &AccountUseCase{wallets: make(map[walletKey]*entity.Wallet)}
+}
+
+// Fund creates the account's wallet for asset if needed and sets its
+// balance, for tests to arrange starting balances.
+func (u *AccountUseCase) Fund(accountID uuid.UUID, asset string, balance decimal.Decimal) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	key := walletKey{accountID, asset}
+	if wallet, ok := u.wallets[key]; ok {
+		wallet.Balance = balance
+		return
+	}
+	u.wallets[key] = &entity.Wallet{
+		Base:        entity.Base{ID: uuid.New()},
+		AccountID:   accountID,
+		AssetSymbol: asset,
+		Balance:     balance,
+	}
+}
+
+func (u *AccountUseCase) GetAccountBalance(accountID uuid.UUID) ([]*entity.Wallet, error) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	var wallets []*entity.Wallet
+	for key, wallet := range u.wallets {
+		if key.accountID == accountID {
+			copied := *wallet
+			wallets = append(wallets, &copied)
+		}
+	}
+	if len(wallets) == 0 {
+		return nil, repository.ErrNotFound
+	}
+
+	sort.Slice(wallets, func(i, j int) bool {
+		return wallets[i].AssetSymbol < wallets[j].AssetSymbol
+	})
+	return wallets, nil
+}
+
+func (u *AccountUseCase) GetAssetBalance(accountID uuid.UUID, assetSymbol string) (*entity.Wallet, error) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	wallet, ok := u.wallets[walletKey{accountID, assetSymbol}]
+	if !ok {
+		return nil, repository.ErrNotFound
+	}
+	copied := *wallet
+	return &copied, nil
+}
+
+// GetReservedBalances reports nothing reserved: the OrderUseCase fake does
+// not reserve balance for the orders it rests.
+func (u *AccountUseCase) GetReservedBalances(accountID uuid.UUID) (map[string]decimal.Decimal, error) {
+	return map[string]decimal.Decimal{}, nil
+}
+
+func (u *AccountUseCase) AdjustBalance(_ context.Context, accountID uuid.UUID, assetSymbol string, amount decimal.Decimal) (*entity.Wallet, error) {
+	if amount.IsZero() {
+		return nil, entity.ErrInvalidAdjustment
+	}
+
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	key := walletKey{accountID, assetSymbol}
+	wallet, ok := u.wallets[key]
+	if !ok {
+		// The fake keeps no accounts, so any credit may open a wallet.
+		if amount.IsNegative() {
+			return nil, repository.ErrNotFound
+		}
+		wallet = &entity.Wallet{Base: entity.Base{ID: uuid.New()}, AccountID: accountID, AssetSymbol: assetSymbol}
+		u.wallets[key] = wallet
+	}
+	balance := wallet.Balance.Add(amount)
+	if balance.IsNegative() {
+		return nil, entity.ErrInsufficientBalance
+	}
+	wallet.Balance = balance
+
+	copied := *wallet
+	return &copied, nil
+}
@@ -0,0 +1,26 @@
+package usecasetest
+
+import (
+	"github.com/google/uuid"
+	"github.com/lucas-moura1/mercadobitcoin-challenge/entity"
+)
+
+// ApiKeyUseCase authenticates requests by API key alone: any registered key
+// is accepted whatever its timestamp and signature, and every other key is
+// rejected with entity.ErrInvalidSignature.
+type ApiKeyUseCase struct {
+	accounts map[string]uuid.UUID
+}
+
+// NewApiKeyUseCase registers each key as belonging to its account.
+func NewApiKeyUseCase(keys map[string]uuid.UUID) *ApiKeyUseCase {
+	return &ApiKeyUseCase{accounts: keys}
+}
+
+func (u *ApiKeyUseCase) Authenticate(key, timestamp, signature, method, path string, body []byte) (*entity.ApiKey, error) {
+	accountID, ok := u.accounts[key]
+	if !ok {
+		return nil, entity.ErrInvalidSignature
+	}
+	return &entity.ApiKey{AccountID: accountID, Key: key}, nil
+}
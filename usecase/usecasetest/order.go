@@ -0,0 +1,145 @@
+// Package usecasetest provides in-memory fakes of the usecase interfaces, so
+// the HTTP layer can be exercised end to end, routing and JSON shapes
+// included, without a database. The fakes keep state and behave like the
+// real usecases for the calls they implement; any other method panics
+// through the embedded nil interface.
+package usecasetest
+
+import (
+	"context"
+	"sort"
+	"sync"
+
+	"github.com/google/uuid"
+	"github.com/lucas-moura1/mercadobitcoin-challenge/entity"
+	"github.com/lucas-moura1/mercadobitcoin-challenge/repository"
+	"github.com/lucas-moura1/mercadobitcoin-challenge/usecase"
+	"github.com/shopspring/decimal"
+)
+
+// OrderUseCase stores orders in memory. Orders are validated and rest on the
+// book as placed: there is no matching and no balance check.
+type OrderUseCase struct {
+	usecase.OrderUseCase
+
+	clock  usecase.Clock
+	mu     sync.Mutex
+	orders map[uuid.UUID]*entity.Order
+}
+
+// NewOrderUseCase returns an empty fake. A nil clock uses
+// usecase.SystemClock.
+func NewOrderUseCase(clock usecase.Clock) *OrderUseCase {
+	if clock == nil {
+		clock = usecase.SystemClock
+	}
+	return &OrderUseCase{clock: clock, orders: make(map[uuid.UUID]*entity.Order)}
+}
+
+func (u *OrderUseCase) CreateOrder(_ context.Context, order *entity.Order) error {
+	if pair, err := entity.NormalizeInstrumentPair(order.InstrumentPair); err == nil {
+		order.InstrumentPair = pair
+	}
+	if err := order.Validate(); err != nil {
+		return err
+	}
+	if order.ExpiresAt != nil && !order.ExpiresAt.After(u.clock.Now()) {
+		return entity.ErrInvalidExpiry
+	}
+
+	now := u.clock.Now().UTC()
+	order.ID = uuid.New()
+	order.CreatedAt, order.UpdatedAt = now, now
+	order.Status = string(entity.OrderStatusOpen)
+	order.RemainingQuantity = order.Quantity
+
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	stored := *order
+	u.orders[order.ID] = &stored
+	return nil
+}
+
+func (u *OrderUseCase) CancelOrder(_ context.Context, id uuid.UUID, accountID uuid.UUID) error {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	order, ok := u.orders[id]
+	if !ok {
+		return repository.ErrNotFound
+	}
+	if accountID != uuid.Nil && order.AccountID != accountID {
+		return entity.ErrOrderNotOwned
+	}
+	switch order.Status {
+	case string(entity.OrderStatusOpen), string(entity.OrderStatusPartial):
+		order.Status = string(entity.OrderStatusCancelled)
+		order.UpdatedAt = u.clock.Now().UTC()
+		return nil
+	case string(entity.OrderStatusCancelled):
+		return nil
+	case string(entity.OrderStatusFilled):
+		return entity.ErrOrderFilled
+	default:
+		return entity.ErrOrderNotOpen
+	}
+}
+
+// GetOrderBook aggregates the open orders of the pair by price level, bids
+// best (highest) first and asks best (lowest) first, leaving out levels
+// below minQuantity.
+func (u *OrderUseCase) GetOrderBook(instrumentPair string, minQuantity decimal.Decimal) (*usecase.OrderBook, error) {
+	if !entity.IsValidInstrumentPair(instrumentPair) {
+		return nil, entity.ErrInvalidPairFormat
+	}
+	if minQuantity.IsNegative() {
+		return nil, entity.ErrInvalidMinQuantity
+	}
+
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	bids := make(map[string]*usecase.OrderBookEntry)
+	asks := make(map[string]*usecase.OrderBookEntry)
+	now := u.clock.Now()
+	for _, order := range u.orders {
+		if order.InstrumentPair != instrumentPair || order.Status != string(entity.OrderStatusOpen) {
+			continue
+		}
+		if order.ExpiresAt != nil && !order.ExpiresAt.After(now) {
+			continue
+		}
+
+		levels := asks
+		if order.OrderType == string(entity.OrderTypeBuy) {
+			levels = bids
+		}
+		key := order.Price.String()
+		if level, ok := levels[key]; ok {
+			level.Quantity = level.Quantity.Add(order.RemainingQuantity)
+			continue
+		}
+		levels[key] = &usecase.OrderBookEntry{Price: order.Price, Quantity: order.RemainingQuantity}
+	}
+
+	return &usecase.OrderBook{
+		InstrumentPair: instrumentPair,
+		Bids:           sortedLevels(bids, minQuantity, decimal.Decimal.GreaterThan),
+		Asks:           sortedLevels(asks, minQuantity, decimal.Decimal.LessThan),
+	}, nil
+}
+
+func sortedLevels(levels map[string]*usecase.OrderBookEntry, minQuantity decimal.Decimal, better func(a, b decimal.Decimal) bool) []*usecase.OrderBookEntry {
+	sorted := make([]*usecase.OrderBookEntry, 0, len(levels))
+	for _, level := range levels {
+		if level.Quantity.LessThan(minQuantity) {
+			continue
+		}
+		sorted = append(sorted, level)
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		return better(sorted[i].Price, sorted[j].Price)
+	})
+	return sorted
+}